@@ -3,17 +3,20 @@ package main
 import (
 	"chalk/internal/repo"
 	thttp "chalk/internal/transport/http"
+	"chalk/internal/transport/http/middleware"
 	"chalk/internal/usecases"
+	"chalk/pkg/billing"
+	"chalk/pkg/coderunner"
 	"chalk/pkg/config"
 	"chalk/pkg/log"
 	"chalk/pkg/mailer"
 	"chalk/pkg/migrator"
 	"context"
 	"flag"
-	"fmt"
 	"net/http"
+	"time"
 
-	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/minio/minio-go/v7"
 	"github.com/minio/minio-go/v7/pkg/credentials"
 	"github.com/redis/go-redis/v9"
@@ -31,16 +34,22 @@ func main() {
 	cfg := config.LoadConfig(*configPath)
 
 	// postgres connect
-	pcli, err := pgx.Connect(context.Background(), cfg.PostgresURI)
+	pcli, err := pgxpool.New(context.Background(), cfg.PostgresURI)
 	if err != nil {
 		log.Errorf("postgres connect: %v", err)
 		return
 	}
-	defer pcli.Close(context.Background())
+	defer pcli.Close()
 
 	// migrations
-	migrator := migrator.NewPgx(pcli, *migrationsPath)
+	mconn, err := pcli.Acquire(context.Background())
+	if err != nil {
+		log.Errorf("postgres connect: %v", err)
+		return
+	}
+	migrator := migrator.NewPgx(mconn.Conn(), *migrationsPath)
 	err = migrator.Up()
+	mconn.Release()
 	if err != nil {
 		log.Errorf("up migrations error: %v", err)
 	}
@@ -54,25 +63,45 @@ func main() {
 	rcli := redis.NewClient(opts)
 
 	// s3 connect
-	endpoint := "play.min.io"
-	accessKeyID := "Q3AM3UQ867SPQQA43P2F"
-	secretAccessKey := "zuf+tfteSlswRu7BJ86wekitnifILbZam1KYY3TG"
-	useSSL := false
-	miniocli, err := minio.New(endpoint, &minio.Options{
-		Creds:  credentials.NewStaticV4(accessKeyID, secretAccessKey, ""),
-		Secure: useSSL,
+	miniocli, err := minio.New(cfg.S3.Endpoint, &minio.Options{
+		Creds:  credentials.NewStaticV4(cfg.S3.AccessKeyID, cfg.S3.SecretAccessKey, ""),
+		Secure: cfg.S3.UseSSL,
 	})
 	if err != nil {
 		log.Errorf("minio connect: %v", err)
 		return
 	}
 
-	fmt.Println("miniocli", miniocli)
+	exists, err := miniocli.BucketExists(context.Background(), cfg.S3.Bucket)
+	if err != nil {
+		log.Errorf("check bucket: %v", err)
+		return
+	}
+	if !exists {
+		if err := miniocli.MakeBucket(context.Background(), cfg.S3.Bucket, minio.MakeBucketOptions{}); err != nil {
+			log.Errorf("make bucket: %v", err)
+			return
+		}
+	}
 
 	// repositories
 	acrepo := repo.NewAuthCodeRepo(rcli)
 	srepo := repo.NewSessionsRepo(pcli)
 	urepo := repo.NewUsersRepo(pcli)
+	arepo := repo.NewAccountsRepo(pcli)
+	aurepo := repo.NewAuditRepo(pcli)
+	frepo := repo.NewFilesRepo(pcli, miniocli, cfg.S3.Bucket)
+	crepo := repo.NewCoursesRepo(pcli)
+	brepo := repo.NewBillingRepo(pcli)
+	irepo := repo.NewInvitationsRepo(pcli)
+	clrepo := repo.NewClosureRepo(pcli)
+	ctrepo := repo.NewCourseTreeCacheRepo(rcli)
+	cvrepo := repo.NewCourseVersionsRepo(pcli)
+	rvrepo := repo.NewRevisionsRepo(pcli)
+	prrepo := repo.NewProgressRepo(pcli)
+	ssrepo := repo.NewScormSessionRepo(rcli)
+	csrepo := repo.NewCourseSourcesRepo(pcli)
+	asrepo := repo.NewAssignmentsRepo(pcli)
 
 	// mailer
 	amailer := mailer.New(
@@ -82,11 +111,50 @@ func main() {
 		cfg.Auth.Mailer.SmtpSASLPassword,
 	)
 
+	// billing provider
+	var bprovider billing.Provider
+	switch cfg.Billing.Provider {
+	case "fake":
+		bprovider, err = billing.NewFake(cfg.Billing.WebhookSecret, cfg.Billing.AllowUnsignedWebhooks)
+		if err != nil {
+			log.Errorf("billing provider: %v", err)
+			return
+		}
+	default:
+		log.Errorf("unknown billing provider: %q", cfg.Billing.Provider)
+		return
+	}
+
+	// code runner
+	var runner coderunner.Runner
+	switch cfg.Exercises.Runner {
+	case "":
+		log.Warnf("code runner is disabled, code exercise submissions are not accepted")
+	case "local":
+		runner, err = coderunner.NewLocal(coderunner.LocalConfig{
+			WorkDir:       cfg.Exercises.Local.WorkDir,
+			Prlimit:       cfg.Exercises.Local.Prlimit,
+			UID:           cfg.Exercises.Local.UID,
+			ReadOnlyPaths: cfg.Exercises.Local.ReadOnlyPaths,
+			MaxParallel:   cfg.Exercises.Local.MaxParallel,
+			MaxProcesses:  cfg.Exercises.Local.MaxProcesses,
+			Languages:     cfg.Exercises.Local.Languages,
+		})
+		if err != nil {
+			log.Errorf("local code runner: %v", err)
+			return
+		}
+	default:
+		log.Errorf("unknown code runner: %q", cfg.Exercises.Runner)
+		return
+	}
+
 	// usecases
 	auc := usecases.NewAuthUseCase(
 		acrepo,
 		urepo,
 		srepo,
+		irepo,
 		cfg.Auth.CodeTTL,
 		amailer,
 		cfg.Auth.EmailFromAddr,
@@ -95,7 +163,75 @@ func main() {
 		cfg.Auth.RefreshTokenTTL,
 	)
 
-	handler := thttp.NewHandler(auc)
+	accuc := usecases.NewAccountsUseCase(
+		arepo,
+		urepo,
+		irepo,
+		aurepo,
+		frepo,
+		brepo,
+		amailer,
+		cfg.Auth.EmailFromAddr,
+		cfg.Auth.EmailFromName,
+	)
+	fuc := usecases.NewFilesUseCase(frepo, arepo, cfg.Files.AccountQuota)
+	audituc := usecases.NewAuditUseCase(aurepo, arepo, cfg.Audit.Retention)
+	billinguc := usecases.NewBillingUseCase(brepo, arepo, bprovider)
+	trashuc := usecases.NewTrashUseCase(crepo, arepo, aurepo, ctrepo, cfg.Trash.Retention)
+	coursesuc := usecases.NewCoursesUseCase(crepo, arepo, brepo, frepo, aurepo, ctrepo, cvrepo, rvrepo, prrepo, ssrepo, urepo, csrepo,
+		asrepo, runner, cfg.Courses.TreeCacheTTL, cfg.Files.AccountQuota)
+	closureuc := usecases.NewClosureUseCase(
+		clrepo,
+		arepo,
+		urepo,
+		crepo,
+		frepo,
+		aurepo,
+		amailer,
+		cfg.Auth.EmailFromAddr,
+		cfg.Auth.EmailFromName,
+		cfg.Closure.GracePeriod,
+	)
+
+	// background jobs
+	go runPeriodically(context.Background(), time.Hour, "purge audit log", func(ctx context.Context) error {
+		removed, err := audituc.PurgeExpired(ctx)
+		if removed > 0 {
+			log.Infof("purged %d audit log entries", removed)
+		}
+		return err
+	})
+	go runPeriodically(context.Background(), time.Hour, "purge trash", func(ctx context.Context) error {
+		removed, err := trashuc.PurgeExpired(ctx)
+		if removed > 0 {
+			log.Infof("purged %d trashed course items", removed)
+		}
+		return err
+	})
+	go runPeriodically(context.Background(), time.Hour, "process account closures", closureuc.ProcessClosures)
+
+	trustedProxies, err := middleware.ParseTrustedProxies(cfg.TrustedProxies)
+	if err != nil {
+		log.Errorf("parse trusted proxies: %v", err)
+		return
+	}
+
+	handler := thttp.NewHandler(
+		thttp.TenantConfig{
+			BaseDomain: cfg.Tenant.BaseDomain,
+			PathPrefix: cfg.Tenant.PathPrefix,
+		},
+		trustedProxies,
+		auc,
+		accuc,
+		audituc,
+		fuc,
+		trashuc,
+		billinguc,
+		closureuc,
+		coursesuc,
+		cfg.Files.MaxUploadSize,
+	)
 
 	server := &http.Server{
 		Addr:    ":" + cfg.HTTPPort,
@@ -108,3 +244,18 @@ func main() {
 		return
 	}
 }
+
+func runPeriodically(ctx context.Context, interval time.Duration, name string, job func(ctx context.Context) error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := job(ctx); err != nil {
+			log.Errorf("%s: %v", name, err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
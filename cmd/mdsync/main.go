@@ -0,0 +1,102 @@
+package main
+
+import (
+	uerrors "chalk/internal/errors"
+	"chalk/internal/repo"
+	"chalk/internal/usecases"
+	"chalk/pkg/config"
+	"chalk/pkg/log"
+	"context"
+	"errors"
+	"flag"
+	"os"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+	"github.com/redis/go-redis/v9"
+)
+
+// mdsync создаёт или обновляет курс по каталогу Markdown от имени пользователя:
+//
+//	mdsync -config config.yaml -dir ./course -account 1 -user 1 [-course 42]
+func main() {
+	configPath := flag.String("config", "/etc/chalk/config/config.yaml", "path to config file")
+	dir := flag.String("dir", ".", "path to the course directory with course.yaml")
+	accountID := flag.Int64("account", 0, "account id")
+	userID := flag.Int64("user", 0, "id of the user the changes are made by, an account owner or admin")
+	courseID := flag.Int64("course", 0, "course id to sync, 0 - create a new course")
+	flag.Parse()
+
+	if *accountID == 0 || *userID == 0 {
+		log.Errorf("-account and -user are required")
+		os.Exit(2)
+	}
+
+	cfg := config.LoadConfig(*configPath)
+	ctx := context.Background()
+
+	pcli, err := pgxpool.New(ctx, cfg.PostgresURI)
+	if err != nil {
+		log.Errorf("postgres connect: %v", err)
+		os.Exit(1)
+	}
+	defer pcli.Close()
+
+	opts, err := redis.ParseURL(cfg.RedisURI)
+	if err != nil {
+		log.Errorf("parse redis uri: %v", err)
+		os.Exit(1)
+	}
+	rcli := redis.NewClient(opts)
+
+	miniocli, err := minio.New(cfg.S3.Endpoint, &minio.Options{
+		Creds:  credentials.NewStaticV4(cfg.S3.AccessKeyID, cfg.S3.SecretAccessKey, ""),
+		Secure: cfg.S3.UseSSL,
+	})
+	if err != nil {
+		log.Errorf("minio connect: %v", err)
+		os.Exit(1)
+	}
+
+	coursesuc := usecases.NewCoursesUseCase(
+		repo.NewCoursesRepo(pcli),
+		repo.NewAccountsRepo(pcli),
+		repo.NewBillingRepo(pcli),
+		repo.NewFilesRepo(pcli, miniocli, cfg.S3.Bucket),
+		repo.NewAuditRepo(pcli),
+		repo.NewCourseTreeCacheRepo(rcli),
+		repo.NewCourseVersionsRepo(pcli),
+		repo.NewRevisionsRepo(pcli),
+		repo.NewProgressRepo(pcli),
+		repo.NewScormSessionRepo(rcli),
+		repo.NewUsersRepo(pcli),
+		repo.NewCourseSourcesRepo(pcli),
+		repo.NewAssignmentsRepo(pcli),
+		nil,
+		cfg.Courses.TreeCacheTTL,
+		cfg.Files.AccountQuota,
+	)
+
+	res, err := coursesuc.SyncMarkdownCourse(ctx, usecases.SyncMarkdownCourseParams{
+		ActorID:   *userID,
+		AccountID: *accountID,
+		CourseID:  *courseID,
+		Source:    os.DirFS(*dir),
+	})
+	if err != nil {
+		if errors.Is(err, uerrors.ErrInvalidMarkdownCourse) {
+			for _, p := range res.Problems {
+				log.Errorf("%s: %s", p.Path, p.Message)
+			}
+		}
+		log.Errorf("sync markdown course: %v", err)
+		os.Exit(1)
+	}
+
+	for _, w := range res.Warnings {
+		log.Warnf("%s: %s", w.Path, w.Message)
+	}
+	log.Infof("course %d %q: %d created, %d updated, %d removed",
+		res.Course.ID, res.Course.Name, res.Created, res.Updated, res.Removed)
+}
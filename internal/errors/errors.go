@@ -29,11 +29,92 @@ var (
 
 	ErrUserNotFound = userError{31, "user not found"}
 
-	ErrPermissionDenied     = userError{41, "you don't have permission to perform this action"}
-	ErrUserAlreadyInAccount = userError{42, "user is already in the account"}
-	ErrCannotRemoveYourself = userError{43, "you can't remove yourself"}
-	ErrCannotChangeOwnRole  = userError{44, "you can't change your own role"}
-	ErrCannotModifyOwner    = userError{45, "you can't modify or remove the owner"}
+	ErrPermissionDenied      = userError{41, "you don't have permission to perform this action"}
+	ErrUserAlreadyInAccount  = userError{42, "user is already in the account"}
+	ErrCannotRemoveYourself  = userError{43, "you can't remove yourself"}
+	ErrCannotChangeOwnRole   = userError{44, "you can't change your own role"}
+	ErrCannotModifyOwner     = userError{45, "you can't modify or remove the owner"}
+	ErrInvalidRole           = userError{46, "invalid role"}
+	ErrAccountNotFound       = userError{47, "account not found"}
+	ErrAccountNameTaken      = userError{48, "account name already taken"}
+	ErrAccountMemberNotFound = userError{49, "account member not found"}
+
+	ErrInvalidAccountSlug  = userError{51, "invalid account slug: use 3-63 latin letters, digits and hyphens"}
+	ErrAccountSlugTaken    = userError{52, "account slug already taken"}
+	ErrTenantNotFound      = userError{53, "tenant not found"}
+	ErrInvalidColor        = userError{54, "invalid colour: expected #RRGGBB"}
+	ErrInvalidSupportEmail = userError{55, "invalid support email"}
+	ErrInvalidCursor       = userError{56, "invalid cursor"}
+	ErrInvalidSort         = userError{57, "invalid sort: use name or email"}
+	ErrAccountNotSelected  = userError{58, "account is not selected: set X-Account-ID header"}
+	ErrAccountMismatch     = userError{59, "X-Account-ID does not match the account in the request"}
+
+	ErrFileNotFound         = userError{61, "file not found"}
+	ErrFileTooLarge         = userError{62, "file too large"}
+	ErrInvalidFileType      = userError{63, "file type is not allowed here"}
+	ErrStorageQuotaExceeded = userError{64, "account storage quota exceeded"}
+
+	ErrTrashItemNotFound    = userError{71, "trash item not found"}
+	ErrTrashParentIsDeleted = userError{72, "parent item is in the trash, restore it first"}
+
+	ErrPlanNotFound          = userError{81, "plan not found"}
+	ErrSeatLimitReached      = userError{82, "seat limit of the plan is reached"}
+	ErrPlanLimitsExceeded    = userError{83, "current usage exceeds the limits of this plan"}
+	ErrInvalidBillingWebhook = userError{84, "invalid billing webhook"}
+
+	ErrInvalidImportFile = userError{91, "invalid csv file"}
+	ErrImportTooLarge    = userError{92, "too many rows in import file"}
+
+	ErrAccountClosing    = userError{101, "account is being closed and is read-only"}
+	ErrAccountNotClosing = userError{102, "account closure is not requested"}
+	ErrExportNotReady    = userError{103, "account export is not ready yet"}
+	ErrAccountDeleting   = userError{104, "account data is being deleted, closure can no longer be cancelled"}
+
+	ErrCourseNotFound     = userError{111, "course not found"}
+	ErrModuleNotFound     = userError{112, "module not found"}
+	ErrLessonNotFound     = userError{113, "lesson not found"}
+	ErrBlockNotFound      = userError{114, "block not found"}
+	ErrOrderConflict      = userError{115, "order was changed concurrently, reload and retry"}
+	ErrCourseLimitReached = userError{116, "course limit of the plan is reached"}
+	ErrInvalidName        = userError{117, "name must not be empty"}
+	ErrOrderMismatch      = userError{118, "order must list every item exactly once, reload and retry"}
+
+	ErrCourseNotPublished    = userError{121, "course is not published yet"}
+	ErrCourseVersionNotFound = userError{122, "course version not found"}
+	ErrNoChangesToPublish    = userError{123, "draft has no changes since the last publish"}
+
+	ErrRevisionNotFound       = userError{131, "revision not found"}
+	ErrRevisionsNotComparable = userError{132, "only text content revisions of the same block can be compared"}
+
+	ErrInvalidCourseArchive = userError{141, "invalid course archive"}
+
+	ErrInvalidScormPackage  = userError{151, "file is not a SCORM 1.2 or 2004 package"}
+	ErrScormSessionNotFound = userError{152, "scorm session not found or expired"}
+	ErrInvalidScormData     = userError{153, "invalid scorm runtime data"}
+	ErrScormDataTooLarge    = userError{154, "scorm runtime data is too large"}
+
+	ErrInvalidMarkdownCourse = userError{161, "invalid markdown course"}
+
+	ErrInvalidQuiz             = userError{171, "invalid quiz"}
+	ErrInvalidQuizAnswer       = userError{172, "invalid quiz answer"}
+	ErrQuizAttemptLimitReached = userError{173, "no quiz attempts left"}
+	ErrQuizAttemptConflict     = userError{174, "another attempt was submitted concurrently, reload and retry"}
+
+	ErrInvalidAssignment        = userError{181, "invalid assignment"}
+	ErrInvalidSubmission        = userError{182, "invalid assignment submission"}
+	ErrInvalidRubricGrade       = userError{183, "invalid rubric grade"}
+	ErrSubmissionNotFound       = userError{184, "assignment submission not found"}
+	ErrSubmissionAlreadyGraded  = userError{185, "submission is already graded, it can be resubmitted only after it is returned"}
+	ErrSubmissionStatusConflict = userError{186, "submission cannot be graded or returned in its current status"}
+
+	ErrInvalidCodeExercise   = userError{191, "invalid code exercise"}
+	ErrInvalidCodeSubmission = userError{192, "invalid code submission"}
+	ErrCodeRunnerUnavailable = userError{193, "code execution is not available for this exercise"}
+
+	ErrInvalidAltText    = userError{201, "image alt text is required and must be at most 1000 characters"}
+	ErrInvalidCaption    = userError{202, "caption must be at most 2000 characters"}
+	ErrInvalidBlockTitle = userError{203, "title must be at most 500 characters"}
+	ErrUnsupportedEmbed  = userError{204, "url is not from an allowed embed provider"}
 )
 
 // var userErrors = map[error]struct{}{
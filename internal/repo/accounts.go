@@ -5,26 +5,41 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
 )
 
 type AccountsRepo interface {
+	GetAccountByID(ctx context.Context, id int64) (models.Account, error)
+	GetAccountByName(ctx context.Context, name string) (models.Account, error)
+	GetAccountBySlug(ctx context.Context, slug string) (models.Account, error)
+	CreateAccount(ctx context.Context, params CreateAccountParams) (models.Account, error)
+	GetUserAccounts(ctx context.Context, params GetUserAccountsParams) ([]models.Account, error)
+	GetAccountMember(ctx context.Context, params GetAccountMemberParams) (models.AccountMember, error)
+	GetAccountMembers(ctx context.Context, params GetAccountMembersParams) ([]models.AccountMember, error)
+	CountAccountMembers(ctx context.Context, filter AccountMembersFilter) (int, error)
+	AddAccountMember(ctx context.Context, params AddAccountMemberParams) error
+	RemoveAccountMember(ctx context.Context, params RemoveAccountMemberParams) error
+	UpdateAccountMemberRole(ctx context.Context, params UpdateAccountMemberRoleParams) error
+	GetAccountSettings(ctx context.Context, accountID int64) (models.AccountSettings, error)
+	UpdateAccountSettings(ctx context.Context, settings models.AccountSettings) error
 }
 
-func NewAccountsRepo(db *pgx.Conn) AccountsRepo {
+func NewAccountsRepo(db DB) AccountsRepo {
 	return &accountsRepo{db: db}
 }
 
 type accountsRepo struct {
-	db *pgx.Conn
+	db DB
 }
 
 func (r *accountsRepo) GetAccountByID(ctx context.Context, id int64) (models.Account, error) {
-	const query = `SELECT id, name FROM accounts WHERE id = $1`
+	const query = `SELECT id, name, slug, closure_requested_at, deletion_scheduled_at FROM accounts WHERE id = $1`
 	acc := models.Account{}
-	err := r.db.QueryRow(ctx, query, id).Scan(&acc.ID, acc.Name)
+	err := r.db.QueryRow(ctx, query, id).Scan(&acc.ID, &acc.Name, &acc.Slug, &acc.ClosureRequestedAt, &acc.DeletionScheduledAt)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return models.Account{}, ErrAccountNotFound
@@ -35,9 +50,22 @@ func (r *accountsRepo) GetAccountByID(ctx context.Context, id int64) (models.Acc
 }
 
 func (r *accountsRepo) GetAccountByName(ctx context.Context, name string) (models.Account, error) {
-	const query = `SELECT id, name FROM accounts WHERE name = $1`
+	const query = `SELECT id, name, slug, closure_requested_at, deletion_scheduled_at FROM accounts WHERE name = $1`
 	acc := models.Account{}
-	err := r.db.QueryRow(ctx, query, name).Scan(&acc.ID, acc.Name)
+	err := r.db.QueryRow(ctx, query, name).Scan(&acc.ID, &acc.Name, &acc.Slug, &acc.ClosureRequestedAt, &acc.DeletionScheduledAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.Account{}, ErrAccountNotFound
+		}
+		return models.Account{}, fmt.Errorf("select account: %w", err)
+	}
+	return acc, nil
+}
+
+func (r *accountsRepo) GetAccountBySlug(ctx context.Context, slug string) (models.Account, error) {
+	const query = `SELECT id, name, slug, closure_requested_at, deletion_scheduled_at FROM accounts WHERE slug = $1`
+	acc := models.Account{}
+	err := r.db.QueryRow(ctx, query, slug).Scan(&acc.ID, &acc.Name, &acc.Slug, &acc.ClosureRequestedAt, &acc.DeletionScheduledAt)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return models.Account{}, ErrAccountNotFound
@@ -50,6 +78,7 @@ func (r *accountsRepo) GetAccountByName(ctx context.Context, name string) (model
 type CreateAccountParams struct {
 	OwnerUserID int64
 	AccountName string
+	AccountSlug string
 }
 
 func (r *accountsRepo) CreateAccount(ctx context.Context, params CreateAccountParams) (models.Account, error) {
@@ -60,12 +89,19 @@ func (r *accountsRepo) CreateAccount(ctx context.Context, params CreateAccountPa
 	defer tx.Rollback(ctx)
 
 	var accountID int64
-	err = tx.QueryRow(ctx, "INSERT INTO accounts (name) VALUES ($1) RETURNING id", params.AccountName).Scan(&accountID)
+	err = tx.QueryRow(ctx,
+		"INSERT INTO accounts (name, slug) VALUES ($1, $2) RETURNING id",
+		params.AccountName,
+		params.AccountSlug,
+	).Scan(&accountID)
 	if err != nil {
 		var pgErr *pgconn.PgError
 		if errors.As(err, &pgErr) {
 			switch pgErr.Code {
 			case "23505":
+				if pgErr.ConstraintName == "accounts_slug_key" {
+					return models.Account{}, ErrAccountSlugAlreadyTaken
+				}
 				return models.Account{}, ErrAccountNameAlreadyTaken
 			}
 		}
@@ -93,30 +129,83 @@ func (r *accountsRepo) CreateAccount(ctx context.Context, params CreateAccountPa
 		return models.Account{}, fmt.Errorf("commit: %w", err)
 	}
 
-	return models.Account{ID: accountID, Name: params.AccountName}, nil
+	return models.Account{ID: accountID, Name: params.AccountName, Slug: params.AccountSlug}, nil
 }
 
-type GetAccountMembersParams struct {
+type MembersSortField string
+
+const (
+	MembersSortByName  MembersSortField = "name"
+	MembersSortByEmail MembersSortField = "email"
+)
+
+// membersSortColumns - выражения сортировки, совпадают с выражениями индексов на users.
+// Побайтовое сравнение "C" позволяет одному индексу обслуживать и сортировку, и LIKE по префиксу.
+var membersSortColumns = map[MembersSortField]string{
+	MembersSortByName:  `lower(u.name) COLLATE "C"`,
+	MembersSortByEmail: `lower(u.email) COLLATE "C"`,
+}
+
+// MembersCursor - позиция последней строки предыдущей страницы
+type MembersCursor struct {
+	Key    string
+	UserID int64
+}
+
+type AccountMembersFilter struct {
 	AccountID int64
+	// Search - префикс имени или email без учёта регистра
+	Search *string
+	Role   *models.AccountMembersRole
+	// Group - имя группы аккаунта
+	Group *string
 }
 
+type GetAccountMembersParams struct {
+	AccountMembersFilter
+	Sort  MembersSortField
+	Desc  bool
+	After *MembersCursor
+	Limit int
+}
+
+// GetAccountMembers возвращает страницу участников аккаунта, упорядоченную по Sort и user_id
 func (r *accountsRepo) GetAccountMembers(ctx context.Context, params GetAccountMembersParams) ([]models.AccountMember, error) {
+	sortColumn, ok := membersSortColumns[params.Sort]
+	if !ok {
+		return nil, fmt.Errorf("unknown sort field %q", params.Sort)
+	}
+	direction, cmp := "ASC", ">"
+	if params.Desc {
+		direction, cmp = "DESC", "<"
+	}
 
-	_, err := r.GetAccountByID(ctx, params.AccountID)
-	if err != nil {
-		if errors.Is(err, ErrAccountNotFound) {
-			return nil, err
-		}
-		return nil, fmt.Errorf("check acc exists: %w", err)
+	var afterKey *string
+	var afterID *int64
+	if params.After != nil {
+		afterKey, afterID = &params.After.Key, &params.After.UserID
 	}
 
-	const query = `
-	SELECT am.id, am.user_id, am.account_id, am.role, u.name, u.email
-	FROM account_members am
-	INNER JOIN users u ON u.id = am.user_id
-	WHERE am.account_id = $1`
+	query := fmt.Sprintf(`
+		SELECT am.user_id, am.account_id, am.role, u.name, u.email
+		FROM account_members am
+		INNER JOIN users u ON u.id = am.user_id
+		WHERE %s
+		  AND ($5::TEXT IS NULL OR (%s, am.user_id) %s ($5, $6::BIGINT))
+		ORDER BY %s %s, am.user_id %s
+		LIMIT $7`,
+		accountMembersFilterSQL, sortColumn, cmp, sortColumn, direction, direction,
+	)
 
-	rows, err := r.db.Query(ctx, query, params.AccountID)
+	rows, err := r.db.Query(ctx, query,
+		params.AccountID,
+		searchPattern(params.Search),
+		params.Role,
+		params.Group,
+		afterKey,
+		afterID,
+		params.Limit,
+	)
 	if err != nil {
 		return nil, fmt.Errorf("query account members: %w", err)
 	}
@@ -145,6 +234,75 @@ func (r *accountsRepo) GetAccountMembers(ctx context.Context, params GetAccountM
 	return members, nil
 }
 
+func (r *accountsRepo) CountAccountMembers(ctx context.Context, filter AccountMembersFilter) (int, error) {
+	query := `
+		SELECT COUNT(*)
+		FROM account_members am
+		INNER JOIN users u ON u.id = am.user_id
+		WHERE ` + accountMembersFilterSQL
+
+	var total int
+	err := r.db.QueryRow(ctx, query,
+		filter.AccountID,
+		searchPattern(filter.Search),
+		filter.Role,
+		filter.Group,
+	).Scan(&total)
+	if err != nil {
+		return 0, fmt.Errorf("count account members: %w", err)
+	}
+	return total, nil
+}
+
+// accountMembersFilterSQL - условия AccountMembersFilter, параметры $1-$4
+const accountMembersFilterSQL = `am.account_id = $1
+		  AND ($2::TEXT IS NULL OR lower(u.name) COLLATE "C" LIKE $2 OR lower(u.email) COLLATE "C" LIKE $2)
+		  AND ($3::TEXT IS NULL OR am.role = $3)
+		  AND ($4::TEXT IS NULL OR EXISTS (
+			SELECT 1 FROM account_group_members gm
+			INNER JOIN account_groups g ON g.id = gm.group_id
+			WHERE g.account_id = am.account_id AND g.name = $4 AND gm.user_id = am.user_id
+		  ))`
+
+// searchPattern превращает строку поиска в LIKE-шаблон префикса
+func searchPattern(search *string) *string {
+	if search == nil {
+		return nil
+	}
+	escaped := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(strings.ToLower(*search))
+	pattern := escaped + "%"
+	return &pattern
+}
+
+type GetAccountMemberParams struct {
+	AccountID int64
+	UserID    int64
+}
+
+func (r *accountsRepo) GetAccountMember(ctx context.Context, params GetAccountMemberParams) (models.AccountMember, error) {
+	const query = `
+	SELECT am.user_id, am.account_id, am.role, u.name, u.email
+	FROM account_members am
+	INNER JOIN users u ON u.id = am.user_id
+	WHERE am.account_id = $1 AND am.user_id = $2`
+
+	var m models.AccountMember
+	err := r.db.QueryRow(ctx, query, params.AccountID, params.UserID).Scan(
+		&m.UserID,
+		&m.AccountID,
+		&m.Role,
+		&m.Name,
+		&m.Email,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.AccountMember{}, ErrAccountMemberNotFound
+		}
+		return models.AccountMember{}, fmt.Errorf("select account member: %w", err)
+	}
+	return m, nil
+}
+
 type GetUserAccountsParams struct {
 	UserID int64
 }
@@ -161,7 +319,7 @@ func (r *accountsRepo) GetUserAccounts(ctx context.Context, params GetUserAccoun
 	}
 
 	const query = `
-	SELECT a.id, a.name
+	SELECT a.id, a.name, a.slug, a.closure_requested_at, a.deletion_scheduled_at
 	FROM accounts a
 	INNER JOIN account_members am ON a.id = am.account_id
 	WHERE am.user_id = $1
@@ -178,6 +336,9 @@ func (r *accountsRepo) GetUserAccounts(ctx context.Context, params GetUserAccoun
 		err := rows.Scan(
 			&m.ID,
 			&m.Name,
+			&m.Slug,
+			&m.ClosureRequestedAt,
+			&m.DeletionScheduledAt,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("scan user account: %w", err)
@@ -196,27 +357,49 @@ type AddAccountMemberParams struct {
 	UserID    int64
 	AccountID int64
 	Role      models.AccountMembersRole
+	// Groups - группы участника, отсутствующие группы создаются
+	Groups []string
 }
 
+// AddAccountMember добавляет участника вместе с группами, если в тарифе аккаунта
+// есть свободное место, иначе возвращает ErrSeatLimitReached
 func (r *accountsRepo) AddAccountMember(ctx context.Context, params AddAccountMemberParams) error {
-	const query = `INSERT INTO account_members (user_id, account_id, role) VALUES ($1, $2, $3)`
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := reserveSeats(ctx, tx, params.AccountID, 1); err != nil {
+		return err
+	}
 
-	_, err := r.db.Exec(ctx, query, params.UserID, params.AccountID, params.Role)
+	const query = `INSERT INTO account_members (user_id, account_id, role) VALUES ($1, $2, $3)`
+	_, err = tx.Exec(ctx, query, params.UserID, params.AccountID, params.Role)
 	if err != nil {
 		var pgErr *pgconn.PgError
 		if errors.As(err, &pgErr) {
 			switch pgErr.Code {
 			case "23503":
-				if pgErr.ColumnName == "user_id" {
-					err = ErrUserNotFound
-				} else if pgErr.ColumnName == "account_id" {
-					err = ErrAccountNotFound
+				switch pgErr.ConstraintName {
+				case "fk_account_members__user_id":
+					return ErrUserNotFound
+				case "fk_account_members__account_id":
+					return ErrAccountNotFound
 				}
-				return err
+			case "23505":
+				return ErrUniqueViolation
 			}
 		}
 		return fmt.Errorf("insert account_members: %w", err)
 	}
+	if err := addUserToGroups(ctx, tx, params.AccountID, params.UserID, params.Groups); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit tx: %w", err)
+	}
 	return nil
 }
 
@@ -254,3 +437,80 @@ func (r *accountsRepo) UpdateAccountMemberRole(ctx context.Context, params Updat
 	}
 	return nil
 }
+
+// GetAccountSettings возвращает настройки аккаунта. Если настройки ещё не
+// сохранялись, возвращаются пустые значения.
+func (r *accountsRepo) GetAccountSettings(ctx context.Context, accountID int64) (models.AccountSettings, error) {
+	const query = `
+	SELECT a.id,
+	       COALESCE(s.display_name, ''),
+	       s.logo_file_id,
+	       s.favicon_file_id,
+	       COALESCE(s.primary_color, ''),
+	       COALESCE(s.accent_color, ''),
+	       COALESCE(s.support_email, ''),
+	       COALESCE(s.email_sender_name, '')
+	FROM accounts a
+	LEFT JOIN account_settings s ON s.account_id = a.id
+	WHERE a.id = $1`
+
+	var st models.AccountSettings
+	err := r.db.QueryRow(ctx, query, accountID).Scan(
+		&st.AccountID,
+		&st.DisplayName,
+		&st.LogoFileID,
+		&st.FaviconFileID,
+		&st.PrimaryColor,
+		&st.AccentColor,
+		&st.SupportEmail,
+		&st.EmailSenderName,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.AccountSettings{}, ErrAccountNotFound
+		}
+		return models.AccountSettings{}, fmt.Errorf("select account settings: %w", err)
+	}
+	return st, nil
+}
+
+func (r *accountsRepo) UpdateAccountSettings(ctx context.Context, settings models.AccountSettings) error {
+	const query = `
+	INSERT INTO account_settings
+	(account_id, display_name, logo_file_id, favicon_file_id, primary_color, accent_color, support_email, email_sender_name, updated_at)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	ON CONFLICT (account_id) DO UPDATE SET
+		display_name = EXCLUDED.display_name,
+		logo_file_id = EXCLUDED.logo_file_id,
+		favicon_file_id = EXCLUDED.favicon_file_id,
+		primary_color = EXCLUDED.primary_color,
+		accent_color = EXCLUDED.accent_color,
+		support_email = EXCLUDED.support_email,
+		email_sender_name = EXCLUDED.email_sender_name,
+		updated_at = EXCLUDED.updated_at`
+
+	_, err := r.db.Exec(ctx, query,
+		settings.AccountID,
+		settings.DisplayName,
+		settings.LogoFileID,
+		settings.FaviconFileID,
+		settings.PrimaryColor,
+		settings.AccentColor,
+		settings.SupportEmail,
+		settings.EmailSenderName,
+		time.Now().UTC(),
+	)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23503" {
+			switch pgErr.ConstraintName {
+			case "fk_account_settings__account_id":
+				return ErrAccountNotFound
+			case "fk_account_settings__logo_file_id", "fk_account_settings__favicon_file_id":
+				return ErrFileNotFound
+			}
+		}
+		return fmt.Errorf("upsert account settings: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,325 @@
+package repo
+
+import (
+	"chalk/internal/repo/models"
+	"chalk/pkg/assignment"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+type AssignmentsRepo interface {
+	// SubmitAssignment сохраняет сдачу работы. Оценённую работу нельзя сдать снова,
+	// пока её не вернули на доработку, тогда возвращается ErrSubmissionStatus.
+	SubmitAssignment(ctx context.Context, params SubmitAssignmentParams) (models.Submission, error)
+	GradeSubmission(ctx context.Context, params GradeSubmissionParams) (models.Submission, error)
+	ReturnSubmission(ctx context.Context, params ReturnSubmissionParams) (models.Submission, error)
+	// GetSubmission возвращает работу вместе с историей
+	GetSubmission(ctx context.Context, submissionID int64) (models.Submission, error)
+	// GetUserSubmission возвращает работу участника по заданию вместе с историей
+	GetUserSubmission(ctx context.Context, blockID, userID int64) (models.Submission, error)
+	// GetSubmissionQueue возвращает работы неудалённых заданий курса, давно сданные первыми
+	GetSubmissionQueue(ctx context.Context, params GetSubmissionQueueParams) ([]models.Submission, error)
+}
+
+func NewAssignmentsRepo(db DB) AssignmentsRepo {
+	return &assignmentsRepo{db: db}
+}
+
+type assignmentsRepo struct {
+	db DB
+}
+
+const submissionColumns = `s.id, s.block_id, s.user_id, s.status, s.score, s.submitted_at, s.updated_at`
+
+// scanSubmission читает submissionColumns, extra - следующие за ними колонки
+func scanSubmission(row pgx.Row, extra ...any) (models.Submission, error) {
+	var s models.Submission
+	dest := append([]any{&s.ID, &s.BlockID, &s.UserID, &s.Status, &s.Score, &s.SubmittedAt, &s.UpdatedAt}, extra...)
+	err := row.Scan(dest...)
+	return s, err
+}
+
+type SubmitAssignmentParams struct {
+	BlockID int64
+	UserID  int64
+	// Assignment - задание, которое видит участник, по нему работа будет оценена
+	Assignment assignment.Assignment
+	Text       string
+	FileIDs    []int64
+}
+
+func (r *assignmentsRepo) SubmitAssignment(ctx context.Context, params SubmitAssignmentParams) (models.Submission, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return models.Submission{}, fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	now := time.Now().UTC()
+	// повторная сдача сбрасывает результат прошлой оценки, он остаётся в истории
+	const upsertQuery = `
+		INSERT INTO assignment_submissions AS s (block_id, user_id, status, assignment, submitted_at, updated_at)
+		VALUES ($1, $2, $3, $6, $4, $4)
+		ON CONFLICT (block_id, user_id) DO UPDATE
+		SET status = EXCLUDED.status, score = NULL, assignment = EXCLUDED.assignment,
+			submitted_at = EXCLUDED.submitted_at, updated_at = EXCLUDED.updated_at
+		WHERE s.status <> $5
+		RETURNING ` + submissionColumns
+	s, err := scanSubmission(tx.QueryRow(ctx, upsertQuery,
+		params.BlockID, params.UserID, models.SubmissionSubmitted, now, models.SubmissionGraded, params.Assignment,
+	))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.Submission{}, ErrSubmissionStatus
+		}
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) {
+			switch pgErr.ConstraintName {
+			case "fk_assignment_submissions__block_id":
+				return models.Submission{}, ErrBlockNotFound
+			case "fk_assignment_submissions__user_id":
+				return models.Submission{}, ErrUserNotFound
+			}
+		}
+		return models.Submission{}, fmt.Errorf("upsert submission: %w", err)
+	}
+
+	eventID, err := insertSubmissionEvent(ctx, tx, s.ID, models.SubmissionEvent{
+		Action:    models.SubmissionSubmitted,
+		ActorID:   &params.UserID,
+		Text:      params.Text,
+		CreatedAt: now,
+	})
+	if err != nil {
+		return models.Submission{}, err
+	}
+	const filesQuery = `
+		INSERT INTO assignment_submission_files (event_id, file_id, position)
+		SELECT $1, f.id, f.position FROM unnest($2::BIGINT[]) WITH ORDINALITY AS f (id, position)
+	`
+	if _, err := tx.Exec(ctx, filesQuery, eventID, params.FileIDs); err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) {
+			switch pgErr.Code {
+			case "23503":
+				return models.Submission{}, ErrFileNotFound
+			case "23505":
+				return models.Submission{}, ErrDuplicateSubmissionFile
+			}
+		}
+		return models.Submission{}, fmt.Errorf("insert submission files: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return models.Submission{}, fmt.Errorf("commit tx: %w", err)
+	}
+	return s, nil
+}
+
+type GradeSubmissionParams struct {
+	SubmissionID int64
+	GraderID     int64
+	Grades       []assignment.Grade
+	Score        float64
+	Comment      string
+}
+
+// GradeSubmission оценивает сданную работу. Оценённую работу можно оценить заново.
+func (r *assignmentsRepo) GradeSubmission(ctx context.Context, params GradeSubmissionParams) (models.Submission, error) {
+	return r.changeSubmissionStatus(ctx, params.SubmissionID, []models.SubmissionStatus{models.SubmissionSubmitted, models.SubmissionGraded}, models.SubmissionEvent{
+		Action:  models.SubmissionGraded,
+		ActorID: &params.GraderID,
+		Grades:  params.Grades,
+		Score:   &params.Score,
+		Comment: params.Comment,
+	})
+}
+
+type ReturnSubmissionParams struct {
+	SubmissionID int64
+	ActorID      int64
+	Comment      string
+}
+
+// ReturnSubmission возвращает сданную или оценённую работу на доработку
+func (r *assignmentsRepo) ReturnSubmission(ctx context.Context, params ReturnSubmissionParams) (models.Submission, error) {
+	return r.changeSubmissionStatus(ctx, params.SubmissionID, []models.SubmissionStatus{models.SubmissionSubmitted, models.SubmissionGraded}, models.SubmissionEvent{
+		Action:  models.SubmissionReturned,
+		ActorID: &params.ActorID,
+		Comment: params.Comment,
+	})
+}
+
+// changeSubmissionStatus переводит работу из одного из статусов from в статус события
+// и пишет событие в историю. Score события заменяет результат работы.
+func (r *assignmentsRepo) changeSubmissionStatus(ctx context.Context, submissionID int64, from []models.SubmissionStatus, event models.SubmissionEvent) (models.Submission, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return models.Submission{}, fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	event.CreatedAt = time.Now().UTC()
+	const updateQuery = `
+		UPDATE assignment_submissions AS s
+		SET status = $2, score = COALESCE($3, s.score), updated_at = $4
+		WHERE s.id = $1 AND s.status = ANY($5)
+		RETURNING ` + submissionColumns
+	s, err := scanSubmission(tx.QueryRow(ctx, updateQuery, submissionID, event.Action, event.Score, event.CreatedAt, from))
+	if err != nil {
+		if !errors.Is(err, pgx.ErrNoRows) {
+			return models.Submission{}, fmt.Errorf("update submission: %w", err)
+		}
+		const existsQuery = `SELECT EXISTS (SELECT 1 FROM assignment_submissions WHERE id = $1)`
+		var exists bool
+		if err := tx.QueryRow(ctx, existsQuery, submissionID).Scan(&exists); err != nil {
+			return models.Submission{}, fmt.Errorf("check submission: %w", err)
+		}
+		if !exists {
+			return models.Submission{}, ErrSubmissionNotFound
+		}
+		return models.Submission{}, ErrSubmissionStatus
+	}
+
+	if _, err := insertSubmissionEvent(ctx, tx, s.ID, event); err != nil {
+		return models.Submission{}, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return models.Submission{}, fmt.Errorf("commit tx: %w", err)
+	}
+	return s, nil
+}
+
+func insertSubmissionEvent(ctx context.Context, tx pgx.Tx, submissionID int64, e models.SubmissionEvent) (int64, error) {
+	const query = `
+		INSERT INTO assignment_submission_events (submission_id, action, actor_id, text, grades, score, comment, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id
+	`
+	var id int64
+	err := tx.QueryRow(ctx, query, submissionID, e.Action, e.ActorID, e.Text, e.Grades, e.Score, e.Comment, e.CreatedAt).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("insert submission event: %w", err)
+	}
+	return id, nil
+}
+
+func (r *assignmentsRepo) GetSubmission(ctx context.Context, submissionID int64) (models.Submission, error) {
+	const query = `SELECT ` + submissionColumns + `, s.assignment FROM assignment_submissions s WHERE s.id = $1`
+	return r.getSubmission(ctx, query, submissionID)
+}
+
+func (r *assignmentsRepo) GetUserSubmission(ctx context.Context, blockID, userID int64) (models.Submission, error) {
+	const query = `SELECT ` + submissionColumns + `, s.assignment FROM assignment_submissions s WHERE s.block_id = $1 AND s.user_id = $2`
+	return r.getSubmission(ctx, query, blockID, userID)
+}
+
+// getSubmission читает работу запросом, выбирающим submissionColumns и задание, и её историю
+func (r *assignmentsRepo) getSubmission(ctx context.Context, query string, args ...any) (models.Submission, error) {
+	var a assignment.Assignment
+	s, err := scanSubmission(r.db.QueryRow(ctx, query, args...), &a)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.Submission{}, ErrSubmissionNotFound
+		}
+		return models.Submission{}, fmt.Errorf("select submission: %w", err)
+	}
+	s.Assignment = &a
+
+	const eventsQuery = `
+		SELECT id, action, actor_id, text, grades, score, comment, created_at
+		FROM assignment_submission_events
+		WHERE submission_id = $1
+		ORDER BY id
+	`
+	rows, err := r.db.Query(ctx, eventsQuery, s.ID)
+	if err != nil {
+		return models.Submission{}, fmt.Errorf("query submission events: %w", err)
+	}
+	eventIdx := make(map[int64]int)
+	for rows.Next() {
+		var e models.SubmissionEvent
+		if err := rows.Scan(&e.ID, &e.Action, &e.ActorID, &e.Text, &e.Grades, &e.Score, &e.Comment, &e.CreatedAt); err != nil {
+			rows.Close()
+			return models.Submission{}, fmt.Errorf("scan submission event: %w", err)
+		}
+		eventIdx[e.ID] = len(s.History)
+		s.History = append(s.History, e)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return models.Submission{}, fmt.Errorf("rows error: %w", err)
+	}
+
+	const filesQuery = `
+		SELECT sf.event_id, f.id, f.uploader_user_id, f.account_id, f.name, f.content_type, f.bucket, f.key, f.uploaded_at, f.size
+		FROM assignment_submission_files sf
+		INNER JOIN assignment_submission_events e ON e.id = sf.event_id
+		INNER JOIN files f ON f.id = sf.file_id
+		WHERE e.submission_id = $1
+		ORDER BY sf.event_id, sf.position
+	`
+	rows, err = r.db.Query(ctx, filesQuery, s.ID)
+	if err != nil {
+		return models.Submission{}, fmt.Errorf("query submission files: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var eventID int64
+		var f models.File
+		if err := rows.Scan(&eventID, &f.ID, &f.UploaderUserID, &f.AccountID, &f.Name, &f.ContentType, &f.Bucket, &f.Key, &f.UploadedAt, &f.Size); err != nil {
+			return models.Submission{}, fmt.Errorf("scan submission file: %w", err)
+		}
+		if i, ok := eventIdx[eventID]; ok {
+			s.History[i].Files = append(s.History[i].Files, f)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return models.Submission{}, fmt.Errorf("rows error: %w", err)
+	}
+	return s, nil
+}
+
+type GetSubmissionQueueParams struct {
+	CourseID int64
+	// Status и BlockID - необязательные фильтры
+	Status  *models.SubmissionStatus
+	BlockID *int64
+}
+
+func (r *assignmentsRepo) GetSubmissionQueue(ctx context.Context, params GetSubmissionQueueParams) ([]models.Submission, error) {
+	const query = `
+		SELECT ` + submissionColumns + `
+		FROM assignment_submissions s
+		INNER JOIN blocks b ON b.id = s.block_id
+		INNER JOIN lessons l ON l.id = b.lesson_id
+		INNER JOIN modules m ON m.id = l.module_id
+		WHERE m.course_id = $1 AND m.deleted_at IS NULL AND l.deleted_at IS NULL AND b.deleted_at IS NULL
+			AND ($2::TEXT IS NULL OR s.status = $2::TEXT)
+			AND ($3::BIGINT IS NULL OR s.block_id = $3::BIGINT)
+		ORDER BY s.submitted_at, s.id
+	`
+	rows, err := r.db.Query(ctx, query, params.CourseID, params.Status, params.BlockID)
+	if err != nil {
+		return nil, fmt.Errorf("query submissions: %w", err)
+	}
+	defer rows.Close()
+
+	var submissions []models.Submission
+	for rows.Next() {
+		s, err := scanSubmission(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan submission: %w", err)
+		}
+		submissions = append(submissions, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+	return submissions, nil
+}
@@ -0,0 +1,155 @@
+package repo
+
+import (
+	"chalk/internal/repo/models"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+type AuditRepo interface {
+	CreateEntry(ctx context.Context, params CreateAuditEntryParams) (int64, error)
+	GetEntries(ctx context.Context, params GetAuditEntriesParams) ([]models.AuditEntry, error)
+	RemoveEntriesBefore(ctx context.Context, before time.Time) (int64, error)
+}
+
+func NewAuditRepo(db DB) AuditRepo {
+	return &auditRepo{db: db}
+}
+
+type auditRepo struct {
+	db DB
+}
+
+type CreateAuditEntryParams struct {
+	AccountID   int64
+	ActorUserID int64
+	Action      models.AuditAction
+	TargetType  models.AuditTargetType
+	TargetID    *int64
+	Before      json.RawMessage
+	After       json.RawMessage
+	IP          string
+	CreatedAt   time.Time
+}
+
+func (r *auditRepo) CreateEntry(ctx context.Context, params CreateAuditEntryParams) (int64, error) {
+	const query = `
+		INSERT INTO audit_log (account_id, actor_user_id, action, target_type, target_id, before, after, ip, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING id
+	`
+	var id int64
+	err := r.db.QueryRow(ctx, query,
+		params.AccountID,
+		params.ActorUserID,
+		params.Action,
+		params.TargetType,
+		params.TargetID,
+		params.Before,
+		params.After,
+		params.IP,
+		params.CreatedAt.UTC(),
+	).Scan(&id)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23503" {
+			return 0, ErrAccountNotFound
+		}
+		return 0, fmt.Errorf("insert audit entry: %w", err)
+	}
+	return id, nil
+}
+
+type GetAuditEntriesParams struct {
+	AccountID   int64
+	ActorUserID *int64
+	Action      *models.AuditAction
+	TargetType  *models.AuditTargetType
+	TargetID    *int64
+	From        *time.Time
+	To          *time.Time
+	// BeforeID - курсор: возвращаются записи с id меньше указанного
+	BeforeID *int64
+	Limit    int
+}
+
+// GetEntries возвращает записи журнала аккаунта от новых к старым
+func (r *auditRepo) GetEntries(ctx context.Context, params GetAuditEntriesParams) ([]models.AuditEntry, error) {
+	const query = `
+		SELECT id, account_id, actor_user_id, action, target_type, target_id, before, after, ip, created_at
+		FROM audit_log
+		WHERE account_id = $1
+		  AND ($2::BIGINT IS NULL OR actor_user_id = $2)
+		  AND ($3::TEXT IS NULL OR action = $3)
+		  AND ($4::TEXT IS NULL OR target_type = $4)
+		  AND ($5::BIGINT IS NULL OR target_id = $5)
+		  AND ($6::TIMESTAMP IS NULL OR created_at >= $6)
+		  AND ($7::TIMESTAMP IS NULL OR created_at < $7)
+		  AND ($8::BIGINT IS NULL OR id < $8)
+		ORDER BY id DESC
+		LIMIT $9
+	`
+	rows, err := r.db.Query(ctx, query,
+		params.AccountID,
+		params.ActorUserID,
+		params.Action,
+		params.TargetType,
+		params.TargetID,
+		utcOrNil(params.From),
+		utcOrNil(params.To),
+		params.BeforeID,
+		params.Limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("query audit entries: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []models.AuditEntry
+	for rows.Next() {
+		var e models.AuditEntry
+		err := rows.Scan(
+			&e.ID,
+			&e.AccountID,
+			&e.ActorUserID,
+			&e.Action,
+			&e.TargetType,
+			&e.TargetID,
+			&e.Before,
+			&e.After,
+			&e.IP,
+			&e.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("scan audit entry: %w", err)
+		}
+		entries = append(entries, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+	return entries, nil
+}
+
+// RemoveEntriesBefore удаляет записи старше before, используется для очистки по сроку хранения
+func (r *auditRepo) RemoveEntriesBefore(ctx context.Context, before time.Time) (int64, error) {
+	const query = `DELETE FROM audit_log WHERE created_at < $1`
+	cmdTag, err := r.db.Exec(ctx, query, before.UTC())
+	if err != nil {
+		return 0, fmt.Errorf("delete audit entries: %w", err)
+	}
+	return cmdTag.RowsAffected(), nil
+}
+
+func utcOrNil(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	utc := t.UTC()
+	return &utc
+}
@@ -0,0 +1,262 @@
+package repo
+
+import (
+	"chalk/internal/repo/models"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+type BillingRepo interface {
+	GetPlans(ctx context.Context) ([]models.Plan, error)
+	GetPlan(ctx context.Context, planID string) (models.Plan, error)
+	GetSubscription(ctx context.Context, accountID int64) (models.Subscription, error)
+	CountAccountSeats(ctx context.Context, accountID int64) (int, error)
+	CountAccountCourses(ctx context.Context, accountID int64) (int, error)
+	ApplyBillingEvent(ctx context.Context, params ApplyBillingEventParams) (bool, error)
+}
+
+func NewBillingRepo(db DB) BillingRepo {
+	return &billingRepo{db: db}
+}
+
+type billingRepo struct {
+	db DB
+}
+
+/* ===================== Plans ===================== */
+
+func (r *billingRepo) GetPlans(ctx context.Context) ([]models.Plan, error) {
+	const query = `SELECT id, name, max_seats, max_courses, storage_bytes FROM plans ORDER BY max_seats NULLS LAST, id`
+	rows, err := r.db.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("query plans: %w", err)
+	}
+	defer rows.Close()
+
+	var plans []models.Plan
+	for rows.Next() {
+		var p models.Plan
+		if err := rows.Scan(&p.ID, &p.Name, &p.MaxSeats, &p.MaxCourses, &p.StorageBytes); err != nil {
+			return nil, fmt.Errorf("scan plan: %w", err)
+		}
+		plans = append(plans, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+	return plans, nil
+}
+
+func (r *billingRepo) GetPlan(ctx context.Context, planID string) (models.Plan, error) {
+	const query = `SELECT id, name, max_seats, max_courses, storage_bytes FROM plans WHERE id = $1`
+	var p models.Plan
+	err := r.db.QueryRow(ctx, query, planID).Scan(&p.ID, &p.Name, &p.MaxSeats, &p.MaxCourses, &p.StorageBytes)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.Plan{}, ErrPlanNotFound
+		}
+		return models.Plan{}, fmt.Errorf("select plan: %w", err)
+	}
+	return p, nil
+}
+
+/* ===================== Subscriptions ===================== */
+
+// GetSubscription возвращает подписку аккаунта, аккаунт без подписки работает на тарифе по умолчанию
+func (r *billingRepo) GetSubscription(ctx context.Context, accountID int64) (models.Subscription, error) {
+	const query = `
+		SELECT a.id, p.id, p.name, p.max_seats, p.max_courses, p.storage_bytes,
+			COALESCE(s.status, $2), COALESCE(s.provider, ''), s.provider_subscription_id, s.current_period_end
+		FROM accounts a
+		LEFT JOIN subscriptions s ON s.account_id = a.id
+		INNER JOIN plans p ON p.id = COALESCE(s.plan_id, $3)
+		WHERE a.id = $1
+	`
+	var s models.Subscription
+	err := r.db.QueryRow(ctx, query, accountID, models.SubscriptionStatusActive, models.DefaultPlanID).Scan(
+		&s.AccountID,
+		&s.Plan.ID,
+		&s.Plan.Name,
+		&s.Plan.MaxSeats,
+		&s.Plan.MaxCourses,
+		&s.Plan.StorageBytes,
+		&s.Status,
+		&s.Provider,
+		&s.ProviderSubscriptionID,
+		&s.CurrentPeriodEnd,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.Subscription{}, ErrAccountNotFound
+		}
+		return models.Subscription{}, fmt.Errorf("select subscription: %w", err)
+	}
+	return s, nil
+}
+
+// CountAccountSeats считает занятые места: участников и ожидающие приглашения
+func (r *billingRepo) CountAccountSeats(ctx context.Context, accountID int64) (int, error) {
+	const query = `
+		SELECT (SELECT COUNT(*) FROM account_members WHERE account_id = $1)
+			+ (SELECT COUNT(*) FROM account_invitations WHERE account_id = $1)
+	`
+	var n int
+	if err := r.db.QueryRow(ctx, query, accountID).Scan(&n); err != nil {
+		return 0, fmt.Errorf("count account members: %w", err)
+	}
+	return n, nil
+}
+
+// reserveSeats проверяет в транзакции tx, что в аккаунт можно добавить ещё adding участников
+// или приглашений. Строка аккаунта блокируется до конца транзакции, поэтому параллельные
+// добавления и смена тарифа выстраиваются в очередь и не превышают лимит.
+func reserveSeats(ctx context.Context, tx pgx.Tx, accountID int64, adding int) error {
+	const lockQuery = `SELECT id FROM accounts WHERE id = $1 FOR UPDATE`
+	if err := tx.QueryRow(ctx, lockQuery, accountID).Scan(new(int64)); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrAccountNotFound
+		}
+		return fmt.Errorf("lock account: %w", err)
+	}
+
+	// считаем отдельным запросом после блокировки, чтобы видеть места, занятые до нас
+	const query = `
+		SELECT p.max_seats,
+			(SELECT COUNT(*) FROM account_members WHERE account_id = $1)
+			+ (SELECT COUNT(*) FROM account_invitations WHERE account_id = $1)
+		FROM plans p
+		WHERE p.id = COALESCE((SELECT plan_id FROM subscriptions WHERE account_id = $1), $2)
+	`
+	var maxSeats *int
+	var seats int
+	if err := tx.QueryRow(ctx, query, accountID, models.DefaultPlanID).Scan(&maxSeats, &seats); err != nil {
+		return fmt.Errorf("count account seats: %w", err)
+	}
+	if maxSeats != nil && seats+adding > *maxSeats {
+		return ErrSeatLimitReached
+	}
+	return nil
+}
+
+func (r *billingRepo) CountAccountCourses(ctx context.Context, accountID int64) (int, error) {
+	const query = `SELECT COUNT(*) FROM courses WHERE account_id = $1 AND deleted_at IS NULL`
+	var n int
+	if err := r.db.QueryRow(ctx, query, accountID).Scan(&n); err != nil {
+		return 0, fmt.Errorf("count account courses: %w", err)
+	}
+	return n, nil
+}
+
+/* ===================== Events ===================== */
+
+type SubscriptionUpdate struct {
+	// PlanID - новый тариф, nil - оставить текущий
+	PlanID                 *string
+	Status                 models.SubscriptionStatus
+	ProviderSubscriptionID *string
+	CurrentPeriodEnd       *time.Time
+}
+
+type ApplyBillingEventParams struct {
+	Provider  string
+	EventID   string
+	Type      string
+	AccountID int64
+	Payload   []byte
+	// Subscription - изменение подписки, nil - событие только сохраняется
+	Subscription *SubscriptionUpdate
+}
+
+// ApplyBillingEvent сохраняет событие и применяет изменение подписки в одной транзакции.
+// Возвращает false, если событие уже было обработано.
+func (r *billingRepo) ApplyBillingEvent(ctx context.Context, params ApplyBillingEventParams) (bool, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return false, fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	now := time.Now().UTC()
+	const insertEventQuery = `
+		INSERT INTO billing_events (provider, event_id, type, account_id, payload, received_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (provider, event_id) DO NOTHING
+	`
+	cmdTag, err := tx.Exec(ctx, insertEventQuery,
+		params.Provider,
+		params.EventID,
+		params.Type,
+		params.AccountID,
+		params.Payload,
+		now,
+	)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23503" {
+			return false, ErrAccountNotFound
+		}
+		return false, fmt.Errorf("insert billing event: %w", err)
+	}
+	if cmdTag.RowsAffected() == 0 {
+		return false, nil
+	}
+
+	if upd := params.Subscription; upd != nil {
+		const upsertQuery = `
+			INSERT INTO subscriptions
+			(account_id, plan_id, status, provider, provider_subscription_id, current_period_end, updated_at)
+			VALUES ($1, COALESCE($2, $8), $3, $4, $5, $6, $7)
+			ON CONFLICT (account_id) DO UPDATE SET
+				plan_id = COALESCE($2, subscriptions.plan_id),
+				status = EXCLUDED.status,
+				provider = EXCLUDED.provider,
+				provider_subscription_id = COALESCE(EXCLUDED.provider_subscription_id, subscriptions.provider_subscription_id),
+				current_period_end = COALESCE(EXCLUDED.current_period_end, subscriptions.current_period_end),
+				updated_at = EXCLUDED.updated_at
+			RETURNING plan_id
+		`
+		var planID string
+		err := tx.QueryRow(ctx, upsertQuery,
+			params.AccountID,
+			upd.PlanID,
+			upd.Status,
+			params.Provider,
+			upd.ProviderSubscriptionID,
+			upd.CurrentPeriodEnd,
+			now,
+			models.DefaultPlanID,
+		).Scan(&planID)
+		if err != nil {
+			var pgErr *pgconn.PgError
+			if errors.As(err, &pgErr) && pgErr.Code == "23503" {
+				switch pgErr.ConstraintName {
+				case "fk_subscriptions__plan_id":
+					return false, ErrPlanNotFound
+				case "fk_subscriptions__account_id":
+					return false, ErrAccountNotFound
+				}
+			}
+			return false, fmt.Errorf("upsert subscription: %w", err)
+		}
+
+		// квота хранилища следует за тарифом, 0 - без ограничений
+		const quotaQuery = `
+			INSERT INTO account_storage (account_id, quota_bytes)
+			SELECT $1, COALESCE(storage_bytes, 0) FROM plans WHERE id = $2
+			ON CONFLICT (account_id) DO UPDATE SET quota_bytes = EXCLUDED.quota_bytes
+		`
+		if _, err := tx.Exec(ctx, quotaQuery, params.AccountID, planID); err != nil {
+			return false, fmt.Errorf("update storage quota: %w", err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return false, fmt.Errorf("commit tx: %w", err)
+	}
+	return true, nil
+}
@@ -0,0 +1,304 @@
+package repo
+
+import (
+	"chalk/internal/repo/models"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+type ClosureRepo interface {
+	RequestAccountClosure(ctx context.Context, params RequestAccountClosureParams) (models.AccountClosure, error)
+	CancelAccountClosure(ctx context.Context, accountID int64) (models.AccountClosure, error)
+	GetAccountClosure(ctx context.Context, accountID int64) (models.AccountClosure, error)
+	GetClosuresWithoutExport(ctx context.Context) ([]models.AccountClosure, error)
+	SetAccountExportFile(ctx context.Context, accountID, fileID int64) error
+	GetAccountsDueForDeletion(ctx context.Context, now time.Time) ([]int64, error)
+	GetAccountFiles(ctx context.Context, accountID int64) ([]models.File, error)
+	GetAccountEnrollments(ctx context.Context, accountID int64) ([]models.Enrollment, error)
+	// StartAccountDeletion переводит аккаунт с наступившим сроком удаления в состояние удаления,
+	// после этого закрытие нельзя отменить
+	StartAccountDeletion(ctx context.Context, accountID int64, now time.Time) error
+	DeleteClosedAccount(ctx context.Context, accountID int64) error
+}
+
+func NewClosureRepo(db DB) ClosureRepo {
+	return &closureRepo{db: db}
+}
+
+type closureRepo struct {
+	db DB
+}
+
+const accountClosureColumns = `id, closure_requested_at, closure_requested_by, deletion_scheduled_at, export_file_id`
+
+func scanAccountClosure(row pgx.Row) (models.AccountClosure, error) {
+	var c models.AccountClosure
+	err := row.Scan(&c.AccountID, &c.RequestedAt, &c.RequestedBy, &c.DeletionScheduledAt, &c.ExportFileID)
+	return c, err
+}
+
+/* ===================== Closure ===================== */
+
+type RequestAccountClosureParams struct {
+	AccountID   int64
+	RequestedBy int64
+	RequestedAt time.Time
+	DeleteAt    time.Time
+}
+
+func (r *closureRepo) RequestAccountClosure(ctx context.Context, params RequestAccountClosureParams) (models.AccountClosure, error) {
+	const query = `
+		UPDATE accounts SET
+			closure_requested_at = $2,
+			closure_requested_by = $3,
+			deletion_scheduled_at = $4,
+			export_file_id = NULL
+		WHERE id = $1 AND closure_requested_at IS NULL
+		RETURNING ` + accountClosureColumns
+	c, err := scanAccountClosure(r.db.QueryRow(ctx, query,
+		params.AccountID,
+		params.RequestedAt.UTC(),
+		params.RequestedBy,
+		params.DeleteAt.UTC(),
+	))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.AccountClosure{}, r.closureStateError(ctx, params.AccountID, ErrAccountClosing)
+		}
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23503" {
+			return models.AccountClosure{}, ErrUserNotFound
+		}
+		return models.AccountClosure{}, fmt.Errorf("request account closure: %w", err)
+	}
+	return c, nil
+}
+
+// CancelAccountClosure снимает запрос на закрытие и возвращает его прежнее состояние.
+// Если удаление данных уже началось, возвращает ErrAccountDeleting.
+func (r *closureRepo) CancelAccountClosure(ctx context.Context, accountID int64) (models.AccountClosure, error) {
+	const query = `
+		UPDATE accounts a SET
+			closure_requested_at = NULL,
+			closure_requested_by = NULL,
+			deletion_scheduled_at = NULL,
+			export_file_id = NULL
+		FROM (SELECT ` + accountClosureColumns + ` FROM accounts WHERE id = $1 FOR UPDATE) prev
+		WHERE a.id = prev.id AND prev.closure_requested_at IS NOT NULL AND a.deletion_started_at IS NULL
+		RETURNING prev.id, prev.closure_requested_at, prev.closure_requested_by, prev.deletion_scheduled_at, prev.export_file_id
+	`
+	c, err := scanAccountClosure(r.db.QueryRow(ctx, query, accountID))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.AccountClosure{}, r.cancelStateError(ctx, accountID)
+		}
+		return models.AccountClosure{}, fmt.Errorf("cancel account closure: %w", err)
+	}
+	return c, nil
+}
+
+func (r *closureRepo) GetAccountClosure(ctx context.Context, accountID int64) (models.AccountClosure, error) {
+	const query = `SELECT ` + accountClosureColumns + ` FROM accounts WHERE id = $1 AND closure_requested_at IS NOT NULL`
+	c, err := scanAccountClosure(r.db.QueryRow(ctx, query, accountID))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.AccountClosure{}, r.closureStateError(ctx, accountID, ErrAccountNotClosing)
+		}
+		return models.AccountClosure{}, fmt.Errorf("select account closure: %w", err)
+	}
+	return c, nil
+}
+
+// closureStateError различает отсутствующий аккаунт и аккаунт в неподходящем состоянии
+func (r *closureRepo) closureStateError(ctx context.Context, accountID int64, stateErr error) error {
+	const query = `SELECT EXISTS(SELECT 1 FROM accounts WHERE id = $1)`
+	var exists bool
+	if err := r.db.QueryRow(ctx, query, accountID).Scan(&exists); err != nil {
+		return fmt.Errorf("check account existence: %w", err)
+	}
+	if !exists {
+		return ErrAccountNotFound
+	}
+	return stateErr
+}
+
+// cancelStateError объясняет, почему закрытие аккаунта не отменилось
+func (r *closureRepo) cancelStateError(ctx context.Context, accountID int64) error {
+	const query = `SELECT deletion_started_at IS NOT NULL FROM accounts WHERE id = $1`
+	var deleting bool
+	if err := r.db.QueryRow(ctx, query, accountID).Scan(&deleting); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrAccountNotFound
+		}
+		return fmt.Errorf("check account state: %w", err)
+	}
+	if deleting {
+		return ErrAccountDeleting
+	}
+	return ErrAccountNotClosing
+}
+
+func (r *closureRepo) GetClosuresWithoutExport(ctx context.Context) ([]models.AccountClosure, error) {
+	const query = `
+		SELECT ` + accountClosureColumns + ` FROM accounts
+		WHERE closure_requested_at IS NOT NULL AND export_file_id IS NULL
+		ORDER BY closure_requested_at
+	`
+	rows, err := r.db.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("query account closures: %w", err)
+	}
+	defer rows.Close()
+
+	var closures []models.AccountClosure
+	for rows.Next() {
+		c, err := scanAccountClosure(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan account closure: %w", err)
+		}
+		closures = append(closures, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+	return closures, nil
+}
+
+func (r *closureRepo) SetAccountExportFile(ctx context.Context, accountID, fileID int64) error {
+	const query = `UPDATE accounts SET export_file_id = $2 WHERE id = $1 AND closure_requested_at IS NOT NULL`
+	cmdTag, err := r.db.Exec(ctx, query, accountID, fileID)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23503" {
+			return ErrFileNotFound
+		}
+		return fmt.Errorf("update export file: %w", err)
+	}
+	if cmdTag.RowsAffected() == 0 {
+		return r.closureStateError(ctx, accountID, ErrAccountNotClosing)
+	}
+	return nil
+}
+
+func (r *closureRepo) GetAccountsDueForDeletion(ctx context.Context, now time.Time) ([]int64, error) {
+	const query = `SELECT id FROM accounts WHERE deletion_scheduled_at <= $1 ORDER BY deletion_scheduled_at`
+	rows, err := r.db.Query(ctx, query, now.UTC())
+	if err != nil {
+		return nil, fmt.Errorf("query accounts due for deletion: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("scan account id: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+	return ids, nil
+}
+
+func (r *closureRepo) StartAccountDeletion(ctx context.Context, accountID int64, now time.Time) error {
+	// повторный запуск после сбоя оставляет время первого
+	const query = `
+		UPDATE accounts SET deletion_started_at = COALESCE(deletion_started_at, $2)
+		WHERE id = $1 AND deletion_scheduled_at <= $2
+	`
+	cmdTag, err := r.db.Exec(ctx, query, accountID, now.UTC())
+	if err != nil {
+		return fmt.Errorf("start account deletion: %w", err)
+	}
+	if cmdTag.RowsAffected() == 0 {
+		return r.closureStateError(ctx, accountID, ErrAccountNotClosing)
+	}
+	return nil
+}
+
+// DeleteClosedAccount удаляет аккаунт, удаление которого начато StartAccountDeletion.
+// Строки в базе удаляются каскадно, объекты MinIO нужно удалить заранее.
+func (r *closureRepo) DeleteClosedAccount(ctx context.Context, accountID int64) error {
+	const query = `DELETE FROM accounts WHERE id = $1 AND deletion_started_at IS NOT NULL`
+	cmdTag, err := r.db.Exec(ctx, query, accountID)
+	if err != nil {
+		return fmt.Errorf("delete account: %w", err)
+	}
+	if cmdTag.RowsAffected() == 0 {
+		return r.closureStateError(ctx, accountID, ErrAccountNotClosing)
+	}
+	return nil
+}
+
+/* ===================== Export ===================== */
+
+func (r *closureRepo) GetAccountFiles(ctx context.Context, accountID int64) ([]models.File, error) {
+	const query = `
+		SELECT id, uploader_user_id, account_id, name, content_type, bucket, key, uploaded_at, size
+		FROM files WHERE account_id = $1 ORDER BY id
+	`
+	rows, err := r.db.Query(ctx, query, accountID)
+	if err != nil {
+		return nil, fmt.Errorf("query account files: %w", err)
+	}
+	defer rows.Close()
+
+	var files []models.File
+	for rows.Next() {
+		var f models.File
+		err := rows.Scan(
+			&f.ID,
+			&f.UploaderUserID,
+			&f.AccountID,
+			&f.Name,
+			&f.ContentType,
+			&f.Bucket,
+			&f.Key,
+			&f.UploadedAt,
+			&f.Size,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("scan file: %w", err)
+		}
+		files = append(files, f)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+	return files, nil
+}
+
+func (r *closureRepo) GetAccountEnrollments(ctx context.Context, accountID int64) ([]models.Enrollment, error) {
+	const query = `
+		SELECT cp.course_id, cp.user_id
+		FROM course_participants cp
+		INNER JOIN courses c ON c.id = cp.course_id
+		WHERE c.account_id = $1 AND c.deleted_at IS NULL
+		ORDER BY cp.course_id, cp.user_id
+	`
+	rows, err := r.db.Query(ctx, query, accountID)
+	if err != nil {
+		return nil, fmt.Errorf("query enrollments: %w", err)
+	}
+	defer rows.Close()
+
+	var enrollments []models.Enrollment
+	for rows.Next() {
+		var e models.Enrollment
+		if err := rows.Scan(&e.CourseID, &e.UserID); err != nil {
+			return nil, fmt.Errorf("scan enrollment: %w", err)
+		}
+		enrollments = append(enrollments, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+	return enrollments, nil
+}
@@ -0,0 +1,181 @@
+package repo
+
+import (
+	"chalk/internal/repo/models"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type CourseVersionsRepo interface {
+	PublishCourseVersion(ctx context.Context, params PublishCourseVersionParams) (models.CourseVersion, error)
+	GetCourseVersions(ctx context.Context, courseID int64) ([]models.CourseVersion, error)
+	GetCourseVersion(ctx context.Context, courseID int64, version int) (models.CourseVersion, error)
+	// GetPublishedCourseVersion возвращает версию, которую видят участники, ErrCourseNotPublished если её нет
+	GetPublishedCourseVersion(ctx context.Context, courseID int64) (models.CourseVersion, error)
+	SetPublishedCourseVersion(ctx context.Context, courseID int64, version int) error
+}
+
+func NewCourseVersionsRepo(db DB) CourseVersionsRepo {
+	return &courseVersionsRepo{db: db}
+}
+
+type courseVersionsRepo struct {
+	db DB
+}
+
+type PublishCourseVersionParams struct {
+	CourseID    int64
+	Snapshot    *models.CourseSnapshot
+	PublishedBy int64
+	PublishedAt time.Time
+}
+
+// PublishCourseVersion сохраняет снимок следующей по номеру версией и делает её текущей
+func (r *courseVersionsRepo) PublishCourseVersion(ctx context.Context, params PublishCourseVersionParams) (models.CourseVersion, error) {
+	snapshot, err := json.Marshal(params.Snapshot)
+	if err != nil {
+		return models.CourseVersion{}, fmt.Errorf("marshal snapshot: %w", err)
+	}
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return models.CourseVersion{}, fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// блокировка курса сериализует параллельные публикации
+	const lockQuery = `SELECT id FROM courses WHERE id = $1 AND deleted_at IS NULL FOR UPDATE`
+	if err := tx.QueryRow(ctx, lockQuery, params.CourseID).Scan(new(int64)); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.CourseVersion{}, ErrCourseNotFound
+		}
+		return models.CourseVersion{}, fmt.Errorf("lock course: %w", err)
+	}
+
+	v := models.CourseVersion{
+		CourseID:    params.CourseID,
+		PublishedAt: params.PublishedAt,
+		PublishedBy: &params.PublishedBy,
+		Current:     true,
+		Snapshot:    params.Snapshot,
+	}
+	const insertQuery = `
+		INSERT INTO course_versions (course_id, version, snapshot, published_at, published_by)
+		SELECT $1, COALESCE(MAX(version), 0) + 1, $2, $3, $4 FROM course_versions WHERE course_id = $1
+		RETURNING id, version
+	`
+	err = tx.QueryRow(ctx, insertQuery, params.CourseID, snapshot, params.PublishedAt.UTC(), params.PublishedBy).Scan(&v.ID, &v.Version)
+	if err != nil {
+		return models.CourseVersion{}, fmt.Errorf("insert course version: %w", err)
+	}
+
+	const publishQuery = `UPDATE courses SET published_version_id = $2 WHERE id = $1`
+	if _, err := tx.Exec(ctx, publishQuery, params.CourseID, v.ID); err != nil {
+		return models.CourseVersion{}, fmt.Errorf("update published version: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return models.CourseVersion{}, fmt.Errorf("commit tx: %w", err)
+	}
+	return v, nil
+}
+
+func (r *courseVersionsRepo) GetCourseVersions(ctx context.Context, courseID int64) ([]models.CourseVersion, error) {
+	const query = `
+		SELECT v.id, v.course_id, v.version, v.published_at, v.published_by, v.id = c.published_version_id
+		FROM course_versions v
+		INNER JOIN courses c ON c.id = v.course_id
+		WHERE v.course_id = $1
+		ORDER BY v.version DESC
+	`
+	rows, err := r.db.Query(ctx, query, courseID)
+	if err != nil {
+		return nil, fmt.Errorf("query course versions: %w", err)
+	}
+	defer rows.Close()
+
+	var versions []models.CourseVersion
+	for rows.Next() {
+		var v models.CourseVersion
+		var current *bool
+		if err := rows.Scan(&v.ID, &v.CourseID, &v.Version, &v.PublishedAt, &v.PublishedBy, &current); err != nil {
+			return nil, fmt.Errorf("scan course version: %w", err)
+		}
+		v.Current = current != nil && *current
+		versions = append(versions, v)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+	return versions, nil
+}
+
+func (r *courseVersionsRepo) GetCourseVersion(ctx context.Context, courseID int64, version int) (models.CourseVersion, error) {
+	const query = `
+		SELECT v.id, v.course_id, v.version, v.published_at, v.published_by,
+		       COALESCE(v.id = c.published_version_id, false), v.snapshot
+		FROM course_versions v
+		INNER JOIN courses c ON c.id = v.course_id
+		WHERE v.course_id = $1 AND v.version = $2
+	`
+	v, err := scanCourseVersion(r.db.QueryRow(ctx, query, courseID, version))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.CourseVersion{}, ErrCourseVersionNotFound
+		}
+		return models.CourseVersion{}, fmt.Errorf("select course version: %w", err)
+	}
+	return v, nil
+}
+
+func (r *courseVersionsRepo) GetPublishedCourseVersion(ctx context.Context, courseID int64) (models.CourseVersion, error) {
+	const query = `
+		SELECT v.id, v.course_id, v.version, v.published_at, v.published_by, true, v.snapshot
+		FROM courses c
+		INNER JOIN course_versions v ON v.id = c.published_version_id
+		WHERE c.id = $1 AND c.deleted_at IS NULL
+	`
+	v, err := scanCourseVersion(r.db.QueryRow(ctx, query, courseID))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.CourseVersion{}, ErrCourseNotPublished
+		}
+		return models.CourseVersion{}, fmt.Errorf("select published version: %w", err)
+	}
+	return v, nil
+}
+
+func scanCourseVersion(row pgx.Row) (models.CourseVersion, error) {
+	var v models.CourseVersion
+	var snapshot []byte
+	if err := row.Scan(&v.ID, &v.CourseID, &v.Version, &v.PublishedAt, &v.PublishedBy, &v.Current, &snapshot); err != nil {
+		return models.CourseVersion{}, err
+	}
+	v.Snapshot = &models.CourseSnapshot{}
+	if err := json.Unmarshal(snapshot, v.Snapshot); err != nil {
+		return models.CourseVersion{}, fmt.Errorf("unmarshal snapshot: %w", err)
+	}
+	return v, nil
+}
+
+// SetPublishedCourseVersion делает текущей одну из опубликованных ранее версий
+func (r *courseVersionsRepo) SetPublishedCourseVersion(ctx context.Context, courseID int64, version int) error {
+	const query = `
+		UPDATE courses c SET published_version_id = v.id
+		FROM course_versions v
+		WHERE c.id = $1 AND c.deleted_at IS NULL AND v.course_id = c.id AND v.version = $2
+	`
+	cmdTag, err := r.db.Exec(ctx, query, courseID, version)
+	if err != nil {
+		return fmt.Errorf("update published version: %w", err)
+	}
+	if cmdTag.RowsAffected() == 0 {
+		return ErrCourseVersionNotFound
+	}
+	return nil
+}
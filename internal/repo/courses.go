@@ -2,24 +2,99 @@ package repo
 
 import (
 	"chalk/internal/repo/models"
+	"chalk/pkg/assignment"
+	"chalk/pkg/codeexercise"
+	"chalk/pkg/media"
+	"chalk/pkg/quiz"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
 )
 
 type CoursesRepo interface {
+	CreateCourse(ctx context.Context, params CreateCourseParams) (int64, error)
+	UpdateCourse(ctx context.Context, params UpdateCourseParams) error
+	RemoveCourse(ctx context.Context, params RemoveCourseParams) error
+	GetCoursesByAccountID(ctx context.Context, accountID int64) ([]*models.Course, error)
+	GetCourseByID(ctx context.Context, courseID int64) (*models.Course, error)
+
+	CreateModule(ctx context.Context, params CreateModuleParams) (int64, error)
+	UpdateModule(ctx context.Context, params UpdateModuleParams) error
+	UpdateModuleOrderIdx(ctx context.Context, params UpdateModuleOrderIdxParams) error
+	ReorderModules(ctx context.Context, params ReorderModulesParams) error
+	RemoveModule(ctx context.Context, params RemoveModuleParams) error
+	GetModulesByCourseID(ctx context.Context, courseID int64) ([]*models.Module, error)
+	GetModuleByID(ctx context.Context, moduleID int64) (*models.Module, error)
+
+	CreateLesson(ctx context.Context, params CreateLessonParams) (int64, error)
+	UpdateLesson(ctx context.Context, params UpdateLessonParams) error
+	UpdateLessonOrderIdx(ctx context.Context, params UpdateLessonOrderIdxParams) error
+	ReorderLessons(ctx context.Context, params ReorderLessonsParams) error
+	MoveLesson(ctx context.Context, params MoveLessonParams) (MovedItem, error)
+	RemoveLesson(ctx context.Context, params RemoveLessonParams) error
+	GetLessonsByModuleID(ctx context.Context, moduleID int64) ([]*models.Lesson, error)
+	GetLessonByID(ctx context.Context, lessonID int64) (*models.Lesson, error)
+
+	CreateVideoBlock(ctx context.Context, params CreateVideoBlockParams) (int64, error)
+	CreateTextBlock(ctx context.Context, params CreateTextBlockParams) (int64, error)
+	CreateScormBlock(ctx context.Context, params CreateScormBlockParams) (int64, error)
+	// GetScormBlock возвращает неудалённый блок SCORM, ErrBlockNotFound для блока другого типа
+	GetScormBlock(ctx context.Context, blockID int64) (models.ScormBlock, error)
+	CreateQuizBlock(ctx context.Context, params CreateQuizBlockParams) (int64, error)
+	// GetQuizBlock возвращает неудалённый тест, ErrBlockNotFound для блока другого типа
+	GetQuizBlock(ctx context.Context, blockID int64) (models.QuizBlock, error)
+	UpdateTextBlock(ctx context.Context, params UpdateTextBlockParams) error
+	UpdateQuizBlock(ctx context.Context, params UpdateQuizBlockParams) error
+	CreateAssignmentBlock(ctx context.Context, params CreateAssignmentBlockParams) (int64, error)
+	// GetAssignmentBlock возвращает неудалённое задание, ErrBlockNotFound для блока другого типа
+	GetAssignmentBlock(ctx context.Context, blockID int64) (models.AssignmentBlock, error)
+	UpdateAssignmentBlock(ctx context.Context, params UpdateAssignmentBlockParams) error
+	CreateCodeExerciseBlock(ctx context.Context, params CreateCodeExerciseBlockParams) (int64, error)
+	// GetCodeExerciseBlock возвращает неудалённое упражнение, ErrBlockNotFound для блока другого типа
+	GetCodeExerciseBlock(ctx context.Context, blockID int64) (models.CodeExerciseBlock, error)
+	UpdateCodeExerciseBlock(ctx context.Context, params UpdateCodeExerciseBlockParams) error
+	CreateImageBlock(ctx context.Context, params CreateImageBlockParams) (int64, error)
+	// GetImageBlock возвращает неудалённую картинку, ErrBlockNotFound для блока другого типа
+	GetImageBlock(ctx context.Context, blockID int64) (models.ImageBlock, error)
+	CreateAttachmentBlock(ctx context.Context, params CreateAttachmentBlockParams) (int64, error)
+	// GetAttachmentBlock возвращает неудалённое вложение, ErrBlockNotFound для блока другого типа
+	GetAttachmentBlock(ctx context.Context, blockID int64) (models.AttachmentBlock, error)
+	CreateEmbedBlock(ctx context.Context, params CreateEmbedBlockParams) (int64, error)
+	UpdateBlockOrderIdx(ctx context.Context, params UpdateBlockOrderIdxParams) error
+	ReorderBlocks(ctx context.Context, params ReorderBlocksParams) error
+	MoveBlock(ctx context.Context, params MoveBlockParams) (MovedItem, error)
+	RemoveBlock(ctx context.Context, params RemoveBlockParams) error
+	GetBlocksByLessonID(ctx context.Context, lessonID int64) ([]any, error)
+
+	// GetCourseHierarchy загружает дерево курса за постоянное число запросов
+	GetCourseHierarchy(ctx context.Context, courseID int64) (*models.CourseHierarchy, error)
+	// GetCourseSnapshot загружает черновик курса вместе с содержимым блоков
+	GetCourseSnapshot(ctx context.Context, courseID int64) (*models.CourseSnapshot, error)
+	CreateCourseFromSnapshot(ctx context.Context, params CreateCourseFromSnapshotParams) (int64, error)
+
+	IsUserEnrolled(ctx context.Context, userID, courseID int64) (bool, error)
+	// GetItemOwner возвращает курс и аккаунт, которым принадлежит неудалённый элемент курса
+	GetItemOwner(ctx context.Context, itemType models.TrashItemType, id int64) (models.ItemOwner, error)
+
+	GetTrash(ctx context.Context, accountID int64) ([]models.TrashItem, error)
+	GetTrashItemAccountID(ctx context.Context, itemType models.TrashItemType, id int64) (int64, error)
+	RestoreTrashItem(ctx context.Context, params RestoreTrashItemParams) error
+	PurgeTrash(ctx context.Context, before time.Time) (int64, error)
 }
 
-func NewCoursesRepo(db *pgx.Conn) CoursesRepo {
+func NewCoursesRepo(db DB) CoursesRepo {
 	return &coursesRepo{db: db}
 }
 
 type coursesRepo struct {
-	db *pgx.Conn
+	db DB
 }
 
 /* ===================== Courses ===================== */
@@ -48,27 +123,76 @@ func (r *coursesRepo) CreateCourse(ctx context.Context, params CreateCourseParam
 type UpdateCourseParams struct {
 	CourseID int64
 	Name     string
+	Author   RevisionAuthor
 }
 
 func (r *coursesRepo) UpdateCourse(ctx context.Context, params UpdateCourseParams) error {
-	const query = `UPDATE courses SET (name) = ($1) WHERE id = $2`
-	cmdTag, err := r.db.Exec(ctx, query, params.Name, params.CourseID)
+	return r.updateItemName(ctx, models.TrashItemCourse, params.CourseID, params.Name, params.Author)
+}
+
+var itemNameTables = map[models.TrashItemType]struct {
+	table    string
+	notFound error
+}{
+	models.TrashItemCourse: {"courses", ErrCourseNotFound},
+	models.TrashItemModule: {"modules", ErrModuleNotFound},
+	models.TrashItemLesson: {"lessons", ErrLessonNotFound},
+}
+
+// updateItemName переименовывает курс, модуль или урок и пишет правку в историю
+func (r *coursesRepo) updateItemName(ctx context.Context, itemType models.TrashItemType, id int64, name string, author RevisionAuthor) error {
+	t, ok := itemNameTables[itemType]
+	if !ok {
+		return fmt.Errorf("unknown item type %q", itemType)
+	}
+
+	tx, err := r.db.Begin(ctx)
 	if err != nil {
-		return fmt.Errorf("update course: %w", err)
+		return fmt.Errorf("begin tx: %w", err)
 	}
-	if cmdTag.RowsAffected() == 0 {
-		return ErrCourseNotFound
+	defer tx.Rollback(ctx)
+
+	selectQuery := fmt.Sprintf(`SELECT name FROM %s WHERE id = $1 AND deleted_at IS NULL FOR UPDATE`, t.table)
+	var oldName string
+	if err := tx.QueryRow(ctx, selectQuery, id).Scan(&oldName); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return t.notFound
+		}
+		return fmt.Errorf("select %s: %w", itemType, err)
+	}
+
+	updateQuery := fmt.Sprintf(`UPDATE %s SET name = $1 WHERE id = $2`, t.table)
+	if _, err := tx.Exec(ctx, updateQuery, name, id); err != nil {
+		return fmt.Errorf("update %s: %w", itemType, err)
+	}
+
+	err = insertRevision(ctx, tx, insertRevisionParams{
+		Author:   author,
+		ItemType: itemType,
+		ItemID:   id,
+		Field:    models.RevisionFieldName,
+		OldValue: oldName,
+		Value:    name,
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit tx: %w", err)
 	}
 	return nil
 }
 
 type RemoveCourseParams struct {
-	CourseID int64
+	CourseID  int64
+	DeletedBy int64
 }
 
+// RemoveCourse перемещает курс в корзину, окончательно он удаляется PurgeTrash
 func (r *coursesRepo) RemoveCourse(ctx context.Context, params RemoveCourseParams) error {
-	const query = `DELETE FROM courses WHERE id = $1`
-	cmdTag, err := r.db.Exec(ctx, query, params.CourseID)
+	const query = `UPDATE courses SET deleted_at = $1, deleted_by = $2 WHERE id = $3 AND deleted_at IS NULL`
+	cmdTag, err := r.db.Exec(ctx, query, time.Now().UTC(), params.DeletedBy, params.CourseID)
 	if err != nil {
 		return fmt.Errorf("delete course: %w", err)
 	}
@@ -89,7 +213,7 @@ func (r *coursesRepo) GetCoursesByAccountID(ctx context.Context, accountID int64
 		return nil, ErrAccountNotFound
 	}
 
-	const query = `SELECT id, account_id, name FROM courses WHERE account_id = $1`
+	const query = `SELECT id, account_id, name FROM courses WHERE account_id = $1 AND deleted_at IS NULL`
 	rows, err := r.db.Query(ctx, query, accountID)
 	if err != nil {
 		return nil, fmt.Errorf("query courses: %w", err)
@@ -111,7 +235,7 @@ func (r *coursesRepo) GetCoursesByAccountID(ctx context.Context, accountID int64
 }
 
 func (r *coursesRepo) GetCourseByID(ctx context.Context, courseID int64) (*models.Course, error) {
-	const query = `SELECT id, account_id, name FROM courses WHERE id = $1`
+	const query = `SELECT id, account_id, name FROM courses WHERE id = $1 AND deleted_at IS NULL`
 	var course models.Course
 	err := r.db.QueryRow(ctx, query, courseID).Scan(&course.ID, &course.AccountID, &course.Name)
 	if err != nil {
@@ -240,10 +364,19 @@ func (r *coursesRepo) CreateModule(ctx context.Context, params CreateModuleParam
 	}
 	defer tx.Rollback(ctx)
 
+	// Шаг 0: Блокируем курс, чтобы параллельные вставки не получили один order_idx
+	const lockQuery = `SELECT id FROM courses WHERE id = $1 AND deleted_at IS NULL FOR UPDATE`
+	if err := tx.QueryRow(ctx, lockQuery, params.CourseID).Scan(new(int64)); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, ErrCourseNotFound
+		}
+		return 0, fmt.Errorf("lock course: %w", err)
+	}
+
 	var newOrderIdx int64
 
 	// Шаг 1: Получаем максимальный order_idx для данного курса
-	const maxQuery = `SELECT COALESCE(MAX(order_idx), 0) FROM modules WHERE course_id = $1`
+	const maxQuery = `SELECT COALESCE(MAX(order_idx), 0) FROM modules WHERE course_id = $1 AND deleted_at IS NULL`
 	var maxOrderIdx int64
 	if err := tx.QueryRow(ctx, maxQuery, params.CourseID).Scan(&maxOrderIdx); err != nil {
 		return 0, fmt.Errorf("get max order_idx: %w", err)
@@ -253,14 +386,14 @@ func (r *coursesRepo) CreateModule(ctx context.Context, params CreateModuleParam
 	if params.OrderIdx == nil || *params.OrderIdx > maxOrderIdx {
 		newOrderIdx = maxOrderIdx + 1
 	} else {
-		newOrderIdx = max(*params.OrderIdx, 0)
+		newOrderIdx = max(*params.OrderIdx, 1)
 
 		// Сдвигаем элементы с order_idx >= newOrderIdx на 1
 		if newOrderIdx <= maxOrderIdx {
 			const shiftQuery = `
 				UPDATE modules
 				SET order_idx = order_idx + 1
-				WHERE course_id = $1 AND order_idx >= $2
+				WHERE course_id = $1 AND order_idx >= $2 AND deleted_at IS NULL
 			`
 			if _, err := tx.Exec(ctx, shiftQuery, params.CourseID, newOrderIdx); err != nil {
 				return 0, fmt.Errorf("shift modules: %w", err)
@@ -295,23 +428,17 @@ func (r *coursesRepo) CreateModule(ctx context.Context, params CreateModuleParam
 type UpdateModuleParams struct {
 	ModuleID int64
 	Name     string
+	Author   RevisionAuthor
 }
 
 func (r *coursesRepo) UpdateModule(ctx context.Context, params UpdateModuleParams) error {
-	const query = `UPDATE modules SET (name) = ($1) WHERE id = $2`
-	cmdTag, err := r.db.Exec(ctx, query, params.Name, params.ModuleID)
-	if err != nil {
-		return fmt.Errorf("update module: %w", err)
-	}
-	if cmdTag.RowsAffected() == 0 {
-		return ErrModuleNotFound
-	}
-	return nil
+	return r.updateItemName(ctx, models.TrashItemModule, params.ModuleID, params.Name, params.Author)
 }
 
 type UpdateModuleOrderIdxParams struct {
 	ModuleID int64
 	OrderIdx int64
+	Author   RevisionAuthor
 }
 
 func (r *coursesRepo) UpdateModuleOrderIdx(ctx context.Context, params UpdateModuleOrderIdxParams) error {
@@ -322,17 +449,14 @@ func (r *coursesRepo) UpdateModuleOrderIdx(ctx context.Context, params UpdateMod
 	defer tx.Rollback(ctx)
 
 	// получаем текущее course_id и order_idx для модуля
-	const getQuery = `SELECT course_id, order_idx FROM modules WHERE id = $1`
-	var courseID, oldIdx int64
-	if err := tx.QueryRow(ctx, getQuery, params.ModuleID).Scan(&courseID, &oldIdx); err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			return ErrModuleNotFound
-		}
-		return fmt.Errorf("select module: %w", err)
+	courseID, idx, err := lockOrderItem(ctx, tx, moduleOrderTables, params.ModuleID)
+	if err != nil {
+		return err
 	}
+	oldIdx := int64(idx)
 
 	// находим максимальный индекс в этом курсе
-	const maxQuery = `SELECT COALESCE(MAX(order_idx), 0) FROM modules WHERE course_id = $1`
+	const maxQuery = `SELECT COALESCE(MAX(order_idx), 0) FROM modules WHERE course_id = $1 AND deleted_at IS NULL`
 	var maxIdx int64
 	if err := tx.QueryRow(ctx, maxQuery, courseID).Scan(&maxIdx); err != nil {
 		return fmt.Errorf("select max order_idx: %w", err)
@@ -341,8 +465,8 @@ func (r *coursesRepo) UpdateModuleOrderIdx(ctx context.Context, params UpdateMod
 	// нормализуем новый индекс
 	newIdx := params.OrderIdx
 	switch {
-	case newIdx < 0:
-		newIdx = 0
+	case newIdx < 1:
+		newIdx = 1
 	case newIdx > maxIdx:
 		newIdx = maxIdx
 	}
@@ -356,18 +480,34 @@ func (r *coursesRepo) UpdateModuleOrderIdx(ctx context.Context, params UpdateMod
 	const updateOrderQuery = `
         UPDATE modules
            SET order_idx = CASE
-               WHEN id = $1 THEN $2
+               WHEN id = $1 THEN $3
                WHEN $2 < $3 AND course_id = $4 AND order_idx > $2 AND order_idx <= $3 THEN order_idx - 1
                WHEN $2 > $3 AND course_id = $4 AND order_idx >= $3 AND order_idx < $2 THEN order_idx + 1
                ELSE order_idx
            END
          WHERE course_id = $4
+           AND deleted_at IS NULL
            AND (id = $1 OR (order_idx BETWEEN LEAST($2, $3) AND GREATEST($2, $3)))
     `
 	if _, err := tx.Exec(ctx, updateOrderQuery, params.ModuleID, oldIdx, newIdx, courseID); err != nil {
+		if isOrderConflict(err) {
+			return ErrOrderConflict
+		}
 		return fmt.Errorf("update order_idx: %w", err)
 	}
 
+	err = insertRevision(ctx, tx, insertRevisionParams{
+		Author:   params.Author,
+		ItemType: models.TrashItemModule,
+		ItemID:   params.ModuleID,
+		Field:    models.RevisionFieldOrderIdx,
+		OldValue: strconv.FormatInt(int64(oldIdx), 10),
+		Value:    strconv.FormatInt(int64(newIdx), 10),
+	})
+	if err != nil {
+		return err
+	}
+
 	if err := tx.Commit(ctx); err != nil {
 		return fmt.Errorf("commit tx: %w", err)
 	}
@@ -375,18 +515,51 @@ func (r *coursesRepo) UpdateModuleOrderIdx(ctx context.Context, params UpdateMod
 	return nil
 }
 
+type ReorderModulesParams struct {
+	CourseID int64
+	// ModuleIDs - все элементы в новом порядке
+	ModuleIDs []int64
+	Author    RevisionAuthor
+}
+
+func (r *coursesRepo) ReorderModules(ctx context.Context, params ReorderModulesParams) error {
+	return r.reorderItems(ctx, moduleOrderTables, params.CourseID, params.ModuleIDs, params.Author)
+}
+
 type RemoveModuleParams struct {
-	ModuleID int64
+	ModuleID  int64
+	DeletedBy int64
 }
 
+// RemoveModule перемещает модуль в корзину и закрывает образовавшийся промежуток в порядке
 func (r *coursesRepo) RemoveModule(ctx context.Context, params RemoveModuleParams) error {
-	const query = `DELETE FROM modules WHERE id = $1`
-	cmdTag, err := r.db.Exec(ctx, query, params.ModuleID)
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// родитель блокируется раньше элемента, как и в остальных изменениях порядка
+	courseID, orderIdx, err := lockOrderItem(ctx, tx, moduleOrderTables, params.ModuleID)
 	if err != nil {
+		return err
+	}
+
+	const removeQuery = `UPDATE modules SET deleted_at = $1, deleted_by = $2 WHERE id = $3`
+	if _, err := tx.Exec(ctx, removeQuery, time.Now().UTC(), params.DeletedBy, params.ModuleID); err != nil {
 		return fmt.Errorf("delete module: %w", err)
 	}
-	if cmdTag.RowsAffected() == 0 {
-		return ErrModuleNotFound
+
+	const closeGapQuery = `
+		UPDATE modules SET order_idx = order_idx - 1
+		WHERE course_id = $1 AND order_idx > $2 AND deleted_at IS NULL
+	`
+	if _, err := tx.Exec(ctx, closeGapQuery, courseID, orderIdx); err != nil {
+		return fmt.Errorf("close order gap: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit tx: %w", err)
 	}
 	return nil
 }
@@ -400,7 +573,7 @@ func (r *coursesRepo) GetModulesByCourseID(ctx context.Context, courseID int64)
 		return nil, fmt.Errorf("check course existence: %v", err)
 	}
 
-	const query = `SELECT id, course_id, name, order_idx FROM modules WHERE course_id = $1 ORDER BY order_idx ASC`
+	const query = `SELECT id, course_id, name, order_idx FROM modules WHERE course_id = $1 AND deleted_at IS NULL ORDER BY order_idx ASC`
 	rows, err := r.db.Query(ctx, query, courseID)
 	if err != nil {
 		return nil, fmt.Errorf("query modules: %w", err)
@@ -422,7 +595,11 @@ func (r *coursesRepo) GetModulesByCourseID(ctx context.Context, courseID int64)
 }
 
 func (r *coursesRepo) GetModuleByID(ctx context.Context, moduleID int64) (*models.Module, error) {
-	const query = `SELECT id, course_id, order_idx, name FROM modules WHERE id = $1`
+	const query = `
+		SELECT m.id, m.course_id, m.order_idx, m.name
+		FROM modules m
+		INNER JOIN courses c ON c.id = m.course_id
+		WHERE m.id = $1 AND m.deleted_at IS NULL AND c.deleted_at IS NULL`
 	var module models.Module
 	err := r.db.QueryRow(ctx, query, moduleID).Scan(&module.ID, &module.CourseID, &module.OrderIdx, &module.Name)
 	if err != nil {
@@ -449,10 +626,18 @@ func (r *coursesRepo) CreateLesson(ctx context.Context, params CreateLessonParam
 	}
 	defer tx.Rollback(ctx)
 
+	const lockQuery = `SELECT id FROM modules WHERE id = $1 AND deleted_at IS NULL FOR UPDATE`
+	if err := tx.QueryRow(ctx, lockQuery, params.ModuleID).Scan(new(int64)); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, ErrModuleNotFound
+		}
+		return 0, fmt.Errorf("lock module: %w", err)
+	}
+
 	var newOrderIdx int
 
 	// Получаем максимальный order_idx для модуля
-	const maxQuery = `SELECT COALESCE(MAX(order_idx), 0) FROM lessons WHERE module_id = $1`
+	const maxQuery = `SELECT COALESCE(MAX(order_idx), 0) FROM lessons WHERE module_id = $1 AND deleted_at IS NULL`
 	var maxOrderIdx int
 	if err := tx.QueryRow(ctx, maxQuery, params.ModuleID).Scan(&maxOrderIdx); err != nil {
 		return 0, fmt.Errorf("get max order_idx: %w", err)
@@ -461,12 +646,12 @@ func (r *coursesRepo) CreateLesson(ctx context.Context, params CreateLessonParam
 	if params.OrderIdx == nil || *params.OrderIdx > maxOrderIdx {
 		newOrderIdx = maxOrderIdx + 1
 	} else {
-		newOrderIdx = max(*params.OrderIdx, 0)
+		newOrderIdx = max(*params.OrderIdx, 1)
 		if newOrderIdx <= maxOrderIdx {
 			const shiftQuery = `
 				UPDATE lessons
 				SET order_idx = order_idx + 1
-				WHERE module_id = $1 AND order_idx >= $2
+				WHERE module_id = $1 AND order_idx >= $2 AND deleted_at IS NULL
 			`
 			if _, err := tx.Exec(ctx, shiftQuery, params.ModuleID, newOrderIdx); err != nil {
 				return 0, fmt.Errorf("shift lessons: %w", err)
@@ -499,23 +684,17 @@ func (r *coursesRepo) CreateLesson(ctx context.Context, params CreateLessonParam
 type UpdateLessonParams struct {
 	LessonID int64
 	Name     string
+	Author   RevisionAuthor
 }
 
 func (r *coursesRepo) UpdateLesson(ctx context.Context, params UpdateLessonParams) error {
-	const query = `UPDATE lessons SET name = $1 WHERE id = $2`
-	cmdTag, err := r.db.Exec(ctx, query, params.Name, params.LessonID)
-	if err != nil {
-		return fmt.Errorf("update lesson: %w", err)
-	}
-	if cmdTag.RowsAffected() == 0 {
-		return ErrLessonNotFound
-	}
-	return nil
+	return r.updateItemName(ctx, models.TrashItemLesson, params.LessonID, params.Name, params.Author)
 }
 
 type UpdateLessonOrderIdxParams struct {
 	LessonID int64
 	OrderIdx int
+	Author   RevisionAuthor
 }
 
 func (r *coursesRepo) UpdateLessonOrderIdx(ctx context.Context, params UpdateLessonOrderIdxParams) error {
@@ -525,17 +704,12 @@ func (r *coursesRepo) UpdateLessonOrderIdx(ctx context.Context, params UpdateLes
 	}
 	defer tx.Rollback(ctx)
 
-	const getQuery = `SELECT module_id, order_idx FROM lessons WHERE id = $1`
-	var moduleID int64
-	var oldIdx int
-	if err := tx.QueryRow(ctx, getQuery, params.LessonID).Scan(&moduleID, &oldIdx); err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			return ErrLessonNotFound
-		}
-		return fmt.Errorf("select lesson: %w", err)
+	moduleID, oldIdx, err := lockOrderItem(ctx, tx, lessonOrderTables, params.LessonID)
+	if err != nil {
+		return err
 	}
 
-	const maxQuery = `SELECT COALESCE(MAX(order_idx), 0) FROM lessons WHERE module_id = $1`
+	const maxQuery = `SELECT COALESCE(MAX(order_idx), 0) FROM lessons WHERE module_id = $1 AND deleted_at IS NULL`
 	var maxIdx int
 	if err := tx.QueryRow(ctx, maxQuery, moduleID).Scan(&maxIdx); err != nil {
 		return fmt.Errorf("select max order_idx: %w", err)
@@ -543,8 +717,8 @@ func (r *coursesRepo) UpdateLessonOrderIdx(ctx context.Context, params UpdateLes
 
 	newIdx := params.OrderIdx
 	switch {
-	case newIdx < 0:
-		newIdx = 0
+	case newIdx < 1:
+		newIdx = 1
 	case newIdx > maxIdx:
 		newIdx = maxIdx
 	}
@@ -556,18 +730,34 @@ func (r *coursesRepo) UpdateLessonOrderIdx(ctx context.Context, params UpdateLes
 	const updateOrderQuery = `
 		UPDATE lessons
 		SET order_idx = CASE
-			WHEN id = $1 THEN $2
+			WHEN id = $1 THEN $3
 			WHEN $2 < $3 AND module_id = $4 AND order_idx > $2 AND order_idx <= $3 THEN order_idx - 1
 			WHEN $2 > $3 AND module_id = $4 AND order_idx >= $3 AND order_idx < $2 THEN order_idx + 1
 			ELSE order_idx
 		END
 		WHERE module_id = $4
+		  AND deleted_at IS NULL
 		  AND (id = $1 OR (order_idx BETWEEN LEAST($2, $3) AND GREATEST($2, $3)))
 	`
 	if _, err := tx.Exec(ctx, updateOrderQuery, params.LessonID, oldIdx, newIdx, moduleID); err != nil {
+		if isOrderConflict(err) {
+			return ErrOrderConflict
+		}
 		return fmt.Errorf("update order_idx: %w", err)
 	}
 
+	err = insertRevision(ctx, tx, insertRevisionParams{
+		Author:   params.Author,
+		ItemType: models.TrashItemLesson,
+		ItemID:   params.LessonID,
+		Field:    models.RevisionFieldOrderIdx,
+		OldValue: strconv.FormatInt(int64(oldIdx), 10),
+		Value:    strconv.FormatInt(int64(newIdx), 10),
+	})
+	if err != nil {
+		return err
+	}
+
 	if err := tx.Commit(ctx); err != nil {
 		return fmt.Errorf("commit tx: %w", err)
 	}
@@ -575,18 +765,64 @@ func (r *coursesRepo) UpdateLessonOrderIdx(ctx context.Context, params UpdateLes
 	return nil
 }
 
-type RemoveLessonParams struct {
+type ReorderLessonsParams struct {
+	ModuleID int64
+	// LessonIDs - все элементы в новом порядке
+	LessonIDs []int64
+	Author    RevisionAuthor
+}
+
+func (r *coursesRepo) ReorderLessons(ctx context.Context, params ReorderLessonsParams) error {
+	return r.reorderItems(ctx, lessonOrderTables, params.ModuleID, params.LessonIDs, params.Author)
+}
+
+type MoveLessonParams struct {
 	LessonID int64
+	ModuleID int64
+	// OrderIdx - позиция в новом модуле, nil - в конец
+	OrderIdx *int
+	Author   RevisionAuthor
 }
 
+// MoveLesson переносит урок в другой модуль того же или другого курса
+func (r *coursesRepo) MoveLesson(ctx context.Context, params MoveLessonParams) (MovedItem, error) {
+	return r.moveItem(ctx, lessonOrderTables, params.LessonID, params.ModuleID, params.OrderIdx, params.Author)
+}
+
+type RemoveLessonParams struct {
+	LessonID  int64
+	DeletedBy int64
+}
+
+// RemoveLesson перемещает урок в корзину и закрывает образовавшийся промежуток в порядке
 func (r *coursesRepo) RemoveLesson(ctx context.Context, params RemoveLessonParams) error {
-	const query = `DELETE FROM lessons WHERE id = $1`
-	cmdTag, err := r.db.Exec(ctx, query, params.LessonID)
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// родитель блокируется раньше элемента, как и в остальных изменениях порядка
+	moduleID, orderIdx, err := lockOrderItem(ctx, tx, lessonOrderTables, params.LessonID)
 	if err != nil {
+		return err
+	}
+
+	const removeQuery = `UPDATE lessons SET deleted_at = $1, deleted_by = $2 WHERE id = $3`
+	if _, err := tx.Exec(ctx, removeQuery, time.Now().UTC(), params.DeletedBy, params.LessonID); err != nil {
 		return fmt.Errorf("delete lesson: %w", err)
 	}
-	if cmdTag.RowsAffected() == 0 {
-		return ErrLessonNotFound
+
+	const closeGapQuery = `
+		UPDATE lessons SET order_idx = order_idx - 1
+		WHERE module_id = $1 AND order_idx > $2 AND deleted_at IS NULL
+	`
+	if _, err := tx.Exec(ctx, closeGapQuery, moduleID, orderIdx); err != nil {
+		return fmt.Errorf("close order gap: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit tx: %w", err)
 	}
 	return nil
 }
@@ -600,7 +836,7 @@ func (r *coursesRepo) GetLessonsByModuleID(ctx context.Context, moduleID int64)
 		return nil, fmt.Errorf("check module existence: %v", err)
 	}
 
-	const query = `SELECT id, module_id, name, order_idx FROM lessons WHERE module_id = $1 ORDER BY order_idx ASC`
+	const query = `SELECT id, module_id, name, order_idx FROM lessons WHERE module_id = $1 AND deleted_at IS NULL ORDER BY order_idx ASC`
 	rows, err := r.db.Query(ctx, query, moduleID)
 	if err != nil {
 		return nil, fmt.Errorf("query lessons: %w", err)
@@ -622,7 +858,12 @@ func (r *coursesRepo) GetLessonsByModuleID(ctx context.Context, moduleID int64)
 }
 
 func (r *coursesRepo) GetLessonByID(ctx context.Context, lessonID int64) (*models.Lesson, error) {
-	const query = `SELECT id, module_id, order_idx, name FROM lessons WHERE id = $1`
+	const query = `
+		SELECT l.id, l.module_id, l.order_idx, l.name
+		FROM lessons l
+		INNER JOIN modules m ON m.id = l.module_id
+		INNER JOIN courses c ON c.id = m.course_id
+		WHERE l.id = $1 AND l.deleted_at IS NULL AND m.deleted_at IS NULL AND c.deleted_at IS NULL`
 	var lesson models.Lesson
 	err := r.db.QueryRow(ctx, query, lessonID).Scan(&lesson.ID, &lesson.ModuleID, &lesson.OrderIdx, &lesson.Name)
 	if err != nil {
@@ -636,13 +877,21 @@ func (r *coursesRepo) GetLessonByID(ctx context.Context, lessonID int64) (*model
 
 /* ===================== Blocks ===================== */
 
-type createBaseBlockParams struct {
+type CreateBaseBlockParams struct {
 	LessonID int64
 	OrderIdx *int
 }
 
-func createBaseBlock(ctx context.Context, tx pgx.Tx, params createBaseBlockParams) (int64, error) {
-	const maxQuery = `SELECT COALESCE(MAX(order_idx), 0) FROM blocks WHERE lesson_id = $1`
+func createBaseBlock(ctx context.Context, tx pgx.Tx, params CreateBaseBlockParams, blockType models.BlockType) (int64, error) {
+	const lockQuery = `SELECT id FROM lessons WHERE id = $1 AND deleted_at IS NULL FOR UPDATE`
+	if err := tx.QueryRow(ctx, lockQuery, params.LessonID).Scan(new(int64)); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, ErrLessonNotFound
+		}
+		return 0, fmt.Errorf("lock lesson: %w", err)
+	}
+
+	const maxQuery = `SELECT COALESCE(MAX(order_idx), 0) FROM blocks WHERE lesson_id = $1 AND deleted_at IS NULL`
 	var maxOrderIdx int
 	if err := tx.QueryRow(ctx, maxQuery, params.LessonID).Scan(&maxOrderIdx); err != nil {
 		return 0, fmt.Errorf("get max order_idx: %w", err)
@@ -652,15 +901,15 @@ func createBaseBlock(ctx context.Context, tx pgx.Tx, params createBaseBlockParam
 	if params.OrderIdx == nil || *params.OrderIdx > maxOrderIdx {
 		newOrderIdx = maxOrderIdx + 1
 	} else {
-		newOrderIdx = max(*params.OrderIdx, 0)
-		const shiftQuery = `UPDATE blocks SET order_idx = order_idx + 1 WHERE lesson_id = $1 AND order_idx >= $2`
+		newOrderIdx = max(*params.OrderIdx, 1)
+		const shiftQuery = `UPDATE blocks SET order_idx = order_idx + 1 WHERE lesson_id = $1 AND order_idx >= $2 AND deleted_at IS NULL`
 		if _, err := tx.Exec(ctx, shiftQuery, params.LessonID, newOrderIdx); err != nil {
 			return 0, fmt.Errorf("shift order_idx: %w", err)
 		}
 	}
 	const insertBlockQuery = `INSERT INTO blocks (lesson_id, order_idx, type) VALUES ($1, $2, $3) RETURNING id`
 	var blockID int64
-	if err := tx.QueryRow(ctx, insertBlockQuery, params.LessonID, newOrderIdx, models.BlockTypeVideo).Scan(&blockID); err != nil {
+	if err := tx.QueryRow(ctx, insertBlockQuery, params.LessonID, newOrderIdx, blockType).Scan(&blockID); err != nil {
 		var pqErr *pgconn.PgError
 		if errors.As(err, &pqErr) && pqErr.Code == "23503" {
 			return 0, ErrLessonNotFound
@@ -672,7 +921,7 @@ func createBaseBlock(ctx context.Context, tx pgx.Tx, params createBaseBlockParam
 }
 
 type CreateVideoBlockParams struct {
-	createBaseBlockParams
+	CreateBaseBlockParams
 	FileID int64
 }
 
@@ -683,7 +932,7 @@ func (r *coursesRepo) CreateVideoBlock(ctx context.Context, params CreateVideoBl
 	}
 	defer tx.Rollback(ctx)
 
-	blockID, err := createBaseBlock(ctx, tx, params.createBaseBlockParams)
+	blockID, err := createBaseBlock(ctx, tx, params.CreateBaseBlockParams, models.BlockTypeVideo)
 	if err != nil {
 		return 0, err
 	}
@@ -704,7 +953,7 @@ func (r *coursesRepo) CreateVideoBlock(ctx context.Context, params CreateVideoBl
 }
 
 type CreateTextBlockParams struct {
-	createBaseBlockParams
+	CreateBaseBlockParams
 	Content string
 }
 
@@ -715,7 +964,7 @@ func (r *coursesRepo) CreateTextBlock(ctx context.Context, params CreateTextBloc
 	}
 	defer tx.Rollback(ctx)
 
-	blockID, err := createBaseBlock(ctx, tx, params.createBaseBlockParams)
+	blockID, err := createBaseBlock(ctx, tx, params.CreateBaseBlockParams, models.BlockTypeText)
 	if err != nil {
 		return 0, err
 	}
@@ -731,118 +980,821 @@ func (r *coursesRepo) CreateTextBlock(ctx context.Context, params CreateTextBloc
 	return blockID, nil
 }
 
-type UpdateBlockOrderIdxParams struct {
-	BlockID  int64
-	OrderIdx int
+type CreateScormBlockParams struct {
+	CreateBaseBlockParams
+	FileID int64
 }
 
-func (r *coursesRepo) UpdateBlockOrderIdx(ctx context.Context, params UpdateBlockOrderIdxParams) error {
+func (r *coursesRepo) CreateScormBlock(ctx context.Context, params CreateScormBlockParams) (int64, error) {
 	tx, err := r.db.Begin(ctx)
 	if err != nil {
-		return fmt.Errorf("begin tx: %w", err)
+		return 0, fmt.Errorf("begin tx: %w", err)
 	}
 	defer tx.Rollback(ctx)
 
-	const getQuery = `SELECT lesson_id, order_idx FROM blocks WHERE id = $1`
-	var lessonID int64
-	var oldIdx int
-	if err := tx.QueryRow(ctx, getQuery, params.BlockID).Scan(&lessonID, &oldIdx); err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			return ErrBlockNotFound
+	blockID, err := createBaseBlock(ctx, tx, params.CreateBaseBlockParams, models.BlockTypeSCORM)
+	if err != nil {
+		return 0, err
+	}
+
+	const insertScormQuery = `INSERT INTO scorm_blocks (id, file_id) VALUES ($1, $2)`
+	if _, err := tx.Exec(ctx, insertScormQuery, blockID, params.FileID); err != nil {
+		var pqErr *pgconn.PgError
+		if errors.As(err, &pqErr) && pqErr.Code == "23503" {
+			return 0, ErrFileNotFound
 		}
-		return fmt.Errorf("select block: %w", err)
+		return 0, fmt.Errorf("insert into scorm_blocks: %w", err)
 	}
 
-	const maxQuery = `SELECT COALESCE(MAX(order_idx), 0) FROM blocks WHERE lesson_id = $1`
-	var maxIdx int
-	if err := tx.QueryRow(ctx, maxQuery, lessonID).Scan(&maxIdx); err != nil {
-		return fmt.Errorf("select max order_idx: %w", err)
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("commit: %w", err)
 	}
+	return blockID, nil
+}
 
-	newIdx := params.OrderIdx
-	switch {
-	case newIdx < 0:
-		newIdx = 0
-	case newIdx > maxIdx:
-		newIdx = maxIdx
+func (r *coursesRepo) GetScormBlock(ctx context.Context, blockID int64) (models.ScormBlock, error) {
+	const query = `
+		SELECT b.id, b.lesson_id, b.order_idx, b.type, sb.file_id
+		FROM blocks b
+		INNER JOIN scorm_blocks sb ON sb.id = b.id
+		WHERE b.id = $1 AND b.deleted_at IS NULL
+	`
+	var b models.ScormBlock
+	err := r.db.QueryRow(ctx, query, blockID).Scan(&b.ID, &b.LessonID, &b.OrderIdx, &b.Type, &b.FileID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.ScormBlock{}, ErrBlockNotFound
+		}
+		return models.ScormBlock{}, fmt.Errorf("select scorm block: %w", err)
 	}
+	return b, nil
+}
 
-	if newIdx == oldIdx {
-		return tx.Commit(ctx)
+type CreateQuizBlockParams struct {
+	CreateBaseBlockParams
+	Quiz quiz.Quiz
+}
+
+func (r *coursesRepo) CreateQuizBlock(ctx context.Context, params CreateQuizBlockParams) (int64, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("begin tx: %w", err)
 	}
+	defer tx.Rollback(ctx)
 
-	const updateOrderQuery = `
-		UPDATE blocks
-		SET order_idx = CASE
-			WHEN id = $1 THEN $2
-			WHEN $2 < $3 AND lesson_id = $4 AND order_idx > $2 AND order_idx <= $3 THEN order_idx - 1
-			WHEN $2 > $3 AND lesson_id = $4 AND order_idx >= $3 AND order_idx < $2 THEN order_idx + 1
-			ELSE order_idx
-		END
-		WHERE lesson_id = $4
-		  AND (id = $1 OR (order_idx BETWEEN LEAST($2, $3) AND GREATEST($2, $3)))
-	`
-	if _, err := tx.Exec(ctx, updateOrderQuery, params.BlockID, oldIdx, newIdx, lessonID); err != nil {
-		return fmt.Errorf("update block order_idx: %w", err)
+	blockID, err := createBaseBlock(ctx, tx, params.CreateBaseBlockParams, models.BlockTypeQuiz)
+	if err != nil {
+		return 0, err
+	}
+	if err := insertQuizBlock(ctx, tx, blockID, params.Quiz); err != nil {
+		return 0, err
 	}
 
 	if err := tx.Commit(ctx); err != nil {
-		return fmt.Errorf("commit tx: %w", err)
+		return 0, fmt.Errorf("commit: %w", err)
 	}
+	return blockID, nil
+}
 
+func insertQuizBlock(ctx context.Context, tx pgx.Tx, blockID int64, q quiz.Quiz) error {
+	const query = `INSERT INTO quiz_blocks (id, passing_score, max_attempts, questions) VALUES ($1, $2, $3, $4)`
+	if _, err := tx.Exec(ctx, query, blockID, q.PassingScore, q.MaxAttempts, q.Questions); err != nil {
+		return fmt.Errorf("insert into quiz_blocks: %w", err)
+	}
 	return nil
 }
 
-type RemoveBlockParams struct {
+func (r *coursesRepo) GetQuizBlock(ctx context.Context, blockID int64) (models.QuizBlock, error) {
+	const query = `
+		SELECT b.id, b.lesson_id, b.order_idx, b.type, qb.passing_score, qb.max_attempts, qb.questions
+		FROM blocks b
+		INNER JOIN quiz_blocks qb ON qb.id = b.id
+		WHERE b.id = $1 AND b.deleted_at IS NULL
+	`
+	var b models.QuizBlock
+	err := r.db.QueryRow(ctx, query, blockID).Scan(&b.ID, &b.LessonID, &b.OrderIdx, &b.Type,
+		&b.Quiz.PassingScore, &b.Quiz.MaxAttempts, &b.Quiz.Questions)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.QuizBlock{}, ErrBlockNotFound
+		}
+		return models.QuizBlock{}, fmt.Errorf("select quiz block: %w", err)
+	}
+	return b, nil
+}
+
+type UpdateQuizBlockParams struct {
 	BlockID int64
+	Quiz    quiz.Quiz
+	Author  RevisionAuthor
 }
 
-func (r *coursesRepo) RemoveBlock(ctx context.Context, params RemoveBlockParams) error {
-	const query = `DELETE FROM blocks WHERE id = $1`
-	cmdTag, err := r.db.Exec(ctx, query, params.BlockID)
+// UpdateQuizBlock заменяет тест целиком. Правка хранит старый и новый тест в JSON.
+// Сохранённые попытки не пересчитываются.
+func (r *coursesRepo) UpdateQuizBlock(ctx context.Context, params UpdateQuizBlockParams) error {
+	tx, err := r.db.Begin(ctx)
 	if err != nil {
-		return fmt.Errorf("delete block: %w", err)
+		return fmt.Errorf("begin tx: %w", err)
 	}
-	if cmdTag.RowsAffected() == 0 {
-		return ErrBlockNotFound
+	defer tx.Rollback(ctx)
+
+	const selectQuery = `
+		SELECT qb.passing_score, qb.max_attempts, qb.questions
+		FROM quiz_blocks qb
+		INNER JOIN blocks b ON b.id = qb.id
+		WHERE b.id = $1 AND b.deleted_at IS NULL
+		FOR UPDATE OF qb
+	`
+	var old quiz.Quiz
+	if err := tx.QueryRow(ctx, selectQuery, params.BlockID).Scan(&old.PassingScore, &old.MaxAttempts, &old.Questions); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrBlockNotFound
+		}
+		return fmt.Errorf("select quiz block: %w", err)
+	}
+
+	const updateQuery = `UPDATE quiz_blocks SET passing_score = $1, max_attempts = $2, questions = $3 WHERE id = $4`
+	if _, err := tx.Exec(ctx, updateQuery, params.Quiz.PassingScore, params.Quiz.MaxAttempts, params.Quiz.Questions, params.BlockID); err != nil {
+		return fmt.Errorf("update quiz block: %w", err)
+	}
+
+	oldValue, err := json.Marshal(old)
+	if err != nil {
+		return fmt.Errorf("marshal old quiz: %w", err)
+	}
+	value, err := json.Marshal(params.Quiz)
+	if err != nil {
+		return fmt.Errorf("marshal quiz: %w", err)
+	}
+	err = insertRevision(ctx, tx, insertRevisionParams{
+		Author:   params.Author,
+		ItemType: models.TrashItemBlock,
+		ItemID:   params.BlockID,
+		Field:    models.RevisionFieldQuiz,
+		OldValue: string(oldValue),
+		Value:    string(value),
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit tx: %w", err)
 	}
 	return nil
 }
 
-func (r *coursesRepo) GetBlocksByLessonID(ctx context.Context, lessonID int64) ([]any, error) {
-	_, err := r.GetLessonByID(ctx, lessonID)
+type CreateAssignmentBlockParams struct {
+	CreateBaseBlockParams
+	Assignment assignment.Assignment
+}
+
+func (r *coursesRepo) CreateAssignmentBlock(ctx context.Context, params CreateAssignmentBlockParams) (int64, error) {
+	tx, err := r.db.Begin(ctx)
 	if err != nil {
-		if errors.Is(err, ErrLessonNotFound) {
-			return nil, ErrLessonNotFound
-		}
-		return nil, fmt.Errorf("check lesson existence: %w", err)
+		return 0, fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	blockID, err := createBaseBlock(ctx, tx, params.CreateBaseBlockParams, models.BlockTypeAssignment)
+	if err != nil {
+		return 0, err
 	}
+	if err := insertAssignmentBlock(ctx, tx, blockID, params.Assignment); err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("commit: %w", err)
+	}
+	return blockID, nil
+}
 
+func insertAssignmentBlock(ctx context.Context, tx pgx.Tx, blockID int64, a assignment.Assignment) error {
 	const query = `
-        SELECT 
-            b.id, b.lesson_id, b.order_idx, b.type,
-            vb.file_id,
-            tb.content
-        FROM blocks b
-        LEFT JOIN video_blocks vb ON b.id = vb.id
-        LEFT JOIN text_blocks tb ON b.id = tb.id
-        WHERE b.lesson_id = $1
-        ORDER BY b.order_idx ASC
-    `
+		INSERT INTO assignment_blocks (id, instructions, allow_text, allow_files, passing_score, rubric)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`
+	if _, err := tx.Exec(ctx, query, blockID, a.Instructions, a.AllowText, a.AllowFiles, a.PassingScore, a.Rubric); err != nil {
+		return fmt.Errorf("insert into assignment_blocks: %w", err)
+	}
+	return nil
+}
 
-	rows, err := r.db.Query(ctx, query, lessonID)
+func (r *coursesRepo) GetAssignmentBlock(ctx context.Context, blockID int64) (models.AssignmentBlock, error) {
+	const query = `
+		SELECT b.id, b.lesson_id, b.order_idx, b.type, ab.instructions, ab.allow_text, ab.allow_files, ab.passing_score, ab.rubric
+		FROM blocks b
+		INNER JOIN assignment_blocks ab ON ab.id = b.id
+		WHERE b.id = $1 AND b.deleted_at IS NULL
+	`
+	var b models.AssignmentBlock
+	a := &b.Assignment
+	err := r.db.QueryRow(ctx, query, blockID).Scan(&b.ID, &b.LessonID, &b.OrderIdx, &b.Type,
+		&a.Instructions, &a.AllowText, &a.AllowFiles, &a.PassingScore, &a.Rubric)
 	if err != nil {
-		return nil, fmt.Errorf("query blocks: %w", err)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.AssignmentBlock{}, ErrBlockNotFound
+		}
+		return models.AssignmentBlock{}, fmt.Errorf("select assignment block: %w", err)
 	}
-	defer rows.Close()
+	return b, nil
+}
 
-	var blocks []any
-	for rows.Next() {
-		var (
-			base    models.BaseBlock
-			fileID  *int64
-			content *string
-		)
+type UpdateAssignmentBlockParams struct {
+	BlockID    int64
+	Assignment assignment.Assignment
+	Author     RevisionAuthor
+}
+
+// UpdateAssignmentBlock заменяет задание целиком. Правка хранит старое и новое задание в JSON.
+// Оценённые работы не пересчитываются.
+func (r *coursesRepo) UpdateAssignmentBlock(ctx context.Context, params UpdateAssignmentBlockParams) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	const selectQuery = `
+		SELECT ab.instructions, ab.allow_text, ab.allow_files, ab.passing_score, ab.rubric
+		FROM assignment_blocks ab
+		INNER JOIN blocks b ON b.id = ab.id
+		WHERE b.id = $1 AND b.deleted_at IS NULL
+		FOR UPDATE OF ab
+	`
+	var old assignment.Assignment
+	err = tx.QueryRow(ctx, selectQuery, params.BlockID).Scan(&old.Instructions, &old.AllowText, &old.AllowFiles, &old.PassingScore, &old.Rubric)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrBlockNotFound
+		}
+		return fmt.Errorf("select assignment block: %w", err)
+	}
+
+	a := params.Assignment
+	const updateQuery = `
+		UPDATE assignment_blocks
+		SET instructions = $1, allow_text = $2, allow_files = $3, passing_score = $4, rubric = $5
+		WHERE id = $6
+	`
+	if _, err := tx.Exec(ctx, updateQuery, a.Instructions, a.AllowText, a.AllowFiles, a.PassingScore, a.Rubric, params.BlockID); err != nil {
+		return fmt.Errorf("update assignment block: %w", err)
+	}
+
+	oldValue, err := json.Marshal(old)
+	if err != nil {
+		return fmt.Errorf("marshal old assignment: %w", err)
+	}
+	value, err := json.Marshal(a)
+	if err != nil {
+		return fmt.Errorf("marshal assignment: %w", err)
+	}
+	err = insertRevision(ctx, tx, insertRevisionParams{
+		Author:   params.Author,
+		ItemType: models.TrashItemBlock,
+		ItemID:   params.BlockID,
+		Field:    models.RevisionFieldAssignment,
+		OldValue: string(oldValue),
+		Value:    string(value),
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit tx: %w", err)
+	}
+	return nil
+}
+
+type CreateCodeExerciseBlockParams struct {
+	CreateBaseBlockParams
+	Exercise codeexercise.Exercise
+}
+
+func (r *coursesRepo) CreateCodeExerciseBlock(ctx context.Context, params CreateCodeExerciseBlockParams) (int64, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	blockID, err := createBaseBlock(ctx, tx, params.CreateBaseBlockParams, models.BlockTypeCodeExercise)
+	if err != nil {
+		return 0, err
+	}
+	if err := insertCodeExerciseBlock(ctx, tx, blockID, params.Exercise); err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("commit: %w", err)
+	}
+	return blockID, nil
+}
+
+func insertCodeExerciseBlock(ctx context.Context, tx pgx.Tx, blockID int64, e codeexercise.Exercise) error {
+	const query = `
+		INSERT INTO code_exercise_blocks (id, language, starter_code, time_limit_ms, memory_limit_mb, passing_score, tests)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`
+	if _, err := tx.Exec(ctx, query, blockID, e.Language, e.StarterCode, e.TimeLimitMs, e.MemoryLimitMB, e.PassingScore, e.Tests); err != nil {
+		return fmt.Errorf("insert into code_exercise_blocks: %w", err)
+	}
+	return nil
+}
+
+func (r *coursesRepo) GetCodeExerciseBlock(ctx context.Context, blockID int64) (models.CodeExerciseBlock, error) {
+	const query = `
+		SELECT b.id, b.lesson_id, b.order_idx, b.type,
+			ce.language, ce.starter_code, ce.time_limit_ms, ce.memory_limit_mb, ce.passing_score, ce.tests
+		FROM blocks b
+		INNER JOIN code_exercise_blocks ce ON ce.id = b.id
+		WHERE b.id = $1 AND b.deleted_at IS NULL
+	`
+	var b models.CodeExerciseBlock
+	e := &b.Exercise
+	err := r.db.QueryRow(ctx, query, blockID).Scan(&b.ID, &b.LessonID, &b.OrderIdx, &b.Type,
+		&e.Language, &e.StarterCode, &e.TimeLimitMs, &e.MemoryLimitMB, &e.PassingScore, &e.Tests)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.CodeExerciseBlock{}, ErrBlockNotFound
+		}
+		return models.CodeExerciseBlock{}, fmt.Errorf("select code exercise block: %w", err)
+	}
+	return b, nil
+}
+
+type UpdateCodeExerciseBlockParams struct {
+	BlockID  int64
+	Exercise codeexercise.Exercise
+	Author   RevisionAuthor
+}
+
+// UpdateCodeExerciseBlock заменяет упражнение целиком. Правка хранит старое и новое упражнение в JSON.
+// Прошлые решения не перепроверяются.
+func (r *coursesRepo) UpdateCodeExerciseBlock(ctx context.Context, params UpdateCodeExerciseBlockParams) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	const selectQuery = `
+		SELECT ce.language, ce.starter_code, ce.time_limit_ms, ce.memory_limit_mb, ce.passing_score, ce.tests
+		FROM code_exercise_blocks ce
+		INNER JOIN blocks b ON b.id = ce.id
+		WHERE b.id = $1 AND b.deleted_at IS NULL
+		FOR UPDATE OF ce
+	`
+	var old codeexercise.Exercise
+	err = tx.QueryRow(ctx, selectQuery, params.BlockID).Scan(&old.Language, &old.StarterCode, &old.TimeLimitMs, &old.MemoryLimitMB, &old.PassingScore, &old.Tests)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrBlockNotFound
+		}
+		return fmt.Errorf("select code exercise block: %w", err)
+	}
+
+	e := params.Exercise
+	const updateQuery = `
+		UPDATE code_exercise_blocks
+		SET language = $1, starter_code = $2, time_limit_ms = $3, memory_limit_mb = $4, passing_score = $5, tests = $6
+		WHERE id = $7
+	`
+	if _, err := tx.Exec(ctx, updateQuery, e.Language, e.StarterCode, e.TimeLimitMs, e.MemoryLimitMB, e.PassingScore, e.Tests, params.BlockID); err != nil {
+		return fmt.Errorf("update code exercise block: %w", err)
+	}
+
+	oldValue, err := json.Marshal(old)
+	if err != nil {
+		return fmt.Errorf("marshal old code exercise: %w", err)
+	}
+	value, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("marshal code exercise: %w", err)
+	}
+	err = insertRevision(ctx, tx, insertRevisionParams{
+		Author:   params.Author,
+		ItemType: models.TrashItemBlock,
+		ItemID:   params.BlockID,
+		Field:    models.RevisionFieldCodeExercise,
+		OldValue: string(oldValue),
+		Value:    string(value),
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit tx: %w", err)
+	}
+	return nil
+}
+
+type CreateImageBlockParams struct {
+	CreateBaseBlockParams
+	FileID  int64
+	AltText string
+	Caption string
+}
+
+func (r *coursesRepo) CreateImageBlock(ctx context.Context, params CreateImageBlockParams) (int64, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	blockID, err := createBaseBlock(ctx, tx, params.CreateBaseBlockParams, models.BlockTypeImage)
+	if err != nil {
+		return 0, err
+	}
+	if err := insertImageBlock(ctx, tx, blockID, params.FileID, params.AltText, params.Caption); err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("commit: %w", err)
+	}
+	return blockID, nil
+}
+
+func insertImageBlock(ctx context.Context, tx pgx.Tx, blockID, fileID int64, altText, caption string) error {
+	const query = `INSERT INTO image_blocks (id, file_id, alt_text, caption) VALUES ($1, $2, $3, $4)`
+	if _, err := tx.Exec(ctx, query, blockID, fileID, altText, caption); err != nil {
+		var pqErr *pgconn.PgError
+		if errors.As(err, &pqErr) && pqErr.Code == "23503" {
+			return ErrFileNotFound
+		}
+		return fmt.Errorf("insert into image_blocks: %w", err)
+	}
+	return nil
+}
+
+func (r *coursesRepo) GetImageBlock(ctx context.Context, blockID int64) (models.ImageBlock, error) {
+	const query = `
+		SELECT b.id, b.lesson_id, b.order_idx, b.type, ib.file_id, ib.alt_text, ib.caption
+		FROM blocks b
+		INNER JOIN image_blocks ib ON ib.id = b.id
+		WHERE b.id = $1 AND b.deleted_at IS NULL
+	`
+	var b models.ImageBlock
+	err := r.db.QueryRow(ctx, query, blockID).Scan(&b.ID, &b.LessonID, &b.OrderIdx, &b.Type, &b.FileID, &b.AltText, &b.Caption)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.ImageBlock{}, ErrBlockNotFound
+		}
+		return models.ImageBlock{}, fmt.Errorf("select image block: %w", err)
+	}
+	return b, nil
+}
+
+type CreateAttachmentBlockParams struct {
+	CreateBaseBlockParams
+	FileID int64
+	Title  string
+}
+
+func (r *coursesRepo) CreateAttachmentBlock(ctx context.Context, params CreateAttachmentBlockParams) (int64, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	blockID, err := createBaseBlock(ctx, tx, params.CreateBaseBlockParams, models.BlockTypeAttachment)
+	if err != nil {
+		return 0, err
+	}
+	if err := insertAttachmentBlock(ctx, tx, blockID, params.FileID, params.Title); err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("commit: %w", err)
+	}
+	return blockID, nil
+}
+
+func insertAttachmentBlock(ctx context.Context, tx pgx.Tx, blockID, fileID int64, title string) error {
+	const query = `INSERT INTO attachment_blocks (id, file_id, title) VALUES ($1, $2, $3)`
+	if _, err := tx.Exec(ctx, query, blockID, fileID, title); err != nil {
+		var pqErr *pgconn.PgError
+		if errors.As(err, &pqErr) && pqErr.Code == "23503" {
+			return ErrFileNotFound
+		}
+		return fmt.Errorf("insert into attachment_blocks: %w", err)
+	}
+	return nil
+}
+
+func (r *coursesRepo) GetAttachmentBlock(ctx context.Context, blockID int64) (models.AttachmentBlock, error) {
+	const query = `
+		SELECT b.id, b.lesson_id, b.order_idx, b.type, ab.file_id, ab.title
+		FROM blocks b
+		INNER JOIN attachment_blocks ab ON ab.id = b.id
+		WHERE b.id = $1 AND b.deleted_at IS NULL
+	`
+	var b models.AttachmentBlock
+	err := r.db.QueryRow(ctx, query, blockID).Scan(&b.ID, &b.LessonID, &b.OrderIdx, &b.Type, &b.FileID, &b.Title)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.AttachmentBlock{}, ErrBlockNotFound
+		}
+		return models.AttachmentBlock{}, fmt.Errorf("select attachment block: %w", err)
+	}
+	return b, nil
+}
+
+type CreateEmbedBlockParams struct {
+	CreateBaseBlockParams
+	// Embed должен быть получен из media.ResolveEmbed
+	Embed media.Embed
+	Title string
+}
+
+func (r *coursesRepo) CreateEmbedBlock(ctx context.Context, params CreateEmbedBlockParams) (int64, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	blockID, err := createBaseBlock(ctx, tx, params.CreateBaseBlockParams, models.BlockTypeEmbed)
+	if err != nil {
+		return 0, err
+	}
+	if err := insertEmbedBlock(ctx, tx, blockID, params.Embed, params.Title); err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("commit: %w", err)
+	}
+	return blockID, nil
+}
+
+func insertEmbedBlock(ctx context.Context, tx pgx.Tx, blockID int64, e media.Embed, title string) error {
+	const query = `INSERT INTO embed_blocks (id, provider, url, title) VALUES ($1, $2, $3, $4)`
+	if _, err := tx.Exec(ctx, query, blockID, e.Provider, e.URL, title); err != nil {
+		return fmt.Errorf("insert into embed_blocks: %w", err)
+	}
+	return nil
+}
+
+type UpdateTextBlockParams struct {
+	BlockID int64
+	Content string
+	Author  RevisionAuthor
+}
+
+func (r *coursesRepo) UpdateTextBlock(ctx context.Context, params UpdateTextBlockParams) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	const selectQuery = `
+		SELECT tb.content
+		FROM text_blocks tb
+		INNER JOIN blocks b ON b.id = tb.id
+		WHERE b.id = $1 AND b.deleted_at IS NULL
+		FOR UPDATE OF tb
+	`
+	var oldContent string
+	if err := tx.QueryRow(ctx, selectQuery, params.BlockID).Scan(&oldContent); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrBlockNotFound
+		}
+		return fmt.Errorf("select text block: %w", err)
+	}
+
+	const updateQuery = `UPDATE text_blocks SET content = $1 WHERE id = $2`
+	if _, err := tx.Exec(ctx, updateQuery, params.Content, params.BlockID); err != nil {
+		return fmt.Errorf("update text block: %w", err)
+	}
+
+	err = insertRevision(ctx, tx, insertRevisionParams{
+		Author:   params.Author,
+		ItemType: models.TrashItemBlock,
+		ItemID:   params.BlockID,
+		Field:    models.RevisionFieldContent,
+		OldValue: oldContent,
+		Value:    params.Content,
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit tx: %w", err)
+	}
+	return nil
+}
+
+type UpdateBlockOrderIdxParams struct {
+	BlockID  int64
+	OrderIdx int
+	Author   RevisionAuthor
+}
+
+func (r *coursesRepo) UpdateBlockOrderIdx(ctx context.Context, params UpdateBlockOrderIdxParams) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	lessonID, oldIdx, err := lockOrderItem(ctx, tx, blockOrderTables, params.BlockID)
+	if err != nil {
+		return err
+	}
+
+	const maxQuery = `SELECT COALESCE(MAX(order_idx), 0) FROM blocks WHERE lesson_id = $1 AND deleted_at IS NULL`
+	var maxIdx int
+	if err := tx.QueryRow(ctx, maxQuery, lessonID).Scan(&maxIdx); err != nil {
+		return fmt.Errorf("select max order_idx: %w", err)
+	}
+
+	newIdx := params.OrderIdx
+	switch {
+	case newIdx < 1:
+		newIdx = 1
+	case newIdx > maxIdx:
+		newIdx = maxIdx
+	}
+
+	if newIdx == oldIdx {
+		return tx.Commit(ctx)
+	}
+
+	const updateOrderQuery = `
+		UPDATE blocks
+		SET order_idx = CASE
+			WHEN id = $1 THEN $3
+			WHEN $2 < $3 AND lesson_id = $4 AND order_idx > $2 AND order_idx <= $3 THEN order_idx - 1
+			WHEN $2 > $3 AND lesson_id = $4 AND order_idx >= $3 AND order_idx < $2 THEN order_idx + 1
+			ELSE order_idx
+		END
+		WHERE lesson_id = $4
+		  AND deleted_at IS NULL
+		  AND (id = $1 OR (order_idx BETWEEN LEAST($2, $3) AND GREATEST($2, $3)))
+	`
+	if _, err := tx.Exec(ctx, updateOrderQuery, params.BlockID, oldIdx, newIdx, lessonID); err != nil {
+		if isOrderConflict(err) {
+			return ErrOrderConflict
+		}
+		return fmt.Errorf("update block order_idx: %w", err)
+	}
+
+	err = insertRevision(ctx, tx, insertRevisionParams{
+		Author:   params.Author,
+		ItemType: models.TrashItemBlock,
+		ItemID:   params.BlockID,
+		Field:    models.RevisionFieldOrderIdx,
+		OldValue: strconv.FormatInt(int64(oldIdx), 10),
+		Value:    strconv.FormatInt(int64(newIdx), 10),
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit tx: %w", err)
+	}
+
+	return nil
+}
+
+type ReorderBlocksParams struct {
+	LessonID int64
+	// BlockIDs - все элементы в новом порядке
+	BlockIDs []int64
+	Author   RevisionAuthor
+}
+
+func (r *coursesRepo) ReorderBlocks(ctx context.Context, params ReorderBlocksParams) error {
+	return r.reorderItems(ctx, blockOrderTables, params.LessonID, params.BlockIDs, params.Author)
+}
+
+type MoveBlockParams struct {
+	BlockID  int64
+	LessonID int64
+	// OrderIdx - позиция в новом уроке, nil - в конец
+	OrderIdx *int
+	Author   RevisionAuthor
+}
+
+// MoveBlock переносит блок в другой урок
+func (r *coursesRepo) MoveBlock(ctx context.Context, params MoveBlockParams) (MovedItem, error) {
+	return r.moveItem(ctx, blockOrderTables, params.BlockID, params.LessonID, params.OrderIdx, params.Author)
+}
+
+type RemoveBlockParams struct {
+	BlockID   int64
+	DeletedBy int64
+}
+
+// RemoveBlock перемещает блок в корзину и закрывает образовавшийся промежуток в порядке
+func (r *coursesRepo) RemoveBlock(ctx context.Context, params RemoveBlockParams) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// родитель блокируется раньше элемента, как и в остальных изменениях порядка
+	lessonID, orderIdx, err := lockOrderItem(ctx, tx, blockOrderTables, params.BlockID)
+	if err != nil {
+		return err
+	}
+
+	const removeQuery = `UPDATE blocks SET deleted_at = $1, deleted_by = $2 WHERE id = $3`
+	if _, err := tx.Exec(ctx, removeQuery, time.Now().UTC(), params.DeletedBy, params.BlockID); err != nil {
+		return fmt.Errorf("delete block: %w", err)
+	}
+
+	const closeGapQuery = `
+		UPDATE blocks SET order_idx = order_idx - 1
+		WHERE lesson_id = $1 AND order_idx > $2 AND deleted_at IS NULL
+	`
+	if _, err := tx.Exec(ctx, closeGapQuery, lessonID, orderIdx); err != nil {
+		return fmt.Errorf("close order gap: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit tx: %w", err)
+	}
+	return nil
+}
+
+func (r *coursesRepo) GetBlocksByLessonID(ctx context.Context, lessonID int64) ([]any, error) {
+	_, err := r.GetLessonByID(ctx, lessonID)
+	if err != nil {
+		if errors.Is(err, ErrLessonNotFound) {
+			return nil, ErrLessonNotFound
+		}
+		return nil, fmt.Errorf("check lesson existence: %w", err)
+	}
+
+	const query = `
+        SELECT 
+            b.id, b.lesson_id, b.order_idx, b.type,
+            COALESCE(vb.file_id, sb.file_id, ib.file_id, atb.file_id),
+            tb.content,
+            qb.passing_score, qb.max_attempts, qb.questions,
+            ab.instructions, ab.allow_text, ab.allow_files, ab.passing_score, ab.rubric,
+            ce.language, ce.starter_code, ce.time_limit_ms, ce.memory_limit_mb, ce.passing_score, ce.tests,
+            ib.alt_text, ib.caption, COALESCE(atb.title, eb.title), eb.provider, eb.url
+        FROM blocks b
+        LEFT JOIN video_blocks vb ON b.id = vb.id
+        LEFT JOIN scorm_blocks sb ON b.id = sb.id
+        LEFT JOIN text_blocks tb ON b.id = tb.id
+        LEFT JOIN quiz_blocks qb ON b.id = qb.id
+        LEFT JOIN assignment_blocks ab ON b.id = ab.id
+        LEFT JOIN code_exercise_blocks ce ON b.id = ce.id
+        LEFT JOIN image_blocks ib ON b.id = ib.id
+        LEFT JOIN attachment_blocks atb ON b.id = atb.id
+        LEFT JOIN embed_blocks eb ON b.id = eb.id
+        WHERE b.lesson_id = $1 AND b.deleted_at IS NULL
+        ORDER BY b.order_idx ASC
+    `
+
+	rows, err := r.db.Query(ctx, query, lessonID)
+	if err != nil {
+		return nil, fmt.Errorf("query blocks: %w", err)
+	}
+	defer rows.Close()
+
+	var blocks []any
+	for rows.Next() {
+		var (
+			base         models.BaseBlock
+			fileID       *int64
+			content      *string
+			passingScore *float64
+			maxAttempts  *int
+			questions    []quiz.Question
+			instructions *string
+			allowText    *bool
+			allowFiles   *bool
+			assignScore  *float64
+			rubric       []assignment.Criterion
+			language     *string
+			starterCode  *string
+			timeLimit    *int
+			memoryLimit  *int
+			codeScore    *float64
+			tests        []codeexercise.TestCase
+			altText      *string
+			caption      *string
+			title        *string
+			provider     *string
+			embedURL     *string
+		)
 
 		err := rows.Scan(
 			&base.ID,
@@ -851,38 +1803,1118 @@ func (r *coursesRepo) GetBlocksByLessonID(ctx context.Context, lessonID int64) (
 			&base.Type,
 			&fileID,
 			&content,
+			&passingScore,
+			&maxAttempts,
+			&questions,
+			&instructions,
+			&allowText,
+			&allowFiles,
+			&assignScore,
+			&rubric,
+			&language,
+			&starterCode,
+			&timeLimit,
+			&memoryLimit,
+			&codeScore,
+			&tests,
+			&altText,
+			&caption,
+			&title,
+			&provider,
+			&embedURL,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("scan block: %w", err)
 		}
 
-		switch base.Type {
-		case models.BlockTypeVideo:
-			if fileID == nil {
-				return nil, fmt.Errorf("video block %d has no file_id", base.ID)
+		switch base.Type {
+		case models.BlockTypeVideo:
+			if fileID == nil {
+				return nil, fmt.Errorf("video block %d has no file_id", base.ID)
+			}
+			blocks = append(blocks, &models.VideoBlock{
+				BaseBlock: base,
+				FileID:    *fileID,
+			})
+
+		case models.BlockTypeText:
+			if content == nil {
+				return nil, fmt.Errorf("text block %d has no content", base.ID)
+			}
+			blocks = append(blocks, &models.TextBlock{
+				BaseBlock: base,
+				Content:   *content,
+			})
+
+		case models.BlockTypeSCORM:
+			if fileID == nil {
+				return nil, fmt.Errorf("scorm block %d has no file_id", base.ID)
+			}
+			blocks = append(blocks, &models.ScormBlock{
+				BaseBlock: base,
+				FileID:    *fileID,
+			})
+
+		case models.BlockTypeQuiz:
+			if passingScore == nil {
+				return nil, fmt.Errorf("quiz block %d has no quiz", base.ID)
+			}
+			blocks = append(blocks, &models.QuizBlock{
+				BaseBlock: base,
+				Quiz:      quiz.Quiz{PassingScore: *passingScore, MaxAttempts: maxAttempts, Questions: questions},
+			})
+
+		case models.BlockTypeAssignment:
+			if instructions == nil || allowText == nil || allowFiles == nil || assignScore == nil {
+				return nil, fmt.Errorf("assignment block %d has no assignment", base.ID)
+			}
+			blocks = append(blocks, &models.AssignmentBlock{
+				BaseBlock: base,
+				Assignment: assignment.Assignment{
+					Instructions: *instructions,
+					AllowText:    *allowText,
+					AllowFiles:   *allowFiles,
+					PassingScore: *assignScore,
+					Rubric:       rubric,
+				},
+			})
+
+		case models.BlockTypeCodeExercise:
+			if language == nil || starterCode == nil || timeLimit == nil || memoryLimit == nil || codeScore == nil {
+				return nil, fmt.Errorf("code exercise block %d has no exercise", base.ID)
+			}
+			blocks = append(blocks, &models.CodeExerciseBlock{
+				BaseBlock: base,
+				Exercise: codeexercise.Exercise{
+					Language:      *language,
+					StarterCode:   *starterCode,
+					TimeLimitMs:   *timeLimit,
+					MemoryLimitMB: *memoryLimit,
+					PassingScore:  *codeScore,
+					Tests:         tests,
+				},
+			})
+
+		case models.BlockTypeImage:
+			if fileID == nil || altText == nil || caption == nil {
+				return nil, fmt.Errorf("image block %d has no image", base.ID)
+			}
+			blocks = append(blocks, &models.ImageBlock{
+				BaseBlock: base,
+				FileID:    *fileID,
+				AltText:   *altText,
+				Caption:   *caption,
+			})
+
+		case models.BlockTypeAttachment:
+			if fileID == nil || title == nil {
+				return nil, fmt.Errorf("attachment block %d has no file_id", base.ID)
+			}
+			blocks = append(blocks, &models.AttachmentBlock{
+				BaseBlock: base,
+				FileID:    *fileID,
+				Title:     *title,
+			})
+
+		case models.BlockTypeEmbed:
+			if provider == nil || embedURL == nil || title == nil {
+				return nil, fmt.Errorf("embed block %d has no url", base.ID)
+			}
+			blocks = append(blocks, &models.EmbedBlock{
+				BaseBlock: base,
+				Embed:     media.Embed{Provider: *provider, URL: *embedURL},
+				Title:     *title,
+			})
+
+		default:
+			return nil, fmt.Errorf("unknown block type: %s", base.Type)
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return blocks, nil
+}
+
+// isOrderConflict - параллельное изменение порядка заняло ту же позицию
+// MovedItem - положение элемента до и после переноса
+type MovedItem struct {
+	FromParentID int64
+	FromOrderIdx int
+	ParentID     int64
+	OrderIdx     int
+}
+
+// orderTables описывает таблицы упорядоченного элемента и его родителя
+type orderTables struct {
+	itemType       models.TrashItemType
+	table          string
+	parentColumn   string
+	parentTable    string
+	constraint     string
+	notFound       error
+	parentNotFound error
+}
+
+var moduleOrderTables = orderTables{
+	itemType:       models.TrashItemModule,
+	table:          "modules",
+	parentColumn:   "course_id",
+	parentTable:    "courses",
+	constraint:     "excl_modules__course_id_order_idx",
+	notFound:       ErrModuleNotFound,
+	parentNotFound: ErrCourseNotFound,
+}
+
+var lessonOrderTables = orderTables{
+	itemType:       models.TrashItemLesson,
+	table:          "lessons",
+	parentColumn:   "module_id",
+	parentTable:    "modules",
+	constraint:     "excl_lessons__module_id_order_idx",
+	notFound:       ErrLessonNotFound,
+	parentNotFound: ErrModuleNotFound,
+}
+
+var blockOrderTables = orderTables{
+	itemType:       models.TrashItemBlock,
+	table:          "blocks",
+	parentColumn:   "lesson_id",
+	parentTable:    "lessons",
+	constraint:     "excl_blocks__lesson_id_order_idx",
+	notFound:       ErrBlockNotFound,
+	parentNotFound: ErrLessonNotFound,
+}
+
+// moveItem переносит элемент в другого родителя: закрывает промежуток в исходном,
+// сдвигает элементы нового и ставит элемент на позицию. Уникальность порядка
+// проверяется при коммите, поэтому промежуточные состояния не конфликтуют.
+// Смена родителя и позиции пишется в историю правок элемента.
+func (r *coursesRepo) moveItem(ctx context.Context, t orderTables, id, parentID int64, orderIdx *int, author RevisionAuthor) (MovedItem, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return MovedItem{}, fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, fmt.Sprintf(`SET CONSTRAINTS %s DEFERRED`, t.constraint)); err != nil {
+		return MovedItem{}, fmt.Errorf("defer order constraint: %w", err)
+	}
+
+	// оба родителя блокируются раньше элемента, как и в остальных изменениях порядка
+	var moved MovedItem
+	moved.FromParentID, moved.FromOrderIdx, err = lockOrderItem(ctx, tx, t, id, parentID)
+	if err != nil {
+		return MovedItem{}, err
+	}
+
+	closeGapQuery := fmt.Sprintf(
+		`UPDATE %s SET order_idx = order_idx - 1 WHERE %s = $1 AND order_idx > $2 AND id <> $3 AND deleted_at IS NULL`,
+		t.table, t.parentColumn,
+	)
+	if _, err := tx.Exec(ctx, closeGapQuery, moved.FromParentID, moved.FromOrderIdx, id); err != nil {
+		return MovedItem{}, fmt.Errorf("close order gap: %w", err)
+	}
+
+	maxQuery := fmt.Sprintf(
+		`SELECT COALESCE(MAX(order_idx), 0) FROM %s WHERE %s = $1 AND id <> $2 AND deleted_at IS NULL`,
+		t.table, t.parentColumn,
+	)
+	var maxIdx int
+	if err := tx.QueryRow(ctx, maxQuery, parentID, id).Scan(&maxIdx); err != nil {
+		return MovedItem{}, fmt.Errorf("select max order_idx: %w", err)
+	}
+
+	moved.ParentID = parentID
+	if orderIdx == nil || *orderIdx > maxIdx {
+		moved.OrderIdx = maxIdx + 1
+	} else {
+		moved.OrderIdx = max(*orderIdx, 1)
+		shiftQuery := fmt.Sprintf(
+			`UPDATE %s SET order_idx = order_idx + 1 WHERE %s = $1 AND order_idx >= $2 AND id <> $3 AND deleted_at IS NULL`,
+			t.table, t.parentColumn,
+		)
+		if _, err := tx.Exec(ctx, shiftQuery, parentID, moved.OrderIdx, id); err != nil {
+			return MovedItem{}, fmt.Errorf("shift order_idx: %w", err)
+		}
+	}
+
+	updateQuery := fmt.Sprintf(`UPDATE %s SET %s = $1, order_idx = $2 WHERE id = $3`, t.table, t.parentColumn)
+	if _, err := tx.Exec(ctx, updateQuery, parentID, moved.OrderIdx, id); err != nil {
+		return MovedItem{}, fmt.Errorf("move %s: %w", t.table, err)
+	}
+
+	revisions := []insertRevisionParams{
+		{
+			Author:   author,
+			ItemType: t.itemType,
+			ItemID:   id,
+			Field:    models.RevisionFieldParentID,
+			OldValue: strconv.FormatInt(moved.FromParentID, 10),
+			Value:    strconv.FormatInt(moved.ParentID, 10),
+		},
+		{
+			Author:   author,
+			ItemType: t.itemType,
+			ItemID:   id,
+			Field:    models.RevisionFieldOrderIdx,
+			OldValue: strconv.Itoa(moved.FromOrderIdx),
+			Value:    strconv.Itoa(moved.OrderIdx),
+		},
+	}
+	for _, rev := range revisions {
+		if err := insertRevision(ctx, tx, rev); err != nil {
+			return MovedItem{}, err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		if isOrderConflict(err) {
+			return MovedItem{}, ErrOrderConflict
+		}
+		return MovedItem{}, fmt.Errorf("commit tx: %w", err)
+	}
+	return moved, nil
+}
+
+// lockOrderItem блокирует родителя элемента вместе с родителями из targets, а затем сам элемент,
+// и возвращает его текущее положение. Родители всегда блокируются раньше элементов и в порядке id,
+// поэтому изменения порядка внутри родителя не перекрываются и не блокируют друг друга.
+func lockOrderItem(ctx context.Context, tx pgx.Tx, t orderTables, id int64, targets ...int64) (parentID int64, orderIdx int, err error) {
+	parentQuery := fmt.Sprintf(`SELECT %s FROM %s WHERE id = $1 AND deleted_at IS NULL`, t.parentColumn, t.table)
+	if err := tx.QueryRow(ctx, parentQuery, id).Scan(&parentID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, 0, t.notFound
+		}
+		return 0, 0, fmt.Errorf("select %s: %w", t.table, err)
+	}
+
+	if err := lockParents(ctx, tx, t, parentID, targets); err != nil {
+		return 0, 0, err
+	}
+
+	// элемент мог быть перенесён, пока ждали блокировку
+	itemQuery := fmt.Sprintf(`SELECT %s, order_idx FROM %s WHERE id = $1 AND deleted_at IS NULL FOR UPDATE`, t.parentColumn, t.table)
+	var lockedParentID int64
+	if err := tx.QueryRow(ctx, itemQuery, id).Scan(&lockedParentID, &orderIdx); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, 0, t.notFound
+		}
+		if isOrderConflict(err) {
+			return 0, 0, ErrOrderConflict
+		}
+		return 0, 0, fmt.Errorf("select %s: %w", t.table, err)
+	}
+	if lockedParentID != parentID {
+		return 0, 0, ErrOrderConflict
+	}
+	return parentID, orderIdx, nil
+}
+
+// lockParents блокирует родителей в порядке id. Текущий родитель элемента может быть в корзине,
+// родители из targets - нет.
+func lockParents(ctx context.Context, tx pgx.Tx, t orderTables, parentID int64, targets []int64) error {
+	lockQuery := fmt.Sprintf(`SELECT id, deleted_at IS NULL FROM %s WHERE id = ANY($1) ORDER BY id FOR UPDATE`, t.parentTable)
+	rows, err := tx.Query(ctx, lockQuery, append([]int64{parentID}, targets...))
+	if err != nil {
+		return fmt.Errorf("lock %s: %w", t.parentTable, err)
+	}
+	alive := make(map[int64]bool)
+	for rows.Next() {
+		var lockedID int64
+		var notDeleted bool
+		if err := rows.Scan(&lockedID, &notDeleted); err != nil {
+			rows.Close()
+			return fmt.Errorf("scan %s: %w", t.parentTable, err)
+		}
+		alive[lockedID] = notDeleted
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		if isOrderConflict(err) {
+			return ErrOrderConflict
+		}
+		return fmt.Errorf("lock %s: %w", t.parentTable, err)
+	}
+
+	if _, ok := alive[parentID]; !ok {
+		return t.parentNotFound
+	}
+	for _, target := range targets {
+		if !alive[target] {
+			return t.parentNotFound
+		}
+	}
+	return nil
+}
+
+// reorderItems задаёт порядок всех элементов родителя одним запросом. ids должен
+// содержать каждый неудалённый элемент родителя ровно один раз, позиции нумеруются с 1.
+func (r *coursesRepo) reorderItems(ctx context.Context, t orderTables, parentID int64, ids []int64, author RevisionAuthor) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	lockQuery := fmt.Sprintf(`SELECT id FROM %s WHERE id = $1 AND deleted_at IS NULL FOR UPDATE`, t.parentTable)
+	if err := tx.QueryRow(ctx, lockQuery, parentID).Scan(new(int64)); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return t.parentNotFound
+		}
+		return fmt.Errorf("lock %s: %w", t.parentTable, err)
+	}
+
+	// порядок должен перечислять ровно текущие элементы родителя
+	checkQuery := fmt.Sprintf(`
+		SELECT
+			(SELECT COUNT(*) FROM %[1]s WHERE %[2]s = $1 AND deleted_at IS NULL),
+			(SELECT COUNT(*) FROM %[1]s WHERE %[2]s = $1 AND deleted_at IS NULL AND id = ANY($2)),
+			(SELECT COUNT(DISTINCT id) FROM unnest($2::BIGINT[]) AS id)
+	`, t.table, t.parentColumn)
+	var total, listed, distinct int
+	if err := tx.QueryRow(ctx, checkQuery, parentID, ids).Scan(&total, &listed, &distinct); err != nil {
+		return fmt.Errorf("check %s order: %w", t.table, err)
+	}
+	if total != len(ids) || listed != len(ids) || distinct != len(ids) {
+		return ErrOrderMismatch
+	}
+
+	if _, err := tx.Exec(ctx, fmt.Sprintf(`SET CONSTRAINTS %s DEFERRED`, t.constraint)); err != nil {
+		return fmt.Errorf("defer order constraint: %w", err)
+	}
+
+	updateQuery := fmt.Sprintf(`
+		UPDATE %[1]s t SET order_idx = o.idx
+		FROM unnest($2::BIGINT[]) WITH ORDINALITY AS o(id, idx),
+			(SELECT id, order_idx FROM %[1]s WHERE %[2]s = $1 AND deleted_at IS NULL) prev
+		WHERE t.id = o.id AND prev.id = o.id AND prev.order_idx <> o.idx
+		RETURNING t.id, prev.order_idx, t.order_idx
+	`, t.table, t.parentColumn)
+	rows, err := tx.Query(ctx, updateQuery, parentID, ids)
+	if err != nil {
+		return fmt.Errorf("update %s order: %w", t.table, err)
+	}
+	var revisions []insertRevisionParams
+	for rows.Next() {
+		var id int64
+		var oldIdx, newIdx int
+		if err := rows.Scan(&id, &oldIdx, &newIdx); err != nil {
+			rows.Close()
+			return fmt.Errorf("scan %s order: %w", t.table, err)
+		}
+		revisions = append(revisions, insertRevisionParams{
+			Author:   author,
+			ItemType: t.itemType,
+			ItemID:   id,
+			Field:    models.RevisionFieldOrderIdx,
+			OldValue: strconv.Itoa(oldIdx),
+			Value:    strconv.Itoa(newIdx),
+		})
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("rows error: %w", err)
+	}
+
+	for _, rev := range revisions {
+		if err := insertRevision(ctx, tx, rev); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		if isOrderConflict(err) {
+			return ErrOrderConflict
+		}
+		return fmt.Errorf("commit tx: %w", err)
+	}
+	return nil
+}
+
+// isOrderConflict - гонка за позицию: нарушение exclusion-ограничения порядка (23P01)
+// или взаимоблокировка (40P01), повторный запрос пройдёт
+func isOrderConflict(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && (pgErr.Code == "23P01" || pgErr.Code == "40P01")
+}
+
+func (r *coursesRepo) GetItemOwner(ctx context.Context, itemType models.TrashItemType, id int64) (models.ItemOwner, error) {
+	var (
+		query    string
+		notFound error
+	)
+	switch itemType {
+	case models.TrashItemCourse:
+		query = `SELECT account_id, id FROM courses WHERE id = $1 AND deleted_at IS NULL`
+		notFound = ErrCourseNotFound
+	case models.TrashItemModule:
+		query = `
+			SELECT c.account_id, c.id FROM modules m
+			INNER JOIN courses c ON c.id = m.course_id
+			WHERE m.id = $1 AND m.deleted_at IS NULL AND c.deleted_at IS NULL`
+		notFound = ErrModuleNotFound
+	case models.TrashItemLesson:
+		query = `
+			SELECT c.account_id, c.id FROM lessons l
+			INNER JOIN modules m ON m.id = l.module_id
+			INNER JOIN courses c ON c.id = m.course_id
+			WHERE l.id = $1 AND l.deleted_at IS NULL AND m.deleted_at IS NULL AND c.deleted_at IS NULL`
+		notFound = ErrLessonNotFound
+	case models.TrashItemBlock:
+		query = `
+			SELECT c.account_id, c.id FROM blocks b
+			INNER JOIN lessons l ON l.id = b.lesson_id
+			INNER JOIN modules m ON m.id = l.module_id
+			INNER JOIN courses c ON c.id = m.course_id
+			WHERE b.id = $1 AND b.deleted_at IS NULL AND l.deleted_at IS NULL
+			  AND m.deleted_at IS NULL AND c.deleted_at IS NULL`
+		notFound = ErrBlockNotFound
+	default:
+		return models.ItemOwner{}, fmt.Errorf("unknown item type: %s", itemType)
+	}
+
+	var owner models.ItemOwner
+	if err := r.db.QueryRow(ctx, query, id).Scan(&owner.AccountID, &owner.CourseID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.ItemOwner{}, notFound
+		}
+		return models.ItemOwner{}, fmt.Errorf("select item owner: %w", err)
+	}
+	return owner, nil
+}
+
+/* ===================== Hierarchy ===================== */
+
+// blockPreviewLen - длина начала текста в сводке текстового блока
+const blockPreviewLen = 200
+
+func (r *coursesRepo) GetCourseHierarchy(ctx context.Context, courseID int64) (*models.CourseHierarchy, error) {
+	course, err := r.GetCourseByID(ctx, courseID)
+	if err != nil {
+		return nil, err
+	}
+	tree := &models.CourseHierarchy{Course: course, Modules: []*models.ModuleHierarchy{}}
+
+	modules, err := r.GetModulesByCourseID(ctx, courseID)
+	if err != nil {
+		return nil, err
+	}
+	moduleByID := make(map[int64]*models.ModuleHierarchy, len(modules))
+	for _, m := range modules {
+		mh := &models.ModuleHierarchy{Module: m, Lessons: []*models.LessonHierarchy{}}
+		moduleByID[m.ID] = mh
+		tree.Modules = append(tree.Modules, mh)
+	}
+
+	const lessonsQuery = `
+		SELECT l.id, l.module_id, l.name, l.order_idx
+		FROM lessons l
+		INNER JOIN modules m ON m.id = l.module_id
+		WHERE m.course_id = $1 AND m.deleted_at IS NULL AND l.deleted_at IS NULL
+		ORDER BY l.module_id, l.order_idx
+	`
+	rows, err := r.db.Query(ctx, lessonsQuery, courseID)
+	if err != nil {
+		return nil, fmt.Errorf("query lessons: %w", err)
+	}
+	lessonByID := make(map[int64]*models.LessonHierarchy)
+	for rows.Next() {
+		var l models.Lesson
+		if err := rows.Scan(&l.ID, &l.ModuleID, &l.Name, &l.OrderIdx); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("scan lesson: %w", err)
+		}
+		lh := &models.LessonHierarchy{Lesson: &l, Blocks: []*models.BlockSummary{}}
+		lessonByID[l.ID] = lh
+		if mh, ok := moduleByID[l.ModuleID]; ok {
+			mh.Lessons = append(mh.Lessons, lh)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	const blocksQuery = `
+		SELECT b.id, b.lesson_id, b.order_idx, b.type, COALESCE(vb.file_id, sb.file_id, ib.file_id, atb.file_id),
+			COALESCE(LEFT(tb.content, $2), '')
+		FROM blocks b
+		INNER JOIN lessons l ON l.id = b.lesson_id
+		INNER JOIN modules m ON m.id = l.module_id
+		LEFT JOIN video_blocks vb ON vb.id = b.id
+		LEFT JOIN scorm_blocks sb ON sb.id = b.id
+		LEFT JOIN image_blocks ib ON ib.id = b.id
+		LEFT JOIN attachment_blocks atb ON atb.id = b.id
+		LEFT JOIN text_blocks tb ON tb.id = b.id
+		WHERE m.course_id = $1 AND m.deleted_at IS NULL AND l.deleted_at IS NULL AND b.deleted_at IS NULL
+		ORDER BY b.lesson_id, b.order_idx
+	`
+	rows, err = r.db.Query(ctx, blocksQuery, courseID, blockPreviewLen)
+	if err != nil {
+		return nil, fmt.Errorf("query blocks: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var b models.BlockSummary
+		if err := rows.Scan(&b.ID, &b.LessonID, &b.OrderIdx, &b.Type, &b.FileID, &b.Preview); err != nil {
+			return nil, fmt.Errorf("scan block: %w", err)
+		}
+		if lh, ok := lessonByID[b.LessonID]; ok {
+			lh.Blocks = append(lh.Blocks, &b)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+	return tree, nil
+}
+
+func (r *coursesRepo) GetCourseSnapshot(ctx context.Context, courseID int64) (*models.CourseSnapshot, error) {
+	tree, err := r.GetCourseHierarchy(ctx, courseID)
+	if err != nil {
+		return nil, err
+	}
+
+	const contentQuery = `
+		SELECT b.id, tb.content
+		FROM blocks b
+		INNER JOIN lessons l ON l.id = b.lesson_id
+		INNER JOIN modules m ON m.id = l.module_id
+		INNER JOIN text_blocks tb ON tb.id = b.id
+		WHERE m.course_id = $1 AND m.deleted_at IS NULL AND l.deleted_at IS NULL AND b.deleted_at IS NULL
+	`
+	rows, err := r.db.Query(ctx, contentQuery, courseID)
+	if err != nil {
+		return nil, fmt.Errorf("query text blocks: %w", err)
+	}
+	defer rows.Close()
+
+	contents := make(map[int64]string)
+	for rows.Next() {
+		var id int64
+		var content string
+		if err := rows.Scan(&id, &content); err != nil {
+			return nil, fmt.Errorf("scan text block: %w", err)
+		}
+		contents[id] = content
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+	rows.Close()
+
+	const quizQuery = `
+		SELECT b.id, qb.passing_score, qb.max_attempts, qb.questions
+		FROM blocks b
+		INNER JOIN lessons l ON l.id = b.lesson_id
+		INNER JOIN modules m ON m.id = l.module_id
+		INNER JOIN quiz_blocks qb ON qb.id = b.id
+		WHERE m.course_id = $1 AND m.deleted_at IS NULL AND l.deleted_at IS NULL AND b.deleted_at IS NULL
+	`
+	rows, err = r.db.Query(ctx, quizQuery, courseID)
+	if err != nil {
+		return nil, fmt.Errorf("query quiz blocks: %w", err)
+	}
+	defer rows.Close()
+
+	quizzes := make(map[int64]*quiz.Quiz)
+	for rows.Next() {
+		var id int64
+		var q quiz.Quiz
+		if err := rows.Scan(&id, &q.PassingScore, &q.MaxAttempts, &q.Questions); err != nil {
+			return nil, fmt.Errorf("scan quiz block: %w", err)
+		}
+		quizzes[id] = &q
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+	rows.Close()
+
+	const assignmentQuery = `
+		SELECT b.id, ab.instructions, ab.allow_text, ab.allow_files, ab.passing_score, ab.rubric
+		FROM blocks b
+		INNER JOIN lessons l ON l.id = b.lesson_id
+		INNER JOIN modules m ON m.id = l.module_id
+		INNER JOIN assignment_blocks ab ON ab.id = b.id
+		WHERE m.course_id = $1 AND m.deleted_at IS NULL AND l.deleted_at IS NULL AND b.deleted_at IS NULL
+	`
+	rows, err = r.db.Query(ctx, assignmentQuery, courseID)
+	if err != nil {
+		return nil, fmt.Errorf("query assignment blocks: %w", err)
+	}
+	defer rows.Close()
+
+	assignments := make(map[int64]*assignment.Assignment)
+	for rows.Next() {
+		var id int64
+		var a assignment.Assignment
+		if err := rows.Scan(&id, &a.Instructions, &a.AllowText, &a.AllowFiles, &a.PassingScore, &a.Rubric); err != nil {
+			return nil, fmt.Errorf("scan assignment block: %w", err)
+		}
+		assignments[id] = &a
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+	rows.Close()
+
+	const exerciseQuery = `
+		SELECT b.id, ce.language, ce.starter_code, ce.time_limit_ms, ce.memory_limit_mb, ce.passing_score, ce.tests
+		FROM blocks b
+		INNER JOIN lessons l ON l.id = b.lesson_id
+		INNER JOIN modules m ON m.id = l.module_id
+		INNER JOIN code_exercise_blocks ce ON ce.id = b.id
+		WHERE m.course_id = $1 AND m.deleted_at IS NULL AND l.deleted_at IS NULL AND b.deleted_at IS NULL
+	`
+	rows, err = r.db.Query(ctx, exerciseQuery, courseID)
+	if err != nil {
+		return nil, fmt.Errorf("query code exercise blocks: %w", err)
+	}
+	defer rows.Close()
+
+	exercises := make(map[int64]*codeexercise.Exercise)
+	for rows.Next() {
+		var id int64
+		var e codeexercise.Exercise
+		if err := rows.Scan(&id, &e.Language, &e.StarterCode, &e.TimeLimitMs, &e.MemoryLimitMB, &e.PassingScore, &e.Tests); err != nil {
+			return nil, fmt.Errorf("scan code exercise block: %w", err)
+		}
+		exercises[id] = &e
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+	rows.Close()
+
+	// подписи картинок, вложений и встроенных страниц, файлы блоков уже есть в дереве
+	const mediaQuery = `
+		SELECT b.id, COALESCE(ib.alt_text, ''), COALESCE(ib.caption, ''), COALESCE(atb.title, eb.title, ''), eb.provider, eb.url
+		FROM blocks b
+		INNER JOIN lessons l ON l.id = b.lesson_id
+		INNER JOIN modules m ON m.id = l.module_id
+		LEFT JOIN image_blocks ib ON ib.id = b.id
+		LEFT JOIN attachment_blocks atb ON atb.id = b.id
+		LEFT JOIN embed_blocks eb ON eb.id = b.id
+		WHERE m.course_id = $1 AND m.deleted_at IS NULL AND l.deleted_at IS NULL AND b.deleted_at IS NULL
+			AND b.type IN ('image', 'attachment', 'embed')
+	`
+	rows, err = r.db.Query(ctx, mediaQuery, courseID)
+	if err != nil {
+		return nil, fmt.Errorf("query media blocks: %w", err)
+	}
+	defer rows.Close()
+
+	medias := make(map[int64]models.BlockSnapshot)
+	for rows.Next() {
+		var (
+			id       int64
+			bs       models.BlockSnapshot
+			provider *string
+			embedURL *string
+		)
+		if err := rows.Scan(&id, &bs.AltText, &bs.Caption, &bs.Title, &provider, &embedURL); err != nil {
+			return nil, fmt.Errorf("scan media block: %w", err)
+		}
+		if provider != nil && embedURL != nil {
+			bs.Embed = &media.Embed{Provider: *provider, URL: *embedURL}
+		}
+		medias[id] = bs
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	snapshot := &models.CourseSnapshot{
+		ID:      tree.Course.ID,
+		Name:    tree.Course.Name,
+		Modules: make([]models.ModuleSnapshot, 0, len(tree.Modules)),
+	}
+	for _, m := range tree.Modules {
+		ms := models.ModuleSnapshot{
+			ID:       m.Module.ID,
+			OrderIdx: m.Module.OrderIdx,
+			Name:     m.Module.Name,
+			Lessons:  make([]models.LessonSnapshot, 0, len(m.Lessons)),
+		}
+		for _, l := range m.Lessons {
+			ls := models.LessonSnapshot{
+				ID:       l.Lesson.ID,
+				OrderIdx: l.Lesson.OrderIdx,
+				Name:     l.Lesson.Name,
+				Blocks:   make([]models.BlockSnapshot, 0, len(l.Blocks)),
 			}
-			blocks = append(blocks, &models.VideoBlock{
-				BaseBlock: base,
-				FileID:    *fileID,
-			})
+			for _, b := range l.Blocks {
+				bs := models.BlockSnapshot{ID: b.ID, OrderIdx: b.OrderIdx, Type: b.Type, FileID: b.FileID}
+				if content, ok := contents[b.ID]; ok {
+					bs.Content = &content
+				}
+				bs.Quiz = quizzes[b.ID]
+				bs.Assignment = assignments[b.ID]
+				bs.CodeExercise = exercises[b.ID]
+				if m, ok := medias[b.ID]; ok {
+					bs.AltText, bs.Caption, bs.Title, bs.Embed = m.AltText, m.Caption, m.Title, m.Embed
+				}
+				ls.Blocks = append(ls.Blocks, bs)
+			}
+			ms.Lessons = append(ms.Lessons, ls)
+		}
+		snapshot.Modules = append(snapshot.Modules, ms)
+	}
+	return snapshot, nil
+}
 
-		case models.BlockTypeText:
-			if content == nil {
-				return nil, fmt.Errorf("text block %d has no content", base.ID)
+type CreateCourseFromSnapshotParams struct {
+	AccountID int64
+	Name      string
+	Snapshot  *models.CourseSnapshot
+	// FileIDs заменяет файлы блоков на их копии, файл без замены используется как есть
+	FileIDs map[int64]int64
+}
+
+// CreateCourseFromSnapshot создаёт новый курс со всем содержимым снимка в одной транзакции.
+// Идентификаторы снимка не используются, порядок элементов сохраняется.
+func (r *coursesRepo) CreateCourseFromSnapshot(ctx context.Context, params CreateCourseFromSnapshotParams) (int64, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	const courseQuery = `INSERT INTO courses (account_id, name) VALUES ($1, $2) RETURNING id`
+	var courseID int64
+	if err := tx.QueryRow(ctx, courseQuery, params.AccountID, params.Name).Scan(&courseID); err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23503" {
+			return 0, ErrAccountNotFound
+		}
+		return 0, fmt.Errorf("insert course: %w", err)
+	}
+
+	const moduleQuery = `INSERT INTO modules (course_id, order_idx, name) VALUES ($1, $2, $3) RETURNING id`
+	const lessonQuery = `INSERT INTO lessons (module_id, order_idx, name) VALUES ($1, $2, $3) RETURNING id`
+	const blockQuery = `INSERT INTO blocks (lesson_id, order_idx, type) VALUES ($1, $2, $3) RETURNING id`
+	for _, m := range params.Snapshot.Modules {
+		var moduleID int64
+		if err := tx.QueryRow(ctx, moduleQuery, courseID, m.OrderIdx, m.Name).Scan(&moduleID); err != nil {
+			return 0, fmt.Errorf("insert module: %w", err)
+		}
+		for _, l := range m.Lessons {
+			var lessonID int64
+			if err := tx.QueryRow(ctx, lessonQuery, moduleID, l.OrderIdx, l.Name).Scan(&lessonID); err != nil {
+				return 0, fmt.Errorf("insert lesson: %w", err)
 			}
-			blocks = append(blocks, &models.TextBlock{
-				BaseBlock: base,
-				Content:   *content,
-			})
+			for _, b := range l.Blocks {
+				var blockID int64
+				if err := tx.QueryRow(ctx, blockQuery, lessonID, b.OrderIdx, b.Type).Scan(&blockID); err != nil {
+					return 0, fmt.Errorf("insert block: %w", err)
+				}
+				if err := insertBlockContent(ctx, tx, blockID, b, params.FileIDs); err != nil {
+					return 0, err
+				}
+			}
+		}
+	}
 
-		default:
-			return nil, fmt.Errorf("unknown block type: %s", base.Type)
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("commit tx: %w", err)
+	}
+	return courseID, nil
+}
+
+// insertBlockContent создаёт строку подтипа блока по данным снимка
+func insertBlockContent(ctx context.Context, tx pgx.Tx, blockID int64, b models.BlockSnapshot, fileIDs map[int64]int64) error {
+	switch b.Type {
+	case models.BlockTypeText:
+		var content string
+		if b.Content != nil {
+			content = *b.Content
+		}
+		const query = `INSERT INTO text_blocks (id, content) VALUES ($1, $2)`
+		if _, err := tx.Exec(ctx, query, blockID, content); err != nil {
+			return fmt.Errorf("insert text block: %w", err)
+		}
+	case models.BlockTypeVideo, models.BlockTypeSCORM:
+		if b.FileID == nil {
+			return fmt.Errorf("%s block %d has no file", b.Type, b.ID)
+		}
+		fileID := *b.FileID
+		if copyID, ok := fileIDs[fileID]; ok {
+			fileID = copyID
+		}
+		query := `INSERT INTO video_blocks (id, file_id) VALUES ($1, $2)`
+		if b.Type == models.BlockTypeSCORM {
+			query = `INSERT INTO scorm_blocks (id, file_id) VALUES ($1, $2)`
+		}
+		if _, err := tx.Exec(ctx, query, blockID, fileID); err != nil {
+			var pgErr *pgconn.PgError
+			if errors.As(err, &pgErr) && pgErr.Code == "23503" {
+				return ErrFileNotFound
+			}
+			return fmt.Errorf("insert %s block: %w", b.Type, err)
+		}
+	case models.BlockTypeQuiz:
+		if b.Quiz == nil {
+			return fmt.Errorf("quiz block %d has no quiz", b.ID)
 		}
+		return insertQuizBlock(ctx, tx, blockID, *b.Quiz)
+	case models.BlockTypeAssignment:
+		if b.Assignment == nil {
+			return fmt.Errorf("assignment block %d has no assignment", b.ID)
+		}
+		return insertAssignmentBlock(ctx, tx, blockID, *b.Assignment)
+	case models.BlockTypeCodeExercise:
+		if b.CodeExercise == nil {
+			return fmt.Errorf("code exercise block %d has no exercise", b.ID)
+		}
+		return insertCodeExerciseBlock(ctx, tx, blockID, *b.CodeExercise)
+	case models.BlockTypeImage, models.BlockTypeAttachment:
+		if b.FileID == nil {
+			return fmt.Errorf("%s block %d has no file", b.Type, b.ID)
+		}
+		fileID := *b.FileID
+		if copyID, ok := fileIDs[fileID]; ok {
+			fileID = copyID
+		}
+		if b.Type == models.BlockTypeImage {
+			return insertImageBlock(ctx, tx, blockID, fileID, b.AltText, b.Caption)
+		}
+		return insertAttachmentBlock(ctx, tx, blockID, fileID, b.Title)
+	case models.BlockTypeEmbed:
+		if b.Embed == nil {
+			return fmt.Errorf("embed block %d has no embed", b.ID)
+		}
+		return insertEmbedBlock(ctx, tx, blockID, *b.Embed, b.Title)
+	default:
+		return fmt.Errorf("unknown block type %q", b.Type)
+	}
+	return nil
+}
+
+/* ===================== Trash ===================== */
+
+// GetTrash возвращает удалённые элементы аккаунта. Элементы, удалённые вместе
+// с родителем, не показываются отдельно: они восстанавливаются вместе с ним.
+func (r *coursesRepo) GetTrash(ctx context.Context, accountID int64) ([]models.TrashItem, error) {
+	const query = `
+		SELECT 'course', c.id, NULL::BIGINT, c.name, c.deleted_at, c.deleted_by
+		FROM courses c
+		WHERE c.account_id = $1 AND c.deleted_at IS NOT NULL
+		UNION ALL
+		SELECT 'module', m.id, m.course_id, m.name, m.deleted_at, m.deleted_by
+		FROM modules m
+		INNER JOIN courses c ON c.id = m.course_id
+		WHERE c.account_id = $1 AND m.deleted_at IS NOT NULL AND c.deleted_at IS NULL
+		UNION ALL
+		SELECT 'lesson', l.id, l.module_id, l.name, l.deleted_at, l.deleted_by
+		FROM lessons l
+		INNER JOIN modules m ON m.id = l.module_id
+		INNER JOIN courses c ON c.id = m.course_id
+		WHERE c.account_id = $1 AND l.deleted_at IS NOT NULL AND m.deleted_at IS NULL AND c.deleted_at IS NULL
+		UNION ALL
+		SELECT 'block', b.id, b.lesson_id, b.type, b.deleted_at, b.deleted_by
+		FROM blocks b
+		INNER JOIN lessons l ON l.id = b.lesson_id
+		INNER JOIN modules m ON m.id = l.module_id
+		INNER JOIN courses c ON c.id = m.course_id
+		WHERE c.account_id = $1 AND b.deleted_at IS NOT NULL AND l.deleted_at IS NULL AND m.deleted_at IS NULL AND c.deleted_at IS NULL
+		ORDER BY 5 DESC
+	`
+	rows, err := r.db.Query(ctx, query, accountID)
+	if err != nil {
+		return nil, fmt.Errorf("query trash: %w", err)
 	}
+	defer rows.Close()
 
+	var items []models.TrashItem
+	for rows.Next() {
+		var it models.TrashItem
+		if err := rows.Scan(&it.Type, &it.ID, &it.ParentID, &it.Name, &it.DeletedAt, &it.DeletedBy); err != nil {
+			return nil, fmt.Errorf("scan trash item: %w", err)
+		}
+		items = append(items, it)
+	}
 	if err := rows.Err(); err != nil {
 		return nil, fmt.Errorf("rows error: %w", err)
 	}
+	return items, nil
+}
 
-	return blocks, nil
+// GetTrashItemAccountID возвращает аккаунт, которому принадлежит удалённый элемент
+func (r *coursesRepo) GetTrashItemAccountID(ctx context.Context, itemType models.TrashItemType, id int64) (int64, error) {
+	var query string
+	switch itemType {
+	case models.TrashItemCourse:
+		query = `SELECT account_id FROM courses WHERE id = $1 AND deleted_at IS NOT NULL`
+	case models.TrashItemModule:
+		query = `
+			SELECT c.account_id FROM modules m
+			INNER JOIN courses c ON c.id = m.course_id
+			WHERE m.id = $1 AND m.deleted_at IS NOT NULL`
+	case models.TrashItemLesson:
+		query = `
+			SELECT c.account_id FROM lessons l
+			INNER JOIN modules m ON m.id = l.module_id
+			INNER JOIN courses c ON c.id = m.course_id
+			WHERE l.id = $1 AND l.deleted_at IS NOT NULL`
+	case models.TrashItemBlock:
+		query = `
+			SELECT c.account_id FROM blocks b
+			INNER JOIN lessons l ON l.id = b.lesson_id
+			INNER JOIN modules m ON m.id = l.module_id
+			INNER JOIN courses c ON c.id = m.course_id
+			WHERE b.id = $1 AND b.deleted_at IS NOT NULL`
+	default:
+		return 0, ErrTrashItemNotFound
+	}
+
+	var accountID int64
+	if err := r.db.QueryRow(ctx, query, id).Scan(&accountID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, ErrTrashItemNotFound
+		}
+		return 0, fmt.Errorf("select trash item account: %w", err)
+	}
+	return accountID, nil
+}
+
+type RestoreTrashItemParams struct {
+	Type models.TrashItemType
+	ID   int64
+}
+
+// orderedTrashTables описывает упорядоченные элементы: таблицу, родителя и таблицу родителя
+var orderedTrashTables = map[models.TrashItemType]struct {
+	table, parentColumn, parentTable string
+	parentNotFound                   error
+}{
+	models.TrashItemModule: {"modules", "course_id", "courses", ErrCourseNotFound},
+	models.TrashItemLesson: {"lessons", "module_id", "modules", ErrModuleNotFound},
+	models.TrashItemBlock:  {"blocks", "lesson_id", "lessons", ErrLessonNotFound},
+}
+
+// RestoreTrashItem возвращает элемент из корзины. Модули, уроки и блоки
+// встают на прежнюю позицию, если она ещё существует, иначе в конец.
+func (r *coursesRepo) RestoreTrashItem(ctx context.Context, params RestoreTrashItemParams) error {
+	if params.Type == models.TrashItemCourse {
+		const query = `UPDATE courses SET deleted_at = NULL, deleted_by = NULL WHERE id = $1 AND deleted_at IS NOT NULL`
+		cmdTag, err := r.db.Exec(ctx, query, params.ID)
+		if err != nil {
+			return fmt.Errorf("restore course: %w", err)
+		}
+		if cmdTag.RowsAffected() == 0 {
+			return ErrTrashItemNotFound
+		}
+		return nil
+	}
+
+	t, ok := orderedTrashTables[params.Type]
+	if !ok {
+		return ErrTrashItemNotFound
+	}
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	parentQuery := fmt.Sprintf(`SELECT %s FROM %s WHERE id = $1 AND deleted_at IS NOT NULL`, t.parentColumn, t.table)
+	var parentID int64
+	if err := tx.QueryRow(ctx, parentQuery, params.ID).Scan(&parentID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrTrashItemNotFound
+		}
+		return fmt.Errorf("select trash item: %w", err)
+	}
+
+	// родитель блокируется раньше элемента, как и в остальных изменениях порядка.
+	// Он тоже может быть в корзине, тогда сначала нужно восстановить его.
+	lockParentQuery := fmt.Sprintf(`SELECT id FROM %s WHERE id = $1 AND deleted_at IS NULL FOR UPDATE`, t.parentTable)
+	if err := tx.QueryRow(ctx, lockParentQuery, parentID).Scan(new(int64)); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return t.parentNotFound
+		}
+		if isOrderConflict(err) {
+			return ErrOrderConflict
+		}
+		return fmt.Errorf("lock parent: %w", err)
+	}
+
+	getQuery := fmt.Sprintf(`SELECT %s, order_idx FROM %s WHERE id = $1 AND deleted_at IS NOT NULL FOR UPDATE`, t.parentColumn, t.table)
+	var lockedParentID int64
+	var oldIdx int
+	if err := tx.QueryRow(ctx, getQuery, params.ID).Scan(&lockedParentID, &oldIdx); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrTrashItemNotFound
+		}
+		if isOrderConflict(err) {
+			return ErrOrderConflict
+		}
+		return fmt.Errorf("select trash item: %w", err)
+	}
+	if lockedParentID != parentID {
+		return ErrOrderConflict
+	}
+
+	maxQuery := fmt.Sprintf(`SELECT COALESCE(MAX(order_idx), 0) FROM %s WHERE %s = $1 AND deleted_at IS NULL`, t.table, t.parentColumn)
+	var maxIdx int
+	if err := tx.QueryRow(ctx, maxQuery, parentID).Scan(&maxIdx); err != nil {
+		return fmt.Errorf("select max order_idx: %w", err)
+	}
+
+	newIdx := min(oldIdx, maxIdx+1)
+	shiftQuery := fmt.Sprintf(`UPDATE %s SET order_idx = order_idx + 1 WHERE %s = $1 AND order_idx >= $2 AND deleted_at IS NULL`, t.table, t.parentColumn)
+	if _, err := tx.Exec(ctx, shiftQuery, parentID, newIdx); err != nil {
+		return fmt.Errorf("shift order_idx: %w", err)
+	}
+
+	restoreQuery := fmt.Sprintf(`UPDATE %s SET deleted_at = NULL, deleted_by = NULL, order_idx = $1 WHERE id = $2`, t.table)
+	if _, err := tx.Exec(ctx, restoreQuery, newIdx, params.ID); err != nil {
+		return fmt.Errorf("restore item: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit tx: %w", err)
+	}
+	return nil
+}
+
+// PurgeTrash окончательно удаляет элементы, попавшие в корзину раньше before
+func (r *coursesRepo) PurgeTrash(ctx context.Context, before time.Time) (int64, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var removed int64
+	for _, table := range []string{"blocks", "lessons", "modules", "courses"} {
+		query := fmt.Sprintf(`DELETE FROM %s WHERE deleted_at < $1`, table)
+		cmdTag, err := tx.Exec(ctx, query, before.UTC())
+		if err != nil {
+			return 0, fmt.Errorf("purge %s: %w", table, err)
+		}
+		removed += cmdTag.RowsAffected()
+	}
+
+	// история правок окончательно удалённых элементов больше не нужна
+	const revisionsQuery = `
+		DELETE FROM content_revisions r
+		WHERE (r.item_type = 'course' AND NOT EXISTS (SELECT 1 FROM courses WHERE id = r.item_id))
+		   OR (r.item_type = 'module' AND NOT EXISTS (SELECT 1 FROM modules WHERE id = r.item_id))
+		   OR (r.item_type = 'lesson' AND NOT EXISTS (SELECT 1 FROM lessons WHERE id = r.item_id))
+		   OR (r.item_type = 'block' AND NOT EXISTS (SELECT 1 FROM blocks WHERE id = r.item_id))
+	`
+	if _, err := tx.Exec(ctx, revisionsQuery); err != nil {
+		return 0, fmt.Errorf("purge revisions: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("commit tx: %w", err)
+	}
+	return removed, nil
 }
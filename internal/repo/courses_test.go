@@ -0,0 +1,276 @@
+package repo
+
+import (
+	"chalk/pkg/migrator"
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// testDB подключается к пустой тестовой базе из CHALK_TEST_POSTGRES_URI и накатывает миграции.
+// Без переменной тесты, которым нужна база, пропускаются.
+func testDB(t *testing.T) *pgxpool.Pool {
+	t.Helper()
+	uri := os.Getenv("CHALK_TEST_POSTGRES_URI")
+	if uri == "" {
+		t.Skip("CHALK_TEST_POSTGRES_URI is not set")
+	}
+	db, err := pgxpool.New(context.Background(), uri)
+	if err != nil {
+		t.Fatalf("postgres connect: %v", err)
+	}
+	t.Cleanup(db.Close)
+	conn, err := db.Acquire(context.Background())
+	if err != nil {
+		t.Fatalf("postgres connect: %v", err)
+	}
+	defer conn.Release()
+	if err := migrator.NewPgx(conn.Conn(), "../../migrations").Up(); err != nil {
+		t.Fatalf("up migrations: %v", err)
+	}
+	return db
+}
+
+// testAuthor создаёт пользователя и аккаунт с уникальными именами
+func testAuthor(t *testing.T, db DB) RevisionAuthor {
+	t.Helper()
+	ctx := context.Background()
+	suffix := fmt.Sprintf("%d", time.Now().UnixNano())
+	userID, err := NewUsersRepo(db).CreateUser(ctx, CreateUserParams{
+		Email: "order-" + suffix + "@example.com", PasswordHashed: "x", Name: "order",
+	})
+	if err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	acc, err := NewAccountsRepo(db).CreateAccount(ctx, CreateAccountParams{
+		OwnerUserID: userID, AccountName: "order-" + suffix, AccountSlug: "order-" + suffix,
+	})
+	if err != nil {
+		t.Fatalf("create account: %v", err)
+	}
+	return RevisionAuthor{AccountID: acc.ID, UserID: userID}
+}
+
+// orderOf возвращает неудалённые элементы родителя по порядку и проверяет, что ранги идут 1..n без дыр
+func orderOf(t *testing.T, db DB, tables orderTables, parentID int64) []int64 {
+	t.Helper()
+	query := fmt.Sprintf(`SELECT id, order_idx FROM %s WHERE %s = $1 AND deleted_at IS NULL ORDER BY order_idx`,
+		tables.table, tables.parentColumn)
+	rows, err := db.Query(context.Background(), query, parentID)
+	if err != nil {
+		t.Fatalf("select order: %v", err)
+	}
+	defer rows.Close()
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		var idx int
+		if err := rows.Scan(&id, &idx); err != nil {
+			t.Fatalf("scan order: %v", err)
+		}
+		if idx != len(ids)+1 {
+			t.Fatalf("%s %d has order_idx %d, want %d", tables.table, id, idx, len(ids)+1)
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatalf("select order: %v", err)
+	}
+	return ids
+}
+
+func TestUpdateOrderIdx(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+	r := NewCoursesRepo(db)
+	author := testAuthor(t, db)
+
+	courseID, err := r.CreateCourse(ctx, CreateCourseParams{AccountID: author.AccountID, Name: "order"})
+	if err != nil {
+		t.Fatalf("create course: %v", err)
+	}
+	var modules, lessons, blocks []int64
+	for i := range 3 {
+		id, err := r.CreateModule(ctx, CreateModuleParams{CourseID: courseID, Name: fmt.Sprintf("m%d", i)})
+		if err != nil {
+			t.Fatalf("create module: %v", err)
+		}
+		modules = append(modules, id)
+	}
+	for i := range 3 {
+		id, err := r.CreateLesson(ctx, CreateLessonParams{ModuleID: modules[0], Name: fmt.Sprintf("l%d", i)})
+		if err != nil {
+			t.Fatalf("create lesson: %v", err)
+		}
+		lessons = append(lessons, id)
+	}
+	for i := range 3 {
+		id, err := r.CreateTextBlock(ctx, CreateTextBlockParams{
+			CreateBaseBlockParams: CreateBaseBlockParams{LessonID: lessons[0]},
+			Content:               fmt.Sprintf("b%d", i),
+		})
+		if err != nil {
+			t.Fatalf("create block: %v", err)
+		}
+		blocks = append(blocks, id)
+	}
+
+	tests := []struct {
+		name     string
+		tables   orderTables
+		parentID int64
+		ids      []int64
+		move     func(id int64, idx int) error
+	}{
+		{
+			name: "module", tables: moduleOrderTables, parentID: courseID, ids: modules,
+			move: func(id int64, idx int) error {
+				return r.UpdateModuleOrderIdx(ctx, UpdateModuleOrderIdxParams{ModuleID: id, OrderIdx: int64(idx), Author: author})
+			},
+		},
+		{
+			name: "lesson", tables: lessonOrderTables, parentID: modules[0], ids: lessons,
+			move: func(id int64, idx int) error {
+				return r.UpdateLessonOrderIdx(ctx, UpdateLessonOrderIdxParams{LessonID: id, OrderIdx: idx, Author: author})
+			},
+		},
+		{
+			name: "block", tables: blockOrderTables, parentID: lessons[0], ids: blocks,
+			move: func(id int64, idx int) error {
+				return r.UpdateBlockOrderIdx(ctx, UpdateBlockOrderIdxParams{BlockID: id, OrderIdx: idx, Author: author})
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, b, c := tt.ids[0], tt.ids[1], tt.ids[2]
+			steps := []struct {
+				id   int64
+				idx  int
+				want []int64
+			}{
+				// вниз
+				{a, 3, []int64{b, c, a}},
+				// вверх
+				{a, 1, []int64{a, b, c}},
+				// вниз на одну позицию
+				{b, 3, []int64{a, c, b}},
+				// вверх на одну позицию
+				{b, 2, []int64{a, b, c}},
+				// индексы за границами прижимаются к краям
+				{c, 0, []int64{c, a, b}},
+				{c, 10, []int64{a, b, c}},
+			}
+			for _, s := range steps {
+				if err := tt.move(s.id, s.idx); err != nil {
+					t.Fatalf("move %d to %d: %v", s.id, s.idx, err)
+				}
+				if got := orderOf(t, db, tt.tables, tt.parentID); !slices.Equal(got, s.want) {
+					t.Fatalf("move %d to %d: order %v, want %v", s.id, s.idx, got, s.want)
+				}
+			}
+		})
+	}
+}
+
+func TestCreateAndMoveAtZero(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+	r := NewCoursesRepo(db)
+	author := testAuthor(t, db)
+
+	courseID, err := r.CreateCourse(ctx, CreateCourseParams{AccountID: author.AccountID, Name: "order"})
+	if err != nil {
+		t.Fatalf("create course: %v", err)
+	}
+	zero := 0
+	zero64 := int64(0)
+	from, err := r.CreateModule(ctx, CreateModuleParams{CourseID: courseID, Name: "from"})
+	if err != nil {
+		t.Fatalf("create module: %v", err)
+	}
+	to, err := r.CreateModule(ctx, CreateModuleParams{CourseID: courseID, Name: "to", OrderIdx: &zero64})
+	if err != nil {
+		t.Fatalf("create module: %v", err)
+	}
+	if got := orderOf(t, db, moduleOrderTables, courseID); !slices.Equal(got, []int64{to, from}) {
+		t.Fatalf("modules %v, want %v", got, []int64{to, from})
+	}
+
+	var lessons []int64
+	for range 2 {
+		id, err := r.CreateLesson(ctx, CreateLessonParams{ModuleID: to, Name: "l", OrderIdx: &zero})
+		if err != nil {
+			t.Fatalf("create lesson: %v", err)
+		}
+		lessons = append([]int64{id}, lessons...)
+	}
+	moved, err := r.CreateLesson(ctx, CreateLessonParams{ModuleID: from, Name: "moved"})
+	if err != nil {
+		t.Fatalf("create lesson: %v", err)
+	}
+	res, err := r.MoveLesson(ctx, MoveLessonParams{LessonID: moved, ModuleID: to, OrderIdx: &zero, Author: author})
+	if err != nil {
+		t.Fatalf("move lesson: %v", err)
+	}
+	if res.OrderIdx != 1 {
+		t.Fatalf("moved to %d, want 1", res.OrderIdx)
+	}
+	want := append([]int64{moved}, lessons...)
+	if got := orderOf(t, db, lessonOrderTables, to); !slices.Equal(got, want) {
+		t.Fatalf("lessons %v, want %v", got, want)
+	}
+	if got := orderOf(t, db, lessonOrderTables, from); len(got) != 0 {
+		t.Fatalf("lessons left in source module: %v", got)
+	}
+}
+
+func TestConcurrentOrderChanges(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+	r := NewCoursesRepo(db)
+	author := testAuthor(t, db)
+
+	courseID, err := r.CreateCourse(ctx, CreateCourseParams{AccountID: author.AccountID, Name: "order"})
+	if err != nil {
+		t.Fatalf("create course: %v", err)
+	}
+	var modules []int64
+	for i := range 8 {
+		id, err := r.CreateModule(ctx, CreateModuleParams{CourseID: courseID, Name: fmt.Sprintf("m%d", i)})
+		if err != nil {
+			t.Fatalf("create module: %v", err)
+		}
+		modules = append(modules, id)
+	}
+
+	// изменения порядка в одном курсе выстраиваются в очередь на блокировке курса
+	var wg sync.WaitGroup
+	errs := make(chan error, len(modules)*4)
+	for i, id := range modules {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range 4 {
+				idx := int64((i+j)%len(modules) + 1)
+				errs <- r.UpdateModuleOrderIdx(ctx, UpdateModuleOrderIdxParams{ModuleID: id, OrderIdx: idx, Author: author})
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("update order_idx: %v", err)
+		}
+	}
+	if got := orderOf(t, db, moduleOrderTables, courseID); len(got) != len(modules) {
+		t.Fatalf("%d modules after reorder, want %d", len(got), len(modules))
+	}
+}
@@ -0,0 +1,87 @@
+package repo
+
+import (
+	"chalk/internal/repo/models"
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+type CourseSourcesRepo interface {
+	GetCourseSources(ctx context.Context, courseID int64) ([]models.CourseSource, error)
+	// ReplaceCourseSources заменяет все соответствия курса переданными
+	ReplaceCourseSources(ctx context.Context, courseID int64, sources []models.CourseSource) error
+}
+
+func NewCourseSourcesRepo(db DB) CourseSourcesRepo {
+	return &courseSourcesRepo{db: db}
+}
+
+type courseSourcesRepo struct {
+	db DB
+}
+
+func (r *courseSourcesRepo) GetCourseSources(ctx context.Context, courseID int64) ([]models.CourseSource, error) {
+	const query = `SELECT path, item_type, item_id, checksum FROM course_sources WHERE course_id = $1 ORDER BY item_type, path`
+	rows, err := r.db.Query(ctx, query, courseID)
+	if err != nil {
+		return nil, fmt.Errorf("select course sources: %w", err)
+	}
+	defer rows.Close()
+
+	var sources []models.CourseSource
+	for rows.Next() {
+		var s models.CourseSource
+		if err := rows.Scan(&s.Path, &s.ItemType, &s.ItemID, &s.Checksum); err != nil {
+			return nil, fmt.Errorf("scan course source: %w", err)
+		}
+		sources = append(sources, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+	return sources, nil
+}
+
+func (r *courseSourcesRepo) ReplaceCourseSources(ctx context.Context, courseID int64, sources []models.CourseSource) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	const deleteQuery = `DELETE FROM course_sources WHERE course_id = $1`
+	if _, err := tx.Exec(ctx, deleteQuery, courseID); err != nil {
+		return fmt.Errorf("delete course sources: %w", err)
+	}
+
+	paths := make([]string, 0, len(sources))
+	types := make([]string, 0, len(sources))
+	ids := make([]int64, 0, len(sources))
+	checksums := make([]string, 0, len(sources))
+	for _, s := range sources {
+		paths = append(paths, s.Path)
+		types = append(types, string(s.ItemType))
+		ids = append(ids, s.ItemID)
+		checksums = append(checksums, s.Checksum)
+	}
+	const insertQuery = `
+		INSERT INTO course_sources (course_id, path, item_type, item_id, checksum)
+		SELECT $1, s.path, s.item_type, s.item_id, s.checksum
+		FROM unnest($2::TEXT[], $3::TEXT[], $4::BIGINT[], $5::TEXT[]) AS s(path, item_type, item_id, checksum)
+	`
+	if _, err := tx.Exec(ctx, insertQuery, courseID, paths, types, ids, checksums); err != nil {
+		var pqErr *pgconn.PgError
+		if errors.As(err, &pqErr) && pqErr.Code == "23503" {
+			return ErrCourseNotFound
+		}
+		return fmt.Errorf("insert course sources: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package repo
+
+import (
+	"chalk/internal/repo/models"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const courseTreeRepoPrefix string = "coursetree"
+
+// CourseTreeCacheRepo кэширует дерево курса. Каждое изменение курса увеличивает
+// его поколение, а дерево хранится под ключом поколения, поэтому дерево,
+// прочитанное до изменения, не может перезаписать кэш после инвалидации.
+type CourseTreeCacheRepo interface {
+	// Get возвращает поколение курса и дерево, ErrRecordNotFound если дерева этого поколения нет
+	Get(ctx context.Context, courseID int64) (int64, *models.CourseHierarchy, error)
+	Set(ctx context.Context, courseID, generation int64, tree *models.CourseHierarchy, ttl time.Duration) error
+	Invalidate(ctx context.Context, courseID int64) error
+}
+
+func NewCourseTreeCacheRepo(rdb *redis.Client) CourseTreeCacheRepo {
+	return &courseTreeCacheRepo{rdb: rdb}
+}
+
+type courseTreeCacheRepo struct {
+	rdb *redis.Client
+}
+
+func (r *courseTreeCacheRepo) generationKey(courseID int64) string {
+	return fmt.Sprintf("%s:%d:gen", courseTreeRepoPrefix, courseID)
+}
+
+func (r *courseTreeCacheRepo) treeKey(courseID, generation int64) string {
+	return fmt.Sprintf("%s:%d:%d", courseTreeRepoPrefix, courseID, generation)
+}
+
+func (r *courseTreeCacheRepo) Get(ctx context.Context, courseID int64) (int64, *models.CourseHierarchy, error) {
+	generation, err := r.rdb.Get(ctx, r.generationKey(courseID)).Int64()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return 0, nil, fmt.Errorf("failed to get generation: %w", err)
+	}
+
+	tree := &models.CourseHierarchy{}
+	err = r.rdb.Get(ctx, r.treeKey(courseID, generation)).Scan(tree)
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return generation, nil, ErrRecordNotFound
+		}
+		return 0, nil, fmt.Errorf("failed to get value: %w", err)
+	}
+	return generation, tree, nil
+}
+
+func (r *courseTreeCacheRepo) Set(ctx context.Context, courseID, generation int64, tree *models.CourseHierarchy, ttl time.Duration) error {
+	if err := r.rdb.Set(ctx, r.treeKey(courseID, generation), tree, ttl).Err(); err != nil {
+		return fmt.Errorf("failed to set value: %w", err)
+	}
+	return nil
+}
+
+func (r *courseTreeCacheRepo) Invalidate(ctx context.Context, courseID int64) error {
+	// деревья прошлых поколений истекают по ttl
+	if err := r.rdb.Incr(ctx, r.generationKey(courseID)).Err(); err != nil {
+		return fmt.Errorf("failed to increment generation: %w", err)
+	}
+	return nil
+}
@@ -10,6 +10,7 @@ var (
 
 	// specific
 	ErrAccountNameAlreadyTaken = errors.New("account name already taken")
+	ErrAccountSlugAlreadyTaken = errors.New("account slug already taken")
 	ErrAccountOwnerNotFound    = errors.New("user with account_owner_id not found")
 	ErrAccountNotFound         = errors.New("account not found")
 	ErrUserNotFound            = errors.New("user not found")
@@ -18,7 +19,25 @@ var (
 	ErrModuleNotFound          = errors.New("module not found")
 	ErrLessonNotFound          = errors.New("lesson not found")
 	ErrBlockNotFound           = errors.New("block not found")
+	ErrOrderConflict           = errors.New("order position is taken by a concurrent change")
+	ErrOrderMismatch           = errors.New("order does not list every item of the parent exactly once")
+	ErrCourseNotPublished      = errors.New("course is not published")
+	ErrCourseVersionNotFound   = errors.New("course version not found")
+	ErrRevisionNotFound        = errors.New("revision not found")
+	ErrScormDataTooLarge       = errors.New("scorm runtime data is too large")
+	ErrQuizAttemptLimitReached = errors.New("quiz attempt limit reached")
+	ErrQuizAttemptConflict     = errors.New("quiz attempt number is taken by a concurrent submit")
+	ErrSubmissionNotFound      = errors.New("assignment submission not found")
+	ErrSubmissionStatus        = errors.New("assignment submission status does not allow this action")
+	ErrDuplicateSubmissionFile = errors.New("file is attached to the submission twice")
 	ErrFileNotFound            = errors.New("file not found")
+	ErrTrashItemNotFound       = errors.New("trash item not found")
+	ErrStorageQuotaExceeded    = errors.New("storage quota exceeded")
+	ErrPlanNotFound            = errors.New("plan not found")
+	ErrSeatLimitReached        = errors.New("seat limit reached")
+	ErrAccountClosing          = errors.New("account is closing")
+	ErrAccountNotClosing       = errors.New("account is not closing")
+	ErrAccountDeleting         = errors.New("account deletion has started")
 
 	ErrAlreadyEnrolled  = errors.New("user already enrolled in course")
 	ErrNotEnrolled      = errors.New("user not enrolled in course")
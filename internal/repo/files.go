@@ -16,17 +16,27 @@ import (
 )
 
 type FilesRepo interface {
+	UploadFile(ctx context.Context, params UploadFileParams) (models.File, error)
+	CopyFile(ctx context.Context, params CopyFileParams) (models.File, error)
+	GetFileInfo(ctx context.Context, fileID int64) (models.File, error)
+	GetFileByID(ctx context.Context, fileID int64) (io.ReadCloser, error)
+	// OpenFileAt открывает объект с произвольным доступом, например чтобы читать zip без загрузки целиком
+	OpenFileAt(ctx context.Context, file models.File) (ReadAtCloser, error)
+	GetStorageUsage(ctx context.Context, params GetStorageUsageParams) (models.StorageUsage, error)
+	RemoveFile(ctx context.Context, file models.File) error
+	DiscardFile(ctx context.Context, file models.File) error
 }
 
-func NewFilesRepo(db *pgx.Conn, miniocli *minio.Client) FilesRepo {
+func NewFilesRepo(db DB, miniocli *minio.Client, bucket string) FilesRepo {
 	return &filesRepo{
 		db:       db,
+		bucket:   bucket,
 		miniocli: miniocli,
 	}
 }
 
 type filesRepo struct {
-	db       *pgx.Conn
+	db       DB
 	bucket   string
 	miniocli *minio.Client
 }
@@ -34,9 +44,14 @@ type filesRepo struct {
 type UploadFileParams struct {
 	Reader         io.Reader
 	UploaderUserID int64
-	Name           string
-	ContentType    string
-	Size           int64
+	AccountID      int64
+	// DefaultQuota - квота аккаунта, если для него не задана своя, 0 - без ограничений
+	DefaultQuota int64
+	// SkipQuota - служебный файл, который не учитывается в занятом месте
+	SkipQuota   bool
+	Name        string
+	ContentType string
+	Size        int64
 }
 
 func (r *filesRepo) UploadFile(ctx context.Context, params UploadFileParams) (models.File, error) {
@@ -50,6 +65,20 @@ func (r *filesRepo) UploadFile(ctx context.Context, params UploadFileParams) (mo
 		return models.File{}, ErrUserNotFound
 	}
 
+	// место резервируется до загрузки, чтобы параллельные загрузки не превысили квоту
+	var reserved int64
+	if !params.SkipQuota {
+		if err := r.reserveStorage(ctx, params.AccountID, params.Size, params.DefaultQuota); err != nil {
+			return models.File{}, err
+		}
+		reserved = params.Size
+	}
+	defer func() {
+		if reserved != 0 {
+			r.releaseStorage(ctx, params.AccountID, reserved)
+		}
+	}()
+
 	key, err := uuid.NewV7()
 	if err != nil {
 		return models.File{}, fmt.Errorf("failed to gen file key: %w", err)
@@ -60,14 +89,20 @@ func (r *filesRepo) UploadFile(ctx context.Context, params UploadFileParams) (mo
 	if err != nil {
 		return models.File{}, fmt.Errorf("upload file: %w", err)
 	}
+	if reserved != 0 && uploadInfo.Size != params.Size {
+		// размер мог быть неизвестен заранее, учитываем фактический
+		r.releaseStorage(ctx, params.AccountID, params.Size-uploadInfo.Size)
+		reserved = uploadInfo.Size
+	}
 
 	var fileID int64
 	uploadedAt := time.Now()
 	query := `INSERT INTO files 
-	(uploader_user_id, name, content_type, bucket, key, uploaded_at, size)
-	VALUES($1, $2, $3, $4, $5, $6, $7) RETURNING id`
+	(uploader_user_id, account_id, name, content_type, bucket, key, uploaded_at, size)
+	VALUES($1, $2, $3, $4, $5, $6, $7, $8) RETURNING id`
 	err = r.db.QueryRow(ctx, query,
 		params.UploaderUserID,
+		params.AccountID,
 		params.Name,
 		params.ContentType,
 		uploadInfo.Bucket,
@@ -82,16 +117,20 @@ func (r *filesRepo) UploadFile(ctx context.Context, params UploadFileParams) (mo
 
 		var pgErr *pgconn.PgError
 		if errors.As(err, &pgErr) {
-			switch pgErr.Code {
-			case "23503":
+			switch pgErr.ConstraintName {
+			case "fk_files__uploader_user_id":
 				return models.File{}, ErrUserNotFound
+			case "fk_files__account_id":
+				return models.File{}, ErrAccountNotFound
 			}
 		}
 		return models.File{}, fmt.Errorf("insert into files: %w", err)
 	}
+	reserved = 0
 	return models.File{
 		ID:             fileID,
 		UploaderUserID: params.UploaderUserID,
+		AccountID:      &params.AccountID,
 		Name:           params.Name,
 		ContentType:    params.ContentType,
 		Bucket:         uploadInfo.Bucket,
@@ -101,17 +140,99 @@ func (r *filesRepo) UploadFile(ctx context.Context, params UploadFileParams) (mo
 	}, nil
 }
 
-func (r *filesRepo) GetFileInfo(ctx context.Context, fileID int64) (models.File, error) {
-	file := models.File{}
-	const query = `SELECT id, uploader_user_id, name, content_type, bucket, key, uploaded_at, size WHERE id = $1`
-	err := r.db.QueryRow(ctx, query, fileID).Scan(
-		file.ID,
+type CopyFileParams struct {
+	FileID         int64
+	UploaderUserID int64
+	// AccountID - аккаунт, в квоту которого засчитывается копия
+	AccountID    int64
+	DefaultQuota int64
+}
+
+// CopyFile копирует объект внутри хранилища и создаёт для копии отдельную запись о файле
+func (r *filesRepo) CopyFile(ctx context.Context, params CopyFileParams) (models.File, error) {
+	src, err := r.GetFileInfo(ctx, params.FileID)
+	if err != nil {
+		return models.File{}, err
+	}
+
+	if err := r.reserveStorage(ctx, params.AccountID, src.Size, params.DefaultQuota); err != nil {
+		return models.File{}, err
+	}
+	reserved := src.Size
+	defer func() {
+		if reserved != 0 {
+			r.releaseStorage(ctx, params.AccountID, reserved)
+		}
+	}()
+
+	key, err := uuid.NewV7()
+	if err != nil {
+		return models.File{}, fmt.Errorf("failed to gen file key: %w", err)
+	}
+
+	// ComposeObject, в отличие от CopyObject, копирует и объекты больше 5 ГиБ
+	dst := minio.CopyDestOptions{Bucket: r.bucket, Object: key.String()}
+	info, err := r.miniocli.ComposeObject(ctx, dst, minio.CopySrcOptions{Bucket: src.Bucket, Object: src.Key})
+	if err != nil {
+		return models.File{}, fmt.Errorf("copy object: %w", err)
+	}
+
+	file := models.File{
+		UploaderUserID: params.UploaderUserID,
+		AccountID:      &params.AccountID,
+		Name:           src.Name,
+		ContentType:    src.ContentType,
+		Bucket:         info.Bucket,
+		Key:            info.Key,
+		Size:           src.Size,
+		UploadedAt:     time.Now(),
+	}
+	const query = `INSERT INTO files 
+	(uploader_user_id, account_id, name, content_type, bucket, key, uploaded_at, size)
+	VALUES($1, $2, $3, $4, $5, $6, $7, $8) RETURNING id`
+	err = r.db.QueryRow(ctx, query,
 		file.UploaderUserID,
+		params.AccountID,
 		file.Name,
 		file.ContentType,
 		file.Bucket,
 		file.Key,
-		file.UploadedAt,
+		file.UploadedAt.UTC(),
+		file.Size,
+	).Scan(&file.ID)
+	if err != nil {
+		if err := r.miniocli.RemoveObject(ctx, info.Bucket, info.Key, minio.RemoveObjectOptions{}); err != nil {
+			log.Errorf("remove object: %v", err)
+		}
+
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) {
+			switch pgErr.ConstraintName {
+			case "fk_files__uploader_user_id":
+				return models.File{}, ErrUserNotFound
+			case "fk_files__account_id":
+				return models.File{}, ErrAccountNotFound
+			}
+		}
+		return models.File{}, fmt.Errorf("insert into files: %w", err)
+	}
+	reserved = 0
+	return file, nil
+}
+
+func (r *filesRepo) GetFileInfo(ctx context.Context, fileID int64) (models.File, error) {
+	file := models.File{}
+	const query = `SELECT id, uploader_user_id, account_id, name, content_type, bucket, key, uploaded_at, size FROM files WHERE id = $1`
+	err := r.db.QueryRow(ctx, query, fileID).Scan(
+		&file.ID,
+		&file.UploaderUserID,
+		&file.AccountID,
+		&file.Name,
+		&file.ContentType,
+		&file.Bucket,
+		&file.Key,
+		&file.UploadedAt,
+		&file.Size,
 	)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
@@ -122,7 +243,7 @@ func (r *filesRepo) GetFileInfo(ctx context.Context, fileID int64) (models.File,
 	return file, nil
 }
 
-func (r *filesRepo) GetFileByID(ctx context.Context, fileID int64) (io.Reader, error) {
+func (r *filesRepo) GetFileByID(ctx context.Context, fileID int64) (io.ReadCloser, error) {
 	fi, err := r.GetFileInfo(ctx, fileID)
 	if err != nil {
 		if errors.Is(err, ErrFileNotFound) {
@@ -139,6 +260,135 @@ func (r *filesRepo) GetFileByID(ctx context.Context, fileID int64) (io.Reader, e
 	return obj, nil
 }
 
+type ReadAtCloser interface {
+	io.ReaderAt
+	io.Closer
+}
+
+func (r *filesRepo) OpenFileAt(ctx context.Context, file models.File) (ReadAtCloser, error) {
+	obj, err := r.miniocli.GetObject(ctx, file.Bucket, file.Key, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get object: %w", err)
+	}
+	return obj, nil
+}
+
+// RemoveFile удаляет объект из MinIO и запись о файле. Занятое аккаунтом место
+// не пересчитывается: файл удаляется служебно, вместе с аккаунтом или архивом.
+func (r *filesRepo) RemoveFile(ctx context.Context, file models.File) error {
+	err := r.miniocli.RemoveObject(ctx, file.Bucket, file.Key, minio.RemoveObjectOptions{})
+	if err != nil {
+		return fmt.Errorf("remove object: %w", err)
+	}
+
+	const query = `DELETE FROM files WHERE id = $1`
+	if _, err := r.db.Exec(ctx, query, file.ID); err != nil {
+		return fmt.Errorf("delete file: %w", err)
+	}
+	return nil
+}
+
+// DiscardFile удаляет файл, засчитанный в квоту аккаунта, и освобождает занятое им место.
+// Используется для отката незавершённых операций, создавших файл.
+func (r *filesRepo) DiscardFile(ctx context.Context, file models.File) error {
+	if err := r.RemoveFile(ctx, file); err != nil {
+		return err
+	}
+	if file.AccountID != nil {
+		r.releaseStorage(ctx, *file.AccountID, file.Size)
+	}
+	return nil
+}
+
+/* ===================== Storage ===================== */
+
+// reserveStorage атомарно увеличивает занятое аккаунтом место, если оно укладывается в квоту
+func (r *filesRepo) reserveStorage(ctx context.Context, accountID, size, defaultQuota int64) error {
+	const ensureQuery = `INSERT INTO account_storage (account_id) VALUES ($1) ON CONFLICT (account_id) DO NOTHING`
+	if _, err := r.db.Exec(ctx, ensureQuery, accountID); err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23503" {
+			return ErrAccountNotFound
+		}
+		return fmt.Errorf("insert account storage: %w", err)
+	}
+
+	const query = `
+		UPDATE account_storage SET used_bytes = used_bytes + $2
+		WHERE account_id = $1
+		  AND (COALESCE(quota_bytes, $3) <= 0 OR used_bytes + $2 <= COALESCE(quota_bytes, $3))
+	`
+	cmdTag, err := r.db.Exec(ctx, query, accountID, size, defaultQuota)
+	if err != nil {
+		return fmt.Errorf("reserve storage: %w", err)
+	}
+	if cmdTag.RowsAffected() == 0 {
+		return ErrStorageQuotaExceeded
+	}
+	return nil
+}
+
+// releaseStorage возвращает зарезервированное место. Ошибка только логируется:
+// загрузка к этому моменту уже завершилась неудачей и важнее вернуть исходную ошибку.
+func (r *filesRepo) releaseStorage(ctx context.Context, accountID, size int64) {
+	const query = `UPDATE account_storage SET used_bytes = GREATEST(used_bytes - $2, 0) WHERE account_id = $1`
+	if _, err := r.db.Exec(ctx, query, accountID, size); err != nil {
+		log.Errorf("release storage of account %d: %v", accountID, err)
+	}
+}
+
+type GetStorageUsageParams struct {
+	AccountID    int64
+	DefaultQuota int64
+}
+
+func (r *filesRepo) GetStorageUsage(ctx context.Context, params GetStorageUsageParams) (models.StorageUsage, error) {
+	usage := models.StorageUsage{AccountID: params.AccountID}
+
+	const usageQuery = `
+		SELECT COALESCE(s.used_bytes, 0), COALESCE(s.quota_bytes, $2)
+		FROM accounts a
+		LEFT JOIN account_storage s ON s.account_id = a.id
+		WHERE a.id = $1
+	`
+	var quota int64
+	err := r.db.QueryRow(ctx, usageQuery, params.AccountID, params.DefaultQuota).Scan(&usage.UsedBytes, &quota)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.StorageUsage{}, ErrAccountNotFound
+		}
+		return models.StorageUsage{}, fmt.Errorf("select account storage: %w", err)
+	}
+	if quota > 0 {
+		usage.QuotaBytes = &quota
+	}
+
+	const breakdownQuery = `
+		SELECT content_type, COUNT(*), SUM(size)
+		FROM files
+		WHERE account_id = $1
+		GROUP BY content_type
+		ORDER BY SUM(size) DESC
+	`
+	rows, err := r.db.Query(ctx, breakdownQuery, params.AccountID)
+	if err != nil {
+		return models.StorageUsage{}, fmt.Errorf("query storage breakdown: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var ct models.ContentTypeUsage
+		if err := rows.Scan(&ct.ContentType, &ct.Files, &ct.Bytes); err != nil {
+			return models.StorageUsage{}, fmt.Errorf("scan storage breakdown: %w", err)
+		}
+		usage.ByContentType = append(usage.ByContentType, ct)
+	}
+	if err := rows.Err(); err != nil {
+		return models.StorageUsage{}, fmt.Errorf("rows error: %w", err)
+	}
+	return usage, nil
+}
+
 // func (r *filesRepo) GetFileByKey(ctx context.Context, fileID int64) (io.Reader, error) {
 // 	fi, err := r.GetFileInfo(ctx, fileID)
 // 	if err != nil {
@@ -0,0 +1,188 @@
+package repo
+
+import (
+	"chalk/internal/repo/models"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+type InvitationsRepo interface {
+	CreateInvitation(ctx context.Context, params CreateInvitationParams) (int64, error)
+	HasInvitation(ctx context.Context, accountID int64, email string) (bool, error)
+	AcceptInvitations(ctx context.Context, userID int64, email string) ([]models.AccountInvitation, error)
+}
+
+func NewInvitationsRepo(db DB) InvitationsRepo {
+	return &invitationsRepo{db: db}
+}
+
+type invitationsRepo struct {
+	db DB
+}
+
+/* ===================== Invitations ===================== */
+
+type CreateInvitationParams struct {
+	AccountID int64
+	Email     string
+	Name      string
+	Role      models.AccountMembersRole
+	Groups    []string
+	InvitedBy int64
+}
+
+// CreateInvitation создаёт приглашение, если в тарифе аккаунта есть свободное место,
+// иначе возвращает ErrSeatLimitReached
+func (r *invitationsRepo) CreateInvitation(ctx context.Context, params CreateInvitationParams) (int64, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := reserveSeats(ctx, tx, params.AccountID, 1); err != nil {
+		return 0, err
+	}
+
+	const query = `
+		INSERT INTO account_invitations (account_id, email, name, role, groups, invited_by, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id
+	`
+	groups := params.Groups
+	if groups == nil {
+		groups = []string{}
+	}
+
+	var id int64
+	err = tx.QueryRow(ctx, query,
+		params.AccountID,
+		params.Email,
+		params.Name,
+		params.Role,
+		groups,
+		params.InvitedBy,
+		time.Now().UTC(),
+	).Scan(&id)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) {
+			switch pgErr.Code {
+			case "23503":
+				if pgErr.ConstraintName == "fk_account_invitations__account_id" {
+					return 0, ErrAccountNotFound
+				}
+				return 0, ErrUserNotFound
+			case "23505":
+				return 0, ErrUniqueViolation
+			}
+		}
+		return 0, fmt.Errorf("insert invitation: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("commit tx: %w", err)
+	}
+	return id, nil
+}
+
+func (r *invitationsRepo) HasInvitation(ctx context.Context, accountID int64, email string) (bool, error) {
+	const query = `SELECT EXISTS(SELECT 1 FROM account_invitations WHERE account_id = $1 AND email = $2)`
+	var exists bool
+	if err := r.db.QueryRow(ctx, query, accountID, email).Scan(&exists); err != nil {
+		return false, fmt.Errorf("check invitation: %w", err)
+	}
+	return exists, nil
+}
+
+// AcceptInvitations превращает приглашения на email в участие пользователя в аккаунтах
+// вместе с группами и возвращает принятые приглашения
+func (r *invitationsRepo) AcceptInvitations(ctx context.Context, userID int64, email string) ([]models.AccountInvitation, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	const deleteQuery = `
+		DELETE FROM account_invitations WHERE email = $1
+		RETURNING id, account_id, email, name, role, groups, invited_by, created_at
+	`
+	rows, err := tx.Query(ctx, deleteQuery, email)
+	if err != nil {
+		return nil, fmt.Errorf("delete invitations: %w", err)
+	}
+	var invitations []models.AccountInvitation
+	for rows.Next() {
+		var inv models.AccountInvitation
+		err := rows.Scan(&inv.ID, &inv.AccountID, &inv.Email, &inv.Name, &inv.Role, &inv.Groups, &inv.InvitedBy, &inv.CreatedAt)
+		if err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("scan invitation: %w", err)
+		}
+		invitations = append(invitations, inv)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	const memberQuery = `
+		INSERT INTO account_members (user_id, account_id, role) VALUES ($1, $2, $3)
+		ON CONFLICT (user_id, account_id) DO NOTHING
+	`
+	for _, inv := range invitations {
+		if _, err := tx.Exec(ctx, memberQuery, userID, inv.AccountID, inv.Role); err != nil {
+			return nil, fmt.Errorf("insert account member: %w", err)
+		}
+		if err := addUserToGroups(ctx, tx, inv.AccountID, userID, inv.Groups); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("commit tx: %w", err)
+	}
+	return invitations, nil
+}
+
+/* ===================== Groups ===================== */
+
+// addUserToGroups добавляет пользователя в группы аккаунта, отсутствующие группы создаются
+func addUserToGroups(ctx context.Context, tx pgx.Tx, accountID, userID int64, groups []string) error {
+	if len(groups) == 0 {
+		return nil
+	}
+
+	const groupsQuery = `
+		INSERT INTO account_groups (account_id, name)
+		SELECT $1, unnest($2::TEXT[])
+		ON CONFLICT (account_id, name) DO NOTHING
+	`
+	if _, err := tx.Exec(ctx, groupsQuery, accountID, groups); err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23503" {
+			return ErrAccountNotFound
+		}
+		return fmt.Errorf("insert groups: %w", err)
+	}
+
+	const membersQuery = `
+		INSERT INTO account_group_members (group_id, user_id)
+		SELECT id, $3 FROM account_groups WHERE account_id = $1 AND name = ANY($2::TEXT[])
+		ON CONFLICT (group_id, user_id) DO NOTHING
+	`
+	if _, err := tx.Exec(ctx, membersQuery, accountID, groups, userID); err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23503" {
+			return ErrUserNotFound
+		}
+		return fmt.Errorf("insert group members: %w", err)
+	}
+	return nil
+}
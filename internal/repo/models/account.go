@@ -1,5 +1,7 @@
 package models
 
+import "time"
+
 type AccountMembersRole string
 
 const (
@@ -19,4 +21,24 @@ type AccountMember struct {
 type Account struct {
 	ID   int64
 	Name string
+	Slug string
+	// ClosureRequestedAt - время запроса закрытия, закрывающийся аккаунт доступен только для чтения
+	ClosureRequestedAt  *time.Time
+	DeletionScheduledAt *time.Time
+}
+
+// IsClosing сообщает, что аккаунт ожидает удаления
+func (a Account) IsClosing() bool {
+	return a.ClosureRequestedAt != nil
+}
+
+type AccountSettings struct {
+	AccountID       int64
+	DisplayName     string
+	LogoFileID      *int64
+	FaviconFileID   *int64
+	PrimaryColor    string
+	AccentColor     string
+	SupportEmail    string
+	EmailSenderName string
 }
@@ -0,0 +1,48 @@
+package models
+
+import (
+	"chalk/pkg/assignment"
+	"time"
+)
+
+type SubmissionStatus string
+
+const (
+	SubmissionSubmitted SubmissionStatus = "submitted"
+	SubmissionGraded    SubmissionStatus = "graded"
+	// SubmissionReturned - работа возвращена на доработку, участник может сдать её снова
+	SubmissionReturned SubmissionStatus = "returned"
+)
+
+// Submission - работа участника по заданию, одна на участника и задание
+type Submission struct {
+	ID      int64
+	BlockID int64
+	UserID  int64
+	Status  SubmissionStatus
+	// Score - результат последней оценки в процентах, nil если работа сдана после неё
+	Score *float64
+	// SubmittedAt - последняя сдача
+	SubmittedAt time.Time
+	UpdatedAt   time.Time
+	// Assignment - задание, которое видел участник при последней сдаче, по нему ставится оценка.
+	// Как и History, заполняется только при чтении одной работы.
+	Assignment *assignment.Assignment
+	History    []SubmissionEvent
+}
+
+// SubmissionEvent - сдача, оценка или возврат работы. Action совпадает со статусом,
+// в который событие перевело работу.
+type SubmissionEvent struct {
+	ID      int64
+	Action  SubmissionStatus
+	ActorID *int64
+	// Text и Files - содержимое сдачи
+	Text  string
+	Files []File
+	// Grades и Score - оценка по рубрике
+	Grades    []assignment.Grade
+	Score     *float64
+	Comment   string
+	CreatedAt time.Time
+}
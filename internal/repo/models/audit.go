@@ -0,0 +1,57 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type AuditAction string
+
+const (
+	AuditActionAccountCreate    AuditAction = "account.create"
+	AuditActionMemberAdd        AuditAction = "member.add"
+	AuditActionMemberInvite     AuditAction = "member.invite"
+	AuditActionMemberRemove     AuditAction = "member.remove"
+	AuditActionMemberRoleChange AuditAction = "member.role_change"
+	AuditActionSettingsUpdate   AuditAction = "account.settings_update"
+	AuditActionTrashRestore     AuditAction = "trash.restore"
+
+	AuditActionAccountClosureRequest AuditAction = "account.closure_request"
+	AuditActionAccountClosureCancel  AuditAction = "account.closure_cancel"
+
+	AuditActionContentCreate   AuditAction = "content.create"
+	AuditActionContentUpdate   AuditAction = "content.update"
+	AuditActionContentReorder  AuditAction = "content.reorder"
+	AuditActionContentMove     AuditAction = "content.move"
+	AuditActionContentDelete   AuditAction = "content.delete"
+	AuditActionCoursePublish   AuditAction = "course.publish"
+	AuditActionCourseRollback  AuditAction = "course.rollback"
+	AuditActionCourseDuplicate AuditAction = "course.duplicate"
+	AuditActionCourseImport    AuditAction = "course.import"
+	AuditActionCourseSync      AuditAction = "course.sync"
+)
+
+type AuditTargetType string
+
+const (
+	AuditTargetAccount    AuditTargetType = "account"
+	AuditTargetMember     AuditTargetType = "member"
+	AuditTargetInvitation AuditTargetType = "invitation"
+	AuditTargetCourse     AuditTargetType = "course"
+	AuditTargetModule     AuditTargetType = "module"
+	AuditTargetLesson     AuditTargetType = "lesson"
+	AuditTargetBlock      AuditTargetType = "block"
+)
+
+type AuditEntry struct {
+	ID          int64
+	AccountID   int64
+	ActorUserID *int64
+	Action      AuditAction
+	TargetType  AuditTargetType
+	TargetID    *int64
+	Before      json.RawMessage
+	After       json.RawMessage
+	IP          string
+	CreatedAt   time.Time
+}
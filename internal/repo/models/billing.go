@@ -0,0 +1,31 @@
+package models
+
+import "time"
+
+const DefaultPlanID = "free"
+
+// Plan - тариф, nil в лимитах - без ограничений
+type Plan struct {
+	ID           string
+	Name         string
+	MaxSeats     *int
+	MaxCourses   *int
+	StorageBytes *int64
+}
+
+type SubscriptionStatus string
+
+const (
+	SubscriptionStatusActive    SubscriptionStatus = "active"
+	SubscriptionStatusPastDue   SubscriptionStatus = "past_due"
+	SubscriptionStatusCancelled SubscriptionStatus = "cancelled"
+)
+
+type Subscription struct {
+	AccountID              int64
+	Plan                   Plan
+	Status                 SubscriptionStatus
+	Provider               string
+	ProviderSubscriptionID *string
+	CurrentPeriodEnd       *time.Time
+}
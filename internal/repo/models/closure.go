@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+type AccountClosure struct {
+	AccountID           int64
+	RequestedAt         time.Time
+	RequestedBy         *int64
+	DeletionScheduledAt time.Time
+	// ExportFileID - архив с данными аккаунта, nil пока архив не собран
+	ExportFileID *int64
+}
+
+type Enrollment struct {
+	CourseID int64
+	UserID   int64
+}
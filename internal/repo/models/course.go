@@ -1,5 +1,14 @@
 package models
 
+import (
+	"chalk/pkg/assignment"
+	"chalk/pkg/codeexercise"
+	"chalk/pkg/media"
+	"chalk/pkg/quiz"
+	"encoding/json"
+	"time"
+)
+
 type Course struct {
 	ID        int64
 	AccountID int64
@@ -25,6 +34,17 @@ type BlockType string
 const (
 	BlockTypeVideo BlockType = "video"
 	BlockTypeText  BlockType = "text"
+	BlockTypeSCORM BlockType = "scorm"
+	BlockTypeQuiz  BlockType = "quiz"
+	// BlockTypeAssignment - задание, которое проверяет преподаватель
+	BlockTypeAssignment BlockType = "assignment"
+	// BlockTypeCodeExercise - упражнение, код которого проверяется тестами
+	BlockTypeCodeExercise BlockType = "code_exercise"
+	BlockTypeImage        BlockType = "image"
+	// BlockTypeAttachment - файл, который участник скачивает
+	BlockTypeAttachment BlockType = "attachment"
+	// BlockTypeEmbed - страница стороннего сервиса в iframe
+	BlockTypeEmbed BlockType = "embed"
 )
 
 type BaseBlock struct {
@@ -44,12 +64,99 @@ type TextBlock struct {
 	Content string
 }
 
+// ScormBlock - блок с пакетом SCORM, FileID - zip пакета
+type ScormBlock struct {
+	BaseBlock
+	FileID int64
+}
+
+// QuizBlock - тест с верными ответами, участникам отдаётся без них
+type QuizBlock struct {
+	BaseBlock
+	Quiz quiz.Quiz
+}
+
+type AssignmentBlock struct {
+	BaseBlock
+	Assignment assignment.Assignment
+}
+
+type CodeExerciseBlock struct {
+	BaseBlock
+	Exercise codeexercise.Exercise
+}
+
+type ImageBlock struct {
+	BaseBlock
+	FileID  int64
+	AltText string
+	Caption string
+}
+
+// AttachmentBlock - вложение, пустой Title - показывается имя файла
+type AttachmentBlock struct {
+	BaseBlock
+	FileID int64
+	Title  string
+}
+
+// EmbedBlock - встроенная страница, Title - заголовок iframe для экранных дикторов
+type EmbedBlock struct {
+	BaseBlock
+	Embed media.Embed
+	Title string
+}
+
 type CourseHierarchy struct {
 	Course  *Course
 	Modules []*ModuleHierarchy
 }
 
+func (h CourseHierarchy) MarshalBinary() ([]byte, error) {
+	return json.Marshal(h)
+}
+
+func (h *CourseHierarchy) UnmarshalBinary(data []byte) error {
+	return json.Unmarshal(data, h)
+}
+
 type ModuleHierarchy struct {
 	Module  *Module
-	Lessons []*Lesson
+	Lessons []*LessonHierarchy
+}
+
+type LessonHierarchy struct {
+	Lesson *Lesson
+	Blocks []*BlockSummary
+}
+
+// BlockSummary - блок без содержимого, для текстовых блоков только начало текста
+type BlockSummary struct {
+	BaseBlock
+	FileID  *int64
+	Preview string
+}
+
+// ItemOwner - курс и аккаунт, которым принадлежит элемент курса
+type ItemOwner struct {
+	AccountID int64
+	CourseID  int64
+}
+
+type TrashItemType string
+
+const (
+	TrashItemCourse TrashItemType = "course"
+	TrashItemModule TrashItemType = "module"
+	TrashItemLesson TrashItemType = "lesson"
+	TrashItemBlock  TrashItemType = "block"
+)
+
+type TrashItem struct {
+	Type      TrashItemType
+	ID        int64
+	ParentID  *int64
+	Name      string
+	DeletedAt time.Time
+	DeletedBy *int64
 }
@@ -0,0 +1,18 @@
+package models
+
+type CourseSourceType string
+
+const (
+	CourseSourceModule CourseSourceType = "module"
+	CourseSourceLesson CourseSourceType = "lesson"
+	CourseSourceFile   CourseSourceType = "file"
+)
+
+// CourseSource связывает путь в каталоге Markdown с элементом курса или загруженным файлом
+type CourseSource struct {
+	Path     string
+	ItemType CourseSourceType
+	ItemID   int64
+	// Checksum - sha256 содержимого файла, по нему повторная синхронизация не загружает файл заново
+	Checksum string
+}
@@ -0,0 +1,76 @@
+package models
+
+import (
+	"chalk/pkg/assignment"
+	"chalk/pkg/codeexercise"
+	"chalk/pkg/media"
+	"chalk/pkg/quiz"
+	"time"
+)
+
+// CourseSnapshot - полное дерево курса с содержимым блоков. Хранится в JSON
+// опубликованных версий, поэтому теги полей менять нельзя.
+type CourseSnapshot struct {
+	ID      int64            `json:"id"`
+	Name    string           `json:"name"`
+	Modules []ModuleSnapshot `json:"modules"`
+}
+
+type ModuleSnapshot struct {
+	ID       int64            `json:"id"`
+	OrderIdx int              `json:"order_idx"`
+	Name     string           `json:"name"`
+	Lessons  []LessonSnapshot `json:"lessons"`
+}
+
+type LessonSnapshot struct {
+	ID       int64           `json:"id"`
+	OrderIdx int             `json:"order_idx"`
+	Name     string          `json:"name"`
+	Blocks   []BlockSnapshot `json:"blocks"`
+}
+
+type BlockSnapshot struct {
+	ID           int64                  `json:"id"`
+	OrderIdx     int                    `json:"order_idx"`
+	Type         BlockType              `json:"type"`
+	Content      *string                `json:"content,omitempty"`
+	FileID       *int64                 `json:"file_id,omitempty"`
+	Quiz         *quiz.Quiz             `json:"quiz,omitempty"`
+	Assignment   *assignment.Assignment `json:"assignment,omitempty"`
+	CodeExercise *codeexercise.Exercise `json:"code_exercise,omitempty"`
+	// AltText и Caption - у картинок, Title - у вложений и встроенных страниц
+	AltText string       `json:"alt_text,omitempty"`
+	Caption string       `json:"caption,omitempty"`
+	Title   string       `json:"title,omitempty"`
+	Embed   *media.Embed `json:"embed,omitempty"`
+}
+
+type CourseVersion struct {
+	ID          int64
+	CourseID    int64
+	Version     int
+	PublishedAt time.Time
+	PublishedBy *int64
+	// Current - эту версию сейчас видят участники курса
+	Current bool
+	// Snapshot заполняется только при чтении одной версии
+	Snapshot *CourseSnapshot
+}
+
+type CourseChangeType string
+
+const (
+	CourseChangeAdded    CourseChangeType = "added"
+	CourseChangeRemoved  CourseChangeType = "removed"
+	CourseChangeModified CourseChangeType = "modified"
+	CourseChangeMoved    CourseChangeType = "moved"
+)
+
+// CourseChange - отличие черновика от опубликованной версии
+type CourseChange struct {
+	ItemType TrashItemType
+	ID       int64
+	Name     string
+	Change   CourseChangeType
+}
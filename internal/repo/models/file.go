@@ -5,10 +5,26 @@ import "time"
 type File struct {
 	ID             int64
 	UploaderUserID int64
-	Name           string
-	ContentType    string
-	Bucket         string
-	Key            string
-	Size           int64
-	UploadedAt     time.Time
+	// AccountID - аккаунт, в квоту которого засчитан файл, nil у старых непривязанных файлов
+	AccountID   *int64
+	Name        string
+	ContentType string
+	Bucket      string
+	Key         string
+	Size        int64
+	UploadedAt  time.Time
+}
+
+type StorageUsage struct {
+	AccountID int64
+	UsedBytes int64
+	// QuotaBytes - действующая квота, nil - без ограничений
+	QuotaBytes    *int64
+	ByContentType []ContentTypeUsage
+}
+
+type ContentTypeUsage struct {
+	ContentType string
+	Files       int64
+	Bytes       int64
 }
@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+type AccountInvitation struct {
+	ID        int64
+	AccountID int64
+	Email     string
+	Name      string
+	Role      AccountMembersRole
+	Groups    []string
+	InvitedBy *int64
+	CreatedAt time.Time
+}
@@ -0,0 +1,40 @@
+package models
+
+import (
+	"chalk/pkg/codeexercise"
+	"chalk/pkg/quiz"
+	"time"
+)
+
+// BlockProgress - прохождение блока участником курса
+type BlockProgress struct {
+	BlockID int64
+	UserID  int64
+	// CompletedAt - первое завершение блока, nil - блок не завершён
+	CompletedAt *time.Time
+	// Score - результат в процентах, nil - блок не оценивается
+	Score     *float64
+	UpdatedAt time.Time
+}
+
+// QuizAttempt - проверенная попытка теста, AttemptNo идёт с 1
+type QuizAttempt struct {
+	ID          int64
+	BlockID     int64
+	UserID      int64
+	AttemptNo   int
+	Answers     []quiz.Answer
+	Result      quiz.Result
+	SubmittedAt time.Time
+}
+
+// CodeSubmission - проверенное решение упражнения
+type CodeSubmission struct {
+	ID          int64
+	BlockID     int64
+	UserID      int64
+	Language    string
+	Code        string
+	Result      codeexercise.Result
+	SubmittedAt time.Time
+}
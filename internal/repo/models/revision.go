@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+type RevisionField string
+
+const (
+	RevisionFieldName     RevisionField = "name"
+	RevisionFieldContent  RevisionField = "content"
+	RevisionFieldOrderIdx RevisionField = "order_idx"
+	// RevisionFieldQuiz - тест блока целиком в JSON
+	RevisionFieldQuiz RevisionField = "quiz"
+	// RevisionFieldAssignment - задание блока целиком в JSON
+	RevisionFieldAssignment RevisionField = "assignment"
+	// RevisionFieldCodeExercise - упражнение блока целиком в JSON
+	RevisionFieldCodeExercise RevisionField = "code_exercise"
+	// RevisionFieldParentID - родитель урока или блока, меняется при переносе
+	RevisionFieldParentID RevisionField = "parent_id"
+)
+
+type Revision struct {
+	ID        int64
+	AccountID int64
+	ItemType  TrashItemType
+	ItemID    int64
+	Field     RevisionField
+	// OldValue - значение до правки, nil если неизвестно
+	OldValue  *string
+	Value     string
+	AuthorID  *int64
+	CreatedAt time.Time
+}
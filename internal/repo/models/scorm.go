@@ -0,0 +1,34 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// ScormSession - запуск пакета участником. Идентификатор сессии передаётся в пути
+// запросов к файлам пакета и API среды выполнения вместо токена доступа.
+type ScormSession struct {
+	AccountID  int64
+	CourseID   int64
+	BlockID    int64
+	UserID     int64
+	FileID     int64
+	Version    string
+	LaunchPath string
+}
+
+func (s ScormSession) MarshalBinary() ([]byte, error) {
+	return json.Marshal(s)
+}
+
+func (s *ScormSession) UnmarshalBinary(data []byte) error {
+	return json.Unmarshal(data, s)
+}
+
+// ScormRuntimeData - значения модели данных cmi, сохранённые пакетом
+type ScormRuntimeData struct {
+	BlockID   int64
+	UserID    int64
+	Values    map[string]string
+	UpdatedAt time.Time
+}
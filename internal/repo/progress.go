@@ -0,0 +1,308 @@
+package repo
+
+import (
+	"chalk/internal/repo/models"
+	"chalk/pkg/codeexercise"
+	"chalk/pkg/quiz"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+type ProgressRepo interface {
+	// GetScormRuntimeData возвращает сохранённые значения cmi, пустые если пакет ещё ничего не сохранял
+	GetScormRuntimeData(ctx context.Context, blockID, userID int64) (models.ScormRuntimeData, error)
+	// SaveScormRuntimeData дописывает значения к сохранённым и возвращает все значения
+	SaveScormRuntimeData(ctx context.Context, params SaveScormRuntimeDataParams) (models.ScormRuntimeData, error)
+	UpdateBlockProgress(ctx context.Context, params UpdateBlockProgressParams) (models.BlockProgress, error)
+	// GetCourseProgress возвращает прохождение неудалённых блоков курса участником
+	GetCourseProgress(ctx context.Context, courseID, userID int64) ([]models.BlockProgress, error)
+	// CreateQuizAttempt сохраняет попытку со следующим номером, ErrQuizAttemptLimitReached если попытки кончились
+	CreateQuizAttempt(ctx context.Context, params CreateQuizAttemptParams) (models.QuizAttempt, error)
+	// GetQuizAttempts возвращает попытки участника по порядку
+	GetQuizAttempts(ctx context.Context, blockID, userID int64) ([]models.QuizAttempt, error)
+	CreateCodeSubmission(ctx context.Context, params CreateCodeSubmissionParams) (models.CodeSubmission, error)
+	// GetCodeSubmissions возвращает последние limit решений участника, новые первыми
+	GetCodeSubmissions(ctx context.Context, blockID, userID int64, limit int) ([]models.CodeSubmission, error)
+}
+
+func NewProgressRepo(db DB) ProgressRepo {
+	return &progressRepo{db: db}
+}
+
+type progressRepo struct {
+	db DB
+}
+
+func (r *progressRepo) GetScormRuntimeData(ctx context.Context, blockID, userID int64) (models.ScormRuntimeData, error) {
+	data := models.ScormRuntimeData{BlockID: blockID, UserID: userID}
+	const query = `SELECT data, updated_at FROM scorm_runtime_data WHERE block_id = $1 AND user_id = $2`
+	err := r.db.QueryRow(ctx, query, blockID, userID).Scan(&data.Values, &data.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			data.Values = map[string]string{}
+			return data, nil
+		}
+		return models.ScormRuntimeData{}, fmt.Errorf("select scorm runtime data: %w", err)
+	}
+	return data, nil
+}
+
+type SaveScormRuntimeDataParams struct {
+	BlockID int64
+	UserID  int64
+	Values  map[string]string
+}
+
+func (r *progressRepo) SaveScormRuntimeData(ctx context.Context, params SaveScormRuntimeDataParams) (models.ScormRuntimeData, error) {
+	data := models.ScormRuntimeData{BlockID: params.BlockID, UserID: params.UserID}
+	// слияние в одном запросе, чтобы параллельные сохранения не затирали значения друг друга
+	const query = `
+		INSERT INTO scorm_runtime_data (block_id, user_id, data, updated_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (block_id, user_id) DO UPDATE
+		SET data = scorm_runtime_data.data || EXCLUDED.data, updated_at = EXCLUDED.updated_at
+		RETURNING data, updated_at
+	`
+	err := r.db.QueryRow(ctx, query, params.BlockID, params.UserID, params.Values, time.Now().UTC()).Scan(&data.Values, &data.UpdatedAt)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) {
+			switch {
+			case pgErr.Code == "23514":
+				return models.ScormRuntimeData{}, ErrScormDataTooLarge
+			case pgErr.ConstraintName == "fk_scorm_runtime_data__block_id":
+				return models.ScormRuntimeData{}, ErrBlockNotFound
+			case pgErr.ConstraintName == "fk_scorm_runtime_data__user_id":
+				return models.ScormRuntimeData{}, ErrUserNotFound
+			}
+		}
+		return models.ScormRuntimeData{}, fmt.Errorf("upsert scorm runtime data: %w", err)
+	}
+	return data, nil
+}
+
+type UpdateBlockProgressParams struct {
+	BlockID   int64
+	UserID    int64
+	Completed bool
+	// Score - nil оставляет сохранённый результат
+	Score *float64
+	// KeepBest сохраняет результат, только если он лучше сохранённого
+	KeepBest bool
+}
+
+// UpdateBlockProgress сохраняет прохождение блока. Время завершения не сбрасывается:
+// блок, завершённый однажды, остаётся завершённым.
+func (r *progressRepo) UpdateBlockProgress(ctx context.Context, params UpdateBlockProgressParams) (models.BlockProgress, error) {
+	now := time.Now().UTC()
+	var completedAt *time.Time
+	if params.Completed {
+		completedAt = &now
+	}
+
+	p := models.BlockProgress{BlockID: params.BlockID, UserID: params.UserID}
+	const query = `
+		INSERT INTO block_progress (block_id, user_id, completed_at, score, updated_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (block_id, user_id) DO UPDATE
+		SET completed_at = COALESCE(block_progress.completed_at, EXCLUDED.completed_at),
+			score = CASE WHEN $6 THEN GREATEST(block_progress.score, EXCLUDED.score)
+				ELSE COALESCE(EXCLUDED.score, block_progress.score) END,
+			updated_at = EXCLUDED.updated_at
+		RETURNING completed_at, score, updated_at
+	`
+	err := r.db.QueryRow(ctx, query, params.BlockID, params.UserID, completedAt, params.Score, now, params.KeepBest).
+		Scan(&p.CompletedAt, &p.Score, &p.UpdatedAt)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) {
+			switch pgErr.ConstraintName {
+			case "fk_block_progress__block_id":
+				return models.BlockProgress{}, ErrBlockNotFound
+			case "fk_block_progress__user_id":
+				return models.BlockProgress{}, ErrUserNotFound
+			}
+		}
+		return models.BlockProgress{}, fmt.Errorf("upsert block progress: %w", err)
+	}
+	return p, nil
+}
+
+func (r *progressRepo) GetCourseProgress(ctx context.Context, courseID, userID int64) ([]models.BlockProgress, error) {
+	const query = `
+		SELECT bp.block_id, bp.user_id, bp.completed_at, bp.score, bp.updated_at
+		FROM block_progress bp
+		INNER JOIN blocks b ON b.id = bp.block_id
+		INNER JOIN lessons l ON l.id = b.lesson_id
+		INNER JOIN modules m ON m.id = l.module_id
+		WHERE m.course_id = $1 AND bp.user_id = $2
+			AND m.deleted_at IS NULL AND l.deleted_at IS NULL AND b.deleted_at IS NULL
+		ORDER BY m.order_idx, l.order_idx, b.order_idx
+	`
+	rows, err := r.db.Query(ctx, query, courseID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("query block progress: %w", err)
+	}
+	defer rows.Close()
+
+	var progress []models.BlockProgress
+	for rows.Next() {
+		var p models.BlockProgress
+		if err := rows.Scan(&p.BlockID, &p.UserID, &p.CompletedAt, &p.Score, &p.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("scan block progress: %w", err)
+		}
+		progress = append(progress, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+	return progress, nil
+}
+
+type CreateQuizAttemptParams struct {
+	BlockID int64
+	UserID  int64
+	Answers []quiz.Answer
+	Result  quiz.Result
+	// MaxAttempts - nil без ограничения
+	MaxAttempts *int
+}
+
+func (r *progressRepo) CreateQuizAttempt(ctx context.Context, params CreateQuizAttemptParams) (models.QuizAttempt, error) {
+	a := models.QuizAttempt{
+		BlockID:     params.BlockID,
+		UserID:      params.UserID,
+		Answers:     params.Answers,
+		Result:      params.Result,
+		SubmittedAt: time.Now().UTC(),
+	}
+	// номер и проверка лимита в одном запросе: одновременная попытка с тем же номером
+	// упрётся в уникальный ключ
+	const query = `
+		INSERT INTO quiz_attempts (block_id, user_id, attempt_no, answers, result, score, passed, submitted_at)
+		SELECT $1, $2, COALESCE(MAX(attempt_no), 0) + 1, $3::JSONB, $4::JSONB, $5::DOUBLE PRECISION, $6::BOOLEAN, $7::TIMESTAMP
+		FROM quiz_attempts
+		WHERE block_id = $1 AND user_id = $2
+		HAVING $8::INT IS NULL OR COUNT(*) < $8::INT
+		RETURNING id, attempt_no
+	`
+	err := r.db.QueryRow(ctx, query, params.BlockID, params.UserID, params.Answers, params.Result,
+		params.Result.Score, params.Result.Passed, a.SubmittedAt, params.MaxAttempts).Scan(&a.ID, &a.AttemptNo)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.QuizAttempt{}, ErrQuizAttemptLimitReached
+		}
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) {
+			switch {
+			case pgErr.Code == "23505":
+				return models.QuizAttempt{}, ErrQuizAttemptConflict
+			case pgErr.ConstraintName == "fk_quiz_attempts__block_id":
+				return models.QuizAttempt{}, ErrBlockNotFound
+			case pgErr.ConstraintName == "fk_quiz_attempts__user_id":
+				return models.QuizAttempt{}, ErrUserNotFound
+			}
+		}
+		return models.QuizAttempt{}, fmt.Errorf("insert quiz attempt: %w", err)
+	}
+	return a, nil
+}
+
+func (r *progressRepo) GetQuizAttempts(ctx context.Context, blockID, userID int64) ([]models.QuizAttempt, error) {
+	const query = `
+		SELECT id, block_id, user_id, attempt_no, answers, result, submitted_at
+		FROM quiz_attempts
+		WHERE block_id = $1 AND user_id = $2
+		ORDER BY attempt_no
+	`
+	rows, err := r.db.Query(ctx, query, blockID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("query quiz attempts: %w", err)
+	}
+	defer rows.Close()
+
+	var attempts []models.QuizAttempt
+	for rows.Next() {
+		var a models.QuizAttempt
+		if err := rows.Scan(&a.ID, &a.BlockID, &a.UserID, &a.AttemptNo, &a.Answers, &a.Result, &a.SubmittedAt); err != nil {
+			return nil, fmt.Errorf("scan quiz attempt: %w", err)
+		}
+		attempts = append(attempts, a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+	return attempts, nil
+}
+
+type CreateCodeSubmissionParams struct {
+	BlockID  int64
+	UserID   int64
+	Language string
+	Code     string
+	Result   codeexercise.Result
+}
+
+func (r *progressRepo) CreateCodeSubmission(ctx context.Context, params CreateCodeSubmissionParams) (models.CodeSubmission, error) {
+	s := models.CodeSubmission{
+		BlockID:     params.BlockID,
+		UserID:      params.UserID,
+		Language:    params.Language,
+		Code:        params.Code,
+		Result:      params.Result,
+		SubmittedAt: time.Now().UTC(),
+	}
+	const query = `
+		INSERT INTO code_submissions (block_id, user_id, language, code, result, score, passed, submitted_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id
+	`
+	err := r.db.QueryRow(ctx, query, params.BlockID, params.UserID, params.Language, params.Code, params.Result,
+		params.Result.Score, params.Result.Passed, s.SubmittedAt).Scan(&s.ID)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) {
+			switch pgErr.ConstraintName {
+			case "fk_code_submissions__block_id":
+				return models.CodeSubmission{}, ErrBlockNotFound
+			case "fk_code_submissions__user_id":
+				return models.CodeSubmission{}, ErrUserNotFound
+			}
+		}
+		return models.CodeSubmission{}, fmt.Errorf("insert code submission: %w", err)
+	}
+	return s, nil
+}
+
+func (r *progressRepo) GetCodeSubmissions(ctx context.Context, blockID, userID int64, limit int) ([]models.CodeSubmission, error) {
+	const query = `
+		SELECT id, block_id, user_id, language, code, result, submitted_at
+		FROM code_submissions
+		WHERE block_id = $1 AND user_id = $2
+		ORDER BY submitted_at DESC, id DESC
+		LIMIT $3
+	`
+	rows, err := r.db.Query(ctx, query, blockID, userID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("query code submissions: %w", err)
+	}
+	defer rows.Close()
+
+	var submissions []models.CodeSubmission
+	for rows.Next() {
+		var s models.CodeSubmission
+		if err := rows.Scan(&s.ID, &s.BlockID, &s.UserID, &s.Language, &s.Code, &s.Result, &s.SubmittedAt); err != nil {
+			return nil, fmt.Errorf("scan code submission: %w", err)
+		}
+		submissions = append(submissions, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+	return submissions, nil
+}
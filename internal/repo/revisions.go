@@ -0,0 +1,131 @@
+package repo
+
+import (
+	"chalk/internal/repo/models"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type RevisionsRepo interface {
+	GetRevisions(ctx context.Context, params GetRevisionsParams) ([]models.Revision, error)
+	GetRevision(ctx context.Context, revisionID int64) (models.Revision, error)
+}
+
+func NewRevisionsRepo(db DB) RevisionsRepo {
+	return &revisionsRepo{db: db}
+}
+
+type revisionsRepo struct {
+	db DB
+}
+
+// RevisionAuthor - кто и в каком аккаунте правит элемент курса, пишется в историю правок
+type RevisionAuthor struct {
+	AccountID int64
+	UserID    int64
+}
+
+type insertRevisionParams struct {
+	Author   RevisionAuthor
+	ItemType models.TrashItemType
+	ItemID   int64
+	Field    models.RevisionField
+	OldValue string
+	Value    string
+}
+
+// insertRevision пишет правку в транзакции изменения. Правка без изменения значения не пишется.
+func insertRevision(ctx context.Context, tx pgx.Tx, params insertRevisionParams) error {
+	if params.OldValue == params.Value {
+		return nil
+	}
+	const query = `
+		INSERT INTO content_revisions (account_id, item_type, item_id, field, old_value, value, author_id, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, 0), $8)
+	`
+	_, err := tx.Exec(ctx, query,
+		params.Author.AccountID,
+		params.ItemType,
+		params.ItemID,
+		params.Field,
+		params.OldValue,
+		params.Value,
+		params.Author.UserID,
+		time.Now().UTC(),
+	)
+	if err != nil {
+		return fmt.Errorf("insert revision: %w", err)
+	}
+	return nil
+}
+
+type GetRevisionsParams struct {
+	ItemType models.TrashItemType
+	ItemID   int64
+	// BeforeID - вернуть правки старше указанной
+	BeforeID *int64
+	Limit    int
+}
+
+const revisionColumns = `id, account_id, item_type, item_id, field, old_value, value, author_id, created_at`
+
+func scanRevision(row pgx.Row) (models.Revision, error) {
+	var rev models.Revision
+	err := row.Scan(
+		&rev.ID,
+		&rev.AccountID,
+		&rev.ItemType,
+		&rev.ItemID,
+		&rev.Field,
+		&rev.OldValue,
+		&rev.Value,
+		&rev.AuthorID,
+		&rev.CreatedAt,
+	)
+	return rev, err
+}
+
+// GetRevisions возвращает правки элемента, новые первыми
+func (r *revisionsRepo) GetRevisions(ctx context.Context, params GetRevisionsParams) ([]models.Revision, error) {
+	const query = `
+		SELECT ` + revisionColumns + `
+		FROM content_revisions
+		WHERE item_type = $1 AND item_id = $2 AND ($3::BIGINT IS NULL OR id < $3)
+		ORDER BY id DESC
+		LIMIT $4
+	`
+	rows, err := r.db.Query(ctx, query, params.ItemType, params.ItemID, params.BeforeID, params.Limit)
+	if err != nil {
+		return nil, fmt.Errorf("query revisions: %w", err)
+	}
+	defer rows.Close()
+
+	var revisions []models.Revision
+	for rows.Next() {
+		rev, err := scanRevision(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan revision: %w", err)
+		}
+		revisions = append(revisions, rev)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+	return revisions, nil
+}
+
+func (r *revisionsRepo) GetRevision(ctx context.Context, revisionID int64) (models.Revision, error) {
+	const query = `SELECT ` + revisionColumns + ` FROM content_revisions WHERE id = $1`
+	rev, err := scanRevision(r.db.QueryRow(ctx, query, revisionID))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.Revision{}, ErrRevisionNotFound
+		}
+		return models.Revision{}, fmt.Errorf("select revision: %w", err)
+	}
+	return rev, nil
+}
@@ -0,0 +1,46 @@
+package repo
+
+import (
+	"chalk/internal/repo/models"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const scormSessionRepoPrefix string = "scormsession"
+
+type ScormSessionRepo interface {
+	Set(ctx context.Context, sessionID string, session models.ScormSession, ttl time.Duration) error
+	Get(ctx context.Context, sessionID string) (models.ScormSession, error)
+}
+
+func NewScormSessionRepo(rdb *redis.Client) ScormSessionRepo {
+	return &scormSessionRepo{rdb: rdb}
+}
+
+type scormSessionRepo struct {
+	rdb *redis.Client
+}
+
+func (r *scormSessionRepo) Set(ctx context.Context, sessionID string, session models.ScormSession, ttl time.Duration) error {
+	err := r.rdb.Set(ctx, fmt.Sprintf("%s:%s", scormSessionRepoPrefix, sessionID), session, ttl).Err()
+	if err != nil {
+		return fmt.Errorf("failed to set value: %w", err)
+	}
+	return nil
+}
+
+func (r *scormSessionRepo) Get(ctx context.Context, sessionID string) (models.ScormSession, error) {
+	session := models.ScormSession{}
+	err := r.rdb.Get(ctx, fmt.Sprintf("%s:%s", scormSessionRepoPrefix, sessionID)).Scan(&session)
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return models.ScormSession{}, ErrRecordNotFound
+		}
+		return models.ScormSession{}, fmt.Errorf("failed to get value: %w", err)
+	}
+	return session, nil
+}
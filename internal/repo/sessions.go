@@ -17,12 +17,12 @@ type SessionsRepo interface {
 	UpdateSessionWithRefreshToken(ctx context.Context, params UpdateSessionWithRefreshTokenParams) (int64, error)
 }
 
-func NewSessionsRepo(db *pgx.Conn) SessionsRepo {
+func NewSessionsRepo(db DB) SessionsRepo {
 	return &sessionsRepo{db: db}
 }
 
 type sessionsRepo struct {
-	db *pgx.Conn
+	db DB
 }
 
 type CreateSessionParams struct {
@@ -16,12 +16,12 @@ type UsersRepo interface {
 	GetUserByEmail(ctx context.Context, email string) (models.User, error)
 }
 
-func NewUsersRepo(db *pgx.Conn) UsersRepo {
+func NewUsersRepo(db DB) UsersRepo {
 	return &usersRepo{db: db}
 }
 
 type usersRepo struct {
-	db *pgx.Conn
+	db DB
 }
 
 type CreateUserParams struct {
@@ -1 +1,277 @@
 package http
+
+import (
+	"chalk/internal/repo/models"
+	"chalk/internal/transport/http/dto"
+	"chalk/internal/usecases"
+	"net/http"
+	"strconv"
+)
+
+func (h *Handler) CreateAccount(w http.ResponseWriter, r *http.Request) {
+	var req dto.CreateAccountRequest
+	if err := readJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	acc, err := h.accountsUC.CreateAccount(r.Context(), usecases.CreateAccountParams{
+		OwnerUserID: sessionFromRequest(r).UserID,
+		Name:        req.Name,
+		Slug:        req.Slug,
+	})
+	if err != nil {
+		writeAppError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, dto.CreateAccountResponse{Account: toAccountDTO(acc)})
+}
+
+func (h *Handler) GetMyAccounts(w http.ResponseWriter, r *http.Request) {
+	accounts, err := h.accountsUC.GetUserAccounts(r.Context(), sessionFromRequest(r).UserID)
+	if err != nil {
+		writeAppError(w, err)
+		return
+	}
+
+	res := dto.GetMyAccountsResponse{Accounts: make([]dto.Account, 0, len(accounts))}
+	for _, acc := range accounts {
+		res.Accounts = append(res.Accounts, toAccountDTO(acc))
+	}
+	writeJSON(w, http.StatusOK, res)
+}
+
+// GetTenant возвращает аккаунт, к которому относится запрос (поддомен или префикс /t/<slug>/)
+func (h *Handler) GetTenant(w http.ResponseWriter, r *http.Request) {
+	acc, _ := tenantFromRequest(r)
+	settings, err := h.accountsUC.GetSettings(r.Context(), acc.ID)
+	if err != nil {
+		writeAppError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, dto.GetTenantResponse{
+		Account:  toAccountDTO(acc),
+		Branding: toAccountSettingsDTO(settings),
+	})
+}
+
+func (h *Handler) AddAccountMember(w http.ResponseWriter, r *http.Request) {
+	accountID, err := pathInt64(r, "id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var req dto.AddAccountMemberRequest
+	if err := readJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	err = h.accountsUC.AddMember(r.Context(), usecases.AddMemberParams{
+		ActorID:   sessionFromRequest(r).UserID,
+		AccountID: accountID,
+		UserID:    req.UserID,
+		Role:      models.AccountMembersRole(req.Role),
+	})
+	if err != nil {
+		writeAppError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, struct{}{})
+}
+
+func (h *Handler) GetAccountMembers(w http.ResponseWriter, r *http.Request) {
+	accountID, err := pathInt64(r, "id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	params := usecases.GetMembersParams{
+		ActorID:   sessionFromRequest(r).UserID,
+		AccountID: accountID,
+		Search:    queryString(r, "q"),
+		Group:     queryString(r, "group"),
+		Cursor:    queryString(r, "cursor"),
+	}
+	if role := queryString(r, "role"); role != nil {
+		rl := models.AccountMembersRole(*role)
+		params.Role = &rl
+	}
+	if sort := queryString(r, "sort"); sort != nil {
+		params.Sort = *sort
+	}
+	if order := queryString(r, "order"); order != nil {
+		switch *order {
+		case "asc":
+		case "desc":
+			params.Desc = true
+		default:
+			writeError(w, http.StatusBadRequest, "invalid query parameter order")
+			return
+		}
+	}
+	if limit := queryString(r, "limit"); limit != nil {
+		if params.Limit, err = strconv.Atoi(*limit); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid query parameter limit")
+			return
+		}
+	}
+
+	page, err := h.accountsUC.GetMembers(r.Context(), params)
+	if err != nil {
+		writeAppError(w, err)
+		return
+	}
+
+	res := dto.GetAccountMembersResponse{
+		Members:    make([]dto.AccountMember, 0, len(page.Members)),
+		Total:      page.Total,
+		NextCursor: page.NextCursor,
+	}
+	for _, m := range page.Members {
+		res.Members = append(res.Members, dto.AccountMember{
+			UserID: m.UserID,
+			Name:   m.Name,
+			Email:  m.Email,
+			Role:   string(m.Role),
+		})
+	}
+	writeJSON(w, http.StatusOK, res)
+}
+
+func (h *Handler) RemoveAccountMember(w http.ResponseWriter, r *http.Request) {
+	accountID, err := pathInt64(r, "id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var req dto.RemoveAccountMemberRequest
+	if err := readJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	err = h.accountsUC.RemoveMember(r.Context(), usecases.RemoveMemberParams{
+		ActorID:   sessionFromRequest(r).UserID,
+		AccountID: accountID,
+		UserID:    req.UserID,
+	})
+	if err != nil {
+		writeAppError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, struct{}{})
+}
+
+func (h *Handler) UpdateAccountMember(w http.ResponseWriter, r *http.Request) {
+	accountID, err := pathInt64(r, "id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var req dto.UpdateAccountMemberRequest
+	if err := readJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	err = h.accountsUC.ChangeMemberRole(r.Context(), usecases.ChangeMemberRoleParams{
+		ActorID:   sessionFromRequest(r).UserID,
+		AccountID: accountID,
+		UserID:    req.UserID,
+		Role:      models.AccountMembersRole(req.Role),
+	})
+	if err != nil {
+		writeAppError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, struct{}{})
+}
+
+func toAccountDTO(acc models.Account) dto.Account {
+	return dto.Account{ID: acc.ID, Name: acc.Name, Slug: acc.Slug}
+}
+
+func (h *Handler) GetAccountSettings(w http.ResponseWriter, r *http.Request) {
+	accountID, err := pathInt64(r, "id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	settings, err := h.accountsUC.GetSettings(r.Context(), accountID)
+	if err != nil {
+		writeAppError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, dto.GetAccountSettingsResponse{Settings: toAccountSettingsDTO(settings)})
+}
+
+func (h *Handler) UpdateAccountSettings(w http.ResponseWriter, r *http.Request) {
+	accountID, err := pathInt64(r, "id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var req dto.UpdateAccountSettingsRequest
+	if err := readJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	settings, err := h.accountsUC.UpdateSettings(r.Context(), usecases.UpdateSettingsParams{
+		ActorID:         sessionFromRequest(r).UserID,
+		AccountID:       accountID,
+		DisplayName:     req.DisplayName,
+		LogoFileID:      req.LogoFileID,
+		FaviconFileID:   req.FaviconFileID,
+		PrimaryColor:    req.PrimaryColor,
+		AccentColor:     req.AccentColor,
+		SupportEmail:    req.SupportEmail,
+		EmailSenderName: req.EmailSenderName,
+	})
+	if err != nil {
+		writeAppError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, dto.UpdateAccountSettingsResponse{Settings: toAccountSettingsDTO(settings)})
+}
+
+func (h *Handler) GetTenantLogo(w http.ResponseWriter, r *http.Request) {
+	h.writeBrandingAsset(w, r, usecases.BrandingAssetLogo)
+}
+
+func (h *Handler) GetTenantFavicon(w http.ResponseWriter, r *http.Request) {
+	h.writeBrandingAsset(w, r, usecases.BrandingAssetFavicon)
+}
+
+func (h *Handler) writeBrandingAsset(w http.ResponseWriter, r *http.Request, asset usecases.BrandingAsset) {
+	acc, _ := tenantFromRequest(r)
+	file, obj, err := h.accountsUC.GetBrandingAsset(r.Context(), acc.ID, asset)
+	if err != nil {
+		writeAppError(w, err)
+		return
+	}
+	defer obj.Close()
+
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	writeFile(w, file, obj, "inline")
+}
+
+func toAccountSettingsDTO(s models.AccountSettings) dto.AccountSettings {
+	return dto.AccountSettings{
+		DisplayName:     s.DisplayName,
+		LogoFileID:      s.LogoFileID,
+		FaviconFileID:   s.FaviconFileID,
+		PrimaryColor:    s.PrimaryColor,
+		AccentColor:     s.AccentColor,
+		SupportEmail:    s.SupportEmail,
+		EmailSenderName: s.EmailSenderName,
+	}
+}
@@ -0,0 +1,323 @@
+package http
+
+import (
+	uerrors "chalk/internal/errors"
+	"chalk/internal/repo/models"
+	"chalk/internal/transport/http/dto"
+	"chalk/internal/usecases"
+	"chalk/pkg/assignment"
+	"errors"
+	"net/http"
+)
+
+func (h *Handler) CreateAssignmentBlock(w http.ResponseWriter, r *http.Request) {
+	item, err := courseItemFromRequest(r, "lesson_id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var req dto.CreateAssignmentBlockRequest
+	if err := readJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	res, err := h.coursesUC.CreateAssignmentBlock(r.Context(), usecases.CreateAssignmentBlockParams{
+		ActorID:    item.ActorID,
+		AccountID:  item.AccountID,
+		LessonID:   item.ID,
+		Assignment: fromAssignmentDTO(req.Assignment),
+		OrderIdx:   req.OrderIdx,
+	})
+	if err != nil {
+		writeAssignmentError(w, err, res.Problems)
+		return
+	}
+	writeJSON(w, http.StatusCreated, dto.CreateBlockResponse{ID: res.BlockID})
+}
+
+func (h *Handler) UpdateAssignmentBlock(w http.ResponseWriter, r *http.Request) {
+	item, err := courseItemFromRequest(r, "block_id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var req dto.UpdateAssignmentBlockRequest
+	if err := readJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	res, err := h.coursesUC.UpdateAssignmentBlock(r.Context(), usecases.UpdateAssignmentBlockParams{
+		ActorID:    item.ActorID,
+		AccountID:  item.AccountID,
+		BlockID:    item.ID,
+		Assignment: fromAssignmentDTO(req.Assignment),
+	})
+	if err != nil {
+		writeAssignmentError(w, err, res.Problems)
+		return
+	}
+	writeJSON(w, http.StatusOK, struct{}{})
+}
+
+func (h *Handler) GetAssignment(w http.ResponseWriter, r *http.Request) {
+	item, err := courseItemFromRequest(r, "block_id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	state, err := h.coursesUC.GetAssignment(r.Context(), item)
+	if err != nil {
+		writeAppError(w, err)
+		return
+	}
+	res := dto.GetAssignmentResponse{BlockID: state.BlockID, Assignment: toAssignmentDTO(state.Assignment)}
+	if state.Submission != nil {
+		s := toSubmissionDTO(*state.Submission)
+		res.Submission = &s
+	}
+	writeJSON(w, http.StatusOK, res)
+}
+
+func (h *Handler) SubmitAssignment(w http.ResponseWriter, r *http.Request) {
+	item, err := courseItemFromRequest(r, "block_id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var req dto.SubmitAssignmentRequest
+	if err := readJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	submission, err := h.coursesUC.SubmitAssignment(r.Context(), usecases.SubmitAssignmentParams{
+		ActorID:   item.ActorID,
+		AccountID: item.AccountID,
+		BlockID:   item.ID,
+		Text:      req.Text,
+		FileIDs:   req.FileIDs,
+	})
+	if err != nil {
+		writeAppError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, dto.SubmissionResponse{Submission: toSubmissionDTO(submission)})
+}
+
+func (h *Handler) GetSubmissionQueue(w http.ResponseWriter, r *http.Request) {
+	item, err := courseItemFromRequest(r, "course_id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	blockID, err := queryInt64(r, "block_id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	params := usecases.GetSubmissionQueueParams{
+		ActorID:   item.ActorID,
+		AccountID: item.AccountID,
+		CourseID:  item.ID,
+		BlockID:   blockID,
+	}
+	if status := queryString(r, "status"); status != nil {
+		s := models.SubmissionStatus(*status)
+		params.Status = &s
+	}
+
+	submissions, err := h.coursesUC.GetSubmissionQueue(r.Context(), params)
+	if err != nil {
+		writeAppError(w, err)
+		return
+	}
+	res := dto.GetSubmissionQueueResponse{Submissions: make([]dto.Submission, 0, len(submissions))}
+	for _, s := range submissions {
+		res.Submissions = append(res.Submissions, toSubmissionDTO(s))
+	}
+	writeJSON(w, http.StatusOK, res)
+}
+
+func (h *Handler) GetSubmission(w http.ResponseWriter, r *http.Request) {
+	item, err := courseItemFromRequest(r, "submission_id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	submission, err := h.coursesUC.GetSubmission(r.Context(), item)
+	if err != nil {
+		writeAppError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, dto.SubmissionResponse{Submission: toSubmissionDTO(submission)})
+}
+
+func (h *Handler) GradeSubmission(w http.ResponseWriter, r *http.Request) {
+	item, err := courseItemFromRequest(r, "submission_id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var req dto.GradeSubmissionRequest
+	if err := readJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	grades := make([]assignment.Grade, 0, len(req.Grades))
+	for _, g := range req.Grades {
+		grades = append(grades, assignment.Grade{CriterionID: g.CriterionID, Points: g.Points, Comment: g.Comment})
+	}
+
+	res, err := h.coursesUC.GradeSubmission(r.Context(), usecases.GradeSubmissionParams{
+		ActorID:      item.ActorID,
+		AccountID:    item.AccountID,
+		SubmissionID: item.ID,
+		Grades:       grades,
+		Comment:      req.Comment,
+	})
+	if err != nil {
+		writeAppError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, dto.GradeSubmissionResponse{
+		Submission: toSubmissionDTO(res.Submission),
+		Progress:   toBlockProgressDTO(res.Progress),
+	})
+}
+
+func (h *Handler) ReturnSubmission(w http.ResponseWriter, r *http.Request) {
+	item, err := courseItemFromRequest(r, "submission_id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var req dto.ReturnSubmissionRequest
+	if err := readJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	submission, err := h.coursesUC.ReturnSubmission(r.Context(), usecases.ReturnSubmissionParams{
+		ActorID:      item.ActorID,
+		AccountID:    item.AccountID,
+		SubmissionID: item.ID,
+		Comment:      req.Comment,
+	})
+	if err != nil {
+		writeAppError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, dto.SubmissionResponse{Submission: toSubmissionDTO(submission)})
+}
+
+func (h *Handler) DownloadSubmissionFile(w http.ResponseWriter, r *http.Request) {
+	item, err := courseItemFromRequest(r, "submission_id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	fileID, err := pathInt64(r, "file_id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	file, obj, err := h.coursesUC.OpenSubmissionFile(r.Context(), usecases.OpenSubmissionFileParams{
+		ActorID:      item.ActorID,
+		AccountID:    item.AccountID,
+		SubmissionID: item.ID,
+		FileID:       fileID,
+	})
+	if err != nil {
+		writeAppError(w, err)
+		return
+	}
+	defer obj.Close()
+
+	writeFile(w, file, obj, "attachment")
+}
+
+// writeAssignmentError отдаёт ошибки задания списком, остальные ошибки - как обычно
+func writeAssignmentError(w http.ResponseWriter, err error, problems []assignment.Problem) {
+	if !errors.Is(err, uerrors.ErrInvalidAssignment) {
+		writeAppError(w, err)
+		return
+	}
+	res := dto.InvalidAssignmentResponse{Error: err.Error(), Problems: make([]dto.AssignmentProblem, 0, len(problems))}
+	for _, p := range problems {
+		res.Problems = append(res.Problems, dto.AssignmentProblem{Path: p.Path, Message: p.Message})
+	}
+	writeJSON(w, http.StatusBadRequest, res)
+}
+
+func toAssignmentDTO(a assignment.Assignment) dto.Assignment {
+	res := dto.Assignment{
+		Instructions: a.Instructions,
+		AllowText:    a.AllowText,
+		AllowFiles:   a.AllowFiles,
+		PassingScore: a.PassingScore,
+		Rubric:       make([]dto.AssignmentCriterion, 0, len(a.Rubric)),
+	}
+	for _, c := range a.Rubric {
+		res.Rubric = append(res.Rubric, dto.AssignmentCriterion{ID: c.ID, Title: c.Title, Description: c.Description, Points: c.Points})
+	}
+	return res
+}
+
+func fromAssignmentDTO(a dto.Assignment) assignment.Assignment {
+	res := assignment.Assignment{
+		Instructions: a.Instructions,
+		AllowText:    a.AllowText,
+		AllowFiles:   a.AllowFiles,
+		PassingScore: a.PassingScore,
+		Rubric:       make([]assignment.Criterion, 0, len(a.Rubric)),
+	}
+	for _, c := range a.Rubric {
+		res.Rubric = append(res.Rubric, assignment.Criterion{ID: c.ID, Title: c.Title, Description: c.Description, Points: c.Points})
+	}
+	return res
+}
+
+func toSubmissionDTO(s models.Submission) dto.Submission {
+	res := dto.Submission{
+		ID:          s.ID,
+		BlockID:     s.BlockID,
+		UserID:      s.UserID,
+		Status:      string(s.Status),
+		Score:       s.Score,
+		SubmittedAt: s.SubmittedAt,
+		UpdatedAt:   s.UpdatedAt,
+	}
+	if s.Assignment != nil {
+		a := toAssignmentDTO(*s.Assignment)
+		res.Assignment = &a
+	}
+	for _, e := range s.History {
+		event := dto.SubmissionEvent{
+			ID:        e.ID,
+			Action:    string(e.Action),
+			ActorID:   e.ActorID,
+			Text:      e.Text,
+			Score:     e.Score,
+			Comment:   e.Comment,
+			CreatedAt: e.CreatedAt,
+		}
+		for _, f := range e.Files {
+			event.Files = append(event.Files, toFileDTO(f))
+		}
+		for _, g := range e.Grades {
+			event.Grades = append(event.Grades, dto.RubricGrade{CriterionID: g.CriterionID, Points: g.Points, Comment: g.Comment})
+		}
+		res.History = append(res.History, event)
+	}
+	return res
+}
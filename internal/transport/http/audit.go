@@ -0,0 +1,122 @@
+package http
+
+import (
+	"chalk/internal/repo/models"
+	"chalk/internal/transport/http/dto"
+	"chalk/internal/usecases"
+	"chalk/pkg/log"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+func (h *Handler) GetAuditLog(w http.ResponseWriter, r *http.Request) {
+	params, err := readAuditLogParams(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	page, err := h.auditUC.GetAccountAuditLog(r.Context(), params)
+	if err != nil {
+		writeAppError(w, err)
+		return
+	}
+
+	res := dto.GetAuditLogResponse{
+		Entries:      make([]dto.AuditEntry, 0, len(page.Entries)),
+		NextBeforeID: page.NextBeforeID,
+	}
+	for _, e := range page.Entries {
+		res.Entries = append(res.Entries, dto.AuditEntry{
+			ID:          e.ID,
+			ActorUserID: e.ActorUserID,
+			Action:      string(e.Action),
+			TargetType:  string(e.TargetType),
+			TargetID:    e.TargetID,
+			Before:      e.Before,
+			After:       e.After,
+			IP:          e.IP,
+			CreatedAt:   e.CreatedAt,
+		})
+	}
+	writeJSON(w, http.StatusOK, res)
+}
+
+func (h *Handler) ExportAuditLog(w http.ResponseWriter, r *http.Request) {
+	params, err := readAuditLogParams(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// проверка прав выполняется до записи первой строки, поэтому ошибку
+	// доступа ещё можно отдать обычным JSON-ответом
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(
+		"attachment; filename=audit-%d-%s.csv", params.AccountID, time.Now().UTC().Format("20060102-150405"),
+	))
+	tw := &trackingWriter{w: w}
+	if err := h.auditUC.ExportAccountAuditLogCSV(r.Context(), params, tw); err != nil {
+		if !tw.written {
+			w.Header().Del("Content-Disposition")
+			writeAppError(w, err)
+			return
+		}
+		// часть CSV уже отправлена со статусом 200: обрываем соединение,
+		// чтобы клиент не принял неполный файл за целый
+		log.Errorf("export audit log of account %d: %v", params.AccountID, err)
+		panic(http.ErrAbortHandler)
+	}
+}
+
+// trackingWriter запоминает, что в ответ уже что-то записано
+type trackingWriter struct {
+	w       io.Writer
+	written bool
+}
+
+func (t *trackingWriter) Write(p []byte) (int, error) {
+	t.written = true
+	return t.w.Write(p)
+}
+
+func readAuditLogParams(r *http.Request) (usecases.GetAccountAuditLogParams, error) {
+	params := usecases.GetAccountAuditLogParams{ActorID: sessionFromRequest(r).UserID}
+
+	var err error
+	if params.AccountID, err = pathInt64(r, "id"); err != nil {
+		return params, err
+	}
+	if params.ActorUserID, err = queryInt64(r, "actor_user_id"); err != nil {
+		return params, err
+	}
+	if params.TargetID, err = queryInt64(r, "target_id"); err != nil {
+		return params, err
+	}
+	if params.BeforeID, err = queryInt64(r, "before_id"); err != nil {
+		return params, err
+	}
+	if params.From, err = queryTime(r, "from"); err != nil {
+		return params, err
+	}
+	if params.To, err = queryTime(r, "to"); err != nil {
+		return params, err
+	}
+	if action := queryString(r, "action"); action != nil {
+		a := models.AuditAction(*action)
+		params.Action = &a
+	}
+	if targetType := queryString(r, "target_type"); targetType != nil {
+		t := models.AuditTargetType(*targetType)
+		params.TargetType = &t
+	}
+	if limit := queryString(r, "limit"); limit != nil {
+		if params.Limit, err = strconv.Atoi(*limit); err != nil {
+			return params, fmt.Errorf("invalid query parameter limit")
+		}
+	}
+	return params, nil
+}
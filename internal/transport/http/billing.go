@@ -0,0 +1,98 @@
+package http
+
+import (
+	"chalk/internal/repo/models"
+	"chalk/internal/transport/http/dto"
+	"chalk/internal/usecases"
+	"io"
+	"net/http"
+)
+
+// maxWebhookSize - ограничение тела вебхука платёжного провайдера
+const maxWebhookSize = 1 << 20
+
+func (h *Handler) GetPlans(w http.ResponseWriter, r *http.Request) {
+	plans, err := h.billingUC.GetPlans(r.Context())
+	if err != nil {
+		writeAppError(w, err)
+		return
+	}
+
+	res := dto.GetPlansResponse{Plans: make([]dto.Plan, 0, len(plans))}
+	for _, p := range plans {
+		res.Plans = append(res.Plans, toPlanDTO(p))
+	}
+	writeJSON(w, http.StatusOK, res)
+}
+
+func (h *Handler) GetSubscription(w http.ResponseWriter, r *http.Request) {
+	accountID, err := pathInt64(r, "id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	sub, err := h.billingUC.GetSubscription(r.Context(), usecases.GetSubscriptionParams{
+		ActorID:   sessionFromRequest(r).UserID,
+		AccountID: accountID,
+	})
+	if err != nil {
+		writeAppError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, dto.GetSubscriptionResponse{Subscription: dto.Subscription{
+		Plan:             toPlanDTO(sub.Plan),
+		Status:           string(sub.Status),
+		CurrentPeriodEnd: sub.CurrentPeriodEnd,
+	}})
+}
+
+func (h *Handler) StartCheckout(w http.ResponseWriter, r *http.Request) {
+	accountID, err := pathInt64(r, "id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var req dto.StartCheckoutRequest
+	if err := readJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	url, err := h.billingUC.StartCheckout(r.Context(), usecases.StartCheckoutParams{
+		ActorID:   sessionFromRequest(r).UserID,
+		AccountID: accountID,
+		PlanID:    req.PlanID,
+	})
+	if err != nil {
+		writeAppError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, dto.StartCheckoutResponse{CheckoutURL: url})
+}
+
+func (h *Handler) BillingWebhook(w http.ResponseWriter, r *http.Request) {
+	payload, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxWebhookSize))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "read body: "+err.Error())
+		return
+	}
+
+	if err := h.billingUC.HandleWebhook(r.Context(), r.Header, payload); err != nil {
+		writeAppError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, struct{}{})
+}
+
+func toPlanDTO(p models.Plan) dto.Plan {
+	return dto.Plan{
+		ID:           p.ID,
+		Name:         p.Name,
+		MaxSeats:     p.MaxSeats,
+		MaxCourses:   p.MaxCourses,
+		StorageBytes: p.StorageBytes,
+	}
+}
@@ -0,0 +1,98 @@
+package http
+
+import (
+	"chalk/internal/repo/models"
+	"chalk/internal/transport/http/dto"
+	"chalk/internal/usecases"
+	"net/http"
+)
+
+func (h *Handler) RequestAccountClosure(w http.ResponseWriter, r *http.Request) {
+	accountID, err := pathInt64(r, "id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var req dto.RequestAccountClosureRequest
+	if err := readJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	closure, err := h.closureUC.RequestClosure(r.Context(), usecases.RequestClosureParams{
+		ActorID:   sessionFromRequest(r).UserID,
+		AccountID: accountID,
+		Password:  req.Password,
+	})
+	if err != nil {
+		writeAppError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, dto.GetAccountClosureResponse{Closure: toAccountClosureDTO(closure)})
+}
+
+func (h *Handler) CancelAccountClosure(w http.ResponseWriter, r *http.Request) {
+	accountID, err := pathInt64(r, "id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	err = h.closureUC.CancelClosure(r.Context(), usecases.CancelClosureParams{
+		ActorID:   sessionFromRequest(r).UserID,
+		AccountID: accountID,
+	})
+	if err != nil {
+		writeAppError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, struct{}{})
+}
+
+func (h *Handler) GetAccountClosure(w http.ResponseWriter, r *http.Request) {
+	accountID, err := pathInt64(r, "id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	closure, err := h.closureUC.GetClosure(r.Context(), usecases.GetClosureParams{
+		ActorID:   sessionFromRequest(r).UserID,
+		AccountID: accountID,
+	})
+	if err != nil {
+		writeAppError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, dto.GetAccountClosureResponse{Closure: toAccountClosureDTO(closure)})
+}
+
+func (h *Handler) DownloadAccountExport(w http.ResponseWriter, r *http.Request) {
+	accountID, err := pathInt64(r, "id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	file, obj, err := h.closureUC.GetExport(r.Context(), usecases.GetClosureParams{
+		ActorID:   sessionFromRequest(r).UserID,
+		AccountID: accountID,
+	})
+	if err != nil {
+		writeAppError(w, err)
+		return
+	}
+	defer obj.Close()
+
+	writeFile(w, file, obj, "attachment")
+}
+
+func toAccountClosureDTO(c models.AccountClosure) dto.AccountClosure {
+	return dto.AccountClosure{
+		RequestedAt:         c.RequestedAt,
+		RequestedBy:         c.RequestedBy,
+		DeletionScheduledAt: c.DeletionScheduledAt,
+		ExportReady:         c.ExportFileID != nil,
+	}
+}
@@ -0,0 +1,201 @@
+package http
+
+import (
+	uerrors "chalk/internal/errors"
+	"chalk/internal/repo/models"
+	"chalk/internal/transport/http/dto"
+	"chalk/internal/usecases"
+	"chalk/pkg/codeexercise"
+	"errors"
+	"net/http"
+)
+
+func (h *Handler) CreateCodeExerciseBlock(w http.ResponseWriter, r *http.Request) {
+	item, err := courseItemFromRequest(r, "lesson_id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var req dto.CreateCodeExerciseBlockRequest
+	if err := readJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	res, err := h.coursesUC.CreateCodeExerciseBlock(r.Context(), usecases.CreateCodeExerciseBlockParams{
+		ActorID:   item.ActorID,
+		AccountID: item.AccountID,
+		LessonID:  item.ID,
+		Exercise:  fromCodeExerciseDTO(req.Exercise),
+		OrderIdx:  req.OrderIdx,
+	})
+	if err != nil {
+		writeCodeExerciseError(w, err, res.Problems)
+		return
+	}
+	writeJSON(w, http.StatusCreated, dto.CreateBlockResponse{ID: res.BlockID})
+}
+
+func (h *Handler) UpdateCodeExerciseBlock(w http.ResponseWriter, r *http.Request) {
+	item, err := courseItemFromRequest(r, "block_id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var req dto.UpdateCodeExerciseBlockRequest
+	if err := readJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	res, err := h.coursesUC.UpdateCodeExerciseBlock(r.Context(), usecases.UpdateCodeExerciseBlockParams{
+		ActorID:   item.ActorID,
+		AccountID: item.AccountID,
+		BlockID:   item.ID,
+		Exercise:  fromCodeExerciseDTO(req.Exercise),
+	})
+	if err != nil {
+		writeCodeExerciseError(w, err, res.Problems)
+		return
+	}
+	writeJSON(w, http.StatusOK, struct{}{})
+}
+
+func (h *Handler) GetCodeExercise(w http.ResponseWriter, r *http.Request) {
+	item, err := courseItemFromRequest(r, "block_id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	state, err := h.coursesUC.GetCodeExercise(r.Context(), item)
+	if err != nil {
+		writeAppError(w, err)
+		return
+	}
+	res := dto.GetCodeExerciseResponse{
+		BlockID:     state.BlockID,
+		Exercise:    toCodeExerciseDTO(state.Exercise),
+		Submissions: make([]dto.CodeSubmission, 0, len(state.Submissions)),
+	}
+	for _, s := range state.Submissions {
+		res.Submissions = append(res.Submissions, toCodeSubmissionDTO(s))
+	}
+	writeJSON(w, http.StatusOK, res)
+}
+
+func (h *Handler) SubmitCode(w http.ResponseWriter, r *http.Request) {
+	item, err := courseItemFromRequest(r, "block_id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var req dto.SubmitCodeRequest
+	if err := readJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	res, err := h.coursesUC.SubmitCode(r.Context(), usecases.SubmitCodeParams{
+		ActorID:   item.ActorID,
+		AccountID: item.AccountID,
+		BlockID:   item.ID,
+		Code:      req.Code,
+	})
+	if err != nil {
+		writeAppError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, dto.SubmitCodeResponse{
+		Submission: toCodeSubmissionDTO(res.Submission),
+		Progress:   toBlockProgressDTO(res.Progress),
+	})
+}
+
+// writeCodeExerciseError отдаёт ошибки упражнения списком, остальные ошибки - как обычно
+func writeCodeExerciseError(w http.ResponseWriter, err error, problems []codeexercise.Problem) {
+	if !errors.Is(err, uerrors.ErrInvalidCodeExercise) {
+		writeAppError(w, err)
+		return
+	}
+	res := dto.InvalidCodeExerciseResponse{Error: err.Error(), Problems: make([]dto.CodeExerciseProblem, 0, len(problems))}
+	for _, p := range problems {
+		res.Problems = append(res.Problems, dto.CodeExerciseProblem{Path: p.Path, Message: p.Message})
+	}
+	writeJSON(w, http.StatusBadRequest, res)
+}
+
+func toCodeExerciseDTO(e codeexercise.Exercise) dto.CodeExercise {
+	res := dto.CodeExercise{
+		Language:      e.Language,
+		StarterCode:   e.StarterCode,
+		TimeLimitMs:   e.TimeLimitMs,
+		MemoryLimitMB: e.MemoryLimitMB,
+		PassingScore:  e.PassingScore,
+		Tests:         make([]dto.CodeTestCase, 0, len(e.Tests)),
+	}
+	for _, t := range e.Tests {
+		res.Tests = append(res.Tests, dto.CodeTestCase{
+			ID:             t.ID,
+			Name:           t.Name,
+			Input:          t.Input,
+			ExpectedOutput: t.ExpectedOutput,
+			Points:         t.Points,
+			Hidden:         t.Hidden,
+		})
+	}
+	return res
+}
+
+func fromCodeExerciseDTO(e dto.CodeExercise) codeexercise.Exercise {
+	res := codeexercise.Exercise{
+		Language:      e.Language,
+		StarterCode:   e.StarterCode,
+		TimeLimitMs:   e.TimeLimitMs,
+		MemoryLimitMB: e.MemoryLimitMB,
+		PassingScore:  e.PassingScore,
+		Tests:         make([]codeexercise.TestCase, 0, len(e.Tests)),
+	}
+	for _, t := range e.Tests {
+		res.Tests = append(res.Tests, codeexercise.TestCase{
+			ID:             t.ID,
+			Name:           t.Name,
+			Input:          t.Input,
+			ExpectedOutput: t.ExpectedOutput,
+			Points:         t.Points,
+			Hidden:         t.Hidden,
+		})
+	}
+	return res
+}
+
+func toCodeSubmissionDTO(s models.CodeSubmission) dto.CodeSubmission {
+	res := dto.CodeSubmission{
+		ID:           s.ID,
+		Language:     s.Language,
+		Code:         s.Code,
+		Score:        s.Result.Score,
+		Points:       s.Result.Points,
+		MaxPoints:    s.Result.MaxPoints,
+		Passed:       s.Result.Passed,
+		CompileError: s.Result.CompileError,
+		Tests:        make([]dto.CodeTestResult, 0, len(s.Result.Tests)),
+		SubmittedAt:  s.SubmittedAt,
+	}
+	for _, t := range s.Result.Tests {
+		res.Tests = append(res.Tests, dto.CodeTestResult{
+			TestID:     t.TestID,
+			Status:     string(t.Status),
+			Points:     t.Points,
+			MaxPoints:  t.MaxPoints,
+			Stdout:     t.Stdout,
+			Stderr:     t.Stderr,
+			ExitCode:   t.ExitCode,
+			DurationMs: t.DurationMs,
+		})
+	}
+	return res
+}
@@ -0,0 +1,127 @@
+package http
+
+import (
+	uerrors "chalk/internal/errors"
+	"chalk/internal/transport/http/dto"
+	"chalk/internal/usecases"
+	"chalk/pkg/coursearchive"
+	"context"
+	"errors"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"time"
+)
+
+// ExportCourse отдаёт архив курса с манифестом и файлами блоков
+func (h *Handler) ExportCourse(w http.ResponseWriter, r *http.Request) {
+	item, err := courseItemFromRequest(r, "course_id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// проверка прав выполняется до записи первого байта, поэтому ошибку
+	// доступа ещё можно отдать обычным JSON-ответом
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(
+		"attachment; filename=course-%d-%s.zip", item.ID, time.Now().UTC().Format("20060102-150405"),
+	))
+	if err := h.coursesUC.ExportCourse(r.Context(), item, w); err != nil {
+		w.Header().Del("Content-Disposition")
+		writeAppError(w, err)
+		return
+	}
+}
+
+// ImportCourse создаёт курс из архива в поле формы file, поле name задаёт название курса
+func (h *Handler) ImportCourse(w http.ResponseWriter, r *http.Request) {
+	res, ok := h.importFromForm(w, r, h.coursesUC.ImportCourse)
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusCreated, dto.CourseResponse{Course: toCourseDTO(res.Course)})
+}
+
+// ImportPackage создаёт курс из пакета IMS Common Cartridge или SCORM в поле формы file
+// и возвращает список того, что не удалось перенести
+func (h *Handler) ImportPackage(w http.ResponseWriter, r *http.Request) {
+	res, ok := h.importFromForm(w, r, h.coursesUC.ImportPackage)
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusCreated, dto.ImportPackageResponse{
+		Course:   toCourseDTO(res.Course),
+		Warnings: toArchiveProblemsDTO(res.Warnings),
+	})
+}
+
+type importFunc func(ctx context.Context, params usecases.ImportCourseParams) (usecases.CourseImportResult, error)
+
+// importFromForm разбирает форму загрузки и вызывает импорт, ошибки пишет в ответ сам
+func (h *Handler) importFromForm(w http.ResponseWriter, r *http.Request, importFn importFunc) (usecases.CourseImportResult, bool) {
+	accountID, err := pathInt64(r, "id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return usecases.CourseImportResult{}, false
+	}
+
+	part, size, ok := h.archiveFromForm(w, r)
+	if !ok {
+		return usecases.CourseImportResult{}, false
+	}
+	defer r.MultipartForm.RemoveAll()
+	defer part.Close()
+
+	res, err := importFn(r.Context(), usecases.ImportCourseParams{
+		ActorID:   sessionFromRequest(r).UserID,
+		AccountID: accountID,
+		Archive:   part,
+		Size:      size,
+		Name:      r.FormValue("name"),
+	})
+	if err != nil {
+		if errors.Is(err, uerrors.ErrInvalidCourseArchive) {
+			writeInvalidArchive(w, err, res.Problems)
+			return usecases.CourseImportResult{}, false
+		}
+		writeAppError(w, err)
+		return usecases.CourseImportResult{}, false
+	}
+	return res, true
+}
+
+// archiveFromForm разбирает форму загрузки и открывает поле file, ошибки пишет в ответ сам.
+// Временные файлы формы удаляет вызывающий через r.MultipartForm.RemoveAll.
+func (h *Handler) archiveFromForm(w http.ResponseWriter, r *http.Request) (multipart.File, int64, bool) {
+	r.Body = http.MaxBytesReader(w, r.Body, h.maxUploadSize)
+	if err := r.ParseMultipartForm(uploadMemoryLimit); err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			writeError(w, http.StatusRequestEntityTooLarge, uerrors.ErrFileTooLarge.Error())
+			return nil, 0, false
+		}
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("parse multipart form: %v", err))
+		return nil, 0, false
+	}
+
+	part, header, err := r.FormFile("file")
+	if err != nil {
+		r.MultipartForm.RemoveAll()
+		writeError(w, http.StatusBadRequest, "form field file is required")
+		return nil, 0, false
+	}
+	return part, header.Size, true
+}
+
+func writeInvalidArchive(w http.ResponseWriter, err error, problems []coursearchive.Problem) {
+	writeJSON(w, http.StatusBadRequest, dto.InvalidArchiveResponse{Error: err.Error(), Problems: toArchiveProblemsDTO(problems)})
+}
+
+func toArchiveProblemsDTO(problems []coursearchive.Problem) []dto.ArchiveProblem {
+	res := make([]dto.ArchiveProblem, 0, len(problems))
+	for _, p := range problems {
+		res = append(res, dto.ArchiveProblem{Path: p.Path, Message: p.Message})
+	}
+	return res
+}
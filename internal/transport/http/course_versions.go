@@ -0,0 +1,203 @@
+package http
+
+import (
+	"chalk/internal/repo/models"
+	"chalk/internal/transport/http/dto"
+	"chalk/internal/usecases"
+	"net/http"
+)
+
+func (h *Handler) GetCourseDraft(w http.ResponseWriter, r *http.Request) {
+	item, err := courseItemFromRequest(r, "course_id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	draft, err := h.coursesUC.GetDraft(r.Context(), item)
+	if err != nil {
+		writeAppError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, dto.GetCourseDraftResponse{Course: toCourseContentDTO(draft, true)})
+}
+
+func (h *Handler) PublishCourse(w http.ResponseWriter, r *http.Request) {
+	item, err := courseItemFromRequest(r, "course_id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	version, err := h.coursesUC.PublishCourse(r.Context(), item)
+	if err != nil {
+		writeAppError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, dto.PublishCourseResponse{Version: toCourseVersionDTO(version)})
+}
+
+func (h *Handler) GetCourseVersions(w http.ResponseWriter, r *http.Request) {
+	item, err := courseItemFromRequest(r, "course_id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	versions, err := h.coursesUC.GetCourseVersions(r.Context(), item)
+	if err != nil {
+		writeAppError(w, err)
+		return
+	}
+
+	res := dto.GetCourseVersionsResponse{Versions: make([]dto.CourseVersion, 0, len(versions))}
+	for _, v := range versions {
+		res.Versions = append(res.Versions, toCourseVersionDTO(v))
+	}
+	writeJSON(w, http.StatusOK, res)
+}
+
+func (h *Handler) GetCourseChanges(w http.ResponseWriter, r *http.Request) {
+	item, err := courseItemFromRequest(r, "course_id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	changes, err := h.coursesUC.GetCourseChanges(r.Context(), item)
+	if err != nil {
+		writeAppError(w, err)
+		return
+	}
+
+	res := dto.GetCourseChangesResponse{Changes: make([]dto.CourseChange, 0, len(changes))}
+	for _, c := range changes {
+		res.Changes = append(res.Changes, dto.CourseChange{
+			Type:   string(c.ItemType),
+			ID:     c.ID,
+			Name:   c.Name,
+			Change: string(c.Change),
+		})
+	}
+	writeJSON(w, http.StatusOK, res)
+}
+
+func (h *Handler) RollbackCourse(w http.ResponseWriter, r *http.Request) {
+	item, err := courseItemFromRequest(r, "course_id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var req dto.RollbackCourseRequest
+	if err := readJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	err = h.coursesUC.RollbackCourse(r.Context(), usecases.RollbackCourseParams{
+		ActorID:   item.ActorID,
+		AccountID: item.AccountID,
+		CourseID:  item.ID,
+		Version:   req.Version,
+	})
+	if err != nil {
+		writeAppError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, struct{}{})
+}
+
+func (h *Handler) GetPublishedCourse(w http.ResponseWriter, r *http.Request) {
+	item, err := courseItemFromRequest(r, "course_id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	version, err := h.coursesUC.GetPublishedCourse(r.Context(), item)
+	if err != nil {
+		writeAppError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, dto.GetPublishedCourseResponse{
+		Version: toCourseVersionDTO(version),
+		Course:  toCourseContentDTO(version.Snapshot, false),
+	})
+}
+
+func toCourseVersionDTO(v models.CourseVersion) dto.CourseVersion {
+	return dto.CourseVersion{
+		Version:     v.Version,
+		PublishedAt: v.PublishedAt,
+		PublishedBy: v.PublishedBy,
+		Current:     v.Current,
+	}
+}
+
+// toCourseContentDTO переводит снимок курса, withAnswers оставляет верные ответы тестов для редакторов
+func toCourseContentDTO(s *models.CourseSnapshot, withAnswers bool) dto.CourseContent {
+	res := dto.CourseContent{
+		ID:      s.ID,
+		Name:    s.Name,
+		Modules: make([]dto.ModuleContent, 0, len(s.Modules)),
+	}
+	for _, m := range s.Modules {
+		mc := dto.ModuleContent{
+			ID:       m.ID,
+			OrderIdx: m.OrderIdx,
+			Name:     m.Name,
+			Lessons:  make([]dto.LessonContent, 0, len(m.Lessons)),
+		}
+		for _, l := range m.Lessons {
+			lc := dto.LessonContent{
+				ID:       l.ID,
+				OrderIdx: l.OrderIdx,
+				Name:     l.Name,
+				Blocks:   make([]dto.BlockContent, 0, len(l.Blocks)),
+			}
+			for _, b := range l.Blocks {
+				bc := dto.BlockContent{
+					ID:       b.ID,
+					OrderIdx: b.OrderIdx,
+					Type:     string(b.Type),
+					Content:  b.Content,
+					FileID:   b.FileID,
+				}
+				if b.Quiz != nil {
+					q := *b.Quiz
+					if !withAnswers {
+						q = q.WithoutAnswers()
+					}
+					qd := toQuizDTO(q)
+					bc.Quiz = &qd
+				}
+				if b.Assignment != nil {
+					ad := toAssignmentDTO(*b.Assignment)
+					bc.Assignment = &ad
+				}
+				if b.CodeExercise != nil {
+					e := *b.CodeExercise
+					if !withAnswers {
+						e = e.WithoutHiddenTests()
+					}
+					ed := toCodeExerciseDTO(e)
+					bc.CodeExercise = &ed
+				}
+				switch b.Type {
+				case models.BlockTypeImage:
+					bc.AltText, bc.Caption = &b.AltText, &b.Caption
+				case models.BlockTypeAttachment, models.BlockTypeEmbed:
+					bc.Title = &b.Title
+				}
+				if b.Embed != nil {
+					bc.Embed = &dto.Embed{Provider: b.Embed.Provider, URL: b.Embed.URL}
+				}
+				lc.Blocks = append(lc.Blocks, bc)
+			}
+			mc.Lessons = append(mc.Lessons, lc)
+		}
+		res.Modules = append(res.Modules, mc)
+	}
+	return res
+}
@@ -0,0 +1,631 @@
+package http
+
+import (
+	"chalk/internal/repo/models"
+	"chalk/internal/transport/http/dto"
+	"chalk/internal/usecases"
+	"context"
+	"net/http"
+)
+
+// courseItemFromRequest читает аккаунт из пути и элемент курса из параметра param
+func courseItemFromRequest(r *http.Request, param string) (usecases.CourseItemParams, error) {
+	accountID, err := pathInt64(r, "id")
+	if err != nil {
+		return usecases.CourseItemParams{}, err
+	}
+	id, err := pathInt64(r, param)
+	if err != nil {
+		return usecases.CourseItemParams{}, err
+	}
+	return usecases.CourseItemParams{
+		ActorID:   sessionFromRequest(r).UserID,
+		AccountID: accountID,
+		ID:        id,
+	}, nil
+}
+
+/* ===================== Courses ===================== */
+
+func (h *Handler) GetCourses(w http.ResponseWriter, r *http.Request) {
+	accountID, err := pathInt64(r, "id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	courses, err := h.coursesUC.GetCourses(r.Context(), usecases.GetCoursesParams{
+		ActorID:   sessionFromRequest(r).UserID,
+		AccountID: accountID,
+	})
+	if err != nil {
+		writeAppError(w, err)
+		return
+	}
+
+	res := dto.GetCoursesResponse{Courses: make([]dto.Course, 0, len(courses))}
+	for _, c := range courses {
+		res.Courses = append(res.Courses, toCourseDTO(c))
+	}
+	writeJSON(w, http.StatusOK, res)
+}
+
+func (h *Handler) GetCourse(w http.ResponseWriter, r *http.Request) {
+	item, err := courseItemFromRequest(r, "course_id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	course, err := h.coursesUC.GetCourse(r.Context(), item)
+	if err != nil {
+		writeAppError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, dto.CourseResponse{Course: toCourseDTO(course)})
+}
+
+func (h *Handler) GetCourseTree(w http.ResponseWriter, r *http.Request) {
+	item, err := courseItemFromRequest(r, "course_id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	tree, err := h.coursesUC.GetCourseTree(r.Context(), item)
+	if err != nil {
+		writeAppError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, dto.GetCourseTreeResponse{Course: toCourseTreeDTO(tree)})
+}
+
+func (h *Handler) CreateCourse(w http.ResponseWriter, r *http.Request) {
+	accountID, err := pathInt64(r, "id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var req dto.RenameCourseItemRequest
+	if err := readJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	course, err := h.coursesUC.CreateCourse(r.Context(), usecases.CreateCourseParams{
+		ActorID:   sessionFromRequest(r).UserID,
+		AccountID: accountID,
+		Name:      req.Name,
+	})
+	if err != nil {
+		writeAppError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, dto.CourseResponse{Course: toCourseDTO(course)})
+}
+
+func (h *Handler) DuplicateCourse(w http.ResponseWriter, r *http.Request) {
+	item, err := courseItemFromRequest(r, "course_id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var req dto.DuplicateCourseRequest
+	if err := readJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	course, err := h.coursesUC.DuplicateCourse(r.Context(), usecases.DuplicateCourseParams{
+		ActorID:         item.ActorID,
+		AccountID:       item.AccountID,
+		CourseID:        item.ID,
+		TargetAccountID: req.TargetAccountID,
+		Name:            req.Name,
+		CopyFiles:       req.CopyFiles,
+	})
+	if err != nil {
+		writeAppError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, dto.CourseResponse{Course: toCourseDTO(course)})
+}
+
+func (h *Handler) UpdateCourse(w http.ResponseWriter, r *http.Request) {
+	h.renameCourseItem(w, r, "course_id", h.coursesUC.UpdateCourse)
+}
+
+func (h *Handler) RemoveCourse(w http.ResponseWriter, r *http.Request) {
+	h.removeCourseItem(w, r, "course_id", h.coursesUC.RemoveCourse)
+}
+
+/* ===================== Modules ===================== */
+
+func (h *Handler) GetModules(w http.ResponseWriter, r *http.Request) {
+	item, err := courseItemFromRequest(r, "course_id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	modules, err := h.coursesUC.GetModules(r.Context(), item)
+	if err != nil {
+		writeAppError(w, err)
+		return
+	}
+
+	res := dto.GetModulesResponse{Modules: make([]dto.Module, 0, len(modules))}
+	for _, m := range modules {
+		res.Modules = append(res.Modules, toModuleDTO(m))
+	}
+	writeJSON(w, http.StatusOK, res)
+}
+
+func (h *Handler) CreateModule(w http.ResponseWriter, r *http.Request) {
+	item, err := courseItemFromRequest(r, "course_id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var req dto.CreateCourseItemRequest
+	if err := readJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	module, err := h.coursesUC.CreateModule(r.Context(), usecases.CreateModuleParams{
+		ActorID:   item.ActorID,
+		AccountID: item.AccountID,
+		CourseID:  item.ID,
+		Name:      req.Name,
+		OrderIdx:  req.OrderIdx,
+	})
+	if err != nil {
+		writeAppError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, dto.ModuleResponse{Module: toModuleDTO(module)})
+}
+
+func (h *Handler) UpdateModule(w http.ResponseWriter, r *http.Request) {
+	h.renameCourseItem(w, r, "module_id", h.coursesUC.UpdateModule)
+}
+
+func (h *Handler) ReorderModule(w http.ResponseWriter, r *http.Request) {
+	h.reorderCourseItem(w, r, "module_id", h.coursesUC.ReorderModule)
+}
+
+func (h *Handler) ReorderModules(w http.ResponseWriter, r *http.Request) {
+	h.reorderChildren(w, r, "course_id", h.coursesUC.ReorderModules)
+}
+
+func (h *Handler) RemoveModule(w http.ResponseWriter, r *http.Request) {
+	h.removeCourseItem(w, r, "module_id", h.coursesUC.RemoveModule)
+}
+
+/* ===================== Lessons ===================== */
+
+func (h *Handler) GetLessons(w http.ResponseWriter, r *http.Request) {
+	item, err := courseItemFromRequest(r, "module_id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	lessons, err := h.coursesUC.GetLessons(r.Context(), item)
+	if err != nil {
+		writeAppError(w, err)
+		return
+	}
+
+	res := dto.GetLessonsResponse{Lessons: make([]dto.Lesson, 0, len(lessons))}
+	for _, l := range lessons {
+		res.Lessons = append(res.Lessons, toLessonDTO(l))
+	}
+	writeJSON(w, http.StatusOK, res)
+}
+
+func (h *Handler) CreateLesson(w http.ResponseWriter, r *http.Request) {
+	item, err := courseItemFromRequest(r, "module_id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var req dto.CreateCourseItemRequest
+	if err := readJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	lesson, err := h.coursesUC.CreateLesson(r.Context(), usecases.CreateLessonParams{
+		ActorID:   item.ActorID,
+		AccountID: item.AccountID,
+		ModuleID:  item.ID,
+		Name:      req.Name,
+		OrderIdx:  req.OrderIdx,
+	})
+	if err != nil {
+		writeAppError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, dto.LessonResponse{Lesson: toLessonDTO(lesson)})
+}
+
+func (h *Handler) UpdateLesson(w http.ResponseWriter, r *http.Request) {
+	h.renameCourseItem(w, r, "lesson_id", h.coursesUC.UpdateLesson)
+}
+
+func (h *Handler) ReorderLesson(w http.ResponseWriter, r *http.Request) {
+	h.reorderCourseItem(w, r, "lesson_id", h.coursesUC.ReorderLesson)
+}
+
+func (h *Handler) MoveLesson(w http.ResponseWriter, r *http.Request) {
+	item, err := courseItemFromRequest(r, "lesson_id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var req dto.MoveLessonRequest
+	if err := readJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	err = h.coursesUC.MoveLesson(r.Context(), usecases.MoveCourseItemParams{
+		ActorID:   item.ActorID,
+		AccountID: item.AccountID,
+		ID:        item.ID,
+		ParentID:  req.ModuleID,
+		OrderIdx:  req.OrderIdx,
+	})
+	if err != nil {
+		writeAppError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, struct{}{})
+}
+
+func (h *Handler) ReorderLessons(w http.ResponseWriter, r *http.Request) {
+	h.reorderChildren(w, r, "module_id", h.coursesUC.ReorderLessons)
+}
+
+func (h *Handler) RemoveLesson(w http.ResponseWriter, r *http.Request) {
+	h.removeCourseItem(w, r, "lesson_id", h.coursesUC.RemoveLesson)
+}
+
+/* ===================== Blocks ===================== */
+
+func (h *Handler) GetBlocks(w http.ResponseWriter, r *http.Request) {
+	item, err := courseItemFromRequest(r, "lesson_id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	blocks, err := h.coursesUC.GetBlocks(r.Context(), item)
+	if err != nil {
+		writeAppError(w, err)
+		return
+	}
+
+	res := dto.GetBlocksResponse{Blocks: make([]dto.Block, 0, len(blocks))}
+	for _, b := range blocks {
+		res.Blocks = append(res.Blocks, toBlockDTO(b))
+	}
+	writeJSON(w, http.StatusOK, res)
+}
+
+func (h *Handler) CreateTextBlock(w http.ResponseWriter, r *http.Request) {
+	item, err := courseItemFromRequest(r, "lesson_id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var req dto.CreateTextBlockRequest
+	if err := readJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	id, err := h.coursesUC.CreateTextBlock(r.Context(), usecases.CreateTextBlockParams{
+		ActorID:   item.ActorID,
+		AccountID: item.AccountID,
+		LessonID:  item.ID,
+		Content:   req.Content,
+		OrderIdx:  req.OrderIdx,
+	})
+	if err != nil {
+		writeAppError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, dto.CreateBlockResponse{ID: id})
+}
+
+func (h *Handler) CreateVideoBlock(w http.ResponseWriter, r *http.Request) {
+	item, err := courseItemFromRequest(r, "lesson_id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var req dto.CreateVideoBlockRequest
+	if err := readJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	id, err := h.coursesUC.CreateVideoBlock(r.Context(), usecases.CreateVideoBlockParams{
+		ActorID:   item.ActorID,
+		AccountID: item.AccountID,
+		LessonID:  item.ID,
+		FileID:    req.FileID,
+		OrderIdx:  req.OrderIdx,
+	})
+	if err != nil {
+		writeAppError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, dto.CreateBlockResponse{ID: id})
+}
+
+func (h *Handler) UpdateTextBlock(w http.ResponseWriter, r *http.Request) {
+	item, err := courseItemFromRequest(r, "block_id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var req dto.UpdateTextBlockRequest
+	if err := readJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	err = h.coursesUC.UpdateTextBlock(r.Context(), usecases.UpdateTextBlockParams{
+		ActorID:   item.ActorID,
+		AccountID: item.AccountID,
+		BlockID:   item.ID,
+		Content:   req.Content,
+	})
+	if err != nil {
+		writeAppError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, struct{}{})
+}
+
+func (h *Handler) ReorderBlock(w http.ResponseWriter, r *http.Request) {
+	h.reorderCourseItem(w, r, "block_id", h.coursesUC.ReorderBlock)
+}
+
+func (h *Handler) MoveBlock(w http.ResponseWriter, r *http.Request) {
+	item, err := courseItemFromRequest(r, "block_id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var req dto.MoveBlockRequest
+	if err := readJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	err = h.coursesUC.MoveBlock(r.Context(), usecases.MoveCourseItemParams{
+		ActorID:   item.ActorID,
+		AccountID: item.AccountID,
+		ID:        item.ID,
+		ParentID:  req.LessonID,
+		OrderIdx:  req.OrderIdx,
+	})
+	if err != nil {
+		writeAppError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, struct{}{})
+}
+
+func (h *Handler) ReorderBlocks(w http.ResponseWriter, r *http.Request) {
+	h.reorderChildren(w, r, "lesson_id", h.coursesUC.ReorderBlocks)
+}
+
+func (h *Handler) RemoveBlock(w http.ResponseWriter, r *http.Request) {
+	h.removeCourseItem(w, r, "block_id", h.coursesUC.RemoveBlock)
+}
+
+/* ===================== Helpers ===================== */
+
+func (h *Handler) renameCourseItem(
+	w http.ResponseWriter,
+	r *http.Request,
+	param string,
+	rename func(ctx context.Context, params usecases.RenameCourseItemParams) error,
+) {
+	item, err := courseItemFromRequest(r, param)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var req dto.RenameCourseItemRequest
+	if err := readJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	err = rename(r.Context(), usecases.RenameCourseItemParams{
+		ActorID:   item.ActorID,
+		AccountID: item.AccountID,
+		ID:        item.ID,
+		Name:      req.Name,
+	})
+	if err != nil {
+		writeAppError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, struct{}{})
+}
+
+func (h *Handler) reorderChildren(
+	w http.ResponseWriter,
+	r *http.Request,
+	param string,
+	reorder func(ctx context.Context, params usecases.ReorderChildrenParams) error,
+) {
+	parent, err := courseItemFromRequest(r, param)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var req dto.ReorderChildrenRequest
+	if err := readJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	err = reorder(r.Context(), usecases.ReorderChildrenParams{
+		ActorID:   parent.ActorID,
+		AccountID: parent.AccountID,
+		ParentID:  parent.ID,
+		IDs:       req.IDs,
+	})
+	if err != nil {
+		writeAppError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, struct{}{})
+}
+
+func (h *Handler) reorderCourseItem(
+	w http.ResponseWriter,
+	r *http.Request,
+	param string,
+	reorder func(ctx context.Context, params usecases.ReorderCourseItemParams) error,
+) {
+	item, err := courseItemFromRequest(r, param)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var req dto.ReorderCourseItemRequest
+	if err := readJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	err = reorder(r.Context(), usecases.ReorderCourseItemParams{
+		ActorID:   item.ActorID,
+		AccountID: item.AccountID,
+		ID:        item.ID,
+		OrderIdx:  req.OrderIdx,
+	})
+	if err != nil {
+		writeAppError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, struct{}{})
+}
+
+func (h *Handler) removeCourseItem(
+	w http.ResponseWriter,
+	r *http.Request,
+	param string,
+	remove func(ctx context.Context, params usecases.CourseItemParams) error,
+) {
+	item, err := courseItemFromRequest(r, param)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := remove(r.Context(), item); err != nil {
+		writeAppError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, struct{}{})
+}
+
+func toCourseDTO(c *models.Course) dto.Course {
+	return dto.Course{ID: c.ID, Name: c.Name}
+}
+
+func toModuleDTO(m *models.Module) dto.Module {
+	return dto.Module{ID: m.ID, CourseID: m.CourseID, OrderIdx: m.OrderIdx, Name: m.Name}
+}
+
+func toLessonDTO(l *models.Lesson) dto.Lesson {
+	return dto.Lesson{ID: l.ID, ModuleID: l.ModuleID, OrderIdx: l.OrderIdx, Name: l.Name}
+}
+
+func toBlockDTO(b any) dto.Block {
+	switch b := b.(type) {
+	case *models.TextBlock:
+		return dto.Block{ID: b.ID, LessonID: b.LessonID, OrderIdx: b.OrderIdx, Type: string(b.Type), Content: &b.Content}
+	case *models.VideoBlock:
+		return dto.Block{ID: b.ID, LessonID: b.LessonID, OrderIdx: b.OrderIdx, Type: string(b.Type), FileID: &b.FileID}
+	case *models.ScormBlock:
+		return dto.Block{ID: b.ID, LessonID: b.LessonID, OrderIdx: b.OrderIdx, Type: string(b.Type), FileID: &b.FileID}
+	case *models.QuizBlock:
+		q := toQuizDTO(b.Quiz)
+		return dto.Block{ID: b.ID, LessonID: b.LessonID, OrderIdx: b.OrderIdx, Type: string(b.Type), Quiz: &q}
+	case *models.AssignmentBlock:
+		a := toAssignmentDTO(b.Assignment)
+		return dto.Block{ID: b.ID, LessonID: b.LessonID, OrderIdx: b.OrderIdx, Type: string(b.Type), Assignment: &a}
+	case *models.CodeExerciseBlock:
+		e := toCodeExerciseDTO(b.Exercise)
+		return dto.Block{ID: b.ID, LessonID: b.LessonID, OrderIdx: b.OrderIdx, Type: string(b.Type), CodeExercise: &e}
+	case *models.ImageBlock:
+		return dto.Block{ID: b.ID, LessonID: b.LessonID, OrderIdx: b.OrderIdx, Type: string(b.Type), FileID: &b.FileID,
+			AltText: &b.AltText, Caption: &b.Caption}
+	case *models.AttachmentBlock:
+		return dto.Block{ID: b.ID, LessonID: b.LessonID, OrderIdx: b.OrderIdx, Type: string(b.Type), FileID: &b.FileID, Title: &b.Title}
+	case *models.EmbedBlock:
+		return dto.Block{ID: b.ID, LessonID: b.LessonID, OrderIdx: b.OrderIdx, Type: string(b.Type), Title: &b.Title,
+			Embed: &dto.Embed{Provider: b.Embed.Provider, URL: b.Embed.URL}}
+	}
+	return dto.Block{}
+}
+
+func toCourseTreeDTO(t *models.CourseHierarchy) dto.CourseTree {
+	res := dto.CourseTree{
+		ID:      t.Course.ID,
+		Name:    t.Course.Name,
+		Modules: make([]dto.CourseTreeModule, 0, len(t.Modules)),
+	}
+	for _, m := range t.Modules {
+		mn := dto.CourseTreeModule{
+			ID:       m.Module.ID,
+			OrderIdx: m.Module.OrderIdx,
+			Name:     m.Module.Name,
+			Lessons:  make([]dto.CourseTreeLesson, 0, len(m.Lessons)),
+		}
+		for _, l := range m.Lessons {
+			ln := dto.CourseTreeLesson{
+				ID:       l.Lesson.ID,
+				OrderIdx: l.Lesson.OrderIdx,
+				Name:     l.Lesson.Name,
+				Blocks:   make([]dto.BlockSummary, 0, len(l.Blocks)),
+			}
+			for _, b := range l.Blocks {
+				ln.Blocks = append(ln.Blocks, dto.BlockSummary{
+					ID:       b.ID,
+					OrderIdx: b.OrderIdx,
+					Type:     string(b.Type),
+					FileID:   b.FileID,
+					Preview:  b.Preview,
+				})
+			}
+			mn.Lessons = append(mn.Lessons, ln)
+		}
+		res.Modules = append(res.Modules, mn)
+	}
+	return res
+}
@@ -0,0 +1,74 @@
+package dto
+
+type Account struct {
+	ID   int64  `json:"id"`
+	Name string `json:"name"`
+	Slug string `json:"slug"`
+}
+
+type CreateAccountRequest struct {
+	Name string `json:"name"`
+	Slug string `json:"slug"`
+}
+
+type CreateAccountResponse struct {
+	Account Account `json:"account"`
+}
+
+type GetMyAccountsResponse struct {
+	Accounts []Account `json:"accounts"`
+}
+
+type AddAccountMemberRequest struct {
+	UserID int64  `json:"user_id"`
+	Role   string `json:"role"`
+}
+
+type AccountMember struct {
+	UserID int64  `json:"user_id"`
+	Name   string `json:"name"`
+	Email  string `json:"email"`
+	Role   string `json:"role"`
+}
+
+type GetAccountMembersResponse struct {
+	Members    []AccountMember `json:"members"`
+	Total      int             `json:"total"`
+	NextCursor *string         `json:"next_cursor"`
+}
+
+type RemoveAccountMemberRequest struct {
+	UserID int64 `json:"user_id"`
+}
+
+type UpdateAccountMemberRequest struct {
+	UserID int64  `json:"user_id"`
+	Role   string `json:"role"`
+}
+
+type GetTenantResponse struct {
+	Account  Account         `json:"account"`
+	Branding AccountSettings `json:"branding"`
+}
+
+type AccountSettings struct {
+	DisplayName     string `json:"display_name"`
+	LogoFileID      *int64 `json:"logo_file_id"`
+	FaviconFileID   *int64 `json:"favicon_file_id"`
+	PrimaryColor    string `json:"primary_color"`
+	AccentColor     string `json:"accent_color"`
+	SupportEmail    string `json:"support_email"`
+	EmailSenderName string `json:"email_sender_name"`
+}
+
+type GetAccountSettingsResponse struct {
+	Settings AccountSettings `json:"settings"`
+}
+
+type UpdateAccountSettingsRequest struct {
+	AccountSettings
+}
+
+type UpdateAccountSettingsResponse struct {
+	Settings AccountSettings `json:"settings"`
+}
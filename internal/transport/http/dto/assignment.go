@@ -0,0 +1,106 @@
+package dto
+
+import "time"
+
+type Assignment struct {
+	Instructions string                `json:"instructions"`
+	AllowText    bool                  `json:"allow_text"`
+	AllowFiles   bool                  `json:"allow_files"`
+	PassingScore float64               `json:"passing_score"`
+	Rubric       []AssignmentCriterion `json:"rubric"`
+}
+
+type AssignmentCriterion struct {
+	ID          string  `json:"id"`
+	Title       string  `json:"title"`
+	Description string  `json:"description,omitempty"`
+	Points      float64 `json:"points"`
+}
+
+type CreateAssignmentBlockRequest struct {
+	Assignment Assignment `json:"assignment"`
+	OrderIdx   *int       `json:"order_idx"`
+}
+
+type UpdateAssignmentBlockRequest struct {
+	Assignment Assignment `json:"assignment"`
+}
+
+type AssignmentProblem struct {
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+// InvalidAssignmentResponse - ответ на задание с ошибками, в Problems перечислены все найденные
+type InvalidAssignmentResponse struct {
+	Error    string              `json:"error"`
+	Problems []AssignmentProblem `json:"problems"`
+}
+
+type RubricGrade struct {
+	CriterionID string  `json:"criterion_id"`
+	Points      float64 `json:"points"`
+	Comment     string  `json:"comment,omitempty"`
+}
+
+// SubmissionEvent - запись истории работы: сдача, оценка или возврат на доработку
+type SubmissionEvent struct {
+	ID     int64  `json:"id"`
+	Action string `json:"action"`
+	// ActorID - null, если пользователь удалён
+	ActorID   *int64        `json:"actor_id"`
+	Text      string        `json:"text,omitempty"`
+	Files     []File        `json:"files,omitempty"`
+	Grades    []RubricGrade `json:"grades,omitempty"`
+	Score     *float64      `json:"score,omitempty"`
+	Comment   string        `json:"comment,omitempty"`
+	CreatedAt time.Time     `json:"created_at"`
+}
+
+type Submission struct {
+	ID      int64  `json:"id"`
+	BlockID int64  `json:"block_id"`
+	UserID  int64  `json:"user_id"`
+	Status  string `json:"status"`
+	// Score - результат последней оценки, null до оценки
+	Score       *float64  `json:"score"`
+	SubmittedAt time.Time `json:"submitted_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+	// Assignment - задание, по которому оценивается работа, и History отдаются только для одной работы, не в очереди
+	Assignment *Assignment       `json:"assignment,omitempty"`
+	History    []SubmissionEvent `json:"history,omitempty"`
+}
+
+type GetAssignmentResponse struct {
+	BlockID    int64      `json:"block_id"`
+	Assignment Assignment `json:"assignment"`
+	// Submission - null, если работа ещё не сдавалась
+	Submission *Submission `json:"submission"`
+}
+
+type SubmitAssignmentRequest struct {
+	Text    string  `json:"text"`
+	FileIDs []int64 `json:"file_ids"`
+}
+
+type SubmissionResponse struct {
+	Submission Submission `json:"submission"`
+}
+
+type GetSubmissionQueueResponse struct {
+	Submissions []Submission `json:"submissions"`
+}
+
+type GradeSubmissionRequest struct {
+	Grades  []RubricGrade `json:"grades"`
+	Comment string        `json:"comment"`
+}
+
+type GradeSubmissionResponse struct {
+	Submission Submission    `json:"submission"`
+	Progress   BlockProgress `json:"progress"`
+}
+
+type ReturnSubmissionRequest struct {
+	Comment string `json:"comment"`
+}
@@ -0,0 +1,23 @@
+package dto
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type AuditEntry struct {
+	ID          int64           `json:"id"`
+	ActorUserID *int64          `json:"actor_user_id"`
+	Action      string          `json:"action"`
+	TargetType  string          `json:"target_type"`
+	TargetID    *int64          `json:"target_id"`
+	Before      json.RawMessage `json:"before,omitempty"`
+	After       json.RawMessage `json:"after,omitempty"`
+	IP          string          `json:"ip"`
+	CreatedAt   time.Time       `json:"created_at"`
+}
+
+type GetAuditLogResponse struct {
+	Entries      []AuditEntry `json:"entries"`
+	NextBeforeID *int64       `json:"next_before_id"`
+}
@@ -0,0 +1,33 @@
+package dto
+
+import "time"
+
+type Plan struct {
+	ID           string `json:"id"`
+	Name         string `json:"name"`
+	MaxSeats     *int   `json:"max_seats"`
+	MaxCourses   *int   `json:"max_courses"`
+	StorageBytes *int64 `json:"storage_bytes"`
+}
+
+type GetPlansResponse struct {
+	Plans []Plan `json:"plans"`
+}
+
+type Subscription struct {
+	Plan             Plan       `json:"plan"`
+	Status           string     `json:"status"`
+	CurrentPeriodEnd *time.Time `json:"current_period_end"`
+}
+
+type GetSubscriptionResponse struct {
+	Subscription Subscription `json:"subscription"`
+}
+
+type StartCheckoutRequest struct {
+	PlanID string `json:"plan_id"`
+}
+
+type StartCheckoutResponse struct {
+	CheckoutURL string `json:"checkout_url"`
+}
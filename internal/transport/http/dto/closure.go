@@ -0,0 +1,18 @@
+package dto
+
+import "time"
+
+type RequestAccountClosureRequest struct {
+	Password string `json:"password"`
+}
+
+type AccountClosure struct {
+	RequestedAt         time.Time `json:"requested_at"`
+	RequestedBy         *int64    `json:"requested_by"`
+	DeletionScheduledAt time.Time `json:"deletion_scheduled_at"`
+	ExportReady         bool      `json:"export_ready"`
+}
+
+type GetAccountClosureResponse struct {
+	Closure AccountClosure `json:"closure"`
+}
@@ -0,0 +1,83 @@
+package dto
+
+import "time"
+
+type CodeExercise struct {
+	Language      string         `json:"language"`
+	StarterCode   string         `json:"starter_code"`
+	TimeLimitMs   int            `json:"time_limit_ms"`
+	MemoryLimitMB int            `json:"memory_limit_mb"`
+	PassingScore  float64        `json:"passing_score"`
+	Tests         []CodeTestCase `json:"tests"`
+}
+
+// CodeTestCase - у скрытого теста участнику отдаются только id, название и баллы
+type CodeTestCase struct {
+	ID             string  `json:"id"`
+	Name           string  `json:"name,omitempty"`
+	Input          string  `json:"input"`
+	ExpectedOutput string  `json:"expected_output"`
+	Points         float64 `json:"points"`
+	Hidden         bool    `json:"hidden"`
+}
+
+type CreateCodeExerciseBlockRequest struct {
+	Exercise CodeExercise `json:"exercise"`
+	OrderIdx *int         `json:"order_idx"`
+}
+
+type UpdateCodeExerciseBlockRequest struct {
+	Exercise CodeExercise `json:"exercise"`
+}
+
+type CodeExerciseProblem struct {
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+// InvalidCodeExerciseResponse - ответ на упражнение с ошибками, в Problems перечислены все найденные
+type InvalidCodeExerciseResponse struct {
+	Error    string                `json:"error"`
+	Problems []CodeExerciseProblem `json:"problems"`
+}
+
+// CodeTestResult - результат теста, вывод программы на скрытых тестах не отдаётся
+type CodeTestResult struct {
+	TestID     string  `json:"test_id"`
+	Status     string  `json:"status"`
+	Points     float64 `json:"points"`
+	MaxPoints  float64 `json:"max_points"`
+	Stdout     string  `json:"stdout,omitempty"`
+	Stderr     string  `json:"stderr,omitempty"`
+	ExitCode   int     `json:"exit_code"`
+	DurationMs int64   `json:"duration_ms"`
+}
+
+type CodeSubmission struct {
+	ID           int64            `json:"id"`
+	Language     string           `json:"language"`
+	Code         string           `json:"code"`
+	Score        float64          `json:"score"`
+	Points       float64          `json:"points"`
+	MaxPoints    float64          `json:"max_points"`
+	Passed       bool             `json:"passed"`
+	CompileError string           `json:"compile_error,omitempty"`
+	Tests        []CodeTestResult `json:"tests"`
+	SubmittedAt  time.Time        `json:"submitted_at"`
+}
+
+type GetCodeExerciseResponse struct {
+	BlockID  int64        `json:"block_id"`
+	Exercise CodeExercise `json:"exercise"`
+	// Submissions - последние решения вызывающего, новые первыми
+	Submissions []CodeSubmission `json:"submissions"`
+}
+
+type SubmitCodeRequest struct {
+	Code string `json:"code"`
+}
+
+type SubmitCodeResponse struct {
+	Submission CodeSubmission `json:"submission"`
+	Progress   BlockProgress  `json:"progress"`
+}
@@ -0,0 +1,250 @@
+package dto
+
+import "time"
+
+type Course struct {
+	ID   int64  `json:"id"`
+	Name string `json:"name"`
+}
+
+type Module struct {
+	ID       int64  `json:"id"`
+	CourseID int64  `json:"course_id"`
+	OrderIdx int    `json:"order_idx"`
+	Name     string `json:"name"`
+}
+
+type Lesson struct {
+	ID       int64  `json:"id"`
+	ModuleID int64  `json:"module_id"`
+	OrderIdx int    `json:"order_idx"`
+	Name     string `json:"name"`
+}
+
+type Block struct {
+	ID           int64         `json:"id"`
+	LessonID     int64         `json:"lesson_id"`
+	OrderIdx     int           `json:"order_idx"`
+	Type         string        `json:"type"`
+	Content      *string       `json:"content,omitempty"`
+	FileID       *int64        `json:"file_id,omitempty"`
+	Quiz         *Quiz         `json:"quiz,omitempty"`
+	Assignment   *Assignment   `json:"assignment,omitempty"`
+	CodeExercise *CodeExercise `json:"code_exercise,omitempty"`
+	AltText      *string       `json:"alt_text,omitempty"`
+	Caption      *string       `json:"caption,omitempty"`
+	Title        *string       `json:"title,omitempty"`
+	Embed        *Embed        `json:"embed,omitempty"`
+}
+
+type GetCoursesResponse struct {
+	Courses []Course `json:"courses"`
+}
+
+type CourseResponse struct {
+	Course Course `json:"course"`
+}
+
+type GetModulesResponse struct {
+	Modules []Module `json:"modules"`
+}
+
+type ModuleResponse struct {
+	Module Module `json:"module"`
+}
+
+type GetLessonsResponse struct {
+	Lessons []Lesson `json:"lessons"`
+}
+
+type LessonResponse struct {
+	Lesson Lesson `json:"lesson"`
+}
+
+type GetBlocksResponse struct {
+	Blocks []Block `json:"blocks"`
+}
+
+type CreateBlockResponse struct {
+	ID int64 `json:"id"`
+}
+
+type CreateCourseItemRequest struct {
+	Name string `json:"name"`
+	// OrderIdx - позиция среди соседей, если не указана - в конец
+	OrderIdx *int `json:"order_idx"`
+}
+
+type RenameCourseItemRequest struct {
+	Name string `json:"name"`
+}
+
+type DuplicateCourseRequest struct {
+	Name            string `json:"name"`
+	TargetAccountID int64  `json:"target_account_id"`
+	CopyFiles       bool   `json:"copy_files"`
+}
+
+type ReorderCourseItemRequest struct {
+	OrderIdx int `json:"order_idx"`
+}
+
+type ReorderChildrenRequest struct {
+	IDs []int64 `json:"ids"`
+}
+
+type MoveLessonRequest struct {
+	ModuleID int64 `json:"module_id"`
+	OrderIdx *int  `json:"order_idx"`
+}
+
+type MoveBlockRequest struct {
+	LessonID int64 `json:"lesson_id"`
+	OrderIdx *int  `json:"order_idx"`
+}
+
+type CreateTextBlockRequest struct {
+	Content  string `json:"content"`
+	OrderIdx *int   `json:"order_idx"`
+}
+
+type CreateVideoBlockRequest struct {
+	FileID   int64 `json:"file_id"`
+	OrderIdx *int  `json:"order_idx"`
+}
+
+type UpdateTextBlockRequest struct {
+	Content string `json:"content"`
+}
+
+type CourseTree struct {
+	ID      int64              `json:"id"`
+	Name    string             `json:"name"`
+	Modules []CourseTreeModule `json:"modules"`
+}
+
+type CourseTreeModule struct {
+	ID       int64              `json:"id"`
+	OrderIdx int                `json:"order_idx"`
+	Name     string             `json:"name"`
+	Lessons  []CourseTreeLesson `json:"lessons"`
+}
+
+type CourseTreeLesson struct {
+	ID       int64          `json:"id"`
+	OrderIdx int            `json:"order_idx"`
+	Name     string         `json:"name"`
+	Blocks   []BlockSummary `json:"blocks"`
+}
+
+type BlockSummary struct {
+	ID       int64  `json:"id"`
+	OrderIdx int    `json:"order_idx"`
+	Type     string `json:"type"`
+	FileID   *int64 `json:"file_id,omitempty"`
+	Preview  string `json:"preview,omitempty"`
+}
+
+type GetCourseTreeResponse struct {
+	Course CourseTree `json:"course"`
+}
+
+type CourseContent struct {
+	ID      int64           `json:"id"`
+	Name    string          `json:"name"`
+	Modules []ModuleContent `json:"modules"`
+}
+
+type ModuleContent struct {
+	ID       int64           `json:"id"`
+	OrderIdx int             `json:"order_idx"`
+	Name     string          `json:"name"`
+	Lessons  []LessonContent `json:"lessons"`
+}
+
+type LessonContent struct {
+	ID       int64          `json:"id"`
+	OrderIdx int            `json:"order_idx"`
+	Name     string         `json:"name"`
+	Blocks   []BlockContent `json:"blocks"`
+}
+
+type BlockContent struct {
+	ID           int64         `json:"id"`
+	OrderIdx     int           `json:"order_idx"`
+	Type         string        `json:"type"`
+	Content      *string       `json:"content,omitempty"`
+	FileID       *int64        `json:"file_id,omitempty"`
+	Quiz         *Quiz         `json:"quiz,omitempty"`
+	Assignment   *Assignment   `json:"assignment,omitempty"`
+	CodeExercise *CodeExercise `json:"code_exercise,omitempty"`
+	AltText      *string       `json:"alt_text,omitempty"`
+	Caption      *string       `json:"caption,omitempty"`
+	Title        *string       `json:"title,omitempty"`
+	Embed        *Embed        `json:"embed,omitempty"`
+}
+
+type GetCourseDraftResponse struct {
+	Course CourseContent `json:"course"`
+}
+
+type CourseVersion struct {
+	Version     int       `json:"version"`
+	PublishedAt time.Time `json:"published_at"`
+	PublishedBy *int64    `json:"published_by"`
+	Current     bool      `json:"current"`
+}
+
+type PublishCourseResponse struct {
+	Version CourseVersion `json:"version"`
+}
+
+type GetCourseVersionsResponse struct {
+	Versions []CourseVersion `json:"versions"`
+}
+
+type CourseChange struct {
+	Type   string `json:"type"`
+	ID     int64  `json:"id"`
+	Name   string `json:"name"`
+	Change string `json:"change"`
+}
+
+type GetCourseChangesResponse struct {
+	Changes []CourseChange `json:"changes"`
+}
+
+type RollbackCourseRequest struct {
+	Version int `json:"version"`
+}
+
+type GetPublishedCourseResponse struct {
+	Version CourseVersion `json:"version"`
+	Course  CourseContent `json:"course"`
+}
+
+type ArchiveProblem struct {
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+// InvalidArchiveResponse - ответ на архив с ошибками, в Problems перечислены все найденные
+type InvalidArchiveResponse struct {
+	Error    string           `json:"error"`
+	Problems []ArchiveProblem `json:"problems"`
+}
+
+// ImportPackageResponse - созданный курс и то, что из пакета перенести не удалось
+type ImportPackageResponse struct {
+	Course   Course           `json:"course"`
+	Warnings []ArchiveProblem `json:"warnings"`
+}
+
+// MarkdownSyncResponse - курс после синхронизации с каталогом Markdown и число изменённых элементов
+type MarkdownSyncResponse struct {
+	Course   Course           `json:"course"`
+	Created  int              `json:"created"`
+	Updated  int              `json:"updated"`
+	Removed  int              `json:"removed"`
+	Warnings []ArchiveProblem `json:"warnings"`
+}
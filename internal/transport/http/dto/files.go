@@ -0,0 +1,29 @@
+package dto
+
+import "time"
+
+type File struct {
+	ID          int64     `json:"id"`
+	AccountID   *int64    `json:"account_id"`
+	Name        string    `json:"name"`
+	ContentType string    `json:"content_type"`
+	Size        int64     `json:"size"`
+	UploadedAt  time.Time `json:"uploaded_at"`
+}
+
+type UploadFileResponse struct {
+	File File `json:"file"`
+}
+
+type ContentTypeUsage struct {
+	ContentType string `json:"content_type"`
+	Files       int64  `json:"files"`
+	Bytes       int64  `json:"bytes"`
+}
+
+type GetStorageUsageResponse struct {
+	UsedBytes int64 `json:"used_bytes"`
+	// QuotaBytes - null, если квота не ограничена
+	QuotaBytes    *int64             `json:"quota_bytes"`
+	ByContentType []ContentTypeUsage `json:"by_content_type"`
+}
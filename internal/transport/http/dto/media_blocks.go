@@ -0,0 +1,27 @@
+package dto
+
+type Embed struct {
+	Provider string `json:"provider"`
+	// URL - адрес для iframe
+	URL string `json:"url"`
+}
+
+type CreateImageBlockRequest struct {
+	FileID   int64  `json:"file_id"`
+	AltText  string `json:"alt_text"`
+	Caption  string `json:"caption"`
+	OrderIdx *int   `json:"order_idx"`
+}
+
+type CreateAttachmentBlockRequest struct {
+	FileID   int64  `json:"file_id"`
+	Title    string `json:"title"`
+	OrderIdx *int   `json:"order_idx"`
+}
+
+// CreateEmbedBlockRequest - URL - ссылка на страницу сервиса, например на видео YouTube
+type CreateEmbedBlockRequest struct {
+	URL      string `json:"url"`
+	Title    string `json:"title"`
+	OrderIdx *int   `json:"order_idx"`
+}
@@ -0,0 +1,17 @@
+package dto
+
+type MemberImportRow struct {
+	Line    int    `json:"line"`
+	Email   string `json:"email"`
+	Status  string `json:"status"`
+	Result  string `json:"result,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+type ImportMembersResponse struct {
+	DryRun  bool              `json:"dry_run"`
+	Created int               `json:"created"`
+	Skipped int               `json:"skipped"`
+	Errored int               `json:"errored"`
+	Rows    []MemberImportRow `json:"rows"`
+}
@@ -0,0 +1,96 @@
+package dto
+
+import "time"
+
+// Quiz - тест блока. Участникам отдаётся без верных ответов и отзывов.
+type Quiz struct {
+	PassingScore float64        `json:"passing_score"`
+	MaxAttempts  *int           `json:"max_attempts"`
+	Questions    []QuizQuestion `json:"questions"`
+}
+
+type QuizQuestion struct {
+	ID                string       `json:"id"`
+	Type              string       `json:"type"`
+	Text              string       `json:"text"`
+	Points            float64      `json:"points"`
+	Options           []QuizOption `json:"options,omitempty"`
+	Answer            *float64     `json:"answer,omitempty"`
+	Tolerance         float64      `json:"tolerance,omitempty"`
+	Answers           []string     `json:"answers,omitempty"`
+	CaseSensitive     bool         `json:"case_sensitive,omitempty"`
+	CorrectFeedback   string       `json:"correct_feedback,omitempty"`
+	IncorrectFeedback string       `json:"incorrect_feedback,omitempty"`
+}
+
+type QuizOption struct {
+	ID       string `json:"id"`
+	Text     string `json:"text"`
+	Correct  bool   `json:"correct,omitempty"`
+	Feedback string `json:"feedback,omitempty"`
+}
+
+type CreateQuizBlockRequest struct {
+	Quiz     Quiz `json:"quiz"`
+	OrderIdx *int `json:"order_idx"`
+}
+
+type UpdateQuizBlockRequest struct {
+	Quiz Quiz `json:"quiz"`
+}
+
+type QuizProblem struct {
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+// InvalidQuizResponse - ответ на тест с ошибками, в Problems перечислены все найденные
+type InvalidQuizResponse struct {
+	Error    string        `json:"error"`
+	Problems []QuizProblem `json:"problems"`
+}
+
+type QuizAnswer struct {
+	QuestionID string   `json:"question_id"`
+	OptionIDs  []string `json:"option_ids,omitempty"`
+	Number     *float64 `json:"number,omitempty"`
+	Text       string   `json:"text,omitempty"`
+}
+
+type QuizQuestionResult struct {
+	QuestionID string   `json:"question_id"`
+	Answered   bool     `json:"answered"`
+	Correct    bool     `json:"correct"`
+	Points     float64  `json:"points"`
+	MaxPoints  float64  `json:"max_points"`
+	Feedback   []string `json:"feedback"`
+}
+
+type QuizAttempt struct {
+	ID          int64                `json:"id"`
+	AttemptNo   int                  `json:"attempt_no"`
+	Score       float64              `json:"score"`
+	Points      float64              `json:"points"`
+	MaxPoints   float64              `json:"max_points"`
+	Passed      bool                 `json:"passed"`
+	Answers     []QuizAnswer         `json:"answers"`
+	Questions   []QuizQuestionResult `json:"questions"`
+	SubmittedAt time.Time            `json:"submitted_at"`
+}
+
+type GetQuizResponse struct {
+	BlockID  int64         `json:"block_id"`
+	Quiz     Quiz          `json:"quiz"`
+	Attempts []QuizAttempt `json:"attempts"`
+	// AttemptsLeft - null без ограничения
+	AttemptsLeft *int `json:"attempts_left"`
+}
+
+type SubmitQuizAttemptRequest struct {
+	Answers []QuizAnswer `json:"answers"`
+}
+
+type SubmitQuizAttemptResponse struct {
+	Attempt  QuizAttempt   `json:"attempt"`
+	Progress BlockProgress `json:"progress"`
+}
@@ -0,0 +1,28 @@
+package dto
+
+import "time"
+
+type Revision struct {
+	ID        int64     `json:"id"`
+	Type      string    `json:"type"`
+	ItemID    int64     `json:"item_id"`
+	Field     string    `json:"field"`
+	OldValue  *string   `json:"old_value"`
+	Value     string    `json:"value"`
+	AuthorID  *int64    `json:"author_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type GetRevisionsResponse struct {
+	Revisions    []Revision `json:"revisions"`
+	NextBeforeID *int64     `json:"next_before_id"`
+}
+
+type DiffLine struct {
+	Op   string `json:"op"`
+	Text string `json:"text"`
+}
+
+type DiffRevisionsResponse struct {
+	Lines []DiffLine `json:"lines"`
+}
@@ -0,0 +1,40 @@
+package dto
+
+import "time"
+
+type CreateScormBlockRequest struct {
+	FileID   int64 `json:"file_id"`
+	OrderIdx *int  `json:"order_idx"`
+}
+
+type LaunchScormResponse struct {
+	SessionID string `json:"session_id"`
+	Version   string `json:"version"`
+	// PlayerURL - страница с API среды выполнения, открывается в iframe или новом окне
+	PlayerURL string `json:"player_url"`
+}
+
+type ScormRuntimeResponse struct {
+	Version    string            `json:"version"`
+	LaunchPath string            `json:"launch_path"`
+	Values     map[string]string `json:"values"`
+}
+
+type CommitScormRuntimeRequest struct {
+	Values map[string]string `json:"values"`
+}
+
+type BlockProgress struct {
+	BlockID     int64      `json:"block_id"`
+	CompletedAt *time.Time `json:"completed_at"`
+	Score       *float64   `json:"score"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+type BlockProgressResponse struct {
+	Progress BlockProgress `json:"progress"`
+}
+
+type GetCourseProgressResponse struct {
+	Blocks []BlockProgress `json:"blocks"`
+}
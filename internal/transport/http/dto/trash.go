@@ -0,0 +1,21 @@
+package dto
+
+import "time"
+
+type TrashItem struct {
+	Type      string    `json:"type"`
+	ID        int64     `json:"id"`
+	ParentID  *int64    `json:"parent_id"`
+	Name      string    `json:"name"`
+	DeletedAt time.Time `json:"deleted_at"`
+	DeletedBy *int64    `json:"deleted_by"`
+}
+
+type GetTrashResponse struct {
+	Items []TrashItem `json:"items"`
+}
+
+type RestoreTrashItemRequest struct {
+	Type string `json:"type"`
+	ID   int64  `json:"id"`
+}
@@ -0,0 +1,133 @@
+package http
+
+import (
+	uerrors "chalk/internal/errors"
+	"chalk/internal/repo/models"
+	"chalk/internal/transport/http/dto"
+	"chalk/internal/usecases"
+	"chalk/pkg/log"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+)
+
+// uploadMemoryLimit - часть multipart-формы, которая держится в памяти, остальное пишется во временный файл
+const uploadMemoryLimit = 8 << 20
+
+func (h *Handler) UploadFile(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, h.maxUploadSize)
+	if err := r.ParseMultipartForm(uploadMemoryLimit); err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			writeError(w, http.StatusRequestEntityTooLarge, uerrors.ErrFileTooLarge.Error())
+			return
+		}
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("parse multipart form: %v", err))
+		return
+	}
+	defer r.MultipartForm.RemoveAll()
+
+	part, header, err := r.FormFile("file")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "form field file is required")
+		return
+	}
+	defer part.Close()
+
+	file, err := h.filesUC.UploadFile(r.Context(), usecases.UploadFileParams{
+		UploaderUserID: sessionFromRequest(r).UserID,
+		AccountID:      activeAccountFromRequest(r).Account.ID,
+		Name:           header.Filename,
+		ContentType:    header.Header.Get("Content-Type"),
+		Size:           header.Size,
+		Reader:         part,
+	})
+	if err != nil {
+		writeAppError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, dto.UploadFileResponse{File: toFileDTO(file)})
+}
+
+func (h *Handler) DownloadFile(w http.ResponseWriter, r *http.Request) {
+	fileID, err := pathInt64(r, "id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	file, obj, err := h.filesUC.GetFile(r.Context(), usecases.GetFileParams{
+		ActorID: sessionFromRequest(r).UserID,
+		FileID:  fileID,
+	})
+	if err != nil {
+		writeAppError(w, err)
+		return
+	}
+	defer obj.Close()
+
+	writeFile(w, file, obj, "attachment")
+}
+
+func (h *Handler) GetStorageUsage(w http.ResponseWriter, r *http.Request) {
+	accountID, err := pathInt64(r, "id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	usage, err := h.filesUC.GetStorageUsage(r.Context(), usecases.GetStorageUsageParams{
+		ActorID:   sessionFromRequest(r).UserID,
+		AccountID: accountID,
+	})
+	if err != nil {
+		writeAppError(w, err)
+		return
+	}
+
+	res := dto.GetStorageUsageResponse{
+		UsedBytes:     usage.UsedBytes,
+		QuotaBytes:    usage.QuotaBytes,
+		ByContentType: make([]dto.ContentTypeUsage, 0, len(usage.ByContentType)),
+	}
+	for _, ct := range usage.ByContentType {
+		res.ByContentType = append(res.ByContentType, dto.ContentTypeUsage{
+			ContentType: ct.ContentType,
+			Files:       ct.Files,
+			Bytes:       ct.Bytes,
+		})
+	}
+	writeJSON(w, http.StatusOK, res)
+}
+
+// writeFile отдаёт содержимое файла, disposition - attachment или inline. Content-Type
+// указал загрузивший, поэтому браузеру запрещено угадывать тип, а открытый inline файл
+// (например, SVG или HTML) работает в песочнице без скриптов и доступа к нашему origin.
+func writeFile(w http.ResponseWriter, file models.File, content io.Reader, disposition string) {
+	w.Header().Set("Content-Type", file.ContentType)
+	w.Header().Set("Content-Length", strconv.FormatInt(file.Size, 10))
+	w.Header().Set("Content-Disposition", mime.FormatMediaType(disposition, map[string]string{"filename": file.Name}))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	if disposition == "inline" {
+		w.Header().Set("Content-Security-Policy", "sandbox")
+	}
+	w.WriteHeader(http.StatusOK)
+	if _, err := io.Copy(w, content); err != nil {
+		log.Warnf("write file %d: %v", file.ID, err)
+	}
+}
+
+func toFileDTO(f models.File) dto.File {
+	return dto.File{
+		ID:          f.ID,
+		AccountID:   f.AccountID,
+		Name:        f.Name,
+		ContentType: f.ContentType,
+		Size:        f.Size,
+		UploadedAt:  f.UploadedAt,
+	}
+}
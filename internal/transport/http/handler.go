@@ -4,27 +4,175 @@ import (
 	"chalk/internal/transport/http/middleware"
 	"chalk/internal/usecases"
 	"net/http"
+	"net/netip"
 )
 
 type Handler struct {
-	mux    *http.ServeMux
-	authUC usecases.AuthUseCase
+	mux        *http.ServeMux
+	authUC     usecases.AuthUseCase
+	accountsUC usecases.AccountsUseCase
+	auditUC    usecases.AuditUseCase
+	filesUC    usecases.FilesUseCase
+	trashUC    usecases.TrashUseCase
+	billingUC  usecases.BillingUseCase
+	closureUC  usecases.ClosureUseCase
+	coursesUC  usecases.CoursesUseCase
+
+	maxUploadSize int64
 }
 
 func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	h.mux.ServeHTTP(w, r)
 }
 
-func NewHandler(authUC usecases.AuthUseCase) http.Handler {
+type TenantConfig struct {
+	// BaseDomain - домен, поддомены которого соответствуют аккаунтам (<slug>.chalkhub.ru)
+	BaseDomain string
+	// PathPrefix - разрешить выбор аккаунта префиксом /t/<slug>/, для разработки
+	PathPrefix bool
+}
+
+func NewHandler(
+	tenantCfg TenantConfig,
+	trustedProxies []netip.Prefix,
+	authUC usecases.AuthUseCase,
+	accountsUC usecases.AccountsUseCase,
+	auditUC usecases.AuditUseCase,
+	filesUC usecases.FilesUseCase,
+	trashUC usecases.TrashUseCase,
+	billingUC usecases.BillingUseCase,
+	closureUC usecases.ClosureUseCase,
+	coursesUC usecases.CoursesUseCase,
+	maxUploadSize int64,
+) http.Handler {
 	h := &Handler{
-		authUC: authUC,
-		mux:    http.NewServeMux(),
+		authUC:        authUC,
+		accountsUC:    accountsUC,
+		auditUC:       auditUC,
+		filesUC:       filesUC,
+		trashUC:       trashUC,
+		billingUC:     billingUC,
+		closureUC:     closureUC,
+		coursesUC:     coursesUC,
+		mux:           http.NewServeMux(),
+		maxUploadSize: maxUploadSize,
 	}
 	h.mux.HandleFunc("POST /auth/sendcode", h.SendCode)
 	h.mux.HandleFunc("POST /auth/signup", h.SignUp)
 	h.mux.HandleFunc("POST /auth/signin", h.SignIn)
 	h.mux.HandleFunc("POST /auth/refresh", h.RefreshSession)
 
+	h.mux.Handle("GET /tenant", middleware.RequireTenant(http.HandlerFunc(h.GetTenant)))
+	h.mux.Handle("GET /tenant/logo", middleware.RequireTenant(http.HandlerFunc(h.GetTenantLogo)))
+	h.mux.Handle("GET /tenant/favicon", middleware.RequireTenant(http.HandlerFunc(h.GetTenantFavicon)))
+
+	h.mux.Handle("POST /accounts/create", h.withAuth(h.CreateAccount))
+	h.mux.Handle("GET /accounts/myaccounts", h.withAuth(h.GetMyAccounts))
+	h.mux.Handle("GET /accounts/{id}/members", h.withAccount(h.GetAccountMembers))
+	h.mux.Handle("POST /accounts/{id}/members/add", h.withAccount(h.AddAccountMember))
+	h.mux.Handle("POST /accounts/{id}/members/delete", h.withAccount(h.RemoveAccountMember))
+	h.mux.Handle("POST /accounts/{id}/members/update", h.withAccount(h.UpdateAccountMember))
+	h.mux.Handle("POST /accounts/{id}/members/import", h.withAccount(h.ImportMembers))
+	h.mux.Handle("GET /accounts/{id}/settings", h.withAccount(h.GetAccountSettings))
+	h.mux.Handle("POST /accounts/{id}/settings/update", h.withAccount(h.UpdateAccountSettings))
+
+	h.mux.Handle("POST /files/upload", h.withActiveAccount(h.UploadFile))
+	h.mux.Handle("GET /files/{id}", h.withAuth(h.DownloadFile))
+	h.mux.Handle("GET /accounts/{id}/storage", h.withAccount(h.GetStorageUsage))
+
+	h.mux.Handle("GET /accounts/{id}/audit", h.withAccount(h.GetAuditLog))
+	h.mux.Handle("GET /accounts/{id}/audit/export", h.withAccount(h.ExportAuditLog))
+
+	h.mux.Handle("GET /accounts/{id}/trash", h.withAccount(h.GetTrash))
+	h.mux.Handle("POST /accounts/{id}/trash/restore", h.withAccount(h.RestoreTrashItem))
+
+	h.mux.HandleFunc("GET /plans", h.GetPlans)
+	h.mux.HandleFunc("POST /billing/webhook", h.BillingWebhook)
+	h.mux.Handle("GET /accounts/{id}/subscription", h.withAccount(h.GetSubscription))
+	h.mux.Handle("POST /accounts/{id}/subscription/checkout", h.withAccount(h.StartCheckout))
+
+	h.mux.Handle("GET /accounts/{id}/closure", h.withAccount(h.GetAccountClosure))
+	h.mux.Handle("POST /accounts/{id}/closure", h.withAccount(h.RequestAccountClosure))
+	h.mux.Handle("POST /accounts/{id}/closure/cancel", h.withAccount(h.CancelAccountClosure))
+	h.mux.Handle("GET /accounts/{id}/closure/export", h.withAccount(h.DownloadAccountExport))
+
+	h.mux.Handle("GET /accounts/{id}/courses", h.withAccount(h.GetCourses))
+	h.mux.Handle("POST /accounts/{id}/courses/create", h.withAccount(h.CreateCourse))
+	h.mux.Handle("POST /accounts/{id}/courses/import", h.withAccount(h.ImportCourse))
+	h.mux.Handle("POST /accounts/{id}/courses/import-package", h.withAccount(h.ImportPackage))
+	h.mux.Handle("POST /accounts/{id}/courses/import-markdown", h.withAccount(h.ImportMarkdownCourse))
+	h.mux.Handle("GET /accounts/{id}/courses/{course_id}", h.withAccount(h.GetCourse))
+	h.mux.Handle("GET /accounts/{id}/courses/{course_id}/tree", h.withAccount(h.GetCourseTree))
+	h.mux.Handle("GET /accounts/{id}/courses/{course_id}/draft", h.withAccount(h.GetCourseDraft))
+	h.mux.Handle("GET /accounts/{id}/courses/{course_id}/changes", h.withAccount(h.GetCourseChanges))
+	h.mux.Handle("POST /accounts/{id}/courses/{course_id}/publish", h.withAccount(h.PublishCourse))
+	h.mux.Handle("GET /accounts/{id}/courses/{course_id}/versions", h.withAccount(h.GetCourseVersions))
+	h.mux.Handle("POST /accounts/{id}/courses/{course_id}/rollback", h.withAccount(h.RollbackCourse))
+	h.mux.Handle("GET /accounts/{id}/courses/{course_id}/published", h.withAccount(h.GetPublishedCourse))
+	h.mux.Handle("GET /accounts/{id}/courses/{course_id}/revisions", h.withAccount(h.GetCourseRevisions))
+	h.mux.Handle("POST /accounts/{id}/courses/{course_id}/duplicate", h.withAccount(h.DuplicateCourse))
+	h.mux.Handle("GET /accounts/{id}/courses/{course_id}/export", h.withAccount(h.ExportCourse))
+	h.mux.Handle("POST /accounts/{id}/courses/{course_id}/sync-markdown", h.withAccount(h.SyncMarkdownCourse))
+	h.mux.Handle("GET /accounts/{id}/courses/{course_id}/progress", h.withAccount(h.GetCourseProgress))
+	h.mux.Handle("POST /accounts/{id}/courses/{course_id}/update", h.withAccount(h.UpdateCourse))
+	h.mux.Handle("POST /accounts/{id}/courses/{course_id}/delete", h.withAccount(h.RemoveCourse))
+	h.mux.Handle("GET /accounts/{id}/courses/{course_id}/modules", h.withAccount(h.GetModules))
+	h.mux.Handle("POST /accounts/{id}/courses/{course_id}/modules/create", h.withAccount(h.CreateModule))
+	h.mux.Handle("POST /accounts/{id}/courses/{course_id}/modules/reorder", h.withAccount(h.ReorderModules))
+	h.mux.Handle("GET /accounts/{id}/modules/{module_id}/revisions", h.withAccount(h.GetModuleRevisions))
+	h.mux.Handle("POST /accounts/{id}/modules/{module_id}/update", h.withAccount(h.UpdateModule))
+	h.mux.Handle("POST /accounts/{id}/modules/{module_id}/reorder", h.withAccount(h.ReorderModule))
+	h.mux.Handle("POST /accounts/{id}/modules/{module_id}/delete", h.withAccount(h.RemoveModule))
+	h.mux.Handle("GET /accounts/{id}/modules/{module_id}/lessons", h.withAccount(h.GetLessons))
+	h.mux.Handle("POST /accounts/{id}/modules/{module_id}/lessons/create", h.withAccount(h.CreateLesson))
+	h.mux.Handle("POST /accounts/{id}/modules/{module_id}/lessons/reorder", h.withAccount(h.ReorderLessons))
+	h.mux.Handle("GET /accounts/{id}/lessons/{lesson_id}/revisions", h.withAccount(h.GetLessonRevisions))
+	h.mux.Handle("POST /accounts/{id}/lessons/{lesson_id}/update", h.withAccount(h.UpdateLesson))
+	h.mux.Handle("POST /accounts/{id}/lessons/{lesson_id}/reorder", h.withAccount(h.ReorderLesson))
+	h.mux.Handle("POST /accounts/{id}/lessons/{lesson_id}/move", h.withAccount(h.MoveLesson))
+	h.mux.Handle("POST /accounts/{id}/lessons/{lesson_id}/delete", h.withAccount(h.RemoveLesson))
+	h.mux.Handle("GET /accounts/{id}/lessons/{lesson_id}/blocks", h.withAccount(h.GetBlocks))
+	h.mux.Handle("POST /accounts/{id}/lessons/{lesson_id}/blocks/text/create", h.withAccount(h.CreateTextBlock))
+	h.mux.Handle("POST /accounts/{id}/lessons/{lesson_id}/blocks/video/create", h.withAccount(h.CreateVideoBlock))
+	h.mux.Handle("POST /accounts/{id}/lessons/{lesson_id}/blocks/scorm/create", h.withAccount(h.CreateScormBlock))
+	h.mux.Handle("POST /accounts/{id}/lessons/{lesson_id}/blocks/quiz/create", h.withAccount(h.CreateQuizBlock))
+	h.mux.Handle("POST /accounts/{id}/lessons/{lesson_id}/blocks/assignment/create", h.withAccount(h.CreateAssignmentBlock))
+	h.mux.Handle("POST /accounts/{id}/lessons/{lesson_id}/blocks/code-exercise/create", h.withAccount(h.CreateCodeExerciseBlock))
+	h.mux.Handle("POST /accounts/{id}/lessons/{lesson_id}/blocks/image/create", h.withAccount(h.CreateImageBlock))
+	h.mux.Handle("POST /accounts/{id}/lessons/{lesson_id}/blocks/attachment/create", h.withAccount(h.CreateAttachmentBlock))
+	h.mux.Handle("POST /accounts/{id}/lessons/{lesson_id}/blocks/embed/create", h.withAccount(h.CreateEmbedBlock))
+	h.mux.Handle("POST /accounts/{id}/lessons/{lesson_id}/blocks/reorder", h.withAccount(h.ReorderBlocks))
+	h.mux.Handle("POST /accounts/{id}/blocks/{block_id}/text/update", h.withAccount(h.UpdateTextBlock))
+	h.mux.Handle("POST /accounts/{id}/blocks/{block_id}/quiz/update", h.withAccount(h.UpdateQuizBlock))
+	h.mux.Handle("POST /accounts/{id}/blocks/{block_id}/assignment/update", h.withAccount(h.UpdateAssignmentBlock))
+	h.mux.Handle("POST /accounts/{id}/blocks/{block_id}/code-exercise/update", h.withAccount(h.UpdateCodeExerciseBlock))
+	h.mux.Handle("POST /accounts/{id}/blocks/{block_id}/reorder", h.withAccount(h.ReorderBlock))
+	h.mux.Handle("POST /accounts/{id}/blocks/{block_id}/move", h.withAccount(h.MoveBlock))
+	h.mux.Handle("POST /accounts/{id}/blocks/{block_id}/delete", h.withAccount(h.RemoveBlock))
+	h.mux.Handle("POST /accounts/{id}/blocks/{block_id}/scorm/launch", h.withAccount(h.LaunchScorm))
+	h.mux.Handle("GET /accounts/{id}/blocks/{block_id}/quiz", h.withAccount(h.GetQuiz))
+	h.mux.Handle("POST /accounts/{id}/blocks/{block_id}/quiz/attempts", h.withAccount(h.SubmitQuizAttempt))
+	h.mux.Handle("GET /accounts/{id}/blocks/{block_id}/assignment", h.withAccount(h.GetAssignment))
+	h.mux.Handle("POST /accounts/{id}/blocks/{block_id}/assignment/submissions", h.withAccount(h.SubmitAssignment))
+	h.mux.Handle("GET /accounts/{id}/blocks/{block_id}/code-exercise", h.withAccount(h.GetCodeExercise))
+	h.mux.Handle("POST /accounts/{id}/blocks/{block_id}/code-exercise/submissions", h.withAccount(h.SubmitCode))
+	h.mux.Handle("GET /accounts/{id}/blocks/{block_id}/image", h.withAccount(h.DownloadImage))
+	h.mux.Handle("GET /accounts/{id}/blocks/{block_id}/attachment", h.withAccount(h.DownloadAttachment))
+	h.mux.Handle("GET /accounts/{id}/courses/{course_id}/assignment-submissions", h.withAccount(h.GetSubmissionQueue))
+	h.mux.Handle("GET /accounts/{id}/assignment-submissions/{submission_id}", h.withAccount(h.GetSubmission))
+	h.mux.Handle("POST /accounts/{id}/assignment-submissions/{submission_id}/grade", h.withAccount(h.GradeSubmission))
+	h.mux.Handle("POST /accounts/{id}/assignment-submissions/{submission_id}/return", h.withAccount(h.ReturnSubmission))
+	h.mux.Handle("GET /accounts/{id}/assignment-submissions/{submission_id}/files/{file_id}", h.withAccount(h.DownloadSubmissionFile))
+	h.mux.Handle("GET /accounts/{id}/blocks/{block_id}/revisions", h.withAccount(h.GetBlockRevisions))
+	h.mux.Handle("GET /accounts/{id}/blocks/{block_id}/revisions/diff", h.withAccount(h.DiffBlockRevisions))
+	h.mux.Handle("POST /accounts/{id}/revisions/{revision_id}/restore", h.withAccount(h.RestoreRevision))
+
+	// сессия SCORM авторизует запросы идентификатором в пути
+	h.mux.HandleFunc("GET /scorm/{session_id}/player", h.GetScormPlayer)
+	h.mux.HandleFunc("GET /scorm/{session_id}/content/{path...}", h.GetScormContent)
+	h.mux.HandleFunc("GET /scorm/{session_id}/runtime", h.GetScormRuntime)
+	h.mux.HandleFunc("POST /scorm/{session_id}/runtime", h.CommitScormRuntime)
+
 	/*
 		c
 
@@ -59,5 +207,22 @@ func NewHandler(authUC usecases.AuthUseCase) http.Handler {
 		/accounts/getbyid
 	*/
 
-	return middleware.LoggingMiddleware(h)
+	var next http.Handler = h
+	next = middleware.TenantMiddleware(next, accountsUC, tenantCfg.BaseDomain, tenantCfg.PathPrefix)
+	next = middleware.ClientIPMiddleware(next, trustedProxies)
+	return middleware.LoggingMiddleware(next)
+}
+
+func (h *Handler) withAuth(f http.HandlerFunc) http.Handler {
+	return middleware.AuthMiddleware(f, h.authUC)
+}
+
+// withAccount - маршрут в аккаунте из пути /accounts/{id}/..., участие проверяется до вызова обработчика
+func (h *Handler) withAccount(f http.HandlerFunc) http.Handler {
+	return middleware.AuthMiddleware(middleware.AccountMiddleware(f, h.accountsUC, "id"), h.authUC)
+}
+
+// withActiveAccount - маршрут в аккаунте из заголовка X-Account-ID или tenant
+func (h *Handler) withActiveAccount(f http.HandlerFunc) http.Handler {
+	return middleware.AuthMiddleware(middleware.AccountMiddleware(f, h.accountsUC, ""), h.authUC)
 }
@@ -0,0 +1,68 @@
+package http
+
+import (
+	"archive/zip"
+	uerrors "chalk/internal/errors"
+	"chalk/internal/transport/http/dto"
+	"chalk/internal/usecases"
+	"chalk/pkg/coursearchive"
+	"errors"
+	"net/http"
+)
+
+// ImportMarkdownCourse создаёт курс из zip с каталогом Markdown в поле формы file
+func (h *Handler) ImportMarkdownCourse(w http.ResponseWriter, r *http.Request) {
+	accountID, err := pathInt64(r, "id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	h.syncMarkdownCourse(w, r, accountID, 0, http.StatusCreated)
+}
+
+// SyncMarkdownCourse приводит курс к zip с каталогом Markdown в поле формы file
+func (h *Handler) SyncMarkdownCourse(w http.ResponseWriter, r *http.Request) {
+	item, err := courseItemFromRequest(r, "course_id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	h.syncMarkdownCourse(w, r, item.AccountID, item.ID, http.StatusOK)
+}
+
+func (h *Handler) syncMarkdownCourse(w http.ResponseWriter, r *http.Request, accountID, courseID int64, status int) {
+	part, size, ok := h.archiveFromForm(w, r)
+	if !ok {
+		return
+	}
+	defer r.MultipartForm.RemoveAll()
+	defer part.Close()
+
+	zr, err := zip.NewReader(part, size)
+	if err != nil {
+		writeInvalidArchive(w, uerrors.ErrInvalidMarkdownCourse, []coursearchive.Problem{{Message: "file is not a zip archive: " + err.Error()}})
+		return
+	}
+
+	res, err := h.coursesUC.SyncMarkdownCourse(r.Context(), usecases.SyncMarkdownCourseParams{
+		ActorID:   sessionFromRequest(r).UserID,
+		AccountID: accountID,
+		CourseID:  courseID,
+		Source:    zr,
+	})
+	if err != nil {
+		if errors.Is(err, uerrors.ErrInvalidMarkdownCourse) {
+			writeInvalidArchive(w, err, res.Problems)
+			return
+		}
+		writeAppError(w, err)
+		return
+	}
+	writeJSON(w, status, dto.MarkdownSyncResponse{
+		Course:   toCourseDTO(res.Course),
+		Created:  res.Created,
+		Updated:  res.Updated,
+		Removed:  res.Removed,
+		Warnings: toArchiveProblemsDTO(res.Warnings),
+	})
+}
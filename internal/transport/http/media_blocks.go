@@ -0,0 +1,127 @@
+package http
+
+import (
+	"chalk/internal/transport/http/dto"
+	"chalk/internal/usecases"
+	"net/http"
+)
+
+func (h *Handler) CreateImageBlock(w http.ResponseWriter, r *http.Request) {
+	item, err := courseItemFromRequest(r, "lesson_id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var req dto.CreateImageBlockRequest
+	if err := readJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	id, err := h.coursesUC.CreateImageBlock(r.Context(), usecases.CreateImageBlockParams{
+		ActorID:   item.ActorID,
+		AccountID: item.AccountID,
+		LessonID:  item.ID,
+		FileID:    req.FileID,
+		AltText:   req.AltText,
+		Caption:   req.Caption,
+		OrderIdx:  req.OrderIdx,
+	})
+	if err != nil {
+		writeAppError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, dto.CreateBlockResponse{ID: id})
+}
+
+func (h *Handler) CreateAttachmentBlock(w http.ResponseWriter, r *http.Request) {
+	item, err := courseItemFromRequest(r, "lesson_id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var req dto.CreateAttachmentBlockRequest
+	if err := readJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	id, err := h.coursesUC.CreateAttachmentBlock(r.Context(), usecases.CreateAttachmentBlockParams{
+		ActorID:   item.ActorID,
+		AccountID: item.AccountID,
+		LessonID:  item.ID,
+		FileID:    req.FileID,
+		Title:     req.Title,
+		OrderIdx:  req.OrderIdx,
+	})
+	if err != nil {
+		writeAppError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, dto.CreateBlockResponse{ID: id})
+}
+
+func (h *Handler) CreateEmbedBlock(w http.ResponseWriter, r *http.Request) {
+	item, err := courseItemFromRequest(r, "lesson_id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var req dto.CreateEmbedBlockRequest
+	if err := readJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	id, err := h.coursesUC.CreateEmbedBlock(r.Context(), usecases.CreateEmbedBlockParams{
+		ActorID:   item.ActorID,
+		AccountID: item.AccountID,
+		LessonID:  item.ID,
+		URL:       req.URL,
+		Title:     req.Title,
+		OrderIdx:  req.OrderIdx,
+	})
+	if err != nil {
+		writeAppError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, dto.CreateBlockResponse{ID: id})
+}
+
+// DownloadImage отдаёт картинку для показа в уроке
+func (h *Handler) DownloadImage(w http.ResponseWriter, r *http.Request) {
+	item, err := courseItemFromRequest(r, "block_id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	file, obj, err := h.coursesUC.OpenImage(r.Context(), item)
+	if err != nil {
+		writeAppError(w, err)
+		return
+	}
+	defer obj.Close()
+
+	writeFile(w, file, obj, "inline")
+}
+
+func (h *Handler) DownloadAttachment(w http.ResponseWriter, r *http.Request) {
+	item, err := courseItemFromRequest(r, "block_id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	file, obj, err := h.coursesUC.OpenAttachment(r.Context(), item)
+	if err != nil {
+		writeAppError(w, err)
+		return
+	}
+	defer obj.Close()
+
+	writeFile(w, file, obj, "attachment")
+}
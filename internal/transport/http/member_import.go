@@ -0,0 +1,118 @@
+package http
+
+import (
+	uerrors "chalk/internal/errors"
+	"chalk/internal/transport/http/dto"
+	"chalk/internal/usecases"
+	"chalk/pkg/log"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// maxImportFileSize - ограничение размера CSV-файла импорта участников
+const maxImportFileSize = 10 << 20
+
+var memberImportCSVHeader = []string{"line", "email", "status", "result", "message"}
+
+// ImportMembers импортирует участников из CSV в поле формы file.
+// ?dry_run=true только строит отчёт, ?format=csv отдаёт отчёт файлом.
+func (h *Handler) ImportMembers(w http.ResponseWriter, r *http.Request) {
+	accountID, err := pathInt64(r, "id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	dryRun, err := queryBool(r, "dry_run")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportFileSize)
+	if err := r.ParseMultipartForm(uploadMemoryLimit); err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			writeError(w, http.StatusRequestEntityTooLarge, uerrors.ErrFileTooLarge.Error())
+			return
+		}
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("parse multipart form: %v", err))
+		return
+	}
+	defer r.MultipartForm.RemoveAll()
+
+	part, _, err := r.FormFile("file")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "form field file is required")
+		return
+	}
+	defer part.Close()
+
+	report, err := h.accountsUC.ImportMembers(r.Context(), usecases.ImportMembersParams{
+		ActorID:   sessionFromRequest(r).UserID,
+		AccountID: accountID,
+		Reader:    part,
+		DryRun:    dryRun,
+	})
+	if err != nil {
+		writeAppError(w, err)
+		return
+	}
+
+	if f := queryString(r, "format"); f != nil && *f == "csv" {
+		writeMemberImportReportCSV(w, accountID, report)
+		return
+	}
+
+	res := dto.ImportMembersResponse{
+		DryRun:  report.DryRun,
+		Created: report.Created,
+		Skipped: report.Skipped,
+		Errored: report.Errored,
+		Rows:    make([]dto.MemberImportRow, 0, len(report.Rows)),
+	}
+	for _, row := range report.Rows {
+		res.Rows = append(res.Rows, dto.MemberImportRow{
+			Line:    row.Line,
+			Email:   row.Email,
+			Status:  string(row.Status),
+			Result:  string(row.Result),
+			Message: row.Message,
+		})
+	}
+	writeJSON(w, http.StatusOK, res)
+}
+
+func writeMemberImportReportCSV(w http.ResponseWriter, accountID int64, report usecases.MemberImportReport) {
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(
+		"attachment; filename=member-import-%d-%s.csv", accountID, time.Now().UTC().Format("20060102-150405"),
+	))
+	w.WriteHeader(http.StatusOK)
+
+	cw := csv.NewWriter(w)
+	if err := cw.Write(memberImportCSVHeader); err != nil {
+		log.Warnf("write import report: %v", err)
+		return
+	}
+	for _, row := range report.Rows {
+		err := cw.Write([]string{
+			strconv.Itoa(row.Line),
+			row.Email,
+			string(row.Status),
+			string(row.Result),
+			row.Message,
+		})
+		if err != nil {
+			log.Warnf("write import report: %v", err)
+			return
+		}
+	}
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		log.Warnf("write import report: %v", err)
+	}
+}
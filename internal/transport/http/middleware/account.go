@@ -0,0 +1,93 @@
+package middleware
+
+import (
+	"chalk/internal/entities"
+	"chalk/internal/errors"
+	"chalk/internal/repo/models"
+	"chalk/internal/usecases"
+	"chalk/pkg/log"
+	"net/http"
+	"strconv"
+)
+
+const AccountIDHeader = "X-Account-ID"
+
+// AccountMiddleware определяет аккаунт, в котором выполняется запрос, проверяет
+// участие в нём пользователя сессии и кладёт аккаунт и роль в контекст.
+// Аккаунт берётся из параметра пути pathParam (если задан), заголовка X-Account-ID
+// или tenant. Если источников несколько, они должны совпадать.
+// Должен выполняться после AuthMiddleware.
+func AccountMiddleware(next http.Handler, accUC usecases.AccountsUseCase, pathParam string) http.Handler {
+	return &accountMiddleware{
+		next:      next,
+		accUC:     accUC,
+		pathParam: pathParam,
+	}
+}
+
+type accountMiddleware struct {
+	next      http.Handler
+	accUC     usecases.AccountsUseCase
+	pathParam string
+}
+
+func (mw *accountMiddleware) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	accountID, err := mw.accountID(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	session, ok := r.Context().Value(SessionKey).(entities.Session)
+	if !ok {
+		http.Error(w, errors.ErrInvalidAccessToken.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	aa, err := mw.accUC.ResolveActiveAccount(r.Context(), session.UserID, accountID)
+	if err != nil {
+		switch {
+		case err == errors.ErrAccountNotFound:
+			http.Error(w, err.Error(), http.StatusNotFound)
+		case errors.IsUserError(err):
+			http.Error(w, err.Error(), http.StatusForbidden)
+		default:
+			log.Errorf("resolve active account error: %v", err)
+			http.Error(w, "internal error", http.StatusInternalServerError)
+		}
+		return
+	}
+	r = r.WithContext(usecases.WithActiveAccount(r.Context(), aa))
+	mw.next.ServeHTTP(w, r)
+}
+
+func (mw *accountMiddleware) accountID(r *http.Request) (int64, error) {
+	var ids []int64
+	if mw.pathParam != "" {
+		id, err := strconv.ParseInt(r.PathValue(mw.pathParam), 10, 64)
+		if err != nil {
+			return 0, errors.ErrAccountNotFound
+		}
+		ids = append(ids, id)
+	}
+	if raw := r.Header.Get(AccountIDHeader); raw != "" {
+		id, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return 0, errors.ErrAccountNotSelected
+		}
+		ids = append(ids, id)
+	}
+	if tenant, ok := r.Context().Value(TenantKey).(models.Account); ok {
+		ids = append(ids, tenant.ID)
+	}
+
+	if len(ids) == 0 {
+		return 0, errors.ErrAccountNotSelected
+	}
+	for _, id := range ids[1:] {
+		if id != ids[0] {
+			return 0, errors.ErrAccountMismatch
+		}
+	}
+	return ids[0], nil
+}
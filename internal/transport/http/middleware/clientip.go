@@ -0,0 +1,90 @@
+package middleware
+
+import (
+	"chalk/internal/usecases"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// ClientIPMiddleware кладёт в контекст адрес клиента. X-Forwarded-For учитывается,
+// только если запрос пришёл от доверенного прокси из trusted.
+func ClientIPMiddleware(next http.Handler, trusted []netip.Prefix) http.Handler {
+	return &clientIPMiddleware{next: next, trusted: trusted}
+}
+
+type clientIPMiddleware struct {
+	next    http.Handler
+	trusted []netip.Prefix
+}
+
+func (mw *clientIPMiddleware) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	r = r.WithContext(usecases.WithClientIP(r.Context(), mw.clientIP(r)))
+	mw.next.ServeHTTP(w, r)
+}
+
+// ParseTrustedProxies разбирает список адресов и подсетей доверенных прокси
+func ParseTrustedProxies(values []string) ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(values))
+	for _, v := range values {
+		v = strings.TrimSpace(v)
+		if !strings.Contains(v, "/") {
+			addr, err := netip.ParseAddr(v)
+			if err != nil {
+				return nil, fmt.Errorf("invalid trusted proxy %q: %w", v, err)
+			}
+			prefixes = append(prefixes, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+			continue
+		}
+		prefix, err := netip.ParsePrefix(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q: %w", v, err)
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+	return prefixes, nil
+}
+
+// clientIP берёт адрес соединения, а если он принадлежит доверенному прокси - самый правый
+// адрес X-Forwarded-For, не являющийся доверенным прокси. Левые значения клиент может подделать.
+func (mw *clientIPMiddleware) clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	if !mw.isTrusted(host) {
+		return host
+	}
+	hops := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := strings.TrimSpace(hops[i])
+		if hop == "" {
+			continue
+		}
+		if _, err := netip.ParseAddr(hop); err != nil {
+			// мусор в заголовке - дальше цепочке доверять нельзя
+			return host
+		}
+		if !mw.isTrusted(hop) {
+			return hop
+		}
+		host = hop
+	}
+	return host
+}
+
+func (mw *clientIPMiddleware) isTrusted(ip string) bool {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, p := range mw.trusted {
+		if p.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
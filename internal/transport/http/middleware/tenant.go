@@ -0,0 +1,110 @@
+package middleware
+
+import (
+	"chalk/internal/errors"
+	"chalk/internal/usecases"
+	"chalk/pkg/log"
+	"context"
+	"net"
+	"net/http"
+	"strings"
+)
+
+const TenantKey string = "tenant"
+
+// tenantPathPrefix используется в разработке, когда поддомены недоступны:
+// запрос /t/<slug>/courses обрабатывается как /courses на поддомене <slug>
+const tenantPathPrefix = "/t/"
+
+// TenantMiddleware определяет аккаунт по поддомену <slug>.<baseDomain> или,
+// если включен pathPrefix, по префиксу пути /t/<slug>/ и кладёт его в контекст.
+// Запросы без tenant пропускаются без изменений, для неизвестного tenant отдаётся 404.
+func TenantMiddleware(next http.Handler, accUC usecases.AccountsUseCase, baseDomain string, pathPrefix bool) http.Handler {
+	return &tenantMiddleware{
+		next:       next,
+		accUC:      accUC,
+		baseDomain: strings.ToLower(strings.TrimPrefix(baseDomain, ".")),
+		pathPrefix: pathPrefix,
+	}
+}
+
+type tenantMiddleware struct {
+	next       http.Handler
+	accUC      usecases.AccountsUseCase
+	baseDomain string
+	pathPrefix bool
+}
+
+func (mw *tenantMiddleware) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	slug, ok := mw.slugFromHost(r.Host)
+	if !ok && mw.pathPrefix {
+		slug, ok = slugFromPath(r)
+	}
+	if !ok {
+		mw.next.ServeHTTP(w, r)
+		return
+	}
+
+	acc, err := mw.accUC.ResolveTenant(r.Context(), slug)
+	if err != nil {
+		if errors.IsUserError(err) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+		} else {
+			log.Errorf("resolve tenant error: %v", err)
+			http.Error(w, "internal error", http.StatusInternalServerError)
+		}
+		return
+	}
+	r = r.WithContext(context.WithValue(r.Context(), TenantKey, acc))
+	mw.next.ServeHTTP(w, r)
+}
+
+func (mw *tenantMiddleware) slugFromHost(hostport string) (string, bool) {
+	if mw.baseDomain == "" {
+		return "", false
+	}
+	host := hostport
+	if h, _, err := net.SplitHostPort(hostport); err == nil {
+		host = h
+	}
+	host = strings.ToLower(host)
+
+	sub, ok := strings.CutSuffix(host, "."+mw.baseDomain)
+	if !ok || sub == "" || strings.Contains(sub, ".") {
+		return "", false
+	}
+	return sub, true
+}
+
+// slugFromPath достаёт slug из префикса /t/<slug>/ и убирает префикс из пути запроса
+func slugFromPath(r *http.Request) (string, bool) {
+	rest, ok := strings.CutPrefix(r.URL.Path, tenantPathPrefix)
+	if !ok {
+		return "", false
+	}
+	slug, path, _ := strings.Cut(rest, "/")
+	if slug == "" {
+		return "", false
+	}
+
+	r.URL.Path = "/" + path
+	r.URL.RawPath = ""
+	return slug, true
+}
+
+// RequireTenant отклоняет запросы, для которых TenantMiddleware не определил аккаунт
+func RequireTenant(next http.Handler) http.Handler {
+	return &requireTenantMiddleware{next: next}
+}
+
+type requireTenantMiddleware struct {
+	next http.Handler
+}
+
+func (mw *requireTenantMiddleware) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Context().Value(TenantKey) == nil {
+		http.Error(w, errors.ErrTenantNotFound.Error(), http.StatusNotFound)
+		return
+	}
+	mw.next.ServeHTTP(w, r)
+}
@@ -0,0 +1,212 @@
+package http
+
+import (
+	uerrors "chalk/internal/errors"
+	"chalk/internal/repo/models"
+	"chalk/internal/transport/http/dto"
+	"chalk/internal/usecases"
+	"chalk/pkg/quiz"
+	"errors"
+	"net/http"
+)
+
+func (h *Handler) CreateQuizBlock(w http.ResponseWriter, r *http.Request) {
+	item, err := courseItemFromRequest(r, "lesson_id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var req dto.CreateQuizBlockRequest
+	if err := readJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	res, err := h.coursesUC.CreateQuizBlock(r.Context(), usecases.CreateQuizBlockParams{
+		ActorID:   item.ActorID,
+		AccountID: item.AccountID,
+		LessonID:  item.ID,
+		Quiz:      fromQuizDTO(req.Quiz),
+		OrderIdx:  req.OrderIdx,
+	})
+	if err != nil {
+		writeQuizError(w, err, res.Problems)
+		return
+	}
+	writeJSON(w, http.StatusCreated, dto.CreateBlockResponse{ID: res.BlockID})
+}
+
+func (h *Handler) UpdateQuizBlock(w http.ResponseWriter, r *http.Request) {
+	item, err := courseItemFromRequest(r, "block_id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var req dto.UpdateQuizBlockRequest
+	if err := readJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	res, err := h.coursesUC.UpdateQuizBlock(r.Context(), usecases.UpdateQuizBlockParams{
+		ActorID:   item.ActorID,
+		AccountID: item.AccountID,
+		BlockID:   item.ID,
+		Quiz:      fromQuizDTO(req.Quiz),
+	})
+	if err != nil {
+		writeQuizError(w, err, res.Problems)
+		return
+	}
+	writeJSON(w, http.StatusOK, struct{}{})
+}
+
+func (h *Handler) GetQuiz(w http.ResponseWriter, r *http.Request) {
+	item, err := courseItemFromRequest(r, "block_id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	state, err := h.coursesUC.GetQuiz(r.Context(), item)
+	if err != nil {
+		writeAppError(w, err)
+		return
+	}
+	res := dto.GetQuizResponse{
+		BlockID:      state.BlockID,
+		Quiz:         toQuizDTO(state.Quiz),
+		Attempts:     make([]dto.QuizAttempt, 0, len(state.Attempts)),
+		AttemptsLeft: state.AttemptsLeft,
+	}
+	for _, a := range state.Attempts {
+		res.Attempts = append(res.Attempts, toQuizAttemptDTO(a))
+	}
+	writeJSON(w, http.StatusOK, res)
+}
+
+func (h *Handler) SubmitQuizAttempt(w http.ResponseWriter, r *http.Request) {
+	item, err := courseItemFromRequest(r, "block_id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var req dto.SubmitQuizAttemptRequest
+	if err := readJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	answers := make([]quiz.Answer, 0, len(req.Answers))
+	for _, a := range req.Answers {
+		answers = append(answers, quiz.Answer{QuestionID: a.QuestionID, OptionIDs: a.OptionIDs, Number: a.Number, Text: a.Text})
+	}
+
+	res, err := h.coursesUC.SubmitQuizAttempt(r.Context(), usecases.SubmitQuizAttemptParams{
+		ActorID:   item.ActorID,
+		AccountID: item.AccountID,
+		BlockID:   item.ID,
+		Answers:   answers,
+	})
+	if err != nil {
+		writeAppError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, dto.SubmitQuizAttemptResponse{
+		Attempt:  toQuizAttemptDTO(res.Attempt),
+		Progress: toBlockProgressDTO(res.Progress),
+	})
+}
+
+// writeQuizError отдаёт ошибки теста списком, остальные ошибки - как обычно
+func writeQuizError(w http.ResponseWriter, err error, problems []quiz.Problem) {
+	if !errors.Is(err, uerrors.ErrInvalidQuiz) {
+		writeAppError(w, err)
+		return
+	}
+	res := dto.InvalidQuizResponse{Error: err.Error(), Problems: make([]dto.QuizProblem, 0, len(problems))}
+	for _, p := range problems {
+		res.Problems = append(res.Problems, dto.QuizProblem{Path: p.Path, Message: p.Message})
+	}
+	writeJSON(w, http.StatusBadRequest, res)
+}
+
+func toQuizDTO(q quiz.Quiz) dto.Quiz {
+	res := dto.Quiz{PassingScore: q.PassingScore, MaxAttempts: q.MaxAttempts, Questions: make([]dto.QuizQuestion, 0, len(q.Questions))}
+	for _, qs := range q.Questions {
+		question := dto.QuizQuestion{
+			ID:                qs.ID,
+			Type:              string(qs.Type),
+			Text:              qs.Text,
+			Points:            qs.Points,
+			Answer:            qs.Answer,
+			Tolerance:         qs.Tolerance,
+			Answers:           qs.Answers,
+			CaseSensitive:     qs.CaseSensitive,
+			CorrectFeedback:   qs.CorrectFeedback,
+			IncorrectFeedback: qs.IncorrectFeedback,
+		}
+		for _, o := range qs.Options {
+			question.Options = append(question.Options, dto.QuizOption{ID: o.ID, Text: o.Text, Correct: o.Correct, Feedback: o.Feedback})
+		}
+		res.Questions = append(res.Questions, question)
+	}
+	return res
+}
+
+func fromQuizDTO(q dto.Quiz) quiz.Quiz {
+	res := quiz.Quiz{PassingScore: q.PassingScore, MaxAttempts: q.MaxAttempts, Questions: make([]quiz.Question, 0, len(q.Questions))}
+	for _, qs := range q.Questions {
+		question := quiz.Question{
+			ID:                qs.ID,
+			Type:              quiz.QuestionType(qs.Type),
+			Text:              qs.Text,
+			Points:            qs.Points,
+			Answer:            qs.Answer,
+			Tolerance:         qs.Tolerance,
+			Answers:           qs.Answers,
+			CaseSensitive:     qs.CaseSensitive,
+			CorrectFeedback:   qs.CorrectFeedback,
+			IncorrectFeedback: qs.IncorrectFeedback,
+		}
+		for _, o := range qs.Options {
+			question.Options = append(question.Options, quiz.Option{ID: o.ID, Text: o.Text, Correct: o.Correct, Feedback: o.Feedback})
+		}
+		res.Questions = append(res.Questions, question)
+	}
+	return res
+}
+
+func toQuizAttemptDTO(a models.QuizAttempt) dto.QuizAttempt {
+	res := dto.QuizAttempt{
+		ID:          a.ID,
+		AttemptNo:   a.AttemptNo,
+		Score:       a.Result.Score,
+		Points:      a.Result.Points,
+		MaxPoints:   a.Result.MaxPoints,
+		Passed:      a.Result.Passed,
+		Answers:     make([]dto.QuizAnswer, 0, len(a.Answers)),
+		Questions:   make([]dto.QuizQuestionResult, 0, len(a.Result.Questions)),
+		SubmittedAt: a.SubmittedAt,
+	}
+	for _, ans := range a.Answers {
+		res.Answers = append(res.Answers, dto.QuizAnswer{QuestionID: ans.QuestionID, OptionIDs: ans.OptionIDs, Number: ans.Number, Text: ans.Text})
+	}
+	for _, q := range a.Result.Questions {
+		feedback := q.Feedback
+		if feedback == nil {
+			feedback = []string{}
+		}
+		res.Questions = append(res.Questions, dto.QuizQuestionResult{
+			QuestionID: q.QuestionID,
+			Answered:   q.Answered,
+			Correct:    q.Correct,
+			Points:     q.Points,
+			MaxPoints:  q.MaxPoints,
+			Feedback:   feedback,
+		})
+	}
+	return res
+}
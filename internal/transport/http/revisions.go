@@ -0,0 +1,135 @@
+package http
+
+import (
+	"chalk/internal/repo/models"
+	"chalk/internal/transport/http/dto"
+	"chalk/internal/usecases"
+	"fmt"
+	"net/http"
+	"strconv"
+)
+
+func (h *Handler) GetCourseRevisions(w http.ResponseWriter, r *http.Request) {
+	h.getRevisions(w, r, "course_id", models.TrashItemCourse)
+}
+
+func (h *Handler) GetModuleRevisions(w http.ResponseWriter, r *http.Request) {
+	h.getRevisions(w, r, "module_id", models.TrashItemModule)
+}
+
+func (h *Handler) GetLessonRevisions(w http.ResponseWriter, r *http.Request) {
+	h.getRevisions(w, r, "lesson_id", models.TrashItemLesson)
+}
+
+func (h *Handler) GetBlockRevisions(w http.ResponseWriter, r *http.Request) {
+	h.getRevisions(w, r, "block_id", models.TrashItemBlock)
+}
+
+func (h *Handler) getRevisions(w http.ResponseWriter, r *http.Request, param string, itemType models.TrashItemType) {
+	item, err := courseItemFromRequest(r, param)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	params := usecases.GetRevisionsParams{
+		ActorID:   item.ActorID,
+		AccountID: item.AccountID,
+		ItemType:  itemType,
+		ItemID:    item.ID,
+	}
+	if params.BeforeID, err = queryInt64(r, "before_id"); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if limit := queryString(r, "limit"); limit != nil {
+		if params.Limit, err = strconv.Atoi(*limit); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid query parameter limit")
+			return
+		}
+	}
+
+	page, err := h.coursesUC.GetRevisions(r.Context(), params)
+	if err != nil {
+		writeAppError(w, err)
+		return
+	}
+
+	res := dto.GetRevisionsResponse{
+		Revisions:    make([]dto.Revision, 0, len(page.Revisions)),
+		NextBeforeID: page.NextBeforeID,
+	}
+	for _, rev := range page.Revisions {
+		res.Revisions = append(res.Revisions, dto.Revision{
+			ID:        rev.ID,
+			Type:      string(rev.ItemType),
+			ItemID:    rev.ItemID,
+			Field:     string(rev.Field),
+			OldValue:  rev.OldValue,
+			Value:     rev.Value,
+			AuthorID:  rev.AuthorID,
+			CreatedAt: rev.CreatedAt,
+		})
+	}
+	writeJSON(w, http.StatusOK, res)
+}
+
+func (h *Handler) DiffBlockRevisions(w http.ResponseWriter, r *http.Request) {
+	item, err := courseItemFromRequest(r, "block_id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	params := usecases.DiffRevisionsParams{
+		ActorID:   item.ActorID,
+		AccountID: item.AccountID,
+		BlockID:   item.ID,
+	}
+	for _, q := range []struct {
+		name string
+		dst  *int64
+	}{{"from", &params.FromID}, {"to", &params.ToID}} {
+		id, err := queryInt64(r, q.name)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if id == nil {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("query parameter %s is required", q.name))
+			return
+		}
+		*q.dst = *id
+	}
+
+	lines, err := h.coursesUC.DiffRevisions(r.Context(), params)
+	if err != nil {
+		writeAppError(w, err)
+		return
+	}
+
+	res := dto.DiffRevisionsResponse{Lines: make([]dto.DiffLine, 0, len(lines))}
+	for _, l := range lines {
+		res.Lines = append(res.Lines, dto.DiffLine{Op: string(l.Op), Text: l.Text})
+	}
+	writeJSON(w, http.StatusOK, res)
+}
+
+func (h *Handler) RestoreRevision(w http.ResponseWriter, r *http.Request) {
+	item, err := courseItemFromRequest(r, "revision_id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	err = h.coursesUC.RestoreRevision(r.Context(), usecases.RestoreRevisionParams{
+		ActorID:    item.ActorID,
+		AccountID:  item.AccountID,
+		RevisionID: item.ID,
+	})
+	if err != nil {
+		writeAppError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, struct{}{})
+}
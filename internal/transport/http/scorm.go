@@ -0,0 +1,291 @@
+package http
+
+import (
+	"chalk/internal/repo/models"
+	"chalk/internal/transport/http/dto"
+	"chalk/internal/usecases"
+	"chalk/pkg/log"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+func (h *Handler) CreateScormBlock(w http.ResponseWriter, r *http.Request) {
+	item, err := courseItemFromRequest(r, "lesson_id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var req dto.CreateScormBlockRequest
+	if err := readJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	id, err := h.coursesUC.CreateScormBlock(r.Context(), usecases.CreateScormBlockParams{
+		ActorID:   item.ActorID,
+		AccountID: item.AccountID,
+		LessonID:  item.ID,
+		FileID:    req.FileID,
+		OrderIdx:  req.OrderIdx,
+	})
+	if err != nil {
+		writeAppError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, dto.CreateBlockResponse{ID: id})
+}
+
+// LaunchScorm начинает сессию пакета. Файлы пакета и API среды выполнения
+// доступны по идентификатору сессии в пути без токена доступа: iframe не может
+// передать заголовок Authorization.
+func (h *Handler) LaunchScorm(w http.ResponseWriter, r *http.Request) {
+	item, err := courseItemFromRequest(r, "block_id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	launch, err := h.coursesUC.LaunchScorm(r.Context(), item)
+	if err != nil {
+		writeAppError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, dto.LaunchScormResponse{
+		SessionID: launch.SessionID,
+		Version:   string(launch.Version),
+		PlayerURL: "/scorm/" + url.PathEscape(launch.SessionID) + "/player",
+	})
+}
+
+// GetScormPlayer отдаёт страницу, которая объявляет API SCORM и открывает пакет во вложенном фрейме
+func (h *Handler) GetScormPlayer(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(http.StatusOK)
+	io.WriteString(w, scormPlayerPage)
+}
+
+func (h *Handler) GetScormContent(w http.ResponseWriter, r *http.Request) {
+	file, err := h.coursesUC.OpenScormFile(r.Context(), r.PathValue("session_id"), r.PathValue("path"))
+	if err != nil {
+		writeAppError(w, err)
+		return
+	}
+	defer file.Reader.Close()
+
+	w.Header().Set("Content-Type", file.ContentType)
+	w.Header().Set("Content-Length", strconv.FormatInt(file.Size, 10))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(http.StatusOK)
+	if _, err := io.Copy(w, file.Reader); err != nil {
+		log.Warnf("write scorm file %s: %v", r.PathValue("path"), err)
+	}
+}
+
+func (h *Handler) GetScormRuntime(w http.ResponseWriter, r *http.Request) {
+	runtime, err := h.coursesUC.GetScormRuntime(r.Context(), r.PathValue("session_id"))
+	if err != nil {
+		writeAppError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, dto.ScormRuntimeResponse{
+		Version:    runtime.Version,
+		LaunchPath: runtime.LaunchPath,
+		Values:     runtime.Values,
+	})
+}
+
+func (h *Handler) CommitScormRuntime(w http.ResponseWriter, r *http.Request) {
+	var req dto.CommitScormRuntimeRequest
+	if err := readJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	progress, err := h.coursesUC.CommitScormRuntime(r.Context(), usecases.CommitScormRuntimeParams{
+		SessionID: r.PathValue("session_id"),
+		Values:    req.Values,
+	})
+	if err != nil {
+		writeAppError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, dto.BlockProgressResponse{Progress: toBlockProgressDTO(progress)})
+}
+
+func (h *Handler) GetCourseProgress(w http.ResponseWriter, r *http.Request) {
+	item, err := courseItemFromRequest(r, "course_id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	progress, err := h.coursesUC.GetCourseProgress(r.Context(), item)
+	if err != nil {
+		writeAppError(w, err)
+		return
+	}
+	res := dto.GetCourseProgressResponse{Blocks: make([]dto.BlockProgress, 0, len(progress))}
+	for _, p := range progress {
+		res.Blocks = append(res.Blocks, toBlockProgressDTO(p))
+	}
+	writeJSON(w, http.StatusOK, res)
+}
+
+func toBlockProgressDTO(p models.BlockProgress) dto.BlockProgress {
+	return dto.BlockProgress{BlockID: p.BlockID, CompletedAt: p.CompletedAt, Score: p.Score, UpdatedAt: p.UpdatedAt}
+}
+
+// scormPlayerPage объявляет API SCORM 1.2 (window.API) или SCORM 2004 (window.API_1484_11)
+// и загружает пакет во фрейм. Значения читаются из памяти страницы, изменённые
+// значения отправляются на сервер при Commit и завершении.
+const scormPlayerPage = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>SCORM</title>
+<style>html, body, iframe { margin: 0; width: 100%; height: 100%; border: 0; display: block; }</style>
+</head>
+<body>
+<iframe id="sco" title="SCORM"></iframe>
+<script>
+(function () {
+  "use strict";
+  var values = {}, dirty = {}, lastError = "0", state = "new", scorm2004 = false;
+  var messages = {
+    "0": "No error", "101": "General exception", "103": "Already initialized",
+    "104": "Content instance terminated", "112": "Termination before initialization",
+    "113": "Termination after termination", "122": "Retrieve data before initialization",
+    "123": "Retrieve data after termination", "132": "Store data before initialization",
+    "133": "Store data after termination", "142": "Commit before initialization",
+    "143": "Commit after termination", "201": "Invalid argument error",
+    "301": "Not initialized", "401": "Not implemented error", "403": "Element is read only",
+    "404": "Data model element is read only"
+  };
+  var readOnly = /(^cmi\.core\.(student_id|student_name|credit|entry|lesson_mode|total_time)$)|(^cmi\.(learner_id|learner_name|credit|entry|mode|total_time|launch_data)$)|(\._(count|children|version)$)/;
+
+  function fail(code12, code2004) {
+    lastError = scorm2004 ? code2004 : code12;
+    return "false";
+  }
+  function ok(result) {
+    lastError = "0";
+    return result;
+  }
+  function notRunning(beforeCode, afterCode) {
+    if (state === "new") { return fail("301", beforeCode); }
+    if (state === "done") { return fail("101", afterCode); }
+    return null;
+  }
+
+  function count(prefix) {
+    var seen = {}, n = 0;
+    Object.keys(values).forEach(function (k) {
+      if (k.indexOf(prefix) === 0) {
+        var idx = k.slice(prefix.length).split(".")[0];
+        if (/^\d+$/.test(idx) && !seen[idx]) { seen[idx] = true; n++; }
+      }
+    });
+    return String(n);
+  }
+
+  function send(keepalive) {
+    var batch = dirty;
+    dirty = {};
+    if (Object.keys(batch).length === 0) { return; }
+    fetch("runtime", {
+      method: "POST",
+      headers: { "Content-Type": "application/json" },
+      body: JSON.stringify({ values: batch }),
+      keepalive: keepalive
+    }).then(function (res) {
+      if (!res.ok) { throw new Error("commit failed: " + res.status); }
+    }).catch(function (err) {
+      // неотправленные значения уйдут со следующим Commit, если пакет не изменил их снова
+      Object.keys(batch).forEach(function (k) { if (!(k in dirty)) { dirty[k] = batch[k]; } });
+      console.error(err);
+    });
+  }
+
+  var runtime = {
+    initialize: function () {
+      if (state === "running") { return fail("101", "103"); }
+      if (state === "done") { return fail("101", "104"); }
+      state = "running";
+      return ok("true");
+    },
+    terminate: function () {
+      var err = notRunning("112", "113");
+      if (err !== null) { return err; }
+      state = "done";
+      send(true);
+      return ok("true");
+    },
+    getValue: function (key) {
+      var err = notRunning("122", "123");
+      if (err !== null) { return ""; }
+      key = String(key);
+      if (key === "") { fail("201", "301"); return ""; }
+      if (/\._version$/.test(key)) { return ok(scorm2004 ? "1.0" : "3.4"); }
+      if (/\._count$/.test(key)) { return ok(count(key.slice(0, -"_count".length))); }
+      if (/\._children$/.test(key)) { return ok(""); }
+      return ok(values.hasOwnProperty(key) ? values[key] : "");
+    },
+    setValue: function (key, value) {
+      var err = notRunning("132", "133");
+      if (err !== null) { return err; }
+      key = String(key);
+      if (!/^(cmi|adl)\./.test(key)) { return fail("201", "401"); }
+      if (readOnly.test(key)) { return fail("403", "404"); }
+      values[key] = String(value);
+      dirty[key] = values[key];
+      return ok("true");
+    },
+    commit: function () {
+      var err = notRunning("142", "143");
+      if (err !== null) { return err; }
+      send(false);
+      return ok("true");
+    },
+    getLastError: function () { return lastError; },
+    getErrorString: function (code) { return messages[String(code)] || ""; },
+    getDiagnostic: function (code) { return messages[String(code === "" ? lastError : code)] || ""; }
+  };
+
+  window.API = {
+    LMSInitialize: runtime.initialize, LMSFinish: runtime.terminate,
+    LMSGetValue: runtime.getValue, LMSSetValue: runtime.setValue, LMSCommit: runtime.commit,
+    LMSGetLastError: runtime.getLastError, LMSGetErrorString: runtime.getErrorString,
+    LMSGetDiagnostic: runtime.getDiagnostic
+  };
+  window.API_1484_11 = {
+    Initialize: runtime.initialize, Terminate: runtime.terminate,
+    GetValue: runtime.getValue, SetValue: runtime.setValue, Commit: runtime.commit,
+    GetLastError: runtime.getLastError, GetErrorString: runtime.getErrorString,
+    GetDiagnostic: runtime.getDiagnostic
+  };
+
+  window.addEventListener("pagehide", function () { send(true); });
+
+  fetch("runtime").then(function (res) {
+    if (!res.ok) { throw new Error("session expired, launch the package again"); }
+    return res.json();
+  }).then(function (data) {
+    scorm2004 = data.version === "2004";
+    values = data.values || {};
+    var parts = data.launch_path.split("?");
+    var src = "content/" + parts[0].split("/").map(encodeURIComponent).join("/");
+    if (parts.length > 1) { src += "?" + parts.slice(1).join("?"); }
+    document.getElementById("sco").src = src;
+  }).catch(function (err) {
+    document.body.textContent = err.message;
+  });
+})();
+</script>
+</body>
+</html>
+`
@@ -0,0 +1,64 @@
+package http
+
+import (
+	"chalk/internal/repo/models"
+	"chalk/internal/transport/http/dto"
+	"chalk/internal/usecases"
+	"net/http"
+)
+
+func (h *Handler) GetTrash(w http.ResponseWriter, r *http.Request) {
+	accountID, err := pathInt64(r, "id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	items, err := h.trashUC.GetTrash(r.Context(), usecases.GetTrashParams{
+		ActorID:   sessionFromRequest(r).UserID,
+		AccountID: accountID,
+	})
+	if err != nil {
+		writeAppError(w, err)
+		return
+	}
+
+	res := dto.GetTrashResponse{Items: make([]dto.TrashItem, 0, len(items))}
+	for _, it := range items {
+		res.Items = append(res.Items, dto.TrashItem{
+			Type:      string(it.Type),
+			ID:        it.ID,
+			ParentID:  it.ParentID,
+			Name:      it.Name,
+			DeletedAt: it.DeletedAt,
+			DeletedBy: it.DeletedBy,
+		})
+	}
+	writeJSON(w, http.StatusOK, res)
+}
+
+func (h *Handler) RestoreTrashItem(w http.ResponseWriter, r *http.Request) {
+	accountID, err := pathInt64(r, "id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var req dto.RestoreTrashItemRequest
+	if err := readJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	err = h.trashUC.Restore(r.Context(), usecases.RestoreTrashItemParams{
+		ActorID:   sessionFromRequest(r).UserID,
+		AccountID: accountID,
+		Type:      models.TrashItemType(req.Type),
+		ID:        req.ID,
+	})
+	if err != nil {
+		writeAppError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, struct{}{})
+}
@@ -1,12 +1,18 @@
 package http
 
 import (
+	"chalk/internal/entities"
 	"chalk/internal/errors"
+	"chalk/internal/repo/models"
+	"chalk/internal/transport/http/middleware"
+	"chalk/internal/usecases"
 	"chalk/pkg/log"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
+	"strconv"
+	"time"
 )
 
 func readJSON(r *http.Request, dst any) error {
@@ -34,11 +40,108 @@ func writeError(w http.ResponseWriter, status int, msg string) {
 	writeJSON(w, status, map[string]string{"error": msg})
 }
 
+// userErrorStatuses - пользовательские ошибки со статусом, отличным от 400
+var userErrorStatuses = map[error]int{
+	errors.ErrCourseNotFound:           http.StatusNotFound,
+	errors.ErrModuleNotFound:           http.StatusNotFound,
+	errors.ErrLessonNotFound:           http.StatusNotFound,
+	errors.ErrBlockNotFound:            http.StatusNotFound,
+	errors.ErrCourseNotPublished:       http.StatusNotFound,
+	errors.ErrCourseVersionNotFound:    http.StatusNotFound,
+	errors.ErrRevisionNotFound:         http.StatusNotFound,
+	errors.ErrScormSessionNotFound:     http.StatusNotFound,
+	errors.ErrScormDataTooLarge:        http.StatusRequestEntityTooLarge,
+	errors.ErrNoChangesToPublish:       http.StatusConflict,
+	errors.ErrOrderConflict:            http.StatusConflict,
+	errors.ErrAccountDeleting:          http.StatusConflict,
+	errors.ErrOrderMismatch:            http.StatusConflict,
+	errors.ErrCourseLimitReached:       http.StatusConflict,
+	errors.ErrQuizAttemptLimitReached:  http.StatusConflict,
+	errors.ErrQuizAttemptConflict:      http.StatusConflict,
+	errors.ErrSubmissionNotFound:       http.StatusNotFound,
+	errors.ErrSubmissionAlreadyGraded:  http.StatusConflict,
+	errors.ErrSubmissionStatusConflict: http.StatusConflict,
+	errors.ErrCodeRunnerUnavailable:    http.StatusServiceUnavailable,
+}
+
 func writeAppError(w http.ResponseWriter, err error) {
 	if errors.IsUserError(err) {
-		writeError(w, http.StatusBadRequest, err.Error())
+		status, ok := userErrorStatuses[err]
+		if !ok {
+			status = http.StatusBadRequest
+		}
+		writeError(w, status, err.Error())
 		return
 	}
 	log.Errorf("internal error: %v", err)
 	writeError(w, http.StatusInternalServerError, "internal error")
 }
+
+func sessionFromRequest(r *http.Request) entities.Session {
+	// AuthMiddleware гарантирует наличие сессии в контексте
+	s, _ := r.Context().Value(middleware.SessionKey).(entities.Session)
+	return s
+}
+
+func tenantFromRequest(r *http.Request) (models.Account, bool) {
+	acc, ok := r.Context().Value(middleware.TenantKey).(models.Account)
+	return acc, ok
+}
+
+func activeAccountFromRequest(r *http.Request) usecases.ActiveAccount {
+	// AccountMiddleware гарантирует наличие аккаунта в контексте
+	aa, _ := usecases.ActiveAccountFromContext(r.Context())
+	return aa
+}
+
+func pathInt64(r *http.Request, name string) (int64, error) {
+	v, err := strconv.ParseInt(r.PathValue(name), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid path parameter %s", name)
+	}
+	return v, nil
+}
+
+func queryInt64(r *http.Request, name string) (*int64, error) {
+	raw := r.URL.Query().Get(name)
+	if raw == "" {
+		return nil, nil
+	}
+	v, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid query parameter %s", name)
+	}
+	return &v, nil
+}
+
+func queryString(r *http.Request, name string) *string {
+	raw := r.URL.Query().Get(name)
+	if raw == "" {
+		return nil
+	}
+	return &raw
+}
+
+func queryTime(r *http.Request, name string) (*time.Time, error) {
+	raw := r.URL.Query().Get(name)
+	if raw == "" {
+		return nil, nil
+	}
+	v, err := time.Parse(time.RFC3339, raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid query parameter %s: expected RFC3339", name)
+	}
+	return &v, nil
+}
+
+func queryBool(r *http.Request, name string) (bool, error) {
+	raw := r.URL.Query().Get(name)
+	if raw == "" {
+		return false, nil
+	}
+	v, err := strconv.ParseBool(raw)
+	if err != nil {
+		return false, fmt.Errorf("invalid query parameter %s", name)
+	}
+	return v, nil
+}
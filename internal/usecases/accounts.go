@@ -0,0 +1,788 @@
+package usecases
+
+import (
+	uerrors "chalk/internal/errors"
+	"chalk/internal/repo"
+	"chalk/internal/repo/models"
+	"chalk/pkg/log"
+	"chalk/pkg/mailer"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/mail"
+	"regexp"
+	"strings"
+)
+
+type AccountsUseCase interface {
+	CreateAccount(ctx context.Context, params CreateAccountParams) (models.Account, error)
+	GetUserAccounts(ctx context.Context, userID int64) ([]models.Account, error)
+	ResolveTenant(ctx context.Context, slug string) (models.Account, error)
+	ResolveActiveAccount(ctx context.Context, userID, accountID int64) (ActiveAccount, error)
+	AddMember(ctx context.Context, params AddMemberParams) error
+	RemoveMember(ctx context.Context, params RemoveMemberParams) error
+	ChangeMemberRole(ctx context.Context, params ChangeMemberRoleParams) error
+	GetSettings(ctx context.Context, accountID int64) (models.AccountSettings, error)
+	UpdateSettings(ctx context.Context, params UpdateSettingsParams) (models.AccountSettings, error)
+	GetBrandingAsset(ctx context.Context, accountID int64, asset BrandingAsset) (models.File, io.ReadCloser, error)
+	ImportMembers(ctx context.Context, params ImportMembersParams) (MemberImportReport, error)
+	GetMembers(ctx context.Context, params GetMembersParams) (MembersPage, error)
+}
+
+func NewAccountsUseCase(
+	ar repo.AccountsRepo,
+	ur repo.UsersRepo,
+	ir repo.InvitationsRepo,
+	aur repo.AuditRepo,
+	fr repo.FilesRepo,
+	br repo.BillingRepo,
+	mailer mailer.Mailer,
+	emailFromAddr string,
+	emailFromName string,
+) AccountsUseCase {
+	return &accountsUseCase{
+		ar:     ar,
+		ur:     ur,
+		ir:     ir,
+		aur:    aur,
+		fr:     fr,
+		br:     br,
+		mailer: newAccountMailer(mailer, ar, emailFromAddr, emailFromName),
+	}
+}
+
+type accountsUseCase struct {
+	ar  repo.AccountsRepo
+	ur  repo.UsersRepo
+	ir  repo.InvitationsRepo
+	aur repo.AuditRepo
+	fr  repo.FilesRepo
+	br  repo.BillingRepo
+
+	mailer *accountMailer
+}
+
+type CreateAccountParams struct {
+	OwnerUserID int64
+	Name        string
+	// Slug - поддомен аккаунта, если не указан, формируется из имени
+	Slug string
+}
+
+func (uc *accountsUseCase) CreateAccount(ctx context.Context, params CreateAccountParams) (models.Account, error) {
+	slug := params.Slug
+	if slug == "" {
+		slug = slugify(params.Name)
+	}
+	if !isValidSlug(slug) {
+		return models.Account{}, uerrors.ErrInvalidAccountSlug
+	}
+
+	acc, err := uc.ar.CreateAccount(ctx, repo.CreateAccountParams{
+		OwnerUserID: params.OwnerUserID,
+		AccountName: params.Name,
+		AccountSlug: slug,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, repo.ErrAccountNameAlreadyTaken):
+			return models.Account{}, uerrors.ErrAccountNameTaken
+		case errors.Is(err, repo.ErrAccountSlugAlreadyTaken):
+			return models.Account{}, uerrors.ErrAccountSlugTaken
+		case errors.Is(err, repo.ErrAccountOwnerNotFound):
+			return models.Account{}, uerrors.ErrUserNotFound
+		}
+		return models.Account{}, fmt.Errorf("failed to create account: %w", err)
+	}
+
+	recordAudit(ctx, uc.aur, auditRecord{
+		AccountID:   acc.ID,
+		ActorUserID: params.OwnerUserID,
+		Action:      models.AuditActionAccountCreate,
+		TargetType:  models.AuditTargetAccount,
+		TargetID:    &acc.ID,
+		After:       map[string]any{"name": acc.Name, "slug": acc.Slug},
+	})
+	return acc, nil
+}
+
+func (uc *accountsUseCase) GetUserAccounts(ctx context.Context, userID int64) ([]models.Account, error) {
+	accounts, err := uc.ar.GetUserAccounts(ctx, repo.GetUserAccountsParams{UserID: userID})
+	if err != nil {
+		if errors.Is(err, repo.ErrUserNotFound) {
+			return nil, uerrors.ErrUserNotFound
+		}
+		return nil, fmt.Errorf("failed to get user accounts: %w", err)
+	}
+	return accounts, nil
+}
+
+func (uc *accountsUseCase) ResolveTenant(ctx context.Context, slug string) (models.Account, error) {
+	if !isValidSlug(slug) {
+		return models.Account{}, uerrors.ErrTenantNotFound
+	}
+	acc, err := uc.ar.GetAccountBySlug(ctx, slug)
+	if err != nil {
+		if errors.Is(err, repo.ErrAccountNotFound) {
+			return models.Account{}, uerrors.ErrTenantNotFound
+		}
+		return models.Account{}, fmt.Errorf("failed to get account by slug: %w", err)
+	}
+	return acc, nil
+}
+
+// ResolveActiveAccount проверяет, что пользователь состоит в аккаунте, в котором выполняется запрос
+func (uc *accountsUseCase) ResolveActiveAccount(ctx context.Context, userID, accountID int64) (ActiveAccount, error) {
+	acc, err := uc.ar.GetAccountByID(ctx, accountID)
+	if err != nil {
+		if errors.Is(err, repo.ErrAccountNotFound) {
+			return ActiveAccount{}, uerrors.ErrAccountNotFound
+		}
+		return ActiveAccount{}, fmt.Errorf("failed to get account: %w", err)
+	}
+	member, err := uc.ar.GetAccountMember(ctx, repo.GetAccountMemberParams{AccountID: accountID, UserID: userID})
+	if err != nil {
+		if errors.Is(err, repo.ErrAccountMemberNotFound) {
+			return ActiveAccount{}, uerrors.ErrPermissionDenied
+		}
+		return ActiveAccount{}, fmt.Errorf("failed to get account member: %w", err)
+	}
+	return ActiveAccount{Account: acc, Member: member}, nil
+}
+
+type AddMemberParams struct {
+	ActorID   int64
+	AccountID int64
+	UserID    int64
+	Role      models.AccountMembersRole
+}
+
+func (uc *accountsUseCase) AddMember(ctx context.Context, params AddMemberParams) error {
+	if !isAssignableRole(params.Role) {
+		return uerrors.ErrInvalidRole
+	}
+
+	actorRole, err := getAccountRole(ctx, uc.ar, params.ActorID, params.AccountID)
+	if err != nil {
+		return err
+	}
+
+	if actorRole != models.AccountMembersRoleOwner && actorRole != models.AccountMembersRoleAdmin {
+		return uerrors.ErrPermissionDenied
+	}
+
+	if err := requireWritableAccount(ctx, uc.ar, params.AccountID); err != nil {
+		return err
+	}
+
+	// Только владелец может назначать админов
+	if params.Role == models.AccountMembersRoleAdmin && actorRole != models.AccountMembersRoleOwner {
+		return uerrors.ErrPermissionDenied
+	}
+
+	err = uc.ar.AddAccountMember(ctx, repo.AddAccountMemberParams{
+		UserID:    params.UserID,
+		AccountID: params.AccountID,
+		Role:      params.Role,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, repo.ErrUniqueViolation):
+			return uerrors.ErrUserAlreadyInAccount
+		case errors.Is(err, repo.ErrSeatLimitReached):
+			return uerrors.ErrSeatLimitReached
+		case errors.Is(err, repo.ErrUserNotFound):
+			return uerrors.ErrUserNotFound
+		case errors.Is(err, repo.ErrAccountNotFound):
+			return uerrors.ErrAccountNotFound
+		}
+		return fmt.Errorf("failed to add account member: %w", err)
+	}
+
+	recordAudit(ctx, uc.aur, auditRecord{
+		AccountID:   params.AccountID,
+		ActorUserID: params.ActorID,
+		Action:      models.AuditActionMemberAdd,
+		TargetType:  models.AuditTargetMember,
+		TargetID:    &params.UserID,
+		After:       map[string]any{"role": params.Role},
+	})
+
+	uc.notifyMemberAdded(ctx, params.AccountID, params.UserID)
+	return nil
+}
+
+const memberAddedTemplate = "Здравствуйте, %s!\n\nВас добавили в %s."
+
+// notifyMemberAdded сообщает пользователю о добавлении в аккаунт. Участник уже
+// добавлен, поэтому ошибка отправки только логируется.
+func (uc *accountsUseCase) notifyMemberAdded(ctx context.Context, accountID, userID int64) {
+	member, err := uc.ar.GetAccountMember(ctx, repo.GetAccountMemberParams{AccountID: accountID, UserID: userID})
+	if err != nil {
+		log.Warnf("notify member added: get member: %v", err)
+		return
+	}
+	name, err := uc.accountDisplayName(ctx, accountID)
+	if err != nil {
+		log.Warnf("notify member added: %v", err)
+		return
+	}
+
+	err = uc.mailer.Send(ctx, accountMail{
+		AccountID: accountID,
+		To:        member.Email,
+		Subject:   fmt.Sprintf("Вы добавлены в %s", name),
+		Body:      fmt.Sprintf(memberAddedTemplate, member.Name, name),
+	})
+	if err != nil {
+		log.Warnf("notify member added: %v", err)
+	}
+}
+
+// accountDisplayName возвращает отображаемое имя аккаунта из настроек или его системное имя
+func (uc *accountsUseCase) accountDisplayName(ctx context.Context, accountID int64) (string, error) {
+	settings, err := uc.ar.GetAccountSettings(ctx, accountID)
+	if err != nil {
+		return "", fmt.Errorf("failed to get account settings: %w", err)
+	}
+	if settings.DisplayName != "" {
+		return settings.DisplayName, nil
+	}
+	acc, err := uc.ar.GetAccountByID(ctx, accountID)
+	if err != nil {
+		return "", fmt.Errorf("failed to get account: %w", err)
+	}
+	return acc.Name, nil
+}
+
+const (
+	membersDefaultPageSize = 50
+	membersMaxPageSize     = 200
+)
+
+type GetMembersParams struct {
+	ActorID   int64
+	AccountID int64
+
+	Search *string
+	Role   *models.AccountMembersRole
+	Group  *string
+	// Sort - name или email, по умолчанию name
+	Sort string
+	Desc bool
+	// Cursor - NextCursor предыдущей страницы
+	Cursor *string
+	Limit  int
+}
+
+type MembersPage struct {
+	Members []models.AccountMember
+	// Total - число участников, подходящих под фильтры, без учёта пагинации
+	Total int
+	// NextCursor - курсор следующей страницы, nil если участников больше нет
+	NextCursor *string
+}
+
+func (uc *accountsUseCase) GetMembers(ctx context.Context, params GetMembersParams) (MembersPage, error) {
+	if _, err := getAccountRole(ctx, uc.ar, params.ActorID, params.AccountID); err != nil {
+		return MembersPage{}, err
+	}
+
+	sort := repo.MembersSortField(params.Sort)
+	if sort == "" {
+		sort = repo.MembersSortByName
+	}
+	if sort != repo.MembersSortByName && sort != repo.MembersSortByEmail {
+		return MembersPage{}, uerrors.ErrInvalidSort
+	}
+
+	var after *repo.MembersCursor
+	if params.Cursor != nil {
+		c, err := decodeMembersCursor(*params.Cursor)
+		if err != nil {
+			return MembersPage{}, uerrors.ErrInvalidCursor
+		}
+		after = &c
+	}
+
+	limit := params.Limit
+	switch {
+	case limit <= 0:
+		limit = membersDefaultPageSize
+	case limit > membersMaxPageSize:
+		limit = membersMaxPageSize
+	}
+
+	filter := repo.AccountMembersFilter{
+		AccountID: params.AccountID,
+		Search:    params.Search,
+		Role:      params.Role,
+		Group:     params.Group,
+	}
+	members, err := uc.ar.GetAccountMembers(ctx, repo.GetAccountMembersParams{
+		AccountMembersFilter: filter,
+		Sort:                 sort,
+		Desc:                 params.Desc,
+		After:                after,
+		Limit:                limit + 1,
+	})
+	if err != nil {
+		return MembersPage{}, fmt.Errorf("failed to get account members: %w", err)
+	}
+	total, err := uc.ar.CountAccountMembers(ctx, filter)
+	if err != nil {
+		return MembersPage{}, fmt.Errorf("failed to count account members: %w", err)
+	}
+
+	page := MembersPage{Members: members, Total: total}
+	if len(members) > limit {
+		page.Members = members[:limit]
+		last := page.Members[limit-1]
+		next := encodeMembersCursor(repo.MembersCursor{Key: membersSortKey(last, sort), UserID: last.UserID})
+		page.NextCursor = &next
+	}
+	return page, nil
+}
+
+// membersSortKey - значение, по которому репозиторий сортирует участников
+func membersSortKey(m models.AccountMember, sort repo.MembersSortField) string {
+	if sort == repo.MembersSortByEmail {
+		return strings.ToLower(m.Email)
+	}
+	return strings.ToLower(m.Name)
+}
+
+type membersCursorJSON struct {
+	Key    string `json:"k"`
+	UserID int64  `json:"id"`
+}
+
+func encodeMembersCursor(c repo.MembersCursor) string {
+	data, _ := json.Marshal(membersCursorJSON{Key: c.Key, UserID: c.UserID})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeMembersCursor(s string) (repo.MembersCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return repo.MembersCursor{}, err
+	}
+	var c membersCursorJSON
+	if err := json.Unmarshal(data, &c); err != nil {
+		return repo.MembersCursor{}, err
+	}
+	return repo.MembersCursor{Key: c.Key, UserID: c.UserID}, nil
+}
+
+type RemoveMemberParams struct {
+	ActorID   int64
+	AccountID int64
+	UserID    int64
+}
+
+func (uc *accountsUseCase) RemoveMember(ctx context.Context, params RemoveMemberParams) error {
+	if params.ActorID == params.UserID {
+		return uerrors.ErrCannotRemoveYourself
+	}
+
+	actorRole, err := getAccountRole(ctx, uc.ar, params.ActorID, params.AccountID)
+	if err != nil {
+		return err
+	}
+
+	if actorRole != models.AccountMembersRoleOwner && actorRole != models.AccountMembersRoleAdmin {
+		return uerrors.ErrPermissionDenied
+	}
+
+	if err := requireWritableAccount(ctx, uc.ar, params.AccountID); err != nil {
+		return err
+	}
+
+	member, err := uc.getMember(ctx, params.AccountID, params.UserID)
+	if err != nil {
+		return err
+	}
+
+	// Защита от удаления владельца или админа другим админом
+	if member.Role == models.AccountMembersRoleOwner {
+		return uerrors.ErrCannotModifyOwner
+	}
+	if member.Role == models.AccountMembersRoleAdmin && actorRole != models.AccountMembersRoleOwner {
+		return uerrors.ErrPermissionDenied
+	}
+
+	err = uc.ar.RemoveAccountMember(ctx, repo.RemoveAccountMemberParams{
+		AccountID: params.AccountID,
+		UserID:    params.UserID,
+	})
+	if err != nil {
+		if errors.Is(err, repo.ErrAccountMemberNotFound) {
+			return uerrors.ErrAccountMemberNotFound
+		}
+		return fmt.Errorf("failed to remove account member: %w", err)
+	}
+
+	recordAudit(ctx, uc.aur, auditRecord{
+		AccountID:   params.AccountID,
+		ActorUserID: params.ActorID,
+		Action:      models.AuditActionMemberRemove,
+		TargetType:  models.AuditTargetMember,
+		TargetID:    &params.UserID,
+		Before:      map[string]any{"role": member.Role, "email": member.Email},
+	})
+	return nil
+}
+
+type ChangeMemberRoleParams struct {
+	ActorID   int64
+	AccountID int64
+	UserID    int64
+	Role      models.AccountMembersRole
+}
+
+func (uc *accountsUseCase) ChangeMemberRole(ctx context.Context, params ChangeMemberRoleParams) error {
+	if !isAssignableRole(params.Role) {
+		return uerrors.ErrInvalidRole
+	}
+
+	if params.ActorID == params.UserID {
+		return uerrors.ErrCannotChangeOwnRole
+	}
+
+	actorRole, err := getAccountRole(ctx, uc.ar, params.ActorID, params.AccountID)
+	if err != nil {
+		return err
+	}
+
+	// Только владелец может менять роли админа и назначать админов
+	if actorRole != models.AccountMembersRoleOwner {
+		return uerrors.ErrPermissionDenied
+	}
+
+	if err := requireWritableAccount(ctx, uc.ar, params.AccountID); err != nil {
+		return err
+	}
+
+	member, err := uc.getMember(ctx, params.AccountID, params.UserID)
+	if err != nil {
+		return err
+	}
+
+	// Защита от смены роли владельца
+	if member.Role == models.AccountMembersRoleOwner {
+		return uerrors.ErrCannotModifyOwner
+	}
+
+	if member.Role == params.Role {
+		return nil
+	}
+
+	err = uc.ar.UpdateAccountMemberRole(ctx, repo.UpdateAccountMemberRoleParams{
+		AccountID: params.AccountID,
+		UserID:    params.UserID,
+		Role:      params.Role,
+	})
+	if err != nil {
+		if errors.Is(err, repo.ErrAccountMemberNotFound) {
+			return uerrors.ErrAccountMemberNotFound
+		}
+		return fmt.Errorf("failed to update member role: %w", err)
+	}
+
+	recordAudit(ctx, uc.aur, auditRecord{
+		AccountID:   params.AccountID,
+		ActorUserID: params.ActorID,
+		Action:      models.AuditActionMemberRoleChange,
+		TargetType:  models.AuditTargetMember,
+		TargetID:    &params.UserID,
+		Before:      map[string]any{"role": member.Role},
+		After:       map[string]any{"role": params.Role},
+	})
+	return nil
+}
+
+func (uc *accountsUseCase) GetSettings(ctx context.Context, accountID int64) (models.AccountSettings, error) {
+	settings, err := uc.ar.GetAccountSettings(ctx, accountID)
+	if err != nil {
+		if errors.Is(err, repo.ErrAccountNotFound) {
+			return models.AccountSettings{}, uerrors.ErrAccountNotFound
+		}
+		return models.AccountSettings{}, fmt.Errorf("failed to get account settings: %w", err)
+	}
+	return settings, nil
+}
+
+type UpdateSettingsParams struct {
+	ActorID         int64
+	AccountID       int64
+	DisplayName     string
+	LogoFileID      *int64
+	FaviconFileID   *int64
+	PrimaryColor    string
+	AccentColor     string
+	SupportEmail    string
+	EmailSenderName string
+}
+
+var colorRegexp = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+
+func (uc *accountsUseCase) UpdateSettings(ctx context.Context, params UpdateSettingsParams) (models.AccountSettings, error) {
+	if err := requireAccountAdmin(ctx, uc.ar, params.ActorID, params.AccountID); err != nil {
+		return models.AccountSettings{}, err
+	}
+
+	if err := requireWritableAccount(ctx, uc.ar, params.AccountID); err != nil {
+		return models.AccountSettings{}, err
+	}
+
+	for _, c := range []string{params.PrimaryColor, params.AccentColor} {
+		if c != "" && !colorRegexp.MatchString(c) {
+			return models.AccountSettings{}, uerrors.ErrInvalidColor
+		}
+	}
+	if params.SupportEmail != "" {
+		addr, err := mail.ParseAddress(params.SupportEmail)
+		if err != nil || addr.Address != params.SupportEmail {
+			return models.AccountSettings{}, uerrors.ErrInvalidSupportEmail
+		}
+	}
+	if err := uc.checkBrandingFile(ctx, params.AccountID, params.LogoFileID, isImageContentType); err != nil {
+		return models.AccountSettings{}, err
+	}
+	if err := uc.checkBrandingFile(ctx, params.AccountID, params.FaviconFileID, isFaviconContentType); err != nil {
+		return models.AccountSettings{}, err
+	}
+
+	before, err := uc.GetSettings(ctx, params.AccountID)
+	if err != nil {
+		return models.AccountSettings{}, err
+	}
+
+	settings := models.AccountSettings{
+		AccountID:       params.AccountID,
+		DisplayName:     strings.TrimSpace(params.DisplayName),
+		LogoFileID:      params.LogoFileID,
+		FaviconFileID:   params.FaviconFileID,
+		PrimaryColor:    strings.ToLower(params.PrimaryColor),
+		AccentColor:     strings.ToLower(params.AccentColor),
+		SupportEmail:    params.SupportEmail,
+		EmailSenderName: strings.TrimSpace(params.EmailSenderName),
+	}
+	if err := uc.ar.UpdateAccountSettings(ctx, settings); err != nil {
+		switch {
+		case errors.Is(err, repo.ErrAccountNotFound):
+			return models.AccountSettings{}, uerrors.ErrAccountNotFound
+		case errors.Is(err, repo.ErrFileNotFound):
+			return models.AccountSettings{}, uerrors.ErrFileNotFound
+		}
+		return models.AccountSettings{}, fmt.Errorf("failed to update account settings: %w", err)
+	}
+
+	recordAudit(ctx, uc.aur, auditRecord{
+		AccountID:   params.AccountID,
+		ActorUserID: params.ActorID,
+		Action:      models.AuditActionSettingsUpdate,
+		TargetType:  models.AuditTargetAccount,
+		TargetID:    &params.AccountID,
+		Before:      before,
+		After:       settings,
+	})
+	return settings, nil
+}
+
+type BrandingAsset string
+
+const (
+	BrandingAssetLogo    BrandingAsset = "logo"
+	BrandingAssetFavicon BrandingAsset = "favicon"
+)
+
+func (uc *accountsUseCase) GetBrandingAsset(ctx context.Context, accountID int64, asset BrandingAsset) (models.File, io.ReadCloser, error) {
+	settings, err := uc.GetSettings(ctx, accountID)
+	if err != nil {
+		return models.File{}, nil, err
+	}
+
+	var fileID *int64
+	switch asset {
+	case BrandingAssetLogo:
+		fileID = settings.LogoFileID
+	case BrandingAssetFavicon:
+		fileID = settings.FaviconFileID
+	}
+	if fileID == nil {
+		return models.File{}, nil, uerrors.ErrFileNotFound
+	}
+
+	file, err := uc.fr.GetFileInfo(ctx, *fileID)
+	if err != nil {
+		if errors.Is(err, repo.ErrFileNotFound) {
+			return models.File{}, nil, uerrors.ErrFileNotFound
+		}
+		return models.File{}, nil, fmt.Errorf("failed to get file info: %w", err)
+	}
+	return openFile(ctx, uc.fr, file)
+}
+
+func (uc *accountsUseCase) checkBrandingFile(ctx context.Context, accountID int64, fileID *int64, allowed func(string) bool) error {
+	if fileID == nil {
+		return nil
+	}
+	file, err := uc.fr.GetFileInfo(ctx, *fileID)
+	if err != nil {
+		if errors.Is(err, repo.ErrFileNotFound) {
+			return uerrors.ErrFileNotFound
+		}
+		return fmt.Errorf("failed to get file info: %w", err)
+	}
+	// файл другого аккаунта для вызывающего не существует
+	if file.AccountID == nil || *file.AccountID != accountID {
+		return uerrors.ErrFileNotFound
+	}
+	if !allowed(file.ContentType) {
+		return uerrors.ErrInvalidFileType
+	}
+	return nil
+}
+
+func isImageContentType(contentType string) bool {
+	return strings.HasPrefix(contentType, "image/")
+}
+
+func isFaviconContentType(contentType string) bool {
+	switch contentType {
+	case "image/x-icon", "image/vnd.microsoft.icon", "image/png", "image/svg+xml":
+		return true
+	}
+	return false
+}
+
+func (uc *accountsUseCase) getMember(ctx context.Context, accountID, userID int64) (models.AccountMember, error) {
+	member, err := uc.ar.GetAccountMember(ctx, repo.GetAccountMemberParams{
+		AccountID: accountID,
+		UserID:    userID,
+	})
+	if err != nil {
+		if errors.Is(err, repo.ErrAccountMemberNotFound) {
+			return models.AccountMember{}, uerrors.ErrAccountMemberNotFound
+		}
+		return models.AccountMember{}, fmt.Errorf("failed to get account member: %w", err)
+	}
+	return member, nil
+}
+
+// isAssignableRole проверяет, что роль можно выдать участнику. Роль владельца
+// выдаётся только при создании аккаунта.
+func isAssignableRole(role models.AccountMembersRole) bool {
+	return role == models.AccountMembersRoleAdmin || role == models.AccountMembersRoleMember
+}
+
+// getAccountRole возвращает роль пользователя в аккаунте. Если пользователь
+// не состоит в аккаунте, возвращается ErrPermissionDenied.
+func getAccountRole(ctx context.Context, ar repo.AccountsRepo, userID, accountID int64) (models.AccountMembersRole, error) {
+	// участие уже проверено AccountMiddleware для этого запроса
+	if aa, ok := ActiveAccountFromContext(ctx); ok && aa.Account.ID == accountID && aa.Member.UserID == userID {
+		return aa.Member.Role, nil
+	}
+
+	member, err := ar.GetAccountMember(ctx, repo.GetAccountMemberParams{
+		AccountID: accountID,
+		UserID:    userID,
+	})
+	if err != nil {
+		if errors.Is(err, repo.ErrAccountMemberNotFound) {
+			return "", uerrors.ErrPermissionDenied
+		}
+		return "", fmt.Errorf("failed to get actor role: %w", err)
+	}
+	return member.Role, nil
+}
+
+func requireAccountAdmin(ctx context.Context, ar repo.AccountsRepo, userID, accountID int64) error {
+	role, err := getAccountRole(ctx, ar, userID, accountID)
+	if err != nil {
+		return err
+	}
+	if role != models.AccountMembersRoleOwner && role != models.AccountMembersRoleAdmin {
+		return uerrors.ErrPermissionDenied
+	}
+	return nil
+}
+
+// requireWritableAccount запрещает изменения в аккаунте, который ожидает удаления
+func requireWritableAccount(ctx context.Context, ar repo.AccountsRepo, accountID int64) error {
+	var acc models.Account
+	if aa, ok := ActiveAccountFromContext(ctx); ok && aa.Account.ID == accountID {
+		acc = aa.Account
+	} else {
+		var err error
+		acc, err = ar.GetAccountByID(ctx, accountID)
+		if err != nil {
+			if errors.Is(err, repo.ErrAccountNotFound) {
+				return uerrors.ErrAccountNotFound
+			}
+			return fmt.Errorf("failed to get account: %w", err)
+		}
+	}
+	if acc.IsClosing() {
+		return uerrors.ErrAccountClosing
+	}
+	return nil
+}
+
+var slugRegexp = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{1,61}[a-z0-9]$`)
+
+// reservedSlugs заняты служебными поддоменами
+var reservedSlugs = map[string]struct{}{
+	"www":    {},
+	"api":    {},
+	"app":    {},
+	"admin":  {},
+	"mail":   {},
+	"static": {},
+	"files":  {},
+}
+
+func isValidSlug(slug string) bool {
+	if _, reserved := reservedSlugs[slug]; reserved {
+		return false
+	}
+	return slugRegexp.MatchString(slug)
+}
+
+var translit = map[rune]string{
+	'а': "a", 'б': "b", 'в': "v", 'г': "g", 'д': "d", 'е': "e", 'ё': "e", 'ж': "zh",
+	'з': "z", 'и': "i", 'й': "y", 'к': "k", 'л': "l", 'м': "m", 'н': "n", 'о': "o",
+	'п': "p", 'р': "r", 'с': "s", 'т': "t", 'у': "u", 'ф': "f", 'х': "h", 'ц': "ts",
+	'ч': "ch", 'ш': "sh", 'щ': "sch", 'ъ': "", 'ы': "y", 'ь': "", 'э': "e", 'ю': "yu",
+	'я': "ya",
+}
+
+// slugify приводит имя аккаунта к виду, пригодному для поддомена
+func slugify(name string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(name) {
+		switch {
+		case r >= 'a' && r <= 'z' || r >= '0' && r <= '9':
+			b.WriteRune(r)
+			dash = false
+		case translit[r] != "":
+			b.WriteString(translit[r])
+			dash = false
+		default:
+			if !dash && b.Len() > 0 {
+				b.WriteByte('-')
+				dash = true
+			}
+		}
+	}
+	slug := strings.Trim(b.String(), "-")
+	if len(slug) > 63 {
+		slug = strings.TrimRight(slug[:63], "-")
+	}
+	return slug
+}
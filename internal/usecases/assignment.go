@@ -0,0 +1,401 @@
+package usecases
+
+import (
+	uerrors "chalk/internal/errors"
+	"chalk/internal/repo"
+	"chalk/internal/repo/models"
+	"chalk/pkg/assignment"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+)
+
+const (
+	// maxSubmissionFiles и maxSubmissionTextLen ограничивают одну сдачу работы
+	maxSubmissionFiles   = 10
+	maxSubmissionTextLen = 100000
+	maxGradeCommentLen   = 10000
+)
+
+// SaveAssignmentResult - сохранённое задание или ошибки, из-за которых оно не сохранено
+type SaveAssignmentResult struct {
+	BlockID int64
+	// Problems заполняется вместе с ErrInvalidAssignment
+	Problems []assignment.Problem
+}
+
+type CreateAssignmentBlockParams struct {
+	ActorID    int64
+	AccountID  int64
+	LessonID   int64
+	Assignment assignment.Assignment
+	// OrderIdx - позиция в уроке, nil - в конец
+	OrderIdx *int
+}
+
+func (uc *coursesUseCase) CreateAssignmentBlock(ctx context.Context, params CreateAssignmentBlockParams) (SaveAssignmentResult, error) {
+	courseID, err := uc.authorizeItem(ctx, params.ActorID, params.AccountID, models.TrashItemLesson, params.LessonID)
+	if err != nil {
+		return SaveAssignmentResult{}, err
+	}
+	a := params.Assignment
+	if problems := validateAssignment(&a); len(problems) > 0 {
+		return SaveAssignmentResult{Problems: problems}, uerrors.ErrInvalidAssignment
+	}
+
+	id, err := uc.cr.CreateAssignmentBlock(ctx, repo.CreateAssignmentBlockParams{
+		CreateBaseBlockParams: repo.CreateBaseBlockParams{LessonID: params.LessonID, OrderIdx: params.OrderIdx},
+		Assignment:            a,
+	})
+	if err != nil {
+		return SaveAssignmentResult{}, courseError(err, "create assignment block")
+	}
+
+	uc.recordChange(ctx, courseID, params.ActorID, params.AccountID, models.AuditActionContentCreate, models.TrashItemBlock, id,
+		nil, map[string]any{"lesson_id": params.LessonID, "type": models.BlockTypeAssignment, "criteria": len(a.Rubric)})
+	return SaveAssignmentResult{BlockID: id}, nil
+}
+
+type UpdateAssignmentBlockParams struct {
+	ActorID    int64
+	AccountID  int64
+	BlockID    int64
+	Assignment assignment.Assignment
+}
+
+// UpdateAssignmentBlock заменяет задание целиком. Уже выставленные оценки не пересчитываются.
+func (uc *coursesUseCase) UpdateAssignmentBlock(ctx context.Context, params UpdateAssignmentBlockParams) (SaveAssignmentResult, error) {
+	courseID, err := uc.authorizeItem(ctx, params.ActorID, params.AccountID, models.TrashItemBlock, params.BlockID)
+	if err != nil {
+		return SaveAssignmentResult{}, err
+	}
+	a := params.Assignment
+	if problems := validateAssignment(&a); len(problems) > 0 {
+		return SaveAssignmentResult{Problems: problems}, uerrors.ErrInvalidAssignment
+	}
+
+	err = uc.cr.UpdateAssignmentBlock(ctx, repo.UpdateAssignmentBlockParams{
+		BlockID:    params.BlockID,
+		Assignment: a,
+		Author:     revisionAuthor(params.AccountID, params.ActorID),
+	})
+	if err != nil {
+		return SaveAssignmentResult{}, courseError(err, "update assignment block")
+	}
+
+	uc.recordChange(ctx, courseID, params.ActorID, params.AccountID, models.AuditActionContentUpdate, models.TrashItemBlock, params.BlockID, nil, nil)
+	return SaveAssignmentResult{BlockID: params.BlockID}, nil
+}
+
+func validateAssignment(a *assignment.Assignment) []assignment.Problem {
+	a.Normalize()
+	return a.Validate()
+}
+
+// AssignmentState - задание и работа вызывающего
+type AssignmentState struct {
+	BlockID    int64
+	Assignment assignment.Assignment
+	// Submission - nil, если работа ещё не сдавалась
+	Submission *models.Submission
+}
+
+// GetAssignment возвращает задание участнику курса или администратору аккаунта
+func (uc *coursesUseCase) GetAssignment(ctx context.Context, params CourseItemParams) (AssignmentState, error) {
+	block, a, err := uc.publishedAssignment(ctx, params.ActorID, params.AccountID, params.ID)
+	if err != nil {
+		return AssignmentState{}, err
+	}
+
+	state := AssignmentState{BlockID: block.ID, Assignment: a}
+	submission, err := uc.asr.GetUserSubmission(ctx, block.ID, params.ActorID)
+	switch {
+	case err == nil:
+		state.Submission = &submission
+	case !errors.Is(err, repo.ErrSubmissionNotFound):
+		return AssignmentState{}, fmt.Errorf("failed to get submission: %w", err)
+	}
+	return state, nil
+}
+
+// publishedAssignment возвращает задание блока из опубликованной версии курса
+func (uc *coursesUseCase) publishedAssignment(ctx context.Context, actorID, accountID, blockID int64) (models.BlockSnapshot, assignment.Assignment, error) {
+	_, block, err := uc.publishedBlock(ctx, actorID, accountID, blockID, models.BlockTypeAssignment)
+	if err != nil {
+		return models.BlockSnapshot{}, assignment.Assignment{}, err
+	}
+	if block.Assignment == nil {
+		return models.BlockSnapshot{}, assignment.Assignment{}, uerrors.ErrBlockNotFound
+	}
+	return block, *block.Assignment, nil
+}
+
+type SubmitAssignmentParams struct {
+	ActorID   int64
+	AccountID int64
+	BlockID   int64
+	Text      string
+	// FileIDs - файлы, загруженные вызывающим в аккаунт
+	FileIDs []int64
+}
+
+// SubmitAssignment сдаёт работу на проверку. Оценённую работу можно сдать снова,
+// только если преподаватель вернул её на доработку.
+func (uc *coursesUseCase) SubmitAssignment(ctx context.Context, params SubmitAssignmentParams) (models.Submission, error) {
+	block, a, err := uc.publishedAssignment(ctx, params.ActorID, params.AccountID, params.BlockID)
+	if err != nil {
+		return models.Submission{}, err
+	}
+
+	text := strings.TrimSpace(params.Text)
+	if !validSubmission(a, text, params.FileIDs) {
+		return models.Submission{}, uerrors.ErrInvalidSubmission
+	}
+	for _, id := range params.FileIDs {
+		file, err := uc.getAccountFile(ctx, params.AccountID, id)
+		if err != nil {
+			return models.Submission{}, err
+		}
+		// чужой файл участник скачать не может, значит, не может и сдать
+		if file.UploaderUserID != params.ActorID {
+			return models.Submission{}, uerrors.ErrFileNotFound
+		}
+	}
+
+	submission, err := uc.asr.SubmitAssignment(ctx, repo.SubmitAssignmentParams{
+		BlockID:    block.ID,
+		UserID:     params.ActorID,
+		Assignment: a,
+		Text:       text,
+		FileIDs:    params.FileIDs,
+	})
+	if err != nil {
+		if errors.Is(err, repo.ErrSubmissionStatus) {
+			return models.Submission{}, uerrors.ErrSubmissionAlreadyGraded
+		}
+		return models.Submission{}, submissionError(err, "submit assignment")
+	}
+	return submission, nil
+}
+
+// validSubmission проверяет, что работа не пуста, укладывается в ограничения
+// и содержит только то, что разрешено заданием
+func validSubmission(a assignment.Assignment, text string, fileIDs []int64) bool {
+	switch {
+	case text == "" && len(fileIDs) == 0:
+		return false
+	case text != "" && !a.AllowText, len(fileIDs) > 0 && !a.AllowFiles:
+		return false
+	case len(text) > maxSubmissionTextLen, len(fileIDs) > maxSubmissionFiles:
+		return false
+	}
+	for i, id := range fileIDs {
+		if slices.Contains(fileIDs[:i], id) {
+			return false
+		}
+	}
+	return true
+}
+
+type GetSubmissionQueueParams struct {
+	ActorID   int64
+	AccountID int64
+	CourseID  int64
+	// Status и BlockID - необязательные фильтры
+	Status  *models.SubmissionStatus
+	BlockID *int64
+}
+
+// GetSubmissionQueue возвращает работы курса для проверки, давно сданные первыми
+func (uc *coursesUseCase) GetSubmissionQueue(ctx context.Context, params GetSubmissionQueueParams) ([]models.Submission, error) {
+	if err := uc.authorize(ctx, params.ActorID, params.AccountID, false); err != nil {
+		return nil, err
+	}
+	if _, err := uc.checkItem(ctx, params.AccountID, models.TrashItemCourse, params.CourseID); err != nil {
+		return nil, err
+	}
+	if params.Status != nil && !slices.Contains(submissionStatuses, *params.Status) {
+		return nil, uerrors.ErrInvalidSubmission
+	}
+
+	submissions, err := uc.asr.GetSubmissionQueue(ctx, repo.GetSubmissionQueueParams{
+		CourseID: params.CourseID,
+		Status:   params.Status,
+		BlockID:  params.BlockID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get submission queue: %w", err)
+	}
+	return submissions, nil
+}
+
+var submissionStatuses = []models.SubmissionStatus{models.SubmissionSubmitted, models.SubmissionGraded, models.SubmissionReturned}
+
+// GetSubmission возвращает работу с историей администратору аккаунта
+func (uc *coursesUseCase) GetSubmission(ctx context.Context, params CourseItemParams) (models.Submission, error) {
+	if err := uc.authorize(ctx, params.ActorID, params.AccountID, false); err != nil {
+		return models.Submission{}, err
+	}
+	return uc.getAccountSubmission(ctx, params.AccountID, params.ID)
+}
+
+// getAccountSubmission возвращает работу с историей. Работа по заданию другого
+// аккаунта для вызывающего не существует.
+func (uc *coursesUseCase) getAccountSubmission(ctx context.Context, accountID, submissionID int64) (models.Submission, error) {
+	submission, err := uc.asr.GetSubmission(ctx, submissionID)
+	if err != nil {
+		return models.Submission{}, submissionError(err, "get submission")
+	}
+	if _, err := uc.checkItem(ctx, accountID, models.TrashItemBlock, submission.BlockID); err != nil {
+		if errors.Is(err, uerrors.ErrBlockNotFound) {
+			return models.Submission{}, uerrors.ErrSubmissionNotFound
+		}
+		return models.Submission{}, err
+	}
+	return submission, nil
+}
+
+type GradeSubmissionParams struct {
+	ActorID      int64
+	AccountID    int64
+	SubmissionID int64
+	Grades       []assignment.Grade
+	Comment      string
+}
+
+type GradeSubmissionResult struct {
+	Submission models.Submission
+	Progress   models.BlockProgress
+}
+
+// GradeSubmission оценивает работу по рубрике задания, которое участник видел при сдаче,
+// а не по черновику. Результат становится результатом блока у участника, зачтённая
+// работа завершает блок.
+func (uc *coursesUseCase) GradeSubmission(ctx context.Context, params GradeSubmissionParams) (GradeSubmissionResult, error) {
+	if err := uc.authorize(ctx, params.ActorID, params.AccountID, true); err != nil {
+		return GradeSubmissionResult{}, err
+	}
+	submission, err := uc.getAccountSubmission(ctx, params.AccountID, params.SubmissionID)
+	if err != nil {
+		return GradeSubmissionResult{}, err
+	}
+	comment := strings.TrimSpace(params.Comment)
+	if len(comment) > maxGradeCommentLen {
+		return GradeSubmissionResult{}, uerrors.ErrInvalidRubricGrade
+	}
+	result, err := submission.Assignment.Score(params.Grades)
+	if err != nil {
+		if errors.Is(err, assignment.ErrInvalidGrade) {
+			return GradeSubmissionResult{}, uerrors.ErrInvalidRubricGrade
+		}
+		return GradeSubmissionResult{}, fmt.Errorf("failed to score submission %d: %w", submission.ID, err)
+	}
+
+	graded, err := uc.asr.GradeSubmission(ctx, repo.GradeSubmissionParams{
+		SubmissionID: submission.ID,
+		GraderID:     params.ActorID,
+		Grades:       params.Grades,
+		Score:        result.Score,
+		Comment:      comment,
+	})
+	if err != nil {
+		return GradeSubmissionResult{}, submissionError(err, "grade submission")
+	}
+
+	// оценка преподавателя заменяет прошлую, даже если она ниже
+	progress, err := uc.pr.UpdateBlockProgress(ctx, repo.UpdateBlockProgressParams{
+		BlockID:   submission.BlockID,
+		UserID:    submission.UserID,
+		Completed: result.Passed,
+		Score:     &result.Score,
+	})
+	if err != nil {
+		if errors.Is(err, repo.ErrBlockNotFound) {
+			return GradeSubmissionResult{}, uerrors.ErrBlockNotFound
+		}
+		return GradeSubmissionResult{}, fmt.Errorf("failed to update block progress: %w", err)
+	}
+	return GradeSubmissionResult{Submission: graded, Progress: progress}, nil
+}
+
+type ReturnSubmissionParams struct {
+	ActorID      int64
+	AccountID    int64
+	SubmissionID int64
+	Comment      string
+}
+
+// ReturnSubmission возвращает работу участнику на доработку с комментарием
+func (uc *coursesUseCase) ReturnSubmission(ctx context.Context, params ReturnSubmissionParams) (models.Submission, error) {
+	if err := uc.authorize(ctx, params.ActorID, params.AccountID, true); err != nil {
+		return models.Submission{}, err
+	}
+	submission, err := uc.getAccountSubmission(ctx, params.AccountID, params.SubmissionID)
+	if err != nil {
+		return models.Submission{}, err
+	}
+	// без комментария участник не узнает, что доработать
+	comment := strings.TrimSpace(params.Comment)
+	if comment == "" || len(comment) > maxGradeCommentLen {
+		return models.Submission{}, uerrors.ErrInvalidSubmission
+	}
+
+	returned, err := uc.asr.ReturnSubmission(ctx, repo.ReturnSubmissionParams{
+		SubmissionID: submission.ID,
+		ActorID:      params.ActorID,
+		Comment:      comment,
+	})
+	if err != nil {
+		return models.Submission{}, submissionError(err, "return submission")
+	}
+	return returned, nil
+}
+
+type OpenSubmissionFileParams struct {
+	ActorID      int64
+	AccountID    int64
+	SubmissionID int64
+	FileID       int64
+}
+
+// OpenSubmissionFile открывает файл работы её автору или администратору аккаунта
+func (uc *coursesUseCase) OpenSubmissionFile(ctx context.Context, params OpenSubmissionFileParams) (models.File, io.ReadCloser, error) {
+	role, err := getAccountRole(ctx, uc.ar, params.ActorID, params.AccountID)
+	if err != nil {
+		return models.File{}, nil, err
+	}
+	submission, err := uc.getAccountSubmission(ctx, params.AccountID, params.SubmissionID)
+	if err != nil {
+		return models.File{}, nil, err
+	}
+	if submission.UserID != params.ActorID && role != models.AccountMembersRoleOwner && role != models.AccountMembersRoleAdmin {
+		return models.File{}, nil, uerrors.ErrSubmissionNotFound
+	}
+
+	for _, e := range submission.History {
+		for _, f := range e.Files {
+			if f.ID == params.FileID {
+				return openFile(ctx, uc.fr, f)
+			}
+		}
+	}
+	return models.File{}, nil, uerrors.ErrFileNotFound
+}
+
+// submissionError переводит ошибки репозитория работ в пользовательские
+func submissionError(err error, action string) error {
+	switch {
+	case errors.Is(err, repo.ErrSubmissionNotFound):
+		return uerrors.ErrSubmissionNotFound
+	case errors.Is(err, repo.ErrFileNotFound):
+		return uerrors.ErrFileNotFound
+	case errors.Is(err, repo.ErrDuplicateSubmissionFile):
+		return uerrors.ErrInvalidSubmission
+	case errors.Is(err, repo.ErrSubmissionStatus):
+		return uerrors.ErrSubmissionStatusConflict
+	}
+	return courseError(err, action)
+}
@@ -0,0 +1,222 @@
+package usecases
+
+import (
+	"chalk/internal/repo"
+	"chalk/internal/repo/models"
+	"chalk/pkg/log"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+const (
+	auditDefaultPageSize = 50
+	auditMaxPageSize     = 500
+	auditExportBatchSize = 1000
+)
+
+type AuditUseCase interface {
+	GetAccountAuditLog(ctx context.Context, params GetAccountAuditLogParams) (AuditLogPage, error)
+	ExportAccountAuditLogCSV(ctx context.Context, params GetAccountAuditLogParams, w io.Writer) error
+	PurgeExpired(ctx context.Context) (int64, error)
+}
+
+func NewAuditUseCase(
+	aur repo.AuditRepo,
+	ar repo.AccountsRepo,
+	retention time.Duration,
+) AuditUseCase {
+	return &auditUseCase{
+		aur:       aur,
+		ar:        ar,
+		retention: retention,
+	}
+}
+
+type auditUseCase struct {
+	aur repo.AuditRepo
+	ar  repo.AccountsRepo
+
+	// retention - срок хранения записей, 0 - хранить бессрочно
+	retention time.Duration
+}
+
+type GetAccountAuditLogParams struct {
+	ActorID   int64
+	AccountID int64
+
+	ActorUserID *int64
+	Action      *models.AuditAction
+	TargetType  *models.AuditTargetType
+	TargetID    *int64
+	From        *time.Time
+	To          *time.Time
+	BeforeID    *int64
+	Limit       int
+}
+
+type AuditLogPage struct {
+	Entries []models.AuditEntry
+	// NextBeforeID - курсор следующей страницы, nil если записей больше нет
+	NextBeforeID *int64
+}
+
+func (uc *auditUseCase) GetAccountAuditLog(ctx context.Context, params GetAccountAuditLogParams) (AuditLogPage, error) {
+	if err := requireAccountAdmin(ctx, uc.ar, params.ActorID, params.AccountID); err != nil {
+		return AuditLogPage{}, err
+	}
+
+	limit := params.Limit
+	switch {
+	case limit <= 0:
+		limit = auditDefaultPageSize
+	case limit > auditMaxPageSize:
+		limit = auditMaxPageSize
+	}
+
+	entries, err := uc.aur.GetEntries(ctx, auditEntriesFilter(params, params.BeforeID, limit+1))
+	if err != nil {
+		return AuditLogPage{}, fmt.Errorf("failed to get audit entries: %w", err)
+	}
+
+	page := AuditLogPage{Entries: entries}
+	if len(entries) > limit {
+		page.Entries = entries[:limit]
+		next := page.Entries[limit-1].ID
+		page.NextBeforeID = &next
+	}
+	return page, nil
+}
+
+var auditCSVHeader = []string{"id", "created_at", "actor_user_id", "action", "target_type", "target_id", "before", "after", "ip"}
+
+func (uc *auditUseCase) ExportAccountAuditLogCSV(ctx context.Context, params GetAccountAuditLogParams, w io.Writer) error {
+	if err := requireAccountAdmin(ctx, uc.ar, params.ActorID, params.AccountID); err != nil {
+		return err
+	}
+
+	cw := csv.NewWriter(w)
+	if err := cw.Write(auditCSVHeader); err != nil {
+		return fmt.Errorf("failed to write csv header: %w", err)
+	}
+
+	beforeID := params.BeforeID
+	for {
+		entries, err := uc.aur.GetEntries(ctx, auditEntriesFilter(params, beforeID, auditExportBatchSize))
+		if err != nil {
+			return fmt.Errorf("failed to get audit entries: %w", err)
+		}
+
+		for _, e := range entries {
+			if err := cw.Write(auditEntryCSVRecord(e)); err != nil {
+				return fmt.Errorf("failed to write csv record: %w", err)
+			}
+		}
+
+		if len(entries) < auditExportBatchSize {
+			break
+		}
+		last := entries[len(entries)-1].ID
+		beforeID = &last
+	}
+
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return fmt.Errorf("failed to flush csv: %w", err)
+	}
+	return nil
+}
+
+func (uc *auditUseCase) PurgeExpired(ctx context.Context) (int64, error) {
+	if uc.retention <= 0 {
+		return 0, nil
+	}
+	removed, err := uc.aur.RemoveEntriesBefore(ctx, time.Now().Add(-uc.retention))
+	if err != nil {
+		return 0, fmt.Errorf("failed to remove expired audit entries: %w", err)
+	}
+	return removed, nil
+}
+
+func auditEntriesFilter(params GetAccountAuditLogParams, beforeID *int64, limit int) repo.GetAuditEntriesParams {
+	return repo.GetAuditEntriesParams{
+		AccountID:   params.AccountID,
+		ActorUserID: params.ActorUserID,
+		Action:      params.Action,
+		TargetType:  params.TargetType,
+		TargetID:    params.TargetID,
+		From:        params.From,
+		To:          params.To,
+		BeforeID:    beforeID,
+		Limit:       limit,
+	}
+}
+
+func auditEntryCSVRecord(e models.AuditEntry) []string {
+	optInt := func(v *int64) string {
+		if v == nil {
+			return ""
+		}
+		return strconv.FormatInt(*v, 10)
+	}
+	return []string{
+		strconv.FormatInt(e.ID, 10),
+		e.CreatedAt.UTC().Format(time.RFC3339),
+		optInt(e.ActorUserID),
+		string(e.Action),
+		string(e.TargetType),
+		optInt(e.TargetID),
+		string(e.Before),
+		string(e.After),
+		e.IP,
+	}
+}
+
+type auditRecord struct {
+	AccountID   int64
+	ActorUserID int64
+	Action      models.AuditAction
+	TargetType  models.AuditTargetType
+	TargetID    *int64
+	Before      any
+	After       any
+}
+
+// recordAudit пишет запись в журнал аудита. Действие к этому моменту уже выполнено,
+// поэтому ошибка записи не прерывает запрос, а только логируется.
+func recordAudit(ctx context.Context, aur repo.AuditRepo, rec auditRecord) {
+	params := repo.CreateAuditEntryParams{
+		AccountID:   rec.AccountID,
+		ActorUserID: rec.ActorUserID,
+		Action:      rec.Action,
+		TargetType:  rec.TargetType,
+		TargetID:    rec.TargetID,
+		IP:          clientIPFromContext(ctx),
+		CreatedAt:   time.Now(),
+	}
+
+	var err error
+	if params.Before, err = marshalAuditState(rec.Before); err != nil {
+		log.Errorf("audit %s: marshal before: %v", rec.Action, err)
+		return
+	}
+	if params.After, err = marshalAuditState(rec.After); err != nil {
+		log.Errorf("audit %s: marshal after: %v", rec.Action, err)
+		return
+	}
+
+	if _, err := aur.CreateEntry(ctx, params); err != nil {
+		log.Errorf("audit %s: create entry: %v", rec.Action, err)
+	}
+}
+
+func marshalAuditState(v any) (json.RawMessage, error) {
+	if v == nil {
+		return nil, nil
+	}
+	return json.Marshal(v)
+}
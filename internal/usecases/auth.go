@@ -33,6 +33,7 @@ func NewAuthUseCase(
 	cr repo.EmailCodeRepo,
 	ur repo.UsersRepo,
 	sr repo.SessionsRepo,
+	ir repo.InvitationsRepo,
 	codeTTL time.Duration,
 	mailer mailer.Mailer,
 	smtpFromAddr string,
@@ -44,6 +45,7 @@ func NewAuthUseCase(
 		cr:              cr,
 		ur:              ur,
 		sr:              sr,
+		ir:              ir,
 		codeTTL:         codeTTL,
 		mailer:          mailer,
 		smtpFromAddr:    smtpFromAddr,
@@ -57,6 +59,7 @@ type authUseCase struct {
 	cr repo.EmailCodeRepo
 	ur repo.UsersRepo
 	sr repo.SessionsRepo
+	ir repo.InvitationsRepo
 
 	codeTTL time.Duration
 
@@ -132,6 +135,11 @@ func (uc *authUseCase) SignUp(ctx context.Context, params SignUpParams) (int64,
 		log.Warnf("failed to delete codeID: %v", err)
 	}
 
+	// пользователь уже создан, поэтому ошибка принятия приглашений не прерывает регистрацию
+	if _, err := uc.ir.AcceptInvitations(ctx, userID, code.Email); err != nil {
+		log.Errorf("failed to accept invitations of user %d: %v", userID, err)
+	}
+
 	return userID, err
 }
 
@@ -0,0 +1,180 @@
+package usecases
+
+import (
+	uerrors "chalk/internal/errors"
+	"chalk/internal/repo"
+	"chalk/internal/repo/models"
+	"chalk/pkg/billing"
+	"chalk/pkg/log"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+type BillingUseCase interface {
+	GetPlans(ctx context.Context) ([]models.Plan, error)
+	GetSubscription(ctx context.Context, params GetSubscriptionParams) (models.Subscription, error)
+	StartCheckout(ctx context.Context, params StartCheckoutParams) (string, error)
+	HandleWebhook(ctx context.Context, header http.Header, payload []byte) error
+}
+
+func NewBillingUseCase(
+	br repo.BillingRepo,
+	ar repo.AccountsRepo,
+	provider billing.Provider,
+) BillingUseCase {
+	return &billingUseCase{
+		br:       br,
+		ar:       ar,
+		provider: provider,
+	}
+}
+
+type billingUseCase struct {
+	br repo.BillingRepo
+	ar repo.AccountsRepo
+
+	provider billing.Provider
+}
+
+func (uc *billingUseCase) GetPlans(ctx context.Context) ([]models.Plan, error) {
+	plans, err := uc.br.GetPlans(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get plans: %w", err)
+	}
+	return plans, nil
+}
+
+type GetSubscriptionParams struct {
+	ActorID   int64
+	AccountID int64
+}
+
+func (uc *billingUseCase) GetSubscription(ctx context.Context, params GetSubscriptionParams) (models.Subscription, error) {
+	if _, err := getAccountRole(ctx, uc.ar, params.ActorID, params.AccountID); err != nil {
+		return models.Subscription{}, err
+	}
+
+	sub, err := uc.br.GetSubscription(ctx, params.AccountID)
+	if err != nil {
+		if errors.Is(err, repo.ErrAccountNotFound) {
+			return models.Subscription{}, uerrors.ErrAccountNotFound
+		}
+		return models.Subscription{}, fmt.Errorf("failed to get subscription: %w", err)
+	}
+	return sub, nil
+}
+
+type StartCheckoutParams struct {
+	ActorID   int64
+	AccountID int64
+	PlanID    string
+}
+
+// StartCheckout возвращает ссылку на оплату тарифа. Сам тариф меняется только
+// после подтверждения от провайдера через вебхук.
+func (uc *billingUseCase) StartCheckout(ctx context.Context, params StartCheckoutParams) (string, error) {
+	if err := requireAccountAdmin(ctx, uc.ar, params.ActorID, params.AccountID); err != nil {
+		return "", err
+	}
+
+	if err := requireWritableAccount(ctx, uc.ar, params.AccountID); err != nil {
+		return "", err
+	}
+
+	plan, err := uc.br.GetPlan(ctx, params.PlanID)
+	if err != nil {
+		if errors.Is(err, repo.ErrPlanNotFound) {
+			return "", uerrors.ErrPlanNotFound
+		}
+		return "", fmt.Errorf("failed to get plan: %w", err)
+	}
+
+	// переход на тариф меньше текущего использования не разрешается
+	seats, err := uc.br.CountAccountSeats(ctx, params.AccountID)
+	if err != nil {
+		return "", fmt.Errorf("failed to count seats: %w", err)
+	}
+	courses, err := uc.br.CountAccountCourses(ctx, params.AccountID)
+	if err != nil {
+		return "", fmt.Errorf("failed to count courses: %w", err)
+	}
+	if exceedsLimit(plan.MaxSeats, seats) || exceedsLimit(plan.MaxCourses, courses) {
+		return "", uerrors.ErrPlanLimitsExceeded
+	}
+
+	url, err := uc.provider.CreateCheckout(ctx, billing.CheckoutParams{
+		AccountID: params.AccountID,
+		PlanID:    plan.ID,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to create checkout: %w", err)
+	}
+	return url, nil
+}
+
+// HandleWebhook применяет событие провайдера. Повторная доставка уже
+// обработанного события ничего не меняет.
+func (uc *billingUseCase) HandleWebhook(ctx context.Context, header http.Header, payload []byte) error {
+	event, err := uc.provider.ParseWebhook(header, payload)
+	if err != nil {
+		if errors.Is(err, billing.ErrInvalidWebhook) {
+			log.Warnf("billing webhook: %v", err)
+			return uerrors.ErrInvalidBillingWebhook
+		}
+		return fmt.Errorf("failed to parse webhook: %w", err)
+	}
+
+	applied, err := uc.br.ApplyBillingEvent(ctx, repo.ApplyBillingEventParams{
+		Provider:     uc.provider.Name(),
+		EventID:      event.ID,
+		Type:         string(event.Type),
+		AccountID:    event.AccountID,
+		Payload:      event.Payload,
+		Subscription: subscriptionUpdateFromEvent(event),
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, repo.ErrAccountNotFound):
+			// аккаунт мог быть удалён, провайдеру повторять доставку незачем
+			log.Warnf("billing webhook %s: account %d not found", event.ID, event.AccountID)
+			return nil
+		case errors.Is(err, repo.ErrPlanNotFound):
+			return uerrors.ErrPlanNotFound
+		}
+		return fmt.Errorf("failed to apply billing event: %w", err)
+	}
+	if !applied {
+		log.Infof("billing webhook %s: already processed", event.ID)
+	}
+	return nil
+}
+
+func subscriptionUpdateFromEvent(e billing.Event) *repo.SubscriptionUpdate {
+	upd := &repo.SubscriptionUpdate{CurrentPeriodEnd: e.PeriodEnd}
+	if e.SubscriptionID != "" {
+		upd.ProviderSubscriptionID = &e.SubscriptionID
+	}
+	if e.PlanID != "" {
+		upd.PlanID = &e.PlanID
+	}
+
+	switch e.Type {
+	case billing.EventPaymentSucceeded, billing.EventSubscriptionUpdated:
+		upd.Status = models.SubscriptionStatusActive
+	case billing.EventPaymentFailed:
+		upd.Status = models.SubscriptionStatusPastDue
+	case billing.EventSubscriptionCancelled:
+		planID := models.DefaultPlanID
+		upd.PlanID = &planID
+		upd.Status = models.SubscriptionStatusCancelled
+	default:
+		return nil
+	}
+	return upd
+}
+
+func exceedsLimit(limit *int, used int) bool {
+	return limit != nil && used > *limit
+}
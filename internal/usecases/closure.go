@@ -0,0 +1,662 @@
+package usecases
+
+import (
+	"archive/zip"
+	uerrors "chalk/internal/errors"
+	"chalk/internal/repo"
+	"chalk/internal/repo/models"
+	"chalk/pkg/assignment"
+	"chalk/pkg/codeexercise"
+	"chalk/pkg/log"
+	"chalk/pkg/mailer"
+	"chalk/pkg/media"
+	"chalk/pkg/quiz"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+const accountExportFormat = "chalk-account-export"
+
+type ClosureUseCase interface {
+	RequestClosure(ctx context.Context, params RequestClosureParams) (models.AccountClosure, error)
+	CancelClosure(ctx context.Context, params CancelClosureParams) error
+	GetClosure(ctx context.Context, params GetClosureParams) (models.AccountClosure, error)
+	GetExport(ctx context.Context, params GetClosureParams) (models.File, io.ReadCloser, error)
+	// ProcessClosures собирает архивы закрывающихся аккаунтов и удаляет аккаунты с истёкшим сроком
+	ProcessClosures(ctx context.Context) error
+}
+
+func NewClosureUseCase(
+	clr repo.ClosureRepo,
+	ar repo.AccountsRepo,
+	ur repo.UsersRepo,
+	cr repo.CoursesRepo,
+	fr repo.FilesRepo,
+	aur repo.AuditRepo,
+	mailer mailer.Mailer,
+	emailFromAddr string,
+	emailFromName string,
+	gracePeriod time.Duration,
+) ClosureUseCase {
+	return &closureUseCase{
+		clr:         clr,
+		ar:          ar,
+		ur:          ur,
+		cr:          cr,
+		fr:          fr,
+		aur:         aur,
+		mailer:      newAccountMailer(mailer, ar, emailFromAddr, emailFromName),
+		gracePeriod: gracePeriod,
+	}
+}
+
+type closureUseCase struct {
+	clr repo.ClosureRepo
+	ar  repo.AccountsRepo
+	ur  repo.UsersRepo
+	cr  repo.CoursesRepo
+	fr  repo.FilesRepo
+	aur repo.AuditRepo
+
+	mailer *accountMailer
+
+	// gracePeriod - время между запросом закрытия и удалением данных
+	gracePeriod time.Duration
+}
+
+type RequestClosureParams struct {
+	ActorID   int64
+	AccountID int64
+	// Password - пароль владельца для повторной аутентификации
+	Password string
+}
+
+func (uc *closureUseCase) RequestClosure(ctx context.Context, params RequestClosureParams) (models.AccountClosure, error) {
+	if err := uc.requireOwner(ctx, params.ActorID, params.AccountID); err != nil {
+		return models.AccountClosure{}, err
+	}
+
+	user, err := uc.ur.GetUserById(ctx, params.ActorID)
+	if err != nil {
+		if errors.Is(err, repo.ErrRecordNotFound) {
+			return models.AccountClosure{}, uerrors.ErrUserNotFound
+		}
+		return models.AccountClosure{}, fmt.Errorf("failed to get user: %w", err)
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(user.HashPass), []byte(params.Password)); err != nil {
+		return models.AccountClosure{}, uerrors.ErrInvalidPassword
+	}
+
+	now := time.Now()
+	closure, err := uc.clr.RequestAccountClosure(ctx, repo.RequestAccountClosureParams{
+		AccountID:   params.AccountID,
+		RequestedBy: params.ActorID,
+		RequestedAt: now,
+		DeleteAt:    now.Add(uc.gracePeriod),
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, repo.ErrAccountNotFound):
+			return models.AccountClosure{}, uerrors.ErrAccountNotFound
+		case errors.Is(err, repo.ErrAccountClosing):
+			return models.AccountClosure{}, uerrors.ErrAccountClosing
+		}
+		return models.AccountClosure{}, fmt.Errorf("failed to request account closure: %w", err)
+	}
+
+	recordAudit(ctx, uc.aur, auditRecord{
+		AccountID:   params.AccountID,
+		ActorUserID: params.ActorID,
+		Action:      models.AuditActionAccountClosureRequest,
+		TargetType:  models.AuditTargetAccount,
+		TargetID:    &params.AccountID,
+		After:       map[string]any{"deletion_scheduled_at": closure.DeletionScheduledAt},
+	})
+
+	uc.notifyOwner(ctx, params.AccountID, user, "Аккаунт будет закрыт", fmt.Sprintf(
+		"Здравствуйте, %s!\n\nАккаунт закрыт для изменений, данные будут удалены %s. "+
+			"До этого момента закрытие можно отменить, а архив с данными будет доступен для скачивания.",
+		user.Name, closure.DeletionScheduledAt.UTC().Format("02.01.2006 15:04 UTC"),
+	))
+	return closure, nil
+}
+
+type CancelClosureParams struct {
+	ActorID   int64
+	AccountID int64
+}
+
+func (uc *closureUseCase) CancelClosure(ctx context.Context, params CancelClosureParams) error {
+	if err := uc.requireOwner(ctx, params.ActorID, params.AccountID); err != nil {
+		return err
+	}
+
+	prev, err := uc.clr.CancelAccountClosure(ctx, params.AccountID)
+	if err != nil {
+		switch {
+		case errors.Is(err, repo.ErrAccountNotFound):
+			return uerrors.ErrAccountNotFound
+		case errors.Is(err, repo.ErrAccountNotClosing):
+			return uerrors.ErrAccountNotClosing
+		case errors.Is(err, repo.ErrAccountDeleting):
+			return uerrors.ErrAccountDeleting
+		}
+		return fmt.Errorf("failed to cancel account closure: %w", err)
+	}
+
+	// архив больше не нужен, ошибка удаления не отменяет восстановление аккаунта
+	if prev.ExportFileID != nil {
+		if err := uc.removeFile(ctx, *prev.ExportFileID); err != nil {
+			log.Warnf("cancel closure of account %d: remove export: %v", params.AccountID, err)
+		}
+	}
+
+	recordAudit(ctx, uc.aur, auditRecord{
+		AccountID:   params.AccountID,
+		ActorUserID: params.ActorID,
+		Action:      models.AuditActionAccountClosureCancel,
+		TargetType:  models.AuditTargetAccount,
+		TargetID:    &params.AccountID,
+		Before:      map[string]any{"deletion_scheduled_at": prev.DeletionScheduledAt},
+	})
+	return nil
+}
+
+type GetClosureParams struct {
+	ActorID   int64
+	AccountID int64
+}
+
+func (uc *closureUseCase) GetClosure(ctx context.Context, params GetClosureParams) (models.AccountClosure, error) {
+	if err := requireAccountAdmin(ctx, uc.ar, params.ActorID, params.AccountID); err != nil {
+		return models.AccountClosure{}, err
+	}
+	return uc.getClosure(ctx, params.AccountID)
+}
+
+func (uc *closureUseCase) GetExport(ctx context.Context, params GetClosureParams) (models.File, io.ReadCloser, error) {
+	if err := uc.requireOwner(ctx, params.ActorID, params.AccountID); err != nil {
+		return models.File{}, nil, err
+	}
+
+	closure, err := uc.getClosure(ctx, params.AccountID)
+	if err != nil {
+		return models.File{}, nil, err
+	}
+	if closure.ExportFileID == nil {
+		return models.File{}, nil, uerrors.ErrExportNotReady
+	}
+
+	file, err := uc.fr.GetFileInfo(ctx, *closure.ExportFileID)
+	if err != nil {
+		if errors.Is(err, repo.ErrFileNotFound) {
+			return models.File{}, nil, uerrors.ErrExportNotReady
+		}
+		return models.File{}, nil, fmt.Errorf("failed to get export file: %w", err)
+	}
+	return openFile(ctx, uc.fr, file)
+}
+
+func (uc *closureUseCase) getClosure(ctx context.Context, accountID int64) (models.AccountClosure, error) {
+	closure, err := uc.clr.GetAccountClosure(ctx, accountID)
+	if err != nil {
+		switch {
+		case errors.Is(err, repo.ErrAccountNotFound):
+			return models.AccountClosure{}, uerrors.ErrAccountNotFound
+		case errors.Is(err, repo.ErrAccountNotClosing):
+			return models.AccountClosure{}, uerrors.ErrAccountNotClosing
+		}
+		return models.AccountClosure{}, fmt.Errorf("failed to get account closure: %w", err)
+	}
+	return closure, nil
+}
+
+func (uc *closureUseCase) requireOwner(ctx context.Context, userID, accountID int64) error {
+	role, err := getAccountRole(ctx, uc.ar, userID, accountID)
+	if err != nil {
+		return err
+	}
+	if role != models.AccountMembersRoleOwner {
+		return uerrors.ErrPermissionDenied
+	}
+	return nil
+}
+
+func (uc *closureUseCase) ProcessClosures(ctx context.Context) error {
+	closures, err := uc.clr.GetClosuresWithoutExport(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get closures without export: %w", err)
+	}
+	for _, c := range closures {
+		if err := uc.exportAccount(ctx, c); err != nil {
+			log.Errorf("export account %d: %v", c.AccountID, err)
+		}
+	}
+
+	now := time.Now()
+	ids, err := uc.clr.GetAccountsDueForDeletion(ctx, now)
+	if err != nil {
+		return fmt.Errorf("failed to get accounts due for deletion: %w", err)
+	}
+	for _, id := range ids {
+		if err := uc.deleteAccount(ctx, id, now); err != nil {
+			log.Errorf("delete account %d: %v", id, err)
+		}
+	}
+	return nil
+}
+
+// deleteAccount удаляет объекты MinIO аккаунта, затем сам аккаунт. Строки
+// базы удаляются каскадно, а объекты без удаления остались бы в бакете навсегда.
+// Сначала аккаунт переводится в состояние удаления, чтобы владелец не отменил
+// закрытие, когда часть объектов уже удалена.
+func (uc *closureUseCase) deleteAccount(ctx context.Context, accountID int64, now time.Time) error {
+	if err := uc.clr.StartAccountDeletion(ctx, accountID, now); err != nil {
+		if errors.Is(err, repo.ErrAccountNotClosing) || errors.Is(err, repo.ErrAccountNotFound) {
+			// закрытие отменили после выборки
+			return nil
+		}
+		return fmt.Errorf("failed to start account deletion: %w", err)
+	}
+
+	files, err := uc.clr.GetAccountFiles(ctx, accountID)
+	if err != nil {
+		return fmt.Errorf("failed to get account files: %w", err)
+	}
+	for _, f := range files {
+		if err := uc.fr.RemoveFile(ctx, f); err != nil {
+			// аккаунт остаётся до следующего запуска, чтобы не потерять ссылки на объекты
+			return fmt.Errorf("failed to remove file %d: %w", f.ID, err)
+		}
+	}
+
+	if err := uc.clr.DeleteClosedAccount(ctx, accountID); err != nil {
+		return fmt.Errorf("failed to delete account: %w", err)
+	}
+	log.Infof("account %d deleted after closure, %d files removed", accountID, len(files))
+	return nil
+}
+
+func (uc *closureUseCase) removeFile(ctx context.Context, fileID int64) error {
+	file, err := uc.fr.GetFileInfo(ctx, fileID)
+	if err != nil {
+		if errors.Is(err, repo.ErrFileNotFound) {
+			return nil
+		}
+		return err
+	}
+	return uc.fr.RemoveFile(ctx, file)
+}
+
+func (uc *closureUseCase) notifyOwner(ctx context.Context, accountID int64, user models.User, subject, body string) {
+	err := uc.mailer.Send(ctx, accountMail{
+		AccountID: accountID,
+		To:        user.Email,
+		Subject:   subject,
+		Body:      body,
+	})
+	if err != nil {
+		log.Warnf("notify account %d owner: %v", accountID, err)
+	}
+}
+
+/* ===================== Export ===================== */
+
+type accountExportManifest struct {
+	Format     string              `json:"format"`
+	Version    int                 `json:"version"`
+	ExportedAt time.Time           `json:"exported_at"`
+	Account    accountExportHeader `json:"account"`
+}
+
+type accountExportHeader struct {
+	ID   int64  `json:"id"`
+	Name string `json:"name"`
+	Slug string `json:"slug"`
+}
+
+type accountExportSettings struct {
+	DisplayName     string `json:"display_name"`
+	LogoFileID      *int64 `json:"logo_file_id"`
+	FaviconFileID   *int64 `json:"favicon_file_id"`
+	PrimaryColor    string `json:"primary_color"`
+	AccentColor     string `json:"accent_color"`
+	SupportEmail    string `json:"support_email"`
+	EmailSenderName string `json:"email_sender_name"`
+}
+
+type accountExportMember struct {
+	UserID int64  `json:"user_id"`
+	Name   string `json:"name"`
+	Email  string `json:"email"`
+	Role   string `json:"role"`
+}
+
+type accountExportEnrollment struct {
+	CourseID int64 `json:"course_id"`
+	UserID   int64 `json:"user_id"`
+}
+
+type accountExportFile struct {
+	ID          int64     `json:"id"`
+	Name        string    `json:"name"`
+	ContentType string    `json:"content_type"`
+	Size        int64     `json:"size"`
+	UploadedAt  time.Time `json:"uploaded_at"`
+	// Path - путь к содержимому внутри архива
+	Path string `json:"path"`
+}
+
+type accountExportCourse struct {
+	ID      int64                 `json:"id"`
+	Name    string                `json:"name"`
+	Modules []accountExportModule `json:"modules"`
+}
+
+type accountExportModule struct {
+	ID       int64                 `json:"id"`
+	Name     string                `json:"name"`
+	OrderIdx int                   `json:"order_idx"`
+	Lessons  []accountExportLesson `json:"lessons"`
+}
+
+type accountExportLesson struct {
+	ID       int64                `json:"id"`
+	Name     string               `json:"name"`
+	OrderIdx int                  `json:"order_idx"`
+	Blocks   []accountExportBlock `json:"blocks"`
+}
+
+type accountExportBlock struct {
+	ID           int64                  `json:"id"`
+	Type         models.BlockType       `json:"type"`
+	OrderIdx     int                    `json:"order_idx"`
+	Content      *string                `json:"content,omitempty"`
+	FileID       *int64                 `json:"file_id,omitempty"`
+	Quiz         *quiz.Quiz             `json:"quiz,omitempty"`
+	Assignment   *assignment.Assignment `json:"assignment,omitempty"`
+	CodeExercise *codeexercise.Exercise `json:"code_exercise,omitempty"`
+	AltText      string                 `json:"alt_text,omitempty"`
+	Caption      string                 `json:"caption,omitempty"`
+	Title        string                 `json:"title,omitempty"`
+	Embed        *media.Embed           `json:"embed,omitempty"`
+}
+
+// exportAccount собирает архив во временный файл и сохраняет его как файл аккаунта
+func (uc *closureUseCase) exportAccount(ctx context.Context, closure models.AccountClosure) error {
+	if closure.RequestedBy == nil {
+		return fmt.Errorf("closure requester is unknown")
+	}
+
+	tmp, err := os.CreateTemp("", "account-export-*.zip")
+	if err != nil {
+		return fmt.Errorf("failed to create temp file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	acc, err := uc.writeAccountExport(ctx, closure.AccountID, tmp)
+	if err != nil {
+		return err
+	}
+
+	size, err := tmp.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return fmt.Errorf("failed to get export size: %w", err)
+	}
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("failed to rewind export: %w", err)
+	}
+
+	file, err := uc.fr.UploadFile(ctx, repo.UploadFileParams{
+		Reader:         tmp,
+		UploaderUserID: *closure.RequestedBy,
+		AccountID:      closure.AccountID,
+		SkipQuota:      true,
+		Name:           fmt.Sprintf("%s-export-%s.zip", acc.Slug, time.Now().UTC().Format("20060102")),
+		ContentType:    "application/zip",
+		Size:           size,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to upload export: %w", err)
+	}
+
+	if err := uc.clr.SetAccountExportFile(ctx, closure.AccountID, file.ID); err != nil {
+		// закрытие успели отменить, архив больше не нужен
+		if rmErr := uc.fr.RemoveFile(ctx, file); rmErr != nil {
+			log.Warnf("remove unused export %d: %v", file.ID, rmErr)
+		}
+		return fmt.Errorf("failed to save export file: %w", err)
+	}
+
+	if user, err := uc.ur.GetUserById(ctx, *closure.RequestedBy); err == nil {
+		uc.notifyOwner(ctx, closure.AccountID, user, "Архив аккаунта готов", fmt.Sprintf(
+			"Здравствуйте, %s!\n\nАрхив с данными аккаунта готов и доступен для скачивания до %s.",
+			user.Name, closure.DeletionScheduledAt.UTC().Format("02.01.2006 15:04 UTC"),
+		))
+	}
+	return nil
+}
+
+func (uc *closureUseCase) writeAccountExport(ctx context.Context, accountID int64, w io.Writer) (models.Account, error) {
+	acc, err := uc.ar.GetAccountByID(ctx, accountID)
+	if err != nil {
+		return models.Account{}, fmt.Errorf("failed to get account: %w", err)
+	}
+
+	zw := zip.NewWriter(w)
+	writeJSON := func(name string, v any) error {
+		f, err := zw.Create(name)
+		if err != nil {
+			return fmt.Errorf("failed to create %s: %w", name, err)
+		}
+		enc := json.NewEncoder(f)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(v); err != nil {
+			return fmt.Errorf("failed to write %s: %w", name, err)
+		}
+		return nil
+	}
+
+	err = writeJSON("manifest.json", accountExportManifest{
+		Format:     accountExportFormat,
+		Version:    1,
+		ExportedAt: time.Now().UTC(),
+		Account:    accountExportHeader{ID: acc.ID, Name: acc.Name, Slug: acc.Slug},
+	})
+	if err != nil {
+		return models.Account{}, err
+	}
+
+	settings, err := uc.ar.GetAccountSettings(ctx, accountID)
+	if err != nil {
+		return models.Account{}, fmt.Errorf("failed to get settings: %w", err)
+	}
+	err = writeJSON("settings.json", accountExportSettings{
+		DisplayName:     settings.DisplayName,
+		LogoFileID:      settings.LogoFileID,
+		FaviconFileID:   settings.FaviconFileID,
+		PrimaryColor:    settings.PrimaryColor,
+		AccentColor:     settings.AccentColor,
+		SupportEmail:    settings.SupportEmail,
+		EmailSenderName: settings.EmailSenderName,
+	})
+	if err != nil {
+		return models.Account{}, err
+	}
+
+	members, err := uc.exportMembers(ctx, accountID)
+	if err != nil {
+		return models.Account{}, err
+	}
+	if err := writeJSON("members.json", members); err != nil {
+		return models.Account{}, err
+	}
+
+	enrollments, err := uc.clr.GetAccountEnrollments(ctx, accountID)
+	if err != nil {
+		return models.Account{}, fmt.Errorf("failed to get enrollments: %w", err)
+	}
+	exportEnrollments := make([]accountExportEnrollment, 0, len(enrollments))
+	for _, e := range enrollments {
+		exportEnrollments = append(exportEnrollments, accountExportEnrollment{CourseID: e.CourseID, UserID: e.UserID})
+	}
+	if err := writeJSON("enrollments.json", exportEnrollments); err != nil {
+		return models.Account{}, err
+	}
+
+	courses, err := uc.exportCourses(ctx, accountID)
+	if err != nil {
+		return models.Account{}, err
+	}
+	if err := writeJSON("courses.json", courses); err != nil {
+		return models.Account{}, err
+	}
+
+	files, err := uc.clr.GetAccountFiles(ctx, accountID)
+	if err != nil {
+		return models.Account{}, fmt.Errorf("failed to get files: %w", err)
+	}
+	exportFiles := make([]accountExportFile, 0, len(files))
+	for _, f := range files {
+		ef := accountExportFile{
+			ID:          f.ID,
+			Name:        f.Name,
+			ContentType: f.ContentType,
+			Size:        f.Size,
+			UploadedAt:  f.UploadedAt,
+			Path:        path.Join("files", fmt.Sprintf("%d-%s", f.ID, path.Base("/"+f.Name))),
+		}
+		if err := uc.copyFileToZip(ctx, zw, f, ef.Path); err != nil {
+			return models.Account{}, err
+		}
+		exportFiles = append(exportFiles, ef)
+	}
+	if err := writeJSON("files.json", exportFiles); err != nil {
+		return models.Account{}, err
+	}
+
+	if err := zw.Close(); err != nil {
+		return models.Account{}, fmt.Errorf("failed to finish archive: %w", err)
+	}
+	return acc, nil
+}
+
+func (uc *closureUseCase) exportMembers(ctx context.Context, accountID int64) ([]accountExportMember, error) {
+	var members []accountExportMember
+	var after *repo.MembersCursor
+	for {
+		page, err := uc.ar.GetAccountMembers(ctx, repo.GetAccountMembersParams{
+			AccountMembersFilter: repo.AccountMembersFilter{AccountID: accountID},
+			Sort:                 repo.MembersSortByEmail,
+			After:                after,
+			Limit:                membersMaxPageSize,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get members: %w", err)
+		}
+		for _, m := range page {
+			members = append(members, accountExportMember{
+				UserID: m.UserID,
+				Name:   m.Name,
+				Email:  m.Email,
+				Role:   string(m.Role),
+			})
+		}
+		if len(page) < membersMaxPageSize {
+			return members, nil
+		}
+		last := page[len(page)-1]
+		after = &repo.MembersCursor{Key: membersSortKey(last, repo.MembersSortByEmail), UserID: last.UserID}
+	}
+}
+
+func (uc *closureUseCase) exportCourses(ctx context.Context, accountID int64) ([]accountExportCourse, error) {
+	courses, err := uc.cr.GetCoursesByAccountID(ctx, accountID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get courses: %w", err)
+	}
+
+	result := make([]accountExportCourse, 0, len(courses))
+	for _, c := range courses {
+		ec := accountExportCourse{ID: c.ID, Name: c.Name, Modules: []accountExportModule{}}
+		modules, err := uc.cr.GetModulesByCourseID(ctx, c.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get modules of course %d: %w", c.ID, err)
+		}
+		for _, m := range modules {
+			em := accountExportModule{ID: m.ID, Name: m.Name, OrderIdx: m.OrderIdx, Lessons: []accountExportLesson{}}
+			lessons, err := uc.cr.GetLessonsByModuleID(ctx, m.ID)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get lessons of module %d: %w", m.ID, err)
+			}
+			for _, l := range lessons {
+				el := accountExportLesson{ID: l.ID, Name: l.Name, OrderIdx: l.OrderIdx, Blocks: []accountExportBlock{}}
+				blocks, err := uc.cr.GetBlocksByLessonID(ctx, l.ID)
+				if err != nil {
+					return nil, fmt.Errorf("failed to get blocks of lesson %d: %w", l.ID, err)
+				}
+				for _, b := range blocks {
+					el.Blocks = append(el.Blocks, toAccountExportBlock(b))
+				}
+				em.Lessons = append(em.Lessons, el)
+			}
+			ec.Modules = append(ec.Modules, em)
+		}
+		result = append(result, ec)
+	}
+	return result, nil
+}
+
+func toAccountExportBlock(b any) accountExportBlock {
+	switch b := b.(type) {
+	case *models.TextBlock:
+		return accountExportBlock{ID: b.ID, Type: b.Type, OrderIdx: b.OrderIdx, Content: &b.Content}
+	case *models.VideoBlock:
+		return accountExportBlock{ID: b.ID, Type: b.Type, OrderIdx: b.OrderIdx, FileID: &b.FileID}
+	case *models.ScormBlock:
+		return accountExportBlock{ID: b.ID, Type: b.Type, OrderIdx: b.OrderIdx, FileID: &b.FileID}
+	case *models.QuizBlock:
+		return accountExportBlock{ID: b.ID, Type: b.Type, OrderIdx: b.OrderIdx, Quiz: &b.Quiz}
+	case *models.AssignmentBlock:
+		return accountExportBlock{ID: b.ID, Type: b.Type, OrderIdx: b.OrderIdx, Assignment: &b.Assignment}
+	case *models.CodeExerciseBlock:
+		return accountExportBlock{ID: b.ID, Type: b.Type, OrderIdx: b.OrderIdx, CodeExercise: &b.Exercise}
+	case *models.ImageBlock:
+		return accountExportBlock{ID: b.ID, Type: b.Type, OrderIdx: b.OrderIdx, FileID: &b.FileID, AltText: b.AltText, Caption: b.Caption}
+	case *models.AttachmentBlock:
+		return accountExportBlock{ID: b.ID, Type: b.Type, OrderIdx: b.OrderIdx, FileID: &b.FileID, Title: b.Title}
+	case *models.EmbedBlock:
+		return accountExportBlock{ID: b.ID, Type: b.Type, OrderIdx: b.OrderIdx, Embed: &b.Embed, Title: b.Title}
+	}
+	return accountExportBlock{}
+}
+
+func (uc *closureUseCase) copyFileToZip(ctx context.Context, zw *zip.Writer, file models.File, name string) error {
+	obj, err := uc.fr.GetFileByID(ctx, file.ID)
+	if err != nil {
+		return fmt.Errorf("failed to open file %d: %w", file.ID, err)
+	}
+	defer obj.Close()
+
+	w, err := zw.CreateHeader(&zip.FileHeader{
+		Name:     name,
+		Method:   zip.Store,
+		Modified: file.UploadedAt,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", name, err)
+	}
+	if _, err := io.Copy(w, obj); err != nil {
+		return fmt.Errorf("failed to copy file %d: %w", file.ID, err)
+	}
+	return nil
+}
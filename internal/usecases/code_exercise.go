@@ -0,0 +1,203 @@
+package usecases
+
+import (
+	uerrors "chalk/internal/errors"
+	"chalk/internal/repo"
+	"chalk/internal/repo/models"
+	"chalk/pkg/codeexercise"
+	"chalk/pkg/coderunner"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// maxShownCodeSubmissions - сколько последних решений показывается участнику
+const maxShownCodeSubmissions = 20
+
+// SaveCodeExerciseResult - сохранённое упражнение или ошибки, из-за которых оно не сохранено
+type SaveCodeExerciseResult struct {
+	BlockID int64
+	// Problems заполняется вместе с ErrInvalidCodeExercise
+	Problems []codeexercise.Problem
+}
+
+type CreateCodeExerciseBlockParams struct {
+	ActorID   int64
+	AccountID int64
+	LessonID  int64
+	Exercise  codeexercise.Exercise
+	// OrderIdx - позиция в уроке, nil - в конец
+	OrderIdx *int
+}
+
+func (uc *coursesUseCase) CreateCodeExerciseBlock(ctx context.Context, params CreateCodeExerciseBlockParams) (SaveCodeExerciseResult, error) {
+	courseID, err := uc.authorizeItem(ctx, params.ActorID, params.AccountID, models.TrashItemLesson, params.LessonID)
+	if err != nil {
+		return SaveCodeExerciseResult{}, err
+	}
+	e := params.Exercise
+	if problems := uc.validateCodeExercise(&e); len(problems) > 0 {
+		return SaveCodeExerciseResult{Problems: problems}, uerrors.ErrInvalidCodeExercise
+	}
+
+	id, err := uc.cr.CreateCodeExerciseBlock(ctx, repo.CreateCodeExerciseBlockParams{
+		CreateBaseBlockParams: repo.CreateBaseBlockParams{LessonID: params.LessonID, OrderIdx: params.OrderIdx},
+		Exercise:              e,
+	})
+	if err != nil {
+		return SaveCodeExerciseResult{}, courseError(err, "create code exercise block")
+	}
+
+	uc.recordChange(ctx, courseID, params.ActorID, params.AccountID, models.AuditActionContentCreate, models.TrashItemBlock, id,
+		nil, map[string]any{"lesson_id": params.LessonID, "type": models.BlockTypeCodeExercise, "language": e.Language, "tests": len(e.Tests)})
+	return SaveCodeExerciseResult{BlockID: id}, nil
+}
+
+type UpdateCodeExerciseBlockParams struct {
+	ActorID   int64
+	AccountID int64
+	BlockID   int64
+	Exercise  codeexercise.Exercise
+}
+
+// UpdateCodeExerciseBlock заменяет упражнение целиком. Прошлые решения не перепроверяются.
+func (uc *coursesUseCase) UpdateCodeExerciseBlock(ctx context.Context, params UpdateCodeExerciseBlockParams) (SaveCodeExerciseResult, error) {
+	courseID, err := uc.authorizeItem(ctx, params.ActorID, params.AccountID, models.TrashItemBlock, params.BlockID)
+	if err != nil {
+		return SaveCodeExerciseResult{}, err
+	}
+	e := params.Exercise
+	if problems := uc.validateCodeExercise(&e); len(problems) > 0 {
+		return SaveCodeExerciseResult{Problems: problems}, uerrors.ErrInvalidCodeExercise
+	}
+
+	err = uc.cr.UpdateCodeExerciseBlock(ctx, repo.UpdateCodeExerciseBlockParams{
+		BlockID:  params.BlockID,
+		Exercise: e,
+		Author:   revisionAuthor(params.AccountID, params.ActorID),
+	})
+	if err != nil {
+		return SaveCodeExerciseResult{}, courseError(err, "update code exercise block")
+	}
+
+	uc.recordChange(ctx, courseID, params.ActorID, params.AccountID, models.AuditActionContentUpdate, models.TrashItemBlock, params.BlockID, nil, nil)
+	return SaveCodeExerciseResult{BlockID: params.BlockID}, nil
+}
+
+// validateCodeExercise дополнительно проверяет, что раннер умеет запускать язык упражнения.
+// Без раннера язык не проверяется: упражнение можно подготовить до его настройки.
+func (uc *coursesUseCase) validateCodeExercise(e *codeexercise.Exercise) []codeexercise.Problem {
+	e.Normalize()
+	problems := e.Validate()
+	if uc.runner != nil && e.Language != "" && !slices.Contains(uc.runner.Languages(), e.Language) {
+		problems = append(problems, codeexercise.Problem{
+			Path:    "language",
+			Message: fmt.Sprintf("unsupported language %q, supported: %s", e.Language, strings.Join(uc.runner.Languages(), ", ")),
+		})
+	}
+	return problems
+}
+
+// CodeExerciseState - упражнение без скрытых тестов и последние решения вызывающего
+type CodeExerciseState struct {
+	BlockID     int64
+	Exercise    codeexercise.Exercise
+	Submissions []models.CodeSubmission
+}
+
+// GetCodeExercise возвращает упражнение участнику курса или администратору аккаунта
+func (uc *coursesUseCase) GetCodeExercise(ctx context.Context, params CourseItemParams) (CodeExerciseState, error) {
+	block, e, err := uc.publishedCodeExercise(ctx, params.ActorID, params.AccountID, params.ID)
+	if err != nil {
+		return CodeExerciseState{}, err
+	}
+	submissions, err := uc.pr.GetCodeSubmissions(ctx, block.ID, params.ActorID, maxShownCodeSubmissions)
+	if err != nil {
+		return CodeExerciseState{}, fmt.Errorf("failed to get code submissions: %w", err)
+	}
+	for i := range submissions {
+		submissions[i].Result = e.HideOutput(submissions[i].Result)
+	}
+	return CodeExerciseState{BlockID: block.ID, Exercise: e.WithoutHiddenTests(), Submissions: submissions}, nil
+}
+
+// publishedCodeExercise возвращает упражнение блока из опубликованной версии курса
+func (uc *coursesUseCase) publishedCodeExercise(ctx context.Context, actorID, accountID, blockID int64) (models.BlockSnapshot, codeexercise.Exercise, error) {
+	_, block, err := uc.publishedBlock(ctx, actorID, accountID, blockID, models.BlockTypeCodeExercise)
+	if err != nil {
+		return models.BlockSnapshot{}, codeexercise.Exercise{}, err
+	}
+	if block.CodeExercise == nil {
+		return models.BlockSnapshot{}, codeexercise.Exercise{}, uerrors.ErrBlockNotFound
+	}
+	return block, *block.CodeExercise, nil
+}
+
+type SubmitCodeParams struct {
+	ActorID   int64
+	AccountID int64
+	BlockID   int64
+	Code      string
+}
+
+type CodeSubmissionResult struct {
+	Submission models.CodeSubmission
+	Progress   models.BlockProgress
+}
+
+// SubmitCode запускает решение на тестах упражнения и сохраняет результат. Блок завершается
+// первым решением, набравшим проходной балл, в прохождении остаётся лучший результат.
+func (uc *coursesUseCase) SubmitCode(ctx context.Context, params SubmitCodeParams) (CodeSubmissionResult, error) {
+	block, e, err := uc.publishedCodeExercise(ctx, params.ActorID, params.AccountID, params.BlockID)
+	if err != nil {
+		return CodeSubmissionResult{}, err
+	}
+	if strings.TrimSpace(params.Code) == "" || len(params.Code) > codeexercise.MaxCodeLen {
+		return CodeSubmissionResult{}, uerrors.ErrInvalidCodeSubmission
+	}
+	if uc.runner == nil {
+		return CodeSubmissionResult{}, uerrors.ErrCodeRunnerUnavailable
+	}
+
+	run, err := uc.runner.Run(ctx, coderunner.Program{Language: e.Language, Code: params.Code}, e.Inputs(), e.Limits())
+	if err != nil {
+		if errors.Is(err, coderunner.ErrUnsupportedLanguage) || errors.Is(err, coderunner.ErrSandboxUnsupported) {
+			return CodeSubmissionResult{}, uerrors.ErrCodeRunnerUnavailable
+		}
+		return CodeSubmissionResult{}, fmt.Errorf("failed to run code exercise %d: %w", block.ID, err)
+	}
+	result := e.Grade(run)
+
+	submission, err := uc.pr.CreateCodeSubmission(ctx, repo.CreateCodeSubmissionParams{
+		BlockID:  block.ID,
+		UserID:   params.ActorID,
+		Language: e.Language,
+		Code:     params.Code,
+		Result:   result,
+	})
+	if err != nil {
+		if errors.Is(err, repo.ErrBlockNotFound) {
+			return CodeSubmissionResult{}, uerrors.ErrBlockNotFound
+		}
+		return CodeSubmissionResult{}, fmt.Errorf("failed to save code submission: %w", err)
+	}
+
+	progress, err := uc.pr.UpdateBlockProgress(ctx, repo.UpdateBlockProgressParams{
+		BlockID:   block.ID,
+		UserID:    params.ActorID,
+		Completed: result.Passed,
+		Score:     &result.Score,
+		KeepBest:  true,
+	})
+	if err != nil {
+		if errors.Is(err, repo.ErrBlockNotFound) {
+			return CodeSubmissionResult{}, uerrors.ErrBlockNotFound
+		}
+		return CodeSubmissionResult{}, fmt.Errorf("failed to update block progress: %w", err)
+	}
+
+	submission.Result = e.HideOutput(submission.Result)
+	return CodeSubmissionResult{Submission: submission, Progress: progress}, nil
+}
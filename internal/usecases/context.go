@@ -0,0 +1,39 @@
+package usecases
+
+import (
+	"chalk/internal/repo/models"
+	"context"
+)
+
+type ctxKey string
+
+const (
+	clientIPKey      ctxKey = "client_ip"
+	activeAccountKey ctxKey = "active_account"
+)
+
+// WithClientIP сохраняет IP клиента в контексте запроса, он попадает в журнал аудита
+func WithClientIP(ctx context.Context, ip string) context.Context {
+	return context.WithValue(ctx, clientIPKey, ip)
+}
+
+func clientIPFromContext(ctx context.Context) string {
+	ip, _ := ctx.Value(clientIPKey).(string)
+	return ip
+}
+
+// ActiveAccount - аккаунт, в котором выполняется запрос, и участие в нём вызывающего
+type ActiveAccount struct {
+	Account models.Account
+	Member  models.AccountMember
+}
+
+// WithActiveAccount сохраняет проверенное участие в контексте, чтобы проверки прав не повторяли запрос к базе
+func WithActiveAccount(ctx context.Context, aa ActiveAccount) context.Context {
+	return context.WithValue(ctx, activeAccountKey, aa)
+}
+
+func ActiveAccountFromContext(ctx context.Context) (ActiveAccount, bool) {
+	aa, ok := ctx.Value(activeAccountKey).(ActiveAccount)
+	return aa, ok
+}
@@ -0,0 +1,328 @@
+package usecases
+
+import (
+	uerrors "chalk/internal/errors"
+	"chalk/internal/repo"
+	"chalk/internal/repo/models"
+	"chalk/pkg/coursearchive"
+	"chalk/pkg/imspackage"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// ExportCourse пишет черновик курса вместе с файлами блоков в архив формата coursearchive.
+// Права проверяются до записи первого байта.
+func (uc *coursesUseCase) ExportCourse(ctx context.Context, params CourseItemParams, w io.Writer) error {
+	if err := uc.authorize(ctx, params.ActorID, params.AccountID, false); err != nil {
+		return err
+	}
+	if _, err := uc.checkItem(ctx, params.AccountID, models.TrashItemCourse, params.ID); err != nil {
+		return err
+	}
+
+	snapshot, err := uc.cr.GetCourseSnapshot(ctx, params.ID)
+	if err != nil {
+		return courseError(err, "get course snapshot")
+	}
+
+	aw := coursearchive.NewWriter(w)
+	added := make(map[int64]bool)
+	for _, m := range snapshot.Modules {
+		for _, l := range m.Lessons {
+			for _, b := range l.Blocks {
+				if b.FileID == nil || added[*b.FileID] {
+					continue
+				}
+				added[*b.FileID] = true
+				if err := uc.exportFile(ctx, aw, *b.FileID); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	if err := aw.Close(courseSnapshotToManifest(snapshot)); err != nil {
+		return fmt.Errorf("failed to write course archive: %w", err)
+	}
+	return nil
+}
+
+func (uc *coursesUseCase) exportFile(ctx context.Context, aw *coursearchive.Writer, fileID int64) error {
+	info, err := uc.fr.GetFileInfo(ctx, fileID)
+	if err != nil {
+		return fmt.Errorf("failed to get file %d: %w", fileID, err)
+	}
+	obj, err := uc.fr.GetFileByID(ctx, fileID)
+	if err != nil {
+		return fmt.Errorf("failed to get file %d object: %w", fileID, err)
+	}
+	defer obj.Close()
+
+	err = aw.AddFile(coursearchive.File{ID: info.ID, Name: info.Name, ContentType: info.ContentType}, obj)
+	if err != nil {
+		return fmt.Errorf("failed to write file %d: %w", fileID, err)
+	}
+	return nil
+}
+
+type ImportCourseParams struct {
+	ActorID   int64
+	AccountID int64
+	Archive   io.ReaderAt
+	Size      int64
+	// Name - название нового курса, пустое - название из архива
+	Name string
+}
+
+type CourseImportResult struct {
+	Course *models.Course
+	// Problems - ошибки архива, заполняются вместе с ErrInvalidCourseArchive
+	Problems []coursearchive.Problem
+	// Warnings - содержимое пакета, которое не удалось перенести в курс
+	Warnings []coursearchive.Problem
+}
+
+// ImportCourse создаёт новый курс из архива: загружает объекты файлов в хранилище
+// аккаунта и создаёт элементы курса с новыми идентификаторами.
+func (uc *coursesUseCase) ImportCourse(ctx context.Context, params ImportCourseParams) (CourseImportResult, error) {
+	if err := uc.authorize(ctx, params.ActorID, params.AccountID, true); err != nil {
+		return CourseImportResult{}, err
+	}
+	if err := uc.checkCourseLimit(ctx, params.AccountID); err != nil {
+		return CourseImportResult{}, err
+	}
+
+	archive, err := coursearchive.Open(params.Archive, params.Size)
+	if err != nil {
+		return invalidArchiveResult(err, "course archive")
+	}
+
+	return uc.importManifest(ctx, params, archive.Manifest, archive,
+		map[string]any{"archive_version": archive.Manifest.Version, "source_course_id": archive.Manifest.Course.ID})
+}
+
+// ImportPackage создаёт новый курс из пакета IMS Common Cartridge или SCORM.
+// Что не удалось перенести, возвращается в Warnings, курс при этом создаётся.
+func (uc *coursesUseCase) ImportPackage(ctx context.Context, params ImportCourseParams) (CourseImportResult, error) {
+	if err := uc.authorize(ctx, params.ActorID, params.AccountID, true); err != nil {
+		return CourseImportResult{}, err
+	}
+	if err := uc.checkCourseLimit(ctx, params.AccountID); err != nil {
+		return CourseImportResult{}, err
+	}
+
+	pkg, err := imspackage.Open(params.Archive, params.Size)
+	if err != nil {
+		return invalidArchiveResult(err, "package")
+	}
+
+	res, err := uc.importManifest(ctx, params, pkg.Manifest, pkg,
+		map[string]any{"package": pkg.Kind, "skipped": len(pkg.Report)})
+	if err != nil {
+		return res, err
+	}
+	res.Warnings = pkg.Report
+	return res, nil
+}
+
+func invalidArchiveResult(err error, what string) (CourseImportResult, error) {
+	var verr *coursearchive.ValidationError
+	if errors.As(err, &verr) {
+		return CourseImportResult{Problems: verr.Problems}, uerrors.ErrInvalidCourseArchive
+	}
+	return CourseImportResult{}, fmt.Errorf("failed to open %s: %w", what, err)
+}
+
+// archiveFiles - источник объектов файлов, перечисленных в манифесте
+type archiveFiles interface {
+	OpenFile(f coursearchive.File) (io.ReadCloser, error)
+}
+
+// importManifest загружает файлы манифеста и создаёт по нему курс. При ошибке
+// загруженные файлы удаляются.
+func (uc *coursesUseCase) importManifest(ctx context.Context, params ImportCourseParams, manifest coursearchive.Manifest,
+	files archiveFiles, audit map[string]any) (CourseImportResult, error) {
+	name := manifest.Course.Name
+	if params.Name != "" {
+		name = params.Name
+	}
+	name, err := normalizeItemName(name)
+	if err != nil {
+		return CourseImportResult{}, err
+	}
+
+	var uploaded []copiedFile
+	fileIDs := make(map[int64]int64, len(manifest.Files))
+	for i, f := range manifest.Files {
+		file, err := uc.importFile(ctx, files, f, params.ActorID, params.AccountID)
+		if err != nil {
+			uc.discardFiles(ctx, uploaded)
+			if errors.Is(err, coursearchive.ErrChecksumMismatch) {
+				return CourseImportResult{Problems: []coursearchive.Problem{{
+					Path:    fmt.Sprintf("files[%d].sha256", i),
+					Message: fmt.Sprintf("does not match the content of %s", f.Path),
+				}}}, uerrors.ErrInvalidCourseArchive
+			}
+			return CourseImportResult{}, err
+		}
+		uploaded = append(uploaded, copiedFile{SourceID: f.ID, File: file})
+		fileIDs[f.ID] = file.ID
+	}
+
+	courseID, err := uc.cr.CreateCourseFromSnapshot(ctx, repo.CreateCourseFromSnapshotParams{
+		AccountID: params.AccountID,
+		Name:      name,
+		Snapshot:  manifestToCourseSnapshot(manifest),
+		FileIDs:   fileIDs,
+	})
+	if err != nil {
+		uc.discardFiles(ctx, uploaded)
+		if errors.Is(err, repo.ErrAccountNotFound) {
+			return CourseImportResult{}, uerrors.ErrAccountNotFound
+		}
+		return CourseImportResult{}, courseError(err, "create imported course")
+	}
+
+	audit["name"] = name
+	uc.recordChange(ctx, courseID, params.ActorID, params.AccountID, models.AuditActionCourseImport, models.TrashItemCourse, courseID, nil, audit)
+	return CourseImportResult{Course: &models.Course{ID: courseID, AccountID: params.AccountID, Name: name}}, nil
+}
+
+func (uc *coursesUseCase) importFile(ctx context.Context, files archiveFiles, f coursearchive.File, actorID, accountID int64) (models.File, error) {
+	obj, err := files.OpenFile(f)
+	if err != nil {
+		return models.File{}, fmt.Errorf("failed to open archive file %s: %w", f.Path, err)
+	}
+	defer obj.Close()
+
+	// хранилище может не сохранить исходную ошибку чтения, поэтому она запоминается здесь
+	src := &readErrRecorder{r: obj}
+	file, err := uc.fr.UploadFile(ctx, repo.UploadFileParams{
+		Reader:         src,
+		UploaderUserID: actorID,
+		AccountID:      accountID,
+		DefaultQuota:   uc.defaultQuota,
+		Name:           f.Name,
+		ContentType:    f.ContentType,
+		Size:           f.Size,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(src.err, coursearchive.ErrChecksumMismatch):
+			return models.File{}, src.err
+		case errors.Is(err, repo.ErrStorageQuotaExceeded):
+			return models.File{}, uerrors.ErrStorageQuotaExceeded
+		case errors.Is(err, repo.ErrAccountNotFound):
+			return models.File{}, uerrors.ErrAccountNotFound
+		case errors.Is(err, repo.ErrUserNotFound):
+			return models.File{}, uerrors.ErrUserNotFound
+		}
+		return models.File{}, fmt.Errorf("failed to upload archive file %s: %w", f.Path, err)
+	}
+	return file, nil
+}
+
+type readErrRecorder struct {
+	r   io.Reader
+	err error
+}
+
+func (r *readErrRecorder) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	if err != nil && !errors.Is(err, io.EOF) {
+		r.err = err
+	}
+	return n, err
+}
+
+func courseSnapshotToManifest(s *models.CourseSnapshot) coursearchive.Manifest {
+	m := coursearchive.Manifest{
+		ExportedAt: time.Now().UTC(),
+		Course: coursearchive.Course{
+			ID:      s.ID,
+			Name:    s.Name,
+			Modules: make([]coursearchive.Module, 0, len(s.Modules)),
+		},
+	}
+	for _, mod := range s.Modules {
+		am := coursearchive.Module{
+			ID:       mod.ID,
+			OrderIdx: mod.OrderIdx,
+			Name:     mod.Name,
+			Lessons:  make([]coursearchive.Lesson, 0, len(mod.Lessons)),
+		}
+		for _, l := range mod.Lessons {
+			al := coursearchive.Lesson{
+				ID:       l.ID,
+				OrderIdx: l.OrderIdx,
+				Name:     l.Name,
+				Blocks:   make([]coursearchive.Block, 0, len(l.Blocks)),
+			}
+			for _, b := range l.Blocks {
+				al.Blocks = append(al.Blocks, coursearchive.Block{
+					ID:           b.ID,
+					OrderIdx:     b.OrderIdx,
+					Type:         string(b.Type),
+					Content:      b.Content,
+					FileID:       b.FileID,
+					Quiz:         b.Quiz,
+					Assignment:   b.Assignment,
+					CodeExercise: b.CodeExercise,
+					AltText:      b.AltText,
+					Caption:      b.Caption,
+					Title:        b.Title,
+					Embed:        b.Embed,
+				})
+			}
+			am.Lessons = append(am.Lessons, al)
+		}
+		m.Course.Modules = append(m.Course.Modules, am)
+	}
+	return m
+}
+
+func manifestToCourseSnapshot(m coursearchive.Manifest) *models.CourseSnapshot {
+	s := &models.CourseSnapshot{
+		ID:      m.Course.ID,
+		Name:    m.Course.Name,
+		Modules: make([]models.ModuleSnapshot, 0, len(m.Course.Modules)),
+	}
+	for _, mod := range m.Course.Modules {
+		ms := models.ModuleSnapshot{
+			ID:       mod.ID,
+			OrderIdx: mod.OrderIdx,
+			Name:     mod.Name,
+			Lessons:  make([]models.LessonSnapshot, 0, len(mod.Lessons)),
+		}
+		for _, l := range mod.Lessons {
+			ls := models.LessonSnapshot{
+				ID:       l.ID,
+				OrderIdx: l.OrderIdx,
+				Name:     l.Name,
+				Blocks:   make([]models.BlockSnapshot, 0, len(l.Blocks)),
+			}
+			for _, b := range l.Blocks {
+				ls.Blocks = append(ls.Blocks, models.BlockSnapshot{
+					ID:           b.ID,
+					OrderIdx:     b.OrderIdx,
+					Type:         models.BlockType(b.Type),
+					Content:      b.Content,
+					FileID:       b.FileID,
+					Quiz:         b.Quiz,
+					Assignment:   b.Assignment,
+					CodeExercise: b.CodeExercise,
+					AltText:      b.AltText,
+					Caption:      b.Caption,
+					Title:        b.Title,
+					Embed:        b.Embed,
+				})
+			}
+			ms.Lessons = append(ms.Lessons, ls)
+		}
+		s.Modules = append(s.Modules, ms)
+	}
+	return s
+}
@@ -0,0 +1,139 @@
+package usecases
+
+import (
+	uerrors "chalk/internal/errors"
+	"chalk/internal/repo"
+	"chalk/internal/repo/models"
+	"chalk/pkg/log"
+	"context"
+	"errors"
+	"fmt"
+)
+
+type DuplicateCourseParams struct {
+	ActorID   int64
+	AccountID int64
+	CourseID  int64
+	// TargetAccountID - аккаунт копии, 0 - аккаунт исходного курса
+	TargetAccountID int64
+	// Name - название копии, пустое - название исходного курса с пометкой о копии
+	Name string
+	// CopyFiles - копировать объекты видео вместо ссылок на те же файлы.
+	// При копировании в другой аккаунт файлы копируются всегда: они засчитываются в его квоту.
+	CopyFiles bool
+}
+
+// DuplicateCourse создаёт глубокую копию черновика курса. Вызывающий должен быть
+// админом и аккаунта исходного курса, и аккаунта копии.
+func (uc *coursesUseCase) DuplicateCourse(ctx context.Context, params DuplicateCourseParams) (*models.Course, error) {
+	if err := uc.authorize(ctx, params.ActorID, params.AccountID, false); err != nil {
+		return nil, err
+	}
+	if _, err := uc.checkItem(ctx, params.AccountID, models.TrashItemCourse, params.CourseID); err != nil {
+		return nil, err
+	}
+
+	targetAccountID := params.TargetAccountID
+	if targetAccountID == 0 {
+		targetAccountID = params.AccountID
+	}
+	if err := uc.authorize(ctx, params.ActorID, targetAccountID, true); err != nil {
+		return nil, err
+	}
+	if err := uc.checkCourseLimit(ctx, targetAccountID); err != nil {
+		return nil, err
+	}
+
+	snapshot, err := uc.cr.GetCourseSnapshot(ctx, params.CourseID)
+	if err != nil {
+		return nil, courseError(err, "get course snapshot")
+	}
+
+	name := snapshot.Name + " (copy)"
+	if params.Name != "" {
+		if name, err = normalizeItemName(params.Name); err != nil {
+			return nil, err
+		}
+	}
+
+	var copies []copiedFile
+	fileIDs := make(map[int64]int64)
+	if params.CopyFiles || targetAccountID != params.AccountID {
+		copies, err = uc.copySnapshotFiles(ctx, snapshot, params.ActorID, targetAccountID)
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range copies {
+			fileIDs[f.SourceID] = f.File.ID
+		}
+	}
+
+	courseID, err := uc.cr.CreateCourseFromSnapshot(ctx, repo.CreateCourseFromSnapshotParams{
+		AccountID: targetAccountID,
+		Name:      name,
+		Snapshot:  snapshot,
+		FileIDs:   fileIDs,
+	})
+	if err != nil {
+		uc.discardFiles(ctx, copies)
+		if errors.Is(err, repo.ErrAccountNotFound) {
+			return nil, uerrors.ErrAccountNotFound
+		}
+		return nil, courseError(err, "create course copy")
+	}
+
+	uc.recordChange(ctx, courseID, params.ActorID, targetAccountID, models.AuditActionCourseDuplicate, models.TrashItemCourse, courseID,
+		nil, map[string]any{"name": name, "source_account_id": params.AccountID, "source_course_id": params.CourseID})
+	return &models.Course{ID: courseID, AccountID: targetAccountID, Name: name}, nil
+}
+
+type copiedFile struct {
+	SourceID int64
+	File     models.File
+}
+
+// copySnapshotFiles копирует файлы блоков курса в аккаунт. При ошибке уже созданные копии удаляются.
+func (uc *coursesUseCase) copySnapshotFiles(ctx context.Context, snapshot *models.CourseSnapshot, actorID, accountID int64) ([]copiedFile, error) {
+	var copies []copiedFile
+	seen := make(map[int64]bool)
+	for _, m := range snapshot.Modules {
+		for _, l := range m.Lessons {
+			for _, b := range l.Blocks {
+				if b.FileID == nil || seen[*b.FileID] {
+					continue
+				}
+				seen[*b.FileID] = true
+
+				file, err := uc.fr.CopyFile(ctx, repo.CopyFileParams{
+					FileID:         *b.FileID,
+					UploaderUserID: actorID,
+					AccountID:      accountID,
+					DefaultQuota:   uc.defaultQuota,
+				})
+				if err != nil {
+					uc.discardFiles(ctx, copies)
+					switch {
+					case errors.Is(err, repo.ErrFileNotFound):
+						return nil, uerrors.ErrFileNotFound
+					case errors.Is(err, repo.ErrStorageQuotaExceeded):
+						return nil, uerrors.ErrStorageQuotaExceeded
+					case errors.Is(err, repo.ErrAccountNotFound):
+						return nil, uerrors.ErrAccountNotFound
+					}
+					return nil, fmt.Errorf("failed to copy file %d: %w", *b.FileID, err)
+				}
+				copies = append(copies, copiedFile{SourceID: *b.FileID, File: file})
+			}
+		}
+	}
+	return copies, nil
+}
+
+// discardFiles удаляет копии файлов неудавшейся операции. Ошибка только логируется.
+func (uc *coursesUseCase) discardFiles(ctx context.Context, copies []copiedFile) {
+	for _, f := range copies {
+		if err := uc.fr.DiscardFile(ctx, f.File); err != nil {
+			log.Errorf("discard copied file %d: %v", f.File.ID, err)
+		}
+	}
+}
@@ -0,0 +1,297 @@
+package usecases
+
+import (
+	uerrors "chalk/internal/errors"
+	"chalk/internal/repo"
+	"chalk/internal/repo/models"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// GetDraft возвращает черновик курса в том виде, в котором он будет опубликован
+func (uc *coursesUseCase) GetDraft(ctx context.Context, params CourseItemParams) (*models.CourseSnapshot, error) {
+	if err := uc.authorize(ctx, params.ActorID, params.AccountID, false); err != nil {
+		return nil, err
+	}
+	if _, err := uc.checkItem(ctx, params.AccountID, models.TrashItemCourse, params.ID); err != nil {
+		return nil, err
+	}
+	return uc.loadDraft(ctx, params.ID)
+}
+
+func (uc *coursesUseCase) PublishCourse(ctx context.Context, params CourseItemParams) (models.CourseVersion, error) {
+	courseID, err := uc.authorizeItem(ctx, params.ActorID, params.AccountID, models.TrashItemCourse, params.ID)
+	if err != nil {
+		return models.CourseVersion{}, err
+	}
+
+	draft, err := uc.loadDraft(ctx, courseID)
+	if err != nil {
+		return models.CourseVersion{}, err
+	}
+	published, err := uc.loadPublished(ctx, courseID)
+	if err != nil {
+		return models.CourseVersion{}, err
+	}
+	if published != nil && len(diffCourseSnapshots(published, draft)) == 0 {
+		return models.CourseVersion{}, uerrors.ErrNoChangesToPublish
+	}
+
+	version, err := uc.vr.PublishCourseVersion(ctx, repo.PublishCourseVersionParams{
+		CourseID:    courseID,
+		Snapshot:    draft,
+		PublishedBy: params.ActorID,
+		PublishedAt: time.Now(),
+	})
+	if err != nil {
+		return models.CourseVersion{}, courseError(err, "publish course")
+	}
+
+	uc.recordChange(ctx, courseID, params.ActorID, params.AccountID, models.AuditActionCoursePublish, models.TrashItemCourse, courseID,
+		nil, map[string]any{"version": version.Version})
+	return version, nil
+}
+
+func (uc *coursesUseCase) GetCourseVersions(ctx context.Context, params CourseItemParams) ([]models.CourseVersion, error) {
+	if err := uc.authorize(ctx, params.ActorID, params.AccountID, false); err != nil {
+		return nil, err
+	}
+	if _, err := uc.checkItem(ctx, params.AccountID, models.TrashItemCourse, params.ID); err != nil {
+		return nil, err
+	}
+
+	versions, err := uc.vr.GetCourseVersions(ctx, params.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get course versions: %w", err)
+	}
+	return versions, nil
+}
+
+// GetCourseChanges возвращает отличия черновика от версии, которую видят участники
+func (uc *coursesUseCase) GetCourseChanges(ctx context.Context, params CourseItemParams) ([]models.CourseChange, error) {
+	if err := uc.authorize(ctx, params.ActorID, params.AccountID, false); err != nil {
+		return nil, err
+	}
+	if _, err := uc.checkItem(ctx, params.AccountID, models.TrashItemCourse, params.ID); err != nil {
+		return nil, err
+	}
+
+	draft, err := uc.loadDraft(ctx, params.ID)
+	if err != nil {
+		return nil, err
+	}
+	published, err := uc.loadPublished(ctx, params.ID)
+	if err != nil {
+		return nil, err
+	}
+	return diffCourseSnapshots(published, draft), nil
+}
+
+type RollbackCourseParams struct {
+	ActorID   int64
+	AccountID int64
+	CourseID  int64
+	Version   int
+}
+
+// RollbackCourse возвращает участникам одну из прошлых версий, черновик не меняется
+func (uc *coursesUseCase) RollbackCourse(ctx context.Context, params RollbackCourseParams) error {
+	courseID, err := uc.authorizeItem(ctx, params.ActorID, params.AccountID, models.TrashItemCourse, params.CourseID)
+	if err != nil {
+		return err
+	}
+
+	var before *int
+	if published, err := uc.vr.GetPublishedCourseVersion(ctx, courseID); err == nil {
+		before = &published.Version
+	} else if !errors.Is(err, repo.ErrCourseNotPublished) {
+		return fmt.Errorf("failed to get published version: %w", err)
+	}
+
+	if err := uc.vr.SetPublishedCourseVersion(ctx, courseID, params.Version); err != nil {
+		return courseError(err, "rollback course")
+	}
+
+	uc.recordChange(ctx, courseID, params.ActorID, params.AccountID, models.AuditActionCourseRollback, models.TrashItemCourse, courseID,
+		map[string]any{"version": before}, map[string]any{"version": params.Version})
+	return nil
+}
+
+// GetPublishedCourse возвращает версию курса для участников: админам и записанным на курс
+func (uc *coursesUseCase) GetPublishedCourse(ctx context.Context, params CourseItemParams) (models.CourseVersion, error) {
+	role, err := getAccountRole(ctx, uc.ar, params.ActorID, params.AccountID)
+	if err != nil {
+		return models.CourseVersion{}, err
+	}
+	if _, err := uc.checkItem(ctx, params.AccountID, models.TrashItemCourse, params.ID); err != nil {
+		return models.CourseVersion{}, err
+	}
+	if role != models.AccountMembersRoleOwner && role != models.AccountMembersRoleAdmin {
+		enrolled, err := uc.cr.IsUserEnrolled(ctx, params.ActorID, params.ID)
+		if err != nil {
+			return models.CourseVersion{}, fmt.Errorf("failed to check enrollment: %w", err)
+		}
+		if !enrolled {
+			// курс, на который участник не записан, для него не существует
+			return models.CourseVersion{}, uerrors.ErrCourseNotFound
+		}
+	}
+
+	version, err := uc.vr.GetPublishedCourseVersion(ctx, params.ID)
+	if err != nil {
+		return models.CourseVersion{}, courseError(err, "get published course")
+	}
+	return version, nil
+}
+
+func (uc *coursesUseCase) loadDraft(ctx context.Context, courseID int64) (*models.CourseSnapshot, error) {
+	draft, err := uc.cr.GetCourseSnapshot(ctx, courseID)
+	if err != nil {
+		return nil, courseError(err, "get course draft")
+	}
+	return draft, nil
+}
+
+// loadPublished возвращает снимок текущей версии, nil если курс не опубликован
+func (uc *coursesUseCase) loadPublished(ctx context.Context, courseID int64) (*models.CourseSnapshot, error) {
+	version, err := uc.vr.GetPublishedCourseVersion(ctx, courseID)
+	if err != nil {
+		if errors.Is(err, repo.ErrCourseNotPublished) {
+			return nil, nil
+		}
+		return nil, courseError(err, "get published course")
+	}
+	return version.Snapshot, nil
+}
+
+/* ===================== Diff ===================== */
+
+type snapshotItemKey struct {
+	itemType models.TrashItemType
+	id       int64
+}
+
+// snapshotItem - элемент снимка без вложенных элементов
+type snapshotItem struct {
+	key      snapshotItemKey
+	name     string
+	parentID int64
+	orderIdx int
+	content  string
+	fileID   int64
+	// quiz - тест в JSON
+	quiz string
+	// assignment - задание в JSON
+	assignment string
+	// codeExercise - упражнение с кодом в JSON
+	codeExercise string
+	// altText, caption, title и embedURL - у картинок, вложений и встроенных страниц
+	altText  string
+	caption  string
+	title    string
+	embedURL string
+}
+
+func flattenCourseSnapshot(s *models.CourseSnapshot) []snapshotItem {
+	if s == nil {
+		return nil
+	}
+	items := []snapshotItem{{key: snapshotItemKey{models.TrashItemCourse, s.ID}, name: s.Name}}
+	for _, m := range s.Modules {
+		items = append(items, snapshotItem{
+			key:      snapshotItemKey{models.TrashItemModule, m.ID},
+			name:     m.Name,
+			parentID: s.ID,
+			orderIdx: m.OrderIdx,
+		})
+		for _, l := range m.Lessons {
+			items = append(items, snapshotItem{
+				key:      snapshotItemKey{models.TrashItemLesson, l.ID},
+				name:     l.Name,
+				parentID: m.ID,
+				orderIdx: l.OrderIdx,
+			})
+			for _, b := range l.Blocks {
+				it := snapshotItem{
+					key:      snapshotItemKey{models.TrashItemBlock, b.ID},
+					name:     string(b.Type),
+					parentID: l.ID,
+					orderIdx: b.OrderIdx,
+				}
+				if b.Content != nil {
+					it.content = *b.Content
+				}
+				if b.FileID != nil {
+					it.fileID = *b.FileID
+				}
+				if b.Quiz != nil {
+					// тест из снимка всегда сериализуется, он уже был прочитан из JSON
+					data, _ := json.Marshal(b.Quiz)
+					it.quiz = string(data)
+				}
+				if b.Assignment != nil {
+					data, _ := json.Marshal(b.Assignment)
+					it.assignment = string(data)
+				}
+				if b.CodeExercise != nil {
+					data, _ := json.Marshal(b.CodeExercise)
+					it.codeExercise = string(data)
+				}
+				it.altText, it.caption, it.title = b.AltText, b.Caption, b.Title
+				if b.Embed != nil {
+					it.embedURL = b.Embed.URL
+				}
+				items = append(items, it)
+			}
+		}
+	}
+	return items
+}
+
+// diffCourseSnapshots сравнивает снимки поэлементно. Изменение содержимого
+// важнее перемещения, поэтому изменённый и перемещённый элемент помечается modified.
+func diffCourseSnapshots(from, to *models.CourseSnapshot) []models.CourseChange {
+	oldItems := flattenCourseSnapshot(from)
+	oldByKey := make(map[snapshotItemKey]snapshotItem, len(oldItems))
+	for _, it := range oldItems {
+		oldByKey[it.key] = it
+	}
+
+	changes := []models.CourseChange{}
+	seen := make(map[snapshotItemKey]struct{})
+	for _, it := range flattenCourseSnapshot(to) {
+		seen[it.key] = struct{}{}
+		change := models.CourseChange{ItemType: it.key.itemType, ID: it.key.id, Name: it.name}
+
+		prev, ok := oldByKey[it.key]
+		switch {
+		case !ok:
+			change.Change = models.CourseChangeAdded
+		case prev.name != it.name || prev.content != it.content || prev.fileID != it.fileID || prev.quiz != it.quiz ||
+			prev.assignment != it.assignment || prev.codeExercise != it.codeExercise ||
+			prev.altText != it.altText || prev.caption != it.caption || prev.title != it.title || prev.embedURL != it.embedURL:
+			change.Change = models.CourseChangeModified
+		case prev.parentID != it.parentID || prev.orderIdx != it.orderIdx:
+			change.Change = models.CourseChangeMoved
+		default:
+			continue
+		}
+		changes = append(changes, change)
+	}
+
+	for _, it := range oldItems {
+		if _, ok := seen[it.key]; ok {
+			continue
+		}
+		changes = append(changes, models.CourseChange{
+			ItemType: it.key.itemType,
+			ID:       it.key.id,
+			Name:     it.name,
+			Change:   models.CourseChangeRemoved,
+		})
+	}
+	return changes
+}
@@ -0,0 +1,974 @@
+package usecases
+
+import (
+	uerrors "chalk/internal/errors"
+	"chalk/internal/repo"
+	"chalk/internal/repo/models"
+	"chalk/pkg/coderunner"
+	"chalk/pkg/log"
+	"chalk/pkg/textdiff"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+type CoursesUseCase interface {
+	GetCourses(ctx context.Context, params GetCoursesParams) ([]*models.Course, error)
+	GetCourse(ctx context.Context, params CourseItemParams) (*models.Course, error)
+	GetCourseTree(ctx context.Context, params CourseItemParams) (*models.CourseHierarchy, error)
+	CreateCourse(ctx context.Context, params CreateCourseParams) (*models.Course, error)
+	DuplicateCourse(ctx context.Context, params DuplicateCourseParams) (*models.Course, error)
+	ExportCourse(ctx context.Context, params CourseItemParams, w io.Writer) error
+	ImportCourse(ctx context.Context, params ImportCourseParams) (CourseImportResult, error)
+	ImportPackage(ctx context.Context, params ImportCourseParams) (CourseImportResult, error)
+	SyncMarkdownCourse(ctx context.Context, params SyncMarkdownCourseParams) (MarkdownSyncResult, error)
+	UpdateCourse(ctx context.Context, params RenameCourseItemParams) error
+	RemoveCourse(ctx context.Context, params CourseItemParams) error
+
+	GetModules(ctx context.Context, params CourseItemParams) ([]*models.Module, error)
+	CreateModule(ctx context.Context, params CreateModuleParams) (*models.Module, error)
+	UpdateModule(ctx context.Context, params RenameCourseItemParams) error
+	ReorderModule(ctx context.Context, params ReorderCourseItemParams) error
+	ReorderModules(ctx context.Context, params ReorderChildrenParams) error
+	RemoveModule(ctx context.Context, params CourseItemParams) error
+
+	GetLessons(ctx context.Context, params CourseItemParams) ([]*models.Lesson, error)
+	CreateLesson(ctx context.Context, params CreateLessonParams) (*models.Lesson, error)
+	UpdateLesson(ctx context.Context, params RenameCourseItemParams) error
+	ReorderLesson(ctx context.Context, params ReorderCourseItemParams) error
+	ReorderLessons(ctx context.Context, params ReorderChildrenParams) error
+	MoveLesson(ctx context.Context, params MoveCourseItemParams) error
+	RemoveLesson(ctx context.Context, params CourseItemParams) error
+
+	GetBlocks(ctx context.Context, params CourseItemParams) ([]any, error)
+	CreateTextBlock(ctx context.Context, params CreateTextBlockParams) (int64, error)
+	CreateVideoBlock(ctx context.Context, params CreateVideoBlockParams) (int64, error)
+	CreateScormBlock(ctx context.Context, params CreateScormBlockParams) (int64, error)
+	CreateQuizBlock(ctx context.Context, params CreateQuizBlockParams) (SaveQuizResult, error)
+	UpdateTextBlock(ctx context.Context, params UpdateTextBlockParams) error
+	UpdateQuizBlock(ctx context.Context, params UpdateQuizBlockParams) (SaveQuizResult, error)
+	CreateAssignmentBlock(ctx context.Context, params CreateAssignmentBlockParams) (SaveAssignmentResult, error)
+	UpdateAssignmentBlock(ctx context.Context, params UpdateAssignmentBlockParams) (SaveAssignmentResult, error)
+	CreateCodeExerciseBlock(ctx context.Context, params CreateCodeExerciseBlockParams) (SaveCodeExerciseResult, error)
+	UpdateCodeExerciseBlock(ctx context.Context, params UpdateCodeExerciseBlockParams) (SaveCodeExerciseResult, error)
+	CreateImageBlock(ctx context.Context, params CreateImageBlockParams) (int64, error)
+	CreateAttachmentBlock(ctx context.Context, params CreateAttachmentBlockParams) (int64, error)
+	CreateEmbedBlock(ctx context.Context, params CreateEmbedBlockParams) (int64, error)
+	ReorderBlock(ctx context.Context, params ReorderCourseItemParams) error
+	ReorderBlocks(ctx context.Context, params ReorderChildrenParams) error
+	MoveBlock(ctx context.Context, params MoveCourseItemParams) error
+	RemoveBlock(ctx context.Context, params CourseItemParams) error
+
+	GetDraft(ctx context.Context, params CourseItemParams) (*models.CourseSnapshot, error)
+	PublishCourse(ctx context.Context, params CourseItemParams) (models.CourseVersion, error)
+	GetCourseVersions(ctx context.Context, params CourseItemParams) ([]models.CourseVersion, error)
+	GetCourseChanges(ctx context.Context, params CourseItemParams) ([]models.CourseChange, error)
+	RollbackCourse(ctx context.Context, params RollbackCourseParams) error
+	GetPublishedCourse(ctx context.Context, params CourseItemParams) (models.CourseVersion, error)
+
+	LaunchScorm(ctx context.Context, params CourseItemParams) (ScormLaunch, error)
+	OpenScormFile(ctx context.Context, sessionID, name string) (ScormFile, error)
+	GetScormRuntime(ctx context.Context, sessionID string) (ScormRuntime, error)
+	CommitScormRuntime(ctx context.Context, params CommitScormRuntimeParams) (models.BlockProgress, error)
+	GetCourseProgress(ctx context.Context, params CourseItemParams) ([]models.BlockProgress, error)
+	GetQuiz(ctx context.Context, params CourseItemParams) (QuizState, error)
+	SubmitQuizAttempt(ctx context.Context, params SubmitQuizAttemptParams) (QuizAttemptResult, error)
+	GetAssignment(ctx context.Context, params CourseItemParams) (AssignmentState, error)
+	SubmitAssignment(ctx context.Context, params SubmitAssignmentParams) (models.Submission, error)
+	GetCodeExercise(ctx context.Context, params CourseItemParams) (CodeExerciseState, error)
+	SubmitCode(ctx context.Context, params SubmitCodeParams) (CodeSubmissionResult, error)
+	OpenImage(ctx context.Context, params CourseItemParams) (models.File, io.ReadCloser, error)
+	OpenAttachment(ctx context.Context, params CourseItemParams) (models.File, io.ReadCloser, error)
+	GetSubmissionQueue(ctx context.Context, params GetSubmissionQueueParams) ([]models.Submission, error)
+	GetSubmission(ctx context.Context, params CourseItemParams) (models.Submission, error)
+	GradeSubmission(ctx context.Context, params GradeSubmissionParams) (GradeSubmissionResult, error)
+	ReturnSubmission(ctx context.Context, params ReturnSubmissionParams) (models.Submission, error)
+	OpenSubmissionFile(ctx context.Context, params OpenSubmissionFileParams) (models.File, io.ReadCloser, error)
+
+	GetRevisions(ctx context.Context, params GetRevisionsParams) (RevisionsPage, error)
+	DiffRevisions(ctx context.Context, params DiffRevisionsParams) ([]textdiff.Line, error)
+	RestoreRevision(ctx context.Context, params RestoreRevisionParams) error
+}
+
+func NewCoursesUseCase(
+	cr repo.CoursesRepo,
+	ar repo.AccountsRepo,
+	br repo.BillingRepo,
+	fr repo.FilesRepo,
+	aur repo.AuditRepo,
+	tc repo.CourseTreeCacheRepo,
+	vr repo.CourseVersionsRepo,
+	rr repo.RevisionsRepo,
+	pr repo.ProgressRepo,
+	ssr repo.ScormSessionRepo,
+	ur repo.UsersRepo,
+	csr repo.CourseSourcesRepo,
+	asr repo.AssignmentsRepo,
+	runner coderunner.Runner,
+	treeCacheTTL time.Duration,
+	defaultQuota int64,
+) CoursesUseCase {
+	return &coursesUseCase{
+		cr:           cr,
+		vr:           vr,
+		rr:           rr,
+		pr:           pr,
+		ssr:          ssr,
+		ur:           ur,
+		csr:          csr,
+		asr:          asr,
+		runner:       runner,
+		ar:           ar,
+		br:           br,
+		fr:           fr,
+		aur:          aur,
+		tc:           tc,
+		treeCacheTTL: treeCacheTTL,
+		defaultQuota: defaultQuota,
+	}
+}
+
+type coursesUseCase struct {
+	cr  repo.CoursesRepo
+	ar  repo.AccountsRepo
+	br  repo.BillingRepo
+	fr  repo.FilesRepo
+	aur repo.AuditRepo
+	tc  repo.CourseTreeCacheRepo
+	vr  repo.CourseVersionsRepo
+	rr  repo.RevisionsRepo
+	pr  repo.ProgressRepo
+	ssr repo.ScormSessionRepo
+	ur  repo.UsersRepo
+	csr repo.CourseSourcesRepo
+	asr repo.AssignmentsRepo
+	// runner запускает решения упражнений с кодом, nil - запуск недоступен
+	runner coderunner.Runner
+
+	// treeCacheTTL - время жизни дерева курса в кэше, 0 - не кэшировать
+	treeCacheTTL time.Duration
+	// defaultQuota - квота аккаунта на хранилище для копий файлов, 0 - без ограничений
+	defaultQuota int64
+}
+
+type GetCoursesParams struct {
+	ActorID   int64
+	AccountID int64
+}
+
+// CourseItemParams указывает на курс, модуль, урок или блок аккаунта
+type CourseItemParams struct {
+	ActorID   int64
+	AccountID int64
+	ID        int64
+}
+
+type RenameCourseItemParams struct {
+	ActorID   int64
+	AccountID int64
+	ID        int64
+	Name      string
+}
+
+// ReorderChildrenParams задаёт новый порядок всех дочерних элементов ParentID
+type ReorderChildrenParams struct {
+	ActorID   int64
+	AccountID int64
+	ParentID  int64
+	IDs       []int64
+}
+
+// MoveCourseItemParams переносит урок в модуль или блок в урок ParentID
+type MoveCourseItemParams struct {
+	ActorID   int64
+	AccountID int64
+	ID        int64
+	ParentID  int64
+	// OrderIdx - позиция в новом родителе, nil - в конец
+	OrderIdx *int
+}
+
+type ReorderCourseItemParams struct {
+	ActorID   int64
+	AccountID int64
+	ID        int64
+	OrderIdx  int
+}
+
+/* ===================== Courses ===================== */
+
+func (uc *coursesUseCase) GetCourses(ctx context.Context, params GetCoursesParams) ([]*models.Course, error) {
+	if err := uc.authorize(ctx, params.ActorID, params.AccountID, false); err != nil {
+		return nil, err
+	}
+
+	courses, err := uc.cr.GetCoursesByAccountID(ctx, params.AccountID)
+	if err != nil {
+		if errors.Is(err, repo.ErrAccountNotFound) {
+			return nil, uerrors.ErrAccountNotFound
+		}
+		return nil, fmt.Errorf("failed to get courses: %w", err)
+	}
+	return courses, nil
+}
+
+func (uc *coursesUseCase) GetCourse(ctx context.Context, params CourseItemParams) (*models.Course, error) {
+	if err := uc.authorize(ctx, params.ActorID, params.AccountID, false); err != nil {
+		return nil, err
+	}
+	if _, err := uc.checkItem(ctx, params.AccountID, models.TrashItemCourse, params.ID); err != nil {
+		return nil, err
+	}
+
+	course, err := uc.cr.GetCourseByID(ctx, params.ID)
+	if err != nil {
+		return nil, courseError(err, "get course")
+	}
+	return course, nil
+}
+
+// GetCourseTree возвращает курс с модулями, уроками и сводкой блоков одним вызовом
+func (uc *coursesUseCase) GetCourseTree(ctx context.Context, params CourseItemParams) (*models.CourseHierarchy, error) {
+	if err := uc.authorize(ctx, params.ActorID, params.AccountID, false); err != nil {
+		return nil, err
+	}
+	if _, err := uc.checkItem(ctx, params.AccountID, models.TrashItemCourse, params.ID); err != nil {
+		return nil, err
+	}
+
+	if uc.treeCacheTTL <= 0 {
+		return uc.loadCourseTree(ctx, params.ID)
+	}
+
+	// недоступный кэш не должен ломать чтение курса
+	generation, tree, err := uc.tc.Get(ctx, params.ID)
+	switch {
+	case err == nil:
+		return tree, nil
+	case !errors.Is(err, repo.ErrRecordNotFound):
+		log.Warnf("get course %d tree from cache: %v", params.ID, err)
+		return uc.loadCourseTree(ctx, params.ID)
+	}
+
+	tree, err = uc.loadCourseTree(ctx, params.ID)
+	if err != nil {
+		return nil, err
+	}
+	if err := uc.tc.Set(ctx, params.ID, generation, tree, uc.treeCacheTTL); err != nil {
+		log.Warnf("cache course %d tree: %v", params.ID, err)
+	}
+	return tree, nil
+}
+
+func (uc *coursesUseCase) loadCourseTree(ctx context.Context, courseID int64) (*models.CourseHierarchy, error) {
+	tree, err := uc.cr.GetCourseHierarchy(ctx, courseID)
+	if err != nil {
+		return nil, courseError(err, "get course tree")
+	}
+	return tree, nil
+}
+
+type CreateCourseParams struct {
+	ActorID   int64
+	AccountID int64
+	Name      string
+}
+
+func (uc *coursesUseCase) CreateCourse(ctx context.Context, params CreateCourseParams) (*models.Course, error) {
+	name, err := normalizeItemName(params.Name)
+	if err != nil {
+		return nil, err
+	}
+	if err := uc.authorize(ctx, params.ActorID, params.AccountID, true); err != nil {
+		return nil, err
+	}
+	if err := uc.checkCourseLimit(ctx, params.AccountID); err != nil {
+		return nil, err
+	}
+
+	id, err := uc.cr.CreateCourse(ctx, repo.CreateCourseParams{
+		AccountID: params.AccountID,
+		Name:      name,
+	})
+	if err != nil {
+		if errors.Is(err, repo.ErrAccountNotFound) {
+			return nil, uerrors.ErrAccountNotFound
+		}
+		return nil, fmt.Errorf("failed to create course: %w", err)
+	}
+
+	uc.recordChange(ctx, id, params.ActorID, params.AccountID, models.AuditActionContentCreate, models.TrashItemCourse, id,
+		nil, map[string]any{"name": name})
+	return &models.Course{ID: id, AccountID: params.AccountID, Name: name}, nil
+}
+
+func (uc *coursesUseCase) UpdateCourse(ctx context.Context, params RenameCourseItemParams) error {
+	name, err := normalizeItemName(params.Name)
+	if err != nil {
+		return err
+	}
+	courseID, err := uc.authorizeItem(ctx, params.ActorID, params.AccountID, models.TrashItemCourse, params.ID)
+	if err != nil {
+		return err
+	}
+
+	before, err := uc.cr.GetCourseByID(ctx, params.ID)
+	if err != nil {
+		return courseError(err, "get course")
+	}
+	if err := uc.cr.UpdateCourse(ctx, repo.UpdateCourseParams{
+		CourseID: params.ID,
+		Name:     name,
+		Author:   revisionAuthor(params.AccountID, params.ActorID),
+	}); err != nil {
+		return courseError(err, "update course")
+	}
+
+	uc.recordChange(ctx, courseID, params.ActorID, params.AccountID, models.AuditActionContentUpdate, models.TrashItemCourse, params.ID,
+		map[string]any{"name": before.Name}, map[string]any{"name": name})
+	return nil
+}
+
+func (uc *coursesUseCase) RemoveCourse(ctx context.Context, params CourseItemParams) error {
+	courseID, err := uc.authorizeItem(ctx, params.ActorID, params.AccountID, models.TrashItemCourse, params.ID)
+	if err != nil {
+		return err
+	}
+
+	err = uc.cr.RemoveCourse(ctx, repo.RemoveCourseParams{CourseID: params.ID, DeletedBy: params.ActorID})
+	if err != nil {
+		return courseError(err, "remove course")
+	}
+
+	uc.recordChange(ctx, courseID, params.ActorID, params.AccountID, models.AuditActionContentDelete, models.TrashItemCourse, params.ID, nil, nil)
+	return nil
+}
+
+// checkCourseLimit проверяет, что тариф аккаунта позволяет создать ещё один курс
+func (uc *coursesUseCase) checkCourseLimit(ctx context.Context, accountID int64) error {
+	sub, err := uc.br.GetSubscription(ctx, accountID)
+	if err != nil {
+		if errors.Is(err, repo.ErrAccountNotFound) {
+			return uerrors.ErrAccountNotFound
+		}
+		return fmt.Errorf("failed to get subscription: %w", err)
+	}
+	if sub.Plan.MaxCourses == nil {
+		return nil
+	}
+
+	courses, err := uc.br.CountAccountCourses(ctx, accountID)
+	if err != nil {
+		return fmt.Errorf("failed to count courses: %w", err)
+	}
+	if courses+1 > *sub.Plan.MaxCourses {
+		return uerrors.ErrCourseLimitReached
+	}
+	return nil
+}
+
+/* ===================== Modules ===================== */
+
+func (uc *coursesUseCase) GetModules(ctx context.Context, params CourseItemParams) ([]*models.Module, error) {
+	if err := uc.authorize(ctx, params.ActorID, params.AccountID, false); err != nil {
+		return nil, err
+	}
+	if _, err := uc.checkItem(ctx, params.AccountID, models.TrashItemCourse, params.ID); err != nil {
+		return nil, err
+	}
+
+	modules, err := uc.cr.GetModulesByCourseID(ctx, params.ID)
+	if err != nil {
+		return nil, courseError(err, "get modules")
+	}
+	return modules, nil
+}
+
+type CreateModuleParams struct {
+	ActorID   int64
+	AccountID int64
+	CourseID  int64
+	Name      string
+	// OrderIdx - позиция в курсе, nil - в конец
+	OrderIdx *int
+}
+
+func (uc *coursesUseCase) CreateModule(ctx context.Context, params CreateModuleParams) (*models.Module, error) {
+	name, err := normalizeItemName(params.Name)
+	if err != nil {
+		return nil, err
+	}
+	courseID, err := uc.authorizeItem(ctx, params.ActorID, params.AccountID, models.TrashItemCourse, params.CourseID)
+	if err != nil {
+		return nil, err
+	}
+
+	var orderIdx *int64
+	if params.OrderIdx != nil {
+		idx := int64(*params.OrderIdx)
+		orderIdx = &idx
+	}
+	id, err := uc.cr.CreateModule(ctx, repo.CreateModuleParams{
+		CourseID: params.CourseID,
+		Name:     name,
+		OrderIdx: orderIdx,
+	})
+	if err != nil {
+		return nil, courseError(err, "create module")
+	}
+
+	module, err := uc.cr.GetModuleByID(ctx, id)
+	if err != nil {
+		return nil, courseError(err, "get module")
+	}
+
+	uc.recordChange(ctx, courseID, params.ActorID, params.AccountID, models.AuditActionContentCreate, models.TrashItemModule, id,
+		nil, map[string]any{"course_id": params.CourseID, "name": name, "order_idx": module.OrderIdx})
+	return module, nil
+}
+
+func (uc *coursesUseCase) UpdateModule(ctx context.Context, params RenameCourseItemParams) error {
+	name, err := normalizeItemName(params.Name)
+	if err != nil {
+		return err
+	}
+	courseID, err := uc.authorizeItem(ctx, params.ActorID, params.AccountID, models.TrashItemModule, params.ID)
+	if err != nil {
+		return err
+	}
+
+	before, err := uc.cr.GetModuleByID(ctx, params.ID)
+	if err != nil {
+		return courseError(err, "get module")
+	}
+	if err := uc.cr.UpdateModule(ctx, repo.UpdateModuleParams{
+		ModuleID: params.ID,
+		Name:     name,
+		Author:   revisionAuthor(params.AccountID, params.ActorID),
+	}); err != nil {
+		return courseError(err, "update module")
+	}
+
+	uc.recordChange(ctx, courseID, params.ActorID, params.AccountID, models.AuditActionContentUpdate, models.TrashItemModule, params.ID,
+		map[string]any{"name": before.Name}, map[string]any{"name": name})
+	return nil
+}
+
+func (uc *coursesUseCase) ReorderModule(ctx context.Context, params ReorderCourseItemParams) error {
+	courseID, err := uc.authorizeItem(ctx, params.ActorID, params.AccountID, models.TrashItemModule, params.ID)
+	if err != nil {
+		return err
+	}
+
+	err = uc.cr.UpdateModuleOrderIdx(ctx, repo.UpdateModuleOrderIdxParams{
+		ModuleID: params.ID,
+		OrderIdx: int64(params.OrderIdx),
+		Author:   revisionAuthor(params.AccountID, params.ActorID),
+	})
+	if err != nil {
+		return courseError(err, "reorder module")
+	}
+
+	uc.recordChange(ctx, courseID, params.ActorID, params.AccountID, models.AuditActionContentReorder, models.TrashItemModule, params.ID,
+		nil, map[string]any{"order_idx": params.OrderIdx})
+	return nil
+}
+
+func (uc *coursesUseCase) ReorderModules(ctx context.Context, params ReorderChildrenParams) error {
+	return uc.reorderChildren(ctx, params, models.TrashItemCourse, func(author repo.RevisionAuthor) error {
+		return uc.cr.ReorderModules(ctx, repo.ReorderModulesParams{CourseID: params.ParentID, ModuleIDs: params.IDs, Author: author})
+	})
+}
+
+func (uc *coursesUseCase) RemoveModule(ctx context.Context, params CourseItemParams) error {
+	courseID, err := uc.authorizeItem(ctx, params.ActorID, params.AccountID, models.TrashItemModule, params.ID)
+	if err != nil {
+		return err
+	}
+
+	err = uc.cr.RemoveModule(ctx, repo.RemoveModuleParams{ModuleID: params.ID, DeletedBy: params.ActorID})
+	if err != nil {
+		return courseError(err, "remove module")
+	}
+
+	uc.recordChange(ctx, courseID, params.ActorID, params.AccountID, models.AuditActionContentDelete, models.TrashItemModule, params.ID, nil, nil)
+	return nil
+}
+
+/* ===================== Lessons ===================== */
+
+func (uc *coursesUseCase) GetLessons(ctx context.Context, params CourseItemParams) ([]*models.Lesson, error) {
+	if err := uc.authorize(ctx, params.ActorID, params.AccountID, false); err != nil {
+		return nil, err
+	}
+	if _, err := uc.checkItem(ctx, params.AccountID, models.TrashItemModule, params.ID); err != nil {
+		return nil, err
+	}
+
+	lessons, err := uc.cr.GetLessonsByModuleID(ctx, params.ID)
+	if err != nil {
+		return nil, courseError(err, "get lessons")
+	}
+	return lessons, nil
+}
+
+type CreateLessonParams struct {
+	ActorID   int64
+	AccountID int64
+	ModuleID  int64
+	Name      string
+	// OrderIdx - позиция в модуле, nil - в конец
+	OrderIdx *int
+}
+
+func (uc *coursesUseCase) CreateLesson(ctx context.Context, params CreateLessonParams) (*models.Lesson, error) {
+	name, err := normalizeItemName(params.Name)
+	if err != nil {
+		return nil, err
+	}
+	courseID, err := uc.authorizeItem(ctx, params.ActorID, params.AccountID, models.TrashItemModule, params.ModuleID)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := uc.cr.CreateLesson(ctx, repo.CreateLessonParams{
+		ModuleID: params.ModuleID,
+		Name:     name,
+		OrderIdx: params.OrderIdx,
+	})
+	if err != nil {
+		return nil, courseError(err, "create lesson")
+	}
+
+	lesson, err := uc.cr.GetLessonByID(ctx, id)
+	if err != nil {
+		return nil, courseError(err, "get lesson")
+	}
+
+	uc.recordChange(ctx, courseID, params.ActorID, params.AccountID, models.AuditActionContentCreate, models.TrashItemLesson, id,
+		nil, map[string]any{"module_id": params.ModuleID, "name": name, "order_idx": lesson.OrderIdx})
+	return lesson, nil
+}
+
+func (uc *coursesUseCase) UpdateLesson(ctx context.Context, params RenameCourseItemParams) error {
+	name, err := normalizeItemName(params.Name)
+	if err != nil {
+		return err
+	}
+	courseID, err := uc.authorizeItem(ctx, params.ActorID, params.AccountID, models.TrashItemLesson, params.ID)
+	if err != nil {
+		return err
+	}
+
+	before, err := uc.cr.GetLessonByID(ctx, params.ID)
+	if err != nil {
+		return courseError(err, "get lesson")
+	}
+	if err := uc.cr.UpdateLesson(ctx, repo.UpdateLessonParams{
+		LessonID: params.ID,
+		Name:     name,
+		Author:   revisionAuthor(params.AccountID, params.ActorID),
+	}); err != nil {
+		return courseError(err, "update lesson")
+	}
+
+	uc.recordChange(ctx, courseID, params.ActorID, params.AccountID, models.AuditActionContentUpdate, models.TrashItemLesson, params.ID,
+		map[string]any{"name": before.Name}, map[string]any{"name": name})
+	return nil
+}
+
+func (uc *coursesUseCase) ReorderLesson(ctx context.Context, params ReorderCourseItemParams) error {
+	courseID, err := uc.authorizeItem(ctx, params.ActorID, params.AccountID, models.TrashItemLesson, params.ID)
+	if err != nil {
+		return err
+	}
+
+	err = uc.cr.UpdateLessonOrderIdx(ctx, repo.UpdateLessonOrderIdxParams{
+		LessonID: params.ID,
+		OrderIdx: params.OrderIdx,
+		Author:   revisionAuthor(params.AccountID, params.ActorID),
+	})
+	if err != nil {
+		return courseError(err, "reorder lesson")
+	}
+
+	uc.recordChange(ctx, courseID, params.ActorID, params.AccountID, models.AuditActionContentReorder, models.TrashItemLesson, params.ID,
+		nil, map[string]any{"order_idx": params.OrderIdx})
+	return nil
+}
+
+func (uc *coursesUseCase) MoveLesson(ctx context.Context, params MoveCourseItemParams) error {
+	return uc.moveItem(ctx, params, models.TrashItemLesson, models.TrashItemModule, func(orderIdx *int) (repo.MovedItem, error) {
+		return uc.cr.MoveLesson(ctx, repo.MoveLessonParams{
+			LessonID: params.ID,
+			ModuleID: params.ParentID,
+			OrderIdx: orderIdx,
+			Author:   revisionAuthor(params.AccountID, params.ActorID),
+		})
+	})
+}
+
+func (uc *coursesUseCase) ReorderLessons(ctx context.Context, params ReorderChildrenParams) error {
+	return uc.reorderChildren(ctx, params, models.TrashItemModule, func(author repo.RevisionAuthor) error {
+		return uc.cr.ReorderLessons(ctx, repo.ReorderLessonsParams{ModuleID: params.ParentID, LessonIDs: params.IDs, Author: author})
+	})
+}
+
+func (uc *coursesUseCase) RemoveLesson(ctx context.Context, params CourseItemParams) error {
+	courseID, err := uc.authorizeItem(ctx, params.ActorID, params.AccountID, models.TrashItemLesson, params.ID)
+	if err != nil {
+		return err
+	}
+
+	err = uc.cr.RemoveLesson(ctx, repo.RemoveLessonParams{LessonID: params.ID, DeletedBy: params.ActorID})
+	if err != nil {
+		return courseError(err, "remove lesson")
+	}
+
+	uc.recordChange(ctx, courseID, params.ActorID, params.AccountID, models.AuditActionContentDelete, models.TrashItemLesson, params.ID, nil, nil)
+	return nil
+}
+
+/* ===================== Blocks ===================== */
+
+func (uc *coursesUseCase) GetBlocks(ctx context.Context, params CourseItemParams) ([]any, error) {
+	if err := uc.authorize(ctx, params.ActorID, params.AccountID, false); err != nil {
+		return nil, err
+	}
+	if _, err := uc.checkItem(ctx, params.AccountID, models.TrashItemLesson, params.ID); err != nil {
+		return nil, err
+	}
+
+	blocks, err := uc.cr.GetBlocksByLessonID(ctx, params.ID)
+	if err != nil {
+		return nil, courseError(err, "get blocks")
+	}
+	return blocks, nil
+}
+
+type CreateTextBlockParams struct {
+	ActorID   int64
+	AccountID int64
+	LessonID  int64
+	Content   string
+	// OrderIdx - позиция в уроке, nil - в конец
+	OrderIdx *int
+}
+
+func (uc *coursesUseCase) CreateTextBlock(ctx context.Context, params CreateTextBlockParams) (int64, error) {
+	courseID, err := uc.authorizeItem(ctx, params.ActorID, params.AccountID, models.TrashItemLesson, params.LessonID)
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := uc.cr.CreateTextBlock(ctx, repo.CreateTextBlockParams{
+		CreateBaseBlockParams: repo.CreateBaseBlockParams{LessonID: params.LessonID, OrderIdx: params.OrderIdx},
+		Content:               params.Content,
+	})
+	if err != nil {
+		return 0, courseError(err, "create text block")
+	}
+
+	uc.recordChange(ctx, courseID, params.ActorID, params.AccountID, models.AuditActionContentCreate, models.TrashItemBlock, id,
+		nil, map[string]any{"lesson_id": params.LessonID, "type": models.BlockTypeText})
+	return id, nil
+}
+
+type CreateVideoBlockParams struct {
+	ActorID   int64
+	AccountID int64
+	LessonID  int64
+	FileID    int64
+	// OrderIdx - позиция в уроке, nil - в конец
+	OrderIdx *int
+}
+
+func (uc *coursesUseCase) CreateVideoBlock(ctx context.Context, params CreateVideoBlockParams) (int64, error) {
+	courseID, err := uc.authorizeItem(ctx, params.ActorID, params.AccountID, models.TrashItemLesson, params.LessonID)
+	if err != nil {
+		return 0, err
+	}
+	if err := uc.checkVideoFile(ctx, params.AccountID, params.FileID); err != nil {
+		return 0, err
+	}
+
+	id, err := uc.cr.CreateVideoBlock(ctx, repo.CreateVideoBlockParams{
+		CreateBaseBlockParams: repo.CreateBaseBlockParams{LessonID: params.LessonID, OrderIdx: params.OrderIdx},
+		FileID:                params.FileID,
+	})
+	if err != nil {
+		return 0, courseError(err, "create video block")
+	}
+
+	uc.recordChange(ctx, courseID, params.ActorID, params.AccountID, models.AuditActionContentCreate, models.TrashItemBlock, id,
+		nil, map[string]any{"lesson_id": params.LessonID, "type": models.BlockTypeVideo, "file_id": params.FileID})
+	return id, nil
+}
+
+// checkVideoFile проверяет, что файл принадлежит аккаунту и является видео
+func (uc *coursesUseCase) checkVideoFile(ctx context.Context, accountID, fileID int64) error {
+	file, err := uc.getAccountFile(ctx, accountID, fileID)
+	if err != nil {
+		return err
+	}
+	if !strings.HasPrefix(file.ContentType, "video/") {
+		return uerrors.ErrInvalidFileType
+	}
+	return nil
+}
+
+// getAccountFile возвращает файл аккаунта. Файл другого аккаунта для вызывающего не существует.
+func (uc *coursesUseCase) getAccountFile(ctx context.Context, accountID, fileID int64) (models.File, error) {
+	file, err := uc.fr.GetFileInfo(ctx, fileID)
+	if err != nil {
+		if errors.Is(err, repo.ErrFileNotFound) {
+			return models.File{}, uerrors.ErrFileNotFound
+		}
+		return models.File{}, fmt.Errorf("failed to get file: %w", err)
+	}
+	if file.AccountID == nil || *file.AccountID != accountID {
+		return models.File{}, uerrors.ErrFileNotFound
+	}
+	return file, nil
+}
+
+type UpdateTextBlockParams struct {
+	ActorID   int64
+	AccountID int64
+	BlockID   int64
+	Content   string
+}
+
+func (uc *coursesUseCase) UpdateTextBlock(ctx context.Context, params UpdateTextBlockParams) error {
+	courseID, err := uc.authorizeItem(ctx, params.ActorID, params.AccountID, models.TrashItemBlock, params.BlockID)
+	if err != nil {
+		return err
+	}
+
+	err = uc.cr.UpdateTextBlock(ctx, repo.UpdateTextBlockParams{
+		BlockID: params.BlockID,
+		Content: params.Content,
+		Author:  revisionAuthor(params.AccountID, params.ActorID),
+	})
+	if err != nil {
+		return courseError(err, "update text block")
+	}
+
+	uc.recordChange(ctx, courseID, params.ActorID, params.AccountID, models.AuditActionContentUpdate, models.TrashItemBlock, params.BlockID, nil, nil)
+	return nil
+}
+
+func (uc *coursesUseCase) ReorderBlock(ctx context.Context, params ReorderCourseItemParams) error {
+	courseID, err := uc.authorizeItem(ctx, params.ActorID, params.AccountID, models.TrashItemBlock, params.ID)
+	if err != nil {
+		return err
+	}
+
+	err = uc.cr.UpdateBlockOrderIdx(ctx, repo.UpdateBlockOrderIdxParams{
+		BlockID:  params.ID,
+		OrderIdx: params.OrderIdx,
+		Author:   revisionAuthor(params.AccountID, params.ActorID),
+	})
+	if err != nil {
+		return courseError(err, "reorder block")
+	}
+
+	uc.recordChange(ctx, courseID, params.ActorID, params.AccountID, models.AuditActionContentReorder, models.TrashItemBlock, params.ID,
+		nil, map[string]any{"order_idx": params.OrderIdx})
+	return nil
+}
+
+func (uc *coursesUseCase) MoveBlock(ctx context.Context, params MoveCourseItemParams) error {
+	return uc.moveItem(ctx, params, models.TrashItemBlock, models.TrashItemLesson, func(orderIdx *int) (repo.MovedItem, error) {
+		return uc.cr.MoveBlock(ctx, repo.MoveBlockParams{
+			BlockID:  params.ID,
+			LessonID: params.ParentID,
+			OrderIdx: orderIdx,
+			Author:   revisionAuthor(params.AccountID, params.ActorID),
+		})
+	})
+}
+
+func (uc *coursesUseCase) ReorderBlocks(ctx context.Context, params ReorderChildrenParams) error {
+	return uc.reorderChildren(ctx, params, models.TrashItemLesson, func(author repo.RevisionAuthor) error {
+		return uc.cr.ReorderBlocks(ctx, repo.ReorderBlocksParams{LessonID: params.ParentID, BlockIDs: params.IDs, Author: author})
+	})
+}
+
+func (uc *coursesUseCase) RemoveBlock(ctx context.Context, params CourseItemParams) error {
+	courseID, err := uc.authorizeItem(ctx, params.ActorID, params.AccountID, models.TrashItemBlock, params.ID)
+	if err != nil {
+		return err
+	}
+
+	err = uc.cr.RemoveBlock(ctx, repo.RemoveBlockParams{BlockID: params.ID, DeletedBy: params.ActorID})
+	if err != nil {
+		return courseError(err, "remove block")
+	}
+
+	uc.recordChange(ctx, courseID, params.ActorID, params.AccountID, models.AuditActionContentDelete, models.TrashItemBlock, params.ID, nil, nil)
+	return nil
+}
+
+/* ===================== Helpers ===================== */
+
+// authorize - курсы редактируют владелец и админы аккаунта, изменения запрещены в закрывающемся аккаунте
+func (uc *coursesUseCase) authorize(ctx context.Context, actorID, accountID int64, write bool) error {
+	if err := requireAccountAdmin(ctx, uc.ar, actorID, accountID); err != nil {
+		return err
+	}
+	if write {
+		return requireWritableAccount(ctx, uc.ar, accountID)
+	}
+	return nil
+}
+
+// authorizeItem - authorize на запись и проверка, что элемент принадлежит аккаунту
+func (uc *coursesUseCase) authorizeItem(ctx context.Context, actorID, accountID int64, itemType models.TrashItemType, id int64) (int64, error) {
+	if err := uc.authorize(ctx, actorID, accountID, true); err != nil {
+		return 0, err
+	}
+	return uc.checkItem(ctx, accountID, itemType, id)
+}
+
+// checkItem возвращает курс элемента. Элемент другого аккаунта для вызывающего не существует.
+func (uc *coursesUseCase) checkItem(ctx context.Context, accountID int64, itemType models.TrashItemType, id int64) (int64, error) {
+	owner, err := uc.cr.GetItemOwner(ctx, itemType, id)
+	if err != nil {
+		return 0, courseError(err, "get item owner")
+	}
+	if owner.AccountID != accountID {
+		return 0, courseError(courseItemNotFound[itemType], "check item owner")
+	}
+	return owner.CourseID, nil
+}
+
+// reorderChildren задаёт порядок всех дочерних элементов родителя одной операцией
+func (uc *coursesUseCase) reorderChildren(
+	ctx context.Context,
+	params ReorderChildrenParams,
+	parentType models.TrashItemType,
+	reorder func(author repo.RevisionAuthor) error,
+) error {
+	courseID, err := uc.authorizeItem(ctx, params.ActorID, params.AccountID, parentType, params.ParentID)
+	if err != nil {
+		return err
+	}
+
+	if err := reorder(revisionAuthor(params.AccountID, params.ActorID)); err != nil {
+		return courseError(err, fmt.Sprintf("reorder %s children", parentType))
+	}
+
+	uc.recordChange(ctx, courseID, params.ActorID, params.AccountID, models.AuditActionContentReorder, parentType, params.ParentID,
+		nil, map[string]any{"order": params.IDs})
+	return nil
+}
+
+// moveItem проверяет, что элемент и новый родитель принадлежат аккаунту, и переносит элемент.
+// Перенос между курсами аккаунта сбрасывает деревья обоих курсов.
+func (uc *coursesUseCase) moveItem(
+	ctx context.Context,
+	params MoveCourseItemParams,
+	itemType, parentType models.TrashItemType,
+	move func(orderIdx *int) (repo.MovedItem, error),
+) error {
+	fromCourseID, err := uc.authorizeItem(ctx, params.ActorID, params.AccountID, itemType, params.ID)
+	if err != nil {
+		return err
+	}
+	courseID, err := uc.checkItem(ctx, params.AccountID, parentType, params.ParentID)
+	if err != nil {
+		return err
+	}
+
+	moved, err := move(params.OrderIdx)
+	if err != nil {
+		return courseError(err, fmt.Sprintf("move %s", itemType))
+	}
+
+	if fromCourseID != courseID {
+		invalidateCourseTree(ctx, uc.tc, fromCourseID)
+	}
+	parentKey := string(parentType) + "_id"
+	uc.recordChange(ctx, courseID, params.ActorID, params.AccountID, models.AuditActionContentMove, itemType, params.ID,
+		map[string]any{parentKey: moved.FromParentID, "order_idx": moved.FromOrderIdx},
+		map[string]any{parentKey: moved.ParentID, "order_idx": moved.OrderIdx})
+	return nil
+}
+
+var courseItemNotFound = map[models.TrashItemType]error{
+	models.TrashItemCourse: repo.ErrCourseNotFound,
+	models.TrashItemModule: repo.ErrModuleNotFound,
+	models.TrashItemLesson: repo.ErrLessonNotFound,
+	models.TrashItemBlock:  repo.ErrBlockNotFound,
+}
+
+// recordChange сбрасывает кэш дерева курса и пишет изменение в журнал аудита
+func (uc *coursesUseCase) recordChange(
+	ctx context.Context,
+	courseID, actorID, accountID int64,
+	action models.AuditAction,
+	itemType models.TrashItemType,
+	id int64,
+	before, after any,
+) {
+	invalidateCourseTree(ctx, uc.tc, courseID)
+	recordAudit(ctx, uc.aur, auditRecord{
+		AccountID:   accountID,
+		ActorUserID: actorID,
+		Action:      action,
+		TargetType:  trashAuditTargets[itemType],
+		TargetID:    &id,
+		Before:      before,
+		After:       after,
+	})
+}
+
+// revisionAuthor - автор правки для истории изменений элементов курса
+func revisionAuthor(accountID, actorID int64) repo.RevisionAuthor {
+	return repo.RevisionAuthor{AccountID: accountID, UserID: actorID}
+}
+
+// invalidateCourseTree сбрасывает кэш дерева курса. Ошибка только логируется:
+// изменение уже сохранено, а устаревшее дерево истечёт по ttl.
+func invalidateCourseTree(ctx context.Context, tc repo.CourseTreeCacheRepo, courseID int64) {
+	if err := tc.Invalidate(ctx, courseID); err != nil {
+		log.Warnf("invalidate course %d tree: %v", courseID, err)
+	}
+}
+
+// courseError переводит ошибки репозитория курсов в пользовательские
+func courseError(err error, action string) error {
+	switch {
+	case errors.Is(err, repo.ErrCourseNotFound):
+		return uerrors.ErrCourseNotFound
+	case errors.Is(err, repo.ErrModuleNotFound):
+		return uerrors.ErrModuleNotFound
+	case errors.Is(err, repo.ErrLessonNotFound):
+		return uerrors.ErrLessonNotFound
+	case errors.Is(err, repo.ErrBlockNotFound):
+		return uerrors.ErrBlockNotFound
+	case errors.Is(err, repo.ErrFileNotFound):
+		return uerrors.ErrFileNotFound
+	case errors.Is(err, repo.ErrOrderConflict):
+		return uerrors.ErrOrderConflict
+	case errors.Is(err, repo.ErrOrderMismatch):
+		return uerrors.ErrOrderMismatch
+	case errors.Is(err, repo.ErrCourseNotPublished):
+		return uerrors.ErrCourseNotPublished
+	case errors.Is(err, repo.ErrCourseVersionNotFound):
+		return uerrors.ErrCourseVersionNotFound
+	}
+	return fmt.Errorf("failed to %s: %w", action, err)
+}
+
+func normalizeItemName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", uerrors.ErrInvalidName
+	}
+	return name, nil
+}
@@ -0,0 +1,134 @@
+package usecases
+
+import (
+	uerrors "chalk/internal/errors"
+	"chalk/internal/repo"
+	"chalk/internal/repo/models"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+)
+
+type FilesUseCase interface {
+	UploadFile(ctx context.Context, params UploadFileParams) (models.File, error)
+	GetFile(ctx context.Context, params GetFileParams) (models.File, io.ReadCloser, error)
+	GetStorageUsage(ctx context.Context, params GetStorageUsageParams) (models.StorageUsage, error)
+}
+
+func NewFilesUseCase(fr repo.FilesRepo, ar repo.AccountsRepo, defaultQuota int64) FilesUseCase {
+	return &filesUseCase{
+		fr:           fr,
+		ar:           ar,
+		defaultQuota: defaultQuota,
+	}
+}
+
+type filesUseCase struct {
+	fr repo.FilesRepo
+	ar repo.AccountsRepo
+
+	// defaultQuota - квота аккаунта на хранилище в байтах, 0 - без ограничений
+	defaultQuota int64
+}
+
+type UploadFileParams struct {
+	UploaderUserID int64
+	AccountID      int64
+	Name           string
+	ContentType    string
+	Size           int64
+	Reader         io.Reader
+}
+
+func (uc *filesUseCase) UploadFile(ctx context.Context, params UploadFileParams) (models.File, error) {
+	if params.ContentType == "" {
+		params.ContentType = "application/octet-stream"
+	}
+
+	// загружать в аккаунт может любой его участник
+	if _, err := getAccountRole(ctx, uc.ar, params.UploaderUserID, params.AccountID); err != nil {
+		return models.File{}, err
+	}
+
+	if err := requireWritableAccount(ctx, uc.ar, params.AccountID); err != nil {
+		return models.File{}, err
+	}
+
+	file, err := uc.fr.UploadFile(ctx, repo.UploadFileParams{
+		Reader:         params.Reader,
+		UploaderUserID: params.UploaderUserID,
+		AccountID:      params.AccountID,
+		DefaultQuota:   uc.defaultQuota,
+		Name:           params.Name,
+		ContentType:    params.ContentType,
+		Size:           params.Size,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, repo.ErrUserNotFound):
+			return models.File{}, uerrors.ErrUserNotFound
+		case errors.Is(err, repo.ErrAccountNotFound):
+			return models.File{}, uerrors.ErrAccountNotFound
+		case errors.Is(err, repo.ErrStorageQuotaExceeded):
+			return models.File{}, uerrors.ErrStorageQuotaExceeded
+		}
+		return models.File{}, fmt.Errorf("failed to upload file: %w", err)
+	}
+	return file, nil
+}
+
+type GetFileParams struct {
+	ActorID int64
+	FileID  int64
+}
+
+func (uc *filesUseCase) GetFile(ctx context.Context, params GetFileParams) (models.File, io.ReadCloser, error) {
+	file, err := uc.fr.GetFileInfo(ctx, params.FileID)
+	if err != nil {
+		if errors.Is(err, repo.ErrFileNotFound) {
+			return models.File{}, nil, uerrors.ErrFileNotFound
+		}
+		return models.File{}, nil, fmt.Errorf("failed to get file info: %w", err)
+	}
+
+	if file.UploaderUserID != params.ActorID {
+		return models.File{}, nil, uerrors.ErrFileNotFound
+	}
+
+	return openFile(ctx, uc.fr, file)
+}
+
+type GetStorageUsageParams struct {
+	ActorID   int64
+	AccountID int64
+}
+
+func (uc *filesUseCase) GetStorageUsage(ctx context.Context, params GetStorageUsageParams) (models.StorageUsage, error) {
+	if _, err := getAccountRole(ctx, uc.ar, params.ActorID, params.AccountID); err != nil {
+		return models.StorageUsage{}, err
+	}
+
+	usage, err := uc.fr.GetStorageUsage(ctx, repo.GetStorageUsageParams{
+		AccountID:    params.AccountID,
+		DefaultQuota: uc.defaultQuota,
+	})
+	if err != nil {
+		if errors.Is(err, repo.ErrAccountNotFound) {
+			return models.StorageUsage{}, uerrors.ErrAccountNotFound
+		}
+		return models.StorageUsage{}, fmt.Errorf("failed to get storage usage: %w", err)
+	}
+	return usage, nil
+}
+
+func openFile(ctx context.Context, fr repo.FilesRepo, file models.File) (models.File, io.ReadCloser, error) {
+	obj, err := fr.GetFileByID(ctx, file.ID)
+	if err != nil {
+		if errors.Is(err, repo.ErrFileNotFound) {
+			return models.File{}, nil, uerrors.ErrFileNotFound
+		}
+		return models.File{}, nil, fmt.Errorf("failed to get file: %w", err)
+	}
+	return file, obj, nil
+}
@@ -0,0 +1,78 @@
+package usecases
+
+import (
+	"chalk/internal/repo"
+	"chalk/pkg/mailer"
+	"context"
+	"fmt"
+	"strings"
+)
+
+// accountMailer отправляет письма пользователям аккаунта с учётом его
+// брендинга: имя отправителя, подпись и адрес поддержки для ответа.
+type accountMailer struct {
+	mailer   mailer.Mailer
+	ar       repo.AccountsRepo
+	fromAddr string
+	fromName string
+}
+
+func newAccountMailer(m mailer.Mailer, ar repo.AccountsRepo, fromAddr, fromName string) *accountMailer {
+	return &accountMailer{
+		mailer:   m,
+		ar:       ar,
+		fromAddr: fromAddr,
+		fromName: fromName,
+	}
+}
+
+type accountMail struct {
+	AccountID int64
+	To        string
+	Subject   string
+	Body      string
+}
+
+func (m *accountMailer) Send(ctx context.Context, mail accountMail) error {
+	msg, err := m.build(ctx, mail)
+	if err != nil {
+		return err
+	}
+	if err := m.mailer.SendMail(m.fromAddr, mail.To, msg.Bytes()); err != nil {
+		return fmt.Errorf("failed to send mail: %w", err)
+	}
+	return nil
+}
+
+func (m *accountMailer) build(ctx context.Context, mail accountMail) (mailer.Message, error) {
+	settings, err := m.ar.GetAccountSettings(ctx, mail.AccountID)
+	if err != nil {
+		return mailer.Message{}, fmt.Errorf("failed to get account settings: %w", err)
+	}
+
+	msg := mailer.Message{
+		FromName: m.fromName,
+		FromAddr: m.fromAddr,
+		ReplyTo:  settings.SupportEmail,
+		To:       mail.To,
+		Subject:  mail.Subject,
+	}
+	if settings.EmailSenderName != "" {
+		msg.FromName = settings.EmailSenderName
+	}
+
+	var body strings.Builder
+	body.WriteString(mail.Body)
+	if settings.DisplayName != "" || settings.SupportEmail != "" {
+		body.WriteString("\n\n--\n")
+		if settings.DisplayName != "" {
+			body.WriteString(settings.DisplayName)
+			body.WriteString("\n")
+		}
+		if settings.SupportEmail != "" {
+			fmt.Fprintf(&body, "Поддержка: %s\n", settings.SupportEmail)
+		}
+	}
+	msg.Body = body.String()
+	return msg, nil
+}
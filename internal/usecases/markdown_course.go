@@ -0,0 +1,496 @@
+package usecases
+
+import (
+	uerrors "chalk/internal/errors"
+	"chalk/internal/repo"
+	"chalk/internal/repo/models"
+	"chalk/pkg/coursearchive"
+	"chalk/pkg/log"
+	"chalk/pkg/mdcourse"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"slices"
+)
+
+type SyncMarkdownCourseParams struct {
+	ActorID   int64
+	AccountID int64
+	// CourseID - курс, который приводится к каталогу, 0 - создать новый курс
+	CourseID int64
+	Source   fs.FS
+}
+
+type MarkdownSyncResult struct {
+	Course *models.Course
+	// Created, Updated, Removed - сколько модулей, уроков и блоков создано, изменено и удалено
+	Created int
+	Updated int
+	Removed int
+	// Problems - ошибки разметки каталога, заполняются вместе с ErrInvalidMarkdownCourse
+	Problems []coursearchive.Problem
+	// Warnings - ссылки на файлы каталога, которые не стали блоками
+	Warnings []coursearchive.Problem
+}
+
+// SyncMarkdownCourse создаёт или обновляет курс по каталогу Markdown. Модули, уроки и
+// файлы медиа находятся по пути в каталоге, при первой синхронизации существующего
+// курса - по названию. Меняется только то, что отличается, поэтому повторный запуск
+// с тем же каталогом ничего не меняет. Лишние элементы курса уходят в корзину.
+// Синхронизация не атомарна: после ошибки повторный запуск доводит курс до каталога.
+func (uc *coursesUseCase) SyncMarkdownCourse(ctx context.Context, params SyncMarkdownCourseParams) (MarkdownSyncResult, error) {
+	if err := uc.authorize(ctx, params.ActorID, params.AccountID, true); err != nil {
+		return MarkdownSyncResult{}, err
+	}
+	if params.CourseID != 0 {
+		if _, err := uc.checkItem(ctx, params.AccountID, models.TrashItemCourse, params.CourseID); err != nil {
+			return MarkdownSyncResult{}, err
+		}
+	} else if err := uc.checkCourseLimit(ctx, params.AccountID); err != nil {
+		return MarkdownSyncResult{}, err
+	}
+
+	src, err := mdcourse.Load(params.Source)
+	if err != nil {
+		var verr *coursearchive.ValidationError
+		if errors.As(err, &verr) {
+			return MarkdownSyncResult{Problems: verr.Problems}, uerrors.ErrInvalidMarkdownCourse
+		}
+		return MarkdownSyncResult{}, fmt.Errorf("failed to read markdown course: %w", err)
+	}
+	name, err := normalizeItemName(src.Name)
+	if err != nil {
+		return MarkdownSyncResult{}, err
+	}
+
+	action := models.AuditActionCourseSync
+	courseID := params.CourseID
+	if courseID == 0 {
+		courseID, err = uc.cr.CreateCourse(ctx, repo.CreateCourseParams{AccountID: params.AccountID, Name: name})
+		if err != nil {
+			if errors.Is(err, repo.ErrAccountNotFound) {
+				return MarkdownSyncResult{}, uerrors.ErrAccountNotFound
+			}
+			return MarkdownSyncResult{}, fmt.Errorf("failed to create course: %w", err)
+		}
+		action = models.AuditActionCourseImport
+	}
+
+	s := &markdownSync{
+		uc:        uc,
+		actorID:   params.ActorID,
+		accountID: params.AccountID,
+		courseID:  courseID,
+		src:       src,
+		fileIDs:   make(map[string]int64, len(src.Media)),
+	}
+	if err := s.run(ctx, name); err != nil {
+		if params.CourseID == 0 {
+			// недособранный новый курс уходит в корзину, повторный импорт создаст курс заново
+			if rmErr := uc.cr.RemoveCourse(ctx, repo.RemoveCourseParams{CourseID: courseID, DeletedBy: params.ActorID}); rmErr != nil {
+				log.Warnf("remove course %d after failed markdown import: %v", courseID, rmErr)
+			}
+		}
+		invalidateCourseTree(ctx, uc.tc, courseID)
+		return MarkdownSyncResult{}, err
+	}
+
+	res := MarkdownSyncResult{
+		Course:   &models.Course{ID: courseID, AccountID: params.AccountID, Name: name},
+		Created:  s.created,
+		Updated:  s.updated,
+		Removed:  s.removed,
+		Warnings: src.Warnings,
+	}
+	if action == models.AuditActionCourseImport || s.created+s.updated+s.removed > 0 {
+		uc.recordChange(ctx, courseID, params.ActorID, params.AccountID, action, models.TrashItemCourse, courseID, nil,
+			map[string]any{"source": "markdown", "created": s.created, "updated": s.updated, "removed": s.removed})
+	}
+	return res, nil
+}
+
+type markdownSync struct {
+	uc        *coursesUseCase
+	actorID   int64
+	accountID int64
+	courseID  int64
+	src       *mdcourse.Course
+
+	// prev - соответствия прошлой синхронизации, next - этой
+	prev map[models.CourseSourceType]map[string]models.CourseSource
+	next []models.CourseSource
+	// fileIDs - загруженные файлы медиа по пути в каталоге
+	fileIDs map[string]int64
+
+	created, updated, removed int
+}
+
+func (s *markdownSync) run(ctx context.Context, name string) error {
+	sources, err := s.uc.csr.GetCourseSources(ctx, s.courseID)
+	if err != nil {
+		return fmt.Errorf("failed to get course sources: %w", err)
+	}
+	s.prev = make(map[models.CourseSourceType]map[string]models.CourseSource)
+	for _, src := range sources {
+		if s.prev[src.ItemType] == nil {
+			s.prev[src.ItemType] = make(map[string]models.CourseSource)
+		}
+		s.prev[src.ItemType][src.Path] = src
+	}
+
+	if err := s.sync(ctx, name); err != nil {
+		// сделанные соответствия сохраняются, чтобы повторный запуск нашёл созданные элементы
+		s.saveSources(ctx, true)
+		return err
+	}
+	return s.saveSources(ctx, false)
+}
+
+func (s *markdownSync) sync(ctx context.Context, name string) error {
+	if err := s.uploadMedia(ctx); err != nil {
+		return err
+	}
+
+	snapshot, err := s.uc.cr.GetCourseSnapshot(ctx, s.courseID)
+	if err != nil {
+		return courseError(err, "get course snapshot")
+	}
+	if snapshot.Name != name {
+		err := s.uc.cr.UpdateCourse(ctx, repo.UpdateCourseParams{CourseID: s.courseID, Name: name, Author: s.author()})
+		if err != nil {
+			return courseError(err, "rename course")
+		}
+		s.updated++
+	}
+
+	return s.syncModules(ctx, snapshot.Modules)
+}
+
+// uploadMedia загружает новые и изменённые файлы медиа, неизменённые берутся с прошлой синхронизации
+func (s *markdownSync) uploadMedia(ctx context.Context) error {
+	for _, m := range s.src.Media {
+		if prev, ok := s.prev[models.CourseSourceFile][m.Path]; ok && prev.Checksum == m.SHA256 {
+			if _, err := s.uc.getAccountFile(ctx, s.accountID, prev.ItemID); err == nil {
+				s.addSource(models.CourseSourceFile, m.Path, prev.ItemID, m.SHA256)
+				continue
+			} else if !errors.Is(err, uerrors.ErrFileNotFound) {
+				return err
+			}
+		}
+
+		file, err := s.uc.importFile(ctx, s.src, coursearchive.File{
+			Path:        m.Path,
+			Name:        m.Name,
+			ContentType: m.ContentType,
+			Size:        m.Size,
+		}, s.actorID, s.accountID)
+		if err != nil {
+			return err
+		}
+		s.addSource(models.CourseSourceFile, m.Path, file.ID, m.SHA256)
+	}
+	return nil
+}
+
+func (s *markdownSync) syncModules(ctx context.Context, existing []models.ModuleSnapshot) error {
+	items := make([]namedItem, 0, len(existing))
+	for _, m := range existing {
+		items = append(items, namedItem{ID: m.ID, Name: m.Name})
+	}
+	paths := make([]string, 0, len(s.src.Modules))
+	names := make([]string, 0, len(s.src.Modules))
+	for _, m := range s.src.Modules {
+		paths = append(paths, m.Path)
+		names = append(names, m.Name)
+	}
+	matched := s.match(models.CourseSourceModule, paths, names, items)
+
+	order := make([]int64, 0, len(s.src.Modules))
+	for i, m := range s.src.Modules {
+		var lessons []models.LessonSnapshot
+		id := matched[i]
+		if id == 0 {
+			created, err := s.uc.cr.CreateModule(ctx, repo.CreateModuleParams{CourseID: s.courseID, Name: m.Name})
+			if err != nil {
+				return courseError(err, "create module")
+			}
+			id = created
+			s.created++
+		} else {
+			cur := existing[slices.IndexFunc(existing, func(e models.ModuleSnapshot) bool { return e.ID == id })]
+			if cur.Name != m.Name {
+				err := s.uc.cr.UpdateModule(ctx, repo.UpdateModuleParams{ModuleID: id, Name: m.Name, Author: s.author()})
+				if err != nil {
+					return courseError(err, "rename module")
+				}
+				s.updated++
+			}
+			lessons = cur.Lessons
+		}
+		s.addSource(models.CourseSourceModule, m.Path, id, "")
+		order = append(order, id)
+
+		if err := s.syncLessons(ctx, id, m, lessons); err != nil {
+			return err
+		}
+	}
+
+	current, err := s.removeUnmatched(items, order, func(id int64) error {
+		return s.uc.cr.RemoveModule(ctx, repo.RemoveModuleParams{ModuleID: id, DeletedBy: s.actorID})
+	})
+	if err != nil {
+		return courseError(err, "remove module")
+	}
+	if !slices.Equal(current, order) {
+		err := s.uc.cr.ReorderModules(ctx, repo.ReorderModulesParams{CourseID: s.courseID, ModuleIDs: order, Author: s.author()})
+		if err != nil {
+			return courseError(err, "reorder modules")
+		}
+		s.updated++
+	}
+	return nil
+}
+
+func (s *markdownSync) syncLessons(ctx context.Context, moduleID int64, m mdcourse.Module, existing []models.LessonSnapshot) error {
+	items := make([]namedItem, 0, len(existing))
+	for _, l := range existing {
+		items = append(items, namedItem{ID: l.ID, Name: l.Name})
+	}
+	paths := make([]string, 0, len(m.Lessons))
+	names := make([]string, 0, len(m.Lessons))
+	for _, l := range m.Lessons {
+		paths = append(paths, l.Path)
+		names = append(names, l.Name)
+	}
+	matched := s.match(models.CourseSourceLesson, paths, names, items)
+
+	order := make([]int64, 0, len(m.Lessons))
+	for i, l := range m.Lessons {
+		var blocks []models.BlockSnapshot
+		id := matched[i]
+		if id == 0 {
+			created, err := s.uc.cr.CreateLesson(ctx, repo.CreateLessonParams{ModuleID: moduleID, Name: l.Name})
+			if err != nil {
+				return courseError(err, "create lesson")
+			}
+			id = created
+			s.created++
+		} else {
+			cur := existing[slices.IndexFunc(existing, func(e models.LessonSnapshot) bool { return e.ID == id })]
+			if cur.Name != l.Name {
+				err := s.uc.cr.UpdateLesson(ctx, repo.UpdateLessonParams{LessonID: id, Name: l.Name, Author: s.author()})
+				if err != nil {
+					return courseError(err, "rename lesson")
+				}
+				s.updated++
+			}
+			blocks = cur.Blocks
+		}
+		s.addSource(models.CourseSourceLesson, l.Path, id, "")
+		order = append(order, id)
+
+		if err := s.syncBlocks(ctx, id, l.Blocks, blocks); err != nil {
+			return err
+		}
+	}
+
+	current, err := s.removeUnmatched(items, order, func(id int64) error {
+		return s.uc.cr.RemoveLesson(ctx, repo.RemoveLessonParams{LessonID: id, DeletedBy: s.actorID})
+	})
+	if err != nil {
+		return courseError(err, "remove lesson")
+	}
+	if !slices.Equal(current, order) {
+		err := s.uc.cr.ReorderLessons(ctx, repo.ReorderLessonsParams{ModuleID: moduleID, LessonIDs: order, Author: s.author()})
+		if err != nil {
+			return courseError(err, "reorder lessons")
+		}
+		s.updated++
+	}
+	return nil
+}
+
+// syncBlocks сопоставляет блоки урока по позиции: совпадающий блок остаётся, текст
+// обновляется на месте, блок другого типа заменяется новым
+func (s *markdownSync) syncBlocks(ctx context.Context, lessonID int64, want []mdcourse.Block, existing []models.BlockSnapshot) error {
+	items := make([]namedItem, 0, len(existing))
+	for _, b := range existing {
+		items = append(items, namedItem{ID: b.ID})
+	}
+
+	order := make([]int64, 0, len(want))
+	for i, b := range want {
+		if i < len(existing) {
+			cur := existing[i]
+			switch {
+			case s.sameBlock(cur, b):
+				order = append(order, cur.ID)
+				continue
+			case cur.Type == models.BlockTypeText && b.Type == coursearchive.BlockTypeText:
+				err := s.uc.cr.UpdateTextBlock(ctx, repo.UpdateTextBlockParams{BlockID: cur.ID, Content: b.Content, Author: s.author()})
+				if err != nil {
+					return courseError(err, "update text block")
+				}
+				s.updated++
+				order = append(order, cur.ID)
+				continue
+			}
+		}
+
+		base := repo.CreateBaseBlockParams{LessonID: lessonID}
+		var id int64
+		var err error
+		if b.Type == coursearchive.BlockTypeVideo {
+			id, err = s.uc.cr.CreateVideoBlock(ctx, repo.CreateVideoBlockParams{CreateBaseBlockParams: base, FileID: s.fileIDs[b.Media]})
+		} else {
+			id, err = s.uc.cr.CreateTextBlock(ctx, repo.CreateTextBlockParams{CreateBaseBlockParams: base, Content: b.Content})
+		}
+		if err != nil {
+			return courseError(err, "create block")
+		}
+		s.created++
+		order = append(order, id)
+	}
+
+	current, err := s.removeUnmatched(items, order, func(id int64) error {
+		return s.uc.cr.RemoveBlock(ctx, repo.RemoveBlockParams{BlockID: id, DeletedBy: s.actorID})
+	})
+	if err != nil {
+		return courseError(err, "remove block")
+	}
+	if !slices.Equal(current, order) {
+		err := s.uc.cr.ReorderBlocks(ctx, repo.ReorderBlocksParams{LessonID: lessonID, BlockIDs: order, Author: s.author()})
+		if err != nil {
+			return courseError(err, "reorder blocks")
+		}
+		s.updated++
+	}
+	return nil
+}
+
+func (s *markdownSync) sameBlock(cur models.BlockSnapshot, b mdcourse.Block) bool {
+	switch b.Type {
+	case coursearchive.BlockTypeText:
+		return cur.Type == models.BlockTypeText && cur.Content != nil && *cur.Content == b.Content
+	case coursearchive.BlockTypeVideo:
+		return cur.Type == models.BlockTypeVideo && cur.FileID != nil && *cur.FileID == s.fileIDs[b.Media]
+	}
+	return false
+}
+
+type namedItem struct {
+	ID   int64
+	Name string
+}
+
+// match находит для каждого пути каталога элемент курса: сначала по соответствию прошлой
+// синхронизации, затем по названию среди элементов, которые не заняты другими путями.
+// 0 - элемент нужно создать.
+func (s *markdownSync) match(itemType models.CourseSourceType, paths, names []string, items []namedItem) []int64 {
+	exists := make(map[int64]bool, len(items))
+	for _, it := range items {
+		exists[it.ID] = true
+	}
+	taken := make(map[int64]bool)
+	res := make([]int64, len(paths))
+	for i, p := range paths {
+		if prev, ok := s.prev[itemType][p]; ok && exists[prev.ItemID] && !taken[prev.ItemID] {
+			res[i] = prev.ItemID
+			taken[prev.ItemID] = true
+		}
+	}
+	for i, name := range names {
+		if res[i] != 0 {
+			continue
+		}
+		for _, it := range items {
+			if it.Name == name && !taken[it.ID] && !s.claimed(itemType, it.ID, paths) {
+				res[i] = it.ID
+				taken[it.ID] = true
+				break
+			}
+		}
+	}
+	return res
+}
+
+// claimed - элемент связан прошлой синхронизацией с путём, который есть в каталоге
+func (s *markdownSync) claimed(itemType models.CourseSourceType, id int64, paths []string) bool {
+	for _, p := range paths {
+		if prev, ok := s.prev[itemType][p]; ok && prev.ItemID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// removeUnmatched удаляет элементы, которых нет в order, и возвращает порядок
+// оставшихся и созданных элементов после удаления: созданные добавлялись в конец
+func (s *markdownSync) removeUnmatched(items []namedItem, order []int64, remove func(id int64) error) ([]int64, error) {
+	keep := make(map[int64]bool, len(order))
+	for _, id := range order {
+		keep[id] = true
+	}
+	existed := make(map[int64]bool, len(items))
+	current := make([]int64, 0, len(order))
+	for _, it := range items {
+		existed[it.ID] = true
+		if !keep[it.ID] {
+			if err := remove(it.ID); err != nil {
+				return nil, err
+			}
+			s.removed++
+			continue
+		}
+		current = append(current, it.ID)
+	}
+	for _, id := range order {
+		if !existed[id] {
+			current = append(current, id)
+		}
+	}
+	return current, nil
+}
+
+func (s *markdownSync) addSource(itemType models.CourseSourceType, path string, id int64, checksum string) {
+	s.next = append(s.next, models.CourseSource{Path: path, ItemType: itemType, ItemID: id, Checksum: checksum})
+	if itemType == models.CourseSourceFile {
+		s.fileIDs[path] = id
+	}
+}
+
+// saveSources сохраняет соответствия. partial - синхронизация прервана, тогда
+// прошлые соответствия путей, до которых она не дошла, остаются.
+func (s *markdownSync) saveSources(ctx context.Context, partial bool) error {
+	sources := s.next
+	if partial {
+		seen := make(map[models.CourseSourceType]map[string]bool)
+		for _, src := range s.next {
+			if seen[src.ItemType] == nil {
+				seen[src.ItemType] = make(map[string]bool)
+			}
+			seen[src.ItemType][src.Path] = true
+		}
+		for itemType, byPath := range s.prev {
+			for p, src := range byPath {
+				if !seen[itemType][p] {
+					sources = append(sources, src)
+				}
+			}
+		}
+	}
+
+	if err := s.uc.csr.ReplaceCourseSources(ctx, s.courseID, sources); err != nil {
+		if partial {
+			log.Warnf("save sources of course %d after failed sync: %v", s.courseID, err)
+			return nil
+		}
+		return courseError(err, "save course sources")
+	}
+	return nil
+}
+
+func (s *markdownSync) author() repo.RevisionAuthor {
+	return revisionAuthor(s.accountID, s.actorID)
+}
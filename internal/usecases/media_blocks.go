@@ -0,0 +1,183 @@
+package usecases
+
+import (
+	uerrors "chalk/internal/errors"
+	"chalk/internal/repo"
+	"chalk/internal/repo/models"
+	"chalk/pkg/media"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	maxAltTextLen    = 1000
+	maxCaptionLen    = 2000
+	maxBlockTitleLen = 500
+)
+
+type CreateImageBlockParams struct {
+	ActorID   int64
+	AccountID int64
+	LessonID  int64
+	FileID    int64
+	AltText   string
+	Caption   string
+	// OrderIdx - позиция в уроке, nil - в конец
+	OrderIdx *int
+}
+
+func (uc *coursesUseCase) CreateImageBlock(ctx context.Context, params CreateImageBlockParams) (int64, error) {
+	courseID, err := uc.authorizeItem(ctx, params.ActorID, params.AccountID, models.TrashItemLesson, params.LessonID)
+	if err != nil {
+		return 0, err
+	}
+	altText := strings.TrimSpace(params.AltText)
+	if altText == "" || utf8.RuneCountInString(altText) > maxAltTextLen {
+		return 0, uerrors.ErrInvalidAltText
+	}
+	caption := strings.TrimSpace(params.Caption)
+	if utf8.RuneCountInString(caption) > maxCaptionLen {
+		return 0, uerrors.ErrInvalidCaption
+	}
+	if err := uc.checkBlockFile(ctx, params.AccountID, params.FileID, media.IsImage); err != nil {
+		return 0, err
+	}
+
+	id, err := uc.cr.CreateImageBlock(ctx, repo.CreateImageBlockParams{
+		CreateBaseBlockParams: repo.CreateBaseBlockParams{LessonID: params.LessonID, OrderIdx: params.OrderIdx},
+		FileID:                params.FileID,
+		AltText:               altText,
+		Caption:               caption,
+	})
+	if err != nil {
+		return 0, courseError(err, "create image block")
+	}
+
+	uc.recordChange(ctx, courseID, params.ActorID, params.AccountID, models.AuditActionContentCreate, models.TrashItemBlock, id,
+		nil, map[string]any{"lesson_id": params.LessonID, "type": models.BlockTypeImage, "file_id": params.FileID})
+	return id, nil
+}
+
+type CreateAttachmentBlockParams struct {
+	ActorID   int64
+	AccountID int64
+	LessonID  int64
+	FileID    int64
+	// Title - подпись ссылки на файл, пустая - показывается имя файла
+	Title string
+	// OrderIdx - позиция в уроке, nil - в конец
+	OrderIdx *int
+}
+
+func (uc *coursesUseCase) CreateAttachmentBlock(ctx context.Context, params CreateAttachmentBlockParams) (int64, error) {
+	courseID, err := uc.authorizeItem(ctx, params.ActorID, params.AccountID, models.TrashItemLesson, params.LessonID)
+	if err != nil {
+		return 0, err
+	}
+	title := strings.TrimSpace(params.Title)
+	if utf8.RuneCountInString(title) > maxBlockTitleLen {
+		return 0, uerrors.ErrInvalidBlockTitle
+	}
+	if err := uc.checkBlockFile(ctx, params.AccountID, params.FileID, media.IsAttachment); err != nil {
+		return 0, err
+	}
+
+	id, err := uc.cr.CreateAttachmentBlock(ctx, repo.CreateAttachmentBlockParams{
+		CreateBaseBlockParams: repo.CreateBaseBlockParams{LessonID: params.LessonID, OrderIdx: params.OrderIdx},
+		FileID:                params.FileID,
+		Title:                 title,
+	})
+	if err != nil {
+		return 0, courseError(err, "create attachment block")
+	}
+
+	uc.recordChange(ctx, courseID, params.ActorID, params.AccountID, models.AuditActionContentCreate, models.TrashItemBlock, id,
+		nil, map[string]any{"lesson_id": params.LessonID, "type": models.BlockTypeAttachment, "file_id": params.FileID})
+	return id, nil
+}
+
+type CreateEmbedBlockParams struct {
+	ActorID   int64
+	AccountID int64
+	LessonID  int64
+	// URL - ссылка на страницу сервиса из списка media.EmbedProviders
+	URL   string
+	Title string
+	// OrderIdx - позиция в уроке, nil - в конец
+	OrderIdx *int
+}
+
+func (uc *coursesUseCase) CreateEmbedBlock(ctx context.Context, params CreateEmbedBlockParams) (int64, error) {
+	courseID, err := uc.authorizeItem(ctx, params.ActorID, params.AccountID, models.TrashItemLesson, params.LessonID)
+	if err != nil {
+		return 0, err
+	}
+	title := strings.TrimSpace(params.Title)
+	if utf8.RuneCountInString(title) > maxBlockTitleLen {
+		return 0, uerrors.ErrInvalidBlockTitle
+	}
+	embed, err := media.ResolveEmbed(params.URL)
+	if err != nil {
+		return 0, uerrors.ErrUnsupportedEmbed
+	}
+
+	id, err := uc.cr.CreateEmbedBlock(ctx, repo.CreateEmbedBlockParams{
+		CreateBaseBlockParams: repo.CreateBaseBlockParams{LessonID: params.LessonID, OrderIdx: params.OrderIdx},
+		Embed:                 embed,
+		Title:                 title,
+	})
+	if err != nil {
+		return 0, courseError(err, "create embed block")
+	}
+
+	uc.recordChange(ctx, courseID, params.ActorID, params.AccountID, models.AuditActionContentCreate, models.TrashItemBlock, id,
+		nil, map[string]any{"lesson_id": params.LessonID, "type": models.BlockTypeEmbed, "provider": embed.Provider})
+	return id, nil
+}
+
+// checkBlockFile проверяет, что файл принадлежит аккаунту и его тип подходит блоку
+func (uc *coursesUseCase) checkBlockFile(ctx context.Context, accountID, fileID int64, allowed func(contentType string) bool) error {
+	file, err := uc.getAccountFile(ctx, accountID, fileID)
+	if err != nil {
+		return err
+	}
+	if !allowed(file.ContentType) {
+		return uerrors.ErrInvalidFileType
+	}
+	return nil
+}
+
+// OpenImage открывает файл картинки участнику курса или администратору аккаунта
+func (uc *coursesUseCase) OpenImage(ctx context.Context, params CourseItemParams) (models.File, io.ReadCloser, error) {
+	_, block, err := uc.publishedBlock(ctx, params.ActorID, params.AccountID, params.ID, models.BlockTypeImage)
+	if err != nil {
+		return models.File{}, nil, err
+	}
+	return uc.openBlockFile(ctx, block.FileID)
+}
+
+// OpenAttachment открывает файл вложения участнику курса или администратору аккаунта
+func (uc *coursesUseCase) OpenAttachment(ctx context.Context, params CourseItemParams) (models.File, io.ReadCloser, error) {
+	_, block, err := uc.publishedBlock(ctx, params.ActorID, params.AccountID, params.ID, models.BlockTypeAttachment)
+	if err != nil {
+		return models.File{}, nil, err
+	}
+	return uc.openBlockFile(ctx, block.FileID)
+}
+
+func (uc *coursesUseCase) openBlockFile(ctx context.Context, fileID *int64) (models.File, io.ReadCloser, error) {
+	if fileID == nil {
+		return models.File{}, nil, uerrors.ErrFileNotFound
+	}
+	file, err := uc.fr.GetFileInfo(ctx, *fileID)
+	if err != nil {
+		if errors.Is(err, repo.ErrFileNotFound) {
+			return models.File{}, nil, uerrors.ErrFileNotFound
+		}
+		return models.File{}, nil, courseError(err, "get block file")
+	}
+	return openFile(ctx, uc.fr, file)
+}
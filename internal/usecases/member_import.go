@@ -0,0 +1,378 @@
+package usecases
+
+import (
+	uerrors "chalk/internal/errors"
+	"chalk/internal/repo"
+	"chalk/internal/repo/models"
+	"chalk/pkg/log"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/mail"
+	"strings"
+)
+
+const (
+	memberImportMaxRows      = 5000
+	memberImportMaxGroupName = 100
+)
+
+type ImportRowStatus string
+
+const (
+	ImportRowCreated ImportRowStatus = "created"
+	ImportRowSkipped ImportRowStatus = "skipped"
+	ImportRowError   ImportRowStatus = "error"
+)
+
+type ImportRowResult string
+
+const (
+	ImportResultMember     ImportRowResult = "member"
+	ImportResultInvitation ImportRowResult = "invitation"
+)
+
+type MemberImportRow struct {
+	// Line - номер строки в файле, начиная с 1
+	Line   int
+	Email  string
+	Status ImportRowStatus
+	// Result - что создано или было бы создано для строки
+	Result  ImportRowResult
+	Message string
+}
+
+type MemberImportReport struct {
+	DryRun  bool
+	Rows    []MemberImportRow
+	Created int
+	Skipped int
+	Errored int
+}
+
+type ImportMembersParams struct {
+	ActorID   int64
+	AccountID int64
+	Reader    io.Reader
+	// DryRun - только проверить файл и построить отчёт, ничего не изменяя
+	DryRun bool
+}
+
+type memberImportLine struct {
+	line   int
+	email  string
+	name   string
+	role   models.AccountMembersRole
+	groups []string
+}
+
+// memberImportColumns - порядок колонок, если в файле нет заголовка
+var memberImportColumns = []string{"email", "name", "role", "groups"}
+
+// ImportMembers добавляет участников из CSV с колонками email, name, role, groups.
+// Зарегистрированные пользователи сразу становятся участниками, для остальных
+// создаются приглашения. Ошибка в строке не прерывает импорт остальных строк.
+func (uc *accountsUseCase) ImportMembers(ctx context.Context, params ImportMembersParams) (MemberImportReport, error) {
+	actorRole, err := getAccountRole(ctx, uc.ar, params.ActorID, params.AccountID)
+	if err != nil {
+		return MemberImportReport{}, err
+	}
+	if actorRole != models.AccountMembersRoleOwner && actorRole != models.AccountMembersRoleAdmin {
+		return MemberImportReport{}, uerrors.ErrPermissionDenied
+	}
+
+	if err := requireWritableAccount(ctx, uc.ar, params.AccountID); err != nil {
+		return MemberImportReport{}, err
+	}
+
+	lines, err := readMemberImportCSV(params.Reader)
+	if err != nil {
+		return MemberImportReport{}, err
+	}
+
+	freeSeats, err := uc.freeSeats(ctx, params.AccountID)
+	if err != nil {
+		return MemberImportReport{}, err
+	}
+
+	report := MemberImportReport{DryRun: params.DryRun}
+	seen := make(map[string]int, len(lines))
+	for _, l := range lines {
+		row := MemberImportRow{Line: l.line, Email: l.email}
+		if msg := validateMemberImportLine(l, actorRole); msg != "" {
+			row.Status, row.Message = ImportRowError, msg
+		} else if first, ok := seen[strings.ToLower(l.email)]; ok {
+			row.Status, row.Message = ImportRowSkipped, fmt.Sprintf("duplicate of line %d", first)
+		} else {
+			seen[strings.ToLower(l.email)] = l.line
+			row = uc.importMemberLine(ctx, params, l, freeSeats)
+		}
+
+		switch row.Status {
+		case ImportRowCreated:
+			report.Created++
+		case ImportRowSkipped:
+			report.Skipped++
+		case ImportRowError:
+			report.Errored++
+		}
+		report.Rows = append(report.Rows, row)
+	}
+	return report, nil
+}
+
+// importMemberLine обрабатывает проверенную строку, freeSeats - оставшиеся места, nil - без ограничений
+func (uc *accountsUseCase) importMemberLine(ctx context.Context, params ImportMembersParams, l memberImportLine, freeSeats *int) MemberImportRow {
+	row := MemberImportRow{Line: l.line, Email: l.email}
+	fail := func(err error) MemberImportRow {
+		log.Errorf("import members into account %d, line %d: %v", params.AccountID, l.line, err)
+		row.Status, row.Message = ImportRowError, "internal error"
+		return row
+	}
+
+	user, err := uc.ur.GetUserByEmail(ctx, l.email)
+	switch {
+	case err == nil:
+		row.Result = ImportResultMember
+		_, err := uc.ar.GetAccountMember(ctx, repo.GetAccountMemberParams{AccountID: params.AccountID, UserID: user.ID})
+		if err == nil {
+			row.Status, row.Message = ImportRowSkipped, "already a member"
+			return row
+		}
+		if !errors.Is(err, repo.ErrAccountMemberNotFound) {
+			return fail(err)
+		}
+	case errors.Is(err, repo.ErrRecordNotFound):
+		row.Result = ImportResultInvitation
+		invited, err := uc.ir.HasInvitation(ctx, params.AccountID, l.email)
+		if err != nil {
+			return fail(err)
+		}
+		if invited {
+			row.Status, row.Message = ImportRowSkipped, "already invited"
+			return row
+		}
+	default:
+		return fail(err)
+	}
+
+	if freeSeats != nil {
+		if *freeSeats <= 0 {
+			row.Status, row.Message = ImportRowError, uerrors.ErrSeatLimitReached.Error()
+			return row
+		}
+		*freeSeats--
+	}
+
+	row.Status = ImportRowCreated
+	if params.DryRun {
+		return row
+	}
+
+	if row.Result == ImportResultMember {
+		err = uc.importMember(ctx, params, user.ID, l)
+	} else {
+		err = uc.importInvitation(ctx, params, l)
+	}
+	if err != nil {
+		switch {
+		case errors.Is(err, repo.ErrUniqueViolation):
+			row.Status, row.Message = ImportRowSkipped, "added concurrently"
+			return row
+		case errors.Is(err, repo.ErrSeatLimitReached):
+			// места заняли параллельно после подсчёта
+			row.Status, row.Message = ImportRowError, uerrors.ErrSeatLimitReached.Error()
+			return row
+		}
+		return fail(err)
+	}
+	return row
+}
+
+func (uc *accountsUseCase) importMember(ctx context.Context, params ImportMembersParams, userID int64, l memberImportLine) error {
+	// участник и его группы добавляются вместе, иначе повторный импорт не назначил бы группы
+	err := uc.ar.AddAccountMember(ctx, repo.AddAccountMemberParams{
+		UserID:    userID,
+		AccountID: params.AccountID,
+		Role:      l.role,
+		Groups:    l.groups,
+	})
+	if err != nil {
+		return err
+	}
+
+	recordAudit(ctx, uc.aur, auditRecord{
+		AccountID:   params.AccountID,
+		ActorUserID: params.ActorID,
+		Action:      models.AuditActionMemberAdd,
+		TargetType:  models.AuditTargetMember,
+		TargetID:    &userID,
+		After:       map[string]any{"role": l.role, "groups": l.groups},
+	})
+	uc.notifyMemberAdded(ctx, params.AccountID, userID)
+	return nil
+}
+
+func (uc *accountsUseCase) importInvitation(ctx context.Context, params ImportMembersParams, l memberImportLine) error {
+	id, err := uc.ir.CreateInvitation(ctx, repo.CreateInvitationParams{
+		AccountID: params.AccountID,
+		Email:     l.email,
+		Name:      l.name,
+		Role:      l.role,
+		Groups:    l.groups,
+		InvitedBy: params.ActorID,
+	})
+	if err != nil {
+		return err
+	}
+
+	recordAudit(ctx, uc.aur, auditRecord{
+		AccountID:   params.AccountID,
+		ActorUserID: params.ActorID,
+		Action:      models.AuditActionMemberInvite,
+		TargetType:  models.AuditTargetInvitation,
+		TargetID:    &id,
+		After:       map[string]any{"email": l.email, "role": l.role, "groups": l.groups},
+	})
+	uc.notifyInvited(ctx, params.AccountID, l)
+	return nil
+}
+
+const invitedTemplate = "Здравствуйте%s!\n\nВас пригласили в %s. Зарегистрируйтесь с адресом %s, чтобы присоединиться."
+
+// notifyInvited отправляет приглашение, ошибка отправки только логируется
+func (uc *accountsUseCase) notifyInvited(ctx context.Context, accountID int64, l memberImportLine) {
+	name, err := uc.accountDisplayName(ctx, accountID)
+	if err != nil {
+		log.Warnf("notify invited: %v", err)
+		return
+	}
+	greeting := ""
+	if l.name != "" {
+		greeting = ", " + l.name
+	}
+
+	err = uc.mailer.Send(ctx, accountMail{
+		AccountID: accountID,
+		To:        l.email,
+		Subject:   fmt.Sprintf("Приглашение в %s", name),
+		Body:      fmt.Sprintf(invitedTemplate, greeting, name, l.email),
+	})
+	if err != nil {
+		log.Warnf("notify invited: %v", err)
+	}
+}
+
+// freeSeats возвращает число свободных мест по тарифу, nil - без ограничений
+func (uc *accountsUseCase) freeSeats(ctx context.Context, accountID int64) (*int, error) {
+	sub, err := uc.br.GetSubscription(ctx, accountID)
+	if err != nil {
+		if errors.Is(err, repo.ErrAccountNotFound) {
+			return nil, uerrors.ErrAccountNotFound
+		}
+		return nil, fmt.Errorf("failed to get subscription: %w", err)
+	}
+	if sub.Plan.MaxSeats == nil {
+		return nil, nil
+	}
+	seats, err := uc.br.CountAccountSeats(ctx, accountID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count seats: %w", err)
+	}
+	free := *sub.Plan.MaxSeats - seats
+	return &free, nil
+}
+
+func validateMemberImportLine(l memberImportLine, actorRole models.AccountMembersRole) string {
+	addr, err := mail.ParseAddress(l.email)
+	if err != nil || addr.Address != l.email {
+		return "invalid email"
+	}
+	if !isAssignableRole(l.role) {
+		return uerrors.ErrInvalidRole.Error()
+	}
+	// Только владелец может назначать админов
+	if l.role == models.AccountMembersRoleAdmin && actorRole != models.AccountMembersRoleOwner {
+		return "only the owner can add admins"
+	}
+	for _, g := range l.groups {
+		if len([]rune(g)) > memberImportMaxGroupName {
+			return fmt.Sprintf("group name is longer than %d characters", memberImportMaxGroupName)
+		}
+	}
+	return ""
+}
+
+// readMemberImportCSV читает строки файла. Заголовок необязателен: если первая
+// строка начинается с email, колонки берутся из неё, иначе используется порядок по умолчанию.
+func readMemberImportCSV(r io.Reader) ([]memberImportLine, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+
+	records, err := cr.ReadAll()
+	if err != nil {
+		return nil, uerrors.ErrInvalidImportFile
+	}
+
+	columns := memberImportColumns
+	offset := 0
+	if len(records) > 0 && len(records[0]) > 0 {
+		// Excel сохраняет UTF-8 с BOM
+		records[0][0] = strings.TrimPrefix(records[0][0], "\ufeff")
+	}
+	if len(records) > 0 && len(records[0]) > 0 && strings.EqualFold(strings.TrimSpace(records[0][0]), "email") {
+		columns = make([]string, len(records[0]))
+		for i, c := range records[0] {
+			columns[i] = strings.ToLower(strings.TrimSpace(c))
+		}
+		records, offset = records[1:], 1
+	}
+	if len(records) > memberImportMaxRows {
+		return nil, uerrors.ErrImportTooLarge
+	}
+
+	lines := make([]memberImportLine, 0, len(records))
+	for i, rec := range records {
+		l := memberImportLine{line: i + 1 + offset}
+		for j, v := range rec {
+			if j >= len(columns) {
+				break
+			}
+			v = strings.TrimSpace(v)
+			switch columns[j] {
+			case "email":
+				l.email = v
+			case "name":
+				l.name = v
+			case "role":
+				l.role = models.AccountMembersRole(strings.ToLower(v))
+			case "groups":
+				l.groups = splitGroups(v)
+			}
+		}
+		if l.role == "" {
+			l.role = models.AccountMembersRoleMember
+		}
+		lines = append(lines, l)
+	}
+	return lines, nil
+}
+
+// splitGroups разбирает список групп, разделённых ; или |
+func splitGroups(s string) []string {
+	parts := strings.FieldsFunc(s, func(r rune) bool { return r == ';' || r == '|' })
+	groups := make([]string, 0, len(parts))
+	seen := make(map[string]struct{}, len(parts))
+	for _, p := range parts {
+		p = strings.TrimSpace(p)
+		if _, ok := seen[p]; p == "" || ok {
+			continue
+		}
+		seen[p] = struct{}{}
+		groups = append(groups, p)
+	}
+	return groups
+}
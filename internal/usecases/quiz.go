@@ -0,0 +1,189 @@
+package usecases
+
+import (
+	uerrors "chalk/internal/errors"
+	"chalk/internal/repo"
+	"chalk/internal/repo/models"
+	"chalk/pkg/quiz"
+	"context"
+	"errors"
+	"fmt"
+)
+
+// SaveQuizResult - сохранённый тест или ошибки, из-за которых он не сохранён
+type SaveQuizResult struct {
+	BlockID int64
+	// Problems заполняется вместе с ErrInvalidQuiz
+	Problems []quiz.Problem
+}
+
+type CreateQuizBlockParams struct {
+	ActorID   int64
+	AccountID int64
+	LessonID  int64
+	Quiz      quiz.Quiz
+	// OrderIdx - позиция в уроке, nil - в конец
+	OrderIdx *int
+}
+
+func (uc *coursesUseCase) CreateQuizBlock(ctx context.Context, params CreateQuizBlockParams) (SaveQuizResult, error) {
+	courseID, err := uc.authorizeItem(ctx, params.ActorID, params.AccountID, models.TrashItemLesson, params.LessonID)
+	if err != nil {
+		return SaveQuizResult{}, err
+	}
+	q := params.Quiz
+	if problems := validateQuiz(&q); len(problems) > 0 {
+		return SaveQuizResult{Problems: problems}, uerrors.ErrInvalidQuiz
+	}
+
+	id, err := uc.cr.CreateQuizBlock(ctx, repo.CreateQuizBlockParams{
+		CreateBaseBlockParams: repo.CreateBaseBlockParams{LessonID: params.LessonID, OrderIdx: params.OrderIdx},
+		Quiz:                  q,
+	})
+	if err != nil {
+		return SaveQuizResult{}, courseError(err, "create quiz block")
+	}
+
+	uc.recordChange(ctx, courseID, params.ActorID, params.AccountID, models.AuditActionContentCreate, models.TrashItemBlock, id,
+		nil, map[string]any{"lesson_id": params.LessonID, "type": models.BlockTypeQuiz, "questions": len(q.Questions)})
+	return SaveQuizResult{BlockID: id}, nil
+}
+
+type UpdateQuizBlockParams struct {
+	ActorID   int64
+	AccountID int64
+	BlockID   int64
+	Quiz      quiz.Quiz
+}
+
+// UpdateQuizBlock заменяет тест целиком. Прошлые попытки сохраняют свои результаты.
+func (uc *coursesUseCase) UpdateQuizBlock(ctx context.Context, params UpdateQuizBlockParams) (SaveQuizResult, error) {
+	courseID, err := uc.authorizeItem(ctx, params.ActorID, params.AccountID, models.TrashItemBlock, params.BlockID)
+	if err != nil {
+		return SaveQuizResult{}, err
+	}
+	q := params.Quiz
+	if problems := validateQuiz(&q); len(problems) > 0 {
+		return SaveQuizResult{Problems: problems}, uerrors.ErrInvalidQuiz
+	}
+
+	err = uc.cr.UpdateQuizBlock(ctx, repo.UpdateQuizBlockParams{
+		BlockID: params.BlockID,
+		Quiz:    q,
+		Author:  revisionAuthor(params.AccountID, params.ActorID),
+	})
+	if err != nil {
+		return SaveQuizResult{}, courseError(err, "update quiz block")
+	}
+
+	uc.recordChange(ctx, courseID, params.ActorID, params.AccountID, models.AuditActionContentUpdate, models.TrashItemBlock, params.BlockID, nil, nil)
+	return SaveQuizResult{BlockID: params.BlockID}, nil
+}
+
+func validateQuiz(q *quiz.Quiz) []quiz.Problem {
+	q.Normalize()
+	return q.Validate()
+}
+
+// QuizState - тест без ответов и попытки вызывающего
+type QuizState struct {
+	BlockID  int64
+	Quiz     quiz.Quiz
+	Attempts []models.QuizAttempt
+	// AttemptsLeft - nil без ограничения
+	AttemptsLeft *int
+}
+
+// GetQuiz возвращает тест участнику курса или администратору аккаунта
+func (uc *coursesUseCase) GetQuiz(ctx context.Context, params CourseItemParams) (QuizState, error) {
+	block, q, err := uc.publishedQuiz(ctx, params.ActorID, params.AccountID, params.ID)
+	if err != nil {
+		return QuizState{}, err
+	}
+	attempts, err := uc.pr.GetQuizAttempts(ctx, block.ID, params.ActorID)
+	if err != nil {
+		return QuizState{}, fmt.Errorf("failed to get quiz attempts: %w", err)
+	}
+
+	state := QuizState{BlockID: block.ID, Quiz: q.WithoutAnswers(), Attempts: attempts}
+	if q.MaxAttempts != nil {
+		left := max(*q.MaxAttempts-len(attempts), 0)
+		state.AttemptsLeft = &left
+	}
+	return state, nil
+}
+
+// publishedQuiz возвращает тест блока из опубликованной версии курса
+func (uc *coursesUseCase) publishedQuiz(ctx context.Context, actorID, accountID, blockID int64) (models.BlockSnapshot, quiz.Quiz, error) {
+	_, block, err := uc.publishedBlock(ctx, actorID, accountID, blockID, models.BlockTypeQuiz)
+	if err != nil {
+		return models.BlockSnapshot{}, quiz.Quiz{}, err
+	}
+	if block.Quiz == nil {
+		return models.BlockSnapshot{}, quiz.Quiz{}, uerrors.ErrBlockNotFound
+	}
+	return block, *block.Quiz, nil
+}
+
+type SubmitQuizAttemptParams struct {
+	ActorID   int64
+	AccountID int64
+	BlockID   int64
+	Answers   []quiz.Answer
+}
+
+type QuizAttemptResult struct {
+	Attempt  models.QuizAttempt
+	Progress models.BlockProgress
+}
+
+// SubmitQuizAttempt проверяет ответы и сохраняет попытку. Блок завершается первой
+// сданной попыткой, в прохождении остаётся лучший результат.
+func (uc *coursesUseCase) SubmitQuizAttempt(ctx context.Context, params SubmitQuizAttemptParams) (QuizAttemptResult, error) {
+	block, q, err := uc.publishedQuiz(ctx, params.ActorID, params.AccountID, params.BlockID)
+	if err != nil {
+		return QuizAttemptResult{}, err
+	}
+
+	result, err := q.Grade(params.Answers)
+	if err != nil {
+		if errors.Is(err, quiz.ErrInvalidAnswer) {
+			return QuizAttemptResult{}, uerrors.ErrInvalidQuizAnswer
+		}
+		return QuizAttemptResult{}, fmt.Errorf("failed to grade quiz %d: %w", block.ID, err)
+	}
+
+	attempt, err := uc.pr.CreateQuizAttempt(ctx, repo.CreateQuizAttemptParams{
+		BlockID:     block.ID,
+		UserID:      params.ActorID,
+		Answers:     params.Answers,
+		Result:      result,
+		MaxAttempts: q.MaxAttempts,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, repo.ErrQuizAttemptLimitReached):
+			return QuizAttemptResult{}, uerrors.ErrQuizAttemptLimitReached
+		case errors.Is(err, repo.ErrQuizAttemptConflict):
+			return QuizAttemptResult{}, uerrors.ErrQuizAttemptConflict
+		case errors.Is(err, repo.ErrBlockNotFound):
+			return QuizAttemptResult{}, uerrors.ErrBlockNotFound
+		}
+		return QuizAttemptResult{}, fmt.Errorf("failed to save quiz attempt: %w", err)
+	}
+
+	progress, err := uc.pr.UpdateBlockProgress(ctx, repo.UpdateBlockProgressParams{
+		BlockID:   block.ID,
+		UserID:    params.ActorID,
+		Completed: result.Passed,
+		Score:     &result.Score,
+		KeepBest:  true,
+	})
+	if err != nil {
+		if errors.Is(err, repo.ErrBlockNotFound) {
+			return QuizAttemptResult{}, uerrors.ErrBlockNotFound
+		}
+		return QuizAttemptResult{}, fmt.Errorf("failed to update block progress: %w", err)
+	}
+	return QuizAttemptResult{Attempt: attempt, Progress: progress}, nil
+}
@@ -0,0 +1,225 @@
+package usecases
+
+import (
+	uerrors "chalk/internal/errors"
+	"chalk/internal/repo"
+	"chalk/internal/repo/models"
+	"chalk/pkg/assignment"
+	"chalk/pkg/codeexercise"
+	"chalk/pkg/quiz"
+	"chalk/pkg/textdiff"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+const (
+	revisionsDefaultPageSize = 50
+	revisionsMaxPageSize     = 200
+)
+
+type GetRevisionsParams struct {
+	ActorID   int64
+	AccountID int64
+	ItemType  models.TrashItemType
+	ItemID    int64
+	BeforeID  *int64
+	Limit     int
+}
+
+type RevisionsPage struct {
+	Revisions []models.Revision
+	// NextBeforeID - курсор следующей страницы, nil если правок больше нет
+	NextBeforeID *int64
+}
+
+// GetRevisions возвращает историю правок элемента курса, новые первыми
+func (uc *coursesUseCase) GetRevisions(ctx context.Context, params GetRevisionsParams) (RevisionsPage, error) {
+	if err := uc.authorize(ctx, params.ActorID, params.AccountID, false); err != nil {
+		return RevisionsPage{}, err
+	}
+	if _, err := uc.checkItem(ctx, params.AccountID, params.ItemType, params.ItemID); err != nil {
+		return RevisionsPage{}, err
+	}
+
+	limit := params.Limit
+	switch {
+	case limit <= 0:
+		limit = revisionsDefaultPageSize
+	case limit > revisionsMaxPageSize:
+		limit = revisionsMaxPageSize
+	}
+
+	revisions, err := uc.rr.GetRevisions(ctx, repo.GetRevisionsParams{
+		ItemType: params.ItemType,
+		ItemID:   params.ItemID,
+		BeforeID: params.BeforeID,
+		Limit:    limit + 1,
+	})
+	if err != nil {
+		return RevisionsPage{}, fmt.Errorf("failed to get revisions: %w", err)
+	}
+
+	page := RevisionsPage{Revisions: revisions}
+	if len(revisions) > limit {
+		page.Revisions = revisions[:limit]
+		next := page.Revisions[limit-1].ID
+		page.NextBeforeID = &next
+	}
+	return page, nil
+}
+
+type DiffRevisionsParams struct {
+	ActorID   int64
+	AccountID int64
+	BlockID   int64
+	FromID    int64
+	ToID      int64
+}
+
+// DiffRevisions сравнивает содержимое текстового блока после двух правок
+func (uc *coursesUseCase) DiffRevisions(ctx context.Context, params DiffRevisionsParams) ([]textdiff.Line, error) {
+	if err := uc.authorize(ctx, params.ActorID, params.AccountID, false); err != nil {
+		return nil, err
+	}
+	if _, err := uc.checkItem(ctx, params.AccountID, models.TrashItemBlock, params.BlockID); err != nil {
+		return nil, err
+	}
+
+	from, err := uc.getRevision(ctx, params.AccountID, params.FromID)
+	if err != nil {
+		return nil, err
+	}
+	to, err := uc.getRevision(ctx, params.AccountID, params.ToID)
+	if err != nil {
+		return nil, err
+	}
+	for _, rev := range []models.Revision{from, to} {
+		if rev.ItemType != models.TrashItemBlock || rev.ItemID != params.BlockID || rev.Field != models.RevisionFieldContent {
+			return nil, uerrors.ErrRevisionsNotComparable
+		}
+	}
+
+	return textdiff.Lines(from.Value, to.Value), nil
+}
+
+type RestoreRevisionParams struct {
+	ActorID    int64
+	AccountID  int64
+	RevisionID int64
+}
+
+// RestoreRevision возвращает элементу значение поля после указанной правки.
+// Восстановление идёт обычным путём изменения и само попадает в историю.
+func (uc *coursesUseCase) RestoreRevision(ctx context.Context, params RestoreRevisionParams) error {
+	if err := uc.authorize(ctx, params.ActorID, params.AccountID, true); err != nil {
+		return err
+	}
+	rev, err := uc.getRevision(ctx, params.AccountID, params.RevisionID)
+	if err != nil {
+		return err
+	}
+
+	item := CourseItemParams{ActorID: params.ActorID, AccountID: params.AccountID, ID: rev.ItemID}
+	switch rev.Field {
+	case models.RevisionFieldName:
+		rename := RenameCourseItemParams{ActorID: item.ActorID, AccountID: item.AccountID, ID: item.ID, Name: rev.Value}
+		switch rev.ItemType {
+		case models.TrashItemCourse:
+			return uc.UpdateCourse(ctx, rename)
+		case models.TrashItemModule:
+			return uc.UpdateModule(ctx, rename)
+		case models.TrashItemLesson:
+			return uc.UpdateLesson(ctx, rename)
+		}
+	case models.RevisionFieldContent:
+		if rev.ItemType == models.TrashItemBlock {
+			return uc.UpdateTextBlock(ctx, UpdateTextBlockParams{
+				ActorID:   item.ActorID,
+				AccountID: item.AccountID,
+				BlockID:   item.ID,
+				Content:   rev.Value,
+			})
+		}
+	case models.RevisionFieldQuiz:
+		var q quiz.Quiz
+		if err := json.Unmarshal([]byte(rev.Value), &q); err != nil {
+			return fmt.Errorf("failed to parse revision %d quiz: %w", rev.ID, err)
+		}
+		_, err := uc.UpdateQuizBlock(ctx, UpdateQuizBlockParams{
+			ActorID:   item.ActorID,
+			AccountID: item.AccountID,
+			BlockID:   item.ID,
+			Quiz:      q,
+		})
+		return err
+	case models.RevisionFieldAssignment:
+		var a assignment.Assignment
+		if err := json.Unmarshal([]byte(rev.Value), &a); err != nil {
+			return fmt.Errorf("failed to parse revision %d assignment: %w", rev.ID, err)
+		}
+		_, err := uc.UpdateAssignmentBlock(ctx, UpdateAssignmentBlockParams{
+			ActorID:    item.ActorID,
+			AccountID:  item.AccountID,
+			BlockID:    item.ID,
+			Assignment: a,
+		})
+		return err
+	case models.RevisionFieldCodeExercise:
+		var e codeexercise.Exercise
+		if err := json.Unmarshal([]byte(rev.Value), &e); err != nil {
+			return fmt.Errorf("failed to parse revision %d code exercise: %w", rev.ID, err)
+		}
+		_, err := uc.UpdateCodeExerciseBlock(ctx, UpdateCodeExerciseBlockParams{
+			ActorID:   item.ActorID,
+			AccountID: item.AccountID,
+			BlockID:   item.ID,
+			Exercise:  e,
+		})
+		return err
+	case models.RevisionFieldParentID:
+		parentID, err := strconv.ParseInt(rev.Value, 10, 64)
+		if err != nil {
+			return fmt.Errorf("failed to parse revision %d parent_id: %w", rev.ID, err)
+		}
+		move := MoveCourseItemParams{ActorID: item.ActorID, AccountID: item.AccountID, ID: item.ID, ParentID: parentID}
+		switch rev.ItemType {
+		case models.TrashItemLesson:
+			return uc.MoveLesson(ctx, move)
+		case models.TrashItemBlock:
+			return uc.MoveBlock(ctx, move)
+		}
+	case models.RevisionFieldOrderIdx:
+		orderIdx, err := strconv.Atoi(rev.Value)
+		if err != nil {
+			return fmt.Errorf("failed to parse revision %d order_idx: %w", rev.ID, err)
+		}
+		reorder := ReorderCourseItemParams{ActorID: item.ActorID, AccountID: item.AccountID, ID: item.ID, OrderIdx: orderIdx}
+		switch rev.ItemType {
+		case models.TrashItemModule:
+			return uc.ReorderModule(ctx, reorder)
+		case models.TrashItemLesson:
+			return uc.ReorderLesson(ctx, reorder)
+		case models.TrashItemBlock:
+			return uc.ReorderBlock(ctx, reorder)
+		}
+	}
+	return fmt.Errorf("unsupported revision %d: %s %s", rev.ID, rev.ItemType, rev.Field)
+}
+
+// getRevision возвращает правку аккаунта. Правка другого аккаунта для вызывающего не существует.
+func (uc *coursesUseCase) getRevision(ctx context.Context, accountID, revisionID int64) (models.Revision, error) {
+	rev, err := uc.rr.GetRevision(ctx, revisionID)
+	if err != nil {
+		if errors.Is(err, repo.ErrRevisionNotFound) {
+			return models.Revision{}, uerrors.ErrRevisionNotFound
+		}
+		return models.Revision{}, fmt.Errorf("failed to get revision: %w", err)
+	}
+	if rev.AccountID != accountID {
+		return models.Revision{}, uerrors.ErrRevisionNotFound
+	}
+	return rev, nil
+}
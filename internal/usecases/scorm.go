@@ -0,0 +1,429 @@
+package usecases
+
+import (
+	uerrors "chalk/internal/errors"
+	"chalk/internal/repo"
+	"chalk/internal/repo/models"
+	"chalk/pkg/coursearchive"
+	"chalk/pkg/imspackage"
+	"chalk/pkg/log"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// scormSessionTTL - сколько живёт запуск пакета, после истечения пакет нужно запустить заново
+	scormSessionTTL = 8 * time.Hour
+
+	maxScormCommitValues = 1000
+	maxScormKeyLen       = 255
+	// maxScormValueLen - suspend_data в SCORM 2004 до 64000 символов
+	maxScormValueLen = 64000
+)
+
+type CreateScormBlockParams struct {
+	ActorID   int64
+	AccountID int64
+	LessonID  int64
+	// FileID - zip пакета SCORM
+	FileID int64
+	// OrderIdx - позиция в уроке, nil - в конец
+	OrderIdx *int
+}
+
+func (uc *coursesUseCase) CreateScormBlock(ctx context.Context, params CreateScormBlockParams) (int64, error) {
+	courseID, err := uc.authorizeItem(ctx, params.ActorID, params.AccountID, models.TrashItemLesson, params.LessonID)
+	if err != nil {
+		return 0, err
+	}
+	file, err := uc.getAccountFile(ctx, params.AccountID, params.FileID)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := uc.parseScormPackage(ctx, file); err != nil {
+		return 0, err
+	}
+
+	id, err := uc.cr.CreateScormBlock(ctx, repo.CreateScormBlockParams{
+		CreateBaseBlockParams: repo.CreateBaseBlockParams{LessonID: params.LessonID, OrderIdx: params.OrderIdx},
+		FileID:                params.FileID,
+	})
+	if err != nil {
+		return 0, courseError(err, "create scorm block")
+	}
+
+	uc.recordChange(ctx, courseID, params.ActorID, params.AccountID, models.AuditActionContentCreate, models.TrashItemBlock, id,
+		nil, map[string]any{"lesson_id": params.LessonID, "type": models.BlockTypeSCORM, "file_id": params.FileID})
+	return id, nil
+}
+
+func (uc *coursesUseCase) parseScormPackage(ctx context.Context, file models.File) (imspackage.SCORM, error) {
+	obj, err := uc.fr.OpenFileAt(ctx, file)
+	if err != nil {
+		return imspackage.SCORM{}, fmt.Errorf("failed to open package: %w", err)
+	}
+	defer obj.Close()
+
+	pkg, err := imspackage.ParseSCORM(obj, file.Size)
+	if err != nil {
+		var verr *coursearchive.ValidationError
+		if errors.As(err, &verr) {
+			log.Debugf("file %d is not a scorm package: %v", file.ID, verr)
+			return imspackage.SCORM{}, uerrors.ErrInvalidScormPackage
+		}
+		return imspackage.SCORM{}, fmt.Errorf("failed to parse package: %w", err)
+	}
+	return pkg, nil
+}
+
+type ScormLaunch struct {
+	SessionID  string
+	Version    imspackage.SCORMVersion
+	LaunchPath string
+}
+
+// LaunchScorm начинает сессию пакета для участника курса или администратора аккаунта
+func (uc *coursesUseCase) LaunchScorm(ctx context.Context, params CourseItemParams) (ScormLaunch, error) {
+	courseID, block, err := uc.publishedBlock(ctx, params.ActorID, params.AccountID, params.ID, models.BlockTypeSCORM)
+	if err != nil {
+		return ScormLaunch{}, err
+	}
+	if block.FileID == nil {
+		return ScormLaunch{}, uerrors.ErrBlockNotFound
+	}
+	file, err := uc.fr.GetFileInfo(ctx, *block.FileID)
+	if err != nil {
+		return ScormLaunch{}, fmt.Errorf("failed to get package file: %w", err)
+	}
+	pkg, err := uc.parseScormPackage(ctx, file)
+	if err != nil {
+		return ScormLaunch{}, err
+	}
+
+	sessionID := genToken(32)
+	err = uc.ssr.Set(ctx, sessionID, models.ScormSession{
+		AccountID:  params.AccountID,
+		CourseID:   courseID,
+		BlockID:    block.ID,
+		UserID:     params.ActorID,
+		FileID:     file.ID,
+		Version:    string(pkg.Version),
+		LaunchPath: pkg.LaunchPath,
+	}, scormSessionTTL)
+	if err != nil {
+		return ScormLaunch{}, fmt.Errorf("failed to save scorm session: %w", err)
+	}
+	return ScormLaunch{SessionID: sessionID, Version: pkg.Version, LaunchPath: pkg.LaunchPath}, nil
+}
+
+// authorizeLearner проверяет, что элемент принадлежит аккаунту, а участник записан
+// на его курс. Администраторам аккаунта доступны все курсы.
+func (uc *coursesUseCase) authorizeLearner(ctx context.Context, actorID, accountID int64, itemType models.TrashItemType, id int64) (int64, error) {
+	role, err := getAccountRole(ctx, uc.ar, actorID, accountID)
+	if err != nil {
+		return 0, err
+	}
+	courseID, err := uc.checkItem(ctx, accountID, itemType, id)
+	if err != nil {
+		return 0, err
+	}
+	if role == models.AccountMembersRoleOwner || role == models.AccountMembersRoleAdmin {
+		return courseID, nil
+	}
+	enrolled, err := uc.cr.IsUserEnrolled(ctx, actorID, courseID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to check enrollment: %w", err)
+	}
+	if !enrolled {
+		// курс, на который участник не записан, для него не существует
+		return 0, courseError(courseItemNotFound[itemType], "check enrollment")
+	}
+	return courseID, nil
+}
+
+// publishedBlock проверяет доступ как authorizeLearner и возвращает блок из текущей опубликованной
+// версии курса: участники видят и сдают опубликованное содержимое, а не черновик. Блока другого
+// типа или которого нет в версии для участника не существует.
+func (uc *coursesUseCase) publishedBlock(ctx context.Context, actorID, accountID, blockID int64, blockType models.BlockType) (int64, models.BlockSnapshot, error) {
+	courseID, err := uc.authorizeLearner(ctx, actorID, accountID, models.TrashItemBlock, blockID)
+	if err != nil {
+		return 0, models.BlockSnapshot{}, err
+	}
+	version, err := uc.vr.GetPublishedCourseVersion(ctx, courseID)
+	if err != nil {
+		return 0, models.BlockSnapshot{}, courseError(err, "get published course")
+	}
+	for _, m := range version.Snapshot.Modules {
+		for _, l := range m.Lessons {
+			for _, b := range l.Blocks {
+				if b.ID == blockID && b.Type == blockType {
+					return courseID, b, nil
+				}
+			}
+		}
+	}
+	return 0, models.BlockSnapshot{}, uerrors.ErrBlockNotFound
+}
+
+func (uc *coursesUseCase) getScormSession(ctx context.Context, sessionID string) (models.ScormSession, error) {
+	session, err := uc.ssr.Get(ctx, sessionID)
+	if err != nil {
+		if errors.Is(err, repo.ErrRecordNotFound) {
+			return models.ScormSession{}, uerrors.ErrScormSessionNotFound
+		}
+		return models.ScormSession{}, fmt.Errorf("failed to get scorm session: %w", err)
+	}
+	return session, nil
+}
+
+type ScormFile struct {
+	Reader      io.ReadCloser
+	ContentType string
+	Size        int64
+}
+
+// OpenScormFile открывает файл пакета сессии. Файлы читаются прямо из zip в хранилище.
+func (uc *coursesUseCase) OpenScormFile(ctx context.Context, sessionID, name string) (ScormFile, error) {
+	session, err := uc.getScormSession(ctx, sessionID)
+	if err != nil {
+		return ScormFile{}, err
+	}
+	file, err := uc.fr.GetFileInfo(ctx, session.FileID)
+	if err != nil {
+		if errors.Is(err, repo.ErrFileNotFound) {
+			return ScormFile{}, uerrors.ErrFileNotFound
+		}
+		return ScormFile{}, fmt.Errorf("failed to get package file: %w", err)
+	}
+
+	obj, err := uc.fr.OpenFileAt(ctx, file)
+	if err != nil {
+		return ScormFile{}, fmt.Errorf("failed to open package: %w", err)
+	}
+	rc, size, err := imspackage.OpenSCORMFile(obj, file.Size, name)
+	if err != nil {
+		obj.Close()
+		if errors.Is(err, imspackage.ErrFileNotFound) {
+			return ScormFile{}, uerrors.ErrFileNotFound
+		}
+		return ScormFile{}, fmt.Errorf("failed to open package file %s: %w", name, err)
+	}
+	return ScormFile{
+		Reader:      &packageFileReader{ReadCloser: rc, obj: obj},
+		ContentType: imspackage.ContentType(name),
+		Size:        size,
+	}, nil
+}
+
+// packageFileReader закрывает вместе с файлом пакета объект zip в хранилище
+type packageFileReader struct {
+	io.ReadCloser
+	obj io.Closer
+}
+
+func (r *packageFileReader) Close() error {
+	err := r.ReadCloser.Close()
+	if objErr := r.obj.Close(); err == nil {
+		err = objErr
+	}
+	return err
+}
+
+type ScormRuntime struct {
+	Version    string
+	LaunchPath string
+	// Values - сохранённые значения cmi вместе со значениями, которые задаёт LMS
+	Values map[string]string
+}
+
+// GetScormRuntime возвращает состояние, с которым пакет начинает сессию
+func (uc *coursesUseCase) GetScormRuntime(ctx context.Context, sessionID string) (ScormRuntime, error) {
+	session, err := uc.getScormSession(ctx, sessionID)
+	if err != nil {
+		return ScormRuntime{}, err
+	}
+	data, err := uc.pr.GetScormRuntimeData(ctx, session.BlockID, session.UserID)
+	if err != nil {
+		return ScormRuntime{}, fmt.Errorf("failed to get scorm runtime data: %w", err)
+	}
+	user, err := uc.ur.GetUserById(ctx, session.UserID)
+	if err != nil {
+		return ScormRuntime{}, fmt.Errorf("failed to get learner: %w", err)
+	}
+
+	values := data.Values
+	stored := len(values) > 0
+	for k, v := range scormDefaults[session.Version] {
+		if _, ok := values[k]; !ok {
+			values[k] = v
+		}
+	}
+	learner := scormLearnerKeys[session.Version]
+	values[learner.id] = strconv.FormatInt(user.ID, 10)
+	values[learner.name] = user.Name
+	// продолжение возможно, только если пакет сам завершил прошлую сессию с exit=suspend
+	entry := "ab-initio"
+	if stored {
+		entry = ""
+		if values[learner.exit] == "suspend" {
+			entry = "resume"
+		}
+	}
+	values[learner.entry] = entry
+	return ScormRuntime{Version: session.Version, LaunchPath: session.LaunchPath, Values: values}, nil
+}
+
+type CommitScormRuntimeParams struct {
+	SessionID string
+	Values    map[string]string
+}
+
+// CommitScormRuntime сохраняет изменённые пакетом значения cmi и переносит
+// статус и результат пакета в прохождение блока
+func (uc *coursesUseCase) CommitScormRuntime(ctx context.Context, params CommitScormRuntimeParams) (models.BlockProgress, error) {
+	session, err := uc.getScormSession(ctx, params.SessionID)
+	if err != nil {
+		return models.BlockProgress{}, err
+	}
+	values, err := writableScormValues(params.Values)
+	if err != nil {
+		return models.BlockProgress{}, err
+	}
+
+	data, err := uc.pr.SaveScormRuntimeData(ctx, repo.SaveScormRuntimeDataParams{
+		BlockID: session.BlockID,
+		UserID:  session.UserID,
+		Values:  values,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, repo.ErrScormDataTooLarge):
+			return models.BlockProgress{}, uerrors.ErrScormDataTooLarge
+		case errors.Is(err, repo.ErrBlockNotFound):
+			return models.BlockProgress{}, uerrors.ErrBlockNotFound
+		}
+		return models.BlockProgress{}, fmt.Errorf("failed to save scorm runtime data: %w", err)
+	}
+
+	completed, score := scormProgress(session.Version, data.Values)
+	progress, err := uc.pr.UpdateBlockProgress(ctx, repo.UpdateBlockProgressParams{
+		BlockID:   session.BlockID,
+		UserID:    session.UserID,
+		Completed: completed,
+		Score:     score,
+	})
+	if err != nil {
+		if errors.Is(err, repo.ErrBlockNotFound) {
+			return models.BlockProgress{}, uerrors.ErrBlockNotFound
+		}
+		return models.BlockProgress{}, fmt.Errorf("failed to update block progress: %w", err)
+	}
+	return progress, nil
+}
+
+// GetCourseProgress возвращает прохождение блоков курса вызывающим
+func (uc *coursesUseCase) GetCourseProgress(ctx context.Context, params CourseItemParams) ([]models.BlockProgress, error) {
+	if _, err := uc.authorizeLearner(ctx, params.ActorID, params.AccountID, models.TrashItemCourse, params.ID); err != nil {
+		return nil, err
+	}
+	progress, err := uc.pr.GetCourseProgress(ctx, params.ID, params.ActorID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get course progress: %w", err)
+	}
+	return progress, nil
+}
+
+var scormKeyRegexp = regexp.MustCompile(`^(cmi|adl)\.[A-Za-z0-9_.]+$`)
+
+// scormReadOnly - значения, которые задаёт LMS: пакет не может их изменить
+var scormReadOnly = map[string]bool{
+	"cmi.core.student_id": true, "cmi.core.student_name": true, "cmi.core.credit": true,
+	"cmi.core.entry": true, "cmi.core.lesson_mode": true, "cmi.core.total_time": true,
+	"cmi.learner_id": true, "cmi.learner_name": true, "cmi.credit": true,
+	"cmi.entry": true, "cmi.mode": true, "cmi.total_time": true,
+	"cmi.launch_data": true, "cmi.completion_threshold": true, "cmi.scaled_passing_score": true,
+	"cmi.max_time_allowed": true, "cmi.time_limit_action": true,
+}
+
+// writableScormValues проверяет значения от пакета и отбрасывает те, что задаёт LMS
+func writableScormValues(values map[string]string) (map[string]string, error) {
+	if len(values) > maxScormCommitValues {
+		return nil, uerrors.ErrInvalidScormData
+	}
+	res := make(map[string]string, len(values))
+	for k, v := range values {
+		if len(k) > maxScormKeyLen || !scormKeyRegexp.MatchString(k) || len(v) > maxScormValueLen {
+			return nil, uerrors.ErrInvalidScormData
+		}
+		if scormReadOnly[k] || strings.HasPrefix(k, "cmi.student_data.") ||
+			strings.HasSuffix(k, "._count") || strings.HasSuffix(k, "._children") || strings.HasSuffix(k, "._version") {
+			continue
+		}
+		res[k] = v
+	}
+	return res, nil
+}
+
+type scormKeys struct {
+	id, name, entry, exit string
+}
+
+var scormLearnerKeys = map[string]scormKeys{
+	string(imspackage.SCORM12):   {id: "cmi.core.student_id", name: "cmi.core.student_name", entry: "cmi.core.entry", exit: "cmi.core.exit"},
+	string(imspackage.SCORM2004): {id: "cmi.learner_id", name: "cmi.learner_name", entry: "cmi.entry", exit: "cmi.exit"},
+}
+
+var scormDefaults = map[string]map[string]string{
+	string(imspackage.SCORM12): {
+		"cmi.core.lesson_status": "not attempted",
+		"cmi.core.lesson_mode":   "normal",
+		"cmi.core.credit":        "credit",
+		"cmi.core.total_time":    "0000:00:00",
+	},
+	string(imspackage.SCORM2004): {
+		"cmi.completion_status": "unknown",
+		"cmi.success_status":    "unknown",
+		"cmi.mode":              "normal",
+		"cmi.credit":            "credit",
+		"cmi.total_time":        "PT0S",
+	},
+}
+
+// scormProgress переводит состояние пакета в завершение блока и результат в процентах
+func scormProgress(version string, values map[string]string) (bool, *float64) {
+	if version == string(imspackage.SCORM12) {
+		status := values["cmi.core.lesson_status"]
+		completed := status == "passed" || status == "completed"
+		return completed, scormScore(values["cmi.core.score.raw"], values["cmi.core.score.min"], values["cmi.core.score.max"])
+	}
+
+	success := values["cmi.success_status"]
+	completed := success == "passed" || (values["cmi.completion_status"] == "completed" && success != "failed")
+	if scaled, err := strconv.ParseFloat(values["cmi.score.scaled"], 64); err == nil && !math.IsNaN(scaled) {
+		score := math.Max(0, math.Min(1, scaled)) * 100
+		return completed, &score
+	}
+	return completed, scormScore(values["cmi.score.raw"], values["cmi.score.min"], values["cmi.score.max"])
+}
+
+// scormScore приводит raw к процентам по min и max. Без диапазона raw считается процентом, как в SCORM 1.2.
+func scormScore(raw, minValue, maxValue string) *float64 {
+	r, err := strconv.ParseFloat(raw, 64)
+	if err != nil || math.IsNaN(r) || math.IsInf(r, 0) {
+		return nil
+	}
+	lo, errMin := strconv.ParseFloat(minValue, 64)
+	hi, errMax := strconv.ParseFloat(maxValue, 64)
+	if errMin == nil && errMax == nil && hi > lo {
+		r = (r - lo) / (hi - lo) * 100
+	}
+	score := math.Max(0, math.Min(100, r))
+	return &score
+}
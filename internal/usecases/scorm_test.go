@@ -0,0 +1,124 @@
+package usecases
+
+import (
+	uerrors "chalk/internal/errors"
+	"chalk/pkg/imspackage"
+	"errors"
+	"fmt"
+	"maps"
+	"strings"
+	"testing"
+)
+
+func TestScormProgress(t *testing.T) {
+	scorm12, scorm2004 := string(imspackage.SCORM12), string(imspackage.SCORM2004)
+	tests := []struct {
+		name      string
+		version   string
+		values    map[string]string
+		completed bool
+		// score - nil, если результата нет
+		score *float64
+	}{
+		{"12 passed", scorm12, map[string]string{"cmi.core.lesson_status": "passed", "cmi.core.score.raw": "80"}, true, ptr(80.0)},
+		{"12 completed", scorm12, map[string]string{"cmi.core.lesson_status": "completed"}, true, nil},
+		{"12 failed", scorm12, map[string]string{"cmi.core.lesson_status": "failed", "cmi.core.score.raw": "30"}, false, ptr(30.0)},
+		{"12 incomplete", scorm12, map[string]string{"cmi.core.lesson_status": "incomplete"}, false, nil},
+		{"12 range", scorm12, map[string]string{"cmi.core.score.raw": "15", "cmi.core.score.min": "10", "cmi.core.score.max": "20"}, false, ptr(50.0)},
+		{"12 raw above 100 clamped", scorm12, map[string]string{"cmi.core.score.raw": "150"}, false, ptr(100.0)},
+		{"12 negative clamped", scorm12, map[string]string{"cmi.core.score.raw": "-5"}, false, ptr(0.0)},
+		{"12 empty range ignored", scorm12, map[string]string{"cmi.core.score.raw": "40", "cmi.core.score.min": "10", "cmi.core.score.max": "10"}, false, ptr(40.0)},
+		{"12 garbage score", scorm12, map[string]string{"cmi.core.score.raw": "abc"}, false, nil},
+		{"12 nan score", scorm12, map[string]string{"cmi.core.score.raw": "NaN"}, false, nil},
+		{"12 inf score", scorm12, map[string]string{"cmi.core.score.raw": "Inf"}, false, nil},
+		{"2004 passed", scorm2004, map[string]string{"cmi.success_status": "passed"}, true, nil},
+		{"2004 completed", scorm2004, map[string]string{"cmi.completion_status": "completed", "cmi.success_status": "unknown"}, true, nil},
+		{"2004 completed but failed", scorm2004, map[string]string{"cmi.completion_status": "completed", "cmi.success_status": "failed"}, false, nil},
+		{"2004 scaled", scorm2004, map[string]string{"cmi.score.scaled": "0.756"}, false, ptr(75.6)},
+		{"2004 scaled clamped", scorm2004, map[string]string{"cmi.score.scaled": "-0.5"}, false, ptr(0.0)},
+		{"2004 scaled wins over raw", scorm2004, map[string]string{"cmi.score.scaled": "0.5", "cmi.score.raw": "90"}, false, ptr(50.0)},
+		{"2004 nan scaled falls back to raw", scorm2004, map[string]string{"cmi.score.scaled": "NaN", "cmi.score.raw": "90"}, false, ptr(90.0)},
+		{"2004 raw range", scorm2004, map[string]string{"cmi.score.raw": "3", "cmi.score.min": "0", "cmi.score.max": "4"}, false, ptr(75.0)},
+		{"2004 nothing", scorm2004, map[string]string{}, false, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			completed, score := scormProgress(tt.version, tt.values)
+			if completed != tt.completed {
+				t.Fatalf("completed = %v, want %v", completed, tt.completed)
+			}
+			switch {
+			case tt.score == nil && score != nil:
+				t.Fatalf("score = %v, want none", *score)
+			case tt.score != nil && score == nil:
+				t.Fatalf("no score, want %v", *tt.score)
+			case tt.score != nil && fmt.Sprintf("%.6f", *score) != fmt.Sprintf("%.6f", *tt.score):
+				t.Fatalf("score = %v, want %v", *score, *tt.score)
+			}
+		})
+	}
+}
+
+func TestWritableScormValues(t *testing.T) {
+	values := map[string]string{
+		"cmi.core.lesson_status":       "passed",
+		"cmi.core.score.raw":           "80",
+		"cmi.suspend_data":             "state",
+		"cmi.interactions.0.id":        "q1",
+		"adl.nav.request":              "continue",
+		"cmi.core.student_id":          "other-user",
+		"cmi.learner_name":             "Someone Else",
+		"cmi.core.total_time":          "9999:00:00",
+		"cmi.scaled_passing_score":     "0",
+		"cmi.student_data.mastery":     "0",
+		"cmi.interactions._count":      "5",
+		"cmi.core.score._children":     "raw",
+		"cmi._version":                 "1.0",
+		"cmi.objectives.0.score.raw":   "10",
+		"cmi.completion_threshold":     "0",
+		"cmi.core.lesson_mode":         "review",
+		"cmi.comments_from_lms.0.text": "x",
+	}
+	got, err := writableScormValues(values)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := map[string]string{
+		"cmi.core.lesson_status":       "passed",
+		"cmi.core.score.raw":           "80",
+		"cmi.suspend_data":             "state",
+		"cmi.interactions.0.id":        "q1",
+		"adl.nav.request":              "continue",
+		"cmi.objectives.0.score.raw":   "10",
+		"cmi.comments_from_lms.0.text": "x",
+	}
+	if !maps.Equal(got, want) {
+		t.Fatalf("writable values %v, want %v", got, want)
+	}
+}
+
+func TestWritableScormValuesInvalid(t *testing.T) {
+	tooMany := make(map[string]string, maxScormCommitValues+1)
+	for i := range maxScormCommitValues + 1 {
+		tooMany[fmt.Sprintf("cmi.interactions.%d.id", i)] = "q"
+	}
+	tests := []struct {
+		name   string
+		values map[string]string
+	}{
+		{"unknown namespace", map[string]string{"foo.bar": "1"}},
+		{"bad characters", map[string]string{"cmi.core score": "1"}},
+		{"long key", map[string]string{"cmi." + strings.Repeat("a", maxScormKeyLen): "1"}},
+		{"long value", map[string]string{"cmi.suspend_data": strings.Repeat("a", maxScormValueLen+1)}},
+		{"too many values", tooMany},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := writableScormValues(tt.values); !errors.Is(err, uerrors.ErrInvalidScormData) {
+				t.Fatalf("err = %v, want ErrInvalidScormData", err)
+			}
+		})
+	}
+}
+
+func ptr[T any](v T) *T { return &v }
@@ -0,0 +1,145 @@
+package usecases
+
+import (
+	uerrors "chalk/internal/errors"
+	"chalk/internal/repo"
+	"chalk/internal/repo/models"
+	"chalk/pkg/log"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+type TrashUseCase interface {
+	GetTrash(ctx context.Context, params GetTrashParams) ([]models.TrashItem, error)
+	Restore(ctx context.Context, params RestoreTrashItemParams) error
+	PurgeExpired(ctx context.Context) (int64, error)
+}
+
+func NewTrashUseCase(
+	cr repo.CoursesRepo,
+	ar repo.AccountsRepo,
+	aur repo.AuditRepo,
+	tc repo.CourseTreeCacheRepo,
+	retention time.Duration,
+) TrashUseCase {
+	return &trashUseCase{
+		cr:        cr,
+		ar:        ar,
+		aur:       aur,
+		tc:        tc,
+		retention: retention,
+	}
+}
+
+type trashUseCase struct {
+	cr  repo.CoursesRepo
+	ar  repo.AccountsRepo
+	aur repo.AuditRepo
+	tc  repo.CourseTreeCacheRepo
+
+	// retention - срок хранения удалённых элементов, 0 - хранить бессрочно
+	retention time.Duration
+}
+
+type GetTrashParams struct {
+	ActorID   int64
+	AccountID int64
+}
+
+func (uc *trashUseCase) GetTrash(ctx context.Context, params GetTrashParams) ([]models.TrashItem, error) {
+	if err := requireAccountAdmin(ctx, uc.ar, params.ActorID, params.AccountID); err != nil {
+		return nil, err
+	}
+
+	items, err := uc.cr.GetTrash(ctx, params.AccountID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get trash: %w", err)
+	}
+	return items, nil
+}
+
+type RestoreTrashItemParams struct {
+	ActorID   int64
+	AccountID int64
+	Type      models.TrashItemType
+	ID        int64
+}
+
+var trashAuditTargets = map[models.TrashItemType]models.AuditTargetType{
+	models.TrashItemCourse: models.AuditTargetCourse,
+	models.TrashItemModule: models.AuditTargetModule,
+	models.TrashItemLesson: models.AuditTargetLesson,
+	models.TrashItemBlock:  models.AuditTargetBlock,
+}
+
+func (uc *trashUseCase) Restore(ctx context.Context, params RestoreTrashItemParams) error {
+	if err := requireAccountAdmin(ctx, uc.ar, params.ActorID, params.AccountID); err != nil {
+		return err
+	}
+
+	if err := requireWritableAccount(ctx, uc.ar, params.AccountID); err != nil {
+		return err
+	}
+
+	targetType, ok := trashAuditTargets[params.Type]
+	if !ok {
+		return uerrors.ErrTrashItemNotFound
+	}
+
+	// элемент другого аккаунта для вызывающего не существует
+	accountID, err := uc.cr.GetTrashItemAccountID(ctx, params.Type, params.ID)
+	if err != nil {
+		if errors.Is(err, repo.ErrTrashItemNotFound) {
+			return uerrors.ErrTrashItemNotFound
+		}
+		return fmt.Errorf("failed to get trash item: %w", err)
+	}
+	if accountID != params.AccountID {
+		return uerrors.ErrTrashItemNotFound
+	}
+
+	err = uc.cr.RestoreTrashItem(ctx, repo.RestoreTrashItemParams{
+		Type: params.Type,
+		ID:   params.ID,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, repo.ErrTrashItemNotFound):
+			return uerrors.ErrTrashItemNotFound
+		case errors.Is(err, repo.ErrCourseNotFound),
+			errors.Is(err, repo.ErrModuleNotFound),
+			errors.Is(err, repo.ErrLessonNotFound):
+			return uerrors.ErrTrashParentIsDeleted
+		}
+		return fmt.Errorf("failed to restore trash item: %w", err)
+	}
+
+	// восстановленный элемент возвращается в дерево курса
+	if owner, err := uc.cr.GetItemOwner(ctx, params.Type, params.ID); err == nil {
+		invalidateCourseTree(ctx, uc.tc, owner.CourseID)
+	} else {
+		log.Warnf("get restored %s %d owner: %v", params.Type, params.ID, err)
+	}
+
+	recordAudit(ctx, uc.aur, auditRecord{
+		AccountID:   params.AccountID,
+		ActorUserID: params.ActorID,
+		Action:      models.AuditActionTrashRestore,
+		TargetType:  targetType,
+		TargetID:    &params.ID,
+	})
+	return nil
+}
+
+func (uc *trashUseCase) PurgeExpired(ctx context.Context) (int64, error) {
+	if uc.retention <= 0 {
+		return 0, nil
+	}
+	removed, err := uc.cr.PurgeTrash(ctx, time.Now().Add(-uc.retention))
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge trash: %w", err)
+	}
+	return removed, nil
+}
@@ -0,0 +1,45 @@
+-- +up
+
+-- audit_log ---------------
+CREATE TABLE IF NOT EXISTS "audit_log" (
+  "id" BIGSERIAL PRIMARY KEY,
+  "account_id" BIGINT NOT NULL,
+  "actor_user_id" BIGINT,
+  "action" TEXT NOT NULL,
+  "target_type" TEXT NOT NULL,
+  "target_id" BIGINT,
+  "before" JSONB,
+  "after" JSONB,
+  "ip" TEXT NOT NULL DEFAULT '',
+  "created_at" TIMESTAMP NOT NULL,
+  CONSTRAINT "fk_audit_log__account_id" FOREIGN KEY ("account_id") REFERENCES "accounts" ("id") ON DELETE CASCADE,
+  CONSTRAINT "fk_audit_log__actor_user_id" FOREIGN KEY ("actor_user_id") REFERENCES "users" ("id") ON DELETE SET NULL
+);
+CREATE INDEX IF NOT EXISTS "idx_audit_log__account_id_id" ON "audit_log" ("account_id", "id" DESC);
+CREATE INDEX IF NOT EXISTS "idx_audit_log__created_at" ON "audit_log" ("created_at");
+
+-- Журнал только дополняется: изменять записи запрещено,
+-- удаление разрешено только для очистки по сроку хранения и каскада от accounts.
+-- Единственное разрешённое изменение - обнуление actor_user_id при удалении пользователя (ON DELETE SET NULL).
+CREATE OR REPLACE FUNCTION trg_audit_log_append_only()
+  RETURNS TRIGGER
+AS $$
+BEGIN
+  IF OLD."actor_user_id" IS NOT NULL AND NEW."actor_user_id" IS NULL
+     AND to_jsonb(NEW) - 'actor_user_id' = to_jsonb(OLD) - 'actor_user_id' THEN
+    RETURN NEW;
+  END IF;
+  RAISE EXCEPTION 'audit_log is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS before_update_audit_log ON audit_log;
+CREATE TRIGGER before_update_audit_log
+  BEFORE UPDATE ON audit_log
+  FOR EACH ROW
+  EXECUTE FUNCTION trg_audit_log_append_only();
+
+-- +down
+DROP TRIGGER IF EXISTS before_update_audit_log ON audit_log;
+DROP FUNCTION IF EXISTS trg_audit_log_append_only();
+DROP TABLE IF EXISTS "audit_log";
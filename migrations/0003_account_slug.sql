@@ -0,0 +1,26 @@
+-- +up
+
+-- accounts.slug ---------------
+ALTER TABLE "accounts" ADD COLUMN IF NOT EXISTS "slug" TEXT;
+
+-- заполняем slug существующих аккаунтов из имени, при пустом, неподходящем для поддомена
+-- или зарезервированном результате и совпадении с уже занятым slug используем account-<id>
+UPDATE "accounts"
+   SET "slug" = NULLIF(trim(BOTH '-' FROM left(regexp_replace(lower("name"), '[^a-z0-9]+', '-', 'g'), 63)), '')
+ WHERE "slug" IS NULL;
+
+UPDATE "accounts" a
+   SET "slug" = 'account-' || a."id"
+ WHERE a."slug" IS NULL
+    OR a."slug" !~ '^[a-z0-9][a-z0-9-]{1,61}[a-z0-9]$'
+    OR a."slug" IN ('www', 'api', 'app', 'admin', 'mail', 'static', 'files')
+    -- account-<N> из имени занял бы запасной slug другого аккаунта
+    OR (a."slug" ~ '^account-[0-9]+$' AND a."slug" <> 'account-' || a."id")
+    OR EXISTS (SELECT 1 FROM "accounts" b WHERE b."slug" = a."slug" AND b."id" < a."id");
+
+ALTER TABLE "accounts" ALTER COLUMN "slug" SET NOT NULL;
+ALTER TABLE "accounts" ADD CONSTRAINT "accounts_slug_key" UNIQUE ("slug");
+
+-- +down
+ALTER TABLE "accounts" DROP CONSTRAINT IF EXISTS "accounts_slug_key";
+ALTER TABLE "accounts" DROP COLUMN IF EXISTS "slug";
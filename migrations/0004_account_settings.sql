@@ -0,0 +1,20 @@
+-- +up
+
+-- account_settings ---------------
+CREATE TABLE IF NOT EXISTS "account_settings" (
+  "account_id" BIGINT PRIMARY KEY,
+  "display_name" TEXT NOT NULL DEFAULT '',
+  "logo_file_id" BIGINT,
+  "favicon_file_id" BIGINT,
+  "primary_color" TEXT NOT NULL DEFAULT '',
+  "accent_color" TEXT NOT NULL DEFAULT '',
+  "support_email" TEXT NOT NULL DEFAULT '',
+  "email_sender_name" TEXT NOT NULL DEFAULT '',
+  "updated_at" TIMESTAMP NOT NULL,
+  CONSTRAINT "fk_account_settings__account_id" FOREIGN KEY ("account_id") REFERENCES "accounts" ("id") ON DELETE CASCADE,
+  CONSTRAINT "fk_account_settings__logo_file_id" FOREIGN KEY ("logo_file_id") REFERENCES "files" ("id") ON DELETE SET NULL,
+  CONSTRAINT "fk_account_settings__favicon_file_id" FOREIGN KEY ("favicon_file_id") REFERENCES "files" ("id") ON DELETE SET NULL
+);
+
+-- +down
+DROP TABLE IF EXISTS "account_settings";
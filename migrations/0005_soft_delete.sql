@@ -0,0 +1,80 @@
+-- +up
+
+-- soft delete ---------------
+ALTER TABLE "courses" ADD COLUMN IF NOT EXISTS "deleted_at" TIMESTAMP;
+ALTER TABLE "courses" ADD COLUMN IF NOT EXISTS "deleted_by" BIGINT;
+ALTER TABLE "courses" ADD CONSTRAINT "fk_courses__deleted_by" FOREIGN KEY ("deleted_by") REFERENCES "users" ("id") ON DELETE SET NULL;
+
+ALTER TABLE "modules" ADD COLUMN IF NOT EXISTS "deleted_at" TIMESTAMP;
+ALTER TABLE "modules" ADD COLUMN IF NOT EXISTS "deleted_by" BIGINT;
+ALTER TABLE "modules" ADD CONSTRAINT "fk_modules__deleted_by" FOREIGN KEY ("deleted_by") REFERENCES "users" ("id") ON DELETE SET NULL;
+
+ALTER TABLE "lessons" ADD COLUMN IF NOT EXISTS "deleted_at" TIMESTAMP;
+ALTER TABLE "lessons" ADD COLUMN IF NOT EXISTS "deleted_by" BIGINT;
+ALTER TABLE "lessons" ADD CONSTRAINT "fk_lessons__deleted_by" FOREIGN KEY ("deleted_by") REFERENCES "users" ("id") ON DELETE SET NULL;
+
+ALTER TABLE "blocks" ADD COLUMN IF NOT EXISTS "deleted_at" TIMESTAMP;
+ALTER TABLE "blocks" ADD COLUMN IF NOT EXISTS "deleted_by" BIGINT;
+ALTER TABLE "blocks" ADD CONSTRAINT "fk_blocks__deleted_by" FOREIGN KEY ("deleted_by") REFERENCES "users" ("id") ON DELETE SET NULL;
+
+-- Удалённый элемент сохраняет свой order_idx как позицию для восстановления,
+-- поэтому уникальность порядка проверяется только среди неудалённых элементов.
+-- Ограничение отложенное (проверяется в конце запроса), чтобы сдвиг
+-- order_idx одним UPDATE не конфликтовал сам с собой.
+ALTER TABLE "modules" DROP CONSTRAINT IF EXISTS "modules_course_id_order_idx_key";
+ALTER TABLE "modules" ADD CONSTRAINT "excl_modules__course_id_order_idx"
+  EXCLUDE USING btree ("course_id" WITH =, "order_idx" WITH =) WHERE ("deleted_at" IS NULL)
+  DEFERRABLE INITIALLY IMMEDIATE;
+
+ALTER TABLE "lessons" DROP CONSTRAINT IF EXISTS "lessons_module_id_order_idx_key";
+ALTER TABLE "lessons" ADD CONSTRAINT "excl_lessons__module_id_order_idx"
+  EXCLUDE USING btree ("module_id" WITH =, "order_idx" WITH =) WHERE ("deleted_at" IS NULL)
+  DEFERRABLE INITIALLY IMMEDIATE;
+
+ALTER TABLE "blocks" DROP CONSTRAINT IF EXISTS "blocks_lesson_id_order_idx_key";
+ALTER TABLE "blocks" ADD CONSTRAINT "excl_blocks__lesson_id_order_idx"
+  EXCLUDE USING btree ("lesson_id" WITH =, "order_idx" WITH =) WHERE ("deleted_at" IS NULL)
+  DEFERRABLE INITIALLY IMMEDIATE;
+
+CREATE INDEX IF NOT EXISTS "idx_courses__deleted_at" ON "courses" ("deleted_at") WHERE "deleted_at" IS NOT NULL;
+CREATE INDEX IF NOT EXISTS "idx_modules__deleted_at" ON "modules" ("deleted_at") WHERE "deleted_at" IS NOT NULL;
+CREATE INDEX IF NOT EXISTS "idx_lessons__deleted_at" ON "lessons" ("deleted_at") WHERE "deleted_at" IS NOT NULL;
+CREATE INDEX IF NOT EXISTS "idx_blocks__deleted_at" ON "blocks" ("deleted_at") WHERE "deleted_at" IS NOT NULL;
+
+-- окончательное удаление блоков должно удалять и их содержимое
+ALTER TABLE "text_blocks" DROP CONSTRAINT IF EXISTS "fk_text_blocks__id";
+ALTER TABLE "text_blocks" ADD CONSTRAINT "fk_text_blocks__id" FOREIGN KEY ("id") REFERENCES "blocks" ("id") ON DELETE CASCADE;
+ALTER TABLE "video_blocks" DROP CONSTRAINT IF EXISTS "fk_video_blocks__id";
+ALTER TABLE "video_blocks" ADD CONSTRAINT "fk_video_blocks__id" FOREIGN KEY ("id") REFERENCES "blocks" ("id") ON DELETE CASCADE;
+
+-- +down
+DELETE FROM "blocks" WHERE "deleted_at" IS NOT NULL;
+DELETE FROM "lessons" WHERE "deleted_at" IS NOT NULL;
+DELETE FROM "modules" WHERE "deleted_at" IS NOT NULL;
+DELETE FROM "courses" WHERE "deleted_at" IS NOT NULL;
+
+ALTER TABLE "video_blocks" DROP CONSTRAINT IF EXISTS "fk_video_blocks__id";
+ALTER TABLE "video_blocks" ADD CONSTRAINT "fk_video_blocks__id" FOREIGN KEY ("id") REFERENCES "blocks" ("id");
+ALTER TABLE "text_blocks" DROP CONSTRAINT IF EXISTS "fk_text_blocks__id";
+ALTER TABLE "text_blocks" ADD CONSTRAINT "fk_text_blocks__id" FOREIGN KEY ("id") REFERENCES "blocks" ("id");
+
+DROP INDEX IF EXISTS "idx_blocks__deleted_at";
+DROP INDEX IF EXISTS "idx_lessons__deleted_at";
+DROP INDEX IF EXISTS "idx_modules__deleted_at";
+DROP INDEX IF EXISTS "idx_courses__deleted_at";
+
+ALTER TABLE "blocks" DROP CONSTRAINT IF EXISTS "excl_blocks__lesson_id_order_idx";
+ALTER TABLE "blocks" ADD CONSTRAINT "blocks_lesson_id_order_idx_key" UNIQUE ("lesson_id", "order_idx");
+ALTER TABLE "lessons" DROP CONSTRAINT IF EXISTS "excl_lessons__module_id_order_idx";
+ALTER TABLE "lessons" ADD CONSTRAINT "lessons_module_id_order_idx_key" UNIQUE ("module_id", "order_idx");
+ALTER TABLE "modules" DROP CONSTRAINT IF EXISTS "excl_modules__course_id_order_idx";
+ALTER TABLE "modules" ADD CONSTRAINT "modules_course_id_order_idx_key" UNIQUE ("course_id", "order_idx");
+
+ALTER TABLE "blocks" DROP COLUMN IF EXISTS "deleted_by";
+ALTER TABLE "blocks" DROP COLUMN IF EXISTS "deleted_at";
+ALTER TABLE "lessons" DROP COLUMN IF EXISTS "deleted_by";
+ALTER TABLE "lessons" DROP COLUMN IF EXISTS "deleted_at";
+ALTER TABLE "modules" DROP COLUMN IF EXISTS "deleted_by";
+ALTER TABLE "modules" DROP COLUMN IF EXISTS "deleted_at";
+ALTER TABLE "courses" DROP COLUMN IF EXISTS "deleted_by";
+ALTER TABLE "courses" DROP COLUMN IF EXISTS "deleted_at";
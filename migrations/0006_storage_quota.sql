@@ -0,0 +1,42 @@
+-- +up
+
+-- files.account_id ---------------
+-- старые файлы без аккаунта привязываются по местам использования,
+-- непривязанные остаются с NULL и в квоте не учитываются
+ALTER TABLE "files" ADD COLUMN IF NOT EXISTS "account_id" BIGINT;
+ALTER TABLE "files" ADD CONSTRAINT "fk_files__account_id" FOREIGN KEY ("account_id") REFERENCES "accounts" ("id") ON DELETE CASCADE;
+CREATE INDEX IF NOT EXISTS "idx_files__account_id" ON "files" ("account_id");
+
+UPDATE "files" f SET "account_id" = s."account_id"
+FROM "account_settings" s
+WHERE f."account_id" IS NULL AND f."id" IN (s."logo_file_id", s."favicon_file_id");
+
+UPDATE "files" f SET "account_id" = c."account_id"
+FROM "video_blocks" vb
+INNER JOIN "blocks" b ON b."id" = vb."id"
+INNER JOIN "lessons" l ON l."id" = b."lesson_id"
+INNER JOIN "modules" m ON m."id" = l."module_id"
+INNER JOIN "courses" c ON c."id" = m."course_id"
+WHERE f."account_id" IS NULL AND f."id" = vb."file_id";
+
+-- account_storage ---------------
+CREATE TABLE IF NOT EXISTS "account_storage" (
+  "account_id" BIGINT PRIMARY KEY,
+  "used_bytes" BIGINT NOT NULL DEFAULT 0,
+  -- quota_bytes - индивидуальная квота, NULL - квота по умолчанию из конфига
+  "quota_bytes" BIGINT,
+  CONSTRAINT "fk_account_storage__account_id" FOREIGN KEY ("account_id") REFERENCES "accounts" ("id") ON DELETE CASCADE,
+  CONSTRAINT "chk_account_storage__used_bytes" CHECK ("used_bytes" >= 0)
+);
+
+INSERT INTO "account_storage" ("account_id", "used_bytes")
+SELECT "account_id", SUM("size") FROM "files"
+WHERE "account_id" IS NOT NULL
+GROUP BY "account_id"
+ON CONFLICT ("account_id") DO NOTHING;
+
+-- +down
+DROP TABLE IF EXISTS "account_storage";
+DROP INDEX IF EXISTS "idx_files__account_id";
+ALTER TABLE "files" DROP CONSTRAINT IF EXISTS "fk_files__account_id";
+ALTER TABLE "files" DROP COLUMN IF EXISTS "account_id";
@@ -0,0 +1,49 @@
+-- +up
+
+-- plans ---------------
+-- NULL в лимитах - без ограничений
+CREATE TABLE IF NOT EXISTS "plans" (
+  "id" TEXT PRIMARY KEY,
+  "name" TEXT NOT NULL,
+  "max_seats" INTEGER,
+  "max_courses" INTEGER,
+  "storage_bytes" BIGINT
+);
+
+INSERT INTO "plans" ("id", "name", "max_seats", "max_courses", "storage_bytes") VALUES
+  ('free', 'Free', 5, 3, 1073741824),
+  ('team', 'Team', 50, 50, 107374182400),
+  ('business', 'Business', NULL, NULL, 1099511627776)
+ON CONFLICT ("id") DO NOTHING;
+
+-- subscriptions ---------------
+-- аккаунт без подписки работает на тарифе free
+CREATE TABLE IF NOT EXISTS "subscriptions" (
+  "account_id" BIGINT PRIMARY KEY,
+  "plan_id" TEXT NOT NULL,
+  "status" TEXT NOT NULL,
+  "provider" TEXT NOT NULL,
+  "provider_subscription_id" TEXT,
+  "current_period_end" TIMESTAMP,
+  "updated_at" TIMESTAMP NOT NULL,
+  CONSTRAINT "fk_subscriptions__account_id" FOREIGN KEY ("account_id") REFERENCES "accounts" ("id") ON DELETE CASCADE,
+  CONSTRAINT "fk_subscriptions__plan_id" FOREIGN KEY ("plan_id") REFERENCES "plans" ("id")
+);
+
+-- billing_events ---------------
+-- обработанные события провайдера, повторная доставка отсекается первичным ключом
+CREATE TABLE IF NOT EXISTS "billing_events" (
+  "provider" TEXT NOT NULL,
+  "event_id" TEXT NOT NULL,
+  "type" TEXT NOT NULL,
+  "account_id" BIGINT,
+  "payload" JSONB,
+  "received_at" TIMESTAMP NOT NULL,
+  PRIMARY KEY ("provider", "event_id"),
+  CONSTRAINT "fk_billing_events__account_id" FOREIGN KEY ("account_id") REFERENCES "accounts" ("id") ON DELETE SET NULL
+);
+
+-- +down
+DROP TABLE IF EXISTS "billing_events";
+DROP TABLE IF EXISTS "subscriptions";
+DROP TABLE IF EXISTS "plans";
@@ -0,0 +1,41 @@
+-- +up
+
+-- account_groups ---------------
+CREATE TABLE IF NOT EXISTS "account_groups" (
+  "id" BIGSERIAL PRIMARY KEY,
+  "account_id" BIGINT NOT NULL,
+  "name" TEXT NOT NULL,
+  CONSTRAINT "fk_account_groups__account_id" FOREIGN KEY ("account_id") REFERENCES "accounts" ("id") ON DELETE CASCADE,
+  CONSTRAINT "account_groups_account_id_name_key" UNIQUE ("account_id", "name")
+);
+
+CREATE TABLE IF NOT EXISTS "account_group_members" (
+  "group_id" BIGINT NOT NULL,
+  "user_id" BIGINT NOT NULL,
+  PRIMARY KEY ("group_id", "user_id"),
+  CONSTRAINT "fk_account_group_members__group_id" FOREIGN KEY ("group_id") REFERENCES "account_groups" ("id") ON DELETE CASCADE,
+  CONSTRAINT "fk_account_group_members__user_id" FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS "idx_account_group_members__user_id" ON "account_group_members" ("user_id");
+
+-- account_invitations ---------------
+-- приглашение незарегистрированного пользователя, превращается в участие при регистрации
+CREATE TABLE IF NOT EXISTS "account_invitations" (
+  "id" BIGSERIAL PRIMARY KEY,
+  "account_id" BIGINT NOT NULL,
+  "email" TEXT NOT NULL,
+  "name" TEXT NOT NULL DEFAULT '',
+  "role" TEXT NOT NULL,
+  "groups" TEXT[] NOT NULL DEFAULT '{}',
+  "invited_by" BIGINT,
+  "created_at" TIMESTAMP NOT NULL,
+  CONSTRAINT "fk_account_invitations__account_id" FOREIGN KEY ("account_id") REFERENCES "accounts" ("id") ON DELETE CASCADE,
+  CONSTRAINT "fk_account_invitations__invited_by" FOREIGN KEY ("invited_by") REFERENCES "users" ("id") ON DELETE SET NULL,
+  CONSTRAINT "account_invitations_account_id_email_key" UNIQUE ("account_id", "email")
+);
+CREATE INDEX IF NOT EXISTS "idx_account_invitations__email" ON "account_invitations" ("email");
+
+-- +down
+DROP TABLE IF EXISTS "account_invitations";
+DROP TABLE IF EXISTS "account_group_members";
+DROP TABLE IF EXISTS "account_groups";
@@ -0,0 +1,14 @@
+-- +up
+
+-- поиск по префиксу и сортировка в каталоге участников
+-- сравнение "C" позволяет одному индексу обслуживать и ORDER BY, и LIKE 'prefix%'
+CREATE INDEX IF NOT EXISTS "idx_users__lower_name" ON "users" ((lower("name") COLLATE "C"), "id");
+CREATE INDEX IF NOT EXISTS "idx_users__lower_email" ON "users" ((lower("email") COLLATE "C"), "id");
+-- фильтр по роли
+CREATE INDEX IF NOT EXISTS "idx_account_members__account_id_role" ON "account_members" ("account_id", "role");
+-- фильтр по группе: группы аккаунта ищутся по уникальному (account_id, name), участники - по первичному ключу
+
+-- +down
+DROP INDEX IF EXISTS "idx_account_members__account_id_role";
+DROP INDEX IF EXISTS "idx_users__lower_email";
+DROP INDEX IF EXISTS "idx_users__lower_name";
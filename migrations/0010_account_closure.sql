@@ -0,0 +1,23 @@
+-- +up
+
+-- закрытие аккаунта ---------------
+ALTER TABLE "accounts" ADD COLUMN IF NOT EXISTS "closure_requested_at" TIMESTAMP;
+ALTER TABLE "accounts" ADD COLUMN IF NOT EXISTS "closure_requested_by" BIGINT;
+ALTER TABLE "accounts" ADD COLUMN IF NOT EXISTS "deletion_scheduled_at" TIMESTAMP;
+-- export_file_id - архив с данными аккаунта, NULL пока архив не собран
+ALTER TABLE "accounts" ADD COLUMN IF NOT EXISTS "export_file_id" BIGINT;
+-- deletion_started_at - началось удаление данных, закрытие уже нельзя отменить
+ALTER TABLE "accounts" ADD COLUMN IF NOT EXISTS "deletion_started_at" TIMESTAMP;
+ALTER TABLE "accounts" ADD CONSTRAINT "fk_accounts__closure_requested_by" FOREIGN KEY ("closure_requested_by") REFERENCES "users" ("id") ON DELETE SET NULL;
+ALTER TABLE "accounts" ADD CONSTRAINT "fk_accounts__export_file_id" FOREIGN KEY ("export_file_id") REFERENCES "files" ("id") ON DELETE SET NULL;
+CREATE INDEX IF NOT EXISTS "idx_accounts__deletion_scheduled_at" ON "accounts" ("deletion_scheduled_at") WHERE "deletion_scheduled_at" IS NOT NULL;
+
+-- +down
+DROP INDEX IF EXISTS "idx_accounts__deletion_scheduled_at";
+ALTER TABLE "accounts" DROP CONSTRAINT IF EXISTS "fk_accounts__export_file_id";
+ALTER TABLE "accounts" DROP CONSTRAINT IF EXISTS "fk_accounts__closure_requested_by";
+ALTER TABLE "accounts" DROP COLUMN IF EXISTS "deletion_started_at";
+ALTER TABLE "accounts" DROP COLUMN IF EXISTS "export_file_id";
+ALTER TABLE "accounts" DROP COLUMN IF EXISTS "deletion_scheduled_at";
+ALTER TABLE "accounts" DROP COLUMN IF EXISTS "closure_requested_by";
+ALTER TABLE "accounts" DROP COLUMN IF EXISTS "closure_requested_at";
@@ -0,0 +1,24 @@
+-- +up
+
+-- course_versions ---------------
+-- опубликованные версии курса, snapshot - неизменяемое дерево курса на момент публикации
+CREATE TABLE IF NOT EXISTS "course_versions" (
+  "id" BIGSERIAL PRIMARY KEY,
+  "course_id" BIGINT NOT NULL,
+  "version" INTEGER NOT NULL,
+  "snapshot" JSONB NOT NULL,
+  "published_at" TIMESTAMP NOT NULL,
+  "published_by" BIGINT,
+  CONSTRAINT "fk_course_versions__course_id" FOREIGN KEY ("course_id") REFERENCES "courses" ("id") ON DELETE CASCADE,
+  CONSTRAINT "fk_course_versions__published_by" FOREIGN KEY ("published_by") REFERENCES "users" ("id") ON DELETE SET NULL,
+  CONSTRAINT "course_versions_course_id_version_key" UNIQUE ("course_id", "version")
+);
+
+-- published_version_id - версия, которую видят участники курса, NULL пока курс не опубликован
+ALTER TABLE "courses" ADD COLUMN IF NOT EXISTS "published_version_id" BIGINT;
+ALTER TABLE "courses" ADD CONSTRAINT "fk_courses__published_version_id" FOREIGN KEY ("published_version_id") REFERENCES "course_versions" ("id") ON DELETE SET NULL;
+
+-- +down
+ALTER TABLE "courses" DROP CONSTRAINT IF EXISTS "fk_courses__published_version_id";
+ALTER TABLE "courses" DROP COLUMN IF EXISTS "published_version_id";
+DROP TABLE IF EXISTS "course_versions";
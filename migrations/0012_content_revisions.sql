@@ -0,0 +1,21 @@
+-- +up
+
+-- content_revisions ---------------
+-- история правок элементов курса: старое и новое значение поля
+CREATE TABLE IF NOT EXISTS "content_revisions" (
+  "id" BIGSERIAL PRIMARY KEY,
+  "account_id" BIGINT NOT NULL,
+  "item_type" TEXT NOT NULL CHECK ("item_type" IN ('course', 'module', 'lesson', 'block')),
+  "item_id" BIGINT NOT NULL,
+  "field" TEXT NOT NULL CHECK ("field" IN ('name', 'content', 'order_idx')),
+  "old_value" TEXT,
+  "value" TEXT NOT NULL,
+  "author_id" BIGINT,
+  "created_at" TIMESTAMP NOT NULL,
+  CONSTRAINT "fk_content_revisions__account_id" FOREIGN KEY ("account_id") REFERENCES "accounts" ("id") ON DELETE CASCADE,
+  CONSTRAINT "fk_content_revisions__author_id" FOREIGN KEY ("author_id") REFERENCES "users" ("id") ON DELETE SET NULL
+);
+CREATE INDEX IF NOT EXISTS "idx_content_revisions__item_type_item_id" ON "content_revisions" ("item_type", "item_id", "id");
+
+-- +down
+DROP TABLE IF EXISTS "content_revisions";
@@ -0,0 +1,48 @@
+-- +up
+
+ALTER TABLE "blocks" DROP CONSTRAINT IF EXISTS "blocks_type_check";
+ALTER TABLE "blocks" ADD CONSTRAINT "blocks_type_check" CHECK ("type" IN ('video', 'text', 'scorm'));
+
+-- scorm_blocks ---------------
+-- file_id - zip пакета, файлы пакета отдаются прямо из архива
+CREATE TABLE IF NOT EXISTS "scorm_blocks" (
+  "id" BIGINT PRIMARY KEY,
+  "file_id" BIGINT NOT NULL,
+  CONSTRAINT "fk_scorm_blocks__id" FOREIGN KEY ("id") REFERENCES "blocks" ("id") ON DELETE CASCADE,
+  CONSTRAINT "fk_scorm_blocks__file_id" FOREIGN KEY ("file_id") REFERENCES "files" ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_scorm_blocks__file_id" ON "scorm_blocks" ("file_id");
+
+-- scorm_runtime_data ---------------
+-- значения модели данных cmi, сохранённые пакетом для участника
+CREATE TABLE IF NOT EXISTS "scorm_runtime_data" (
+  "block_id" BIGINT NOT NULL,
+  "user_id" BIGINT NOT NULL,
+  "data" JSONB NOT NULL CHECK (octet_length("data"::TEXT) <= 1048576),
+  "updated_at" TIMESTAMP NOT NULL,
+  PRIMARY KEY ("block_id", "user_id"),
+  CONSTRAINT "fk_scorm_runtime_data__block_id" FOREIGN KEY ("block_id") REFERENCES "blocks" ("id") ON DELETE CASCADE,
+  CONSTRAINT "fk_scorm_runtime_data__user_id" FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE
+);
+
+-- block_progress ---------------
+-- прохождение блока участником: score в процентах, completed_at - первое завершение
+CREATE TABLE IF NOT EXISTS "block_progress" (
+  "block_id" BIGINT NOT NULL,
+  "user_id" BIGINT NOT NULL,
+  "completed_at" TIMESTAMP,
+  "score" DOUBLE PRECISION CHECK ("score" BETWEEN 0 AND 100),
+  "updated_at" TIMESTAMP NOT NULL,
+  PRIMARY KEY ("block_id", "user_id"),
+  CONSTRAINT "fk_block_progress__block_id" FOREIGN KEY ("block_id") REFERENCES "blocks" ("id") ON DELETE CASCADE,
+  CONSTRAINT "fk_block_progress__user_id" FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS "idx_block_progress__user_id" ON "block_progress" ("user_id");
+
+-- +down
+DROP TABLE IF EXISTS "block_progress";
+DROP TABLE IF EXISTS "scorm_runtime_data";
+DROP TABLE IF EXISTS "scorm_blocks";
+DELETE FROM "blocks" WHERE "type" = 'scorm';
+ALTER TABLE "blocks" DROP CONSTRAINT IF EXISTS "blocks_type_check";
+ALTER TABLE "blocks" ADD CONSTRAINT "blocks_type_check" CHECK ("type" IN ('video', 'text'));
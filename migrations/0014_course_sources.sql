@@ -0,0 +1,17 @@
+-- +up
+
+-- course_sources ---------------
+-- соответствие путей каталога Markdown элементам курса, по нему синхронизация
+-- находит, что обновлять. checksum - sha256 файла медиа, у модулей и уроков пустой
+CREATE TABLE IF NOT EXISTS "course_sources" (
+  "course_id" BIGINT NOT NULL,
+  "path" TEXT NOT NULL,
+  "item_type" TEXT NOT NULL CHECK ("item_type" IN ('module', 'lesson', 'file')),
+  "item_id" BIGINT NOT NULL,
+  "checksum" TEXT NOT NULL DEFAULT '',
+  PRIMARY KEY ("course_id", "item_type", "path"),
+  CONSTRAINT "fk_course_sources__course_id" FOREIGN KEY ("course_id") REFERENCES "courses" ("id") ON DELETE CASCADE
+);
+
+-- +down
+DROP TABLE IF EXISTS "course_sources";
@@ -0,0 +1,46 @@
+-- +up
+
+ALTER TABLE "blocks" DROP CONSTRAINT IF EXISTS "blocks_type_check";
+ALTER TABLE "blocks" ADD CONSTRAINT "blocks_type_check" CHECK ("type" IN ('video', 'text', 'scorm', 'quiz'));
+
+-- quiz_blocks ---------------
+-- questions - вопросы с верными ответами в JSON, max_attempts NULL - без ограничения
+CREATE TABLE IF NOT EXISTS "quiz_blocks" (
+  "id" BIGINT PRIMARY KEY,
+  "passing_score" DOUBLE PRECISION NOT NULL CHECK ("passing_score" BETWEEN 0 AND 100),
+  "max_attempts" INT CHECK ("max_attempts" > 0),
+  "questions" JSONB NOT NULL,
+  CONSTRAINT "fk_quiz_blocks__id" FOREIGN KEY ("id") REFERENCES "blocks" ("id") ON DELETE CASCADE
+);
+
+-- quiz_attempts ---------------
+-- проверенные попытки участника, attempt_no идёт с 1 для каждого участника и теста
+CREATE TABLE IF NOT EXISTS "quiz_attempts" (
+  "id" BIGSERIAL PRIMARY KEY,
+  "block_id" BIGINT NOT NULL,
+  "user_id" BIGINT NOT NULL,
+  "attempt_no" INT NOT NULL,
+  "answers" JSONB NOT NULL,
+  "result" JSONB NOT NULL,
+  "score" DOUBLE PRECISION NOT NULL CHECK ("score" BETWEEN 0 AND 100),
+  "passed" BOOLEAN NOT NULL,
+  "submitted_at" TIMESTAMP NOT NULL,
+  UNIQUE ("block_id", "user_id", "attempt_no"),
+  CONSTRAINT "fk_quiz_attempts__block_id" FOREIGN KEY ("block_id") REFERENCES "blocks" ("id") ON DELETE CASCADE,
+  CONSTRAINT "fk_quiz_attempts__user_id" FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS "idx_quiz_attempts__user_id" ON "quiz_attempts" ("user_id");
+
+-- правка теста хранит тест целиком в JSON
+ALTER TABLE "content_revisions" DROP CONSTRAINT IF EXISTS "content_revisions_field_check";
+ALTER TABLE "content_revisions" ADD CONSTRAINT "content_revisions_field_check" CHECK ("field" IN ('name', 'content', 'order_idx', 'quiz'));
+
+-- +down
+DELETE FROM "content_revisions" WHERE "field" = 'quiz';
+ALTER TABLE "content_revisions" DROP CONSTRAINT IF EXISTS "content_revisions_field_check";
+ALTER TABLE "content_revisions" ADD CONSTRAINT "content_revisions_field_check" CHECK ("field" IN ('name', 'content', 'order_idx'));
+DROP TABLE IF EXISTS "quiz_attempts";
+DROP TABLE IF EXISTS "quiz_blocks";
+DELETE FROM "blocks" WHERE "type" = 'quiz';
+ALTER TABLE "blocks" DROP CONSTRAINT IF EXISTS "blocks_type_check";
+ALTER TABLE "blocks" ADD CONSTRAINT "blocks_type_check" CHECK ("type" IN ('video', 'text', 'scorm'));
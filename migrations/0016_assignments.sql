@@ -0,0 +1,79 @@
+-- +up
+
+ALTER TABLE "blocks" DROP CONSTRAINT IF EXISTS "blocks_type_check";
+ALTER TABLE "blocks" ADD CONSTRAINT "blocks_type_check" CHECK ("type" IN ('video', 'text', 'scorm', 'quiz', 'assignment'));
+
+-- assignment_blocks ---------------
+-- задание с рубрикой в JSON, работы проверяет преподаватель
+CREATE TABLE IF NOT EXISTS "assignment_blocks" (
+  "id" BIGINT PRIMARY KEY,
+  "instructions" TEXT NOT NULL,
+  "allow_text" BOOLEAN NOT NULL,
+  "allow_files" BOOLEAN NOT NULL,
+  "passing_score" DOUBLE PRECISION NOT NULL CHECK ("passing_score" BETWEEN 0 AND 100),
+  "rubric" JSONB NOT NULL,
+  CONSTRAINT "fk_assignment_blocks__id" FOREIGN KEY ("id") REFERENCES "blocks" ("id") ON DELETE CASCADE
+);
+
+-- assignment_submissions ---------------
+-- текущее состояние работы участника, score - результат последней оценки,
+-- assignment - задание из опубликованной версии на момент последней сдачи, по нему ставится оценка
+CREATE TABLE IF NOT EXISTS "assignment_submissions" (
+  "id" BIGSERIAL PRIMARY KEY,
+  "block_id" BIGINT NOT NULL,
+  "user_id" BIGINT NOT NULL,
+  "status" TEXT NOT NULL CHECK ("status" IN ('submitted', 'graded', 'returned')),
+  "score" DOUBLE PRECISION CHECK ("score" BETWEEN 0 AND 100),
+  "assignment" JSONB NOT NULL,
+  "submitted_at" TIMESTAMP NOT NULL,
+  "updated_at" TIMESTAMP NOT NULL,
+  UNIQUE ("block_id", "user_id"),
+  CONSTRAINT "fk_assignment_submissions__block_id" FOREIGN KEY ("block_id") REFERENCES "blocks" ("id") ON DELETE CASCADE,
+  CONSTRAINT "fk_assignment_submissions__user_id" FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS "idx_assignment_submissions__user_id" ON "assignment_submissions" ("user_id");
+CREATE INDEX IF NOT EXISTS "idx_assignment_submissions__status_submitted_at" ON "assignment_submissions" ("status", "submitted_at");
+
+-- assignment_submission_events ---------------
+-- история работы: каждая сдача, оценка и возврат на доработку
+CREATE TABLE IF NOT EXISTS "assignment_submission_events" (
+  "id" BIGSERIAL PRIMARY KEY,
+  "submission_id" BIGINT NOT NULL,
+  "action" TEXT NOT NULL CHECK ("action" IN ('submitted', 'graded', 'returned')),
+  "actor_id" BIGINT,
+  "text" TEXT NOT NULL DEFAULT '',
+  "grades" JSONB,
+  "score" DOUBLE PRECISION CHECK ("score" BETWEEN 0 AND 100),
+  "comment" TEXT NOT NULL DEFAULT '',
+  "created_at" TIMESTAMP NOT NULL,
+  CONSTRAINT "fk_assignment_submission_events__submission_id" FOREIGN KEY ("submission_id") REFERENCES "assignment_submissions" ("id") ON DELETE CASCADE,
+  CONSTRAINT "fk_assignment_submission_events__actor_id" FOREIGN KEY ("actor_id") REFERENCES "users" ("id") ON DELETE SET NULL
+);
+CREATE INDEX IF NOT EXISTS "idx_assignment_submission_events__submission_id" ON "assignment_submission_events" ("submission_id", "id");
+
+-- assignment_submission_files ---------------
+-- файлы, приложенные к сдаче
+CREATE TABLE IF NOT EXISTS "assignment_submission_files" (
+  "event_id" BIGINT NOT NULL,
+  "file_id" BIGINT NOT NULL,
+  "position" INT NOT NULL,
+  PRIMARY KEY ("event_id", "file_id"),
+  CONSTRAINT "fk_assignment_submission_files__event_id" FOREIGN KEY ("event_id") REFERENCES "assignment_submission_events" ("id") ON DELETE CASCADE,
+  CONSTRAINT "fk_assignment_submission_files__file_id" FOREIGN KEY ("file_id") REFERENCES "files" ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_assignment_submission_files__file_id" ON "assignment_submission_files" ("file_id");
+
+ALTER TABLE "content_revisions" DROP CONSTRAINT IF EXISTS "content_revisions_field_check";
+ALTER TABLE "content_revisions" ADD CONSTRAINT "content_revisions_field_check" CHECK ("field" IN ('name', 'content', 'order_idx', 'quiz', 'assignment'));
+
+-- +down
+DELETE FROM "content_revisions" WHERE "field" = 'assignment';
+ALTER TABLE "content_revisions" DROP CONSTRAINT IF EXISTS "content_revisions_field_check";
+ALTER TABLE "content_revisions" ADD CONSTRAINT "content_revisions_field_check" CHECK ("field" IN ('name', 'content', 'order_idx', 'quiz'));
+DROP TABLE IF EXISTS "assignment_submission_files";
+DROP TABLE IF EXISTS "assignment_submission_events";
+DROP TABLE IF EXISTS "assignment_submissions";
+DROP TABLE IF EXISTS "assignment_blocks";
+DELETE FROM "blocks" WHERE "type" = 'assignment';
+ALTER TABLE "blocks" DROP CONSTRAINT IF EXISTS "blocks_type_check";
+ALTER TABLE "blocks" ADD CONSTRAINT "blocks_type_check" CHECK ("type" IN ('video', 'text', 'scorm', 'quiz'));
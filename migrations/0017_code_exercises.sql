@@ -0,0 +1,49 @@
+-- +up
+
+ALTER TABLE "blocks" DROP CONSTRAINT IF EXISTS "blocks_type_check";
+ALTER TABLE "blocks" ADD CONSTRAINT "blocks_type_check" CHECK ("type" IN ('video', 'text', 'scorm', 'quiz', 'assignment', 'code_exercise'));
+
+-- code_exercise_blocks ---------------
+-- tests - тесты с входом и ожидаемым выводом в JSON, ограничения - на запуск одного теста
+CREATE TABLE IF NOT EXISTS "code_exercise_blocks" (
+  "id" BIGINT PRIMARY KEY,
+  "language" TEXT NOT NULL,
+  "starter_code" TEXT NOT NULL,
+  "time_limit_ms" INT NOT NULL CHECK ("time_limit_ms" > 0),
+  "memory_limit_mb" INT NOT NULL CHECK ("memory_limit_mb" > 0),
+  "passing_score" DOUBLE PRECISION NOT NULL CHECK ("passing_score" BETWEEN 0 AND 100),
+  "tests" JSONB NOT NULL,
+  CONSTRAINT "fk_code_exercise_blocks__id" FOREIGN KEY ("id") REFERENCES "blocks" ("id") ON DELETE CASCADE
+);
+
+-- code_submissions ---------------
+-- проверенные решения участника, result - результаты по каждому тесту в JSON
+CREATE TABLE IF NOT EXISTS "code_submissions" (
+  "id" BIGSERIAL PRIMARY KEY,
+  "block_id" BIGINT NOT NULL,
+  "user_id" BIGINT NOT NULL,
+  "language" TEXT NOT NULL,
+  "code" TEXT NOT NULL,
+  "result" JSONB NOT NULL,
+  "score" DOUBLE PRECISION NOT NULL CHECK ("score" BETWEEN 0 AND 100),
+  "passed" BOOLEAN NOT NULL,
+  "submitted_at" TIMESTAMP NOT NULL,
+  CONSTRAINT "fk_code_submissions__block_id" FOREIGN KEY ("block_id") REFERENCES "blocks" ("id") ON DELETE CASCADE,
+  CONSTRAINT "fk_code_submissions__user_id" FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS "idx_code_submissions__block_id_user_id" ON "code_submissions" ("block_id", "user_id", "submitted_at");
+CREATE INDEX IF NOT EXISTS "idx_code_submissions__user_id" ON "code_submissions" ("user_id");
+
+-- правка упражнения хранит упражнение целиком в JSON
+ALTER TABLE "content_revisions" DROP CONSTRAINT IF EXISTS "content_revisions_field_check";
+ALTER TABLE "content_revisions" ADD CONSTRAINT "content_revisions_field_check" CHECK ("field" IN ('name', 'content', 'order_idx', 'quiz', 'assignment', 'code_exercise'));
+
+-- +down
+DELETE FROM "content_revisions" WHERE "field" = 'code_exercise';
+ALTER TABLE "content_revisions" DROP CONSTRAINT IF EXISTS "content_revisions_field_check";
+ALTER TABLE "content_revisions" ADD CONSTRAINT "content_revisions_field_check" CHECK ("field" IN ('name', 'content', 'order_idx', 'quiz', 'assignment'));
+DROP TABLE IF EXISTS "code_submissions";
+DROP TABLE IF EXISTS "code_exercise_blocks";
+DELETE FROM "blocks" WHERE "type" = 'code_exercise';
+ALTER TABLE "blocks" DROP CONSTRAINT IF EXISTS "blocks_type_check";
+ALTER TABLE "blocks" ADD CONSTRAINT "blocks_type_check" CHECK ("type" IN ('video', 'text', 'scorm', 'quiz', 'assignment'));
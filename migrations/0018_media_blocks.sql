@@ -0,0 +1,44 @@
+-- +up
+
+ALTER TABLE "blocks" DROP CONSTRAINT IF EXISTS "blocks_type_check";
+ALTER TABLE "blocks" ADD CONSTRAINT "blocks_type_check" CHECK ("type" IN ('video', 'text', 'scorm', 'quiz', 'assignment', 'code_exercise', 'image', 'attachment', 'embed'));
+
+-- image_blocks ---------------
+CREATE TABLE IF NOT EXISTS "image_blocks" (
+  "id" BIGINT PRIMARY KEY,
+  "file_id" BIGINT NOT NULL,
+  "alt_text" TEXT NOT NULL,
+  "caption" TEXT NOT NULL DEFAULT '',
+  CONSTRAINT "fk_image_blocks__id" FOREIGN KEY ("id") REFERENCES "blocks" ("id") ON DELETE CASCADE,
+  CONSTRAINT "fk_image_blocks__file_id" FOREIGN KEY ("file_id") REFERENCES "files" ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_image_blocks__file_id" ON "image_blocks" ("file_id");
+
+-- attachment_blocks ---------------
+-- title - подпись ссылки на файл, пустая - показывается имя файла
+CREATE TABLE IF NOT EXISTS "attachment_blocks" (
+  "id" BIGINT PRIMARY KEY,
+  "file_id" BIGINT NOT NULL,
+  "title" TEXT NOT NULL DEFAULT '',
+  CONSTRAINT "fk_attachment_blocks__id" FOREIGN KEY ("id") REFERENCES "blocks" ("id") ON DELETE CASCADE,
+  CONSTRAINT "fk_attachment_blocks__file_id" FOREIGN KEY ("file_id") REFERENCES "files" ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_attachment_blocks__file_id" ON "attachment_blocks" ("file_id");
+
+-- embed_blocks ---------------
+-- url - адрес плеера сервиса для iframe, уже проверенный по списку сервисов
+CREATE TABLE IF NOT EXISTS "embed_blocks" (
+  "id" BIGINT PRIMARY KEY,
+  "provider" TEXT NOT NULL,
+  "url" TEXT NOT NULL,
+  "title" TEXT NOT NULL DEFAULT '',
+  CONSTRAINT "fk_embed_blocks__id" FOREIGN KEY ("id") REFERENCES "blocks" ("id") ON DELETE CASCADE
+);
+
+-- +down
+DROP TABLE IF EXISTS "embed_blocks";
+DROP TABLE IF EXISTS "attachment_blocks";
+DROP TABLE IF EXISTS "image_blocks";
+DELETE FROM "blocks" WHERE "type" IN ('image', 'attachment', 'embed');
+ALTER TABLE "blocks" DROP CONSTRAINT IF EXISTS "blocks_type_check";
+ALTER TABLE "blocks" ADD CONSTRAINT "blocks_type_check" CHECK ("type" IN ('video', 'text', 'scorm', 'quiz', 'assignment', 'code_exercise'));
@@ -0,0 +1,10 @@
+-- +up
+
+-- перенос урока или блока пишет в историю смену родителя
+ALTER TABLE "content_revisions" DROP CONSTRAINT IF EXISTS "content_revisions_field_check";
+ALTER TABLE "content_revisions" ADD CONSTRAINT "content_revisions_field_check" CHECK ("field" IN ('name', 'content', 'order_idx', 'quiz', 'assignment', 'code_exercise', 'parent_id'));
+
+-- +down
+DELETE FROM "content_revisions" WHERE "field" = 'parent_id';
+ALTER TABLE "content_revisions" DROP CONSTRAINT IF EXISTS "content_revisions_field_check";
+ALTER TABLE "content_revisions" ADD CONSTRAINT "content_revisions_field_check" CHECK ("field" IN ('name', 'content', 'order_idx', 'quiz', 'assignment', 'code_exercise'));
@@ -0,0 +1,179 @@
+package assignment
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Задание проверяет преподаватель по рубрике: за каждый критерий ставятся баллы
+// от 0 до максимума критерия, результат - процент набранных баллов. Задание хранится
+// в JSON блоков, версий курса и архивов, поэтому теги полей менять нельзя.
+
+const (
+	MaxCriteria = 50
+
+	maxIDLen           = 64
+	maxTitleLen        = 500
+	maxInstructionsLen = 100000
+	maxCommentLen      = 10000
+)
+
+var ErrInvalidGrade = errors.New("invalid rubric grade")
+
+type Assignment struct {
+	Instructions string `json:"instructions"`
+	// AllowText и AllowFiles - что участник может сдать, разрешено хотя бы одно
+	AllowText  bool `json:"allow_text"`
+	AllowFiles bool `json:"allow_files"`
+	// PassingScore - процент баллов, с которым работа зачтена
+	PassingScore float64     `json:"passing_score"`
+	Rubric       []Criterion `json:"rubric"`
+}
+
+type Criterion struct {
+	ID          string  `json:"id"`
+	Title       string  `json:"title"`
+	Description string  `json:"description,omitempty"`
+	Points      float64 `json:"points"`
+}
+
+// Grade - оценка работы по критерию
+type Grade struct {
+	CriterionID string  `json:"criterion_id"`
+	Points      float64 `json:"points"`
+	Comment     string  `json:"comment,omitempty"`
+}
+
+type Result struct {
+	// Score - процент набранных баллов
+	Score     float64
+	Points    float64
+	MaxPoints float64
+	Passed    bool
+}
+
+// Problem - ошибка в задании, Path указывает на поле
+type Problem struct {
+	Path    string
+	Message string
+}
+
+// Normalize убирает лишние пробелы и задаёт идентификаторы критериям без них.
+// Вызывается перед Validate при сохранении задания редактором.
+func (a *Assignment) Normalize() {
+	a.Instructions = strings.TrimSpace(a.Instructions)
+	taken := make(map[string]bool, len(a.Rubric))
+	for _, c := range a.Rubric {
+		taken[strings.TrimSpace(c.ID)] = true
+	}
+	for i := range a.Rubric {
+		c := &a.Rubric[i]
+		c.ID = strings.TrimSpace(c.ID)
+		for n := 1; c.ID == ""; n++ {
+			if id := "c" + strconv.Itoa(n); !taken[id] {
+				c.ID = id
+				taken[id] = true
+			}
+		}
+		c.Title = strings.TrimSpace(c.Title)
+		c.Description = strings.TrimSpace(c.Description)
+	}
+}
+
+// Validate возвращает все найденные ошибки задания
+func (a *Assignment) Validate() []Problem {
+	var problems []Problem
+	addf := func(path, format string, args ...any) {
+		problems = append(problems, Problem{Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if strings.TrimSpace(a.Instructions) == "" {
+		addf("instructions", "must not be empty")
+	} else if len(a.Instructions) > maxInstructionsLen {
+		addf("instructions", "is longer than %d bytes", maxInstructionsLen)
+	}
+	if !a.AllowText && !a.AllowFiles {
+		addf("allow_text", "text or files must be allowed")
+	}
+	if !finite(a.PassingScore) || a.PassingScore < 0 || a.PassingScore > 100 {
+		addf("passing_score", "must be between 0 and 100")
+	}
+	switch {
+	case len(a.Rubric) == 0:
+		addf("rubric", "at least one criterion is required")
+	case len(a.Rubric) > MaxCriteria:
+		addf("rubric", "at most %d criteria are allowed", MaxCriteria)
+	}
+
+	ids := make(map[string]bool, len(a.Rubric))
+	for i, c := range a.Rubric {
+		path := fmt.Sprintf("rubric[%d]", i)
+		switch {
+		case c.ID == "":
+			addf(path+".id", "must not be empty")
+		case len(c.ID) > maxIDLen:
+			addf(path+".id", "is longer than %d bytes", maxIDLen)
+		case ids[c.ID]:
+			addf(path+".id", "duplicate id %q", c.ID)
+		}
+		ids[c.ID] = true
+		switch {
+		case strings.TrimSpace(c.Title) == "":
+			addf(path+".title", "must not be empty")
+		case len(c.Title) > maxTitleLen:
+			addf(path+".title", "is longer than %d bytes", maxTitleLen)
+		}
+		if len(c.Description) > maxCommentLen {
+			addf(path+".description", "is longer than %d bytes", maxCommentLen)
+		}
+		if !finite(c.Points) || c.Points <= 0 {
+			addf(path+".points", "must be positive")
+		}
+	}
+	return problems
+}
+
+// Score считает результат оценки. Каждый критерий рубрики должен быть оценён ровно один раз.
+func (a Assignment) Score(grades []Grade) (Result, error) {
+	byCriterion := make(map[string]Grade, len(grades))
+	for _, g := range grades {
+		if _, ok := byCriterion[g.CriterionID]; ok {
+			return Result{}, fmt.Errorf("%w: duplicate grade for criterion %q", ErrInvalidGrade, g.CriterionID)
+		}
+		if len(g.Comment) > maxCommentLen {
+			return Result{}, fmt.Errorf("%w: comment is longer than %d bytes", ErrInvalidGrade, maxCommentLen)
+		}
+		byCriterion[g.CriterionID] = g
+	}
+
+	var res Result
+	for _, c := range a.Rubric {
+		g, ok := byCriterion[c.ID]
+		if !ok {
+			return Result{}, fmt.Errorf("%w: criterion %q is not graded", ErrInvalidGrade, c.ID)
+		}
+		delete(byCriterion, c.ID)
+		if !finite(g.Points) || g.Points < 0 || g.Points > c.Points {
+			return Result{}, fmt.Errorf("%w: points for criterion %q must be between 0 and %g", ErrInvalidGrade, c.ID, c.Points)
+		}
+		res.Points += g.Points
+		res.MaxPoints += c.Points
+	}
+	for id := range byCriterion {
+		return Result{}, fmt.Errorf("%w: unknown criterion %q", ErrInvalidGrade, id)
+	}
+
+	res.Score = 100
+	if res.MaxPoints > 0 {
+		res.Score = math.Round(res.Points/res.MaxPoints*10000) / 100
+	}
+	res.Passed = res.Score >= a.PassingScore
+	return res, nil
+}
+
+func finite(f float64) bool {
+	return !math.IsNaN(f) && !math.IsInf(f, 0)
+}
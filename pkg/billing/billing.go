@@ -0,0 +1,51 @@
+package billing
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+)
+
+var (
+	ErrInvalidWebhook        = errors.New("invalid webhook")
+	ErrWebhookSecretRequired = errors.New("webhook secret is required")
+)
+
+type EventType string
+
+const (
+	EventPaymentSucceeded      EventType = "payment.succeeded"
+	EventPaymentFailed         EventType = "payment.failed"
+	EventSubscriptionUpdated   EventType = "subscription.updated"
+	EventSubscriptionCancelled EventType = "subscription.cancelled"
+)
+
+// Event - событие платёжного провайдера, приведённое к общему виду
+type Event struct {
+	// ID - идентификатор события у провайдера, по нему отсекаются повторные доставки
+	ID             string
+	Type           EventType
+	AccountID      int64
+	SubscriptionID string
+	// PlanID - тариф после события, пустой, если событие тариф не меняет
+	PlanID    string
+	PeriodEnd *time.Time
+	// Payload - исходное тело вебхука
+	Payload []byte
+}
+
+type CheckoutParams struct {
+	AccountID int64
+	PlanID    string
+}
+
+type Provider interface {
+	// Name - имя провайдера, сохраняется вместе с подпиской и событиями
+	Name() string
+	// CreateCheckout возвращает ссылку на оплату тарифа
+	CreateCheckout(ctx context.Context, params CheckoutParams) (string, error)
+	CancelSubscription(ctx context.Context, subscriptionID string) error
+	// ParseWebhook проверяет подпись вебхука и разбирает событие
+	ParseWebhook(header http.Header, payload []byte) (Event, error)
+}
@@ -0,0 +1,88 @@
+package billing
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+const FakeSignatureHeader = "X-Fake-Signature"
+
+// NewFake возвращает провайдера для разработки и тестов. Оплата не проводится:
+// ссылка на оплату фиктивная, а события отправляются на вебхук вручную в виде
+//
+//	{"id": "evt_1", "type": "payment.succeeded", "account_id": 1, "subscription_id": "sub_1", "plan_id": "team"}
+//
+// Тело должно быть подписано secret по HMAC-SHA256 в заголовке X-Fake-Signature. Без secret
+// вебхуки принимаются без подписи только при allowUnsigned, иначе возвращается ErrWebhookSecretRequired.
+func NewFake(secret string, allowUnsigned bool) (Provider, error) {
+	if secret == "" && !allowUnsigned {
+		return nil, ErrWebhookSecretRequired
+	}
+	return &fakeProvider{secret: secret}, nil
+}
+
+type fakeProvider struct {
+	// secret - пустой только в явно разрешённом режиме без подписи
+	secret string
+}
+
+type fakeEvent struct {
+	ID             string     `json:"id"`
+	Type           EventType  `json:"type"`
+	AccountID      int64      `json:"account_id"`
+	SubscriptionID string     `json:"subscription_id"`
+	PlanID         string     `json:"plan_id"`
+	PeriodEnd      *time.Time `json:"period_end"`
+}
+
+func (p *fakeProvider) Name() string {
+	return "fake"
+}
+
+func (p *fakeProvider) CreateCheckout(ctx context.Context, params CheckoutParams) (string, error) {
+	q := url.Values{}
+	q.Set("account_id", strconv.FormatInt(params.AccountID, 10))
+	q.Set("plan_id", params.PlanID)
+	return "https://billing.invalid/fake/checkout?" + q.Encode(), nil
+}
+
+func (p *fakeProvider) CancelSubscription(ctx context.Context, subscriptionID string) error {
+	return nil
+}
+
+func (p *fakeProvider) ParseWebhook(header http.Header, payload []byte) (Event, error) {
+	if p.secret != "" {
+		mac := hmac.New(sha256.New, []byte(p.secret))
+		mac.Write(payload)
+		expected := hex.EncodeToString(mac.Sum(nil))
+		if !hmac.Equal([]byte(expected), []byte(header.Get(FakeSignatureHeader))) {
+			return Event{}, fmt.Errorf("%w: bad signature", ErrInvalidWebhook)
+		}
+	}
+
+	var e fakeEvent
+	if err := json.Unmarshal(payload, &e); err != nil {
+		return Event{}, fmt.Errorf("%w: %v", ErrInvalidWebhook, err)
+	}
+	if e.ID == "" || e.Type == "" || e.AccountID == 0 {
+		return Event{}, fmt.Errorf("%w: id, type and account_id are required", ErrInvalidWebhook)
+	}
+
+	return Event{
+		ID:             e.ID,
+		Type:           e.Type,
+		AccountID:      e.AccountID,
+		SubscriptionID: e.SubscriptionID,
+		PlanID:         e.PlanID,
+		PeriodEnd:      e.PeriodEnd,
+		Payload:        payload,
+	}, nil
+}
@@ -0,0 +1,285 @@
+package codeexercise
+
+import (
+	"chalk/pkg/coderunner"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Упражнение проверяется запуском кода участника на тестах: программа читает вход
+// теста из stdin, её вывод сравнивается с ожидаемым без учёта пробелов в конце строк
+// и пустых строк в конце. Результат - процент баллов пройденных тестов. Упражнение
+// хранится в JSON блоков, версий курса и архивов, поэтому теги полей менять нельзя.
+
+const (
+	MaxTests    = 50
+	MaxCodeLen  = 64 << 10
+	MaxInputLen = 64 << 10
+
+	DefaultTimeLimitMs   = 2000
+	DefaultMemoryLimitMB = 256
+
+	minTimeLimitMs   = 100
+	maxTimeLimitMs   = 10000
+	minMemoryLimitMB = 32
+	maxMemoryLimitMB = 1024
+
+	maxIDLen   = 64
+	maxNameLen = 500
+	// maxStoredOutput - сколько вывода программы сохраняется в результате теста
+	maxStoredOutput = 4 << 10
+)
+
+type Exercise struct {
+	Language    string `json:"language"`
+	StarterCode string `json:"starter_code"`
+	// TimeLimitMs и MemoryLimitMB - ограничения на запуск одного теста
+	TimeLimitMs   int `json:"time_limit_ms"`
+	MemoryLimitMB int `json:"memory_limit_mb"`
+	// PassingScore - процент баллов, с которым упражнение решено
+	PassingScore float64    `json:"passing_score"`
+	Tests        []TestCase `json:"tests"`
+}
+
+type TestCase struct {
+	ID             string  `json:"id"`
+	Name           string  `json:"name,omitempty"`
+	Input          string  `json:"input"`
+	ExpectedOutput string  `json:"expected_output"`
+	Points         float64 `json:"points"`
+	// Hidden - вход, ожидаемый вывод и вывод программы на тесте участнику не показываются
+	Hidden bool `json:"hidden,omitempty"`
+}
+
+type TestStatus string
+
+const (
+	TestPassed       TestStatus = "passed"
+	TestWrongOutput  TestStatus = "wrong_output"
+	TestRuntimeError TestStatus = "runtime_error"
+	TestTimedOut     TestStatus = "timed_out"
+	TestCompileError TestStatus = "compile_error"
+)
+
+type Result struct {
+	// Score - процент набранных баллов
+	Score     float64 `json:"score"`
+	Points    float64 `json:"points"`
+	MaxPoints float64 `json:"max_points"`
+	Passed    bool    `json:"passed"`
+	// CompileError - вывод компилятора, если программа не собралась
+	CompileError string       `json:"compile_error,omitempty"`
+	Tests        []TestResult `json:"tests"`
+}
+
+type TestResult struct {
+	TestID    string     `json:"test_id"`
+	Status    TestStatus `json:"status"`
+	Points    float64    `json:"points"`
+	MaxPoints float64    `json:"max_points"`
+	// Stdout и Stderr обрезаны до первых килобайт
+	Stdout     string `json:"stdout,omitempty"`
+	Stderr     string `json:"stderr,omitempty"`
+	ExitCode   int    `json:"exit_code"`
+	DurationMs int64  `json:"duration_ms"`
+}
+
+// Problem - ошибка в упражнении, Path указывает на поле
+type Problem struct {
+	Path    string
+	Message string
+}
+
+// Normalize задаёт ограничения по умолчанию и идентификаторы тестам без них.
+// Вызывается перед Validate при сохранении упражнения редактором.
+func (e *Exercise) Normalize() {
+	e.Language = strings.ToLower(strings.TrimSpace(e.Language))
+	if e.TimeLimitMs == 0 {
+		e.TimeLimitMs = DefaultTimeLimitMs
+	}
+	if e.MemoryLimitMB == 0 {
+		e.MemoryLimitMB = DefaultMemoryLimitMB
+	}
+	taken := make(map[string]bool, len(e.Tests))
+	for _, t := range e.Tests {
+		taken[strings.TrimSpace(t.ID)] = true
+	}
+	for i := range e.Tests {
+		t := &e.Tests[i]
+		t.ID = strings.TrimSpace(t.ID)
+		for n := 1; t.ID == ""; n++ {
+			if id := "t" + strconv.Itoa(n); !taken[id] {
+				t.ID = id
+				taken[id] = true
+			}
+		}
+		t.Name = strings.TrimSpace(t.Name)
+	}
+}
+
+// Validate возвращает все найденные ошибки упражнения. Поддерживает ли раннер язык,
+// проверяет вызывающий.
+func (e *Exercise) Validate() []Problem {
+	var problems []Problem
+	addf := func(path, format string, args ...any) {
+		problems = append(problems, Problem{Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if e.Language == "" {
+		addf("language", "must not be empty")
+	}
+	if len(e.StarterCode) > MaxCodeLen {
+		addf("starter_code", "is longer than %d bytes", MaxCodeLen)
+	}
+	if e.TimeLimitMs < minTimeLimitMs || e.TimeLimitMs > maxTimeLimitMs {
+		addf("time_limit_ms", "must be between %d and %d", minTimeLimitMs, maxTimeLimitMs)
+	}
+	if e.MemoryLimitMB < minMemoryLimitMB || e.MemoryLimitMB > maxMemoryLimitMB {
+		addf("memory_limit_mb", "must be between %d and %d", minMemoryLimitMB, maxMemoryLimitMB)
+	}
+	if !finite(e.PassingScore) || e.PassingScore < 0 || e.PassingScore > 100 {
+		addf("passing_score", "must be between 0 and 100")
+	}
+	switch {
+	case len(e.Tests) == 0:
+		addf("tests", "at least one test is required")
+	case len(e.Tests) > MaxTests:
+		addf("tests", "at most %d tests are allowed", MaxTests)
+	}
+
+	ids := make(map[string]bool, len(e.Tests))
+	for i, t := range e.Tests {
+		path := fmt.Sprintf("tests[%d]", i)
+		switch {
+		case t.ID == "":
+			addf(path+".id", "must not be empty")
+		case len(t.ID) > maxIDLen:
+			addf(path+".id", "is longer than %d bytes", maxIDLen)
+		case ids[t.ID]:
+			addf(path+".id", "duplicate id %q", t.ID)
+		}
+		ids[t.ID] = true
+		if len(t.Name) > maxNameLen {
+			addf(path+".name", "is longer than %d bytes", maxNameLen)
+		}
+		if len(t.Input) > MaxInputLen {
+			addf(path+".input", "is longer than %d bytes", MaxInputLen)
+		}
+		if len(t.ExpectedOutput) > MaxInputLen {
+			addf(path+".expected_output", "is longer than %d bytes", MaxInputLen)
+		}
+		if !finite(t.Points) || t.Points <= 0 {
+			addf(path+".points", "must be positive")
+		}
+	}
+	return problems
+}
+
+// WithoutHiddenTests возвращает упражнение для участника: у скрытых тестов остаются
+// только идентификатор, название и баллы
+func (e Exercise) WithoutHiddenTests() Exercise {
+	res := e
+	res.Tests = make([]TestCase, 0, len(e.Tests))
+	for _, t := range e.Tests {
+		if t.Hidden {
+			t = TestCase{ID: t.ID, Name: t.Name, Points: t.Points, Hidden: true}
+		}
+		res.Tests = append(res.Tests, t)
+	}
+	return res
+}
+
+// Inputs - входы тестов в порядке тестов, для раннера
+func (e Exercise) Inputs() []string {
+	inputs := make([]string, 0, len(e.Tests))
+	for _, t := range e.Tests {
+		inputs = append(inputs, t.Input)
+	}
+	return inputs
+}
+
+func (e Exercise) Limits() coderunner.Limits {
+	return coderunner.Limits{
+		Timeout:     time.Duration(e.TimeLimitMs) * time.Millisecond,
+		MemoryBytes: int64(e.MemoryLimitMB) << 20,
+	}
+}
+
+// Grade сравнивает вывод программы на входах Inputs с ожидаемым
+func (e Exercise) Grade(run coderunner.Result) Result {
+	res := Result{CompileError: truncate(run.CompileError), Tests: make([]TestResult, 0, len(e.Tests))}
+	for i, t := range e.Tests {
+		tr := TestResult{TestID: t.ID, MaxPoints: t.Points}
+		res.MaxPoints += t.Points
+		if run.CompileError != "" || i >= len(run.Outputs) {
+			tr.Status = TestCompileError
+			res.Tests = append(res.Tests, tr)
+			continue
+		}
+
+		out := run.Outputs[i]
+		tr.Stdout = truncate(out.Stdout)
+		tr.Stderr = truncate(out.Stderr)
+		tr.ExitCode = out.ExitCode
+		tr.DurationMs = out.Duration.Milliseconds()
+		switch {
+		case out.TimedOut:
+			tr.Status = TestTimedOut
+		case out.ExitCode != 0:
+			tr.Status = TestRuntimeError
+		case normalizeOutput(out.Stdout) != normalizeOutput(t.ExpectedOutput):
+			tr.Status = TestWrongOutput
+		default:
+			tr.Status = TestPassed
+			tr.Points = t.Points
+			res.Points += t.Points
+		}
+		res.Tests = append(res.Tests, tr)
+	}
+
+	if res.MaxPoints > 0 {
+		res.Score = math.Round(res.Points/res.MaxPoints*10000) / 100
+	}
+	res.Passed = res.CompileError == "" && res.Score >= e.PassingScore
+	return res
+}
+
+// HideOutput убирает из результата вывод программы на скрытых тестах упражнения
+// и на тестах, которых в упражнении уже нет
+func (e Exercise) HideOutput(r Result) Result {
+	hidden := make(map[string]bool, len(e.Tests))
+	for _, t := range e.Tests {
+		hidden[t.ID] = t.Hidden
+	}
+	res := r
+	res.Tests = make([]TestResult, 0, len(r.Tests))
+	for _, t := range r.Tests {
+		if h, ok := hidden[t.TestID]; h || !ok {
+			t.Stdout, t.Stderr = "", ""
+		}
+		res.Tests = append(res.Tests, t)
+	}
+	return res
+}
+
+func normalizeOutput(s string) string {
+	lines := strings.Split(strings.ReplaceAll(s, "\r\n", "\n"), "\n")
+	for i, l := range lines {
+		lines[i] = strings.TrimRight(l, " \t\r")
+	}
+	return strings.TrimRight(strings.Join(lines, "\n"), "\n")
+}
+
+func truncate(s string) string {
+	if len(s) <= maxStoredOutput {
+		return s
+	}
+	return strings.ToValidUTF8(s[:maxStoredOutput], "")
+}
+
+func finite(f float64) bool {
+	return !math.IsNaN(f) && !math.IsInf(f, 0)
+}
@@ -0,0 +1,54 @@
+package coderunner
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var (
+	ErrUnsupportedLanguage = errors.New("unsupported language")
+	ErrSandboxUnsupported  = errors.New("sandbox is not supported on this platform")
+)
+
+// Program - код участника на одном из языков раннера
+type Program struct {
+	Language string
+	Code     string
+}
+
+// Limits - ограничения на один запуск программы
+type Limits struct {
+	Timeout     time.Duration
+	MemoryBytes int64
+}
+
+// Output - результат запуска программы на одном входе
+type Output struct {
+	Stdout string
+	Stderr string
+	// Truncated - вывод длиннее лимита раннера и обрезан
+	Truncated bool
+	// ExitCode - -1, если программа остановлена сигналом или по таймауту
+	ExitCode int
+	// Signal - сигнал, остановивший программу, пустой при обычном завершении
+	Signal   string
+	TimedOut bool
+	Duration time.Duration
+}
+
+// Result - результат запуска программы на всех входах. Если программа не собралась,
+// заполнен только CompileError.
+type Result struct {
+	CompileError string
+	Outputs      []Output
+}
+
+type Runner interface {
+	// Languages - языки, которые раннер умеет запускать
+	Languages() []string
+	// Run собирает программу, если язык компилируемый, и запускает её на каждом входе
+	// по порядку. Ошибка возвращается, только если запуск невозможен, ошибки программы
+	// попадают в Result.
+	Run(ctx context.Context, program Program, inputs []string, limits Limits) (Result, error)
+}
@@ -0,0 +1,310 @@
+package coderunner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Language - как собрать и запустить программу. Команды выполняются в каталоге с кодом.
+type Language struct {
+	// File - имя файла, в который записывается код
+	File string `yaml:"file"`
+	// Compile - команда сборки, пустая у интерпретируемых языков
+	Compile []string `yaml:"compile"`
+	Run     []string `yaml:"run"`
+}
+
+// DefaultLanguages - языки локального раннера, если в конфиге они не заданы
+var DefaultLanguages = map[string]Language{
+	"python":     {File: "main.py", Run: []string{"python3", "main.py"}},
+	"javascript": {File: "main.js", Run: []string{"node", "main.js"}},
+	"c":          {File: "main.c", Compile: []string{"cc", "-O2", "-o", "main", "main.c", "-lm"}, Run: []string{"./main"}},
+	"cpp":        {File: "main.cpp", Compile: []string{"c++", "-O2", "-o", "main", "main.cpp"}, Run: []string{"./main"}},
+}
+
+// DefaultReadOnlyPaths - что видно программе из системы сервера, только для чтения.
+// Отсутствующие пути пропускаются, символические ссылки воспроизводятся как есть.
+var DefaultReadOnlyPaths = []string{
+	"/usr", "/bin", "/sbin", "/lib", "/lib32", "/lib64", "/libx32",
+	"/etc/alternatives", "/etc/ld.so.cache", "/etc/ld.so.conf", "/etc/ld.so.conf.d",
+}
+
+// sandboxWorkDir - каталог с кодом внутри песочницы
+const sandboxWorkDir = "/work"
+
+type LocalConfig struct {
+	// WorkDir - где создаются каталоги запусков, пустой - системный временный каталог
+	WorkDir string
+	// Prlimit - путь к prlimit из util-linux, которым задаются ограничения процесса
+	Prlimit string
+	// Path - PATH программы участника
+	Path string
+	// UID - пользователь, от которого запускается код, если сервер работает от root.
+	// Без root код запускается от имени сервера.
+	UID int
+	// ReadOnlyPaths - пути системы, видимые программе только для чтения: компиляторы,
+	// интерпретаторы и их библиотеки. Пустой - DefaultReadOnlyPaths.
+	ReadOnlyPaths []string
+	// MaxParallel - сколько программ собирается и запускается одновременно
+	MaxParallel int
+	// MaxProcesses - сколько процессов и потоков может создать одна программа
+	MaxProcesses int
+	// MaxOutputBytes - сколько вывода каждого потока сохраняется
+	MaxOutputBytes int
+	// MaxFileBytes - максимальный размер файла, который может записать программа
+	MaxFileBytes int64
+	// CompileLimits - ограничения сборки
+	CompileLimits Limits
+	Languages     map[string]Language
+}
+
+// NewLocal возвращает раннер, запускающий код на этой машине. Каждая программа работает
+// в своём корне, где видны только ReadOnlyPaths и её временный каталог, без сети,
+// в отдельных user, PID, IPC и UTS namespace, с ограничениями процессорного времени,
+// памяти, числа процессов и размера файлов. Нужны Linux, user namespace и prlimit.
+// Если песочницу собрать не удалось, раннер не создаётся.
+func NewLocal(cfg LocalConfig) (Runner, error) {
+	if cfg.Prlimit == "" {
+		cfg.Prlimit = "prlimit"
+	}
+	if cfg.Path == "" {
+		cfg.Path = "/usr/local/bin:/usr/bin:/bin"
+	}
+	if cfg.UID == 0 {
+		cfg.UID = 65534
+	}
+	if len(cfg.ReadOnlyPaths) == 0 {
+		cfg.ReadOnlyPaths = DefaultReadOnlyPaths
+	}
+	if cfg.MaxParallel <= 0 {
+		cfg.MaxParallel = 2
+	}
+	if cfg.MaxProcesses <= 0 {
+		cfg.MaxProcesses = 64
+	}
+	if cfg.MaxOutputBytes <= 0 {
+		cfg.MaxOutputBytes = 64 << 10
+	}
+	if cfg.MaxFileBytes <= 0 {
+		cfg.MaxFileBytes = 16 << 20
+	}
+	if cfg.CompileLimits.Timeout <= 0 {
+		cfg.CompileLimits.Timeout = 30 * time.Second
+	}
+	if cfg.CompileLimits.MemoryBytes <= 0 {
+		cfg.CompileLimits.MemoryBytes = 1 << 30
+	}
+	if len(cfg.Languages) == 0 {
+		cfg.Languages = DefaultLanguages
+	}
+	r := &localRunner{cfg: cfg, slots: make(chan struct{}, cfg.MaxParallel)}
+	if err := r.check(); err != nil {
+		return nil, fmt.Errorf("sandbox check failed: %w", err)
+	}
+	return r, nil
+}
+
+// check запускает в песочнице проверку, что каталог запуска не виден по пути в системе сервера
+func (r *localRunner) check() error {
+	runDir, err := r.createRunDir()
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(runDir)
+	if err := prepareDir(runDir, r.cfg.UID); err != nil {
+		return err
+	}
+
+	out, err := r.exec(context.Background(), runDir, []string{"test", "!", "-e", runDir}, "", r.cfg.CompileLimits)
+	if err != nil {
+		return err
+	}
+	if out.ExitCode != 0 {
+		return fmt.Errorf("server files are visible in the sandbox: %s", strings.TrimSpace(out.Stderr))
+	}
+	return nil
+}
+
+// createRunDir создаёт каталог запуска: work - каталог кода, root - точка монтирования корня песочницы
+func (r *localRunner) createRunDir() (string, error) {
+	runDir, err := os.MkdirTemp(r.cfg.WorkDir, "run-")
+	if err != nil {
+		return "", fmt.Errorf("create run dir: %w", err)
+	}
+	for _, name := range []string{"work", "root"} {
+		if err := os.Mkdir(filepath.Join(runDir, name), 0o755); err != nil {
+			os.RemoveAll(runDir)
+			return "", fmt.Errorf("create run dir: %w", err)
+		}
+	}
+	return runDir, nil
+}
+
+type localRunner struct {
+	cfg   LocalConfig
+	slots chan struct{}
+}
+
+func (r *localRunner) Languages() []string {
+	langs := make([]string, 0, len(r.cfg.Languages))
+	for name := range r.cfg.Languages {
+		langs = append(langs, name)
+	}
+	slices.Sort(langs)
+	return langs
+}
+
+func (r *localRunner) Run(ctx context.Context, program Program, inputs []string, limits Limits) (Result, error) {
+	lang, ok := r.cfg.Languages[program.Language]
+	if !ok {
+		return Result{}, ErrUnsupportedLanguage
+	}
+
+	select {
+	case r.slots <- struct{}{}:
+	case <-ctx.Done():
+		return Result{}, ctx.Err()
+	}
+	defer func() { <-r.slots }()
+
+	runDir, err := r.createRunDir()
+	if err != nil {
+		return Result{}, err
+	}
+	defer os.RemoveAll(runDir)
+	if err := os.WriteFile(filepath.Join(runDir, "work", lang.File), []byte(program.Code), 0o644); err != nil {
+		return Result{}, fmt.Errorf("write program: %w", err)
+	}
+	if err := prepareDir(runDir, r.cfg.UID); err != nil {
+		return Result{}, err
+	}
+
+	if len(lang.Compile) > 0 {
+		out, err := r.exec(ctx, runDir, lang.Compile, "", r.cfg.CompileLimits)
+		if err != nil {
+			return Result{}, err
+		}
+		if out.ExitCode != 0 || out.TimedOut {
+			msg := strings.TrimSpace(out.Stderr + "\n" + out.Stdout)
+			if out.TimedOut {
+				msg = "compilation timed out"
+			}
+			return Result{CompileError: msg}, nil
+		}
+	}
+
+	res := Result{Outputs: make([]Output, 0, len(inputs))}
+	for _, input := range inputs {
+		out, err := r.exec(ctx, runDir, lang.Run, input, limits)
+		if err != nil {
+			return Result{}, err
+		}
+		res.Outputs = append(res.Outputs, out)
+	}
+	return res, nil
+}
+
+// exec запускает команду в песочнице. Таймаут запуска - не ошибка, а TimedOut в результате.
+func (r *localRunner) exec(ctx context.Context, runDir string, command []string, stdin string, limits Limits) (Output, error) {
+	runCtx, cancel := context.WithTimeout(ctx, limits.Timeout)
+	defer cancel()
+
+	// процессорного времени даётся с запасом, программу останавливает таймаут,
+	// а лимит CPU защищает от процессов, переживших его
+	cpu := int64(math.Ceil(limits.Timeout.Seconds())) + 1
+	args := []string{
+		"--cpu=" + strconv.FormatInt(cpu, 10),
+		"--data=" + strconv.FormatInt(limits.MemoryBytes, 10),
+		"--fsize=" + strconv.FormatInt(r.cfg.MaxFileBytes, 10),
+		"--nofile=64",
+		"--core=0",
+		"--nproc=" + strconv.Itoa(r.cfg.MaxProcesses),
+	}
+	args = append(append(args, "--"), command...)
+
+	report, reportW, err := os.Pipe()
+	if err != nil {
+		return Output{}, fmt.Errorf("create report pipe: %w", err)
+	}
+	defer report.Close()
+	defer reportW.Close()
+
+	cmd, err := sandboxCommand(runCtx, runDir, r.cfg.UID, r.cfg.ReadOnlyPaths, append([]string{r.cfg.Prlimit}, args...), reportW)
+	if err != nil {
+		return Output{}, err
+	}
+	cmd.Env = []string{"PATH=" + r.cfg.Path, "HOME=" + sandboxWorkDir, "TMPDIR=/tmp", "LANG=C.UTF-8"}
+	cmd.Stdin = strings.NewReader(stdin)
+	stdout := &limitedBuffer{max: r.cfg.MaxOutputBytes}
+	stderr := &limitedBuffer{max: r.cfg.MaxOutputBytes}
+	cmd.Stdout, cmd.Stderr = stdout, stderr
+	cmd.Cancel = func() error { return killGroup(cmd.Process) }
+	cmd.WaitDelay = time.Second
+
+	start := time.Now()
+	if err := cmd.Start(); err != nil {
+		return Output{}, fmt.Errorf("start sandbox: %w", err)
+	}
+	// у песочницы своя копия, после exec программы она закрывается
+	reportW.Close()
+	err = cmd.Wait()
+	out := Output{
+		Stdout:    stdout.String(),
+		Stderr:    stderr.String(),
+		Truncated: stdout.truncated || stderr.truncated,
+		Duration:  time.Since(start),
+	}
+
+	if ctx.Err() != nil {
+		return Output{}, ctx.Err()
+	}
+	if msg, _ := io.ReadAll(report); len(msg) > 0 {
+		return Output{}, fmt.Errorf("sandbox: %s", msg)
+	}
+
+	var exitErr *exec.ExitError
+	switch {
+	case runCtx.Err() != nil:
+		out.ExitCode = -1
+		out.TimedOut = true
+	case errors.As(err, &exitErr):
+		out.ExitCode = exitErr.ExitCode()
+		out.Signal = exitSignal(exitErr)
+		// превышение лимита CPU - тот же таймаут
+		out.TimedOut = out.Signal == "SIGXCPU"
+	case err != nil:
+		return Output{}, fmt.Errorf("run %s: %w", command[0], err)
+	}
+	return out, nil
+}
+
+// limitedBuffer сохраняет первые max байт и отбрасывает остальное, не блокируя программу
+type limitedBuffer struct {
+	buf       []byte
+	max       int
+	truncated bool
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if room := b.max - len(b.buf); room < len(p) {
+		b.buf = append(b.buf, p[:max(room, 0)]...)
+		b.truncated = true
+	} else {
+		b.buf = append(b.buf, p...)
+	}
+	return len(p), nil
+}
+
+func (b *limitedBuffer) String() string {
+	return strings.ToValidUTF8(string(b.buf), "�")
+}
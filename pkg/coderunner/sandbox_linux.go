@@ -0,0 +1,372 @@
+package coderunner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"syscall"
+	"unsafe"
+)
+
+// sandboxInitName - argv[0], с которым сервер перезапускает сам себя, чтобы собрать песочницу
+// в новых namespace до запуска программы
+const sandboxInitName = "chalk-sandbox-init"
+
+// isolation - namespace, в которых работает программа. Новый network namespace содержит
+// только выключенный loopback, так что сети у программы нет. В user namespace программа -
+// root без capabilities, снаружи - пользователь песочницы, а лимит процессов считается
+// только по процессам этого namespace.
+const isolation = syscall.CLONE_NEWUSER | syscall.CLONE_NEWNS | syscall.CLONE_NEWNET |
+	syscall.CLONE_NEWPID | syscall.CLONE_NEWIPC | syscall.CLONE_NEWUTS
+
+// sandboxDevices пробрасываются в /dev песочницы
+var sandboxDevices = []string{"/dev/null", "/dev/zero", "/dev/random", "/dev/urandom"}
+
+// sandboxTmpSize - размер /tmp песочницы, он живёт в памяти
+const sandboxTmpSize = "64m"
+
+func init() {
+	if len(os.Args) > 0 && os.Args[0] == sandboxInitName {
+		sandboxInit()
+	}
+}
+
+// sandboxCommand готовит запуск command в песочнице: сервер перезапускает сам себя в новых
+// namespace, собирает там корень из readOnly и каталога runDir/work и выполняет command.
+// Ошибка сборки песочницы пишется в report, а не в вывод программы.
+func sandboxCommand(ctx context.Context, runDir string, uid int, readOnly, command []string, report *os.File) (*exec.Cmd, error) {
+	// в namespace программа - root, снаружи - пользователь песочницы или, без root, сам сервер.
+	// Дополнительные группы сервера сбрасываются, только если он root: иначе их менять нельзя.
+	hostUID, hostGID := os.Getuid(), os.Getgid()
+	privileged := os.Geteuid() == 0
+	if privileged {
+		hostUID, hostGID = uid, uid
+	}
+
+	cmd := exec.CommandContext(ctx, "/proc/self/exe")
+	cmd.Args = append([]string{sandboxInitName, runDir, strings.Join(readOnly, ":"), "--"}, command...)
+	cmd.ExtraFiles = []*os.File{report}
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		Setpgid:     true,
+		Pdeathsig:   syscall.SIGKILL,
+		Cloneflags:  isolation,
+		UidMappings: []syscall.SysProcIDMap{{ContainerID: 0, HostID: hostUID, Size: 1}},
+		GidMappings: []syscall.SysProcIDMap{{ContainerID: 0, HostID: hostGID, Size: 1}},
+		Credential:  &syscall.Credential{Uid: 0, Gid: 0, NoSetGroups: !privileged},
+
+		GidMappingsEnableSetgroups: privileged,
+	}
+	return cmd, nil
+}
+
+// sandboxInit работает внутри новых namespace: собирает корень, сбрасывает capabilities и
+// выполняет команду. Всё делается в одном потоке ОС, capabilities у потоков свои.
+func sandboxInit() {
+	runtime.LockOSThread()
+	report := os.NewFile(3, "report")
+	syscall.CloseOnExec(3)
+	fail := func(step string, err error) {
+		fmt.Fprintf(report, "%s: %v", step, err)
+		os.Exit(127)
+	}
+	if len(os.Args) < 5 || os.Args[3] != "--" {
+		fail("init", errors.New("bad arguments"))
+	}
+	runDir, command := os.Args[1], os.Args[4:]
+	var readOnly []string
+	if os.Args[2] != "" {
+		readOnly = strings.Split(os.Args[2], ":")
+	}
+
+	lastCap, err := readLastCap()
+	if err != nil {
+		fail("read cap_last_cap", err)
+	}
+	if err := setupRoot(runDir, readOnly); err != nil {
+		fail("setup root", err)
+	}
+	if err := dropCapabilities(lastCap); err != nil {
+		fail("drop capabilities", err)
+	}
+
+	path, err := exec.LookPath(command[0])
+	if err != nil {
+		fail("find command", err)
+	}
+	err = syscall.Exec(path, command, os.Environ())
+	fail("exec "+command[0], err)
+}
+
+// setupRoot собирает на tmpfs корень из путей readOnly, каталога кода, /tmp, /proc и /dev,
+// переходит в него и отмонтирует систему сервера
+func setupRoot(runDir string, readOnly []string) error {
+	root := filepath.Join(runDir, "root")
+
+	if err := syscall.Mount("", "/", "", syscall.MS_REC|syscall.MS_PRIVATE, ""); err != nil {
+		return fmt.Errorf("make mounts private: %w", err)
+	}
+	if err := syscall.Mount("tmpfs", root, "tmpfs", syscall.MS_NOSUID|syscall.MS_NODEV, "size=1m,mode=0755"); err != nil {
+		return fmt.Errorf("mount root: %w", err)
+	}
+
+	for _, p := range readOnly {
+		if err := bindHostPath(p, filepath.Join(root, p)); err != nil {
+			return err
+		}
+	}
+
+	work := filepath.Join(root, sandboxWorkDir)
+	if err := bindMount(filepath.Join(runDir, "work"), work, syscall.MS_NOSUID|syscall.MS_NODEV); err != nil {
+		return err
+	}
+
+	tmp := filepath.Join(root, "tmp")
+	if err := os.Mkdir(tmp, 0o755); err != nil {
+		return fmt.Errorf("create /tmp: %w", err)
+	}
+	if err := syscall.Mount("tmpfs", tmp, "tmpfs", syscall.MS_NOSUID|syscall.MS_NODEV, "size="+sandboxTmpSize+",mode=1777"); err != nil {
+		return fmt.Errorf("mount /tmp: %w", err)
+	}
+
+	proc := filepath.Join(root, "proc")
+	if err := os.Mkdir(proc, 0o755); err != nil {
+		return fmt.Errorf("create /proc: %w", err)
+	}
+	if err := syscall.Mount("proc", proc, "proc", syscall.MS_NOSUID|syscall.MS_NODEV|syscall.MS_NOEXEC, ""); err != nil {
+		return fmt.Errorf("mount /proc: %w", err)
+	}
+
+	dev := filepath.Join(root, "dev")
+	if err := os.Mkdir(dev, 0o755); err != nil {
+		return fmt.Errorf("create /dev: %w", err)
+	}
+	if err := syscall.Mount("tmpfs", dev, "tmpfs", syscall.MS_NOSUID|syscall.MS_NOEXEC, "size=64k,mode=0755"); err != nil {
+		return fmt.Errorf("mount /dev: %w", err)
+	}
+	for _, d := range sandboxDevices {
+		if err := bindMount(d, filepath.Join(root, d), syscall.MS_NOSUID|syscall.MS_NOEXEC); err != nil {
+			return err
+		}
+	}
+
+	old := filepath.Join(root, ".old")
+	if err := os.Mkdir(old, 0o700); err != nil {
+		return fmt.Errorf("create old root: %w", err)
+	}
+	if err := syscall.PivotRoot(root, old); err != nil {
+		return fmt.Errorf("pivot root: %w", err)
+	}
+	if err := syscall.Chdir("/"); err != nil {
+		return fmt.Errorf("chdir /: %w", err)
+	}
+	if err := syscall.Unmount("/.old", syscall.MNT_DETACH); err != nil {
+		return fmt.Errorf("unmount old root: %w", err)
+	}
+	if err := os.Remove("/.old"); err != nil {
+		return fmt.Errorf("remove old root: %w", err)
+	}
+	if err := remount("/", syscall.MS_RDONLY|syscall.MS_NOSUID|syscall.MS_NODEV); err != nil {
+		return err
+	}
+	if err := syscall.Chdir(sandboxWorkDir); err != nil {
+		return fmt.Errorf("chdir %s: %w", sandboxWorkDir, err)
+	}
+	return nil
+}
+
+// bindHostPath повторяет путь системы сервера в корне песочницы только для чтения
+func bindHostPath(src, dst string) error {
+	fi, err := os.Lstat(src)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("stat %s: %w", src, err)
+	}
+	if fi.Mode()&os.ModeSymlink != 0 {
+		target, err := os.Readlink(src)
+		if err != nil {
+			return fmt.Errorf("read link %s: %w", src, err)
+		}
+		if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+			return fmt.Errorf("create %s: %w", filepath.Dir(dst), err)
+		}
+		if err := os.Symlink(target, dst); err != nil {
+			return fmt.Errorf("create link %s: %w", dst, err)
+		}
+		return nil
+	}
+	return bindMount(src, dst, syscall.MS_RDONLY|syscall.MS_NOSUID|syscall.MS_NODEV)
+}
+
+// bindMount монтирует src в dst с флагами flags, создавая точку монтирования
+func bindMount(src, dst string, flags uintptr) error {
+	fi, err := os.Stat(src)
+	if err != nil {
+		return fmt.Errorf("stat %s: %w", src, err)
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return fmt.Errorf("create %s: %w", filepath.Dir(dst), err)
+	}
+	if fi.IsDir() {
+		err = os.Mkdir(dst, 0o755)
+	} else {
+		err = os.WriteFile(dst, nil, 0o644)
+	}
+	if err != nil {
+		return fmt.Errorf("create mount point %s: %w", dst, err)
+	}
+	if err := syscall.Mount(src, dst, "", syscall.MS_BIND|syscall.MS_REC, ""); err != nil {
+		return fmt.Errorf("bind %s: %w", src, err)
+	}
+	return remount(dst, flags)
+}
+
+// remount меняет флаги точки монтирования. Флаги, унаследованные от системы сервера,
+// сохраняются: внутри user namespace снять их нельзя.
+func remount(path string, flags uintptr) error {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(path, &st); err != nil {
+		return fmt.Errorf("statfs %s: %w", path, err)
+	}
+	locked := map[int64]uintptr{
+		0x1:    syscall.MS_RDONLY,
+		0x2:    syscall.MS_NOSUID,
+		0x4:    syscall.MS_NODEV,
+		0x8:    syscall.MS_NOEXEC,
+		0x400:  syscall.MS_NOATIME,
+		0x800:  syscall.MS_NODIRATIME,
+		0x1000: syscall.MS_RELATIME,
+	}
+	for stFlag, ms := range locked {
+		if st.Flags&stFlag != 0 {
+			flags |= ms
+		}
+	}
+	if err := syscall.Mount("", path, "", syscall.MS_BIND|syscall.MS_REMOUNT|flags, ""); err != nil {
+		return fmt.Errorf("remount %s: %w", path, err)
+	}
+	return nil
+}
+
+func readLastCap() (int, error) {
+	data, err := os.ReadFile("/proc/sys/kernel/cap_last_cap")
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(strings.TrimSpace(string(data)))
+}
+
+const (
+	prSetNoNewPrivs      = 38
+	prCapAmbient         = 47
+	prCapAmbientClearAll = 4
+	linuxCapabilityV3    = 0x20080522
+)
+
+// dropCapabilities очищает bounding, inheritable и ambient наборы потока и запрещает
+// повышение привилегий: после exec у программы не будет capabilities, хотя она root
+// в своём user namespace
+func dropCapabilities(lastCap int) error {
+	for c := 0; c <= lastCap; c++ {
+		if err := prctl(syscall.PR_CAPBSET_DROP, uintptr(c)); err != nil {
+			return fmt.Errorf("drop bounding capability %d: %w", c, err)
+		}
+	}
+	if err := prctl(prCapAmbient, prCapAmbientClearAll); err != nil && !errors.Is(err, syscall.EINVAL) {
+		return fmt.Errorf("clear ambient capabilities: %w", err)
+	}
+
+	hdr := struct {
+		version uint32
+		pid     int32
+	}{version: linuxCapabilityV3}
+	var data [2]struct{ effective, permitted, inheritable uint32 }
+	if _, _, errno := syscall.RawSyscall(syscall.SYS_CAPGET, uintptr(unsafe.Pointer(&hdr)), uintptr(unsafe.Pointer(&data[0])), 0); errno != 0 {
+		return fmt.Errorf("capget: %w", errno)
+	}
+	data[0].inheritable, data[1].inheritable = 0, 0
+	if _, _, errno := syscall.RawSyscall(syscall.SYS_CAPSET, uintptr(unsafe.Pointer(&hdr)), uintptr(unsafe.Pointer(&data[0])), 0); errno != 0 {
+		return fmt.Errorf("capset: %w", errno)
+	}
+
+	if err := prctl(prSetNoNewPrivs, 1); err != nil {
+		return fmt.Errorf("set no_new_privs: %w", err)
+	}
+	return nil
+}
+
+func prctl(option, arg uintptr) error {
+	if _, _, errno := syscall.RawSyscall6(syscall.SYS_PRCTL, option, arg, 0, 0, 0, 0); errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+// prepareDir отдаёт каталог кода пользователю песочницы: root сервера в user namespace
+// песочницы не отображается
+func prepareDir(runDir string, uid int) error {
+	if os.Geteuid() != 0 {
+		return nil
+	}
+	// пользователь песочницы проходит через каталог запуска, но не видит его содержимое
+	if err := os.Chmod(runDir, 0o711); err != nil {
+		return fmt.Errorf("chmod run dir: %w", err)
+	}
+	work := filepath.Join(runDir, "work")
+	entries, err := os.ReadDir(work)
+	if err != nil {
+		return fmt.Errorf("read work dir: %w", err)
+	}
+	for _, e := range entries {
+		if err := os.Chown(filepath.Join(work, e.Name()), uid, uid); err != nil {
+			return fmt.Errorf("chown run file: %w", err)
+		}
+	}
+	for _, dir := range []string{work, filepath.Join(runDir, "root")} {
+		if err := os.Chown(dir, uid, uid); err != nil {
+			return fmt.Errorf("chown run dir: %w", err)
+		}
+	}
+	return nil
+}
+
+// killGroup останавливает программу вместе со всеми её процессами
+func killGroup(p *os.Process) error {
+	if err := syscall.Kill(-p.Pid, syscall.SIGKILL); err != nil && !errors.Is(err, syscall.ESRCH) {
+		return err
+	}
+	return nil
+}
+
+func exitSignal(err *exec.ExitError) string {
+	ws, ok := err.Sys().(syscall.WaitStatus)
+	if !ok || !ws.Signaled() {
+		return ""
+	}
+	return signalName(ws.Signal())
+}
+
+func signalName(sig syscall.Signal) string {
+	switch sig {
+	case syscall.SIGXCPU:
+		return "SIGXCPU"
+	case syscall.SIGKILL:
+		return "SIGKILL"
+	case syscall.SIGSEGV:
+		return "SIGSEGV"
+	case syscall.SIGABRT:
+		return "SIGABRT"
+	case syscall.SIGFPE:
+		return "SIGFPE"
+	case syscall.SIGXFSZ:
+		return "SIGXFSZ"
+	}
+	return sig.String()
+}
@@ -0,0 +1,27 @@
+//go:build !linux
+
+package coderunner
+
+import (
+	"context"
+	"os"
+	"os/exec"
+)
+
+// Песочница держится на namespace Linux, на других системах локальный раннер не запускает код
+
+func sandboxCommand(ctx context.Context, runDir string, uid int, readOnly, command []string, report *os.File) (*exec.Cmd, error) {
+	return nil, ErrSandboxUnsupported
+}
+
+func prepareDir(runDir string, uid int) error {
+	return nil
+}
+
+func killGroup(p *os.Process) error {
+	return p.Kill()
+}
+
+func exitSignal(err *exec.ExitError) string {
+	return ""
+}
@@ -1,6 +1,7 @@
 package config
 
 import (
+	"chalk/pkg/coderunner"
 	"log"
 	"os"
 	"time"
@@ -9,10 +10,21 @@ import (
 )
 
 type Config struct {
-	RedisURI    string     `yaml:"redis_uri"`
-	PostgresURI string     `yaml:"postgres_uri"`
-	Auth        AuthConfig `yaml:"auth"`
-	HTTPPort    string     `yaml:"http_port"`
+	RedisURI    string          `yaml:"redis_uri"`
+	PostgresURI string          `yaml:"postgres_uri"`
+	Auth        AuthConfig      `yaml:"auth"`
+	Audit       AuditConfig     `yaml:"audit"`
+	Trash       TrashConfig     `yaml:"trash"`
+	Closure     ClosureConfig   `yaml:"closure"`
+	Courses     CoursesConfig   `yaml:"courses"`
+	Tenant      TenantConfig    `yaml:"tenant"`
+	S3          S3Config        `yaml:"s3"`
+	Files       FilesConfig     `yaml:"files"`
+	Billing     BillingConfig   `yaml:"billing"`
+	Exercises   ExercisesConfig `yaml:"exercises"`
+	HTTPPort    string          `yaml:"http_port"`
+	// TrustedProxies - адреса и подсети прокси, которым можно доверять X-Forwarded-For
+	TrustedProxies []string `yaml:"trusted_proxies"`
 }
 
 type AuthConfig struct {
@@ -24,6 +36,78 @@ type AuthConfig struct {
 	Mailer          MailerConfig  `yaml:"mailer"`
 }
 
+type AuditConfig struct {
+	// Retention - срок хранения записей журнала аудита, 0 - хранить бессрочно
+	Retention time.Duration `yaml:"retention"`
+}
+
+type TrashConfig struct {
+	// Retention - через сколько удалённые элементы курсов удаляются окончательно, 0 - никогда
+	Retention time.Duration `yaml:"retention"`
+}
+
+type ClosureConfig struct {
+	// GracePeriod - через сколько после запроса закрытия данные аккаунта удаляются
+	GracePeriod time.Duration `yaml:"grace_period"`
+}
+
+type CoursesConfig struct {
+	// TreeCacheTTL - время жизни дерева курса в Redis, 0 - не кэшировать
+	TreeCacheTTL time.Duration `yaml:"tree_cache_ttl"`
+}
+
+type TenantConfig struct {
+	BaseDomain string `yaml:"base_domain"`
+	// PathPrefix - разрешить адресацию аккаунта через /t/<slug>/ вместо поддомена
+	PathPrefix bool `yaml:"path_prefix"`
+}
+
+type S3Config struct {
+	Endpoint        string `yaml:"endpoint"`
+	AccessKeyID     string `yaml:"access_key_id"`
+	SecretAccessKey string `yaml:"secret_access_key"`
+	UseSSL          bool   `yaml:"use_ssl"`
+	Bucket          string `yaml:"bucket"`
+}
+
+type FilesConfig struct {
+	// MaxUploadSize - максимальный размер загружаемого файла в байтах
+	MaxUploadSize int64 `yaml:"max_upload_size"`
+	// AccountQuota - квота аккаунта без подписки в байтах, 0 - без ограничений.
+	// Должна совпадать с квотой тарифа free.
+	AccountQuota int64 `yaml:"account_quota"`
+}
+
+type BillingConfig struct {
+	// Provider - платёжный провайдер, пока поддерживается только fake
+	Provider      string `yaml:"provider"`
+	WebhookSecret string `yaml:"webhook_secret"`
+	// AllowUnsignedWebhooks - принимать вебхуки без подписи при пустом WebhookSecret, только для разработки
+	AllowUnsignedWebhooks bool `yaml:"allow_unsigned_webhooks"`
+}
+
+type ExercisesConfig struct {
+	// Runner - где запускаются решения упражнений с кодом: local или пусто - запуск отключён
+	Runner string            `yaml:"runner"`
+	Local  LocalRunnerConfig `yaml:"local"`
+}
+
+type LocalRunnerConfig struct {
+	// WorkDir - каталог для временных каталогов запусков, пустой - системный временный каталог
+	WorkDir string `yaml:"work_dir"`
+	// Prlimit - путь к prlimit из util-linux
+	Prlimit string `yaml:"prlimit"`
+	// UID - пользователь, от которого запускается код, если сервер работает от root
+	UID int `yaml:"uid"`
+	// ReadOnlyPaths - пути системы, видимые коду только для чтения, пусто - пути по умолчанию
+	ReadOnlyPaths []string `yaml:"read_only_paths"`
+	MaxParallel   int      `yaml:"max_parallel"`
+	// MaxProcesses - сколько процессов может создать одна программа
+	MaxProcesses int `yaml:"max_processes"`
+	// Languages - языки и команды их сборки и запуска, пусто - языки по умолчанию
+	Languages map[string]coderunner.Language `yaml:"languages"`
+}
+
 type MailerConfig struct {
 	SmtpHost         string `yaml:"smtp_host"`
 	SmtpPort         string `yaml:"smtp_port"`
@@ -0,0 +1,278 @@
+package coursearchive
+
+import (
+	"chalk/pkg/assignment"
+	"chalk/pkg/codeexercise"
+	"chalk/pkg/media"
+	"chalk/pkg/quiz"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Архив курса - zip с manifest.json и объектами файлов в files/.
+// Version увеличивается при несовместимых изменениях манифеста,
+// архивы старых версий должны продолжать импортироваться.
+const (
+	Format       = "chalk.course"
+	Version      = 1
+	ManifestPath = "manifest.json"
+	FilesDir     = "files/"
+)
+
+const (
+	BlockTypeText         = "text"
+	BlockTypeVideo        = "video"
+	BlockTypeSCORM        = "scorm"
+	BlockTypeQuiz         = "quiz"
+	BlockTypeAssignment   = "assignment"
+	BlockTypeCodeExercise = "code_exercise"
+	BlockTypeImage        = "image"
+	BlockTypeAttachment   = "attachment"
+	BlockTypeEmbed        = "embed"
+)
+
+type Manifest struct {
+	Format     string    `json:"format"`
+	Version    int       `json:"version"`
+	ExportedAt time.Time `json:"exported_at"`
+	Course     Course    `json:"course"`
+	Files      []File    `json:"files"`
+}
+
+// Идентификаторы элементов - идентификаторы исходной инсталляции,
+// при импорте создаются новые элементы, ссылки сохраняются только на файлы.
+
+type Course struct {
+	ID      int64    `json:"id"`
+	Name    string   `json:"name"`
+	Modules []Module `json:"modules"`
+}
+
+type Module struct {
+	ID       int64    `json:"id"`
+	OrderIdx int      `json:"order_idx"`
+	Name     string   `json:"name"`
+	Lessons  []Lesson `json:"lessons"`
+}
+
+type Lesson struct {
+	ID       int64   `json:"id"`
+	OrderIdx int     `json:"order_idx"`
+	Name     string  `json:"name"`
+	Blocks   []Block `json:"blocks"`
+}
+
+type Block struct {
+	ID           int64                  `json:"id"`
+	OrderIdx     int                    `json:"order_idx"`
+	Type         string                 `json:"type"`
+	Content      *string                `json:"content,omitempty"`
+	FileID       *int64                 `json:"file_id,omitempty"`
+	Quiz         *quiz.Quiz             `json:"quiz,omitempty"`
+	Assignment   *assignment.Assignment `json:"assignment,omitempty"`
+	CodeExercise *codeexercise.Exercise `json:"code_exercise,omitempty"`
+	AltText      string                 `json:"alt_text,omitempty"`
+	Caption      string                 `json:"caption,omitempty"`
+	Title        string                 `json:"title,omitempty"`
+	Embed        *media.Embed           `json:"embed,omitempty"`
+}
+
+type File struct {
+	ID          int64  `json:"id"`
+	Path        string `json:"path"`
+	Name        string `json:"name"`
+	ContentType string `json:"content_type"`
+	Size        int64  `json:"size"`
+	SHA256      string `json:"sha256"`
+}
+
+// Problem - ошибка в архиве. Path указывает на поле манифеста или файл архива.
+type Problem struct {
+	Path    string
+	Message string
+}
+
+// ValidationError - архив не может быть импортирован
+type ValidationError struct {
+	Problems []Problem
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, 0, len(e.Problems))
+	for _, p := range e.Problems {
+		msgs = append(msgs, p.Path+": "+p.Message)
+	}
+	return "invalid course archive: " + strings.Join(msgs, "; ")
+}
+
+type validator struct {
+	problems []Problem
+}
+
+func (v *validator) addf(path, format string, args ...any) {
+	v.problems = append(v.problems, Problem{Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+// Validate проверяет манифест без учёта содержимого zip
+func (m *Manifest) Validate() []Problem {
+	v := &validator{}
+	if m.Format != Format {
+		v.addf("format", "must be %q", Format)
+	}
+	if m.Version < 1 || m.Version > Version {
+		v.addf("version", "unsupported version %d, supported 1-%d", m.Version, Version)
+		// поля неизвестной версии проверять бессмысленно
+		return v.problems
+	}
+
+	files := make(map[int64]File, len(m.Files))
+	paths := make(map[string]bool, len(m.Files))
+	for i, f := range m.Files {
+		path := fmt.Sprintf("files[%d]", i)
+		if _, ok := files[f.ID]; ok {
+			v.addf(path+".id", "duplicate file id %d", f.ID)
+		}
+		files[f.ID] = f
+		if !strings.HasPrefix(f.Path, FilesDir) || len(f.Path) == len(FilesDir) || strings.Contains(f.Path, "..") {
+			v.addf(path+".path", "must be a file name inside %s", FilesDir)
+		} else if paths[f.Path] {
+			v.addf(path+".path", "duplicate path %q", f.Path)
+		}
+		paths[f.Path] = true
+		if strings.TrimSpace(f.Name) == "" {
+			v.addf(path+".name", "must not be empty")
+		}
+		if f.Size < 0 {
+			v.addf(path+".size", "must not be negative")
+		}
+		if b, err := hex.DecodeString(f.SHA256); err != nil || len(b) != 32 {
+			v.addf(path+".sha256", "must be a hex encoded sha256 digest")
+		}
+	}
+
+	c := m.Course
+	if strings.TrimSpace(c.Name) == "" {
+		v.addf("course.name", "must not be empty")
+	}
+	moduleIdx := make(map[int]bool)
+	for i, mod := range c.Modules {
+		path := fmt.Sprintf("course.modules[%d]", i)
+		v.checkItem(path, mod.Name, mod.OrderIdx, moduleIdx)
+		lessonIdx := make(map[int]bool)
+		for j, l := range mod.Lessons {
+			path := fmt.Sprintf("%s.lessons[%d]", path, j)
+			v.checkItem(path, l.Name, l.OrderIdx, lessonIdx)
+			blockIdx := make(map[int]bool)
+			for k, b := range l.Blocks {
+				path := fmt.Sprintf("%s.blocks[%d]", path, k)
+				v.checkOrder(path, b.OrderIdx, blockIdx)
+				v.checkBlock(path, b, files)
+			}
+		}
+	}
+	return v.problems
+}
+
+func (v *validator) checkItem(path, name string, orderIdx int, taken map[int]bool) {
+	if strings.TrimSpace(name) == "" {
+		v.addf(path+".name", "must not be empty")
+	}
+	v.checkOrder(path, orderIdx, taken)
+}
+
+func (v *validator) checkOrder(path string, orderIdx int, taken map[int]bool) {
+	switch {
+	case orderIdx < 1:
+		v.addf(path+".order_idx", "must be positive")
+	case taken[orderIdx]:
+		v.addf(path+".order_idx", "duplicate position %d", orderIdx)
+	}
+	taken[orderIdx] = true
+}
+
+func (v *validator) checkBlock(path string, b Block, files map[int64]File) {
+	switch b.Type {
+	case BlockTypeText:
+		if b.Content == nil {
+			v.addf(path+".content", "is required for text blocks")
+		}
+	case BlockTypeVideo:
+		if b.FileID == nil {
+			v.addf(path+".file_id", "is required for video blocks")
+			return
+		}
+		f, ok := files[*b.FileID]
+		if !ok {
+			v.addf(path+".file_id", "file %d is not listed in files", *b.FileID)
+			return
+		}
+		if !strings.HasPrefix(f.ContentType, "video/") {
+			v.addf(path+".file_id", "file %d is not a video", *b.FileID)
+		}
+	case BlockTypeSCORM:
+		if b.FileID == nil {
+			v.addf(path+".file_id", "is required for scorm blocks")
+			return
+		}
+		if _, ok := files[*b.FileID]; !ok {
+			v.addf(path+".file_id", "file %d is not listed in files", *b.FileID)
+		}
+	case BlockTypeQuiz:
+		if b.Quiz == nil {
+			v.addf(path+".quiz", "is required for quiz blocks")
+			return
+		}
+		for _, p := range b.Quiz.Validate() {
+			v.addf(path+".quiz."+p.Path, "%s", p.Message)
+		}
+	case BlockTypeAssignment:
+		if b.Assignment == nil {
+			v.addf(path+".assignment", "is required for assignment blocks")
+			return
+		}
+		for _, p := range b.Assignment.Validate() {
+			v.addf(path+".assignment."+p.Path, "%s", p.Message)
+		}
+	case BlockTypeCodeExercise:
+		if b.CodeExercise == nil {
+			v.addf(path+".code_exercise", "is required for code_exercise blocks")
+			return
+		}
+		for _, p := range b.CodeExercise.Validate() {
+			v.addf(path+".code_exercise."+p.Path, "%s", p.Message)
+		}
+	case BlockTypeImage, BlockTypeAttachment:
+		if b.FileID == nil {
+			v.addf(path+".file_id", "is required for %s blocks", b.Type)
+			return
+		}
+		f, ok := files[*b.FileID]
+		if !ok {
+			v.addf(path+".file_id", "file %d is not listed in files", *b.FileID)
+			return
+		}
+		if b.Type == BlockTypeImage {
+			if strings.TrimSpace(b.AltText) == "" {
+				v.addf(path+".alt_text", "is required for image blocks")
+			}
+			if !media.IsImage(f.ContentType) {
+				v.addf(path+".file_id", "file %d is not an image", *b.FileID)
+			}
+		} else if !media.IsAttachment(f.ContentType) {
+			v.addf(path+".file_id", "file %d of type %q cannot be attached", *b.FileID, f.ContentType)
+		}
+	case BlockTypeEmbed:
+		if b.Embed == nil {
+			v.addf(path+".embed", "is required for embed blocks")
+			return
+		}
+		// в архиве хранится уже переведённый адрес, другой адрес означает правку руками
+		if e, err := media.ResolveEmbed(b.Embed.URL); err != nil || e != *b.Embed {
+			v.addf(path+".embed.url", "is not an embed url of an allowed provider")
+		}
+	default:
+		v.addf(path+".type", "unknown block type %q", b.Type)
+	}
+}
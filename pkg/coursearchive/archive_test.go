@@ -0,0 +1,202 @@
+package coursearchive
+
+import (
+	"bytes"
+	"chalk/pkg/media"
+	"chalk/pkg/quiz"
+	"errors"
+	"io"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func ptr[T any](v T) *T { return &v }
+
+// validManifest возвращает манифест, который проходит проверку. Каждый вызов - новая копия.
+func validManifest(t *testing.T) Manifest {
+	t.Helper()
+	embed, err := media.ResolveEmbed("https://youtu.be/dQw4w9WgXcQ")
+	if err != nil {
+		t.Fatalf("resolve embed: %v", err)
+	}
+	digest := strings.Repeat("ab", 32)
+	return Manifest{
+		Format:  Format,
+		Version: Version,
+		Files: []File{
+			{ID: 1, Path: "files/1", Name: "intro.mp4", ContentType: "video/mp4", Size: 10, SHA256: digest},
+			{ID: 2, Path: "files/2", Name: "cover.png", ContentType: "image/png", Size: 10, SHA256: digest},
+			{ID: 3, Path: "files/3", Name: "notes.pdf", ContentType: "application/pdf", Size: 10, SHA256: digest},
+		},
+		Course: Course{
+			Name: "course",
+			Modules: []Module{{
+				OrderIdx: 1,
+				Name:     "module",
+				Lessons: []Lesson{{
+					OrderIdx: 1,
+					Name:     "lesson",
+					Blocks: []Block{
+						{OrderIdx: 1, Type: BlockTypeText, Content: ptr("text")},
+						{OrderIdx: 2, Type: BlockTypeVideo, FileID: ptr(int64(1))},
+						{OrderIdx: 3, Type: BlockTypeImage, FileID: ptr(int64(2)), AltText: "cover"},
+						{OrderIdx: 4, Type: BlockTypeAttachment, FileID: ptr(int64(3))},
+						{OrderIdx: 5, Type: BlockTypeEmbed, Embed: &embed},
+						{OrderIdx: 6, Type: BlockTypeQuiz, Quiz: &quiz.Quiz{
+							PassingScore: 50,
+							Questions: []quiz.Question{{
+								ID: "q", Type: quiz.SingleChoice, Text: "?", Points: 1,
+								Options: []quiz.Option{{ID: "a", Text: "a", Correct: true}, {ID: "b", Text: "b"}},
+							}},
+						}},
+					},
+				}},
+			}},
+		},
+	}
+}
+
+func problemPaths(problems []Problem) []string {
+	paths := make([]string, 0, len(problems))
+	for _, p := range problems {
+		paths = append(paths, p.Path)
+	}
+	return paths
+}
+
+func TestValidate(t *testing.T) {
+	m := validManifest(t)
+	if problems := m.Validate(); len(problems) != 0 {
+		t.Fatalf("valid manifest has problems: %v", problems)
+	}
+
+	block := func(m *Manifest, i int) *Block { return &m.Course.Modules[0].Lessons[0].Blocks[i] }
+	const blocks = "course.modules[0].lessons[0].blocks"
+	tests := []struct {
+		name   string
+		change func(m *Manifest)
+		paths  []string
+	}{
+		{"format", func(m *Manifest) { m.Format = "zip" }, []string{"format"}},
+		{
+			// остальные поля неизвестной версии не проверяются
+			"future version",
+			func(m *Manifest) { m.Version = Version + 1; m.Course.Name = "" },
+			[]string{"version"},
+		},
+		{"zero version", func(m *Manifest) { m.Version = 0 }, []string{"version"}},
+		{
+			"duplicate file id",
+			func(m *Manifest) { f := m.Files[0]; f.Path = "files/4"; m.Files = append(m.Files, f) },
+			[]string{"files[3].id"},
+		},
+		{"duplicate file path", func(m *Manifest) { m.Files[1].Path = "files/1" }, []string{"files[1].path"}},
+		{"path outside files", func(m *Manifest) { m.Files[0].Path = "manifest.json" }, []string{"files[0].path"}},
+		{"path traversal", func(m *Manifest) { m.Files[0].Path = "files/../manifest.json" }, []string{"files[0].path"}},
+		{"bare files dir", func(m *Manifest) { m.Files[0].Path = FilesDir }, []string{"files[0].path"}},
+		{"blank file name", func(m *Manifest) { m.Files[0].Name = " " }, []string{"files[0].name"}},
+		{"negative size", func(m *Manifest) { m.Files[0].Size = -1 }, []string{"files[0].size"}},
+		{"short digest", func(m *Manifest) { m.Files[0].SHA256 = "abcd" }, []string{"files[0].sha256"}},
+		{"non hex digest", func(m *Manifest) { m.Files[0].SHA256 = strings.Repeat("zz", 32) }, []string{"files[0].sha256"}},
+		{"blank course name", func(m *Manifest) { m.Course.Name = "" }, []string{"course.name"}},
+		{"blank module name", func(m *Manifest) { m.Course.Modules[0].Name = "" }, []string{"course.modules[0].name"}},
+		{"zero module position", func(m *Manifest) { m.Course.Modules[0].OrderIdx = 0 }, []string{"course.modules[0].order_idx"}},
+		{
+			"duplicate lesson position",
+			func(m *Manifest) {
+				mod := &m.Course.Modules[0]
+				mod.Lessons = append(mod.Lessons, Lesson{OrderIdx: 1, Name: "again"})
+			},
+			[]string{"course.modules[0].lessons[1].order_idx"},
+		},
+		{"duplicate block position", func(m *Manifest) { block(m, 1).OrderIdx = 1 }, []string{blocks + "[1].order_idx"}},
+		{"text without content", func(m *Manifest) { block(m, 0).Content = nil }, []string{blocks + "[0].content"}},
+		{"video without file", func(m *Manifest) { block(m, 1).FileID = nil }, []string{blocks + "[1].file_id"}},
+		{"video unknown file", func(m *Manifest) { block(m, 1).FileID = ptr(int64(9)) }, []string{blocks + "[1].file_id"}},
+		{"video not a video", func(m *Manifest) { block(m, 1).FileID = ptr(int64(3)) }, []string{blocks + "[1].file_id"}},
+		{"image without alt text", func(m *Manifest) { block(m, 2).AltText = " " }, []string{blocks + "[2].alt_text"}},
+		{"image not an image", func(m *Manifest) { block(m, 2).FileID = ptr(int64(3)) }, []string{blocks + "[2].file_id"}},
+		{"attachment of video", func(m *Manifest) { block(m, 3).FileID = ptr(int64(1)) }, []string{blocks + "[3].file_id"}},
+		{"embed without url", func(m *Manifest) { block(m, 4).Embed = nil }, []string{blocks + "[4].embed"}},
+		{
+			// адрес, который не получается из ResolveEmbed, правили руками
+			"embed edited",
+			func(m *Manifest) {
+				e := *block(m, 4).Embed
+				e.URL = "https://evil.example/embed"
+				block(m, 4).Embed = &e
+			},
+			[]string{blocks + "[4].embed.url"},
+		},
+		{"quiz without quiz", func(m *Manifest) { block(m, 5).Quiz = nil }, []string{blocks + "[5].quiz"}},
+		{"invalid quiz", func(m *Manifest) { block(m, 5).Quiz.PassingScore = 101 }, []string{blocks + "[5].quiz.passing_score"}},
+		{"assignment without assignment", func(m *Manifest) { block(m, 5).Type = BlockTypeAssignment }, []string{blocks + "[5].assignment"}},
+		{"scorm without file", func(m *Manifest) { block(m, 0).Type = BlockTypeSCORM }, []string{blocks + "[0].file_id"}},
+		{"unknown block type", func(m *Manifest) { block(m, 0).Type = "html" }, []string{blocks + "[0].type"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := validManifest(t)
+			tt.change(&m)
+			if got := problemPaths(m.Validate()); !slices.Equal(got, tt.paths) {
+				t.Fatalf("problems at %q, want %q", got, tt.paths)
+			}
+		})
+	}
+}
+
+func TestWriteAndOpen(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	if err := w.AddFile(File{ID: 7, Name: "notes.txt", ContentType: "text/plain"}, strings.NewReader("hello")); err != nil {
+		t.Fatalf("add file: %v", err)
+	}
+	if err := w.Close(Manifest{Course: Course{Name: "course"}}); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+
+	a, err := Open(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	if len(a.Manifest.Files) != 1 || a.Manifest.Files[0].Path != "files/7" || a.Manifest.Files[0].Size != 5 {
+		t.Fatalf("files %+v", a.Manifest.Files)
+	}
+	rc, err := a.OpenFile(a.Manifest.Files[0])
+	if err != nil {
+		t.Fatalf("open file: %v", err)
+	}
+	defer rc.Close()
+	if data, err := io.ReadAll(rc); err != nil || string(data) != "hello" {
+		t.Fatalf("read file: %q, %v", data, err)
+	}
+
+	// подменённая контрольная сумма обнаруживается при чтении
+	f := a.Manifest.Files[0]
+	f.SHA256 = strings.Repeat("00", 32)
+	rc, err = a.OpenFile(f)
+	if err != nil {
+		t.Fatalf("open file: %v", err)
+	}
+	defer rc.Close()
+	if _, err := io.ReadAll(rc); !errors.Is(err, ErrChecksumMismatch) {
+		t.Fatalf("err = %v, want ErrChecksumMismatch", err)
+	}
+}
+
+func TestOpenInvalid(t *testing.T) {
+	var ve *ValidationError
+	if _, err := Open(strings.NewReader("not a zip"), 9); !errors.As(err, &ve) {
+		t.Fatalf("err = %v, want *ValidationError", err)
+	}
+
+	var buf bytes.Buffer
+	if err := NewWriter(&buf).Close(Manifest{}); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+	_, err := Open(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if !errors.As(err, &ve) || !slices.Equal(problemPaths(ve.Problems), []string{"course.name"}) {
+		t.Fatalf("err = %v, want problem at course.name", err)
+	}
+}
@@ -0,0 +1,115 @@
+package coursearchive
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+)
+
+// maxManifestSize ограничивает манифест, который читается в память целиком
+const maxManifestSize = 16 << 20
+
+var ErrChecksumMismatch = errors.New("file checksum mismatch")
+
+type Archive struct {
+	Manifest Manifest
+	entries  map[string]*zip.File
+}
+
+// Open читает и проверяет архив. Ошибки содержимого возвращаются как *ValidationError.
+func Open(r io.ReaderAt, size int64) (*Archive, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, invalid("archive", "not a zip file: %v", err)
+	}
+
+	a := &Archive{entries: make(map[string]*zip.File, len(zr.File))}
+	for _, f := range zr.File {
+		a.entries[f.Name] = f
+	}
+
+	mf, ok := a.entries[ManifestPath]
+	if !ok {
+		return nil, invalid(ManifestPath, "not found in archive")
+	}
+	if mf.UncompressedSize64 > maxManifestSize {
+		return nil, invalid(ManifestPath, "larger than %d bytes", maxManifestSize)
+	}
+	rc, err := mf.Open()
+	if err != nil {
+		return nil, invalid(ManifestPath, "cannot be read: %v", err)
+	}
+	defer rc.Close()
+	data, err := io.ReadAll(io.LimitReader(rc, maxManifestSize+1))
+	if err != nil {
+		return nil, invalid(ManifestPath, "cannot be read: %v", err)
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&a.Manifest); err != nil {
+		return nil, invalid(ManifestPath, "invalid JSON: %v", err)
+	}
+
+	problems := a.Manifest.Validate()
+	for i, f := range a.Manifest.Files {
+		e, ok := a.entries[f.Path]
+		if !ok {
+			problems = append(problems, Problem{Path: fmt.Sprintf("files[%d].path", i), Message: fmt.Sprintf("%s not found in archive", f.Path)})
+			continue
+		}
+		if int64(e.UncompressedSize64) != f.Size {
+			problems = append(problems, Problem{Path: fmt.Sprintf("files[%d].size", i), Message: fmt.Sprintf("%s has %d bytes in archive", f.Path, e.UncompressedSize64)})
+		}
+	}
+	if len(problems) > 0 {
+		return nil, &ValidationError{Problems: problems}
+	}
+	return a, nil
+}
+
+// OpenFile открывает объект файла. Несовпадение контрольной суммы возвращается
+// как ErrChecksumMismatch при чтении последнего байта: загрузчик с известным
+// размером может так и не дочитать до EOF.
+func (a *Archive) OpenFile(f File) (io.ReadCloser, error) {
+	e, ok := a.entries[f.Path]
+	if !ok {
+		return nil, fmt.Errorf("%s not found in archive", f.Path)
+	}
+	rc, err := e.Open()
+	if err != nil {
+		return nil, fmt.Errorf("open %s: %w", f.Path, err)
+	}
+	return &checksumReader{rc: rc, h: sha256.New(), want: f.SHA256, size: f.Size}, nil
+}
+
+type checksumReader struct {
+	rc   io.ReadCloser
+	h    hash.Hash
+	want string
+	size int64
+	read int64
+}
+
+func (r *checksumReader) Read(p []byte) (int, error) {
+	n, err := r.rc.Read(p)
+	r.h.Write(p[:n])
+	r.read += int64(n)
+	if r.read == r.size && hex.EncodeToString(r.h.Sum(nil)) != r.want {
+		return n, ErrChecksumMismatch
+	}
+	return n, err
+}
+
+func (r *checksumReader) Close() error {
+	return r.rc.Close()
+}
+
+func invalid(path, format string, args ...any) *ValidationError {
+	return &ValidationError{Problems: []Problem{{Path: path, Message: fmt.Sprintf(format, args...)}}}
+}
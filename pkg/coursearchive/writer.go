@@ -0,0 +1,65 @@
+package coursearchive
+
+import (
+	"archive/zip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// Writer пишет архив курса потоком: сначала объекты файлов, в конце манифест
+type Writer struct {
+	zw    *zip.Writer
+	files []File
+}
+
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{zw: zip.NewWriter(w)}
+}
+
+// AddFile записывает объект файла. Путь, размер и контрольная сумма заполняются по содержимому.
+func (w *Writer) AddFile(f File, r io.Reader) error {
+	f.Path = FilesDir + strconv.FormatInt(f.ID, 10)
+	// видео уже сжато, повторное сжатие только тратит процессор
+	fw, err := w.zw.CreateHeader(&zip.FileHeader{Name: f.Path, Method: zip.Store})
+	if err != nil {
+		return fmt.Errorf("create %s: %w", f.Path, err)
+	}
+
+	h := sha256.New()
+	n, err := io.Copy(io.MultiWriter(fw, h), r)
+	if err != nil {
+		return fmt.Errorf("write %s: %w", f.Path, err)
+	}
+	f.Size = n
+	f.SHA256 = hex.EncodeToString(h.Sum(nil))
+	w.files = append(w.files, f)
+	return nil
+}
+
+// Close дописывает манифест со списком добавленных файлов и закрывает архив
+func (w *Writer) Close(m Manifest) error {
+	m.Format = Format
+	m.Version = Version
+	m.Files = w.files
+	if m.Files == nil {
+		m.Files = []File{}
+	}
+
+	mw, err := w.zw.Create(ManifestPath)
+	if err != nil {
+		return fmt.Errorf("create manifest: %w", err)
+	}
+	enc := json.NewEncoder(mw)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(m); err != nil {
+		return fmt.Errorf("write manifest: %w", err)
+	}
+	if err := w.zw.Close(); err != nil {
+		return fmt.Errorf("close archive: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,570 @@
+package imspackage
+
+import (
+	"archive/zip"
+	"chalk/pkg/coursearchive"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"mime"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// Пакет переводит IMS Common Cartridge и SCORM пакеты в манифест архива курса.
+// Верхние элементы организации становятся модулями, вложенные в них - уроками,
+// HTML-ресурсы - текстовыми блоками, видео - видеоблоками. Всё, что перенести
+// не удалось, попадает в отчёт пакета.
+
+const ManifestPath = "imsmanifest.xml"
+
+const (
+	// maxXMLSize ограничивает манифест и XML-описания ресурсов, которые читаются в память целиком
+	maxXMLSize = 16 << 20
+	// maxHTMLSize ограничивает содержимое текстового блока из одного HTML-ресурса
+	maxHTMLSize = 4 << 20
+	// maxListedRefs - сколько ссылок на файлы пакета перечислять в отчёте по одному ресурсу
+	maxListedRefs = 5
+)
+
+var ErrFileNotFound = errors.New("file not found in package")
+
+// DefaultCourseName используется, если в пакете нет названия ни у организации, ни в метаданных
+const DefaultCourseName = "Imported course"
+
+type Kind string
+
+const (
+	KindCommonCartridge Kind = "common_cartridge"
+	KindSCORM           Kind = "scorm"
+)
+
+// Package - разобранный пакет. Manifest ссылается на файлы пакета по путям внутри zip,
+// контрольные суммы у файлов не заполняются.
+type Package struct {
+	Kind     Kind
+	Manifest coursearchive.Manifest
+	// Report - элементы и ресурсы, которые не удалось перенести
+	Report  []coursearchive.Problem
+	entries map[string]*zip.File
+}
+
+// Open читает пакет и строит манифест курса. Ошибки, из-за которых пакет
+// нельзя импортировать совсем, возвращаются как *coursearchive.ValidationError.
+func Open(r io.ReaderAt, size int64) (*Package, error) {
+	p, m, err := open(r, size)
+	if err != nil {
+		return nil, err
+	}
+
+	p.Kind = packageKind(m)
+	c := &converter{
+		pkg:       p,
+		resources: make(map[string]xmlResource, len(m.Resources.Resources)),
+		bases:     make(map[string]string, len(m.Resources.Resources)),
+		fileIDs:   make(map[string]int64),
+	}
+	for _, res := range m.Resources.Resources {
+		c.resources[res.Identifier] = res
+		c.bases[res.Identifier] = joinBase(m.Base, m.Resources.Base, res.Base)
+	}
+	p.Manifest = c.convert(m)
+	p.Report = c.report
+	return p, nil
+}
+
+func open(r io.ReaderAt, size int64) (*Package, *xmlManifest, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, nil, zipError(err)
+	}
+
+	p := &Package{entries: make(map[string]*zip.File, len(zr.File))}
+	for _, f := range zr.File {
+		p.entries[f.Name] = f
+	}
+
+	mf, ok := p.entries[ManifestPath]
+	if !ok {
+		return nil, nil, invalid(ManifestPath, "not found in package root")
+	}
+	m := &xmlManifest{}
+	if err := p.readXML(mf, m); err != nil {
+		return nil, nil, invalid(ManifestPath, "%v", err)
+	}
+	return p, m, nil
+}
+
+// OpenFile открывает файл пакета, на который ссылается манифест
+func (p *Package) OpenFile(f coursearchive.File) (io.ReadCloser, error) {
+	e, ok := p.entries[f.Path]
+	if !ok {
+		return nil, fmt.Errorf("%s not found in package", f.Path)
+	}
+	rc, err := e.Open()
+	if err != nil {
+		return nil, fmt.Errorf("open %s: %w", f.Path, err)
+	}
+	return rc, nil
+}
+
+func (p *Package) readXML(e *zip.File, v any) error {
+	if e.UncompressedSize64 > maxXMLSize {
+		return fmt.Errorf("larger than %d bytes", maxXMLSize)
+	}
+	data, err := p.read(e, maxXMLSize)
+	if err != nil {
+		return err
+	}
+	if err := xml.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("invalid XML: %v", err)
+	}
+	return nil
+}
+
+func (p *Package) read(e *zip.File, limit int64) ([]byte, error) {
+	rc, err := e.Open()
+	if err != nil {
+		return nil, fmt.Errorf("cannot be read: %v", err)
+	}
+	defer rc.Close()
+	data, err := io.ReadAll(io.LimitReader(rc, limit+1))
+	if err != nil {
+		return nil, fmt.Errorf("cannot be read: %v", err)
+	}
+	if int64(len(data)) > limit {
+		return nil, fmt.Errorf("larger than %d bytes", limit)
+	}
+	return data, nil
+}
+
+func packageKind(m *xmlManifest) Kind {
+	schema := strings.ToLower(m.Metadata.Schema)
+	switch {
+	case strings.Contains(schema, "common cartridge"):
+		return KindCommonCartridge
+	case strings.Contains(schema, "scorm"):
+		return KindSCORM
+	}
+	for _, res := range m.Resources.Resources {
+		if res.ScormType != "" || res.ScormType2004 != "" {
+			return KindSCORM
+		}
+	}
+	return KindCommonCartridge
+}
+
+type converter struct {
+	pkg       *Package
+	resources map[string]xmlResource
+	// bases - каталог ресурса с учётом xml:base манифеста, ресурсов и самого ресурса
+	bases   map[string]string
+	fileIDs map[string]int64
+	files   []coursearchive.File
+	report  []coursearchive.Problem
+
+	lastModuleID, lastLessonID, lastBlockID int64
+}
+
+func (c *converter) reportf(path, format string, args ...any) {
+	c.report = append(c.report, coursearchive.Problem{Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+func (c *converter) convert(m *xmlManifest) coursearchive.Manifest {
+	course := coursearchive.Course{Modules: []coursearchive.Module{}}
+
+	orgs := m.Organizations.Organizations
+	if len(orgs) == 0 {
+		c.reportf("organizations", "package has no organization, no modules were created")
+		course.Name = metadataTitle(m)
+		if course.Name == "" {
+			course.Name = DefaultCourseName
+		}
+		return c.manifest(course)
+	}
+	org := orgs[0]
+	for _, o := range orgs {
+		if o.Identifier == m.Organizations.Default {
+			org = o
+			break
+		}
+	}
+	if len(orgs) > 1 {
+		c.reportf("organizations", "package has %d organizations, only %q was imported", len(orgs), org.Identifier)
+	}
+
+	course.Name = strings.TrimSpace(org.Title)
+	items := org.Items
+	// Common Cartridge всегда оборачивает содержимое в один корневой элемент
+	if len(items) == 1 && items[0].IdentifierRef == "" && len(items[0].Items) > 0 &&
+		(c.pkg.Kind == KindCommonCartridge || strings.TrimSpace(items[0].Title) == "") {
+		if course.Name == "" {
+			course.Name = strings.TrimSpace(items[0].Title)
+		}
+		items = items[0].Items
+	}
+	if course.Name == "" {
+		course.Name = metadataTitle(m)
+	}
+	if course.Name == "" {
+		course.Name = DefaultCourseName
+	}
+
+	for i, it := range items {
+		c.lastModuleID++
+		mod := coursearchive.Module{
+			ID:       c.lastModuleID,
+			OrderIdx: i + 1,
+			Name:     itemTitle(it),
+			Lessons:  []coursearchive.Lesson{},
+		}
+		if it.IdentifierRef != "" {
+			// ресурс на уровне модуля становится единственным уроком модуля
+			mod.Lessons = append(mod.Lessons, c.lesson(it, 1))
+		}
+		for _, child := range it.Items {
+			mod.Lessons = append(mod.Lessons, c.lesson(child, len(mod.Lessons)+1))
+		}
+		course.Modules = append(course.Modules, mod)
+	}
+	return c.manifest(course)
+}
+
+func (c *converter) manifest(course coursearchive.Course) coursearchive.Manifest {
+	files := c.files
+	if files == nil {
+		files = []coursearchive.File{}
+	}
+	return coursearchive.Manifest{
+		Format:  coursearchive.Format,
+		Version: coursearchive.Version,
+		Course:  course,
+		Files:   files,
+	}
+}
+
+func (c *converter) lesson(it xmlItem, orderIdx int) coursearchive.Lesson {
+	c.lastLessonID++
+	l := coursearchive.Lesson{
+		ID:       c.lastLessonID,
+		OrderIdx: orderIdx,
+		Name:     itemTitle(it),
+		Blocks:   []coursearchive.Block{},
+	}
+	if it.IdentifierRef != "" {
+		c.addResource(&l, it)
+	}
+	c.addNested(&l, it.Items)
+	return l
+}
+
+// addNested переносит элементы глубже урока в сам урок, заголовок элемента
+// становится отдельным текстовым блоком
+func (c *converter) addNested(l *coursearchive.Lesson, items []xmlItem) {
+	for _, it := range items {
+		c.addText(l, "<h2>"+html.EscapeString(itemTitle(it))+"</h2>")
+		if it.IdentifierRef != "" {
+			c.addResource(l, it)
+		}
+		c.addNested(l, it.Items)
+	}
+}
+
+func (c *converter) addResource(l *coursearchive.Lesson, it xmlItem) {
+	itemPath := "items/" + it.Identifier
+	res, ok := c.resources[it.IdentifierRef]
+	if !ok {
+		c.reportf(itemPath, "resource %q is not described in the manifest", it.IdentifierRef)
+		return
+	}
+	resPath := "resources/" + res.Identifier
+
+	switch {
+	case strings.HasPrefix(res.Type, "imswl_"):
+		c.addWebLink(l, res, resPath)
+	case strings.HasPrefix(res.Type, "imsdt_"):
+		c.addTopic(l, res, resPath)
+	case res.Type == "webcontent" || strings.HasPrefix(res.Type, "associatedcontent/"):
+		if strings.EqualFold(res.ScormType, "sco") || strings.EqualFold(res.ScormType2004, "sco") {
+			c.reportf(resPath, "SCORM SCO %s was not imported, upload the package as a scorm block instead", res.Href)
+			return
+		}
+		c.addWebContent(l, res, resPath)
+	default:
+		c.reportf(resPath, "resource type %q is not supported", res.Type)
+	}
+}
+
+func (c *converter) addWebContent(l *coursearchive.Lesson, res xmlResource, resPath string) {
+	if res.Href == "" {
+		c.reportf(resPath, "resource has no launch file")
+		return
+	}
+	name, ok := c.resolve(res.Identifier, res.Href)
+	if !ok {
+		c.reportf(resPath, "launch file %q is outside the package", res.Href)
+		return
+	}
+	e, ok := c.pkg.entries[name]
+	if !ok {
+		c.reportf(resPath, "launch file %s not found in package", name)
+		return
+	}
+
+	ext := strings.ToLower(path.Ext(name))
+	switch {
+	case ext == ".html" || ext == ".htm":
+		c.addHTML(l, e, resPath)
+	case strings.HasPrefix(ContentType(name), "video/"):
+		c.lastBlockID++
+		fileID := c.addFile(e, ContentType(name))
+		l.Blocks = append(l.Blocks, coursearchive.Block{
+			ID:       c.lastBlockID,
+			OrderIdx: len(l.Blocks) + 1,
+			Type:     coursearchive.BlockTypeVideo,
+			FileID:   &fileID,
+		})
+	default:
+		c.reportf(resPath, "%s: %s files cannot be placed in a lesson", name, ContentType(name))
+	}
+}
+
+func (c *converter) addHTML(l *coursearchive.Lesson, e *zip.File, resPath string) {
+	data, err := c.pkg.read(e, maxHTMLSize)
+	if err != nil {
+		c.reportf(resPath, "%s %v", e.Name, err)
+		return
+	}
+	body := strings.TrimSpace(htmlBody(string(data)))
+	if body == "" {
+		return
+	}
+	c.addText(l, body)
+
+	if refs := c.localRefs(path.Dir(e.Name), body); len(refs) > 0 {
+		listed := refs
+		if len(listed) > maxListedRefs {
+			listed = listed[:maxListedRefs]
+		}
+		msg := strings.Join(listed, ", ")
+		if len(refs) > len(listed) {
+			msg += fmt.Sprintf(" and %d more", len(refs)-len(listed))
+		}
+		c.reportf(resPath, "%s links to package files that were not imported: %s", e.Name, msg)
+	}
+}
+
+func (c *converter) addWebLink(l *coursearchive.Lesson, res xmlResource, resPath string) {
+	var link xmlWebLink
+	if !c.readDescriptor(res, resPath, &link) {
+		return
+	}
+	u, err := url.Parse(strings.TrimSpace(link.URL.Href))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		c.reportf(resPath, "web link %q is not an http(s) URL", link.URL.Href)
+		return
+	}
+	title := strings.TrimSpace(link.Title)
+	if title == "" {
+		title = u.String()
+	}
+	c.addText(l, fmt.Sprintf(`<p><a href="%s">%s</a></p>`, html.EscapeString(u.String()), html.EscapeString(title)))
+}
+
+func (c *converter) addTopic(l *coursearchive.Lesson, res xmlResource, resPath string) {
+	var topic xmlTopic
+	if !c.readDescriptor(res, resPath, &topic) {
+		return
+	}
+	text := strings.TrimSpace(topic.Text.Value)
+	if topic.Text.TextType != "text/html" {
+		text = "<p>" + html.EscapeString(text) + "</p>"
+	}
+	c.addText(l, "<h2>"+html.EscapeString(strings.TrimSpace(topic.Title))+"</h2>"+text)
+	c.reportf(resPath, "discussion topic was imported as text, discussions are not supported")
+}
+
+// readDescriptor читает XML-описание ресурса Common Cartridge из первого файла ресурса
+func (c *converter) readDescriptor(res xmlResource, resPath string, v any) bool {
+	href := res.Href
+	if href == "" && len(res.Files) > 0 {
+		href = res.Files[0].Href
+	}
+	name, ok := c.resolve(res.Identifier, href)
+	if !ok || href == "" {
+		c.reportf(resPath, "resource has no description file")
+		return false
+	}
+	e, ok := c.pkg.entries[name]
+	if !ok {
+		c.reportf(resPath, "description file %s not found in package", name)
+		return false
+	}
+	if err := c.pkg.readXML(e, v); err != nil {
+		c.reportf(resPath, "%s %v", name, err)
+		return false
+	}
+	return true
+}
+
+func (c *converter) addText(l *coursearchive.Lesson, content string) {
+	c.lastBlockID++
+	l.Blocks = append(l.Blocks, coursearchive.Block{
+		ID:       c.lastBlockID,
+		OrderIdx: len(l.Blocks) + 1,
+		Type:     coursearchive.BlockTypeText,
+		Content:  &content,
+	})
+}
+
+func (c *converter) addFile(e *zip.File, contentType string) int64 {
+	if id, ok := c.fileIDs[e.Name]; ok {
+		return id
+	}
+	id := int64(len(c.files) + 1)
+	c.fileIDs[e.Name] = id
+	c.files = append(c.files, coursearchive.File{
+		ID:          id,
+		Path:        e.Name,
+		Name:        path.Base(e.Name),
+		ContentType: contentType,
+		Size:        int64(e.UncompressedSize64),
+	})
+	return id
+}
+
+// resolve переводит ссылку ресурса в имя файла внутри zip
+func (c *converter) resolve(resID, href string) (string, bool) {
+	if i := strings.IndexAny(href, "?#"); i >= 0 {
+		href = href[:i]
+	}
+	if unescaped, err := url.PathUnescape(href); err == nil {
+		href = unescaped
+	}
+	name := path.Clean(path.Join(c.bases[resID], href))
+	if name == "." || strings.HasPrefix(name, "../") || name == ".." || path.IsAbs(name) {
+		return "", false
+	}
+	return name, true
+}
+
+var refAttrRe = regexp.MustCompile(`(?i)\b(?:src|href)\s*=\s*["']([^"']+)["']`)
+
+// localRefs возвращает ссылки HTML на файлы пакета: после импорта они никуда не ведут
+func (c *converter) localRefs(dir, body string) []string {
+	var refs []string
+	seen := make(map[string]bool)
+	for _, m := range refAttrRe.FindAllStringSubmatch(body, -1) {
+		ref := html.UnescapeString(m[1])
+		if strings.Contains(ref, "$IMS-CC-FILEBASE$") {
+			ref = strings.ReplaceAll(ref, "$IMS-CC-FILEBASE$", "")
+		} else if u, err := url.Parse(ref); err != nil || u.Scheme != "" || u.Host != "" || strings.HasPrefix(ref, "#") {
+			continue
+		}
+		if i := strings.IndexAny(ref, "?#"); i >= 0 {
+			ref = ref[:i]
+		}
+		if unescaped, err := url.PathUnescape(ref); err == nil {
+			ref = unescaped
+		}
+		name := path.Clean(path.Join(dir, ref))
+		if strings.HasPrefix(ref, "/") {
+			name = strings.TrimPrefix(path.Clean(ref), "/")
+		}
+		if _, ok := c.pkg.entries[name]; ok && !seen[name] {
+			seen[name] = true
+			refs = append(refs, name)
+		}
+	}
+	return refs
+}
+
+// htmlBody возвращает содержимое body, для фрагмента без body - весь текст
+func htmlBody(doc string) string {
+	lower := strings.ToLower(doc)
+	start := strings.Index(lower, "<body")
+	if start < 0 {
+		return doc
+	}
+	open := strings.IndexByte(lower[start:], '>')
+	if open < 0 {
+		return ""
+	}
+	start += open + 1
+	end := strings.LastIndex(lower, "</body")
+	if end < start {
+		end = len(doc)
+	}
+	return doc[start:end]
+}
+
+func metadataTitle(m *xmlManifest) string {
+	for _, titles := range [][]string{m.Metadata.Titles, m.Metadata.LangTitles} {
+		for _, t := range titles {
+			if t = strings.TrimSpace(t); t != "" {
+				return t
+			}
+		}
+	}
+	return ""
+}
+
+func itemTitle(it xmlItem) string {
+	if t := strings.TrimSpace(it.Title); t != "" {
+		return t
+	}
+	return "Untitled"
+}
+
+func joinBase(bases ...string) string {
+	var b string
+	for _, base := range bases {
+		b = path.Join(b, base)
+	}
+	return b
+}
+
+// extraTypes - типы, которые встроенная таблица mime не знает, а в пакетах они обычны
+var extraTypes = map[string]string{
+	".mp4":  "video/mp4",
+	".m4v":  "video/x-m4v",
+	".mov":  "video/quicktime",
+	".webm": "video/webm",
+	".ogv":  "video/ogg",
+	".mp3":  "audio/mpeg",
+	".m4a":  "audio/mp4",
+	".ogg":  "audio/ogg",
+	".wav":  "audio/wav",
+	".woff": "font/woff",
+	".ttf":  "font/ttf",
+	".swf":  "application/x-shockwave-flash",
+}
+
+// ContentType определяет тип файла пакета по расширению имени
+func ContentType(name string) string {
+	ext := strings.ToLower(path.Ext(name))
+	if t, ok := extraTypes[ext]; ok {
+		return t
+	}
+	if t := mime.TypeByExtension(ext); t != "" {
+		t, _, _ = strings.Cut(t, ";")
+		return t
+	}
+	return "application/octet-stream"
+}
+
+// zipError отделяет повреждённый архив от ошибки чтения из хранилища
+func zipError(err error) error {
+	if errors.Is(err, zip.ErrFormat) || errors.Is(err, zip.ErrAlgorithm) || errors.Is(err, zip.ErrChecksum) {
+		return invalid("package", "not a zip file: %v", err)
+	}
+	return fmt.Errorf("read zip: %w", err)
+}
+
+func invalid(path, format string, args ...any) *coursearchive.ValidationError {
+	return &coursearchive.ValidationError{Problems: []coursearchive.Problem{{Path: path, Message: fmt.Sprintf(format, args...)}}}
+}
@@ -0,0 +1,79 @@
+package imspackage
+
+import "encoding/xml"
+
+// Разметка imsmanifest.xml. Теги без пространства имён совпадают с элементом
+// в любом пространстве, поэтому одни и те же структуры читают Common Cartridge
+// 1.0-1.3, SCORM 1.2 и SCORM 2004.
+
+type xmlManifest struct {
+	// Attrs - в том числе объявления пространств имён, по ним определяется версия SCORM
+	Attrs         []xml.Attr       `xml:",any,attr"`
+	Base          string           `xml:"http://www.w3.org/XML/1998/namespace base,attr"`
+	Metadata      xmlMetadata      `xml:"metadata"`
+	Organizations xmlOrganizations `xml:"organizations"`
+	Resources     xmlResources     `xml:"resources"`
+}
+
+type xmlMetadata struct {
+	Schema        string   `xml:"schema"`
+	SchemaVersion string   `xml:"schemaversion"`
+	Titles        []string `xml:"lom>general>title>string"`
+	LangTitles    []string `xml:"lom>general>title>langstring"`
+}
+
+type xmlOrganizations struct {
+	Default       string            `xml:"default,attr"`
+	Organizations []xmlOrganization `xml:"organization"`
+}
+
+type xmlOrganization struct {
+	Identifier string    `xml:"identifier,attr"`
+	Title      string    `xml:"title"`
+	Items      []xmlItem `xml:"item"`
+}
+
+type xmlItem struct {
+	Identifier    string    `xml:"identifier,attr"`
+	IdentifierRef string    `xml:"identifierref,attr"`
+	Parameters    string    `xml:"parameters,attr"`
+	Title         string    `xml:"title"`
+	Items         []xmlItem `xml:"item"`
+}
+
+type xmlResources struct {
+	Base      string        `xml:"http://www.w3.org/XML/1998/namespace base,attr"`
+	Resources []xmlResource `xml:"resource"`
+}
+
+type xmlResource struct {
+	Identifier string `xml:"identifier,attr"`
+	Type       string `xml:"type,attr"`
+	Href       string `xml:"href,attr"`
+	Base       string `xml:"http://www.w3.org/XML/1998/namespace base,attr"`
+	// SCORM 1.2 пишет scormtype, SCORM 2004 - scormType
+	ScormType     string    `xml:"scormtype,attr"`
+	ScormType2004 string    `xml:"scormType,attr"`
+	Files         []xmlFile `xml:"file"`
+}
+
+type xmlFile struct {
+	Href string `xml:"href,attr"`
+}
+
+// Ресурсы Common Cartridge, которые описаны отдельным XML-файлом
+
+type xmlWebLink struct {
+	Title string `xml:"title"`
+	URL   struct {
+		Href string `xml:"href,attr"`
+	} `xml:"url"`
+}
+
+type xmlTopic struct {
+	Title string `xml:"title"`
+	Text  struct {
+		TextType string `xml:"texttype,attr"`
+		Value    string `xml:",chardata"`
+	} `xml:"text"`
+}
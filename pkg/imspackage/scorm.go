@@ -0,0 +1,147 @@
+package imspackage
+
+import (
+	"archive/zip"
+	"io"
+	"strings"
+)
+
+type SCORMVersion string
+
+const (
+	SCORM12   SCORMVersion = "1.2"
+	SCORM2004 SCORMVersion = "2004"
+)
+
+// SCORM - точка запуска пакета SCORM
+type SCORM struct {
+	Version SCORMVersion
+	// LaunchPath - путь запускаемого файла внутри zip вместе с параметрами элемента
+	LaunchPath string
+	Title      string
+}
+
+// ParseSCORM находит в пакете первый SCO организации по умолчанию. Пакет без SCO
+// возвращается как *coursearchive.ValidationError.
+func ParseSCORM(r io.ReaderAt, size int64) (SCORM, error) {
+	p, m, err := open(r, size)
+	if err != nil {
+		return SCORM{}, err
+	}
+	version, ok := scormVersion(m)
+	if !ok {
+		return SCORM{}, invalid(ManifestPath, "not a SCORM 1.2 or 2004 manifest")
+	}
+
+	resources := make(map[string]xmlResource, len(m.Resources.Resources))
+	for _, res := range m.Resources.Resources {
+		resources[res.Identifier] = res
+	}
+	orgs := m.Organizations.Organizations
+	if len(orgs) == 0 {
+		return SCORM{}, invalid("organizations", "package has no organization")
+	}
+	org := orgs[0]
+	for _, o := range orgs {
+		if o.Identifier == m.Organizations.Default {
+			org = o
+			break
+		}
+	}
+
+	it, res, ok := firstSCO(org.Items, resources)
+	if !ok {
+		return SCORM{}, invalid("organizations", "organization %q has no launchable SCO", org.Identifier)
+	}
+	c := &converter{pkg: p, bases: map[string]string{res.Identifier: joinBase(m.Base, m.Resources.Base, res.Base)}}
+	launch, ok := c.resolve(res.Identifier, res.Href)
+	if !ok {
+		return SCORM{}, invalid("resources/"+res.Identifier, "launch file %q is outside the package", res.Href)
+	}
+	if _, ok := p.entries[launch]; !ok {
+		return SCORM{}, invalid("resources/"+res.Identifier, "launch file %s not found in package", launch)
+	}
+	launch += launchQuery(res.Href, it.Parameters)
+
+	title := strings.TrimSpace(org.Title)
+	if title == "" {
+		title = strings.TrimSpace(it.Title)
+	}
+	return SCORM{Version: version, LaunchPath: launch, Title: title}, nil
+}
+
+// OpenSCORMFile открывает файл пакета для отдачи браузеру
+func OpenSCORMFile(r io.ReaderAt, size int64, name string) (io.ReadCloser, int64, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, 0, zipError(err)
+	}
+	for _, f := range zr.File {
+		if f.Name == name && !f.FileInfo().IsDir() {
+			rc, err := f.Open()
+			if err != nil {
+				return nil, 0, err
+			}
+			return rc, int64(f.UncompressedSize64), nil
+		}
+	}
+	return nil, 0, ErrFileNotFound
+}
+
+func scormVersion(m *xmlManifest) (SCORMVersion, bool) {
+	v := strings.ToLower(m.Metadata.SchemaVersion)
+	switch {
+	case v == "1.2":
+		return SCORM12, true
+	case strings.Contains(v, "2004") || strings.Contains(v, "cam 1.3"):
+		return SCORM2004, true
+	}
+	for _, a := range m.Attrs {
+		switch {
+		case strings.Contains(a.Value, "adlcp_rootv1p2"):
+			return SCORM12, true
+		case strings.Contains(a.Value, "adlcp_v1p3"):
+			return SCORM2004, true
+		}
+	}
+	for _, res := range m.Resources.Resources {
+		switch {
+		case res.ScormType != "":
+			return SCORM12, true
+		case res.ScormType2004 != "":
+			return SCORM2004, true
+		}
+	}
+	return "", false
+}
+
+func firstSCO(items []xmlItem, resources map[string]xmlResource) (xmlItem, xmlResource, bool) {
+	for _, it := range items {
+		if res, ok := resources[it.IdentifierRef]; ok && res.Href != "" &&
+			(strings.EqualFold(res.ScormType, "sco") || strings.EqualFold(res.ScormType2004, "sco")) {
+			return it, res, true
+		}
+		if it, res, ok := firstSCO(it.Items, resources); ok {
+			return it, res, true
+		}
+	}
+	return xmlItem{}, xmlResource{}, false
+}
+
+// launchQuery собирает строку запроса из ссылки ресурса и параметров элемента
+func launchQuery(href, parameters string) string {
+	var query string
+	if _, q, ok := strings.Cut(href, "?"); ok {
+		query, _, _ = strings.Cut(q, "#")
+	}
+	parameters = strings.TrimLeft(parameters, "?&")
+	switch {
+	case query == "" && parameters == "":
+		return ""
+	case query == "":
+		return "?" + parameters
+	case parameters == "":
+		return "?" + query
+	}
+	return "?" + query + "&" + parameters
+}
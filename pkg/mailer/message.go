@@ -0,0 +1,45 @@
+package mailer
+
+import (
+	"bytes"
+	"mime"
+	"net/mail"
+	"strings"
+)
+
+// Message - письмо в текстовом формате с заголовками в UTF-8
+type Message struct {
+	FromName string
+	FromAddr string
+	ReplyTo  string
+	To       string
+	Subject  string
+	Body     string
+}
+
+func (m Message) Bytes() []byte {
+	var buf bytes.Buffer
+
+	from := mail.Address{Name: m.FromName, Address: m.FromAddr}
+	writeHeader(&buf, "From", from.String())
+	writeHeader(&buf, "To", m.To)
+	if m.ReplyTo != "" {
+		writeHeader(&buf, "Reply-To", m.ReplyTo)
+	}
+	writeHeader(&buf, "Subject", mime.QEncoding.Encode("utf-8", m.Subject))
+	writeHeader(&buf, "MIME-Version", "1.0")
+	writeHeader(&buf, "Content-Type", "text/plain; charset=utf-8")
+	writeHeader(&buf, "Content-Transfer-Encoding", "8bit")
+	buf.WriteString("\r\n")
+	buf.WriteString(strings.ReplaceAll(m.Body, "\n", "\r\n"))
+	return buf.Bytes()
+}
+
+var headerSanitizer = strings.NewReplacer("\r", "", "\n", " ")
+
+func writeHeader(buf *bytes.Buffer, name, value string) {
+	buf.WriteString(name)
+	buf.WriteString(": ")
+	buf.WriteString(headerSanitizer.Replace(value))
+	buf.WriteString("\r\n")
+}
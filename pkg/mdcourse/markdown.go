@@ -0,0 +1,133 @@
+package mdcourse
+
+import (
+	"chalk/pkg/coursearchive"
+	"chalk/pkg/imspackage"
+	"errors"
+	"io/fs"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+)
+
+var errUnclosedFrontMatter = errors.New("front-matter is not closed with ---")
+
+// splitFrontMatter отделяет YAML между строками --- в начале урока от текста
+func splitFrontMatter(doc string) (front, body string, err error) {
+	doc = strings.ReplaceAll(doc, "\r\n", "\n")
+	if !strings.HasPrefix(doc, "---\n") {
+		return "", doc, nil
+	}
+	rest := doc[len("---\n"):]
+	for offset := 0; offset <= len(rest); {
+		end := strings.IndexByte(rest[offset:], '\n')
+		line := rest[offset:]
+		if end >= 0 {
+			line = rest[offset : offset+end]
+		}
+		if line == "---" || line == "..." {
+			body = ""
+			if end >= 0 {
+				body = rest[offset+end+1:]
+			}
+			return rest[:offset], body, nil
+		}
+		if end < 0 {
+			break
+		}
+		offset += end + 1
+	}
+	return "", "", errUnclosedFrontMatter
+}
+
+var (
+	// standaloneLink - строка из одной ссылки или картинки: ![alt](target "title")
+	standaloneLink = regexp.MustCompile(`^!?\[[^\]]*\]\(\s*<?([^)\s>]+)>?(?:\s+"[^"]*")?\s*\)$`)
+	// inlineLink - цель любой ссылки или картинки в строке
+	inlineLink = regexp.MustCompile(`\]\(\s*<?([^)\s>]+)`)
+	fence      = regexp.MustCompile("^ {0,3}(```|~~~)")
+)
+
+// blocks делит текст урока на текстовые блоки по строкам-ссылкам на видео каталога
+func (l *loader) blocks(lessonPath, body string) ([]Block, error) {
+	var (
+		blocks  []Block
+		text    []string
+		inFence string
+	)
+	flush := func() {
+		if content := strings.TrimSpace(strings.Join(text, "\n")); content != "" {
+			blocks = append(blocks, Block{Type: coursearchive.BlockTypeText, Content: content})
+		}
+		text = text[:0]
+	}
+
+	for _, line := range strings.Split(body, "\n") {
+		if m := fence.FindStringSubmatch(line); m != nil {
+			switch inFence {
+			case "":
+				inFence = m[1]
+			case m[1]:
+				inFence = ""
+			}
+			text = append(text, line)
+			continue
+		}
+		if inFence != "" {
+			text = append(text, line)
+			continue
+		}
+
+		if m := standaloneLink.FindStringSubmatch(strings.TrimSpace(line)); m != nil {
+			if target, ok := l.localTarget(lessonPath, m[1]); ok {
+				if !strings.HasPrefix(imspackage.ContentType(target), "video/") {
+					l.warnf(lessonPath, "%s: local files other than videos are not uploaded, the link will not resolve", target)
+					text = append(text, line)
+					continue
+				}
+				if err := l.addMedia(target); err != nil {
+					return nil, err
+				}
+				flush()
+				blocks = append(blocks, Block{Type: coursearchive.BlockTypeVideo, Media: target})
+				continue
+			}
+			text = append(text, line)
+			continue
+		}
+		for _, m := range inlineLink.FindAllStringSubmatch(line, -1) {
+			target, ok := l.localTarget(lessonPath, m[1])
+			switch {
+			case !ok:
+			case strings.HasPrefix(imspackage.ContentType(target), "video/"):
+				l.warnf(lessonPath, "%s: put the video link on its own line to make it a video block", target)
+			default:
+				l.warnf(lessonPath, "%s: local files other than videos are not uploaded, the link will not resolve", target)
+			}
+		}
+		text = append(text, line)
+	}
+	flush()
+	return blocks, nil
+}
+
+// localTarget переводит относительную ссылку урока в путь существующего файла каталога.
+// Для отсутствующего файла пишет предупреждение.
+func (l *loader) localTarget(lessonPath, target string) (string, bool) {
+	u, err := url.Parse(target)
+	if err != nil || u.Scheme != "" || u.Host != "" || u.Path == "" || strings.HasPrefix(u.Path, "/") {
+		return "", false
+	}
+	name := path.Join(path.Dir(lessonPath), u.Path)
+	if !fs.ValidPath(name) {
+		l.warnf(lessonPath, "%s: link points outside the course directory", target)
+		return "", false
+	}
+	info, err := fs.Stat(l.fsys, name)
+	if err != nil || info.IsDir() {
+		l.warnf(lessonPath, "%s: file not found", target)
+		return "", false
+	}
+	return name, true
+}
@@ -0,0 +1,372 @@
+package mdcourse
+
+import (
+	"archive/zip"
+	"bytes"
+	"chalk/pkg/coursearchive"
+	"chalk/pkg/imspackage"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Пакет читает курс из каталога Markdown:
+//
+//	course.yaml          name - название курса
+//	01-intro/            модуль; module.yaml с name и order необязателен
+//	  module.yaml
+//	  01-welcome.md      урок; front-matter задаёт title и order
+//	  media/intro.mp4    видео, на которое урок ссылается отдельной строкой
+//
+// Текст урока сохраняется в текстовых блоках как Markdown. Строка, состоящая только
+// из ссылки или картинки на видеофайл каталога, делит урок на блоки и становится
+// видеоблоком. Модули и уроки без order идут после пронумерованных, по имени пути.
+
+const (
+	CourseFile = "course.yaml"
+	ModuleFile = "module.yaml"
+)
+
+const (
+	// maxYAMLSize ограничивает course.yaml и module.yaml
+	maxYAMLSize = 64 << 10
+	// maxLessonSize ограничивает файл урока, он читается в память целиком
+	maxLessonSize = 4 << 20
+)
+
+type Course struct {
+	Name    string
+	Modules []Module
+	// Media - видеофайлы, на которые ссылаются уроки, без повторов
+	Media []Media
+	// Warnings - ссылки на файлы каталога, которые не стали блоками
+	Warnings []coursearchive.Problem
+	fsys     fs.FS
+}
+
+// OpenFile открывает файл медиа по пути из Media
+func (c *Course) OpenFile(f coursearchive.File) (io.ReadCloser, error) {
+	file, err := c.fsys.Open(f.Path)
+	if err != nil {
+		return nil, fsError(err)
+	}
+	return file, nil
+}
+
+type Module struct {
+	// Path - каталог модуля, по нему синхронизация находит модуль курса
+	Path    string
+	Name    string
+	Lessons []Lesson
+}
+
+type Lesson struct {
+	Path   string
+	Name   string
+	Blocks []Block
+}
+
+// Block - текстовый блок с Content или видеоблок с путём файла в Media
+type Block struct {
+	Type    string
+	Content string
+	Media   string
+}
+
+type Media struct {
+	Path        string
+	Name        string
+	ContentType string
+	Size        int64
+	SHA256      string
+}
+
+type courseMeta struct {
+	Name string `yaml:"name"`
+}
+
+type itemMeta struct {
+	Name  string `yaml:"name"`
+	Title string `yaml:"title"`
+	Order *int   `yaml:"order"`
+}
+
+// Load читает курс из каталога. Каталог может лежать в единственной папке верхнего
+// уровня, как бывает в zip. Ошибки разметки возвращаются как *coursearchive.ValidationError
+// со всеми найденными проблемами.
+func Load(fsys fs.FS) (*Course, error) {
+	root, err := findRoot(fsys)
+	if err != nil {
+		return nil, err
+	}
+	l := &loader{fsys: root, course: Course{fsys: root}, media: make(map[string]bool)}
+	course, err := l.load()
+	if err != nil {
+		return nil, err
+	}
+	if len(l.problems) > 0 {
+		return nil, &coursearchive.ValidationError{Problems: l.problems}
+	}
+	return course, nil
+}
+
+func findRoot(fsys fs.FS) (fs.FS, error) {
+	if _, err := fs.Stat(fsys, CourseFile); err == nil {
+		return fsys, nil
+	}
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, fsError(err)
+	}
+	var dirs []string
+	for _, e := range entries {
+		if e.IsDir() && !ignored(e.Name()) {
+			dirs = append(dirs, e.Name())
+		}
+	}
+	if len(dirs) == 1 {
+		if _, err := fs.Stat(fsys, path.Join(dirs[0], CourseFile)); err == nil {
+			return fs.Sub(fsys, dirs[0])
+		}
+	}
+	return nil, invalid(CourseFile, "not found")
+}
+
+type loader struct {
+	fsys     fs.FS
+	course   Course
+	media    map[string]bool
+	problems []coursearchive.Problem
+}
+
+func (l *loader) addf(path, format string, args ...any) {
+	l.problems = append(l.problems, coursearchive.Problem{Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+func (l *loader) warnf(path, format string, args ...any) {
+	l.course.Warnings = append(l.course.Warnings, coursearchive.Problem{Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+func (l *loader) load() (*Course, error) {
+	var meta courseMeta
+	data, err := l.readFile(CourseFile, maxYAMLSize)
+	if err != nil {
+		return nil, err
+	}
+	if data != nil {
+		if err := yaml.Unmarshal(data, &meta); err != nil {
+			l.addf(CourseFile, "invalid yaml: %v", err)
+		} else if l.course.Name = strings.TrimSpace(meta.Name); l.course.Name == "" {
+			l.addf(CourseFile+".name", "is required")
+		}
+	}
+
+	entries, err := fs.ReadDir(l.fsys, ".")
+	if err != nil {
+		return nil, fsError(err)
+	}
+	var modules []ordered[Module]
+	for _, e := range entries {
+		if !e.IsDir() || ignored(e.Name()) {
+			continue
+		}
+		m, order, err := l.module(e.Name())
+		if err != nil {
+			return nil, err
+		}
+		modules = append(modules, ordered[Module]{item: m, order: order, path: m.Path})
+	}
+	l.course.Modules = sortItems(modules)
+	return &l.course, nil
+}
+
+func (l *loader) module(dir string) (Module, *int, error) {
+	m := Module{Path: dir, Name: nameFromPath(dir)}
+	var meta itemMeta
+	metaPath := path.Join(dir, ModuleFile)
+	data, err := l.readFile(metaPath, maxYAMLSize)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return Module{}, nil, err
+	}
+	if data != nil {
+		if err := yaml.Unmarshal(data, &meta); err != nil {
+			l.addf(metaPath, "invalid yaml: %v", err)
+		}
+		if name := strings.TrimSpace(meta.Name); name != "" {
+			m.Name = name
+		}
+	}
+
+	entries, err := fs.ReadDir(l.fsys, dir)
+	if err != nil {
+		return Module{}, nil, fsError(err)
+	}
+	var lessons []ordered[Lesson]
+	for _, e := range entries {
+		if e.IsDir() || ignored(e.Name()) || !strings.EqualFold(path.Ext(e.Name()), ".md") {
+			continue
+		}
+		lesson, order, err := l.lesson(path.Join(dir, e.Name()))
+		if err != nil {
+			return Module{}, nil, err
+		}
+		lessons = append(lessons, ordered[Lesson]{item: lesson, order: order, path: lesson.Path})
+	}
+	m.Lessons = sortItems(lessons)
+	return m, meta.Order, nil
+}
+
+func (l *loader) lesson(name string) (Lesson, *int, error) {
+	lesson := Lesson{Path: name, Name: nameFromPath(name)}
+	data, err := l.readFile(name, maxLessonSize)
+	if err != nil || data == nil {
+		return lesson, nil, err
+	}
+	if !utf8.Valid(data) {
+		l.addf(name, "is not valid UTF-8")
+		return lesson, nil, nil
+	}
+
+	front, body, err := splitFrontMatter(string(data))
+	if err != nil {
+		l.addf(name, "%v", err)
+		return lesson, nil, nil
+	}
+	var meta itemMeta
+	if front != "" {
+		if err := yaml.Unmarshal([]byte(front), &meta); err != nil {
+			l.addf(name, "invalid front-matter: %v", err)
+		}
+		if title := strings.TrimSpace(meta.Title); title != "" {
+			lesson.Name = title
+		}
+	}
+
+	blocks, err := l.blocks(name, body)
+	if err != nil {
+		return lesson, nil, err
+	}
+	lesson.Blocks = blocks
+	return lesson, meta.Order, nil
+}
+
+// readFile читает файл каталога целиком. Отсутствующий course.yaml и слишком большой
+// файл записываются в проблемы, тогда возвращается nil без ошибки.
+func (l *loader) readFile(name string, limit int64) ([]byte, error) {
+	f, err := l.fsys.Open(name)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			if name == CourseFile {
+				l.addf(name, "not found")
+				return nil, nil
+			}
+			return nil, err
+		}
+		return nil, fsError(err)
+	}
+	defer f.Close()
+
+	data, err := io.ReadAll(io.LimitReader(f, limit+1))
+	if err != nil {
+		return nil, fsError(err)
+	}
+	if int64(len(data)) > limit {
+		l.addf(name, "is larger than %d bytes", limit)
+		return nil, nil
+	}
+	return bytes.TrimPrefix(data, []byte("\ufeff")), nil
+}
+
+// addMedia запоминает видеофайл и считает его контрольную сумму
+func (l *loader) addMedia(name string) error {
+	if l.media[name] {
+		return nil
+	}
+	f, err := l.fsys.Open(name)
+	if err != nil {
+		return fsError(err)
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	size, err := io.Copy(h, f)
+	if err != nil {
+		return fsError(err)
+	}
+	l.media[name] = true
+	l.course.Media = append(l.course.Media, Media{
+		Path:        name,
+		Name:        path.Base(name),
+		ContentType: imspackage.ContentType(name),
+		Size:        size,
+		SHA256:      hex.EncodeToString(h.Sum(nil)),
+	})
+	return nil
+}
+
+type ordered[T any] struct {
+	item  T
+	order *int
+	path  string
+}
+
+func sortItems[T any](items []ordered[T]) []T {
+	sort.SliceStable(items, func(i, j int) bool {
+		a, b := items[i], items[j]
+		switch {
+		case a.order != nil && b.order != nil && *a.order != *b.order:
+			return *a.order < *b.order
+		case (a.order == nil) != (b.order == nil):
+			return a.order != nil
+		}
+		return a.path < b.path
+	})
+	res := make([]T, 0, len(items))
+	for _, it := range items {
+		res = append(res, it.item)
+	}
+	return res
+}
+
+var numberPrefix = regexp.MustCompile(`^\d+[-_. ]*`)
+
+// nameFromPath делает название из имени файла: "01-getting_started.md" - "Getting started"
+func nameFromPath(p string) string {
+	base := path.Base(p)
+	base = strings.TrimSuffix(base, path.Ext(base))
+	name := numberPrefix.ReplaceAllString(base, "")
+	name = strings.TrimSpace(strings.NewReplacer("-", " ", "_", " ").Replace(name))
+	if name == "" {
+		return base
+	}
+	r, size := utf8.DecodeRuneInString(name)
+	return string(unicode.ToUpper(r)) + name[size:]
+}
+
+// ignored - служебные файлы и папки вроде .git и _drafts
+func ignored(name string) bool {
+	return strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_")
+}
+
+// fsError отделяет повреждённый zip от ошибок чтения
+func fsError(err error) error {
+	if errors.Is(err, zip.ErrFormat) || errors.Is(err, zip.ErrChecksum) || errors.Is(err, zip.ErrAlgorithm) {
+		return invalid("", "%v", err)
+	}
+	return err
+}
+
+func invalid(path, format string, args ...any) *coursearchive.ValidationError {
+	return &coursearchive.ValidationError{Problems: []coursearchive.Problem{{Path: path, Message: fmt.Sprintf(format, args...)}}}
+}
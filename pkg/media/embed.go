@@ -0,0 +1,171 @@
+package media
+
+import (
+	"errors"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Встраивать можно только страницы известных сервисов. Ссылка, скопированная из адресной
+// строки, переводится в адрес плеера сервиса, который и подставляется в iframe. Переведённый
+// адрес переводится сам в себя, поэтому его можно проверять повторно при импорте.
+
+var ErrUnsupportedEmbed = errors.New("url is not from an allowed embed provider")
+
+const maxEmbedURLLen = 2000
+
+type Embed struct {
+	Provider string `json:"provider"`
+	// URL - адрес для iframe
+	URL string `json:"url"`
+}
+
+type provider struct {
+	name  string
+	hosts []string
+	// src возвращает адрес плеера или false, если ссылка не на встраиваемую страницу
+	src func(u *url.URL) (string, bool)
+}
+
+var (
+	youtubeID   = regexp.MustCompile(`^[A-Za-z0-9_-]{11}$`)
+	vimeoPath   = regexp.MustCompile(`^/(?:video/)?(\d+)(?:/([0-9a-f]+))?/?$`)
+	docsPath    = regexp.MustCompile(`^/(document|presentation|spreadsheets)/d/([A-Za-z0-9_-]+)(?:/.*)?$`)
+	formsPath   = regexp.MustCompile(`^/forms/d/e/([A-Za-z0-9_-]+)(?:/.*)?$`)
+	figmaPath   = regexp.MustCompile(`^/(?:file|design|proto|board)/[A-Za-z0-9]+(?:/.*)?$`)
+	codepenPath = regexp.MustCompile(`^/([A-Za-z0-9_-]+)/(?:pen|embed)/([A-Za-z0-9]+)/?$`)
+	loomPath    = regexp.MustCompile(`^/(?:share|embed)/([0-9a-f]{32})/?$`)
+)
+
+var providers = []provider{
+	{
+		name:  "youtube",
+		hosts: []string{"youtube.com", "www.youtube.com", "m.youtube.com", "youtu.be", "www.youtube-nocookie.com"},
+		src: func(u *url.URL) (string, bool) {
+			var id string
+			switch {
+			case u.Host == "youtu.be":
+				id = strings.Trim(u.Path, "/")
+			case u.Path == "/watch":
+				id = u.Query().Get("v")
+			case strings.HasPrefix(u.Path, "/embed/"), strings.HasPrefix(u.Path, "/shorts/"):
+				_, id, _ = strings.Cut(strings.Trim(u.Path, "/"), "/")
+			}
+			if !youtubeID.MatchString(id) {
+				return "", false
+			}
+			return "https://www.youtube-nocookie.com/embed/" + id, true
+		},
+	},
+	{
+		name:  "vimeo",
+		hosts: []string{"vimeo.com", "www.vimeo.com", "player.vimeo.com"},
+		src: func(u *url.URL) (string, bool) {
+			m := vimeoPath.FindStringSubmatch(u.Path)
+			if m == nil {
+				return "", false
+			}
+			// hash - ключ доступа к видео, открытому только по ссылке
+			hash := m[2]
+			if hash == "" {
+				hash = u.Query().Get("h")
+			}
+			src := "https://player.vimeo.com/video/" + m[1]
+			if hash != "" {
+				src += "?h=" + url.QueryEscape(hash)
+			}
+			return src, true
+		},
+	},
+	{
+		name:  "google_docs",
+		hosts: []string{"docs.google.com"},
+		src: func(u *url.URL) (string, bool) {
+			if m := docsPath.FindStringSubmatch(u.Path); m != nil {
+				return "https://docs.google.com/" + m[1] + "/d/" + m[2] + "/preview", true
+			}
+			if m := formsPath.FindStringSubmatch(u.Path); m != nil {
+				return "https://docs.google.com/forms/d/e/" + m[1] + "/viewform?embedded=true", true
+			}
+			return "", false
+		},
+	},
+	{
+		name:  "figma",
+		hosts: []string{"figma.com", "www.figma.com"},
+		src: func(u *url.URL) (string, bool) {
+			target := u
+			if u.Path == "/embed" {
+				inner, err := url.Parse(u.Query().Get("url"))
+				if err != nil || inner.Scheme != "https" || (inner.Host != "figma.com" && inner.Host != "www.figma.com") {
+					return "", false
+				}
+				target = inner
+			}
+			if !figmaPath.MatchString(target.Path) {
+				return "", false
+			}
+			target = &url.URL{Scheme: "https", Host: "www.figma.com", Path: target.Path, RawQuery: target.RawQuery}
+			return "https://www.figma.com/embed?embed_host=share&url=" + url.QueryEscape(target.String()), true
+		},
+	},
+	{
+		name:  "codepen",
+		hosts: []string{"codepen.io"},
+		src: func(u *url.URL) (string, bool) {
+			m := codepenPath.FindStringSubmatch(u.Path)
+			if m == nil {
+				return "", false
+			}
+			return "https://codepen.io/" + m[1] + "/embed/" + m[2] + "?default-tab=result", true
+		},
+	},
+	{
+		name:  "loom",
+		hosts: []string{"loom.com", "www.loom.com"},
+		src: func(u *url.URL) (string, bool) {
+			m := loomPath.FindStringSubmatch(u.Path)
+			if m == nil {
+				return "", false
+			}
+			return "https://www.loom.com/embed/" + m[1], true
+		},
+	},
+}
+
+// EmbedProviders - имена сервисов, которые можно встраивать
+func EmbedProviders() []string {
+	names := make([]string, 0, len(providers))
+	for _, p := range providers {
+		names = append(names, p.name)
+	}
+	return names
+}
+
+// ResolveEmbed переводит ссылку на страницу сервиса в адрес для iframe. Принимаются
+// только https-ссылки на сервисы из списка, иначе ErrUnsupportedEmbed.
+func ResolveEmbed(rawURL string) (Embed, error) {
+	rawURL = strings.TrimSpace(rawURL)
+	if rawURL == "" || len(rawURL) > maxEmbedURLLen {
+		return Embed{}, ErrUnsupportedEmbed
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Scheme != "https" || u.User != nil || u.Port() != "" {
+		return Embed{}, ErrUnsupportedEmbed
+	}
+	host := strings.ToLower(u.Hostname())
+	for _, p := range providers {
+		if !slices.Contains(p.hosts, host) {
+			continue
+		}
+		u.Host = host
+		src, ok := p.src(u)
+		if !ok {
+			return Embed{}, ErrUnsupportedEmbed
+		}
+		return Embed{Provider: p.name, URL: src}, nil
+	}
+	return Embed{}, ErrUnsupportedEmbed
+}
@@ -0,0 +1,65 @@
+package media
+
+import (
+	"mime"
+	"strings"
+)
+
+// Типы файлов, которые можно показать картинкой или выложить вложением. Тип файла
+// задаёт загрузивший его клиент, поэтому SVG и HTML, которые браузер может исполнить,
+// не принимаются ни там, ни там.
+
+// imageTypes - растровые форматы, которые показывают все браузеры
+var imageTypes = map[string]bool{
+	"image/png":  true,
+	"image/jpeg": true,
+	"image/gif":  true,
+	"image/webp": true,
+	"image/avif": true,
+}
+
+var attachmentTypes = map[string]bool{
+	"application/pdf":  true,
+	"application/zip":  true,
+	"application/json": true,
+	"application/rtf":  true,
+	"text/plain":       true,
+	"text/csv":         true,
+	"text/markdown":    true,
+}
+
+// attachmentPrefixes - семейства офисных форматов
+var attachmentPrefixes = []string{
+	"application/msword",
+	"application/vnd.ms-",
+	"application/vnd.openxmlformats-officedocument.",
+	"application/vnd.oasis.opendocument.",
+	"audio/",
+}
+
+// IsImage сообщает, можно ли показать файл с этим типом в блоке картинки
+func IsImage(contentType string) bool {
+	return imageTypes[baseType(contentType)]
+}
+
+// IsAttachment сообщает, можно ли выложить файл с этим типом вложением. Картинки тоже можно.
+func IsAttachment(contentType string) bool {
+	t := baseType(contentType)
+	if imageTypes[t] || attachmentTypes[t] {
+		return true
+	}
+	for _, p := range attachmentPrefixes {
+		if strings.HasPrefix(t, p) {
+			return true
+		}
+	}
+	return false
+}
+
+func baseType(contentType string) string {
+	t, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return ""
+	}
+	return t
+}
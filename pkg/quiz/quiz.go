@@ -0,0 +1,403 @@
+package quiz
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Тест проверяется автоматически: балл за вопрос начисляется только за полностью
+// верный ответ, результат попытки - процент набранных баллов. Тест хранится в JSON
+// блоков, версий курса и архивов, поэтому теги полей менять нельзя.
+
+type QuestionType string
+
+const (
+	SingleChoice   QuestionType = "single_choice"
+	MultipleChoice QuestionType = "multiple_choice"
+	Numeric        QuestionType = "numeric"
+	ShortText      QuestionType = "short_text"
+)
+
+const (
+	MaxQuestions = 200
+	MaxOptions   = 20
+	MaxAnswers   = 20
+
+	maxIDLen   = 64
+	maxTextLen = 10000
+)
+
+var ErrInvalidAnswer = errors.New("invalid quiz answer")
+
+type Quiz struct {
+	// PassingScore - процент баллов, с которым тест сдан
+	PassingScore float64 `json:"passing_score"`
+	// MaxAttempts - nil без ограничения
+	MaxAttempts *int       `json:"max_attempts,omitempty"`
+	Questions   []Question `json:"questions"`
+}
+
+type Question struct {
+	ID     string       `json:"id"`
+	Type   QuestionType `json:"type"`
+	Text   string       `json:"text"`
+	Points float64      `json:"points"`
+	// Options - варианты вопросов с выбором
+	Options []Option `json:"options,omitempty"`
+	// Answer с допуском Tolerance - верный ответ числового вопроса
+	Answer    *float64 `json:"answer,omitempty"`
+	Tolerance float64  `json:"tolerance,omitempty"`
+	// Answers - верные ответы текстового вопроса, сравниваются без учёта лишних пробелов
+	Answers       []string `json:"answers,omitempty"`
+	CaseSensitive bool     `json:"case_sensitive,omitempty"`
+
+	CorrectFeedback   string `json:"correct_feedback,omitempty"`
+	IncorrectFeedback string `json:"incorrect_feedback,omitempty"`
+}
+
+type Option struct {
+	ID      string `json:"id"`
+	Text    string `json:"text"`
+	Correct bool   `json:"correct,omitempty"`
+	// Feedback показывается участнику, выбравшему вариант
+	Feedback string `json:"feedback,omitempty"`
+}
+
+// Answer - ответ участника на вопрос: варианты, число или текст по типу вопроса
+type Answer struct {
+	QuestionID string   `json:"question_id"`
+	OptionIDs  []string `json:"option_ids,omitempty"`
+	Number     *float64 `json:"number,omitempty"`
+	Text       string   `json:"text,omitempty"`
+}
+
+type Result struct {
+	// Score - процент набранных баллов
+	Score     float64          `json:"score"`
+	Points    float64          `json:"points"`
+	MaxPoints float64          `json:"max_points"`
+	Passed    bool             `json:"passed"`
+	Questions []QuestionResult `json:"questions"`
+}
+
+type QuestionResult struct {
+	QuestionID string   `json:"question_id"`
+	Answered   bool     `json:"answered"`
+	Correct    bool     `json:"correct"`
+	Points     float64  `json:"points"`
+	MaxPoints  float64  `json:"max_points"`
+	Feedback   []string `json:"feedback,omitempty"`
+}
+
+// Problem - ошибка в тесте, Path указывает на поле
+type Problem struct {
+	Path    string
+	Message string
+}
+
+// Normalize убирает лишние пробелы, задаёт идентификаторы вопросам и вариантам без них
+// и один балл вопросам без баллов. Вызывается перед Validate при сохранении теста редактором.
+func (q *Quiz) Normalize() {
+	questionIDs := make(map[string]bool, len(q.Questions))
+	for _, qs := range q.Questions {
+		questionIDs[strings.TrimSpace(qs.ID)] = true
+	}
+	for i := range q.Questions {
+		qs := &q.Questions[i]
+		qs.ID = strings.TrimSpace(qs.ID)
+		if qs.ID == "" {
+			qs.ID = freeID("q", questionIDs)
+		}
+		qs.Text = strings.TrimSpace(qs.Text)
+		if qs.Points == 0 {
+			qs.Points = 1
+		}
+
+		optionIDs := make(map[string]bool, len(qs.Options))
+		for _, o := range qs.Options {
+			optionIDs[strings.TrimSpace(o.ID)] = true
+		}
+		for j := range qs.Options {
+			o := &qs.Options[j]
+			o.ID = strings.TrimSpace(o.ID)
+			if o.ID == "" {
+				o.ID = freeID("o", optionIDs)
+			}
+			o.Text = strings.TrimSpace(o.Text)
+		}
+
+		answers := qs.Answers[:0]
+		for _, a := range qs.Answers {
+			if a = normalizeText(a); a != "" {
+				answers = append(answers, a)
+			}
+		}
+		qs.Answers = answers
+	}
+}
+
+// freeID подбирает свободный идентификатор вида q1, q2
+func freeID(prefix string, taken map[string]bool) string {
+	for i := 1; ; i++ {
+		id := prefix + strconv.Itoa(i)
+		if !taken[id] {
+			taken[id] = true
+			return id
+		}
+	}
+}
+
+// Validate возвращает все найденные ошибки теста
+func (q *Quiz) Validate() []Problem {
+	v := &validator{}
+	if !finite(q.PassingScore) || q.PassingScore < 0 || q.PassingScore > 100 {
+		v.addf("passing_score", "must be between 0 and 100")
+	}
+	if q.MaxAttempts != nil && *q.MaxAttempts < 1 {
+		v.addf("max_attempts", "must be positive")
+	}
+	switch {
+	case len(q.Questions) == 0:
+		v.addf("questions", "at least one question is required")
+	case len(q.Questions) > MaxQuestions:
+		v.addf("questions", "at most %d questions are allowed", MaxQuestions)
+	}
+
+	ids := make(map[string]bool, len(q.Questions))
+	for i, qs := range q.Questions {
+		path := fmt.Sprintf("questions[%d]", i)
+		v.checkID(path+".id", qs.ID, ids)
+		v.checkText(path+".text", qs.Text)
+		if !finite(qs.Points) || qs.Points <= 0 {
+			v.addf(path+".points", "must be positive")
+		}
+		v.checkFeedback(path+".correct_feedback", qs.CorrectFeedback)
+		v.checkFeedback(path+".incorrect_feedback", qs.IncorrectFeedback)
+
+		switch qs.Type {
+		case SingleChoice, MultipleChoice:
+			v.checkOptions(path, qs)
+		case Numeric:
+			if qs.Answer == nil || !finite(*qs.Answer) {
+				v.addf(path+".answer", "is required for numeric questions")
+			}
+			if !finite(qs.Tolerance) || qs.Tolerance < 0 {
+				v.addf(path+".tolerance", "must not be negative")
+			}
+		case ShortText:
+			switch {
+			case len(qs.Answers) == 0:
+				v.addf(path+".answers", "at least one answer is required for short text questions")
+			case len(qs.Answers) > MaxAnswers:
+				v.addf(path+".answers", "at most %d answers are allowed", MaxAnswers)
+			}
+			for j, a := range qs.Answers {
+				if normalizeText(a) == "" {
+					v.addf(fmt.Sprintf("%s.answers[%d]", path, j), "must not be empty")
+				} else if len(a) > maxTextLen {
+					v.addf(fmt.Sprintf("%s.answers[%d]", path, j), "is longer than %d bytes", maxTextLen)
+				}
+			}
+		default:
+			v.addf(path+".type", "unknown question type %q", qs.Type)
+		}
+	}
+	return v.problems
+}
+
+type validator struct {
+	problems []Problem
+}
+
+func (v *validator) addf(path, format string, args ...any) {
+	v.problems = append(v.problems, Problem{Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+func (v *validator) checkID(path, id string, taken map[string]bool) {
+	switch {
+	case id == "":
+		v.addf(path, "must not be empty")
+	case len(id) > maxIDLen:
+		v.addf(path, "is longer than %d bytes", maxIDLen)
+	case taken[id]:
+		v.addf(path, "duplicate id %q", id)
+	}
+	taken[id] = true
+}
+
+func (v *validator) checkText(path, text string) {
+	switch {
+	case strings.TrimSpace(text) == "":
+		v.addf(path, "must not be empty")
+	case len(text) > maxTextLen:
+		v.addf(path, "is longer than %d bytes", maxTextLen)
+	}
+}
+
+func (v *validator) checkFeedback(path, text string) {
+	if len(text) > maxTextLen {
+		v.addf(path, "is longer than %d bytes", maxTextLen)
+	}
+}
+
+func (v *validator) checkOptions(path string, qs Question) {
+	switch {
+	case len(qs.Options) < 2:
+		v.addf(path+".options", "at least two options are required")
+	case len(qs.Options) > MaxOptions:
+		v.addf(path+".options", "at most %d options are allowed", MaxOptions)
+	}
+	ids := make(map[string]bool, len(qs.Options))
+	correct := 0
+	for j, o := range qs.Options {
+		optionPath := fmt.Sprintf("%s.options[%d]", path, j)
+		v.checkID(optionPath+".id", o.ID, ids)
+		v.checkText(optionPath+".text", o.Text)
+		v.checkFeedback(optionPath+".feedback", o.Feedback)
+		if o.Correct {
+			correct++
+		}
+	}
+	if qs.Type == SingleChoice && correct != 1 {
+		v.addf(path+".options", "exactly one option must be correct")
+	}
+	if qs.Type == MultipleChoice && correct == 0 {
+		v.addf(path+".options", "at least one option must be correct")
+	}
+}
+
+// WithoutAnswers возвращает тест для участника: без верных ответов и отзывов
+func (q Quiz) WithoutAnswers() Quiz {
+	res := Quiz{PassingScore: q.PassingScore, MaxAttempts: q.MaxAttempts, Questions: make([]Question, 0, len(q.Questions))}
+	for _, qs := range q.Questions {
+		question := Question{ID: qs.ID, Type: qs.Type, Text: qs.Text, Points: qs.Points}
+		for _, o := range qs.Options {
+			question.Options = append(question.Options, Option{ID: o.ID, Text: o.Text})
+		}
+		res.Questions = append(res.Questions, question)
+	}
+	return res
+}
+
+// Grade проверяет ответы участника. Вопрос без ответа считается неверным.
+// Ответ на неизвестный вопрос, повторный ответ и ответ не того типа - ErrInvalidAnswer.
+func (q Quiz) Grade(answers []Answer) (Result, error) {
+	byQuestion := make(map[string]Answer, len(answers))
+	for _, a := range answers {
+		if _, ok := byQuestion[a.QuestionID]; ok {
+			return Result{}, fmt.Errorf("%w: duplicate answer to question %q", ErrInvalidAnswer, a.QuestionID)
+		}
+		byQuestion[a.QuestionID] = a
+	}
+
+	res := Result{Questions: make([]QuestionResult, 0, len(q.Questions))}
+	for _, qs := range q.Questions {
+		a, answered := byQuestion[qs.ID]
+		delete(byQuestion, qs.ID)
+
+		qr := QuestionResult{QuestionID: qs.ID, Answered: answered, MaxPoints: qs.Points}
+		if answered {
+			correct, feedback, err := qs.check(a)
+			if err != nil {
+				return Result{}, err
+			}
+			qr.Correct = correct
+			qr.Feedback = feedback
+		}
+		if qr.Correct {
+			qr.Points = qs.Points
+			if qs.CorrectFeedback != "" {
+				qr.Feedback = append(qr.Feedback, qs.CorrectFeedback)
+			}
+		} else if qs.IncorrectFeedback != "" {
+			qr.Feedback = append(qr.Feedback, qs.IncorrectFeedback)
+		}
+		res.Points += qr.Points
+		res.MaxPoints += qr.MaxPoints
+		res.Questions = append(res.Questions, qr)
+	}
+	for id := range byQuestion {
+		return Result{}, fmt.Errorf("%w: unknown question %q", ErrInvalidAnswer, id)
+	}
+
+	res.Score = 100
+	if res.MaxPoints > 0 {
+		// округление до сотых, чтобы 2 из 3 при пороге 66.67 давали сдачу
+		res.Score = math.Round(res.Points/res.MaxPoints*10000) / 100
+	}
+	res.Passed = res.Score >= q.PassingScore
+	return res, nil
+}
+
+// check возвращает, верен ли ответ, и отзывы выбранных вариантов
+func (qs Question) check(a Answer) (bool, []string, error) {
+	switch qs.Type {
+	case SingleChoice, MultipleChoice:
+		if a.Number != nil || a.Text != "" {
+			return false, nil, fmt.Errorf("%w: question %q expects option ids", ErrInvalidAnswer, qs.ID)
+		}
+		if qs.Type == SingleChoice && len(a.OptionIDs) > 1 {
+			return false, nil, fmt.Errorf("%w: question %q allows a single option", ErrInvalidAnswer, qs.ID)
+		}
+		chosen := make(map[string]bool, len(a.OptionIDs))
+		for _, id := range a.OptionIDs {
+			chosen[id] = true
+		}
+		correct := true
+		var feedback []string
+		for _, o := range qs.Options {
+			if chosen[o.ID] {
+				delete(chosen, o.ID)
+				if o.Feedback != "" {
+					feedback = append(feedback, o.Feedback)
+				}
+				correct = correct && o.Correct
+				continue
+			}
+			correct = correct && !o.Correct
+		}
+		if len(chosen) > 0 {
+			return false, nil, fmt.Errorf("%w: unknown option of question %q", ErrInvalidAnswer, qs.ID)
+		}
+		return correct && len(a.OptionIDs) > 0, feedback, nil
+
+	case Numeric:
+		if len(a.OptionIDs) > 0 || a.Text != "" || (a.Number != nil && !finite(*a.Number)) {
+			return false, nil, fmt.Errorf("%w: question %q expects a number", ErrInvalidAnswer, qs.ID)
+		}
+		if a.Number == nil || qs.Answer == nil {
+			return false, nil, nil
+		}
+		// допуск на погрешность представления дробей вроде 0.1 + 0.2
+		return math.Abs(*a.Number-*qs.Answer) <= qs.Tolerance+1e-9, nil, nil
+
+	case ShortText:
+		if len(a.OptionIDs) > 0 || a.Number != nil || len(a.Text) > maxTextLen {
+			return false, nil, fmt.Errorf("%w: question %q expects a short text", ErrInvalidAnswer, qs.ID)
+		}
+		text := normalizeText(a.Text)
+		if text == "" {
+			return false, nil, nil
+		}
+		for _, expected := range qs.Answers {
+			expected = normalizeText(expected)
+			if text == expected || (!qs.CaseSensitive && strings.EqualFold(text, expected)) {
+				return true, nil, nil
+			}
+		}
+		return false, nil, nil
+	}
+	return false, nil, fmt.Errorf("unknown question type %q", qs.Type)
+}
+
+// normalizeText схлопывает пробелы, чтобы "  Paris " совпадал с "Paris"
+func normalizeText(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+func finite(f float64) bool {
+	return !math.IsNaN(f) && !math.IsInf(f, 0)
+}
@@ -0,0 +1,224 @@
+package quiz
+
+import (
+	"errors"
+	"math"
+	"slices"
+	"testing"
+)
+
+func ptr[T any](v T) *T { return &v }
+
+func choiceQuestion(t QuestionType, correct ...string) Question {
+	q := Question{ID: "q", Type: t, Text: "?", Points: 1}
+	for _, id := range []string{"a", "b", "c"} {
+		q.Options = append(q.Options, Option{ID: id, Text: id, Correct: slices.Contains(correct, id)})
+	}
+	return q
+}
+
+func TestCheck(t *testing.T) {
+	numeric := Question{ID: "q", Type: Numeric, Text: "?", Points: 1, Answer: ptr(0.3)}
+	withTolerance := numeric
+	withTolerance.Answer, withTolerance.Tolerance = ptr(10.0), 0.5
+	text := Question{ID: "q", Type: ShortText, Text: "?", Points: 1, Answers: []string{"New  York"}}
+	caseSensitive := text
+	caseSensitive.CaseSensitive = true
+
+	tests := []struct {
+		name     string
+		question Question
+		answer   Answer
+		correct  bool
+		invalid  bool
+	}{
+		{"single correct", choiceQuestion(SingleChoice, "b"), Answer{OptionIDs: []string{"b"}}, true, false},
+		{"single wrong", choiceQuestion(SingleChoice, "b"), Answer{OptionIDs: []string{"a"}}, false, false},
+		{"single empty", choiceQuestion(SingleChoice, "b"), Answer{}, false, false},
+		{"single two options", choiceQuestion(SingleChoice, "b"), Answer{OptionIDs: []string{"a", "b"}}, false, true},
+		{"single unknown option", choiceQuestion(SingleChoice, "b"), Answer{OptionIDs: []string{"x"}}, false, true},
+		{"single number", choiceQuestion(SingleChoice, "b"), Answer{Number: ptr(1.0)}, false, true},
+		{"multiple all correct", choiceQuestion(MultipleChoice, "a", "c"), Answer{OptionIDs: []string{"c", "a"}}, true, false},
+		{"multiple partial", choiceQuestion(MultipleChoice, "a", "c"), Answer{OptionIDs: []string{"a"}}, false, false},
+		{"multiple extra", choiceQuestion(MultipleChoice, "a", "c"), Answer{OptionIDs: []string{"a", "b", "c"}}, false, false},
+		{"multiple empty", choiceQuestion(MultipleChoice, "a", "c"), Answer{}, false, false},
+		{"numeric float sum", numeric, Answer{Number: ptr(0.1 + 0.2)}, true, false},
+		{"numeric wrong", numeric, Answer{Number: ptr(0.31)}, false, false},
+		{"numeric empty", numeric, Answer{}, false, false},
+		{"numeric text", numeric, Answer{Text: "0.3"}, false, true},
+		{"numeric nan", numeric, Answer{Number: ptr(math.NaN())}, false, true},
+		{"numeric inf", numeric, Answer{Number: ptr(math.Inf(1))}, false, true},
+		{"tolerance below", withTolerance, Answer{Number: ptr(9.5)}, true, false},
+		{"tolerance above", withTolerance, Answer{Number: ptr(10.5)}, true, false},
+		{"tolerance outside", withTolerance, Answer{Number: ptr(10.51)}, false, false},
+		{"text spaces", text, Answer{Text: "  New York "}, true, false},
+		{"text case", text, Answer{Text: "new york"}, true, false},
+		{"text case sensitive", caseSensitive, Answer{Text: "new york"}, false, false},
+		{"text case sensitive exact", caseSensitive, Answer{Text: "New York"}, true, false},
+		{"text wrong", text, Answer{Text: "York"}, false, false},
+		{"text blank", text, Answer{Text: "   "}, false, false},
+		{"text options", text, Answer{OptionIDs: []string{"a"}}, false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			correct, _, err := tt.question.check(tt.answer)
+			if tt.invalid {
+				if !errors.Is(err, ErrInvalidAnswer) {
+					t.Fatalf("err = %v, want ErrInvalidAnswer", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if correct != tt.correct {
+				t.Fatalf("correct = %v, want %v", correct, tt.correct)
+			}
+		})
+	}
+}
+
+func TestGrade(t *testing.T) {
+	quiz := Quiz{
+		PassingScore: 66.67,
+		Questions: []Question{
+			choiceQuestion(SingleChoice, "a"),
+			{ID: "n", Type: Numeric, Text: "?", Points: 1, Answer: ptr(2.0)},
+			{ID: "t", Type: ShortText, Text: "?", Points: 1, Answers: []string{"yes"}},
+		},
+	}
+	weighted := Quiz{
+		PassingScore: 50,
+		Questions: []Question{
+			choiceQuestion(SingleChoice, "a"),
+			{ID: "n", Type: Numeric, Text: "?", Points: 3, Answer: ptr(2.0)},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		quiz    Quiz
+		answers []Answer
+		score   float64
+		points  float64
+		passed  bool
+	}{
+		{
+			name:    "all correct",
+			quiz:    quiz,
+			answers: []Answer{{QuestionID: "q", OptionIDs: []string{"a"}}, {QuestionID: "n", Number: ptr(2.0)}, {QuestionID: "t", Text: "Yes"}},
+			score:   100, points: 3, passed: true,
+		},
+		{
+			// 2/3 = 66.666..., округляется до 66.67 и проходит порог 66.67
+			name:    "two of three passes rounded threshold",
+			quiz:    quiz,
+			answers: []Answer{{QuestionID: "q", OptionIDs: []string{"a"}}, {QuestionID: "n", Number: ptr(2.0)}},
+			score:   66.67, points: 2, passed: true,
+		},
+		{
+			name:    "one of three",
+			quiz:    quiz,
+			answers: []Answer{{QuestionID: "t", Text: "yes"}},
+			score:   33.33, points: 1, passed: false,
+		},
+		{
+			name:  "no answers",
+			quiz:  quiz,
+			score: 0, points: 0, passed: false,
+		},
+		{
+			name:    "weighted",
+			quiz:    weighted,
+			answers: []Answer{{QuestionID: "n", Number: ptr(2.0)}},
+			score:   75, points: 3, passed: true,
+		},
+		{
+			name:    "weighted light question only",
+			quiz:    weighted,
+			answers: []Answer{{QuestionID: "q", OptionIDs: []string{"a"}}},
+			score:   25, points: 1, passed: false,
+		},
+		{
+			name:  "no questions",
+			quiz:  Quiz{PassingScore: 100},
+			score: 100, points: 0, passed: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := tt.quiz.Grade(tt.answers)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if res.Score != tt.score || res.Points != tt.points || res.Passed != tt.passed {
+				t.Fatalf("score %v, points %v, passed %v; want %v, %v, %v",
+					res.Score, res.Points, res.Passed, tt.score, tt.points, tt.passed)
+			}
+			if len(res.Questions) != len(tt.quiz.Questions) {
+				t.Fatalf("%d question results, want %d", len(res.Questions), len(tt.quiz.Questions))
+			}
+		})
+	}
+}
+
+func TestGradeInvalid(t *testing.T) {
+	quiz := Quiz{Questions: []Question{choiceQuestion(SingleChoice, "a")}}
+	tests := []struct {
+		name    string
+		answers []Answer
+	}{
+		{"duplicate answer", []Answer{{QuestionID: "q", OptionIDs: []string{"a"}}, {QuestionID: "q", OptionIDs: []string{"b"}}}},
+		{"unknown question", []Answer{{QuestionID: "x", OptionIDs: []string{"a"}}}},
+		{"wrong answer type", []Answer{{QuestionID: "q", Text: "a"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := quiz.Grade(tt.answers); !errors.Is(err, ErrInvalidAnswer) {
+				t.Fatalf("err = %v, want ErrInvalidAnswer", err)
+			}
+		})
+	}
+}
+
+func TestGradeFeedback(t *testing.T) {
+	q := choiceQuestion(SingleChoice, "a")
+	q.Options[1].Feedback = "b is close"
+	q.CorrectFeedback = "right"
+	q.IncorrectFeedback = "wrong"
+	quiz := Quiz{Questions: []Question{q}}
+
+	res, err := quiz.Grade([]Answer{{QuestionID: "q", OptionIDs: []string{"b"}}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := []string{"b is close", "wrong"}; !slices.Equal(res.Questions[0].Feedback, want) {
+		t.Fatalf("feedback %q, want %q", res.Questions[0].Feedback, want)
+	}
+
+	res, err = quiz.Grade([]Answer{{QuestionID: "q", OptionIDs: []string{"a"}}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := []string{"right"}; !slices.Equal(res.Questions[0].Feedback, want) {
+		t.Fatalf("feedback %q, want %q", res.Questions[0].Feedback, want)
+	}
+}
+
+func TestWithoutAnswers(t *testing.T) {
+	q := choiceQuestion(SingleChoice, "a")
+	q.Options[0].Feedback = "yes"
+	q.CorrectFeedback = "right"
+	quiz := Quiz{Questions: []Question{q, {ID: "n", Type: Numeric, Text: "?", Points: 1, Answer: ptr(1.0), Tolerance: 0.1}}}
+
+	for _, qs := range quiz.WithoutAnswers().Questions {
+		if qs.Answer != nil || qs.Tolerance != 0 || len(qs.Answers) != 0 || qs.CorrectFeedback != "" {
+			t.Fatalf("question %q leaks answers: %+v", qs.ID, qs)
+		}
+		for _, o := range qs.Options {
+			if o.Correct || o.Feedback != "" {
+				t.Fatalf("option %q leaks answers: %+v", o.ID, o)
+			}
+		}
+	}
+}
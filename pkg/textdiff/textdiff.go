@@ -0,0 +1,97 @@
+package textdiff
+
+import "strings"
+
+type Op string
+
+const (
+	OpEqual  Op = "equal"
+	OpInsert Op = "insert"
+	OpDelete Op = "delete"
+)
+
+// Line - строка результата сравнения. Для OpDelete это строка старого текста, для OpInsert - нового.
+type Line struct {
+	Op   Op
+	Text string
+}
+
+// Lines сравнивает тексты построчно алгоритмом Майерса и возвращает кратчайший список правок
+func Lines(from, to string) []Line {
+	a, b := splitLines(from), splitLines(to)
+	n, m := len(a), len(b)
+	max := n + m
+	if max == 0 {
+		return nil
+	}
+
+	// v[k+max] - наибольший x на диагонали k = x - y,
+	// trace[d] - окно v[-d..d] перед шагом d, по нему восстанавливается путь
+	v := make([]int, 2*max+2)
+	var trace [][]int
+	var x, y int
+search:
+	for d := 0; d <= max; d++ {
+		trace = append(trace, append([]int(nil), v[max-d:max+d+1]...))
+		for k := -d; k <= d; k += 2 {
+			if k == -d || (k != d && v[max+k-1] < v[max+k+1]) {
+				x = v[max+k+1]
+			} else {
+				x = v[max+k-1] + 1
+			}
+			y = x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[max+k] = x
+			if x >= n && y >= m {
+				break search
+			}
+		}
+	}
+
+	// восстанавливаем путь с конца
+	lines := make([]Line, 0, max)
+	x, y = n, m
+	for d := len(trace) - 1; d > 0; d-- {
+		prev := trace[d]
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && prev[d+k-1] < prev[d+k+1]) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := prev[d+prevK]
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			x--
+			y--
+			lines = append(lines, Line{Op: OpEqual, Text: a[x]})
+		}
+		if x == prevX {
+			y--
+			lines = append(lines, Line{Op: OpInsert, Text: b[y]})
+		} else {
+			x--
+			lines = append(lines, Line{Op: OpDelete, Text: a[x]})
+		}
+	}
+	for x > 0 {
+		x--
+		lines = append(lines, Line{Op: OpEqual, Text: a[x]})
+	}
+
+	for i, j := 0, len(lines)-1; i < j; i, j = i+1, j-1 {
+		lines[i], lines[j] = lines[j], lines[i]
+	}
+	return lines
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}